@@ -0,0 +1,240 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/razzkumar/imgx/colorspace"
+)
+
+// ColorBlindness is a type of color vision deficiency simulated by
+// SimulateColorBlindness.
+type ColorBlindness int
+
+// Supported color vision deficiencies.
+const (
+	// Protanopia: missing L (red) cones.
+	Protanopia ColorBlindness = iota
+	// Deuteranopia: missing M (green) cones, the most common form.
+	Deuteranopia
+	// Tritanopia: missing S (blue) cones.
+	Tritanopia
+	// Achromatopsia: no color vision at all.
+	Achromatopsia
+)
+
+var colorBlindnessNames = map[ColorBlindness]string{
+	Protanopia:    "protanopia",
+	Deuteranopia:  "deuteranopia",
+	Tritanopia:    "tritanopia",
+	Achromatopsia: "achromatopsia",
+}
+
+func (c ColorBlindness) String() string {
+	if name, ok := colorBlindnessNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseColorBlindness parses a deficiency name such as "deuteranopia".
+func ParseColorBlindness(s string) (ColorBlindness, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for c, name := range colorBlindnessNames {
+		if s == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("imgx: unknown color blindness type %q (use protanopia, deuteranopia, tritanopia or achromatopsia)", s)
+}
+
+// colorBlindnessMatrices are the full-severity simulation matrices of
+// Machado, Oliveira and Fernandes (2009), applied in linear RGB.
+var colorBlindnessMatrices = map[ColorBlindness][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+	Achromatopsia: {
+		{0.2126, 0.7152, 0.0722},
+		{0.2126, 0.7152, 0.0722},
+		{0.2126, 0.7152, 0.0722},
+	},
+}
+
+// srgbToLinear maps 8-bit sRGB values to linear light.
+var srgbToLinear [256]float64
+
+func init() {
+	for i := range srgbToLinear {
+		srgbToLinear[i] = colorspace.ToLinear(float64(i) / 255)
+	}
+}
+
+// linearToSRGB converts a linear light value to an 8-bit sRGB value.
+func linearToSRGB(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return clamp(colorspace.ToSRGB(v) * 255)
+}
+
+// SimulateColorBlindness renders img as seen by a person with the given
+// color vision deficiency. Use it to check that charts, banners and UI
+// screenshots don't rely on color differences alone.
+//
+// Example:
+//
+//	seen := imgx.SimulateColorBlindness(banner, imgx.Deuteranopia)
+func SimulateColorBlindness(img image.Image, kind ColorBlindness) *image.NRGBA {
+	m, ok := colorBlindnessMatrices[kind]
+	if !ok {
+		return Clone(img)
+	}
+
+	return AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		r, g, b := srgbToLinear[c.R], srgbToLinear[c.G], srgbToLinear[c.B]
+		return color.NRGBA{
+			R: linearToSRGB(m[0][0]*r + m[0][1]*g + m[0][2]*b),
+			G: linearToSRGB(m[1][0]*r + m[1][1]*g + m[1][2]*b),
+			B: linearToSRGB(m[2][0]*r + m[2][1]*g + m[2][2]*b),
+			A: c.A,
+		}
+	})
+}
+
+// SimulateColorBlindness renders the image as seen with a color vision deficiency
+func (img *Image) SimulateColorBlindness(kind ColorBlindness) *Image {
+	newData := SimulateColorBlindness(img.data, kind)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("simulateColorBlindness", fmt.Sprintf("type=%s", kind))
+	return newImage(newData, newMeta)
+}
+
+// RelativeLuminance returns the WCAG 2.x relative luminance of c (0-1).
+func RelativeLuminance(c color.Color) float64 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return 0.2126*srgbToLinear[n.R] + 0.7152*srgbToLinear[n.G] + 0.0722*srgbToLinear[n.B]
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1
+// (identical) to 21 (black on white).
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// ContrastReport is the contrast analysis of a text region.
+type ContrastReport struct {
+	Region     image.Rectangle `json:"region"`
+	Foreground color.NRGBA     `json:"foreground"` // average text color
+	Background color.NRGBA     `json:"background"` // average background color
+	Ratio      float64         `json:"ratio"`
+	AA         bool            `json:"aa"`       // >= 4.5:1, normal text
+	AALarge    bool            `json:"aa_large"` // >= 3:1, large or bold text
+	AAA        bool            `json:"aaa"`      // >= 7:1, normal text
+}
+
+// CheckContrast measures the contrast between text and background in each
+// region (for example boxes returned by OCR). Pixels of a region are split
+// into two groups by luminance (Otsu's method); the larger group is taken
+// as background and the smaller one as text. Regions are relative to the
+// top-left corner of the image; they are clipped to the image and empty
+// regions are skipped.
+//
+// Example:
+//
+//	for _, r := range imgx.CheckContrast(banner, boxes) {
+//		if !r.AA {
+//			fmt.Printf("%v: contrast %.1f:1 fails WCAG AA\n", r.Region, r.Ratio)
+//		}
+//	}
+func CheckContrast(img image.Image, regions []image.Rectangle) []ContrastReport {
+	src := toNRGBA(img)
+	var reports []ContrastReport
+	for _, region := range regions {
+		r := region.Intersect(src.Bounds())
+		if r.Empty() {
+			continue
+		}
+		fg, bg := splitTextColors(src, r)
+		ratio := ContrastRatio(fg, bg)
+		reports = append(reports, ContrastReport{
+			Region:     r,
+			Foreground: fg,
+			Background: bg,
+			Ratio:      ratio,
+			AA:         ratio >= 4.5,
+			AALarge:    ratio >= 3,
+			AAA:        ratio >= 7,
+		})
+	}
+	return reports
+}
+
+// CheckContrast measures the text contrast of each region of the image
+func (img *Image) CheckContrast(regions []image.Rectangle) []ContrastReport {
+	return CheckContrast(img.data, regions)
+}
+
+// splitTextColors returns the mean colors of the minority (text) and
+// majority (background) luminance clusters of region r.
+func splitTextColors(img *image.NRGBA, r image.Rectangle) (fg, bg color.NRGBA) {
+	var hist [256]int
+	lum := func(p []uint8) uint8 {
+		return uint8(luminanceRedWeight*float64(p[0]) + luminanceGreenWeight*float64(p[1]) + luminanceBlueWeight*float64(p[2]) + 0.5)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			hist[lum(img.Pix[img.PixOffset(x, y):])]++
+		}
+	}
+	threshold := otsuThreshold(hist, r.Dx()*r.Dy())
+
+	var sum [2][3]int
+	var count [2]int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := img.Pix[img.PixOffset(x, y):]
+			k := 0
+			if lum(p) > threshold {
+				k = 1
+			}
+			sum[k][0] += int(p[0])
+			sum[k][1] += int(p[1])
+			sum[k][2] += int(p[2])
+			count[k]++
+		}
+	}
+
+	mean := func(k int) color.NRGBA {
+		if count[k] == 0 {
+			k = 1 - k
+		}
+		n := count[k]
+		return color.NRGBA{uint8(sum[k][0] / n), uint8(sum[k][1] / n), uint8(sum[k][2] / n), 255}
+	}
+	if count[0] <= count[1] {
+		return mean(0), mean(1)
+	}
+	return mean(1), mean(0)
+}
@@ -0,0 +1,100 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b color.Color
+		want float64
+	}{
+		{"black on white", color.Black, color.White, 21},
+		{"same color", color.NRGBA{120, 40, 200, 255}, color.NRGBA{120, 40, 200, 255}, 1},
+		{"gray on white", color.NRGBA{118, 118, 118, 255}, color.White, 4.54},
+		{"order independent", color.White, color.NRGBA{118, 118, 118, 255}, 4.54},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ContrastRatio(tc.a, tc.b); math.Abs(got-tc.want) > 0.01 {
+				t.Errorf("ContrastRatio() = %.3f, want %.2f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckContrast(t *testing.T) {
+	img := New(200, 100, color.White)
+	// Dark text on the left half, light gray text on the right half.
+	draw.Draw(img, image.Rect(10, 40, 90, 50), image.NewUniform(color.NRGBA{20, 20, 20, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(110, 40, 190, 50), image.NewUniform(color.NRGBA{200, 200, 200, 255}), image.Point{}, draw.Src)
+
+	reports := CheckContrast(img, []image.Rectangle{
+		image.Rect(0, 30, 100, 60),
+		image.Rect(100, 30, 200, 60),
+		image.Rect(300, 300, 400, 400), // outside the image
+	})
+	if len(reports) != 2 {
+		t.Fatalf("CheckContrast() returned %d reports, want 2", len(reports))
+	}
+
+	dark, light := reports[0], reports[1]
+	if dark.Foreground != (color.NRGBA{20, 20, 20, 255}) || dark.Background != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("dark text colors = %v on %v", dark.Foreground, dark.Background)
+	}
+	if !dark.AA || !dark.AAA {
+		t.Errorf("dark text ratio %.2f should pass AA and AAA", dark.Ratio)
+	}
+	if light.AALarge {
+		t.Errorf("light text ratio %.2f should fail AA large", light.Ratio)
+	}
+}
+
+func TestSimulateColorBlindness(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	green := color.NRGBA{0, 160, 0, 255}
+	img := New(2, 1, red)
+	img.SetNRGBA(1, 0, green)
+
+	for _, kind := range []ColorBlindness{Protanopia, Deuteranopia} {
+		t.Run(kind.String(), func(t *testing.T) {
+			out := SimulateColorBlindness(img, kind)
+			a, b := out.NRGBAAt(0, 0), out.NRGBAAt(1, 0)
+			// Red and green become hard to tell apart.
+			if d := math.Abs(float64(a.R)-float64(b.R)) + math.Abs(float64(a.G)-float64(b.G)); d > 120 {
+				t.Errorf("%s: red %v and green %v are still far apart", kind, a, b)
+			}
+		})
+	}
+
+	gray := SimulateColorBlindness(img, Achromatopsia)
+	if c := gray.NRGBAAt(0, 0); c.R != c.G || c.G != c.B {
+		t.Errorf("achromatopsia produced a colored pixel %v", c)
+	}
+
+	white := SimulateColorBlindness(New(1, 1, color.White), Tritanopia).NRGBAAt(0, 0)
+	if white.R < 250 || white.G < 250 || white.B < 250 {
+		t.Errorf("tritanopia changed white to %v", white)
+	}
+}
+
+func TestParseColorBlindness(t *testing.T) {
+	for _, name := range []string{"protanopia", "Deuteranopia", " tritanopia ", "achromatopsia"} {
+		kind, err := ParseColorBlindness(name)
+		if err != nil {
+			t.Errorf("ParseColorBlindness(%q) error = %v", name, err)
+			continue
+		}
+		if kind.String() == "unknown" {
+			t.Errorf("ParseColorBlindness(%q) = %v", name, kind)
+		}
+	}
+	if _, err := ParseColorBlindness("colorful"); err == nil {
+		t.Error("ParseColorBlindness(\"colorful\") should fail")
+	}
+}
@@ -0,0 +1,175 @@
+package imgx
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// annotationPalette holds the box colors picked by label; the same label
+// always gets the same color.
+var annotationPalette = []color.NRGBA{
+	{230, 25, 75, 255},
+	{60, 180, 75, 255},
+	{255, 225, 25, 255},
+	{0, 130, 200, 255},
+	{245, 130, 48, 255},
+	{145, 30, 180, 255},
+	{70, 240, 240, 255},
+	{240, 50, 230, 255},
+	{210, 245, 60, 255},
+	{0, 128, 128, 255},
+}
+
+// BoxAnnotation is a labeled rectangle drawn by Annotate.
+type BoxAnnotation struct {
+	// Rect is the box in image coordinates.
+	Rect image.Rectangle
+
+	// Label is drawn in a tab above the box (inside it when the box touches
+	// the top edge). Empty labels draw only the box.
+	Label string
+
+	// Color is the color of the box and label tab.
+	// Default is picked from a palette by label.
+	Color color.Color
+}
+
+// AnnotateOptions configures Annotate.
+type AnnotateOptions struct {
+	// Width is the line width in pixels.
+	// Default is 1/300 of the shorter side, at least 2 pixels.
+	Width int
+
+	// Font is the font face of the labels.
+	// If nil, basicfont.Face7x13 is used.
+	Font font.Face
+}
+
+// Annotate draws labeled boxes on a copy of img, e.g. to inspect object
+// detection results or dataset annotations.
+//
+// Example:
+//
+//	annotated := imgx.Annotate(img, []imgx.BoxAnnotation{
+//		{Rect: image.Rect(40, 30, 200, 180), Label: "dog 0.93"},
+//	}, imgx.AnnotateOptions{})
+func Annotate(img image.Image, boxes []BoxAnnotation, opts AnnotateOptions) *image.NRGBA {
+	dst := Clone(img)
+	b := dst.Bounds()
+	if b.Empty() {
+		return dst
+	}
+
+	width := opts.Width
+	if width <= 0 {
+		width = max(2, min(b.Dx(), b.Dy())/300)
+	}
+	face := opts.Font
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+	metrics := face.Metrics()
+	ascent, textHeight := metrics.Ascent.Ceil(), metrics.Height.Ceil()
+
+	for _, box := range boxes {
+		r := box.Rect.Canon().Intersect(b)
+		if r.Empty() {
+			continue
+		}
+		c := box.Color
+		if c == nil {
+			c = annotationColor(box.Label)
+		}
+		src := image.NewUniform(c)
+		for _, edge := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width),
+			image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y),
+			image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y),
+		} {
+			draw.Draw(dst, edge.Intersect(r), src, image.Point{}, draw.Over)
+		}
+
+		if box.Label == "" {
+			continue
+		}
+		textWidth := font.MeasureString(face, box.Label).Ceil()
+		tab := image.Rect(r.Min.X, r.Min.Y-textHeight-2, r.Min.X+textWidth+4, r.Min.Y)
+		if tab.Min.Y < b.Min.Y {
+			tab = tab.Add(image.Pt(0, textHeight+2))
+		}
+		draw.Draw(dst, tab.Intersect(b), src, image.Point{}, draw.Over)
+		drawer := &font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(labelTextColor(c)),
+			Face: face,
+			Dot:  fixed.P(tab.Min.X+2, tab.Min.Y+1+ascent),
+		}
+		drawer.DrawString(box.Label)
+	}
+	return dst
+}
+
+// Annotate draws labeled boxes on the image (see Annotate)
+func (img *Image) Annotate(boxes []BoxAnnotation, opts AnnotateOptions) *Image {
+	newData := Annotate(img.data, boxes, opts)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("annotate", fmt.Sprintf("boxes=%d", len(boxes)))
+	return newImage(newData, newMeta)
+}
+
+// CropBoxes crops each rectangle out of img, grown by padding pixels on
+// every side and clamped to the image, e.g. to save the objects found by
+// object detection. Rectangles outside the image yield empty images.
+//
+// Example:
+//
+//	crops := imgx.CropBoxes(img, []image.Rectangle{image.Rect(40, 30, 200, 180)}, 8)
+func CropBoxes(img image.Image, rects []image.Rectangle, padding int) []*image.NRGBA {
+	crops := make([]*image.NRGBA, len(rects))
+	for i, r := range rects {
+		crops[i] = Crop(img, paddedBox(img, r, padding))
+	}
+	return crops
+}
+
+// CropBoxes crops each rectangle out of the image (see CropBoxes)
+func (img *Image) CropBoxes(rects []image.Rectangle, padding int) []*Image {
+	crops := make([]*Image, len(rects))
+	for i, r := range rects {
+		rect := paddedBox(img.data, r, padding)
+		newMeta := img.metadata.Clone()
+		newMeta.AddOperation("crop", fmt.Sprintf("x=%d, y=%d, w=%d, h=%d", rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy()))
+		crops[i] = newImage(Crop(img.data, rect), newMeta)
+	}
+	return crops
+}
+
+// paddedBox grows r by padding pixels and clamps it to the bounds of img
+func paddedBox(img image.Image, r image.Rectangle, padding int) image.Rectangle {
+	return r.Canon().Inset(-padding).Intersect(img.Bounds())
+}
+
+// annotationColor returns the palette color of label
+func annotationColor(label string) color.NRGBA {
+	h := fnv.New32a()
+	h.Write([]byte(label))
+	return annotationPalette[h.Sum32()%uint32(len(annotationPalette))]
+}
+
+// labelTextColor returns black or white, whichever reads better on c
+func labelTextColor(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	lum := luminanceRedWeight*float64(r>>8) + luminanceGreenWeight*float64(g>>8) + luminanceBlueWeight*float64(b>>8)
+	if lum > 140 {
+		return color.Black
+	}
+	return color.White
+}
@@ -0,0 +1,69 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	img := NewImage(100, 80, color.Black)
+	red := color.NRGBA{255, 0, 0, 255}
+	out := img.Annotate([]BoxAnnotation{
+		{Rect: image.Rect(20, 30, 60, 70), Label: "dog", Color: red},
+		{Rect: image.Rect(0, 0, 30, 20), Label: "cat"},
+	}, AnnotateOptions{Width: 2})
+
+	data := out.ToNRGBA()
+	for _, p := range []image.Point{{20, 50}, {59, 50}, {40, 30}, {40, 69}} {
+		if c := data.NRGBAAt(p.X, p.Y); c != red {
+			t.Errorf("pixel %v on the box edge = %v, want red", p, c)
+		}
+	}
+	if c := data.NRGBAAt(40, 50); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("pixel inside the box = %v, want black", c)
+	}
+	if c := data.NRGBAAt(43, 16); c != red {
+		t.Errorf("label tab pixel = %v, want red", c)
+	}
+	// The tab of a box at the top edge is drawn inside the box
+	if c := data.NRGBAAt(1, 10); c != annotationColor("cat") {
+		t.Errorf("inner label tab pixel = %v, want %v", c, annotationColor("cat"))
+	}
+	if c := img.ToNRGBA().NRGBAAt(20, 50); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Error("Annotate modified the source image")
+	}
+
+	ops := out.GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Action != "annotate" {
+		t.Errorf("operations = %+v, want one annotate record", ops)
+	}
+	if annotationColor("person") != annotationColor("person") {
+		t.Error("annotationColor is not stable")
+	}
+}
+
+func TestCropBoxes(t *testing.T) {
+	img := NewImage(100, 80, color.White)
+	crops := img.CropBoxes([]image.Rectangle{
+		image.Rect(20, 30, 60, 70),
+		image.Rect(90, 0, 120, 10), // partly outside
+		image.Rect(200, 200, 210, 210),
+	}, 5)
+	if len(crops) != 3 {
+		t.Fatalf("CropBoxes() returned %d images, want 3", len(crops))
+	}
+	if got := crops[0].Bounds(); got.Dx() != 50 || got.Dy() != 50 {
+		t.Errorf("padded crop = %v, want 50x50", got)
+	}
+	if got := crops[1].Bounds(); got.Dx() != 15 || got.Dy() != 15 {
+		t.Errorf("clamped crop = %v, want 15x15", got)
+	}
+	if !crops[2].Bounds().Empty() {
+		t.Errorf("crop outside the image = %v, want empty", crops[2].Bounds())
+	}
+	ops := crops[0].GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Action != "crop" || ops[0].Parameters != "x=15, y=25, w=50, h=50" {
+		t.Errorf("operations = %+v", ops)
+	}
+}
@@ -0,0 +1,133 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseAspectRatio parses an aspect ratio such as "16:9", "4/5" or "1.91"
+// and returns it as width divided by height.
+func ParseAspectRatio(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	num, den := s, "1"
+	if i := strings.IndexAny(s, ":/"); i >= 0 {
+		num, den = s[:i], s[i+1:]
+	}
+	w, errW := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	h, errH := strconv.ParseFloat(strings.TrimSpace(den), 64)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 || math.IsInf(w/h, 0) || math.IsNaN(w/h) {
+		return 0, fmt.Errorf("imgx: invalid aspect ratio %q (use W:H, e.g. 16:9)", s)
+	}
+	return w / h, nil
+}
+
+// aspectCropSize returns the size of the largest crop of a width x height
+// image that has the given aspect ratio.
+func aspectCropSize(width, height int, ratio float64) (int, int) {
+	if float64(width)/float64(height) > ratio {
+		return max(1, int(math.Round(float64(height)*ratio))), height
+	}
+	return width, max(1, int(math.Round(float64(width)/ratio)))
+}
+
+// CropToAspect cuts out the largest region of the image that has the
+// specified aspect ratio ("16:9", "4:5", "1.91") and returns it. The anchor
+// selects which part is kept; Smart keeps the most detailed part.
+//
+// Example:
+//
+//	// Instagram portrait post.
+//	dst, err := imgx.CropToAspect(src, "4:5", imgx.Smart)
+func CropToAspect(img image.Image, ratio string, anchor Anchor) (*image.NRGBA, error) {
+	r, err := ParseAspectRatio(ratio)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return &image.NRGBA{}, nil
+	}
+	w, h := aspectCropSize(b.Dx(), b.Dy(), r)
+	return CropAnchor(img, w, h, anchor), nil
+}
+
+// CropToAspect cuts out the largest region with the specified aspect ratio
+func (img *Image) CropToAspect(ratio string, anchor Anchor) (*Image, error) {
+	newData, err := CropToAspect(img.data, ratio, anchor)
+	if err != nil {
+		return nil, err
+	}
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("cropToAspect", fmt.Sprintf("ratio=%s, w=%d, h=%d, anchor=%s", ratio, newData.Bounds().Dx(), newData.Bounds().Dy(), formatAnchorName(anchor)))
+	return newImage(newData, newMeta), nil
+}
+
+// smartAnchorPt returns the top-left corner of the w x h window of img
+// with the most edge energy. Energy is accumulated on a coarse grid so
+// large images stay cheap; ties (e.g. flat images) go to the window
+// closest to the center.
+func smartAnchorPt(img image.Image, w, h int) image.Point {
+	b := img.Bounds()
+	center := anchorPt(b, w, h, Center)
+	slackX, slackY := b.Dx()-w, b.Dy()-h
+	if slackX <= 0 && slackY <= 0 {
+		return center
+	}
+
+	src := toNRGBA(img)
+	cell := max(1, max(src.Rect.Dx(), src.Rect.Dy())/256)
+	gw, gh := (src.Rect.Dx()+cell-1)/cell, (src.Rect.Dy()+cell-1)/cell
+
+	lum := func(x, y int) int {
+		p := src.Pix[src.PixOffset(x, y):]
+		return (299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000
+	}
+	// sat is the summed-area table of the per-cell gradient energy.
+	sat := make([]int64, (gw+1)*(gh+1))
+	for y := 0; y < src.Rect.Dy(); y++ {
+		for x := 0; x < src.Rect.Dx(); x++ {
+			l := lum(x, y)
+			var e int
+			if x+1 < src.Rect.Dx() {
+				e += absint(lum(x+1, y) - l)
+			}
+			if y+1 < src.Rect.Dy() {
+				e += absint(lum(x, y+1) - l)
+			}
+			sat[(y/cell+1)*(gw+1)+x/cell+1] += int64(e)
+		}
+	}
+	for cy := 1; cy <= gh; cy++ {
+		for cx := 1; cx <= gw; cx++ {
+			i := cy*(gw+1) + cx
+			sat[i] += sat[i-1] + sat[i-gw-1] - sat[i-gw-2]
+		}
+	}
+
+	ww := min(gw, max(1, int(math.Round(float64(w)/float64(cell)))))
+	wh := min(gh, max(1, int(math.Round(float64(h)/float64(cell)))))
+	centerCX, centerCY := float64(gw-ww)/2, float64(gh-wh)/2
+	bestX, bestY := 0, 0
+	best, bestDist := int64(-1), math.Inf(1)
+	for cy := 0; cy <= gh-wh; cy++ {
+		for cx := 0; cx <= gw-ww; cx++ {
+			e := sat[(cy+wh)*(gw+1)+cx+ww] - sat[cy*(gw+1)+cx+ww] - sat[(cy+wh)*(gw+1)+cx] + sat[cy*(gw+1)+cx]
+			dist := math.Hypot(float64(cx)-centerCX, float64(cy)-centerCY)
+			if e > best || (e == best && dist < bestDist) {
+				best, bestDist, bestX, bestY = e, dist, cx, cy
+			}
+		}
+	}
+
+	pt := center
+	if slackX > 0 {
+		pt.X = b.Min.X + min(bestX*cell, slackX)
+	}
+	if slackY > 0 {
+		pt.Y = b.Min.Y + min(bestY*cell, slackY)
+	}
+	return pt
+}
@@ -0,0 +1,127 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestParseAspectRatio(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"16:9", 16.0 / 9, false},
+		{"4:5", 0.8, false},
+		{" 3 / 2 ", 1.5, false},
+		{"1.91", 1.91, false},
+		{"1.91:1", 1.91, false},
+		{"", 0, true},
+		{"16:0", 0, true},
+		{"-4:5", 0, true},
+		{"wide", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseAspectRatio(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseAspectRatio(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("ParseAspectRatio(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCropToAspect(t *testing.T) {
+	testCases := []struct {
+		name         string
+		srcW, srcH   int
+		ratio        string
+		wantW, wantH int
+	}{
+		{"landscape to portrait", 4000, 3000, "4:5", 2400, 3000},
+		{"landscape to wide", 4000, 3000, "16:9", 4000, 2250},
+		{"portrait to square", 300, 500, "1:1", 300, 300},
+		{"already matching", 160, 90, "16:9", 160, 90},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, tc.srcW, tc.srcH))
+			got, err := CropToAspect(src, tc.ratio, Center)
+			if err != nil {
+				t.Fatalf("CropToAspect() error = %v", err)
+			}
+			if b := got.Bounds(); b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+				t.Errorf("CropToAspect() size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+			}
+		})
+	}
+
+	if _, err := CropToAspect(image.NewNRGBA(image.Rect(0, 0, 10, 10)), "bad", Center); err == nil {
+		t.Error("CropToAspect() with an invalid ratio should fail")
+	}
+}
+
+func TestCropToAspectSmart(t *testing.T) {
+	// A flat image with a checkerboard near the right edge.
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.NRGBA{128, 128, 128, 255}
+			if x >= 220 && x < 280 && (x/4+y/4)%2 == 0 {
+				c = color.NRGBA{255, 255, 255, 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	img := FromImage(src)
+	got, err := img.CropToAspect("1:1", Smart)
+	if err != nil {
+		t.Fatalf("CropToAspect() error = %v", err)
+	}
+	if b := got.Bounds(); b.Dx() != 100 || b.Dy() != 100 {
+		t.Fatalf("CropToAspect() size = %dx%d, want 100x100", b.Dx(), b.Dy())
+	}
+	// The kept window must contain the whole pattern (x=220..280).
+	pt := smartAnchorPt(src, 100, 100)
+	if pt.X < 180 || pt.X > 200 || pt.Y != 0 {
+		t.Fatalf("smartAnchorPt() = %v, want x in [180, 200]", pt)
+	}
+	if want := Crop(src, image.Rect(pt.X, 0, pt.X+100, 100)); !compareNRGBA(got.ToNRGBA(), want, 0) {
+		t.Error("CropToAspect() pixels do not match the smart window")
+	}
+	ops := got.GetMetadata().Operations
+	if len(ops) == 0 || ops[len(ops)-1].Action != "cropToAspect" {
+		t.Errorf("CropToAspect() did not record the operation: %v", ops)
+	}
+
+	// Without detail, Smart falls back to the center.
+	flat := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	if pt := smartAnchorPt(flat, 100, 100); pt != image.Pt(100, 0) {
+		t.Errorf("smartAnchorPt() on a flat image = %v, want (100,0)", pt)
+	}
+}
+
+func FuzzParseAspectRatio(f *testing.F) {
+	for _, s := range []string{"16:9", "4/5", "1.91", "1:0", "0:1", "1e308:1e-308", ":", "NaN"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		ratio, err := ParseAspectRatio(s)
+		if err != nil {
+			return
+		}
+		if !(ratio > 0) || math.IsInf(ratio, 0) {
+			t.Fatalf("ParseAspectRatio(%q) = %v, want a finite positive ratio", s, ratio)
+		}
+		if w, h := aspectCropSize(640, 480, ratio); w < 1 || w > 640 || h < 1 || h > 480 {
+			t.Errorf("aspectCropSize(640, 480, %v) = %dx%d, want a crop of 640x480", ratio, w, h)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// textPage renders a few lines of dark text on a light background.
+func textPage() *image.NRGBA {
+	lines := []string{
+		"The quick brown fox jumps over the lazy dog.",
+		"Pack my box with five dozen liquor jugs.",
+		"Sphinx of black quartz, judge my vow.",
+		"How vexingly quick daft zebras jump!",
+		"The five boxing wizards jump quickly.",
+	}
+	page := image.NewNRGBA(image.Rect(0, 0, 340, 120))
+	draw.Draw(page, page.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	d := &font.Drawer{Dst: page, Src: image.NewUniform(color.Black), Face: basicfont.Face7x13}
+	for i, line := range lines {
+		d.Dot = fixed.P(10, 20+i*20)
+		d.DrawString(line)
+	}
+	// Scale up so strokes are a few pixels thick, as in a real scan.
+	return Resize(page, 680, 0, NearestNeighbor)
+}
+
+func TestDetectContentOrientation(t *testing.T) {
+	page := textPage()
+	testCases := []struct {
+		name  string
+		img   *image.NRGBA
+		angle int
+	}{
+		{"upright", page, 0},
+		{"rotated 90", Rotate90(page), 270},
+		{"rotated 180", Rotate180(page), 180},
+		{"rotated 270", Rotate270(page), 90},
+		{"inverted colors", Invert(page), 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			angle, confidence := DetectContentOrientation(tc.img)
+			if angle != tc.angle {
+				t.Errorf("DetectContentOrientation() angle = %d, want %d", angle, tc.angle)
+			}
+			if confidence <= 0 || confidence > 1 {
+				t.Errorf("DetectContentOrientation() confidence = %v, want (0, 1]", confidence)
+			}
+		})
+	}
+}
+
+func TestDetectContentOrientationBlank(t *testing.T) {
+	blank := New(100, 100, color.White)
+	angle, confidence := DetectContentOrientation(blank)
+	if angle != 0 || confidence != 0 {
+		t.Errorf("DetectContentOrientation(blank) = %d, %v, want 0, 0", angle, confidence)
+	}
+}
+
+func TestImageAutoRotateByContent(t *testing.T) {
+	page := textPage()
+	img := FromImage(Rotate90(page))
+
+	got := img.AutoRotateByContent()
+	if !compareNRGBA(got.ToNRGBA(), page, 0) {
+		t.Error("AutoRotateByContent() did not restore the upright page")
+	}
+
+	ops := got.GetMetadata().Operations
+	if len(ops) == 0 || ops[len(ops)-1].Action != "auto-rotate" {
+		t.Errorf("AutoRotateByContent() did not record the operation: %v", ops)
+	}
+}
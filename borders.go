@@ -0,0 +1,180 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+)
+
+// Border detection thresholds
+const (
+	// borderTolerance is the largest difference of any channel between a
+	// border pixel and the border color
+	borderTolerance = 16
+	// borderOutliers is the fraction of pixels of a border row or column
+	// that may differ from the border color (noise, compression artifacts)
+	borderOutliers = 0.01
+)
+
+// Borders describes a uniform border around the content of an image, such
+// as the letterbox or pillarbox bars of a video still, the margin of a scan
+// or padding added around a product photo.
+type Borders struct {
+	Content image.Rectangle `json:"content"` // Bounds of the content inside the border
+	Color   color.NRGBA     `json:"color"`   // Mean color of the border
+
+	// Width of the border on each side in pixels
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+	Left   int `json:"left"`
+}
+
+// Found reports whether there is a border on any side.
+func (b Borders) Found() bool {
+	return b.Top+b.Right+b.Bottom+b.Left > 0
+}
+
+// DetectBorders finds the uniform border around the content of img. Each
+// corner color is tried as the border color, and rows and columns are
+// peeled off each side while they match it; the color that removes the
+// most pixels wins. Matching allows for slight noise, so scanned and
+// compressed borders are found too. An image of a single color has no
+// border.
+//
+// Content is in the coordinates of img, so it can be passed to Crop.
+//
+// Example:
+//
+//	if b := imgx.DetectBorders(still); b.Found() {
+//		fmt.Printf("letterboxed: %d/%d px bars\n", b.Top, b.Bottom)
+//		still = imgx.Crop(still, b.Content)
+//	}
+func DetectBorders(img image.Image) Borders {
+	bounds := img.Bounds()
+	result := Borders{Content: bounds}
+	src := toNRGBA(img)
+	b := src.Bounds()
+	if b.Empty() {
+		return result
+	}
+
+	corners := []color.NRGBA{
+		src.NRGBAAt(0, 0),
+		src.NRGBAAt(b.Max.X-1, 0),
+		src.NRGBAAt(0, b.Max.Y-1),
+		src.NRGBAAt(b.Max.X-1, b.Max.Y-1),
+	}
+	best, bestArea := b, 0
+	var border color.NRGBA
+	for _, c := range corners {
+		content := borderContent(src, c)
+		if area := b.Dx()*b.Dy() - content.Dx()*content.Dy(); area > bestArea {
+			best, bestArea, border = content, area, c
+		}
+	}
+	if bestArea == 0 {
+		return result
+	}
+
+	result.Content = best.Add(bounds.Min)
+	result.Color = meanBorderColor(src, best, border)
+	result.Top = best.Min.Y
+	result.Right = b.Max.X - best.Max.X
+	result.Bottom = b.Max.Y - best.Max.Y
+	result.Left = best.Min.X
+	return result
+}
+
+// DetectBorders finds the uniform border around the content of the image (see DetectBorders)
+func (img *Image) DetectBorders() Borders {
+	return DetectBorders(img.data)
+}
+
+// borderContent returns the bounds of src left after removing the rows and
+// columns of color c from each side, or src's bounds if the whole image is c.
+func borderContent(src *image.NRGBA, c color.NRGBA) image.Rectangle {
+	b := src.Bounds()
+
+	// matches reports whether the n pixels from (x, y) in direction
+	// (dx, dy) are of color c
+	matches := func(x, y, dx, dy, n int) bool {
+		allowed := int(float64(n) * borderOutliers)
+		for i := 0; i < n; i++ {
+			if !nearBorderColor(src.Pix[src.PixOffset(x+i*dx, y+i*dy):], c) {
+				if allowed--; allowed < 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	top := b.Min.Y
+	for top < b.Max.Y && matches(b.Min.X, top, 1, 0, b.Dx()) {
+		top++
+	}
+	if top == b.Max.Y {
+		return b
+	}
+	bottom := b.Max.Y
+	for bottom > top && matches(b.Min.X, bottom-1, 1, 0, b.Dx()) {
+		bottom--
+	}
+	left := b.Min.X
+	for left < b.Max.X && matches(left, top, 0, 1, bottom-top) {
+		left++
+	}
+	right := b.Max.X
+	for right > left && matches(right-1, top, 0, 1, bottom-top) {
+		right--
+	}
+	return image.Rect(left, top, right, bottom)
+}
+
+// nearBorderColor reports whether the NRGBA pixel px is within
+// borderTolerance of c. Fully transparent pixels match regardless of
+// their color.
+func nearBorderColor(px []uint8, c color.NRGBA) bool {
+	if px[3] == 0 && c.A == 0 {
+		return true
+	}
+	for i, v := range [4]uint8{c.R, c.G, c.B, c.A} {
+		if d := int(px[i]) - int(v); d > borderTolerance || d < -borderTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// meanBorderColor returns the mean color of the pixels of src outside
+// content that match the border color c.
+func meanBorderColor(src *image.NRGBA, content image.Rectangle, c color.NRGBA) color.NRGBA {
+	var sum [4]int
+	n := 0
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if (image.Point{x, y}).In(content) {
+				x = content.Max.X - 1
+				continue
+			}
+			px := src.Pix[src.PixOffset(x, y):]
+			if !nearBorderColor(px, c) {
+				continue
+			}
+			for i := range sum {
+				sum[i] += int(px[i])
+			}
+			n++
+		}
+	}
+	if n == 0 {
+		return c
+	}
+	return color.NRGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: uint8((sum[3] + n/2) / n),
+	}
+}
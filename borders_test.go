@@ -0,0 +1,158 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectBorders(t *testing.T) {
+	// framed returns a w x h image of border color with a noisy content rect
+	framed := func(w, h int, border color.NRGBA, content image.Rectangle) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(img, img.Bounds(), &image.Uniform{border}, image.Point{}, draw.Src)
+		rng := rand.New(rand.NewSource(1))
+		for y := content.Min.Y; y < content.Max.Y; y++ {
+			for x := content.Min.X; x < content.Max.X; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+			}
+		}
+		return img
+	}
+	black := color.NRGBA{0, 0, 0, 255}
+	white := color.NRGBA{255, 255, 255, 255}
+
+	testCases := []struct {
+		name    string
+		img     image.Image
+		content image.Rectangle
+		color   color.NRGBA
+	}{
+		{
+			name:    "letterbox",
+			img:     framed(160, 90, black, image.Rect(0, 12, 160, 78)),
+			content: image.Rect(0, 12, 160, 78),
+			color:   black,
+		},
+		{
+			name:    "pillarbox",
+			img:     framed(160, 90, black, image.Rect(20, 0, 140, 90)),
+			content: image.Rect(20, 0, 140, 90),
+			color:   black,
+		},
+		{
+			name:    "scan margin",
+			img:     framed(100, 120, white, image.Rect(7, 5, 90, 111)),
+			content: image.Rect(7, 5, 90, 111),
+			color:   white,
+		},
+		{
+			name:    "one side",
+			img:     framed(50, 50, white, image.Rect(0, 0, 50, 40)),
+			content: image.Rect(0, 0, 50, 40),
+			color:   white,
+		},
+		{
+			name:    "no border",
+			img:     framed(40, 30, black, image.Rect(0, 0, 40, 30)),
+			content: image.Rect(0, 0, 40, 30),
+		},
+		{
+			name:    "uniform",
+			img:     New(40, 30, white),
+			content: image.Rect(0, 0, 40, 30),
+		},
+		{
+			name:    "offset bounds",
+			img:     framed(60, 60, black, image.Rect(0, 10, 60, 50)).SubImage(image.Rect(10, 0, 60, 60)),
+			content: image.Rect(10, 10, 60, 50),
+			color:   black,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DetectBorders(tc.img)
+			if got.Content != tc.content {
+				t.Errorf("Content = %v, want %v", got.Content, tc.content)
+			}
+			if got.Color != tc.color {
+				t.Errorf("Color = %v, want %v", got.Color, tc.color)
+			}
+			b := tc.img.Bounds()
+			sides := [4]int{got.Top, got.Right, got.Bottom, got.Left}
+			want := [4]int{
+				tc.content.Min.Y - b.Min.Y, b.Max.X - tc.content.Max.X,
+				b.Max.Y - tc.content.Max.Y, tc.content.Min.X - b.Min.X,
+			}
+			if sides != want {
+				t.Errorf("sides = %v, want %v", sides, want)
+			}
+			if got.Found() != (tc.content != b) {
+				t.Errorf("Found() = %v, want %v", got.Found(), tc.content != b)
+			}
+		})
+	}
+}
+
+func TestDetectBordersNoise(t *testing.T) {
+	// A JPEG-like letterbox: bars of near-black with a few stray pixels
+	img := New(200, 100, color.NRGBA{5, 5, 5, 255})
+	draw.Draw(img, image.Rect(0, 20, 200, 80), &image.Uniform{color.NRGBA{200, 120, 40, 255}}, image.Point{}, draw.Src)
+	img.SetNRGBA(50, 3, color.NRGBA{80, 80, 80, 255})
+	img.SetNRGBA(120, 90, color.NRGBA{14, 2, 9, 255})
+
+	got := DetectBorders(img)
+	if want := image.Rect(0, 20, 200, 80); got.Content != want {
+		t.Errorf("Content = %v, want %v", got.Content, want)
+	}
+	if got.Color.R > 6 || got.Color.G > 6 || got.Color.B > 6 {
+		t.Errorf("Color = %v, want about {5 5 5 255}", got.Color)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	img := New(100, 60, color.Black)
+	draw.Draw(img, image.Rect(0, 10, 100, 50), &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	a := Analyze(img)
+	if want := image.Rect(0, 10, 100, 50); a.Borders.Content != want {
+		t.Errorf("Borders.Content = %v, want %v", a.Borders.Content, want)
+	}
+	if a.ClippedShadowsPercent < 33 || a.ClippedHighlightsPercent < 66 {
+		t.Errorf("clipping = %.1f%%, %.1f%%, want 33.3%%, 66.7%%", a.ClippedShadowsPercent, a.ClippedHighlightsPercent)
+	}
+	if a.Sharpness <= 0 {
+		t.Errorf("Sharpness = %v, want > 0", a.Sharpness)
+	}
+}
+
+func TestMetadataWithAnalysis(t *testing.T) {
+	img := New(100, 60, color.Black)
+	draw.Draw(img, image.Rect(10, 0, 90, 60), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	path := filepath.Join(t.TempDir(), "pillarbox.png")
+	if err := save(img, path); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := Metadata(path, WithBasicOnly())
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if metadata.Analysis != nil {
+		t.Errorf("Analysis = %+v without WithAnalysis, want nil", metadata.Analysis)
+	}
+
+	metadata, err = Metadata(path, WithBasicOnly(), WithAnalysis())
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if metadata.Analysis == nil {
+		t.Fatal("Analysis = nil, want analysis")
+	}
+	if b := metadata.Analysis.Borders; b.Left != 10 || b.Right != 10 || b.Top != 0 || b.Bottom != 0 {
+		t.Errorf("Borders = %+v, want 10px left and right", b)
+	}
+}
@@ -0,0 +1,144 @@
+package imgx
+
+import (
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	gowebp "github.com/gen2brain/webp"
+)
+
+// CapabilityReport describes what this build of imgx can do on this
+// system, for feature gating in applications and for bug reports.
+type CapabilityReport struct {
+	Version string             `json:"version"`
+	Formats []FormatCapability `json:"formats"`
+
+	// Providers lists the configured detection providers. The imgx package
+	// has none of its own: applications that use the detection module fill
+	// it with detection.ConfiguredProviders, as `imgx version` does.
+	Providers []string `json:"providers"`
+
+	// Tools reports which optional external programs were found in PATH:
+	// exiftool (extended metadata), ffmpeg and tesseract.
+	Tools map[string]bool `json:"tools"`
+
+	// SIMD lists the code paths that use SIMD instructions. The image
+	// operations are pure Go; WebP coding uses a system libwebp when one
+	// is installed and a WebAssembly build of it otherwise.
+	SIMD []string `json:"simd"`
+
+	Offline bool      `json:"offline"`
+	Build   BuildInfo `json:"build"`
+}
+
+// FormatCapability tells whether a format can be decoded and encoded
+type FormatCapability struct {
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`
+	Decode     bool     `json:"decode"`
+	Encode     bool     `json:"encode"`
+}
+
+// BuildInfo describes the binary imgx was built into
+type BuildInfo struct {
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Module    string `json:"module,omitempty"`   // version of the imgx module, if known
+	Revision  string `json:"revision,omitempty"` // VCS revision of the main module
+	Time      string `json:"time,omitempty"`     // VCS commit time of the main module
+	Modified  bool   `json:"modified,omitempty"` // built from a modified work tree
+	CGO       bool   `json:"cgo"`
+}
+
+// externalTools are the optional programs reported by Capabilities
+var externalTools = []string{"exiftool", "ffmpeg", "tesseract"}
+
+// Capabilities returns the capabilities of this build of imgx. It looks up
+// the external tools in PATH on every call.
+//
+// Example:
+//
+//	caps := imgx.Capabilities()
+//	if caps.Tools["exiftool"] {
+//	    // extended metadata is available
+//	}
+func Capabilities() *CapabilityReport {
+	c := &CapabilityReport{
+		Version: Version,
+		Formats: formatCapabilities(),
+		Tools:   make(map[string]bool, len(externalTools)),
+		SIMD:    []string{},
+		Offline: IsOffline(),
+		Build:   buildInfo(),
+	}
+	for _, tool := range externalTools {
+		_, err := exec.LookPath(tool)
+		c.Tools[tool] = err == nil
+	}
+	if gowebp.Dynamic() == nil {
+		c.SIMD = append(c.SIMD, "webp (libwebp)")
+	}
+	return c
+}
+
+// formatCapabilities lists the formats in Format order, then PDF (encode
+// only, see EncodePDF)
+func formatCapabilities() []FormatCapability {
+	exts := make(map[Format][]string)
+	for ext, f := range formatExts {
+		exts[f] = append(exts[f], ext)
+	}
+	var formats []FormatCapability
+	for f := JPEG; f <= PSD; f++ {
+		sort.Strings(exts[f])
+		formats = append(formats, FormatCapability{
+			Name:       f.String(),
+			Extensions: exts[f],
+			Decode:     true,
+			Encode:     f != PSD,
+		})
+	}
+	return append(formats, FormatCapability{Name: "PDF", Extensions: []string{"pdf"}, Encode: true})
+}
+
+// buildInfo reads the build information embedded in the binary
+func buildInfo() BuildInfo {
+	b := BuildInfo{GoVersion: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if info.Main.Path == "github.com/razzkumar/imgx" {
+		b.Module = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != "github.com/razzkumar/imgx" {
+			continue
+		}
+		// A replacement by a local directory has no version
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Version != "" {
+			b.Module = dep.Version
+		} else {
+			b.Module = "(devel)"
+		}
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Revision = s.Value
+		case "vcs.time":
+			b.Time = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		case "CGO_ENABLED":
+			b.CGO = s.Value == "1"
+		}
+	}
+	return b
+}
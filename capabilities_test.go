@@ -0,0 +1,43 @@
+package imgx
+
+import (
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	c := Capabilities()
+	if c.Version != Version {
+		t.Errorf("Version = %q, want %q", c.Version, Version)
+	}
+
+	formats := make(map[string]FormatCapability)
+	for _, f := range c.Formats {
+		formats[f.Name] = f
+	}
+	for _, name := range []string{"JPEG", "PNG", "GIF", "TIFF", "BMP", "WEBP"} {
+		if f := formats[name]; !f.Decode || !f.Encode {
+			t.Errorf("format %s = %+v, want decode and encode", name, f)
+		}
+	}
+	if f := formats["PSD"]; !f.Decode || f.Encode {
+		t.Errorf("format PSD = %+v, want decode only", f)
+	}
+	if f := formats["PDF"]; f.Decode || !f.Encode {
+		t.Errorf("format PDF = %+v, want encode only", f)
+	}
+	if got := formats["JPEG"].Extensions; len(got) != 2 || got[0] != "jpeg" || got[1] != "jpg" {
+		t.Errorf("JPEG extensions = %v", got)
+	}
+
+	for _, tool := range []string{"exiftool", "ffmpeg", "tesseract"} {
+		if _, ok := c.Tools[tool]; !ok {
+			t.Errorf("Tools has no entry for %s", tool)
+		}
+	}
+	if c.Tools["exiftool"] != isExiftoolAvailable() {
+		t.Errorf("Tools[exiftool] = %v, want %v", c.Tools["exiftool"], isExiftoolAvailable())
+	}
+	if c.Build.GoVersion == "" || c.Build.OS == "" || c.Build.Arch == "" {
+		t.Errorf("Build = %+v, want Go version, OS and arch", c.Build)
+	}
+}
@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// A11yCommand creates the a11y command
+func A11yCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "a11y",
+		Usage:     "Simulate color blindness and check text contrast (WCAG)",
+		ArgsUsage: "<input>",
+		Description: `Accessibility checks for banners, charts and UI screenshots.
+
+--simulate renders the image as seen with a color vision deficiency
+(protanopia, deuteranopia, tritanopia, achromatopsia, or "all").
+
+--check-contrast measures the WCAG contrast ratio between text and background
+in each text region. Regions are given with --region x,y,w,h (pixels) or found
+with OCR through a detection provider (--ocr). When combined with --simulate,
+contrast is also checked as seen with each deficiency. The command fails when a
+region is below the --level threshold, so it can gate CI pipelines.
+
+Examples:
+  imgx a11y banner.png --simulate deuteranopia
+  imgx a11y banner.png --simulate all
+  imgx a11y banner.png --check-contrast --region 40,20,600,80
+  imgx a11y banner.png --simulate deuteranopia --check-contrast --ocr --provider aws
+  imgx a11y banner.png --check-contrast --ocr --level aaa --json`,
+		// --region values contain commas
+		DisableSliceFlagSeparator: true,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "simulate",
+				Usage: "color vision deficiency to simulate: protanopia, deuteranopia, tritanopia, achromatopsia or all (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "check-contrast",
+				Usage: "check the contrast of text regions against WCAG",
+			},
+			&cli.StringSliceFlag{
+				Name:  "region",
+				Usage: "text region as x,y,w,h in pixels (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "ocr",
+				Usage: "find text regions with OCR (calls the detection provider)",
+			},
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider used with --ocr",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.StringFlag{
+				Name:  "level",
+				Usage: "WCAG level to enforce: aa, aa-large or aaa",
+				Value: "aa",
+				Validator: func(v string) error {
+					if _, err := contrastThreshold(v); err != nil {
+						return err
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the contrast report as JSON",
+			},
+		},
+		Action: a11yAction,
+	}
+}
+
+// contrastCheck is the contrast report of one region as seen with a vision type
+type contrastCheck struct {
+	Vision     string  `json:"vision"`
+	Text       string  `json:"text,omitempty"`
+	X          int     `json:"x"`
+	Y          int     `json:"y"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Foreground string  `json:"foreground"`
+	Background string  `json:"background"`
+	Ratio      float64 `json:"ratio"`
+	Pass       bool    `json:"pass"`
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func a11yAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+	if len(cmd.StringSlice("simulate")) == 0 && !cmd.Bool("check-contrast") {
+		return fmt.Errorf("nothing to do: use --simulate and/or --check-contrast")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	kinds, err := parseSimulations(cmd.StringSlice("simulate"))
+	if err != nil {
+		return err
+	}
+
+	views := []struct {
+		name string
+		img  *imgx.Image
+	}{{"normal", img}}
+	for _, kind := range kinds {
+		simulated := img.SimulateColorBlindness(kind)
+		outputPath := GenerateOutputPath(inputPath, "-"+kind.String())
+		if out := cmd.String("output"); out != "" && len(kinds) == 1 {
+			outputPath = out
+		}
+		if err := saveImage(cmd, simulated, outputPath); err != nil {
+			return err
+		}
+		if !cmd.Bool("json") {
+			infof("%s simulation saved to: %s", kind, outputPath)
+		}
+		views = append(views, struct {
+			name string
+			img  *imgx.Image
+		}{kind.String(), simulated})
+	}
+
+	if !cmd.Bool("check-contrast") {
+		return nil
+	}
+
+	regions, texts, err := textRegions(ctx, cmd, img)
+	if err != nil {
+		return err
+	}
+
+	// Drop regions outside the image so reports line up with texts
+	var inside []image.Rectangle
+	var insideTexts []string
+	for i, r := range regions {
+		if !r.Intersect(img.Bounds()).Empty() {
+			inside = append(inside, r)
+			insideTexts = append(insideTexts, texts[i])
+		} else {
+			warnf("region %v is outside the image", r)
+		}
+	}
+	regions, texts = inside, insideTexts
+	if len(regions) == 0 {
+		return fmt.Errorf("no text regions to check: pass --region or --ocr")
+	}
+
+	threshold, _ := contrastThreshold(cmd.String("level"))
+	var checks []contrastCheck
+	failures := 0
+	for _, view := range views {
+		for i, report := range view.img.CheckContrast(regions) {
+			r := report.Region
+			check := contrastCheck{
+				Vision:     view.name,
+				Text:       texts[i],
+				X:          r.Min.X,
+				Y:          r.Min.Y,
+				Width:      r.Dx(),
+				Height:     r.Dy(),
+				Foreground: hexColor(report.Foreground),
+				Background: hexColor(report.Background),
+				Ratio:      math.Round(report.Ratio*100) / 100,
+				Pass:       report.Ratio >= threshold,
+			}
+			if !check.Pass {
+				failures++
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	if cmd.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(checks); err != nil {
+			return err
+		}
+	} else {
+		printContrastChecks(checks, cmd.String("level"))
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d contrast checks below WCAG %s (%.1f:1)", failures, len(checks), strings.ToUpper(cmd.String("level")), threshold)
+	}
+	return nil
+}
+
+// parseSimulations parses the --simulate values, expanding "all"
+func parseSimulations(values []string) ([]imgx.ColorBlindness, error) {
+	var kinds []imgx.ColorBlindness
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "all") {
+				return []imgx.ColorBlindness{imgx.Protanopia, imgx.Deuteranopia, imgx.Tritanopia, imgx.Achromatopsia}, nil
+			}
+			kind, err := imgx.ParseColorBlindness(name)
+			if err != nil {
+				return nil, err
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+// contrastThreshold returns the minimum contrast ratio of a WCAG level
+func contrastThreshold(level string) (float64, error) {
+	switch strings.ToLower(level) {
+	case "aa":
+		return 4.5, nil
+	case "aa-large":
+		return 3, nil
+	case "aaa":
+		return 7, nil
+	}
+	return 0, fmt.Errorf("invalid level %q: use aa, aa-large or aaa", level)
+}
+
+// textRegions collects the regions given with --region and, with --ocr, the
+// text blocks found by the detection provider
+func textRegions(ctx context.Context, cmd *cli.Command, img *imgx.Image) ([]image.Rectangle, []string, error) {
+	var regions []image.Rectangle
+	var texts []string
+	for _, s := range cmd.StringSlice("region") {
+		r, err := ParseRegion(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		regions = append(regions, r)
+		texts = append(texts, "")
+	}
+
+	if !cmd.Bool("ocr") {
+		return regions, texts, nil
+	}
+	result, err := detection.Detect(ctx, img.ToNRGBA(), cmd.String("provider"), &detection.DetectOptions{
+		Features:   []detection.Feature{detection.FeatureText},
+		MaxResults: 50,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("OCR failed: %w", err)
+	}
+
+	bounds := img.Bounds()
+	located := 0
+	for _, block := range result.Text {
+		if block.BoundingBox == nil {
+			continue
+		}
+		b := block.BoundingBox
+		x, y, w, h := float64(b.X), float64(b.Y), float64(b.Width), float64(b.Height)
+		// Most providers return coordinates normalized to 0-1.
+		if x+w <= 1 && y+h <= 1 {
+			x, w = x*float64(bounds.Dx()), w*float64(bounds.Dx())
+			y, h = y*float64(bounds.Dy()), h*float64(bounds.Dy())
+		}
+		regions = append(regions, image.Rect(int(x), int(y), int(x+w+0.5), int(y+h+0.5)))
+		texts = append(texts, block.Text)
+		located++
+	}
+	if len(result.Text) > 0 && located == 0 {
+		warnf("provider %s returned text without locations", result.Provider)
+	}
+	return regions, texts, nil
+}
+
+// ParseRegion parses a rectangle given as "x,y,w,h"
+func ParseRegion(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("invalid region %q: expected x,y,w,h", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("invalid region %q: %w", s, err)
+		}
+		v[i] = n
+	}
+	if v[2] <= 0 || v[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("invalid region %q: width and height must be positive", s)
+	}
+	if v[0] > math.MaxInt-v[2] || v[1] > math.MaxInt-v[3] {
+		return image.Rectangle{}, fmt.Errorf("invalid region %q: out of range", s)
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
+
+func printContrastChecks(checks []contrastCheck, level string) {
+	fmt.Printf("=== %s (WCAG %s) ===\n", tr("Contrast"), strings.ToUpper(level))
+	vision := ""
+	for _, c := range checks {
+		if c.Vision != vision {
+			vision = c.Vision
+			fmt.Printf("\n"+tr("%s vision")+":\n", vision)
+		}
+		status := "PASS"
+		if !c.Pass {
+			status = "FAIL"
+		}
+		fmt.Printf("  %s  %5.2f:1  %dx%d+%d+%d  "+tr("%s on %s"),
+			status, c.Ratio, c.Width, c.Height, c.X, c.Y, c.Foreground, c.Background)
+		if c.Text != "" {
+			fmt.Printf("  %q", c.Text)
+		}
+		fmt.Println()
+	}
+}
@@ -10,8 +10,9 @@ import (
 // AdjustCommand creates the adjust command for color adjustments
 func AdjustCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "adjust",
-		Usage: "Adjust image colors (brightness, contrast, gamma, saturation, hue)",
+		Name:      "adjust",
+		Usage:     "Adjust image colors (brightness, contrast, gamma, saturation, hue)",
+		ArgsUsage: "<input>",
 		Description: `Adjust various color properties of an image. Multiple adjustments can be applied at once.
 
 Examples:
@@ -76,35 +77,35 @@ func adjustAction(ctx context.Context, cmd *cli.Command) error {
 	result := img
 	if brightness != 0 {
 		if cmd.Bool("verbose") {
-			fmt.Printf("Applying brightness: %.1f\n", brightness)
+			infof("Applying brightness: %.1f", brightness)
 		}
 		result = result.AdjustBrightness(brightness)
 	}
 
 	if contrast != 0 {
 		if cmd.Bool("verbose") {
-			fmt.Printf("Applying contrast: %.1f\n", contrast)
+			infof("Applying contrast: %.1f", contrast)
 		}
 		result = result.AdjustContrast(contrast)
 	}
 
 	if gamma != 1.0 {
 		if cmd.Bool("verbose") {
-			fmt.Printf("Applying gamma: %.2f\n", gamma)
+			infof("Applying gamma: %.2f", gamma)
 		}
 		result = result.AdjustGamma(gamma)
 	}
 
 	if saturation != 0 {
 		if cmd.Bool("verbose") {
-			fmt.Printf("Applying saturation: %.1f\n", saturation)
+			infof("Applying saturation: %.1f", saturation)
 		}
 		result = result.AdjustSaturation(saturation)
 	}
 
 	if hue != 0 {
 		if cmd.Bool("verbose") {
-			fmt.Printf("Applying hue shift: %.1f degrees\n", hue)
+			infof("Applying hue shift: %.1f degrees", hue)
 		}
 		result = result.AdjustHue(hue)
 	}
@@ -117,8 +118,9 @@ func adjustAction(ctx context.Context, cmd *cli.Command) error {
 // GrayscaleCommand creates the grayscale command
 func GrayscaleCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "grayscale",
-		Usage: "Convert image to grayscale",
+		Name:      "grayscale",
+		Usage:     "Convert image to grayscale",
+		ArgsUsage: "<input>",
 		Description: `Convert an image to grayscale using luminance weights (ITU-R BT.601).
 
 Example:
@@ -151,8 +153,9 @@ func grayscaleAction(ctx context.Context, cmd *cli.Command) error {
 // InvertCommand creates the invert command
 func InvertCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "invert",
-		Usage: "Invert image colors (negative)",
+		Name:      "invert",
+		Usage:     "Invert image colors (negative)",
+		ArgsUsage: "<input>",
 		Description: `Invert (negate) all colors in the image.
 
 Example:
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// AltTextCommand creates the alt-text command
+func AltTextCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "alt-text",
+		Usage:     "Generate accessible alt text for images",
+		ArgsUsage: "<file|dir>...",
+		Description: `Generate concise alt text for each image with a vision model (Ollama, Gemini
+or OpenAI) and print it as JSON, keyed by path.
+
+The text describes what matters for someone who can't see the image, without
+"image of" style preambles, and is cut at a sentence or word boundary to
+--max-length characters (125 by default, what most screen readers read without
+pausing). With --xmp, it is also written into each file's XMP description
+(requires exiftool).
+
+Examples:
+  imgx alt-text hero.jpg
+  imgx alt-text ./site/images -r --provider gemini --out alt.json
+  imgx alt-text ./products -r --max-length 80 --xmp`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider: ollama, gemini, google (alias), openai",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.IntFlag{
+				Name:    "max-length",
+				Aliases: []string{"l"},
+				Usage:   "maximum alt text length in characters",
+				Value:   125,
+				Validator: func(v int) error {
+					if v < 20 {
+						return fmt.Errorf("max-length must be at least 20")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "write the JSON to this file instead of stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "xmp",
+				Usage: "also write the alt text into each image's XMP description",
+			},
+		},
+		Action: altTextAction,
+	}
+}
+
+// altTextPrompt asks for alt text following common accessibility guidance
+const altTextPrompt = `Write alt text for this image for people using screen readers.
+Describe the subject and what it is doing, plus any context needed to understand the image, in one or two short sentences of at most %d characters.
+Do not start with "Image of", "Picture of" or "Photo of". Do not guess names, emotions or anything that is not visible.
+If the image contains important text, include it.
+Return JSON: {"description": "..."}`
+
+func altTextAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	provider, err := detection.GetProvider(cmd.String("provider"))
+	if err != nil {
+		return err
+	}
+	if !provider.IsConfigured() {
+		return fmt.Errorf("provider %s is not configured", provider.Name())
+	}
+
+	maxLength := cmd.Int("max-length")
+	altTexts := make(map[string]string)
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		result, err := provider.Detect(ctx, img.ToNRGBA(), &detection.DetectOptions{
+			Features:     []detection.Feature{detection.FeatureDescription},
+			CustomPrompt: fmt.Sprintf(altTextPrompt, maxLength),
+		})
+		if err != nil {
+			warnf("skipping %s: detection failed: %v", path, err)
+			continue
+		}
+		text := CleanAltText(result.Description, maxLength)
+		if text == "" {
+			warnf("skipping %s: provider %s returned no description", path, provider.Name())
+			continue
+		}
+		altTexts[path] = text
+		if cmd.Bool("verbose") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, text)
+		}
+
+		if cmd.Bool("xmp") {
+			if err := imgx.WriteDescription(path, text); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(altTexts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if out := cmd.String("out"); out != "" {
+		if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", out, err)
+		}
+		if cmd.Bool("verbose") {
+			infof("Alt text for %d images saved to: %s", len(altTexts), out)
+		}
+		return nil
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+var altTextPreamble = regexp.MustCompile(`(?i)^(this is |here is |there is )?(an? |the )?(close-up |black and white )?(image|picture|photo|photograph|illustration|screenshot) (of|showing|shows|that shows|depicting|depicts)\s+`)
+
+// CleanAltText normalizes model output into alt text: it strips quotes,
+// markdown and "image of" preambles, collapses whitespace and shortens the
+// text to maxLength characters, preferring to cut at the end of a sentence,
+// then at a word boundary.
+func CleanAltText(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.TrimPrefix(text, "Alt text:")
+	text = strings.Trim(text, " \"'`*_")
+	if loc := altTextPreamble.FindStringIndex(text); loc != nil {
+		text = text[loc[1]:]
+	}
+	if text == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	text = string(unicode.ToUpper(r)) + text[size:]
+
+	if utf8.RuneCountInString(text) > maxLength {
+		runes := []rune(text)
+		cut := string(runes[:maxLength])
+		if i := strings.LastIndexAny(cut, ".!?"); i >= maxLength/2 {
+			return cut[:i+1]
+		}
+		if i := strings.LastIndex(cut[:len(cut)-1], " "); i > 0 {
+			cut = cut[:i]
+		}
+		text = strings.TrimRight(cut, " ,;:-")
+	}
+	if last, _ := utf8.DecodeLastRuneInString(text); !strings.ContainsRune(".!?", last) {
+		if utf8.RuneCountInString(text) >= maxLength {
+			return text
+		}
+		text += "."
+	}
+	return text
+}
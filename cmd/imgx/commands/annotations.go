@@ -0,0 +1,469 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// detectExportAction runs detection on every input image and writes the
+// bounding boxes as COCO or Pascal VOC annotations (detect --export)
+func detectExportAction(ctx context.Context, cmd *cli.Command) error {
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	provider := cmd.String("provider")
+	var router *detection.Router
+	if detection.ResolveProviderAlias(provider) == detection.AutoProvider {
+		if router, err = detection.LoadRouter(cmd.String("routes")); err != nil {
+			return err
+		}
+	}
+
+	opts := &detection.DetectOptions{
+		Features:      detection.ParseFeatures(cmd.String("features")),
+		MaxResults:    cmd.Int("max-results"),
+		MinConfidence: float32(cmd.Float64("confidence")),
+	}
+
+	format, out := cmd.String("export"), cmd.String("out")
+	var images []detection.AnnotatedImage
+	boxes := 0
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		var result *detection.DetectionResult
+		if router != nil {
+			result, err = router.Detect(ctx, img.ToNRGBA(), opts)
+		} else {
+			result, err = detection.Detect(ctx, img.ToNRGBA(), provider, opts)
+		}
+		if err != nil {
+			warnf("skipping %s: detection failed: %v", path, err)
+			continue
+		}
+		if err := reportWarnings(cmd, path, result.Warnings); err != nil {
+			return err
+		}
+
+		bounds := img.Bounds()
+		annotated := detection.AnnotatedImage{Path: path, Width: bounds.Dx(), Height: bounds.Dy(), Result: result}
+		boxes += len(result.BoundingBoxes)
+		if cmd.Bool("verbose") {
+			fmt.Fprintf(os.Stderr, tr("%s: %d boxes")+"\n", path, len(result.BoundingBoxes))
+		}
+
+		if format == "voc" {
+			if err := writeVOCFile(annotated, out); err != nil {
+				return err
+			}
+		}
+		images = append(images, annotated)
+	}
+
+	if boxes == 0 && len(images) > 0 {
+		warnf("no bounding boxes detected; use --features objects")
+	}
+
+	if format == "voc" {
+		if cmd.Bool("verbose") {
+			infof("Pascal VOC annotations for %d images written", len(images))
+		}
+		return nil
+	}
+
+	if out == "" {
+		return detection.WriteCOCO(os.Stdout, images)
+	}
+	var buf bytes.Buffer
+	if err := detection.WriteCOCO(&buf, images); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if cmd.Bool("verbose") {
+		infof("COCO annotations for %d images (%d boxes) saved to: %s", len(images), boxes, out)
+	}
+	return nil
+}
+
+// writeVOCFile writes the Pascal VOC annotation of img to VOCAnnotationPath
+func writeVOCFile(img detection.AnnotatedImage, dir string) error {
+	path := VOCAnnotationPath(img.Path, dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	var buf bytes.Buffer
+	if err := detection.WriteVOC(&buf, img); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// VOCAnnotationPath returns the path of the Pascal VOC annotation of
+// imagePath: <name>.xml in dir, or next to the image if dir is empty
+func VOCAnnotationPath(imagePath, dir string) string {
+	name := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath)) + ".xml"
+	if dir == "" {
+		dir = filepath.Dir(imagePath)
+	}
+	return filepath.Join(dir, name)
+}
+
+// AnnotateCommand creates the annotate command
+func AnnotateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "annotate",
+		Usage: "Draw the boxes of a COCO, Pascal VOC or labelme annotation file on its images",
+		Description: `Render existing annotations onto their images to inspect a dataset: every
+box is drawn with its label (and confidence, for detection exports), in a
+color picked by label. The format of the annotation file is recognized by its
+content; image paths are resolved relative to the annotation file.
+
+Images are written to --out-dir under their own name, or next to the
+original with an "-annotated" suffix.
+
+Examples:
+  imgx annotate --annotations annotations.json --out-dir annotated/
+  imgx annotate --annotations Annotations/street.xml --labels person,car
+  imgx detect ./images -r --provider vision --features objects --export coco --out boxes.json
+  imgx annotate --annotations boxes.json --out-dir review/`,
+		Flags: append(annotationFlags(),
+			&cli.IntFlag{
+				Name:  "width",
+				Usage: "line width in pixels (default: 1/300 of the shorter side)",
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("width must be non-negative")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "out-dir",
+				Usage: "write the annotated images to this directory",
+			},
+		),
+		Action: annotateAction,
+	}
+}
+
+// ExtractObjectsCommand creates the extract-objects command
+func ExtractObjectsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "extract-objects",
+		Usage: "Crop every object of a COCO, Pascal VOC or labelme annotation file to its own image",
+		Description: `Crop each annotated object out of its image, e.g. to review the instances of
+a class or to build a classification dataset from a detection dataset.
+Crops are written to <out>/<label>/<image>-<n>.<ext>.
+
+Examples:
+  imgx extract-objects --annotations coco.json --out crops/
+  imgx extract-objects --annotations coco.json --out crops/ --labels person --padding 8
+  imgx extract-objects --annotations Annotations/street.xml --out crops/ --format png`,
+		Flags: append(annotationFlags(),
+			&cli.StringFlag{
+				Name:     "out",
+				Usage:    "directory for the crops",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "padding",
+				Usage: "pixels added around each box",
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("padding must be non-negative")
+					}
+					return nil
+				},
+			},
+		),
+		Action: extractObjectsAction,
+	}
+}
+
+// annotationFlags returns the flags shared by annotate and extract-objects
+func annotationFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "annotations",
+			Usage:    "COCO (JSON), Pascal VOC (XML) or labelme (JSON) annotation file",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "labels",
+			Usage: "only use boxes with these labels (comma-separated, case-insensitive)",
+		},
+		&cli.Float64Flag{
+			Name:  "min-confidence",
+			Usage: "only use boxes with at least this confidence (0.0-1.0)",
+			Validator: func(v float64) error {
+				if v < 0 || v > 1 {
+					return fmt.Errorf("min-confidence must be between 0.0 and 1.0")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// annotatedObject is a box of an annotation file in image coordinates
+type annotatedObject struct {
+	Label      string
+	Confidence float32
+	Rect       image.Rectangle
+}
+
+// loadAnnotatedImages reads the annotation file and calls fn with each
+// image and its boxes that pass the --labels and --min-confidence filters.
+// Images that cannot be loaded are skipped with a warning.
+func loadAnnotatedImages(ctx context.Context, cmd *cli.Command, fn func(path string, img *imgx.Image, objects []annotatedObject) error) error {
+	images, err := detection.ReadAnnotations(cmd.String("annotations"))
+	if err != nil {
+		return err
+	}
+	var labels []string
+	for _, label := range strings.Split(cmd.String("labels"), ",") {
+		if label = strings.ToLower(strings.TrimSpace(label)); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	minConfidence := float32(cmd.Float64("min-confidence"))
+
+	for _, annotated := range images {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if annotated.Width <= 0 || annotated.Height <= 0 {
+			warnf("skipping %s: the annotation has no image size", annotated.Path)
+			continue
+		}
+		img, err := loadImage(cmd, annotated.Path)
+		if err != nil {
+			warnf("skipping %s: %v", annotated.Path, err)
+			continue
+		}
+		bounds := img.Bounds()
+		if bounds.Dx() != annotated.Width || bounds.Dy() != annotated.Height {
+			warnf("%s: image is %dx%d but annotated as %dx%d; scaling the boxes",
+				annotated.Path, bounds.Dx(), bounds.Dy(), annotated.Width, annotated.Height)
+		}
+
+		var objects []annotatedObject
+		for _, box := range annotated.Result.BoundingBoxes {
+			if len(labels) > 0 && !slices.Contains(labels, strings.ToLower(box.Label)) {
+				continue
+			}
+			if box.Confidence < minConfidence {
+				continue
+			}
+			rect := box.Box.Rect(bounds.Dx(), bounds.Dy()).Add(bounds.Min)
+			if rect.Empty() {
+				continue
+			}
+			objects = append(objects, annotatedObject{Label: box.Label, Confidence: box.Confidence, Rect: rect})
+		}
+		if err := fn(annotated.Path, img, objects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func annotateAction(ctx context.Context, cmd *cli.Command) error {
+	outDir := cmd.String("out-dir")
+	if outDir != "" {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", outDir, err)
+		}
+	}
+
+	count := 0
+	err := loadAnnotatedImages(ctx, cmd, func(path string, img *imgx.Image, objects []annotatedObject) error {
+		boxes := make([]imgx.BoxAnnotation, len(objects))
+		for i, object := range objects {
+			boxes[i] = imgx.BoxAnnotation{Rect: object.Rect, Label: object.Label}
+			if object.Confidence < 1 {
+				boxes[i].Label = fmt.Sprintf("%s %.2f", object.Label, object.Confidence)
+			}
+		}
+		outputPath := GenerateOutputPath(path, "-annotated")
+		if outDir != "" {
+			outputPath = filepath.Join(outDir, filepath.Base(path))
+		}
+		count++
+		return saveImage(cmd, img.Annotate(boxes, imgx.AnnotateOptions{Width: cmd.Int("width")}), outputPath)
+	})
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("verbose") {
+		infof("%d images annotated", count)
+	}
+	return nil
+}
+
+func extractObjectsAction(ctx context.Context, cmd *cli.Command) error {
+	outDir, padding := cmd.String("out"), cmd.Int("padding")
+	count := 0
+	err := loadAnnotatedImages(ctx, cmd, func(path string, img *imgx.Image, objects []annotatedObject) error {
+		rects := make([]image.Rectangle, len(objects))
+		labels := make([]string, len(objects))
+		for i, object := range objects {
+			rects[i], labels[i] = object.Rect, object.Label
+		}
+		n, err := saveCrops(cmd, img.CropBoxes(rects, padding), labels, path, outDir)
+		count += n
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("verbose") {
+		infof("%d objects extracted to: %s", count, outDir)
+	}
+	return nil
+}
+
+// ObjectCropPath returns the path of the n-th object crop of imagePath:
+// <dir>/<label>/<image name>-<n><ext>, with the label slugified
+func ObjectCropPath(dir, imagePath, label string, n int) string {
+	class := Slugify(label)
+	if class == "" {
+		class = "unlabeled"
+	}
+	ext := filepath.Ext(imagePath)
+	stem := strings.TrimSuffix(filepath.Base(imagePath), ext)
+	return filepath.Join(dir, class, fmt.Sprintf("%s-%d%s", stem, n, ext))
+}
+
+// saveObjectCrops saves the boxes of result selected by --crop and
+// --confidence as crops of img (detect --crop)
+func saveObjectCrops(cmd *cli.Command, img *imgx.Image, inputPath string, result *detection.DetectionResult) error {
+	var classes []string
+	if crop := strings.TrimSpace(cmd.String("crop")); crop != "all" && crop != "*" {
+		classes = strings.Split(crop, ",")
+	}
+	boxes := result.FilterBoxes(classes, float32(cmd.Float64("confidence")))
+	if len(boxes) == 0 {
+		if len(result.BoundingBoxes) == 0 {
+			warnf("%s: no bounding boxes to crop; use --features objects", inputPath)
+		} else {
+			warnf("%s: no %s objects detected", inputPath, cmd.String("crop"))
+		}
+		return nil
+	}
+
+	bounds := img.Bounds()
+	rects := make([]image.Rectangle, len(boxes))
+	labels := make([]string, len(boxes))
+	for i, box := range boxes {
+		rects[i] = box.Box.Rect(bounds.Dx(), bounds.Dy()).Add(bounds.Min)
+		labels[i] = box.Label
+	}
+	outDir := cmd.String("out-dir")
+	if outDir == "" {
+		outDir = filepath.Dir(inputPath)
+	}
+	n, err := saveCrops(cmd, img.CropBoxes(rects, cmd.Int("padding")), labels, inputPath, outDir)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("verbose") {
+		infof("%d objects cropped to: %s", n, outDir)
+	}
+	return nil
+}
+
+// Colors of the faces and text drawn by detect --annotate; objects, landmarks
+// and logos get a color by label
+var (
+	faceAnnotationColor = color.NRGBA{255, 225, 25, 255}
+	textAnnotationColor = color.NRGBA{0, 130, 200, 255}
+)
+
+// maxAnnotationText is the number of characters of OCR text drawn in a label
+const maxAnnotationText = 24
+
+// saveDetectionAnnotations draws the located results of detect with at
+// least --confidence on the image and saves it to -o or next to the input
+func saveDetectionAnnotations(cmd *cli.Command, img *imgx.Image, inputPath string, result *detection.DetectionResult) error {
+	bounds := img.Bounds()
+	minConfidence := float32(cmd.Float64("confidence"))
+	var boxes []imgx.BoxAnnotation
+	for _, region := range result.Regions(bounds.Dx(), bounds.Dy()) {
+		if region.Confidence > 0 && region.Confidence < minConfidence {
+			continue
+		}
+		box := imgx.BoxAnnotation{Rect: region.Rect.Add(bounds.Min), Label: region.Label}
+		switch region.Kind {
+		case detection.RegionFace:
+			box.Color = faceAnnotationColor
+		case detection.RegionText:
+			box.Color = textAnnotationColor
+			if text := []rune(strings.Join(strings.Fields(box.Label), " ")); len(text) > maxAnnotationText {
+				box.Label = string(text[:maxAnnotationText-3]) + "..."
+			} else {
+				box.Label = string(text)
+			}
+		}
+		if region.Confidence > 0 && region.Confidence < 1 {
+			box.Label = fmt.Sprintf("%s %.2f", box.Label, region.Confidence)
+		}
+		boxes = append(boxes, box)
+	}
+	if len(boxes) == 0 {
+		warnf("%s: nothing located to annotate; use --features objects, faces or text", inputPath)
+	}
+
+	outputPath := getOutputPath(cmd, inputPath, "-annotated")
+	if err := saveImage(cmd, img.Annotate(boxes, imgx.AnnotateOptions{}), outputPath); err != nil {
+		return err
+	}
+	if cmd.Bool("verbose") {
+		infof("%d regions drawn on: %s", len(boxes), outputPath)
+	}
+	return nil
+}
+
+// saveCrops saves crops of imagePath to ObjectCropPath in dir, numbered
+// from 1, and returns the number saved. Empty crops are skipped.
+func saveCrops(cmd *cli.Command, crops []*imgx.Image, labels []string, imagePath, dir string) (int, error) {
+	saved := 0
+	for i, crop := range crops {
+		if crop.Bounds().Empty() {
+			continue
+		}
+		outputPath := ObjectCropPath(dir, imagePath, labels[i], i+1)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return saved, fmt.Errorf("failed to create %s: %w", filepath.Dir(outputPath), err)
+		}
+		if err := saveImage(cmd, crop, outputPath); err != nil {
+			return saved, err
+		}
+		saved++
+	}
+	return saved, nil
+}
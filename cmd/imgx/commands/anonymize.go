@@ -0,0 +1,504 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// anonymizeProviders are the providers that return the locations of faces,
+// text or objects, local first
+var anonymizeProviders = []string{"yolo", "vision", "aws"}
+
+// AnonymizeCommand creates the anonymize command
+func AnonymizeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "anonymize",
+		Usage:     "Blur faces and license plates in photos",
+		ArgsUsage: "<file|dir>...",
+		Description: `Detect faces, license plate-like text and objects of --classes, blur, pixelate or
+fill them (--mode), and write an audit JSON of what was redacted in each file. Directories are scanned
+for images (recursively with -r). Blurred images are written next to the
+source with an "-anonymized" suffix, or to --out-dir, where the directory
+structure below each input directory is kept. EXIF metadata (GPS location,
+camera serial) is not copied to the output.
+
+The provider must return the locations of what it finds. Without --provider,
+the first configured of these is used:
+  yolo    local YOLO server; redacts objects of --classes, so use a face or
+          license plate model (IMGX_YOLO_LABELS) for faces and plates
+  vision  Google Cloud Vision: faces, text and objects
+  aws     AWS Rekognition: faces and text
+
+Text is plate-like when it has 4 to 10 letters and digits, with at least one
+of each, ignoring spaces, dashes and dots (e.g. "KA 01 AB 1234", "B-MW 123").
+Use --text all to blur all text, or --text none to keep it. Blurred text may
+stay legible; use --text-mode fill to paint it over.
+
+The audit records the kind, label, confidence, pixel box and mode of every
+redacted region, never the text that was read.
+
+Examples:
+  imgx anonymize ./photos -r --out-dir ./public
+  imgx anonymize street.jpg --provider vision --text all --text-mode fill
+  imgx anonymize ./photos -r --mode pixelate
+  imgx anonymize ./photos -r --provider yolo --classes face,license_plate
+  imgx anonymize ./photos -r --dry-run --audit review.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "provider",
+				Usage: "detection provider: yolo, vision or aws (default: the first configured)",
+				Validator: func(v string) error {
+					_, err := anonymizeProvider(v)
+					return err
+				},
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "anonymize directories recursively",
+			},
+			&cli.StringFlag{
+				Name:  "out-dir",
+				Usage: "directory to write anonymized files to (default: next to the source)",
+			},
+			&cli.StringFlag{
+				Name:  "audit",
+				Usage: "path of the audit JSON",
+				Value: "anonymize-audit.json",
+			},
+			&cli.StringFlag{
+				Name:  "classes",
+				Usage: "object classes to blur (comma-separated)",
+				Value: "face,license plate,license_plate,licence plate,number plate",
+			},
+			&cli.StringFlag{
+				Name:  "text",
+				Usage: "text to blur: plates, all or none",
+				Value: "plates",
+				Validator: func(v string) error {
+					if v != "plates" && v != "all" && v != "none" {
+						return fmt.Errorf("text must be plates, all or none")
+					}
+					return nil
+				},
+			},
+			&cli.FloatFlag{
+				Name:  "min-confidence",
+				Usage: "minimum confidence of a region to blur (0-1)",
+				Value: 0.3,
+				Validator: func(v float64) error {
+					if v < 0 || v > 1 {
+						return fmt.Errorf("min-confidence must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "mode",
+				Usage: "how to hide regions: blur, pixelate or fill",
+				Value: "blur",
+				Validator: func(v string) error {
+					_, err := imgx.ParseRedactMode(v)
+					return err
+				},
+			},
+			&cli.StringFlag{
+				Name:  "text-mode",
+				Usage: "how to hide plates and text: blur, pixelate or fill (default: --mode)",
+				Validator: func(v string) error {
+					_, err := imgx.ParseRedactMode(v)
+					return err
+				},
+			},
+			&cli.FloatFlag{
+				Name:  "sigma",
+				Usage: "blur strength (default: a fifth of the shorter side of each region)",
+			},
+			&cli.IntFlag{
+				Name:  "block-size",
+				Usage: "pixelate block size (default: an eighth of the shorter side of each region)",
+			},
+			&cli.IntFlag{
+				Name:  "padding",
+				Usage: "grow each region by this many pixels before redacting",
+				Value: 4,
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only write the audit, no images",
+			},
+		},
+		Action: anonymizeAction,
+	}
+}
+
+// Redaction is one region hidden by anonymize
+type Redaction struct {
+	Kind       string  `json:"kind"`            // face, plate, text or object
+	Label      string  `json:"label,omitempty"` // Object class
+	Confidence float32 `json:"confidence,omitempty"`
+	X          int     `json:"x"`
+	Y          int     `json:"y"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Mode       string  `json:"mode,omitempty"` // blur, pixelate or fill
+}
+
+// Rect returns the region in image coordinates
+func (r Redaction) Rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// anonymizeRecord is the audit entry of one input file
+type anonymizeRecord struct {
+	Input   string      `json:"input"`
+	Output  string      `json:"output,omitempty"`
+	Regions []Redaction `json:"regions"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// anonymizeAudit is the audit JSON written by anonymize
+type anonymizeAudit struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Provider  string            `json:"provider"`
+	DryRun    bool              `json:"dry_run,omitempty"`
+	Files     []anonymizeRecord `json:"files"`
+}
+
+func anonymizeAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+	provider, err := anonymizeProvider(cmd.String("provider"))
+	if err != nil {
+		return err
+	}
+	jobs, err := outputJobs(cmd.Args().Slice(), cmd.Bool("recursive"), cmd.String("out-dir"))
+	if err != nil {
+		return err
+	}
+	if cmd.String("out-dir") == "" {
+		// Skip the output of earlier runs
+		jobs = slices.DeleteFunc(jobs, func(job convertJob) bool {
+			return strings.HasSuffix(strings.TrimSuffix(job.input, filepath.Ext(job.input)), "-anonymized")
+		})
+		for i := range jobs {
+			jobs[i].output = GenerateOutputPath(jobs[i].input, "-anonymized")
+		}
+	}
+
+	opts := &detection.DetectOptions{
+		Features:      []detection.Feature{detection.FeatureObjects},
+		MaxResults:    100,
+		MinConfidence: float32(cmd.Float64("min-confidence")),
+	}
+	if provider != "yolo" {
+		opts.Features = append(opts.Features, detection.FeatureFaces)
+		if cmd.String("text") != "none" {
+			opts.Features = append(opts.Features, detection.FeatureText)
+		}
+	}
+	classes := strings.Split(cmd.String("classes"), ",")
+
+	audit := anonymizeAudit{CreatedAt: time.Now().UTC(), Provider: provider, DryRun: cmd.Bool("dry-run")}
+	var regions, failed int
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := anonymizeFile(ctx, cmd, job, provider, opts, classes)
+		if err != nil {
+			warnf("%s: %v", job.input, err)
+			record.Error = err.Error()
+			failed++
+		} else {
+			infof("%s: %d region(s) redacted", job.input, len(record.Regions))
+		}
+		regions += len(record.Regions)
+		audit.Files = append(audit.Files, record)
+	}
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(cmd.String("audit"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write audit: %w", err)
+	}
+
+	infof("Anonymized %d file(s), %d region(s); audit written to %s", len(jobs)-failed, regions, cmd.String("audit"))
+	if regions == 0 && provider == "yolo" && len(jobs) > failed {
+		warnf("nothing was redacted; the YOLO model needs face or license plate classes (see --classes and IMGX_YOLO_LABELS)")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be anonymized", failed)
+	}
+	return nil
+}
+
+// anonymizeFile detects and redacts the regions of one file
+func anonymizeFile(ctx context.Context, cmd *cli.Command, job convertJob, provider string, opts *detection.DetectOptions, classes []string) (anonymizeRecord, error) {
+	record := anonymizeRecord{Input: job.input, Regions: []Redaction{}}
+	img, err := imgx.Load(job.input, imgx.Options{AutoOrient: true, DisableMetadata: true})
+	if err != nil {
+		return record, err
+	}
+	result, err := detection.Detect(ctx, img.ToNRGBA(), provider, opts)
+	if err != nil {
+		return record, fmt.Errorf("detection failed: %w", err)
+	}
+	if err := reportWarnings(cmd, job.input, result.Warnings); err != nil {
+		return record, err
+	}
+
+	bounds := img.Bounds()
+	record.Regions = FindRedactions(result, bounds.Dx(), bounds.Dy(), classes, cmd.String("text"), float32(cmd.Float64("min-confidence")))
+	mode, _ := imgx.ParseRedactMode(cmd.String("mode"))
+	textMode := mode
+	if cmd.String("text-mode") != "" {
+		textMode, _ = imgx.ParseRedactMode(cmd.String("text-mode"))
+	}
+	rects := make(map[imgx.RedactMode][]image.Rectangle)
+	for i, r := range record.Regions {
+		m := mode
+		if r.Kind == "plate" || r.Kind == "text" {
+			m = textMode
+		}
+		record.Regions[i].Mode = m.String()
+		rects[m] = append(rects[m], r.Rect())
+	}
+	if cmd.Bool("dry-run") {
+		return record, nil
+	}
+
+	// Fill last, so that it is never blurred over
+	for _, m := range []imgx.RedactMode{imgx.RedactBlur, imgx.RedactPixelate, imgx.RedactFill} {
+		if len(rects[m]) == 0 {
+			continue
+		}
+		img = img.Redact(rects[m], imgx.RedactOptions{
+			Mode:      m,
+			Padding:   cmd.Int("padding"),
+			Sigma:     cmd.Float64("sigma"),
+			BlockSize: cmd.Int("block-size"),
+		})
+	}
+	if dir := filepath.Dir(job.output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return record, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if err := saveImage(cmd, img, job.output); err != nil {
+		return record, err
+	}
+	record.Output = job.output
+	return record, nil
+}
+
+// anonymizeProvider resolves the --provider of anonymize, or picks the
+// first configured provider that returns locations
+func anonymizeProvider(name string) (string, error) {
+	if name == "" {
+		configured := detection.ConfiguredProviders()
+		for _, p := range anonymizeProviders {
+			if slices.Contains(configured, p) {
+				return p, nil
+			}
+		}
+		return "", fmt.Errorf("no provider for anonymize configured; set IMGX_YOLO_HOST (local), GOOGLE_VISION_API_KEY or AWS credentials")
+	}
+	resolved := detection.ResolveProviderAlias(name)
+	if resolved == "rekognition" {
+		resolved = "aws"
+	}
+	if !slices.Contains(anonymizeProviders, resolved) {
+		return "", fmt.Errorf("provider %s does not return face or text locations; use yolo, vision or aws", name)
+	}
+	return resolved, nil
+}
+
+// FindRedactions returns the regions of result to redact in a width x height
+// image: faces, text (text is "plates" for plate-like text only, "all" or
+// "none") and bounding boxes whose label is one of classes (ignoring case,
+// spaces and underscores). Regions below minConfidence are skipped; a
+// confidence of 0 means the provider gave none.
+func FindRedactions(result *detection.DetectionResult, width, height int, classes []string, text string, minConfidence float32) []Redaction {
+	regions := []Redaction{}
+	add := func(kind, label string, confidence float32, rect image.Rectangle) {
+		if rect.Empty() || (confidence > 0 && confidence < minConfidence) {
+			return
+		}
+		regions = append(regions, Redaction{
+			Kind:       kind,
+			Label:      label,
+			Confidence: confidence,
+			X:          rect.Min.X,
+			Y:          rect.Min.Y,
+			Width:      rect.Dx(),
+			Height:     rect.Dy(),
+		})
+	}
+
+	for _, f := range result.Faces {
+		if f.BoundingBox != nil {
+			add("face", "", f.Confidence, f.BoundingBox.Rect(width, height))
+		}
+	}
+	if text != "none" {
+		for _, words := range textLines(result.Text, width, height) {
+			plate := make([]bool, len(words))
+			for _, run := range plateRuns(words) {
+				joined := joinWords(words[run[0]:run[1]])
+				add("plate", "", joined.confidence, joined.rect)
+				for i := run[0]; i < run[1]; i++ {
+					plate[i] = true
+				}
+			}
+			if text == "all" {
+				for i, w := range words {
+					if !plate[i] {
+						add("text", "", w.confidence, w.rect)
+					}
+				}
+			}
+		}
+	}
+	wanted := make(map[string]bool)
+	for _, c := range classes {
+		if c = normalizeClass(c); c != "" {
+			wanted[c] = true
+		}
+	}
+	for _, b := range result.BoundingBoxes {
+		if wanted[normalizeClass(b.Label)] {
+			add("object", b.Label, b.Confidence, b.Box.Rect(width, height))
+		}
+	}
+	return regions
+}
+
+// normalizeClass lowercases a class name and drops spaces, dashes and
+// underscores, so "License Plate" matches "license_plate"
+func normalizeClass(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, strings.TrimSpace(s))
+}
+
+// IsPlateLike reports whether text could be a license plate: 4 to 10
+// letters and digits, with at least one of each, ignoring spaces, dashes
+// and dots
+func IsPlateLike(text string) bool {
+	var n, letters, digits int
+	for _, r := range text {
+		switch {
+		case r == ' ' || r == '-' || r == '.' || r == '·':
+		case r >= '0' && r <= '9':
+			digits++
+			n++
+		case unicode.IsLetter(r):
+			letters++
+			n++
+		default:
+			return false
+		}
+	}
+	return n >= 4 && n <= 10 && letters > 0 && digits > 0
+}
+
+// textLine is a word or line of text in image coordinates
+type textLine struct {
+	text       string
+	confidence float32
+	rect       image.Rectangle
+}
+
+// textLines returns the text blocks with a location in pixels as lines of
+// words, left to right: words that follow each other on a line are grouped
+// (Cloud Vision returns plates like "ABC 1234" as two words), other blocks
+// are lines of their own. The full-text block is skipped.
+func textLines(blocks []detection.TextBlock, width, height int) [][]textLine {
+	var lines [][]textLine
+	var words []textLine
+	for _, b := range blocks {
+		if b.BoundingBox == nil || b.Type == "TEXT" {
+			continue
+		}
+		l := textLine{text: b.Text, confidence: b.Confidence, rect: b.BoundingBox.Rect(width, height)}
+		if b.Type == "WORD" {
+			words = append(words, l)
+		} else {
+			lines = append(lines, []textLine{l})
+		}
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return words[i].rect.Min.X < words[j].rect.Min.X
+	})
+	first := len(lines)
+	for _, w := range words {
+		joined := false
+		for i := first; i < len(lines); i++ {
+			last := lines[i][len(lines[i])-1]
+			h := min(last.rect.Dy(), w.rect.Dy())
+			overlap := min(last.rect.Max.Y, w.rect.Max.Y) - max(last.rect.Min.Y, w.rect.Min.Y)
+			if gap := w.rect.Min.X - last.rect.Max.X; overlap*2 > h && gap < h {
+				lines[i] = append(lines[i], w)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			lines = append(lines, []textLine{w})
+		}
+	}
+	return lines
+}
+
+// plateRuns returns the [start, end) ranges of up to 4 consecutive words
+// that read as a license plate, preferring the longest run
+func plateRuns(words []textLine) [][2]int {
+	var runs [][2]int
+	for i := 0; i < len(words); {
+		end := 0
+		for j := i + 1; j <= min(i+4, len(words)); j++ {
+			if IsPlateLike(joinWords(words[i:j]).text) {
+				end = j
+			}
+		}
+		if end == 0 {
+			i++
+			continue
+		}
+		runs = append(runs, [2]int{i, end})
+		i = end
+	}
+	return runs
+}
+
+// joinWords returns words as one line
+func joinWords(words []textLine) textLine {
+	line := words[0]
+	for _, w := range words[1:] {
+		line.text += " " + w.text
+		line.rect = line.rect.Union(w.rect)
+		line.confidence = min(line.confidence, w.confidence)
+	}
+	return line
+}
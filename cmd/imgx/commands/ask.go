@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// AskCommand creates the ask command
+func AskCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "ask",
+		Usage:     "Ask a question about an image and get a typed answer",
+		ArgsUsage: "<input> <question>",
+		Description: `Ask a vision model (Ollama, Gemini or OpenAI) a question about an image. The
+answer is parsed into a typed value with a confidence score: yes/no questions
+give booleans, "how many" questions give numbers, questions with --choices give
+one of the choices and everything else a short string. Use --type to override
+the inferred type.
+
+Examples:
+  imgx ask site.jpg "Is there a person wearing a helmet?"
+  imgx ask crowd.jpg "How many people are in the photo?" --provider gemini
+  imgx ask room.jpg "Where was this taken?" --choices indoor,outdoor --json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider: ollama, gemini, google (alias), openai",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.StringFlag{
+				Name:    "type",
+				Aliases: []string{"t"},
+				Usage:   "answer type: boolean, number, string, enum (default: inferred)",
+				Validator: func(v string) error {
+					switch detection.AnswerType(v) {
+					case detection.AnswerAuto, detection.AnswerBoolean, detection.AnswerNumber, detection.AnswerString, detection.AnswerEnum:
+						return nil
+					}
+					return fmt.Errorf("unknown answer type %q", v)
+				},
+			},
+			&cli.StringFlag{
+				Name:  "choices",
+				Usage: "allowed answers, comma-separated (implies --type enum)",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the answer as JSON",
+			},
+		},
+		Action: askAction,
+	}
+}
+
+func askAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 2 {
+		return fmt.Errorf("input file and question required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	question := strings.Join(cmd.Args().Slice()[1:], " ")
+
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	opts := &detection.AskOptions{Type: detection.AnswerType(cmd.String("type"))}
+	for _, choice := range strings.Split(cmd.String("choices"), ",") {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			opts.Choices = append(opts.Choices, choice)
+		}
+	}
+
+	answer, err := detection.Ask(ctx, img.ToNRGBA(), cmd.String("provider"), question, opts)
+	if err != nil {
+		return err
+	}
+	if err := reportWarnings(cmd, inputPath, answer.Warnings); err != nil {
+		return err
+	}
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(answer, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if answer.Confidence > 0 {
+		fmt.Printf("%s ("+tr("%.1f%% confidence")+")\n", answer, answer.Confidence*100)
+	} else {
+		fmt.Println(answer)
+	}
+	if answer.Explanation != "" {
+		fmt.Printf("  %s\n", answer.Explanation)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// detectBenchCommand creates the detect bench subcommand
+func detectBenchCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark detection providers on the same image",
+		Description: `Runs the same detection several times with each provider and compares latency
+percentiles, success rate and label agreement (Jaccard index of the label sets),
+to choose a default provider empirically. Providers that are not configured are
+skipped with a warning. Each run is a billed API call for cloud providers.
+
+Examples:
+  imgx detect bench --providers gemini,aws,ollama --image test.jpg --runs 5
+  imgx detect bench --image test.jpg --features labels,text --out report.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "providers",
+				Usage: "providers to compare (comma-separated)",
+				Value: "ollama,gemini,openai,aws",
+			},
+			&cli.StringFlag{
+				Name:     "image",
+				Aliases:  []string{"i"},
+				Usage:    "image to detect",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:    "runs",
+				Aliases: []string{"n"},
+				Usage:   "detections per provider",
+				Value:   5,
+				Validator: func(v int) error {
+					if v < 1 {
+						return fmt.Errorf("runs must be at least 1")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:    "features",
+				Aliases: []string{"f"},
+				Usage:   "features to detect (comma-separated)",
+				Value:   "labels",
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "also write the report as JSON to this file",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "print the report as JSON",
+			},
+		},
+		Action: detectBenchAction,
+	}
+}
+
+func detectBenchAction(ctx context.Context, cmd *cli.Command) error {
+	img, err := loadImage(cmd, cmd.String("image"))
+	if err != nil {
+		return err
+	}
+
+	var providers []detection.Provider
+	for _, name := range strings.Split(cmd.String("providers"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		p, err := detection.GetProvider(detection.ResolveProviderAlias(name))
+		if err != nil {
+			warnf("skipping %s: %v", name, err)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return fmt.Errorf("no configured providers to benchmark")
+	}
+
+	opts := detection.DefaultDetectOptions()
+	opts.Features = detection.ParseFeatures(cmd.String("features"))
+	report := detection.Benchmark(ctx, img.ToNRGBA(), providers, &detection.BenchmarkOptions{
+		Runs:   cmd.Int("runs"),
+		Detect: opts,
+	})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if out := cmd.String("out"); out != "" {
+		if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if cmd.Bool("json") {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printBenchmarkReport(report)
+	return nil
+}
+
+// printBenchmarkReport prints a benchmark report as tables
+func printBenchmarkReport(report *detection.BenchmarkReport) {
+	fmt.Printf("=== %s (%dx%d) ===\n\n", tr("Provider Benchmark"), report.ImageWidth, report.ImageHeight)
+	fmt.Printf("%-10s %7s %9s %9s %9s %9s\n", "provider", "success", "p50", "p90", "p99", "max")
+	for _, r := range report.Results {
+		fmt.Printf("%-10s %3d/%-3d %9s %9s %9s %9s\n", r.Provider, r.Successes, r.Runs,
+			formatLatency(r.P50), formatLatency(r.P90), formatLatency(r.P99), formatLatency(r.Max))
+	}
+	for _, r := range report.Results {
+		for _, e := range r.Errors {
+			fmt.Printf("  "+tr("%s error: %s")+"\n", r.Provider, e)
+		}
+	}
+
+	if len(report.Agreement) > 0 {
+		fmt.Println("\n" + tr("Label agreement") + ":")
+		for _, a := range report.Agreement {
+			fmt.Printf("  %-8s vs %-8s %5.1f%%\n", a.A, a.B, a.Agreement*100)
+		}
+	}
+}
+
+// formatLatency formats a latency for the report, "-" when unmeasured
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
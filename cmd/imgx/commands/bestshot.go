@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// BestShotCommand creates the best-shot command
+func BestShotCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "best-shot",
+		Usage:     "Group burst photos and recommend the best shot of each series",
+		ArgsUsage: "<file|dir>...",
+		Description: `Group photos taken within a short time window (EXIF capture time, falling back
+to the file modification time) and score each one with local quality metrics:
+sharpness (variance of the Laplacian) and exposure clipping.
+
+With --faces, each photo is also sent to a detection provider and photos where
+someone has their eyes closed are penalized. Providers that don't report eye
+state (most LLMs without a face schema) simply don't affect the score.
+
+Examples:
+  imgx best-shot ./burst/
+  imgx best-shot ./trip -r --window 5s
+  imgx best-shot ./burst/ --faces --provider aws
+  imgx best-shot ./burst/ --json > picks.json`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.DurationFlag{
+				Name:    "window",
+				Aliases: []string{"w"},
+				Usage:   "maximum time between consecutive shots of a series",
+				Value:   2 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:  "faces",
+				Usage: "penalize closed eyes using face detection (calls the detection provider)",
+			},
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider used with --faces",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "also list single photos that are not part of a series",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output as JSON",
+			},
+		},
+		Action: bestShotAction,
+	}
+}
+
+// shotScore describes one photo of a series
+type shotScore struct {
+	Path       string    `json:"path"`
+	Taken      time.Time `json:"taken"`
+	Sharpness  float64   `json:"sharpness"`
+	Clipped    float64   `json:"clipped"` // fraction of clipped pixels
+	Faces      int       `json:"faces,omitempty"`
+	EyesClosed int       `json:"eyes_closed,omitempty"`
+	Score      float64   `json:"score"`
+}
+
+// shotGroup is a series of photos with its recommended keeper
+type shotGroup struct {
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end"`
+	Keeper string      `json:"keeper"`
+	Shots  []shotScore `json:"shots"`
+}
+
+func bestShotAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	var provider detection.Provider
+	if cmd.Bool("faces") {
+		provider, err = detection.GetProvider(cmd.String("provider"))
+		if err != nil {
+			return err
+		}
+		if !provider.IsConfigured() {
+			return fmt.Errorf("provider %s is not configured", provider.Name())
+		}
+	}
+
+	var shots []shotScore
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		shot, err := scoreShot(ctx, path, provider)
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if cmd.Bool("verbose") {
+			infof("Scored: %s (sharpness %.1f)", path, shot.Sharpness)
+		}
+		shots = append(shots, *shot)
+	}
+
+	groups := groupShots(shots, cmd.Duration("window"))
+	if !cmd.Bool("all") {
+		series := groups[:0]
+		for _, g := range groups {
+			if len(g.Shots) > 1 {
+				series = append(series, g)
+			}
+		}
+		groups = series
+	}
+
+	if cmd.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(groups)
+	}
+
+	for i, g := range groups {
+		infof("Series %d: %d photos, %s - %s", i+1, len(g.Shots),
+			g.Start.Format("2006-01-02 15:04:05"), g.End.Format("15:04:05"))
+		for _, s := range g.Shots {
+			marker := " "
+			if s.Path == g.Keeper {
+				marker = "*"
+			}
+			fmt.Printf("  %s %s  "+tr("score %.2f")+" ("+tr("sharpness %.1f")+", "+tr("clipped %.1f%%"), marker, s.Path, s.Score, s.Sharpness, s.Clipped*100)
+			if s.Faces > 0 {
+				fmt.Printf(", "+tr("eyes closed %d/%d"), s.EyesClosed, s.Faces)
+			}
+			fmt.Println(")")
+		}
+	}
+	fmt.Printf("\n"+tr("%d photos in %d series")+"\n", len(shots), len(groups))
+	return nil
+}
+
+// scoreShot computes the local quality metrics of a photo
+func scoreShot(ctx context.Context, path string, provider detection.Provider) (*shotScore, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+	if err != nil {
+		return nil, err
+	}
+
+	shot := &shotScore{Path: path, Taken: info.ModTime()}
+	if f, err := os.Open(path); err == nil {
+		if exif, err := imgx.ReadEXIF(f); err == nil && !exif.DateTimeOriginal.IsZero() {
+			shot.Taken = exif.DateTimeOriginal
+		}
+		f.Close()
+	}
+
+	shot.Sharpness = img.Sharpness()
+	shadows, highlights := imgx.ExposureClipping(img.ToNRGBA())
+	shot.Clipped = shadows + highlights
+
+	if provider != nil {
+		result, err := provider.Detect(ctx, img.ToNRGBA(), &detection.DetectOptions{
+			Features:      []detection.Feature{detection.FeatureFaces},
+			MaxResults:    20,
+			MinConfidence: 0.5,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("face detection failed: %w", err)
+		}
+		for _, face := range result.Faces {
+			shot.Faces++
+			if face.EyesOpen != nil && !*face.EyesOpen {
+				shot.EyesClosed++
+			}
+		}
+	}
+	return shot, nil
+}
+
+// groupShots splits shots into series of consecutive photos no more than
+// window apart, scores them relative to each other and picks a keeper
+func groupShots(shots []shotScore, window time.Duration) []shotGroup {
+	sort.SliceStable(shots, func(i, j int) bool {
+		return shots[i].Taken.Before(shots[j].Taken)
+	})
+
+	var groups []shotGroup
+	for i := 0; i < len(shots); {
+		j := i + 1
+		for j < len(shots) && shots[j].Taken.Sub(shots[j-1].Taken) <= window {
+			j++
+		}
+		groups = append(groups, rankShots(shots[i:j]))
+		i = j
+	}
+	return groups
+}
+
+// rankShots scores a series: 60% relative sharpness, 20% exposure and 20%
+// open eyes. The highest score becomes the keeper.
+func rankShots(series []shotScore) shotGroup {
+	shots := make([]shotScore, len(series))
+	copy(shots, series)
+
+	maxSharpness := 0.0
+	for _, s := range shots {
+		maxSharpness = max(maxSharpness, s.Sharpness)
+	}
+
+	best := 0
+	for i := range shots {
+		s := &shots[i]
+		sharpness := 1.0
+		if maxSharpness > 0 {
+			sharpness = s.Sharpness / maxSharpness
+		}
+		exposure := max(0, 1-5*s.Clipped)
+		eyes := 1.0
+		if s.Faces > 0 {
+			eyes = 1 - float64(s.EyesClosed)/float64(s.Faces)
+		}
+		s.Score = 0.6*sharpness + 0.2*exposure + 0.2*eyes
+		if s.Score > shots[best].Score {
+			best = i
+		}
+	}
+
+	return shotGroup{
+		Start:  shots[0].Taken,
+		End:    shots[len(shots)-1].Taken,
+		Keeper: shots[best].Path,
+		Shots:  shots,
+	}
+}
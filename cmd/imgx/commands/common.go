@@ -3,7 +3,11 @@ package commands
 import (
 	"fmt"
 	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -84,28 +88,24 @@ func ParseFilter(name string) (imgx.ResampleFilter, error) {
 
 // ParseAnchor converts an anchor name string to imgx.Anchor
 func ParseAnchor(name string) (imgx.Anchor, error) {
-	name = strings.ToLower(name)
-	switch name {
-	case "center", "centre":
-		return imgx.Center, nil
-	case "topleft", "top-left":
-		return imgx.TopLeft, nil
-	case "top":
-		return imgx.Top, nil
-	case "topright", "top-right":
-		return imgx.TopRight, nil
-	case "left":
-		return imgx.Left, nil
-	case "right":
-		return imgx.Right, nil
-	case "bottomleft", "bottom-left":
-		return imgx.BottomLeft, nil
-	case "bottom":
-		return imgx.Bottom, nil
-	case "bottomright", "bottom-right":
-		return imgx.BottomRight, nil
-	default:
-		return imgx.Center, fmt.Errorf("unknown anchor: %s", name)
+	return imgx.ParseAnchor(name)
+}
+
+// validateAnchor is the Validator of --anchor flags, so an unknown anchor
+// is reported before the input is read
+func validateAnchor(name string) error {
+	_, err := ParseAnchor(name)
+	return err
+}
+
+// validatePositive returns the Validator of an int flag that must be
+// positive, such as the dimensions of fit, fill and thumbnail
+func validatePositive(name string) func(int) error {
+	return func(v int) error {
+		if v <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+		return nil
 	}
 }
 
@@ -117,6 +117,22 @@ func GenerateOutputPath(inputPath, suffix string) string {
 	return fmt.Sprintf("%s%s%s", base, suffix, ext)
 }
 
+// ParseSharpen converts a --sharpen value ("auto", "off" or an amount) to
+// resize options
+func ParseSharpen(value string) ([]imgx.ResizeOption, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "off", "none", "0":
+		return nil, nil
+	case "auto":
+		return []imgx.ResizeOption{imgx.WithPostResizeSharpen(imgx.AutoSharpen)}, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 || amount > 5 {
+		return nil, fmt.Errorf("invalid sharpen value %q: use auto, off or an amount between 0 and 5", value)
+	}
+	return []imgx.ResizeOption{imgx.WithPostResizeSharpen(amount)}, nil
+}
+
 // ParseFormat converts a format name to imgx.Format
 func ParseFormat(name string) (imgx.Format, error) {
 	name = strings.ToLower(name)
@@ -138,6 +154,90 @@ func ParseFormat(name string) (imgx.Format, error) {
 	}
 }
 
+// ParseFormatOptions converts format-specific "format.key=value" options to
+// save options for format. Options for other formats are ignored, so the same
+// list can be passed for every output format. Supported keys:
+// jpeg.quality, png.compression (default, none, fast, best), gif.colors,
+// webp.quality, webp.lossless and webp.effort.
+func ParseFormatOptions(format imgx.Format, options []string) ([]imgx.SaveOption, error) {
+	var opts []imgx.SaveOption
+	for _, option := range options {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(option), "=")
+		prefix, name, ok := strings.Cut(strings.ToLower(key), ".")
+		if !ok {
+			return nil, fmt.Errorf("invalid format option %q: expected format.key=value", option)
+		}
+		optFormat, err := ParseFormat(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format option %q: %w", option, err)
+		}
+
+		intValue := func(lo, hi int) (int, error) {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < lo || n > hi {
+				return 0, fmt.Errorf("invalid format option %q: %s must be between %d and %d", option, key, lo, hi)
+			}
+			return n, nil
+		}
+
+		var opt imgx.SaveOption
+		switch optFormat.String() + "." + name {
+		case "JPEG.quality":
+			n, err := intValue(1, 100)
+			if err != nil {
+				return nil, err
+			}
+			opt = imgx.WithJPEGQuality(n)
+		case "PNG.compression":
+			levels := map[string]png.CompressionLevel{
+				"default": png.DefaultCompression,
+				"none":    png.NoCompression,
+				"fast":    png.BestSpeed,
+				"best":    png.BestCompression,
+			}
+			level, ok := levels[strings.ToLower(value)]
+			if !ok {
+				return nil, fmt.Errorf("invalid format option %q: compression must be default, none, fast or best", option)
+			}
+			opt = imgx.WithPNGCompression(level)
+		case "GIF.colors":
+			n, err := intValue(2, 256)
+			if err != nil {
+				return nil, err
+			}
+			opt = imgx.WithGIFNumColors(n)
+		case "WEBP.quality":
+			n, err := intValue(0, 100)
+			if err != nil {
+				return nil, err
+			}
+			opt = imgx.WithWebPQuality(n)
+		case "WEBP.lossless":
+			lossless := true
+			if hasValue {
+				if lossless, err = strconv.ParseBool(value); err != nil {
+					return nil, fmt.Errorf("invalid format option %q: lossless must be true or false", option)
+				}
+			}
+			if lossless {
+				opt = imgx.WithWebPLossless()
+			}
+		case "WEBP.effort":
+			n, err := intValue(0, 6)
+			if err != nil {
+				return nil, err
+			}
+			opt = imgx.WithWebPEffort(n)
+		default:
+			return nil, fmt.Errorf("unknown format option %q", key)
+		}
+		if optFormat == format && opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+	return opts, nil
+}
+
 // FormatName returns the string name of a format
 func FormatName(format imgx.Format) string {
 	return format.String()
@@ -161,3 +261,58 @@ func FormatBytes(bytes int64) string {
 func FormatAspectRatio(width, height int) string {
 	return imgx.FormatAspectRatio(width, height)
 }
+
+// CollectImageFiles expands the given paths into a sorted list of image files.
+// Files are returned as-is; directories are scanned for files with a supported
+// image extension, descending into subdirectories when recursive is true. A
+// file reached twice, e.g. given on its own and through its directory, is
+// listed once.
+func CollectImageFiles(paths []string, recursive bool) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		key, err := filepath.Abs(path)
+		if err != nil {
+			key = filepath.Clean(path)
+		}
+		if !seen[key] {
+			seen[key] = true
+			files = append(files, path)
+		}
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+
+		var dirFiles []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if _, err := imgx.FormatFromFilename(p); err == nil {
+				dirFiles = append(dirFiles, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(dirFiles)
+		for _, p := range dirFiles {
+			add(p)
+		}
+	}
+	return files, nil
+}
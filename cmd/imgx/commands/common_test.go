@@ -1,9 +1,44 @@
 package commands
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode"
 
 	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
 )
 
 func TestParseFormat(t *testing.T) {
@@ -96,6 +131,7 @@ func TestParseAnchor(t *testing.T) {
 		{"topleft", imgx.TopLeft, false},
 		{"top-left", imgx.TopLeft, false},
 		{"bottomright", imgx.BottomRight, false},
+		{"Smart", imgx.Smart, false},
 		{"unknown", imgx.Center, true},
 	}
 
@@ -147,3 +183,1745 @@ func TestChangeExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectImageFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.jpg", "a.png", "notes.txt", "sub/c.webp"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		recursive bool
+		want      []string
+	}{
+		{"flat", false, []string{"a.png", "b.jpg"}},
+		{"recursive", true, []string{"a.png", "b.jpg", "sub/c.webp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CollectImageFiles([]string{dir}, tt.recursive)
+			if err != nil {
+				t.Fatalf("CollectImageFiles() error = %v", err)
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(dir, name))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("CollectImageFiles() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	// Overlapping inputs list each file once
+	b := filepath.Join(dir, "b.jpg")
+	got, err := CollectImageFiles([]string{dir, b, filepath.Join(dir, "sub", "..", "b.jpg"), b}, false)
+	if want := []string{filepath.Join(dir, "a.png"), b}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectImageFiles() with overlapping inputs = %v, %v, want %v", got, err, want)
+	}
+
+	if _, err := CollectImageFiles([]string{filepath.Join(dir, "missing")}, false); err == nil {
+		t.Error("CollectImageFiles() with a missing path should fail")
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.jpg")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors other than a cross-device rename are returned, not copied around
+	dst := filepath.Join(dir, "missing", "a.jpg")
+	if err := moveFile(src, dst); err == nil {
+		t.Error("moveFile() into a missing directory succeeded")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source removed after a failed move: %v", err)
+	}
+
+	dst = filepath.Join(dir, "b.jpg")
+	if err := moveFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "data" {
+		t.Errorf("moved file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists after the move: %v", err)
+	}
+}
+
+func TestCameraName(t *testing.T) {
+	tests := []struct {
+		maker, model, want string
+	}{
+		{"Canon", "Canon EOS R5", "Canon EOS R5"},
+		{"NIKON CORPORATION", "NIKON Z 6", "NIKON CORPORATION NIKON Z 6"},
+		{"FUJIFILM", "X-T4", "FUJIFILM X-T4"},
+		{"Apple", "", "Apple"},
+		{"", "Pixel 8", "Pixel 8"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := cameraName(tt.maker, tt.model); got != tt.want {
+				t.Errorf("cameraName(%q, %q) = %q, want %q", tt.maker, tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{"10,20,100,50", image.Rect(10, 20, 110, 70), false},
+		{" 0, 0, 5, 5 ", image.Rect(0, 0, 5, 5), false},
+		{"10,20,100", image.Rectangle{}, true},
+		{"10,20,0,50", image.Rectangle{}, true},
+		{"a,b,c,d", image.Rectangle{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRegion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRegion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRegion(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResizeSpec(t *testing.T) {
+	tests := []struct {
+		name                       string
+		width, height, long, short string
+		dpi                        float64
+		want                       imgx.ResizeSpec
+		wantErr                    bool
+	}{
+		{"pixels", "800", "", "", "", 0, imgx.ResizeSpec{Width: imgx.Length{Value: 800}}, false},
+		{"zero is unset", "0", "600", "", "", 0, imgx.ResizeSpec{Height: imgx.Length{Value: 600}}, false},
+		{"percent", "50%", "", "", "", 0, imgx.ResizeSpec{Width: imgx.Length{Value: 50, Unit: imgx.Percent}}, false},
+		{"long edge", "", "", "2048", "", 0, imgx.ResizeSpec{LongEdge: imgx.Length{Value: 2048}}, false},
+		{"physical", "10cm", "", "", "", 300, imgx.ResizeSpec{Width: imgx.Length{Value: 10, Unit: imgx.Centimeters}, DPI: 300}, false},
+		{"nothing", "", "", "", "", 0, imgx.ResizeSpec{}, true},
+		{"invalid", "wide", "", "", "", 0, imgx.ResizeSpec{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResizeSpec(tt.width, tt.height, tt.long, tt.short, tt.dpi)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseResizeSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseResizeSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSocialPresets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		check   string
+		want    SocialPreset
+		wantErr bool
+	}{
+		{"built-in", "", "instagram-story", socialPresets["instagram-story"], false},
+		{"custom", write("custom.json", `{"Shop-Banner": {"width": 1920, "height": 600, "format": "webp"}}`),
+			"shop-banner", SocialPreset{Width: 1920, Height: 600, Format: "webp"}, false},
+		{"override", write("override.json", `{"instagram-post": {"aspect": "4:5", "width": 1080}}`),
+			"instagram-post", SocialPreset{Width: 1080, Aspect: "4:5"}, false},
+		{"invalid aspect", write("aspect.json", `{"x": {"aspect": "wide"}}`), "", SocialPreset{}, true},
+		{"empty preset", write("empty.json", `{"x": {"format": "png"}}`), "", SocialPreset{}, true},
+		{"bad json", write("bad.json", `{`), "", SocialPreset{}, true},
+		{"missing file", filepath.Join(dir, "missing.json"), "", SocialPreset{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presets, err := LoadSocialPresets(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSocialPresets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got, ok := presets[tt.check]; !ok || got != tt.want {
+				t.Errorf("LoadSocialPresets()[%q] = %+v, want %+v", tt.check, got, tt.want)
+			}
+			if _, ok := presets["youtube-thumbnail"]; !ok {
+				t.Error("LoadSocialPresets() dropped the built-in presets")
+			}
+		})
+	}
+}
+
+func TestParseSharpen(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantLen int
+		wantErr bool
+	}{
+		{"auto", 1, false},
+		{"AUTO", 1, false},
+		{"off", 0, false},
+		{"", 0, false},
+		{"0", 0, false},
+		{"0.5", 1, false},
+		{"-1", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSharpen(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSharpen(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParseSharpen(%q) returned %d options, want %d", tt.input, len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestParseFormatOptions(t *testing.T) {
+	tests := []struct {
+		format  imgx.Format
+		options []string
+		wantLen int
+		wantErr bool
+	}{
+		{imgx.WEBP, []string{"webp.quality=82", "webp.lossless"}, 2, false},
+		{imgx.WEBP, []string{"webp.lossless=false"}, 0, false},
+		{imgx.WEBP, []string{"webp.effort=6"}, 1, false},
+		{imgx.WEBP, []string{"webp.effort=7"}, 0, true},
+		{imgx.PNG, []string{"webp.lossless", "png.compression=best", "jpg.quality=90"}, 1, false},
+		{imgx.GIF, []string{"gif.colors=64"}, 1, false},
+		{imgx.JPEG, []string{"jpeg.quality=101"}, 0, true},
+		{imgx.PNG, []string{"png.compression=max"}, 0, true},
+		{imgx.PNG, []string{"png.level=3"}, 0, true},
+		{imgx.PNG, []string{"quality=90"}, 0, true},
+		{imgx.PNG, []string{"heic.quality=90"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.options), func(t *testing.T) {
+			got, err := ParseFormatOptions(tt.format, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFormatOptions(%v, %q) error = %v, wantErr %v", tt.format, tt.options, err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParseFormatOptions(%v, %q) returned %d options, want %d", tt.format, tt.options, len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestConvertJobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "sub/b.jpg", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := convertJobs([]string{dir, filepath.Join(dir, "sub", "b.jpg")}, true, "out", imgx.WEBP)
+	if err != nil {
+		t.Fatalf("convertJobs() error = %v", err)
+	}
+	want := []convertJob{
+		{filepath.Join(dir, "a.png"), filepath.Join("out", "a.webp")},
+		{filepath.Join(dir, "sub", "b.jpg"), filepath.Join("out", "sub", "b.webp")},
+		{filepath.Join(dir, "sub", "b.jpg"), filepath.Join("out", "b.webp")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertJobs() = %v, want %v", got, want)
+	}
+
+	got, err = convertJobs([]string{dir}, false, "", imgx.JPEG)
+	if err != nil {
+		t.Fatalf("convertJobs() error = %v", err)
+	}
+	want = []convertJob{{filepath.Join(dir, "a.png"), filepath.Join(dir, "a.jpg")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertJobs() without --out-dir = %v, want %v", got, want)
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    image.Point
+		wantErr bool
+	}{
+		{"5,5", image.Pt(5, 5), false},
+		{" 10 , 20 ", image.Pt(10, 20), false},
+		{"-1,0", image.Pt(-1, 0), false},
+		{"5", image.Point{}, true},
+		{"1,2,3", image.Point{}, true},
+		{"a,b", image.Point{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePoint(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePoint(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParsePoint(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRenameTemplate(t *testing.T) {
+	fields := map[string]string{"date": "2024-05-01", "top_label": "dog", "name": "IMG_0042", "n": "003"}
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"{date}_{top_label}_{n}", "2024-05-01_dog_003", false},
+		{"{top_label}-{name}", "dog-IMG_0042", false},
+		{"plain", "plain", false},
+		{"{date}_{camera}", "", true},
+		{"{top_label}/{name}", "", true},
+		{"..", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := ExpandRenameTemplate(tt.template, fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExpandRenameTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ExpandRenameTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Golden Retriever": "golden-retriever",
+		"  Cat/Dog  ":      "cat-dog",
+		"Café au lait":     "café-au-lait",
+		"---":              "",
+	}
+	for input, want := range tests {
+		if got := Slugify(input); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCleanAltText(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxLength int
+		want      string
+	}{
+		{"plain", "A red bicycle leaning against a brick wall.", 125, "A red bicycle leaning against a brick wall."},
+		{"preamble", "An image of a red bicycle leaning against a wall", 125, "A red bicycle leaning against a wall."},
+		{"quotes and whitespace", "\"a dog   catching\n a frisbee\"", 125, "A dog catching a frisbee."},
+		{"sentence cut", "A dog catches a frisbee in a park. Trees line the path behind it.", 40, "A dog catches a frisbee in a park."},
+		{"word cut", "A golden retriever catching a bright orange frisbee", 30, "A golden retriever catching."},
+		{"empty", "  ", 125, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanAltText(tt.input, tt.maxLength); got != tt.want {
+				t.Errorf("CleanAltText(%q, %d) = %q, want %q", tt.input, tt.maxLength, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{"1024x1024", 1024, 1024, false},
+		{"1920X1080", 1920, 1080, false},
+		{" 512x256 ", 512, 256, false},
+		{"1024", 0, 0, true},
+		{"0x100", 0, 0, true},
+		{"axb", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, h, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if w != tt.wantWidth || h != tt.wantHeight {
+				t.Errorf("ParseSize(%q) = %dx%d, want %dx%d", tt.input, w, h, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestParsePromptVars(t *testing.T) {
+	got, err := ParsePromptVars([]string{"brand=Acme", "tags=red", "blue", "query=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("ParsePromptVars() error: %v", err)
+	}
+	want := map[string]string{"brand": "Acme", "tags": "red,blue", "query": "a=b", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePromptVars() = %v, want %v", got, want)
+	}
+
+	for _, pairs := range [][]string{{"brand"}, {"=Acme"}} {
+		if _, err := ParsePromptVars(pairs); err == nil {
+			t.Errorf("ParsePromptVars(%q) should fail", pairs)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	var hist [256]float64
+	hist[0] = 0.5   // clipped shadows, taller than the interior peak
+	hist[100] = 0.3 // interior peak
+	hist[200] = 0.15
+	hist[201] = 0.05
+
+	got := []rune(Sparkline(hist, 4))
+	want := []rune("██ ▅")
+	if string(got) != string(want) {
+		t.Errorf("Sparkline() = %q, want %q", string(got), string(want))
+	}
+
+	if got := Sparkline([256]float64{}, 8); got != "        " {
+		t.Errorf("Sparkline(empty) = %q, want 8 spaces", got)
+	}
+}
+
+func TestShrinkDownscale(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2880, 1801))
+	testCases := []struct {
+		maxWidth   int
+		screenshot bool
+		want       image.Point
+	}{
+		{maxWidth: 3000, screenshot: true, want: image.Pt(2880, 1801)},
+		{maxWidth: 1440, screenshot: true, want: image.Pt(1440, 901)},
+		{maxWidth: 1200, screenshot: true, want: image.Pt(960, 600)},
+		{maxWidth: 1200, screenshot: false, want: image.Pt(1200, 750)},
+	}
+	for _, tc := range testCases {
+		got := shrinkDownscale(src, tc.maxWidth, tc.screenshot).Bounds().Size()
+		if got != tc.want {
+			t.Errorf("shrinkDownscale(%d, %v) size = %v, want %v", tc.maxWidth, tc.screenshot, got, tc.want)
+		}
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	for _, lang := range []string{"", "en", "C", "en_US.UTF-8"} {
+		c, err := LoadCatalog(lang)
+		if err != nil || len(c) != 0 {
+			t.Errorf("LoadCatalog(%q) = %d messages, %v; want English", lang, len(c), err)
+		}
+	}
+	if _, err := LoadCatalog("xx"); err == nil {
+		t.Error("LoadCatalog(xx) should fail")
+	}
+
+	// Every catalog translates the same messages
+	es, err := LoadCatalog("es_ES.UTF-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range Languages[1:] {
+		c, err := LoadCatalog(lang)
+		if err != nil {
+			t.Fatalf("LoadCatalog(%q) error = %v", lang, err)
+		}
+		for msg := range es {
+			if c[msg] == "" {
+				t.Errorf("%s: missing translation of %q", lang, msg)
+			}
+		}
+		if len(c) != len(es) {
+			t.Errorf("%s has %d messages, want %d", lang, len(c), len(es))
+		}
+	}
+}
+
+// TestCatalogsCoverMessages checks that every message the commands pass to
+// tr, warnf or infof is translated, with the same formatting verbs
+func TestCatalogsCoverMessages(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verbs := regexp.MustCompile(`%[-+# 0-9.\[\]]*[a-zA-Z%]`)
+	fset := token.NewFileSet()
+	msgs := make(map[string]string)
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			fn, ok := call.Fun.(*ast.Ident)
+			if !ok || (fn.Name != "tr" && fn.Name != "warnf" && fn.Name != "infof") {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			msg, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.IndexFunc(verbs.ReplaceAllString(msg, ""), unicode.IsLetter) >= 0 {
+				msgs[msg] = fset.Position(lit.Pos()).String()
+			}
+			return true
+		})
+	}
+
+	for _, lang := range Languages[1:] {
+		c, err := LoadCatalog(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for msg, pos := range msgs {
+			s, ok := c[msg]
+			if !ok {
+				t.Errorf("%s: %s: missing translation of %q", lang, pos, msg)
+				continue
+			}
+			if got, want := verbs.FindAllString(s, -1), verbs.FindAllString(msg, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: translation of %q has verbs %q, want %q", lang, msg, got, want)
+			}
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	defer func(c Catalog) { messages = c }(messages)
+
+	err := fmt.Errorf("failed to open image: %w", &os.PathError{Op: "open", Path: "a.jpg", Err: os.ErrNotExist})
+	messages = nil
+	if got, want := ErrorMessage(err), "Error: failed to open image: open a.jpg: file does not exist"; got != want {
+		t.Errorf("ErrorMessage() = %q, want %q", got, want)
+	}
+
+	messages, _ = LoadCatalog("es")
+	if got, want := ErrorMessage(err), "Error: no se pudo abrir la imagen: open a.jpg: file does not exist"; got != want {
+		t.Errorf("ErrorMessage(es) = %q, want %q", got, want)
+	}
+	if got, want := ErrorMessage(fmt.Errorf("input file required")), "Error: se requiere un archivo de entrada"; got != want {
+		t.Errorf("ErrorMessage(es) = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCompletions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.PNG", "notes.txt", ".hidden.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "photos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		cmd    *cli.Command
+		args   []string
+		images bool
+		want   []string
+	}{
+		{DetectCommand(), []string{"detect", "--provider"}, true, []string{"ollama", "gemini", "google", "aws", "openai", "auto"}},
+		{EditCommand(), []string{"edit", "photo.jpg", "-p"}, true, []string{"gemini", "google", "openai"}},
+		{WatermarkCommand(), []string{"watermark", "--anchor"}, true, anchorNames},
+		{ResizeCommand(), []string{"resize", "--fil"}, true, []string{"--filter"}},
+		{BlurCommand(), []string{"blur", "--s"}, true, []string{"--sigma"}},
+		{BlurCommand(), []string{"blur", "-s"}, true, nil},
+		{BlurCommand(), []string{"blur"}, true, []string{"a.jpg", "b.PNG", "photos/"}},
+		{ProofCommand(), []string{"proof"}, false, []string{"check"}},
+		{CompletionsCommand(), []string{"completions"}, true, []string{"bash", "zsh", "fish"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeCompletions(&buf, tt.cmd, tt.args, tt.images, "/bin/bash")
+		got := strings.Fields(buf.String())
+		if !reflect.DeepEqual(got, tt.want) && (len(got) != 0 || len(tt.want) != 0) {
+			t.Errorf("completions for %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommandDoc(t *testing.T) {
+	doc := parseCommandDoc(&cli.Command{Description: `Resize an image.
+
+  nearest   fastest
+  lanczos   sharpest
+
+Examples:
+  # Halve the width
+  imgx resize a.jpg -w 50%
+
+  imgx resize a.jpg --long-edge 2048`})
+	if want := "Resize an image.\n\n  nearest   fastest\n  lanczos   sharpest"; doc.Description != want {
+		t.Errorf("Description = %q, want %q", doc.Description, want)
+	}
+	want := []string{"# Halve the width", "imgx resize a.jpg -w 50%", "imgx resize a.jpg --long-edge 2048"}
+	if !reflect.DeepEqual(doc.Examples, want) {
+		t.Errorf("Examples = %q, want %q", doc.Examples, want)
+	}
+
+	if doc := parseCommandDoc(&cli.Command{Description: "No examples."}); doc.Description != "No examples." || doc.Examples != nil {
+		t.Errorf("parseCommandDoc(no examples) = %+v", doc)
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	root := &cli.Command{Name: "imgx", Version: "1.0.0"}
+	cmd := &cli.Command{
+		Name:      "blur",
+		Usage:     "Apply Gaussian blur to image",
+		ArgsUsage: "<input>",
+		Description: `.dot-leading line and a \ backslash.
+
+Examples:
+  imgx blur a.jpg -s 2`,
+		Flags: []cli.Flag{
+			&cli.FloatFlag{Name: "sigma", Aliases: []string{"s"}, Usage: "blur strength", Required: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeManPage(&buf, root, cmd, []string{"imgx", "blur"}, "1")
+	page := buf.String()
+	for _, want := range []string{
+		".TH IMGX\\-BLUR 1 \"\" \"imgx 1.0.0\" \"imgx Manual\"\n",
+		".SH NAME\nimgx\\-blur \\- Apply Gaussian blur to image\n",
+		".B imgx blur [options] <input>\n",
+		"\\&.dot\\-leading line and a \\e backslash.\n",
+		".TP\n\\fB\\-\\-sigma\\fR, \\fB\\-s\\fR \\fIfloat\\fR\nblur strength (required)",
+		".SH EXAMPLES\n.RS 4\n.nf\nimgx blur a.jpg \\-s 2\n.fi\n",
+		".SH SEE ALSO\n\\fBimgx\\fR(1)",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("man page does not contain %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.3.2", "1.3.2", 0},
+		{"v1.4.0", "1.3.2", 1},
+		{"1.3.10", "1.3.9", 1},
+		{"1.3", "1.3.1", -1},
+		{"1.4.0-beta.1", "1.4.0", -1},
+		{"1.4.0-beta.2", "1.4.0-beta.10", -1},
+		{"1.4.0-rc.1", "1.4.0-beta.3", 1},
+		{"1.4.0-beta.1", "1.3.2", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInstallRelease(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey := base64.StdEncoding.EncodeToString(pub)
+
+	// A release archive with the new binary
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	newBinary := []byte("#!/bin/sh\necho new\n")
+	tw.WriteHeader(&tar.Header{Name: "imgx", Mode: 0755, Size: int64(len(newBinary)), Typeflag: tar.TypeReg})
+	tw.Write(newBinary)
+	tw.Close()
+	gz.Close()
+
+	name := releaseArchiveName("linux", "amd64")
+	sum := sha256.Sum256(archive.Bytes())
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	manifest := func(tag string, checksums []byte) []byte {
+		sum := sha256.Sum256(checksums)
+		data, _ := json.Marshal(releaseManifest{Tag: tag, Version: strings.TrimPrefix(tag, "v"), Checksums: hex.EncodeToString(sum[:])})
+		return data
+	}
+	files := map[string][]byte{"/" + name: archive.Bytes(), "/checksums.txt": checksums}
+	// Each release directory has a release.json and its signature
+	for dir, m := range map[string][]byte{
+		"/v9.0.0":   manifest("v9.0.0", checksums),
+		"/v0.1.0":   manifest("v0.1.0", checksums),
+		"/replayed": manifest("v1.0.0", checksums),
+		"/altered":  manifest("v9.0.0", []byte("other checksums")),
+	} {
+		files[dir+"/release.json"] = m
+		files[dir+"/release.json.sig"] = ed25519.Sign(priv, m)
+	}
+	files["/bad/release.json"] = files["/v9.0.0/release.json"]
+	files["/bad/release.json.sig"] = ed25519.Sign(priv, []byte("other"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	release := func(tag, dir string) *githubRelease {
+		r := &githubRelease{TagName: tag}
+		for _, a := range []struct{ name, path string }{
+			{name, "/" + name}, {"checksums.txt", "/checksums.txt"},
+			{"release.json", dir + "/release.json"}, {"release.json.sig", dir + "/release.json.sig"},
+		} {
+			r.Assets = append(r.Assets, struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			}{a.name, srv.URL + a.path})
+		}
+		return r
+	}
+
+	exe := filepath.Join(t.TempDir(), "imgx")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := installRelease(ctx, srv.Client(), release("v9.0.0", "/v9.0.0"), "", false, "linux", "amd64", exe); err == nil {
+		t.Error("installRelease() without a signing key should fail")
+	}
+	for _, tt := range []struct {
+		name, tag, dir, want string
+	}{
+		{"bad signature", "v9.0.0", "/bad", "signature"},
+		{"manifest of another release", "v9.0.0", "/replayed", "not v9.0.0"},
+		{"checksums not in the manifest", "v9.0.0", "/altered", "does not match"},
+		{"downgrade", "v0.1.0", "/v0.1.0", "downgrade"},
+	} {
+		err := installRelease(ctx, srv.Client(), release(tt.tag, tt.dir), signingKey, false, "linux", "amd64", exe)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("installRelease(%s) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old" {
+		t.Fatalf("binary replaced despite failed verification: %q", got)
+	}
+	if err := installRelease(ctx, srv.Client(), release("v9.0.0", "/v9.0.0"), signingKey, false, "darwin", "arm64", exe); err == nil {
+		t.Error("installRelease() for a platform without an archive should fail")
+	}
+
+	if err := installRelease(ctx, srv.Client(), release("v9.0.0", "/v9.0.0"), signingKey, false, "linux", "amd64", exe); err != nil {
+		t.Fatalf("installRelease() error = %v", err)
+	}
+	if got, _ := os.ReadFile(exe); !bytes.Equal(got, newBinary) {
+		t.Errorf("binary = %q, want %q", got, newBinary)
+	}
+	if info, _ := os.Stat(exe); info.Mode().Perm()&0111 == 0 {
+		t.Errorf("binary mode = %v, want executable", info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("update left %d files, want 1", len(entries))
+	}
+
+	// --force installs an older release
+	if err := installRelease(ctx, srv.Client(), release("v0.1.0", "/v0.1.0"), signingKey, true, "linux", "amd64", exe); err != nil {
+		t.Errorf("installRelease(downgrade, force) error = %v", err)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, img image.Image, opts ...imgx.EncodeOption) {
+		t.Helper()
+		f, err := imgx.FormatFromFilename(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := imgx.Encode(&buf, img, f, opts...); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	img := func(v uint8) image.Image {
+		return imgx.NewImage(8, 8, color.NRGBA{v, 100, 50, 200}).ToNRGBA()
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"ok.png", "reencoded.png", "converted.png", "modified.png", "missing.png", "sub/nested.png"} {
+		write(name, img(uint8(i*20)))
+	}
+
+	m := &manifest{Version: 1, Algorithm: "sha256", Root: dir}
+	paths, err := CollectImageFiles([]string{dir}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		e, err := hashManifestFile(p, sha256.New)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, _ := filepath.Rel(dir, p)
+		e.Path = filepath.ToSlash(rel)
+		m.Files = append(m.Files, *e)
+	}
+
+	write("reencoded.png", img(20), imgx.PNGCompressionLevel(png.NoCompression))
+	os.Remove(filepath.Join(dir, "converted.png"))
+	write("converted.tiff", img(40))
+	write("modified.png", img(61))
+	os.Remove(filepath.Join(dir, "missing.png"))
+	write("new.png", img(99))
+
+	report, err := verifyManifest(context.Background(), m, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"ok.png":         "ok",
+		"reencoded.png":  "re-encoded",
+		"converted.png":  "re-encoded",
+		"modified.png":   "modified",
+		"missing.png":    "missing",
+		"sub/nested.png": "ok",
+	}
+	for _, r := range report.Files {
+		if r.Status != want[r.Path] {
+			t.Errorf("%s: status = %s, want %s", r.Path, r.Status, want[r.Path])
+		}
+		if r.Path == "converted.png" && r.Found != "converted.tiff" {
+			t.Errorf("converted.png found as %q, want converted.tiff", r.Found)
+		}
+	}
+	if len(report.Files) != len(want) {
+		t.Errorf("verified %d files, want %d", len(report.Files), len(want))
+	}
+	if !reflect.DeepEqual(report.Untracked, []string{"new.png"}) {
+		t.Errorf("untracked = %v, want [new.png]", report.Untracked)
+	}
+}
+
+func TestCompareVR(t *testing.T) {
+	baselines, shots := t.TempDir(), t.TempDir()
+	write := func(dir, name string, img image.Image) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := imgx.FromImage(img).Save(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := func(block color.NRGBA) *image.NRGBA {
+		img := imgx.NewImage(64, 48, color.White).ToNRGBA()
+		for y := 8; y < 24; y++ {
+			for x := 8; x < 56; x++ {
+				img.SetNRGBA(x, y, block)
+			}
+		}
+		return img
+	}
+	blue := color.NRGBA{0, 0, 200, 255}
+
+	for _, name := range []string{"same.png", "noise.png", "changed.png", "resized.png", "missing.png", "sub/nested.png"} {
+		write(baselines, name, page(blue))
+	}
+	for _, name := range []string{"same.png", "sub/nested.png", "new.png"} {
+		write(shots, name, page(blue))
+	}
+	noisy := page(blue)
+	noisy.Pix[0]--
+	write(shots, "noise.png", noisy)
+	write(shots, "changed.png", page(color.NRGBA{200, 0, 0, 255}))
+	write(shots, "resized.png", imgx.NewImage(64, 40, color.White).ToNRGBA())
+
+	report, err := compareVR(context.Background(), baselines, shots, 0.01, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"same.png":       "pass",
+		"noise.png":      "pass",
+		"changed.png":    "fail",
+		"resized.png":    "fail",
+		"missing.png":    "missing",
+		"new.png":        "new",
+		"sub/nested.png": "pass",
+	}
+	for _, r := range report.Results {
+		if r.Status != want[r.Path] {
+			t.Errorf("%s: status = %s (diff %.4f), want %s", r.Path, r.Status, r.Diff, want[r.Path])
+		}
+		if r.Status == "fail" && (r.preview == nil || r.preview.Baseline == "" || r.preview.Current == "") {
+			t.Errorf("%s: no preview for the report", r.Path)
+		}
+	}
+	if len(report.Results) != len(want) {
+		t.Errorf("compared %d images, want %d", len(report.Results), len(want))
+	}
+
+	out := filepath.Join(t.TempDir(), "report.html")
+	if err := writeVRReport(out, report, shots, baselines); err != nil {
+		t.Fatal(err)
+	}
+	html, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "data:image/png;base64,") || !strings.Contains(string(html), "changed.png") {
+		t.Error("report does not show the changed image")
+	}
+
+	// Approving every change and pruning leaves nothing to report
+	approved, pruned, err := approveVR(baselines, shots, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(approved, []string{"changed.png", "new.png", "noise.png", "resized.png"}) {
+		t.Errorf("approved = %v", approved)
+	}
+	if !reflect.DeepEqual(pruned, []string{"missing.png"}) {
+		t.Errorf("pruned = %v, want [missing.png]", pruned)
+	}
+	report, err = compareVR(context.Background(), baselines, shots, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Counts["pass"] != len(report.Results) {
+		t.Errorf("after approve: counts = %v, want all passing", report.Counts)
+	}
+
+	if _, _, err := approveVR(baselines, shots, []string{"../escape.png"}, false); err == nil {
+		t.Error("approving a path outside the directory should fail")
+	}
+}
+
+func FuzzParseColor(f *testing.F) {
+	for _, s := range []string{"ffffff", "#FF0000", "ff000080", "#12345678", "fff", "zzzzzz"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := ParseColor(s)
+		if err != nil {
+			return
+		}
+		hex := fmt.Sprintf("%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+		if again, err := ParseColor(hex); err != nil || again != c {
+			t.Errorf("ParseColor(%q) = %v, but %q parses as %v, %v", s, c, hex, again, err)
+		}
+	})
+}
+
+func FuzzParseRegion(f *testing.F) {
+	for _, s := range []string{"10,20,100,50", " 0, 0, 5, 5 ", "10,20,0,50", "-5,-5,10,10", "9223372036854775807,0,1,1"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		r, err := ParseRegion(s)
+		if err != nil {
+			return
+		}
+		if r.Dx() <= 0 || r.Dy() <= 0 {
+			t.Errorf("ParseRegion(%q) = %v, want a non-empty rectangle", s, r)
+		}
+	})
+}
+
+func TestWriteGallery(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "photo.png")
+	if err := imgx.NewImage(200, 100, color.NRGBA{0, 120, 200, 255}).Save(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src+sidecarSuffix, []byte(`{"provider":"test","labels":[{"name":"sky","confidence":0.5},{"name":"Beach","confidence":0.92}],"safe_search":{"labels":[{"name":"Adult","confidence":0.1,"severity":"VERY_UNLIKELY"}]},"confidence":0.9}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is recorded before StartGallery
+	img, err := imgx.Load(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resized := img.Resize(100, 0, imgx.Lanczos)
+	out := filepath.Join(dir, "photo-small.png")
+	if err := resized.Save(out); err != nil {
+		t.Fatal(err)
+	}
+	recordSave(resized, out, nil)
+
+	StartGallery()
+	recordSave(resized, out, []string{"ICC profile dropped"})
+	report := filepath.Join(dir, "report")
+	if err := WriteGallery(report, []string{"imgx", "resize", src}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(report, "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got galleryReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 {
+		t.Fatalf("report has %d items, want 1", len(got.Items))
+	}
+	item := got.Items[0]
+	if item.Source != src || item.Output != out || item.SourceSize != "200x100" || item.OutputSize != "100x50" {
+		t.Errorf("item = %+v", item)
+	}
+	if item.SourceBytes == 0 || item.OutputBytes == 0 || got.Totals.OutputBytes != item.OutputBytes {
+		t.Errorf("sizes = %d -> %d, totals %+v", item.SourceBytes, item.OutputBytes, got.Totals)
+	}
+	if len(item.Operations) != 1 || !strings.HasPrefix(item.Operations[0], "resize") {
+		t.Errorf("operations = %q, want the resize", item.Operations)
+	}
+	if !reflect.DeepEqual(item.Labels, []string{"Beach (92%)", "sky (50%)"}) {
+		t.Errorf("labels = %q", item.Labels)
+	}
+	if item.SafeSearch != "safe" || item.Flagged {
+		t.Errorf("safe search = %q (flagged %v), want safe", item.SafeSearch, item.Flagged)
+	}
+	for _, thumb := range []string{item.Before, item.After} {
+		if _, err := os.Stat(filepath.Join(report, filepath.FromSlash(thumb))); thumb == "" || err != nil {
+			t.Errorf("thumbnail %q missing: %v", thumb, err)
+		}
+	}
+
+	html, err := os.ReadFile(filepath.Join(report, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"photo-small.png", "Beach (92%)", "ICC profile dropped", item.After, "show original"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("index.html does not contain %q", want)
+		}
+	}
+}
+
+func TestSafeSearchVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  detection.DetectionResult
+		verdict string
+		flagged bool
+	}{
+		{"no ratings", detection.DetectionResult{}, "", false},
+		{"vision unlikely", detection.DetectionResult{SafeSearch: &detection.SafeSearchSummary{Labels: []detection.ModerationLabel{
+			{Name: "Adult", Confidence: 0.1, Severity: "VERY_UNLIKELY"},
+			{Name: "Violence", Confidence: 0.5, Severity: "POSSIBLE"},
+		}}}, "safe", false},
+		{"vision likely", detection.DetectionResult{SafeSearch: &detection.SafeSearchSummary{Labels: []detection.ModerationLabel{
+			{Name: "Adult", Confidence: 0.1, Severity: "VERY_UNLIKELY"},
+			{Name: "Racy", Confidence: 0.7, Severity: "LIKELY"},
+		}}}, "flagged: Racy", true},
+		{"moderation", detection.DetectionResult{
+			Moderation: []detection.ModerationLabel{{Name: "Violence", Confidence: 0.87, Severity: "87.0%"}},
+			SafeSearch: &detection.SafeSearchSummary{Labels: []detection.ModerationLabel{{Name: "Violence", Confidence: 0.87, Severity: "87.0%"}}},
+		}, "flagged: Violence", true},
+		{"unrated confidence", detection.DetectionResult{SafeSearch: &detection.SafeSearchSummary{Labels: []detection.ModerationLabel{
+			{Name: "medical", Confidence: 0.8},
+			{Name: "spoof", Confidence: 0.2},
+		}}}, "flagged: medical", true},
+		{"notes only", detection.DetectionResult{SafeSearch: &detection.SafeSearchSummary{Notes: "nothing unsafe"}}, "safe", false},
+	}
+	for _, tt := range tests {
+		verdict, flagged := safeSearchVerdict(&tt.result)
+		if verdict != tt.verdict || flagged != tt.flagged {
+			t.Errorf("%s: safeSearchVerdict() = %q, %v, want %q, %v", tt.name, verdict, flagged, tt.verdict, tt.flagged)
+		}
+	}
+}
+
+func TestAddSummaryCaption(t *testing.T) {
+	result := &detection.DetectionResult{
+		Labels: []detection.Label{
+			{Name: "sand", Confidence: 0.91},
+			{Name: "sea", Confidence: 0.97},
+			{Name: "sky", Confidence: 0.6},
+		},
+		Moderation: []detection.ModerationLabel{{Name: "Racy"}},
+	}
+	item := imgx.ContactSheetItem{Caption: []string{"beach.jpg"}}
+	addSummaryCaption(&item, result, 2)
+	want := []string{"beach.jpg", "sea (97%), sand (91%)", "flagged: Racy"}
+	if !reflect.DeepEqual(item.Caption, want) {
+		t.Errorf("caption = %q, want %q", item.Caption, want)
+	}
+	if len(item.CaptionColors) != 3 || item.CaptionColors[0] != nil || item.CaptionColors[2] != flaggedColor {
+		t.Errorf("caption colors = %v, want the verdict in red", item.CaptionColors)
+	}
+
+	item = imgx.ContactSheetItem{Caption: []string{"cat.jpg"}}
+	addSummaryCaption(&item, &detection.DetectionResult{Labels: result.Labels}, 0)
+	if !reflect.DeepEqual(item.Caption, []string{"cat.jpg"}) || item.CaptionColors != nil {
+		t.Errorf("caption without labels and ratings = %q, %v", item.Caption, item.CaptionColors)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := map[string][]string{
+		"aws s3 cp {path} s3://bucket/":    {"aws", "s3", "cp", "{path}", "s3://bucket/"},
+		`curl -d '{"file": "{name}"}' url`: {"curl", "-d", `{"file": "{name}"}`, "url"},
+		`echo "a \"b\"" c\ d ''`:           {"echo", `a "b"`, "c d", ""},
+		`printf "a\nb" "c\\d\$"`:           {"printf", `a\nb`, `c\d$`},
+		"  ":                               nil,
+	}
+	for in, want := range tests {
+		got, err := splitCommand(in)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCommand(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := splitCommand(`echo "open`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestPostSaveHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	hook, err := PostSaveHook([]string{`sh -c 'echo "$1" > "$2"' post {stem}-{format}-{size} {path}.done`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook(path, imgx.SaveInfo{Format: "JPEG", Size: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path + ".done"); err != nil || string(data) != "photo-jpeg-42\n" {
+		t.Errorf("post command wrote %q, %v", data, err)
+	}
+
+	hook, _ = PostSaveHook([]string{"sh -c 'exit 3'"})
+	if err := hook(path, imgx.SaveInfo{}); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("failing command error = %v", err)
+	}
+	if _, err := PostSaveHook([]string{""}); err == nil {
+		t.Error("empty command accepted")
+	}
+}
+
+func TestWriteImageFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "photo.png")
+	if err := imgx.NewImage(40, 20, color.White).Save(src); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := imgx.Encode(&buf, imgx.NewImage(20, 10, color.White).ToNRGBA(), imgx.PNG); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+	var got imgx.SaveInfo
+	fail := false
+	remove := imgx.OnSave(func(path string, info imgx.SaveInfo) error {
+		saved = append(saved, path)
+		got = info
+		if fail {
+			return errors.New("upload failed")
+		}
+		return nil
+	})
+	defer remove()
+
+	out := filepath.Join(dir, "photo-min.png")
+	write := &cli.Command{
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "post-policy"},
+			&cli.BoolFlag{Name: "warnings-as-errors"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return writeImageFile(cmd, out, src, buf.Bytes())
+		},
+	}
+	StartGallery()
+	err := write.Run(context.Background(), []string{"write"})
+	if galleryErr := WriteGallery(filepath.Join(dir, "report"), []string{"imgx"}); err == nil {
+		err = galleryErr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0] != out {
+		t.Errorf("hooks ran for %q, want %s", saved, out)
+	}
+	if got.Format != "PNG" || got.Width != 20 || got.Height != 10 || got.Size != int64(buf.Len()) || got.SourcePath != src {
+		t.Errorf("SaveInfo = %+v", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report", "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report galleryReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Items) != 1 || report.Items[0].Output != out || report.Items[0].Source != src || report.Items[0].OutputSize != "20x10" {
+		t.Errorf("gallery items = %+v", report.Items)
+	}
+
+	// A failing hook fails the write unless --post-policy says otherwise
+	fail = true
+	if err := write.Run(context.Background(), []string{"write"}); !errors.Is(err, imgx.ErrHookFailed) {
+		t.Errorf("failing hook: err = %v, want ErrHookFailed", err)
+	}
+	if err := write.Run(context.Background(), []string{"write", "--post-policy", "ignore"}); err != nil {
+		t.Errorf("--post-policy ignore: err = %v", err)
+	}
+}
+
+func TestVOCAnnotationPath(t *testing.T) {
+	tests := []struct {
+		image, dir, want string
+	}{
+		{"photos/street.jpg", "", filepath.Join("photos", "street.xml")},
+		{"photos/street.v2.png", "Annotations", filepath.Join("Annotations", "street.v2.xml")},
+	}
+	for _, tt := range tests {
+		if got := VOCAnnotationPath(tt.image, tt.dir); got != tt.want {
+			t.Errorf("VOCAnnotationPath(%q, %q) = %q, want %q", tt.image, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestObjectCropPath(t *testing.T) {
+	if got, want := ObjectCropPath("crops", "photos/street.jpg", "Traffic Light", 2), filepath.Join("crops", "traffic-light", "street-2.jpg"); got != want {
+		t.Errorf("ObjectCropPath() = %q, want %q", got, want)
+	}
+	if got, want := ObjectCropPath("crops", "a.png", "", 1), filepath.Join("crops", "unlabeled", "a-1.png"); got != want {
+		t.Errorf("ObjectCropPath() = %q, want %q", got, want)
+	}
+}
+
+func TestIsPlateLike(t *testing.T) {
+	for text, want := range map[string]bool{
+		"KA 01 AB 1234": true,
+		"B-MW 123":      true,
+		"7ABC123":       true,
+		"ABC":           false,
+		"2024":          false,
+		"OPEN":          false,
+		"AB1":           false,
+		"ABCDEF 123456": false,
+		"$12.99":        false,
+	} {
+		if got := IsPlateLike(text); got != want {
+			t.Errorf("IsPlateLike(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestFindRedactions(t *testing.T) {
+	result := &detection.DetectionResult{
+		Faces: []detection.Face{{Confidence: 0.9, BoundingBox: &detection.Box{X: 0.1, Y: 0.1, Width: 0.1, Height: 0.2}}},
+		Text: []detection.TextBlock{
+			{Text: "PARKING ABC 1234", Type: "TEXT", BoundingBox: &detection.Box{Width: 1, Height: 1}},
+			// Plate read as two words, next to another word on the same line
+			{Text: "PARKING", Type: "WORD", Confidence: 0.9, BoundingBox: &detection.Box{X: 0.34, Y: 0.5, Width: 0.15, Height: 0.05}},
+			{Text: "1234", Type: "WORD", Confidence: 0.8, BoundingBox: &detection.Box{X: 0.56, Y: 0.5, Width: 0.08, Height: 0.05}},
+			{Text: "ABC", Type: "WORD", Confidence: 0.9, BoundingBox: &detection.Box{X: 0.5, Y: 0.5, Width: 0.05, Height: 0.05}},
+		},
+		BoundingBoxes: []detection.BoundingBox{
+			{Label: "License_Plate", Confidence: 0.7, Box: detection.Box{X: 0.5, Y: 0.8, Width: 0.1, Height: 0.05}},
+			{Label: "car", Confidence: 0.9, Box: detection.Box{X: 0.4, Y: 0.4, Width: 0.4, Height: 0.5}},
+			{Label: "face", Confidence: 0.2, Box: detection.Box{X: 0.7, Y: 0.1, Width: 0.1, Height: 0.1}},
+		},
+	}
+	classes := []string{"face", "license plate"}
+
+	got := FindRedactions(result, 1000, 1000, classes, "plates", 0.3)
+	want := []Redaction{
+		{Kind: "face", Confidence: 0.9, X: 100, Y: 100, Width: 100, Height: 200},
+		{Kind: "plate", Confidence: 0.8, X: 500, Y: 500, Width: 140, Height: 50},
+		{Kind: "object", Label: "License_Plate", Confidence: 0.7, X: 500, Y: 800, Width: 100, Height: 50},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindRedactions(plates) = %+v, want %+v", got, want)
+	}
+
+	got = FindRedactions(result, 1000, 1000, classes, "all", 0.3)
+	if len(got) != 4 || got[2].Kind != "text" || got[2].X != 340 {
+		t.Errorf("FindRedactions(all) = %+v, want the plate and the other word", got)
+	}
+	if got := FindRedactions(result, 1000, 1000, nil, "none", 0.3); len(got) != 1 || got[0].Kind != "face" {
+		t.Errorf("FindRedactions(none) = %+v, want only the face", got)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"IMG_10.jpg", "img_2.jpg", "IMG_9.jpg", "IMG_0001.jpg", "IMG_1b.jpg", "IMG_1a.jpg", "IMG_.jpg"}
+	sort.Slice(names, func(i, j int) bool { return NaturalLess(names[i], names[j]) })
+	want := []string{"IMG_.jpg", "IMG_0001.jpg", "IMG_1a.jpg", "IMG_1b.jpg", "img_2.jpg", "IMG_9.jpg", "IMG_10.jpg"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("natural order = %v, want %v", names, want)
+	}
+}
+
+func TestTimelapseFrames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"f10.png", "f9.png", "f100.png", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	extra := filepath.Join(t.TempDir(), "a1.png")
+	os.WriteFile(extra, nil, 0644)
+
+	frames, err := timelapseFrames([]string{dir, extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range frames {
+		names = append(names, filepath.Base(f))
+	}
+	// Arguments keep their order, directories are sorted naturally
+	if want := []string{"f9.png", "f10.png", "f100.png", "a1.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("timelapseFrames() = %v, want %v", names, want)
+	}
+
+	if got := meanStep([]float64{100, 130, 110}); got != 25 {
+		t.Errorf("meanStep() = %v, want 25", got)
+	}
+}
+
+func TestWriteComparePage(t *testing.T) {
+	before := imgx.New(300, 200, color.NRGBA{200, 40, 40, 255})
+	after := imgx.New(300, 200, color.NRGBA{40, 40, 200, 128})
+	page := &comparePage{
+		Title:  "Grade <v2>",
+		Before: compareSide{Path: "/tmp/a.png", Label: "Before", Size: "300x200", Bytes: 1000},
+		After:  compareSide{Path: "/tmp/b.png", Label: "After", Size: "300x200", Bytes: 800},
+		PSNR:   formatPSNR(comparePSNR(before, after)),
+	}
+	out := filepath.Join(t.TempDir(), "slider.html")
+	if err := writeComparePage(out, page, before, after, 150, 85); err != nil {
+		t.Fatal(err)
+	}
+	if page.Width != 150 || page.Height != 100 {
+		t.Errorf("embedded size = %dx%d, want 150x100", page.Width, page.Height)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+	if n := strings.Count(html, `src="data:image/jpeg;base64,`); n != 2 {
+		t.Errorf("page embeds %d images, want 2", n)
+	}
+	for _, want := range []string{"Grade &lt;v2&gt;", `type="range"`, "a.png", "b.png", "-20.0%"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "ZgotmplZ") || strings.Contains(html, "<script src") {
+		t.Error("page has a filtered URL or an external script")
+	}
+
+	if got := formatPSNR(comparePSNR(before, before)); got != "identical" {
+		t.Errorf("PSNR of identical images = %q", got)
+	}
+	if psnr := comparePSNR(before, after); psnr < 5 || psnr > 20 {
+		t.Errorf("PSNR of different images = %.2f", psnr)
+	}
+}
+
+func TestParsePipeline(t *testing.T) {
+	reg := imgx.NewRegistry()
+	ops, err := ParsePipeline(`
+# Blur the background, keep the subject sharp
+save as $photo; select-color 0,0 10
+save $mask
+load $photo
+apply-mask $mask blur 4; grayscale`, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 6 {
+		t.Fatalf("ParsePipeline() = %d ops, want 6", len(ops))
+	}
+
+	img := imgx.NewImage(8, 8, color.NRGBA{0, 0, 255, 255})
+	got := img.ApplyAll(ops...)
+	if got.Bounds() != img.Bounds() {
+		t.Errorf("bounds = %v", got.Bounds())
+	}
+	if names := reg.Names(); !reflect.DeepEqual(names, []string{"mask", "photo"}) {
+		t.Errorf("named images = %v", names)
+	}
+
+	for _, tt := range []struct{ steps, want string }{
+		{"load $mask", "step 1 (load $mask): $mask is not saved by an earlier step"},
+		{"save as $a; apply-mask $a explode", `step 2 (apply-mask $a explode): unknown step "explode"`},
+		{"save as mask", "step 1 (save as mask): expected save as $name"},
+		{"blur soft", `step 1 (blur soft): invalid value "soft"`},
+		{"grayscale 2", "step 1 (grayscale 2): grayscale takes no arguments"},
+		{" ; # nothing", "pipeline has no steps"},
+		{"fit 0 100", "step 1 (fit 0 100): width and height must be positive integers"},
+		{"crop-center 0 0", "step 1 (crop-center 0 0): width and height must be positive integers"},
+		{"resize 0 0", "step 1 (resize 0 0): width and height must be non-negative integers, not both 0"},
+	} {
+		if _, err := ParsePipeline(tt.steps, imgx.NewRegistry()); err == nil || err.Error() != tt.want {
+			t.Errorf("ParsePipeline(%q) error = %v, want %q", tt.steps, err, tt.want)
+		}
+	}
+
+	// resize keeps the aspect ratio for a 0 dimension
+	if _, err := ParsePipeline("resize 100 0", imgx.NewRegistry()); err != nil {
+		t.Errorf("ParsePipeline(resize 100 0) error = %v", err)
+	}
+}
+
+// jpegWithISO encodes a small JPEG whose EXIF records iso
+func jpegWithISO(t *testing.T, iso uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := imgx.Encode(&buf, imgx.NewImage(64, 48, color.NRGBA{90, 120, 150, 255}).ToNRGBA(), imgx.JPEG); err != nil {
+		t.Fatal(err)
+	}
+	// Little-endian TIFF: IFD0 points to an EXIF IFD holding ISOSpeedRatings
+	le := binary.LittleEndian
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(le.AppendUint16(le.AppendUint16(tiff, 0x8769), 4), 1, 0, 0, 0, 26, 0, 0, 0, 0, 0, 0, 0)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(le.AppendUint16(le.AppendUint16(tiff, 0x8827), 3), 1, 0, 0, 0)
+	tiff = append(le.AppendUint16(tiff, iso), 0, 0, 0, 0, 0, 0)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestPipelineConditions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iso.jpg")
+	if err := os.WriteFile(path, jpegWithISO(t, 3200), 0644); err != nil {
+		t.Fatal(err)
+	}
+	photo, err := imgx.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := false
+	moderate := pipelineModerate
+	pipelineModerate = func(img *imgx.Image, review bool) bool { return flagged }
+	defer func() { pipelineModerate = moderate }()
+
+	for _, tt := range []struct {
+		name, steps string
+		img         *imgx.Image
+		want        int // Width of the result, 0 when skipped
+	}{
+		{"width holds", "if width > 40 then resize 20 0", photo, 20},
+		{"width checked after earlier steps", "resize 20 0; if width > 40 then resize 10 0", photo, 20},
+		{"iso holds", "if ISO > 1600 then denoise 0.4; if iso >= 3200 then resize 32 0", photo, 32},
+		{"iso does not hold", "if iso < 1600 then resize 32 0", photo, 64},
+		{"iso not recorded", "if iso != 1600 then resize 32 0", imgx.NewImage(64, 48, color.White), 64},
+		{"skip", "if iso > 1600 then skip; resize 32 0", photo, 0},
+		{"not flagged", "if safesearch flagged then skip", photo, 64},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParsePipeline(tt.steps, imgx.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := runPipeline(tt.img, ops)
+			if (got == nil) != (tt.want == 0) || (got != nil && got.Bounds().Dx() != tt.want) {
+				t.Errorf("runPipeline(%q) = %v, want width %d", tt.steps, got, tt.want)
+			}
+		})
+	}
+
+	flagged = true
+	ops, err := ParsePipeline("if safesearch flagged then skip", imgx.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runPipeline(photo, ops); got != nil {
+		t.Error("flagged image was not skipped")
+	}
+
+	for _, tt := range []struct{ steps, want string }{
+		{"if iso > 1600 denoise 0.4", "step 1 (if iso > 1600 denoise 0.4): expected if <condition> then <step>"},
+		{"if speed > 3 then skip", `step 1 (if speed > 3 then skip): unknown value "speed" (width, height, iso, exposure, aperture, focal-length, quality)`},
+		{"if iso ~ 3 then skip", `step 1 (if iso ~ 3 then skip): unknown operator "~" (>, >=, <, <=, ==, !=)`},
+		{"if safesearch bad then skip", "step 1 (if safesearch bad then skip): expected safesearch flagged or safesearch blocked"},
+		{"if width > 1 then save as $a", "step 1 (if width > 1 then save as $a): save cannot be conditional"},
+		{"save as $m; apply-mask $m skip", "step 2 (apply-mask $m skip): skip cannot be masked"},
+		{"skip now", "step 1 (skip now): skip takes no arguments"},
+		{"denoise 2", "step 1 (denoise 2): strength must be between 0 and 1"},
+	} {
+		if _, err := ParsePipeline(tt.steps, imgx.NewRegistry()); err == nil || err.Error() != tt.want {
+			t.Errorf("ParsePipeline(%q) error = %v, want %q", tt.steps, err, tt.want)
+		}
+	}
+}
+
+func TestLoadDaemonConfig(t *testing.T) {
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox")
+	if err := os.Mkdir(inbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg, err := LoadDaemonConfig(write("ok.json", fmt.Sprintf(`{"watches": [{"dir": %q, "out_dir": %q, "steps": "resize 100 0"}]}`, inbox, filepath.Join(dir, "out"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Workers != 2 || cfg.pollInterval != 2*time.Second || cfg.Watches[0].Name != "inbox" {
+		t.Errorf("defaults = %+v", cfg)
+	}
+
+	yamlConfig := fmt.Sprintf("workers: 4\nsettle: 0s\nwatches:\n  - dir: %q\n    out_dir: %q\n    steps: fit 1600 1600\n    quality: 82\n", inbox, filepath.Join(dir, "out"))
+	cfg, err = LoadDaemonConfig(write("daemon.yml", yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := cfg.Watches[0]; cfg.Workers != 4 || cfg.settle != 0 || w.Dir != inbox || w.Steps != "fit 1600 1600" || w.Quality != 82 {
+		t.Errorf("YAML config = %+v", cfg)
+	}
+
+	for name, config := range map[string]string{
+		"nested.json":   fmt.Sprintf(`{"watches": [{"dir": %q, "out_dir": %q, "recursive": true}]}`, inbox, filepath.Join(inbox, "out")),
+		"steps.json":    fmt.Sprintf(`{"watches": [{"dir": %q, "out_dir": %q, "steps": "load $x"}]}`, inbox, dir),
+		"interval.json": fmt.Sprintf(`{"poll_interval": "soon", "watches": [{"dir": %q, "out_dir": %q}]}`, inbox, dir),
+		"empty.json":    `{}`,
+		"daemon.yaml":   "watches: []",
+		"broken.yaml":   "watches: [",
+		"types.yaml":    "workers: many",
+	} {
+		if _, err := LoadDaemonConfig(write(name, config)); err == nil {
+			t.Errorf("LoadDaemonConfig(%s) succeeded", name)
+		}
+	}
+}
+
+func TestDaemonScan(t *testing.T) {
+	dir := t.TempDir()
+	inbox, out := filepath.Join(dir, "inbox"), filepath.Join(dir, "out")
+	if err := os.Mkdir(inbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := imgx.NewImage(40, 20, color.White).Save(filepath.Join(inbox, "a.png")); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "daemon.json")
+	data := fmt.Sprintf(`{"settle": "0s", "watches": [{"dir": %q, "out_dir": %q, "steps": "fit 20 20", "format": "jpg"}]}`, inbox, out)
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDaemon(config, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.scan(context.Background())
+	d.wg.Wait()
+	img, err := imgx.Load(filepath.Join(out, "a.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 20 {
+		t.Errorf("output width = %d, want 20", img.Bounds().Dx())
+	}
+	// Up-to-date outputs are not processed again
+	d.scan(context.Background())
+	d.wg.Wait()
+	if h := d.health(); h.Processed != 1 || h.Failed != 0 || h.Status != "ok" {
+		t.Errorf("health = %+v", h)
+	}
+
+	rec := httptest.NewRecorder()
+	d.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"processed":1`) {
+		t.Errorf("/healthz = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestDaemonSaveHooks(t *testing.T) {
+	dir := t.TempDir()
+	inbox, out := filepath.Join(dir, "inbox"), filepath.Join(dir, "out")
+	if err := os.Mkdir(inbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := imgx.NewImage(40, 20, color.White).Save(filepath.Join(inbox, "a.png")); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "daemon.json")
+	data := fmt.Sprintf(`{"settle": "0s", "watches": [{"dir": %q, "out_dir": %q, "steps": "fit 20 20"}]}`, inbox, out)
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDaemon(config, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var saved []string
+	var got imgx.SaveInfo
+	remove := imgx.OnSave(func(path string, info imgx.SaveInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		saved = append(saved, path)
+		got = info
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("hook ran before %s was written: %v", path, err)
+		}
+		return nil
+	})
+	defer remove()
+
+	d.scan(context.Background())
+	d.wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if want := filepath.Join(out, "a.png"); len(saved) != 1 || saved[0] != want {
+		t.Errorf("hooks ran for %q, want only %s", saved, want)
+	}
+	if got.Format != "PNG" || got.Width != 20 || got.Height != 10 || got.Size == 0 {
+		t.Errorf("SaveInfo = %+v", got)
+	}
+}
+
+func TestDaemonSkip(t *testing.T) {
+	dir := t.TempDir()
+	inbox, out := filepath.Join(dir, "inbox"), filepath.Join(dir, "out")
+	if err := os.Mkdir(inbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, w := range map[string]int{"wide.png": 40, "small.png": 20} {
+		if err := imgx.NewImage(w, 20, color.White).Save(filepath.Join(inbox, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := filepath.Join(dir, "daemon.json")
+	data := fmt.Sprintf(`{"settle": "0s", "watches": [{"dir": %q, "out_dir": %q, "steps": "if width > 30 then skip"}]}`, inbox, out)
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDaemon(config, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skipped images have no output and are not processed again
+	for range 2 {
+		d.scan(context.Background())
+		d.wg.Wait()
+	}
+	if _, err := os.Stat(filepath.Join(out, "wide.png")); !os.IsNotExist(err) {
+		t.Errorf("skipped image was written: %v", err)
+	}
+	if h := d.health(); h.Processed != 1 || h.Skipped != 1 || h.Failed != 0 {
+		t.Errorf("health = %+v", h)
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit("/usr/local/bin/imgx", "/etc/imgx/my daemon.json", "imgx", false)
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/imgx daemon run --config "/etc/imgx/my daemon.json"`,
+		"ExecReload=/bin/kill -HUP $MAINPID",
+		"User=imgx",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit does not contain %q:\n%s", want, unit)
+		}
+	}
+	if unit := systemdUnit("/bin/imgx", "/d.json", "", true); !strings.Contains(unit, "WantedBy=default.target") || strings.Contains(unit, "User=") {
+		t.Errorf("user unit:\n%s", unit)
+	}
+}
@@ -0,0 +1,257 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// CompareCommand creates the compare command
+func CompareCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "compare",
+		Usage:     "Compare two images and export a before/after slider page",
+		ArgsUsage: "<before> <after>",
+		Description: `Print the dimensions, file sizes, PSNR and perceptual hash distance of two
+images, typically an original and its processed version.
+
+With --html, a self-contained before/after slider page is written for sharing
+the result: both images are embedded as base64 JPEGs fitted to --max-size, and
+the slider needs no external scripts or files. The global --quality sets the
+JPEG quality of the embedded images (default 85). The second image is scaled to
+the size of the first, so crops and resizes still line up.
+
+Examples:
+  imgx compare original.jpg processed.jpg
+  imgx compare original.jpg processed.jpg --html slider.html
+  imgx compare raw.png graded.png --html review.html --title "Color grade v2" --labels "Raw,Graded"`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "html",
+				Usage: "write a self-contained before/after slider page to this file",
+			},
+			&cli.IntFlag{
+				Name:      "max-size",
+				Usage:     "largest width or height of the embedded images",
+				Value:     1600,
+				Validator: validatePositive("max-size"),
+			},
+			&cli.StringFlag{
+				Name:  "title",
+				Usage: "page title (default: the file names)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "labels",
+				Usage: "captions of the two sides (default: Before,After)",
+				Value: []string{"Before", "After"},
+				Validator: func(v []string) error {
+					if len(v) != 2 {
+						return fmt.Errorf("labels must be two captions, e.g. Before,After")
+					}
+					return nil
+				},
+			},
+		},
+		Action: compareAction,
+	}
+}
+
+// compareSide is one image of a comparison
+type compareSide struct {
+	Path  string
+	Label string
+	Size  string // WIDTHxHEIGHT
+	Bytes int64
+	Data  template.URL // JPEG data URI of the slider page
+}
+
+// comparePage is the content of the slider page
+type comparePage struct {
+	Title         string
+	Before, After compareSide
+	Width, Height int // Of the embedded images
+	PSNR          string
+	HashDistance  int
+}
+
+func compareAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return fmt.Errorf("two images required: <before> <after>")
+	}
+	labels := cmd.StringSlice("labels")
+	sides := []*compareSide{{Path: cmd.Args().Get(0), Label: labels[0]}, {Path: cmd.Args().Get(1), Label: labels[1]}}
+	images := make([]*image.NRGBA, 2)
+	for i, side := range sides {
+		img, err := imgx.Load(side.Path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+		if err != nil {
+			return fmt.Errorf("%s: %w", side.Path, err)
+		}
+		images[i] = img.ToNRGBA()
+		side.Size = fmt.Sprintf("%dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+		if info, err := os.Stat(side.Path); err == nil {
+			side.Bytes = info.Size()
+		}
+	}
+
+	// Compare at the size of the first image
+	before := images[0]
+	after := images[1]
+	if after.Bounds().Size() != before.Bounds().Size() {
+		after = imgx.Resize(after, before.Bounds().Dx(), before.Bounds().Dy(), imgx.Lanczos)
+	}
+	page := &comparePage{
+		Title:        cmd.String("title"),
+		Before:       *sides[0],
+		After:        *sides[1],
+		PSNR:         formatPSNR(comparePSNR(before, after)),
+		HashDistance: imgx.PerceptualHash(before).Distance(imgx.PerceptualHash(after)),
+	}
+	if page.Title == "" {
+		page.Title = filepath.Base(page.Before.Path) + " vs " + filepath.Base(page.After.Path)
+	}
+
+	for _, side := range []compareSide{page.Before, page.After} {
+		fmt.Printf("%s: %s, %s, %s\n", side.Label, side.Path, side.Size, FormatBytes(side.Bytes))
+	}
+	if page.Before.Bytes > 0 {
+		fmt.Printf("%s: %s\n", tr("Size change"), sizeDelta(page.Before.Bytes, page.After.Bytes))
+	}
+	fmt.Printf("PSNR: %s\n", page.PSNR)
+	fmt.Printf("%s: %d/64\n", tr("Perceptual hash distance"), page.HashDistance)
+
+	out := cmd.String("html")
+	if out == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	quality := 85
+	if cmd.IsSet("quality") {
+		quality = cmd.Int("quality")
+	}
+	if err := writeComparePage(out, page, before, after, cmd.Int("max-size"), quality); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n", tr("Slider saved to"), out)
+	return nil
+}
+
+// writeComparePage embeds before and after, fitted to maxSize, in the slider
+// page and writes it to path
+func writeComparePage(path string, page *comparePage, before, after *image.NRGBA, maxSize, quality int) error {
+	for i, img := range []*image.NRGBA{before, after} {
+		img = imgx.Fit(img, maxSize, maxSize, imgx.Lanczos)
+		page.Width, page.Height = img.Bounds().Dx(), img.Bounds().Dy()
+		// Flatten on white, since JPEG has no alpha
+		bg := imgx.New(page.Width, page.Height, color.White)
+		flat := imgx.Overlay(bg, img, image.Point{}, 1)
+		var buf bytes.Buffer
+		if err := imgx.Encode(&buf, flat, imgx.JPEG, imgx.JPEGQuality(quality)); err != nil {
+			return fmt.Errorf("failed to encode image: %w", err)
+		}
+		data := template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+		if i == 0 {
+			page.Before.Data = data
+		} else {
+			page.After.Data = data
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := compareTemplate.Execute(&buf, page); err != nil {
+		return fmt.Errorf("failed to render page: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write page: %w", err)
+	}
+	return nil
+}
+
+// comparePSNR returns the peak signal-to-noise ratio in dB of the RGB
+// channels of two images of the same size, +Inf when they are identical
+func comparePSNR(a, b *image.NRGBA) float64 {
+	var sum float64
+	var n int
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i, j := a.PixOffset(x, y), b.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				d := float64(a.Pix[i+c]) - float64(b.Pix[j+c])
+				sum += d * d
+			}
+			n += 3
+		}
+	}
+	if n == 0 || sum == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/(sum/float64(n)))
+}
+
+// formatPSNR formats a PSNR for display
+func formatPSNR(psnr float64) string {
+	if math.IsInf(psnr, 1) {
+		return "identical"
+	}
+	return fmt.Sprintf("%.2f dB", psnr)
+}
+
+var compareTemplate = template.Must(template.New("compare").Funcs(template.FuncMap{
+	"bytes": FormatBytes,
+	"delta": sizeDelta,
+	"base":  filepath.Base,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+.compare { position: relative; width: 100%; max-width: {{.Width}}px; aspect-ratio: {{.Width}} / {{.Height}}; overflow: hidden; user-select: none; }
+.compare img { position: absolute; inset: 0; width: 100%; height: 100%; display: block; }
+.compare .before { clip-path: inset(0 50% 0 0); }
+.divider { position: absolute; top: 0; bottom: 0; left: 50%; width: 2px; margin-left: -1px; background: #fff; box-shadow: 0 0 4px rgba(0,0,0,0.6); pointer-events: none; }
+.compare input { position: absolute; inset: 0; width: 100%; height: 100%; margin: 0; opacity: 0; cursor: ew-resize; }
+.tag { position: absolute; top: 0.6em; padding: 0.2em 0.6em; border-radius: 3px; background: rgba(0,0,0,0.55); color: #fff; font-size: 0.85em; pointer-events: none; }
+.tag.left { left: 0.6em; } .tag.right { right: 0.6em; }
+.meta { font-size: 0.9em; color: #555; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="compare" id="compare">
+<img class="after" src="{{.After.Data}}" alt="{{.After.Label}}">
+<img class="before" src="{{.Before.Data}}" alt="{{.Before.Label}}">
+<div class="divider"></div>
+<span class="tag left">{{.Before.Label}}</span><span class="tag right">{{.After.Label}}</span>
+<input type="range" min="0" max="100" value="50" step="0.1" aria-label="Slide between {{.Before.Label}} and {{.After.Label}}">
+</div>
+<p class="meta">{{.Before.Label}}: {{base .Before.Path}} &middot; {{.Before.Size}}{{if .Before.Bytes}} &middot; {{bytes .Before.Bytes}}{{end}}<br>
+{{.After.Label}}: {{base .After.Path}} &middot; {{.After.Size}}{{if .After.Bytes}} &middot; {{bytes .After.Bytes}}{{with delta .Before.Bytes .After.Bytes}} ({{.}}){{end}}{{end}}<br>
+PSNR: {{.PSNR}} &middot; perceptual hash distance: {{.HashDistance}}/64</p>
+<script>
+(function () {
+  var c = document.getElementById("compare");
+  var before = c.querySelector(".before"), divider = c.querySelector(".divider");
+  c.querySelector("input").addEventListener("input", function (e) {
+    before.style.clipPath = "inset(0 " + (100 - e.target.value) + "% 0 0)";
+    divider.style.left = e.target.value + "%";
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// completionFlag is the flag urfave/cli appends to ask for completions
+const completionFlag = "--generate-shell-completion"
+
+var (
+	providerNames   = []string{"ollama", "gemini", "google", "aws", "openai"}
+	generatorNames  = []string{"gemini", "google", "openai"}
+	formatNames     = []string{"jpg", "png", "gif", "tiff", "bmp", "webp"}
+	anchorNames     = []string{"center", "top-left", "top", "top-right", "left", "right", "bottom-left", "bottom", "bottom-right", "smart"}
+	filterNames     = []string{"nearest", "box", "linear", "hermite", "mitchellnetravali", "catmullrom", "bspline", "gaussian", "lanczos", "hann", "hamming", "blackman", "bartlett", "welch", "cosine"}
+	formatOptionKey = []string{"jpeg.quality=", "png.compression=default", "png.compression=none", "png.compression=fast", "png.compression=best", "gif.colors=", "webp.quality=", "webp.lossless"}
+)
+
+// flagCompletions suggests the values of flags, by flag name. A
+// "command/flag" key overrides the suggestions for one command. args are
+// the arguments typed so far, for suggestions that depend on other flags.
+var flagCompletions = map[string]func(args []string) []string{
+	"provider":          withRegistered(providerNames...),
+	"detect/provider":   withRegistered(append(slices.Clone(providerNames), "auto")...),
+	"alt-text/provider": fixed("ollama", "gemini", "google", "openai"),
+	"ask/provider":      fixed("ollama", "gemini", "google", "openai"),
+	"edit/provider":     fixed(generatorNames...),
+	"generate/provider": fixed(generatorNames...),
+	"providers":         fixed("ollama", "gemini", "openai", "aws"),
+	"features": fixed(
+		string(detection.FeatureLabels), string(detection.FeatureText), string(detection.FeatureFaces),
+		string(detection.FeatureWeb), string(detection.FeatureDescription), string(detection.FeatureProperties),
+		string(detection.FeatureObjects), string(detection.FeatureLandmarks), string(detection.FeatureLogos),
+		string(detection.FeatureSafeSearch), string(detection.FeatureSynthetic), string(detection.FeatureWatermark),
+	),
+	"format":          fixed(formatNames...),
+	"to":              fixed(formatNames...),
+	"opt":             fixed(formatOptionKey...),
+	"anchor":          fixed(anchorNames...),
+	"filter":          fixed(filterNames...),
+	"rotate/filter":   fixed(append([]string{"bilinear"}, filterNames...)...),
+	"shear/filter":    fixed(append([]string{"bilinear"}, filterNames...)...),
+	"sharpen":         fixed("auto", "off"),
+	"simulate":        fixed("protanopia", "deuteranopia", "tritanopia", "achromatopsia", "all"),
+	"level":           fixed("aa", "aa-large", "aaa"),
+	"type":            fixed(string(detection.AnswerBoolean), string(detection.AnswerNumber), string(detection.AnswerString), string(detection.AnswerEnum)),
+	"channels":        fixed("rgb", "luminance", "all"),
+	"mode":            fixed("bw", "gray", "color"),
+	"tone":            fixed("neutral", "friendly", "formal", "playful"),
+	"preset":          completePresets,
+	"prompt-template": completePromptTemplates,
+	"var":             completePromptVars,
+}
+
+// argCompletions suggests the positional arguments of commands that don't
+// take images, by command name
+var argCompletions = map[string]func(args []string) []string{
+	"completions": fixed("bash", "zsh", "fish"),
+	"prompts":     completePromptTemplates,
+}
+
+func fixed(values ...string) func([]string) []string {
+	return func([]string) []string { return values }
+}
+
+// withRegistered suggests values and the providers added with
+// detection.RegisterProvider
+func withRegistered(values ...string) func([]string) []string {
+	return func([]string) []string {
+		return append(slices.Clone(values), detection.RegisteredProviders()...)
+	}
+}
+
+// EnableCompletion installs the imgx completion on app and all of its
+// subcommands. Besides subcommands and flags it suggests flag values
+// (providers, features, presets, anchors, format options, ...) and, for
+// commands that run on their own, the image files in the current directory.
+func EnableCompletion(app *cli.Command) {
+	// urfave/cli gives commands without an action a default one later
+	images := app.Action != nil
+	app.ShellComplete = func(ctx context.Context, cmd *cli.Command) {
+		args := os.Args[1:]
+		if n := len(args); n > 0 && args[n-1] == completionFlag {
+			args = args[:n-1]
+		}
+		writeCompletions(cmd.Root().Writer, cmd, args, images, os.Getenv("SHELL"))
+	}
+	for _, sub := range app.Commands {
+		EnableCompletion(sub)
+	}
+}
+
+// writeCompletions writes the completions for the word after args, one per
+// line, for cmd; images adds the image files in the current directory
+func writeCompletions(w io.Writer, cmd *cli.Command, args []string, images bool, shell string) {
+	last := ""
+	if len(args) > 0 {
+		last = args[len(args)-1]
+	}
+	if last == "--" {
+		return
+	}
+
+	if f := lookupFlag(cmd, last); f != nil {
+		if !takesValue(f) {
+			last = ""
+		} else {
+			complete := flagCompletions[cmd.Name+"/"+f.Names()[0]]
+			if complete == nil {
+				complete = flagCompletions[f.Names()[0]]
+			}
+			if complete != nil {
+				for _, v := range complete(args) {
+					fmt.Fprintln(w, v)
+				}
+			}
+			// No suggestions leaves file names to the shell
+			return
+		}
+	}
+
+	if strings.HasPrefix(last, "-") {
+		writeFlagCompletions(w, cmd, last, strings.HasSuffix(shell, "zsh"))
+		return
+	}
+
+	for _, sub := range cmd.VisibleCommands() {
+		fmt.Fprintln(w, sub.Name)
+	}
+	if complete := argCompletions[cmd.Name]; complete != nil {
+		for _, v := range complete(args) {
+			fmt.Fprintln(w, v)
+		}
+	} else if images {
+		for _, name := range imageFiles(".") {
+			fmt.Fprintln(w, name)
+		}
+	}
+}
+
+// writeFlagCompletions writes the flags of cmd and its parents that start
+// with prefix; zsh shows the usage next to each
+func writeFlagCompletions(w io.Writer, cmd *cli.Command, prefix string, zsh bool) {
+	seen := make(map[string]bool)
+	for _, c := range cmd.Lineage() {
+		for _, f := range c.VisibleFlags() {
+			usage := ""
+			if df, ok := f.(cli.DocGenerationFlag); ok {
+				usage = df.GetUsage()
+			}
+			for _, name := range f.Names() {
+				dashed := dashedFlag(name)
+				if seen[dashed] || !strings.HasPrefix(dashed, prefix) || dashed == prefix {
+					continue
+				}
+				seen[dashed] = true
+				if zsh && usage != "" {
+					dashed += ":" + strings.ReplaceAll(usage, ":", `\:`)
+				}
+				fmt.Fprintln(w, dashed)
+			}
+		}
+	}
+}
+
+// lookupFlag returns the flag of cmd or its parents named by arg ("-q",
+// "--quality"), or nil
+func lookupFlag(cmd *cli.Command, arg string) cli.Flag {
+	for _, c := range cmd.Lineage() {
+		for _, f := range c.Flags {
+			for _, name := range f.Names() {
+				if dashedFlag(name) == arg {
+					return f
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dashedFlag returns name as typed: -n for single letters, --name otherwise
+func dashedFlag(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// takesValue reports whether f is followed by a value; boolean flags are not
+func takesValue(f cli.Flag) bool {
+	if df, ok := f.(cli.DocGenerationFlag); ok {
+		return df.TakesValue()
+	}
+	return true
+}
+
+// flagArg returns the value of --name in args, or ""
+func flagArg(args []string, name string) string {
+	for i, a := range args {
+		if a == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v, ok := strings.CutPrefix(a, "--"+name+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// completePresets suggests the social presets, including the custom ones
+// from --presets or the presets file
+func completePresets(args []string) []string {
+	presets, err := LoadSocialPresets(presetsPath(flagArg(args, "presets")))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completePromptTemplates suggests the names of the prompt templates
+func completePromptTemplates([]string) []string {
+	templates, err := detection.ListPromptTemplates()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// completePromptVars suggests "name=" for the variables of the template
+// given with --prompt-template
+func completePromptVars(args []string) []string {
+	name := flagArg(args, "prompt-template")
+	if name == "" {
+		return nil
+	}
+	t, err := detection.LoadPromptTemplate(name)
+	if err != nil {
+		return nil
+	}
+	var vars []string
+	for _, v := range t.Variables() {
+		vars = append(vars, v+"=")
+	}
+	return vars
+}
+
+// imageFiles returns the image files in dir and its subdirectories (with a
+// trailing slash), skipping hidden entries
+func imageFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+		case e.IsDir():
+			names = append(names, name+"/")
+		default:
+			if _, err := imgx.FormatFromFilename(name); err == nil {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
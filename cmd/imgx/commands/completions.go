@@ -3,6 +3,8 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/urfave/cli/v3"
 )
@@ -11,12 +13,12 @@ const bashSetup = `# Bash completion setup for imgx
 # Add this to your ~/.bashrc or ~/.bash_profile:
 
 # Option 1: Source directly
-PROG=imgx source <(imgx --generate-shell-completion)
+source <(imgx completion bash)
 
 # Option 2: Save to file (recommended)
-imgx --generate-shell-completion > /etc/bash_completion.d/imgx
+imgx completion bash > /etc/bash_completion.d/imgx
 # or for user-only:
-imgx --generate-shell-completion > ~/.local/share/bash-completion/completions/imgx
+imgx completion bash > ~/.local/share/bash-completion/completions/imgx
 `
 
 const zshSetup = `# Zsh completion setup for imgx
@@ -24,7 +26,7 @@ const zshSetup = `# Zsh completion setup for imgx
 
 # Option 1: Source directly
 autoload -U compinit; compinit
-PROG=imgx source <(imgx --generate-shell-completion)
+source <(imgx completion zsh)
 
 # Option 2: Save to file (recommended)
 # First, ensure completion directory is in fpath
@@ -33,14 +35,14 @@ fpath=(~/.zsh/completion $fpath)
 autoload -U compinit; compinit
 
 # Then save completion file:
-imgx --generate-shell-completion > ~/.zsh/completion/_imgx
+imgx completion zsh > ~/.zsh/completion/_imgx
 `
 
 const fishSetup = `# Fish completion setup for imgx
 # Fish completion is automatic once the file is in the right place.
 
 # Save completion file:
-imgx --generate-shell-completion > ~/.config/fish/completions/imgx.fish
+imgx completion fish > ~/.config/fish/completions/imgx.fish
 
 # Reload completions (optional):
 fish_update_completions
@@ -53,8 +55,10 @@ func CompletionsCommand() *cli.Command {
 		ArgsUsage: "[SHELL]",
 		Description: `Display instructions for setting up shell completions for imgx.
 
-imgx uses urfave/cli's built-in completion system. Use the --generate-shell-completion
-flag to generate completions dynamically.
+imgx uses urfave/cli's built-in completion system; "imgx completion <shell>"
+prints the completion script. Besides commands and flags, bash and zsh
+complete flag values (providers, features, presets, anchors, filters, format
+options, prompt templates) and image files.
 
 Supported shells: bash, zsh, fish
 
@@ -66,7 +70,7 @@ Examples:
   imgx completions bash
 
   # Generate completion script
-  imgx --generate-shell-completion`,
+  imgx completion bash`,
 		Action: completionsAction,
 	}
 }
@@ -79,10 +83,12 @@ func completionsAction(ctx context.Context, cmd *cli.Command) error {
 
 	if shell == "" {
 		// Show all shells
-		fmt.Println("Shell Completion Setup for imgx")
-		fmt.Println("================================")
+		title := tr("Shell Completion Setup for imgx")
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("=", utf8.RuneCountInString(title)))
 		fmt.Println()
-		fmt.Println("imgx supports dynamic shell completions using the --generate-shell-completion flag.")
+		fmt.Println(tr("imgx supports dynamic shell completions: commands, flags, flag values and image files."))
+		fmt.Println(tr("Run 'imgx completions <bash|zsh|fish>' for setup instructions, or load the\nscript printed by 'imgx completion <bash|zsh|fish|pwsh>'."))
 
 		return nil
 	}
@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// contactSheetDetectSize bounds the images sent to the provider; the
+// labels and safe search verdict don't need more
+const contactSheetDetectSize = 1024
+
+// contactSheetChunk is the number of images loaded for detection at once
+const contactSheetChunk = 16
+
+// flaggedColor is the caption color of a flagged safe search verdict
+var flaggedColor = color.NRGBA{200, 0, 0, 255}
+
+// ContactSheetCommand creates the contact-sheet command
+func ContactSheetCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "contact-sheet",
+		Usage:     "Lay out thumbnails of many images on one sheet for review",
+		ArgsUsage: "<file|dir>...",
+		Description: `Lay out thumbnails of the images in a grid, each captioned with its file name,
+and save the sheet as one image.
+
+With --summary, the top labels and the safe search verdict ("safe" or the
+flagged categories, in red) are printed beneath each thumbnail, so a large
+ingest can be reviewed at a glance. Detection results are read from the
+sidecar files written by "imgx rename --sidecar" (photo.jpg.detect.json);
+images without one are sent to --provider in parallel. Use --sidecar to save
+the new results for later runs.
+
+Examples:
+  imgx contact-sheet ./shoot -o sheet.jpg
+  imgx contact-sheet ./ingest -r --summary --provider vision -o review.png
+  imgx contact-sheet ./ingest -r --summary --columns 8 --thumb-size 160 --sidecar -o review.png`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "output file",
+				Value:   "contact-sheet.png",
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.IntFlag{
+				Name:  "columns",
+				Usage: "thumbnails per row",
+				Value: 5,
+				Validator: func(v int) error {
+					if v < 1 {
+						return fmt.Errorf("columns must be at least 1")
+					}
+					return nil
+				},
+			},
+			&cli.IntFlag{
+				Name:  "thumb-size",
+				Usage: "size of the square each thumbnail is fitted in, in pixels",
+				Value: 200,
+				Validator: func(v int) error {
+					if v < 32 {
+						return fmt.Errorf("thumb-size must be at least 32")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "summary",
+				Usage: "print the top labels and the safe search verdict beneath each thumbnail",
+			},
+			&cli.IntFlag{
+				Name:  "labels",
+				Usage: "number of labels printed with --summary",
+				Value: 3,
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("labels must not be negative")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider for images without a sidecar: ollama, gemini, google (alias), aws, openai, vision, or a fallback list",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "images sent to the provider at once",
+				Value: 4,
+				Validator: func(v int) error {
+					if v < 1 {
+						return fmt.Errorf("concurrency must be at least 1")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "sidecar",
+				Usage: "save new detection results to sidecar files for later runs",
+			},
+		},
+		Action: contactSheetAction,
+	}
+}
+
+func contactSheetAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no images found")
+	}
+
+	thumbSize := cmd.Int("thumb-size")
+	items := make([]imgx.ContactSheetItem, len(paths))
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		items[i].Caption = []string{filepath.Base(path)}
+		img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+		if err != nil {
+			warnf("%s: %v", path, err)
+			items[i].Caption = append(items[i].Caption, "unreadable")
+			items[i].CaptionColors = []color.Color{nil, flaggedColor}
+			continue
+		}
+		items[i].Image = img.Fit(thumbSize, thumbSize, imgx.Lanczos).ToNRGBA()
+	}
+
+	if cmd.Bool("summary") {
+		results, err := contactSheetDetections(ctx, cmd, paths, items)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			if result != nil {
+				addSummaryCaption(&items[i], result, cmd.Int("labels"))
+			}
+		}
+	}
+
+	sheet := imgx.ContactSheet(items, imgx.ContactSheetOptions{
+		Columns:   cmd.Int("columns"),
+		ThumbSize: thumbSize,
+	})
+	output := cmd.String("output")
+	if err := saveImage(cmd, imgx.FromImage(sheet), output); err != nil {
+		return err
+	}
+	infof("Contact sheet of %d images saved to: %s", len(paths), output)
+	return nil
+}
+
+// contactSheetDetections returns the detection result of each path: its
+// sidecar, or the result of a batch run for the images without one. Images
+// that failed to load or to be detected have no result.
+func contactSheetDetections(ctx context.Context, cmd *cli.Command, paths []string, items []imgx.ContactSheetItem) ([]*detection.DetectionResult, error) {
+	results := make([]*detection.DetectionResult, len(paths))
+	var missing []int
+	for i, path := range paths {
+		if items[i].Image == nil {
+			continue
+		}
+		if results[i] = readDetectionSidecar(path); results[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	opts := &detection.DetectOptions{
+		Features:   []detection.Feature{detection.FeatureLabels, detection.FeatureSafeSearch},
+		MaxResults: 10,
+	}
+	batch := detection.BatchOptions{Concurrency: cmd.Int("concurrency")}
+	for start := 0; start < len(missing); start += contactSheetChunk {
+		chunk := missing[start:min(start+contactSheetChunk, len(missing))]
+		var imgs []*image.NRGBA
+		var indexes []int
+		for _, i := range chunk {
+			img, err := imgx.Load(paths[i], imgx.Options{AutoOrient: true, DisableMetadata: true})
+			if err != nil {
+				warnf("%s: %v", paths[i], err)
+				continue
+			}
+			imgs = append(imgs, img.Fit(contactSheetDetectSize, contactSheetDetectSize, imgx.Lanczos).ToNRGBA())
+			indexes = append(indexes, i)
+		}
+
+		batchResults, err := detection.DetectBatch(ctx, imgs, cmd.String("provider"), opts, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range batchResults {
+			path := paths[indexes[r.Index]]
+			if r.Err != nil {
+				warnf("%s: detection failed: %v", path, r.Err)
+				continue
+			}
+			results[indexes[r.Index]] = r.Result
+			if cmd.Bool("sidecar") {
+				if err := writeSidecar(path, r.Result); err != nil {
+					warnf("failed to write sidecar for %s: %v", path, err)
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// addSummaryCaption adds the top n labels of result and its safe search
+// verdict to the caption of item
+func addSummaryCaption(item *imgx.ContactSheetItem, result *detection.DetectionResult, n int) {
+	if labels := galleryLabels(result); len(labels) > 0 && n > 0 {
+		item.Caption = append(item.Caption, strings.Join(labels[:min(n, len(labels))], ", "))
+	}
+	verdict, flagged := safeSearchVerdict(result)
+	if verdict == "" {
+		return
+	}
+	item.Caption = append(item.Caption, verdict)
+	if flagged {
+		for len(item.CaptionColors) < len(item.Caption)-1 {
+			item.CaptionColors = append(item.CaptionColors, nil)
+		}
+		item.CaptionColors = append(item.CaptionColors, flaggedColor)
+	}
+}
+
+// safeSearchVerdict summarizes the safe search ratings of result as "safe"
+// or "flagged: " and the flagged categories. It returns "" when result has
+// no safe search ratings. Moderation labels are flagged, as are safe search
+// labels rated likely (Cloud Vision, LLM providers) or with a confidence of
+// at least 50% when unrated.
+func safeSearchVerdict(result *detection.DetectionResult) (string, bool) {
+	if result.SafeSearch == nil && len(result.Moderation) == 0 {
+		return "", false
+	}
+	var flagged []string
+	seen := make(map[string]bool)
+	flag := func(name string) {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			flagged = append(flagged, name)
+		}
+	}
+	for _, l := range result.Moderation {
+		flag(l.Name)
+	}
+	if result.SafeSearch != nil {
+		for _, l := range result.SafeSearch.Labels {
+			switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(l.Severity), " ", "_")) {
+			case "likely", "very_likely", "high", "very_high", "yes", "true":
+				flag(l.Name)
+			case "", "unknown":
+				if l.Confidence >= 0.5 {
+					flag(l.Name)
+				}
+			}
+		}
+	}
+	if len(flagged) == 0 {
+		return "safe", false
+	}
+	return "flagged: " + strings.Join(flagged, ", "), true
+}
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// ConvertCommand creates the convert command
+func ConvertCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "convert",
+		Usage:     "Convert images to another format",
+		ArgsUsage: "<file|dir>...",
+		Description: `Convert images to the format given with --to. Directories are scanned for
+images (recursively with -r). Converted files are written next to the source,
+or to --out-dir, where the directory structure below each input directory is
+kept. Photoshop files (.psd, .psb) are read as their flattened composite.
+
+Conversion is incremental: a file is skipped when its output already exists
+and is at least as new as the source. Use --force to convert it anyway.
+
+--quality applies to JPEG and WebP output. Format-specific encoder options are
+passed with --opt format.key=value (repeatable); options for other formats are
+ignored, so one command line can serve every target format:
+  jpeg.quality=<1-100>
+  png.compression=<default|none|fast|best>
+  gif.colors=<2-256>
+  webp.quality=<0-100>
+  webp.lossless[=true|false]
+  webp.effort=<0-6>
+
+--adaptive classifies each image as a photo, illustration, screenshot or text
+document and picks its settings: a lower JPEG/WebP quality for photos, a higher
+one for illustrations, lossless WebP for screenshots and documents, the best
+PNG compression for everything but photos. --quality and --opt still win.
+
+Examples:
+  imgx convert photo.png --to jpg
+  imgx convert scan.tif -o scan.png --to png
+  imgx convert mockup.psd --to png
+  imgx convert ./photos --to webp --quality 82 --out-dir ./webp -r
+  imgx convert ./icons --to webp --opt webp.lossless --out-dir ./web
+  imgx convert ./archive --to webp --quality 90 --opt webp.effort=6 -r
+  imgx convert ./photos --to png --opt png.compression=best -r --force
+  imgx convert ./uploads --to webp --adaptive --out-dir ./web -r`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "to",
+				Aliases:  []string{"t"},
+				Usage:    "output format (jpg, png, gif, tiff, bmp, webp)",
+				Required: true,
+				Validator: func(v string) error {
+					_, err := ParseFormat(v)
+					return err
+				},
+			},
+			&cli.StringFlag{
+				Name:  "out-dir",
+				Usage: "directory to write converted files to (default: next to the source)",
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "convert directories recursively",
+			},
+			&cli.StringSliceFlag{
+				Name:  "opt",
+				Usage: "format-specific encoder option as format.key=value (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "adaptive",
+				Usage: "pick the encoder settings of each image by its content (photo, illustration, screenshot, text document)",
+			},
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f"},
+				Usage:   "convert even when the output is newer than the source",
+			},
+		},
+		Action: convertAction,
+	}
+}
+
+// convertJob is one file to convert and where to write it
+type convertJob struct {
+	input  string
+	output string
+}
+
+func convertAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+
+	format, err := ParseFormat(cmd.String("to"))
+	if err != nil {
+		return err
+	}
+	opts, err := ParseFormatOptions(format, cmd.StringSlice("opt"))
+	if err != nil {
+		return err
+	}
+	if format == imgx.WEBP && cmd.IsSet("quality") {
+		// Format options come last, so webp.quality still wins
+		opts = append([]imgx.SaveOption{imgx.WithWebPQuality(cmd.Int("quality"))}, opts...)
+	}
+
+	jobs, err := convertJobs(cmd.Args().Slice(), cmd.Bool("recursive"), cmd.String("out-dir"), format)
+	if err != nil {
+		return err
+	}
+	if output := cmd.String("output"); output != "" {
+		if len(jobs) != 1 {
+			return fmt.Errorf("--output can only be used with a single input file")
+		}
+		jobs[0].output = output
+	}
+
+	var converted, upToDate, failed int
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !cmd.Bool("force") && isUpToDate(job.input, job.output) {
+			upToDate++
+			if cmd.Bool("verbose") {
+				fmt.Printf("%s: %s\n", tr("Up to date"), job.output)
+			}
+			continue
+		}
+
+		if err := convertFile(cmd, job, format, opts); err != nil {
+			warnf("%s: %v", job.input, err)
+			failed++
+			continue
+		}
+		converted++
+		fmt.Printf("%s -> %s\n", job.input, job.output)
+	}
+
+	fmt.Printf(tr("Converted %d file(s), %d up to date"), converted, upToDate)
+	if failed > 0 {
+		fmt.Printf(", "+tr("%d failed")+"\n", failed)
+		return fmt.Errorf("%d file(s) could not be converted", failed)
+	}
+	fmt.Println()
+	return nil
+}
+
+// convertFile converts one file
+func convertFile(cmd *cli.Command, job convertJob, format imgx.Format, opts []imgx.SaveOption) error {
+	img, err := loadImage(cmd, job.input)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("adaptive") {
+		class := img.ClassifyContent().Class
+		adaptive := imgx.SaveOptionsFor(class, format)
+		if format == imgx.JPEG && cmd.IsSet("quality") {
+			adaptive = append(adaptive, imgx.WithJPEGQuality(cmd.Int("quality")))
+		}
+		opts = append(adaptive, opts...)
+		if cmd.Bool("verbose") {
+			fmt.Printf("%s: %s\n", tr("Content"), class)
+		}
+	}
+	if dir := filepath.Dir(job.output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return saveImageAs(cmd, img, job.output, format.String(), cmd.Int("quality"), opts...)
+}
+
+// convertJobs expands paths into the files to convert and their output paths
+// in format. With outDir, files found in a directory argument keep their path
+// relative to it and single files are written to outDir directly.
+func convertJobs(paths []string, recursive bool, outDir string, format imgx.Format) ([]convertJob, error) {
+	jobs, err := outputJobs(paths, recursive, outDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		jobs[i].output = changeExtension(jobs[i].output, format)
+	}
+	return jobs, nil
+}
+
+// outputJobs expands paths into image files and their output paths: the
+// file itself without outDir, else its path relative to its directory
+// argument (or its name for file arguments) inside outDir
+func outputJobs(paths []string, recursive bool, outDir string) ([]convertJob, error) {
+	var jobs []convertJob
+	for _, path := range paths {
+		files, err := CollectImageFiles([]string{path}, recursive)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			output := file
+			if outDir != "" {
+				rel := filepath.Base(file)
+				if info.IsDir() {
+					if rel, err = filepath.Rel(path, file); err != nil {
+						return nil, err
+					}
+				}
+				output = filepath.Join(outDir, rel)
+			}
+			jobs = append(jobs, convertJob{input: file, output: output})
+		}
+	}
+	return jobs, nil
+}
+
+// isUpToDate reports whether output exists and is at least as new as input
+func isUpToDate(input, output string) bool {
+	in, err := os.Stat(input)
+	if err != nil {
+		return false
+	}
+	out, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return !out.ModTime().Before(in.ModTime())
+}
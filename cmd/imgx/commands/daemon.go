@@ -0,0 +1,648 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// DaemonCommand creates the daemon command
+func DaemonCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "daemon",
+		Usage: "Process images dropped into watched folders as a service",
+		Commands: []*cli.Command{
+			daemonRunCommand(),
+			daemonInstallCommand(),
+		},
+	}
+}
+
+// daemonRunCommand creates the daemon run subcommand
+func daemonRunCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "run",
+		Usage: "Watch folders and process new images until stopped",
+		Description: `Poll the folders of the config for new or changed images, run each through
+the pipeline steps of its folder (see the pipeline command) and write the
+result to the output folder. An image is processed when its output is missing
+or older, and once it has not changed for "settle", so files still being
+copied are left for the next poll.
+
+The config is JSON, or YAML with the same fields when its name ends in .yaml
+or .yml:
+  {
+    "listen": "127.0.0.1:8089",
+    "workers": 2,
+    "poll_interval": "2s",
+    "settle": "1s",
+    "watches": [
+      {"name": "web", "dir": "/srv/inbox", "out_dir": "/srv/web", "recursive": true,
+       "steps": "fit 1600 1600; sharpen 0.6", "format": "webp", "quality": 82}
+    ]
+  }
+
+GET /healthz on the listen address returns the status and counters as JSON,
+with status 503 when polling has stalled. SIGHUP reloads the config (a
+broken config is logged and the old one kept); SIGINT and SIGTERM stop after
+the images in progress. Logs are structured (JSON by default) on stderr.
+
+Examples:
+  imgx daemon run --config /etc/imgx/daemon.yaml
+  imgx daemon run --config daemon.json --log-format text -v`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Aliases:  []string{"c"},
+				Usage:    "daemon config file (JSON or YAML)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "log format: json or text",
+				Value: "json",
+				Validator: func(v string) error {
+					if v != "json" && v != "text" {
+						return fmt.Errorf("log-format must be json or text")
+					}
+					return nil
+				},
+			},
+		},
+		Action: daemonRunAction,
+	}
+}
+
+// daemonInstallCommand creates the daemon install subcommand
+func daemonInstallCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "install",
+		Usage: "Install the daemon as a systemd service",
+		Description: `Check the config and write a systemd unit that runs "imgx daemon run" with it,
+restarts it on failure and reloads it with SIGHUP ("systemctl reload"). The
+unit runs this imgx binary; install it somewhere stable first. Enabling the
+service is left to you:
+  systemctl daemon-reload && systemctl enable --now imgx
+
+Examples:
+  sudo imgx daemon install --config /etc/imgx/daemon.yaml
+  imgx daemon install --config daemon.json --user-unit
+  imgx daemon install --config daemon.json --print`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Aliases:  []string{"c"},
+				Usage:    "daemon config file (JSON or YAML)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "service name",
+				Value: "imgx",
+			},
+			&cli.StringFlag{
+				Name:  "run-as",
+				Usage: "user the service runs as (system units only)",
+			},
+			&cli.BoolFlag{
+				Name:  "user-unit",
+				Usage: "install a user unit in ~/.config/systemd/user instead of a system unit",
+			},
+			&cli.BoolFlag{
+				Name:  "print",
+				Usage: "print the unit instead of writing it",
+			},
+		},
+		Action: daemonInstallAction,
+	}
+}
+
+// DaemonConfig is the config of the daemon
+type DaemonConfig struct {
+	Listen       string        `json:"listen"`        // Address of the health endpoint; "" disables it
+	Workers      int           `json:"workers"`       // Images processed at once; default 2
+	PollInterval string        `json:"poll_interval"` // Time between polls; default 2s
+	Settle       string        `json:"settle"`        // Time a file must be unchanged; default 1s
+	Watches      []DaemonWatch `json:"watches"`
+
+	pollInterval time.Duration
+	settle       time.Duration
+}
+
+// DaemonWatch is a watched folder of the daemon
+type DaemonWatch struct {
+	Name      string `json:"name"`
+	Dir       string `json:"dir"`
+	OutDir    string `json:"out_dir"`
+	Recursive bool   `json:"recursive"`
+	Steps     string `json:"steps"`   // Pipeline steps; none re-encodes the image
+	Format    string `json:"format"`  // Output format; default that of the input
+	Quality   int    `json:"quality"` // JPEG and WebP quality; default the encoder's
+}
+
+// LoadDaemonConfig reads and checks a daemon config, filling in defaults
+func LoadDaemonConfig(path string) (*DaemonConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("invalid daemon config %s: %w", path, err)
+		}
+	}
+	cfg := &DaemonConfig{Workers: 2, PollInterval: "2s", Settle: "1s"}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+	if err := cfg.check(); err != nil {
+		return nil, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// yamlToJSON converts a YAML document to JSON, so that YAML configs are
+// decoded with the same field names and checks as JSON ones
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// check validates the config and parses its durations
+func (c *DaemonConfig) check() error {
+	var err error
+	if c.pollInterval, err = time.ParseDuration(c.PollInterval); err != nil || c.pollInterval <= 0 {
+		return fmt.Errorf("poll_interval %q must be a positive duration like 2s", c.PollInterval)
+	}
+	if c.settle, err = time.ParseDuration(c.Settle); err != nil || c.settle < 0 {
+		return fmt.Errorf("settle %q must be a duration like 1s", c.Settle)
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1")
+	}
+	if len(c.Watches) == 0 {
+		return fmt.Errorf("no watches")
+	}
+	names := make(map[string]bool)
+	for i := range c.Watches {
+		w := &c.Watches[i]
+		if w.Name == "" {
+			w.Name = filepath.Base(w.Dir)
+		}
+		if names[w.Name] {
+			return fmt.Errorf("watch %q: duplicate name", w.Name)
+		}
+		names[w.Name] = true
+		if w.Dir == "" || w.OutDir == "" {
+			return fmt.Errorf("watch %q: dir and out_dir are required", w.Name)
+		}
+		if info, err := os.Stat(w.Dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("watch %q: %s is not a directory", w.Name, w.Dir)
+		}
+		dir, _ := filepath.Abs(w.Dir)
+		out, _ := filepath.Abs(w.OutDir)
+		if rel, err := filepath.Rel(dir, out); err == nil && (rel == "." || (w.Recursive && !strings.HasPrefix(rel, ".."))) {
+			return fmt.Errorf("watch %q: out_dir must not be inside dir, or outputs are processed again", w.Name)
+		}
+		if w.Format != "" {
+			if _, err := ParseFormat(w.Format); err != nil {
+				return fmt.Errorf("watch %q: %w", w.Name, err)
+			}
+		}
+		if w.Quality < 0 || w.Quality > 100 {
+			return fmt.Errorf("watch %q: quality must be between 1 and 100", w.Name)
+		}
+		if strings.TrimSpace(w.Steps) != "" {
+			if _, err := ParsePipeline(w.Steps, imgx.NewRegistry()); err != nil {
+				return fmt.Errorf("watch %q: %w", w.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// daemon is the state of a running daemon
+type daemon struct {
+	path   string
+	logger *slog.Logger
+
+	mu         sync.Mutex
+	cfg        *DaemonConfig
+	sem        chan struct{} // Worker slots of cfg
+	inFlight   map[string]bool
+	skippedAt  map[string]time.Time // Inputs a skip step dropped, by modification time
+	lastScan   time.Time
+	reloadedAt time.Time
+
+	started   time.Time
+	processed atomic.Int64
+	skipped   atomic.Int64
+	failed    atomic.Int64
+	wg        sync.WaitGroup
+}
+
+// newDaemon loads the config at path
+func newDaemon(path string, logger *slog.Logger) (*daemon, error) {
+	cfg, err := LoadDaemonConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &daemon{
+		path:       path,
+		logger:     logger,
+		cfg:        cfg,
+		sem:        make(chan struct{}, cfg.Workers),
+		inFlight:   make(map[string]bool),
+		skippedAt:  make(map[string]time.Time),
+		started:    now,
+		reloadedAt: now,
+	}, nil
+}
+
+// reload replaces the config with the file's current content, keeping the
+// old one when it is broken. Images in progress finish with the old one.
+func (d *daemon) reload() {
+	cfg, err := LoadDaemonConfig(d.path)
+	if err != nil {
+		d.logger.Error("reload failed, keeping the current config", "error", err)
+		return
+	}
+	d.mu.Lock()
+	if cfg.Listen != d.cfg.Listen {
+		d.logger.Warn("listen address changes need a restart", "listen", d.cfg.Listen)
+	}
+	d.cfg = cfg
+	d.sem = make(chan struct{}, cfg.Workers)
+	d.reloadedAt = time.Now()
+	d.mu.Unlock()
+	d.logger.Info("config reloaded", "watches", len(cfg.Watches), "workers", cfg.Workers)
+}
+
+// config returns the current config
+func (d *daemon) config() *DaemonConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg
+}
+
+// scan starts processing the new and changed images of every watch
+func (d *daemon) scan(ctx context.Context) {
+	d.mu.Lock()
+	cfg, sem := d.cfg, d.sem
+	d.lastScan = time.Now()
+	d.mu.Unlock()
+
+	for _, w := range cfg.Watches {
+		jobs, err := outputJobs([]string{w.Dir}, w.Recursive, w.OutDir)
+		if err != nil {
+			d.logger.Error("scan failed", "watch", w.Name, "error", err)
+			continue
+		}
+		for _, job := range jobs {
+			if w.Format != "" {
+				format, _ := ParseFormat(w.Format)
+				job.output = changeExtension(job.output, format)
+			}
+			if !d.ready(job, cfg.settle) {
+				continue
+			}
+			d.wg.Add(1)
+			go func(w DaemonWatch, job convertJob) {
+				defer d.wg.Done()
+				defer d.done(job.input)
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return
+				}
+				d.process(w, job)
+			}(w, job)
+		}
+	}
+}
+
+// ready reports whether job needs processing and claims it: its output is
+// missing or older, its input has settled and it isn't in progress
+func (d *daemon) ready(job convertJob, settle time.Duration) bool {
+	if isUpToDate(job.input, job.output) {
+		return false
+	}
+	info, err := os.Stat(job.input)
+	if err != nil {
+		return false
+	}
+	if time.Since(info.ModTime()) < settle {
+		d.logger.Debug("waiting for the file to settle", "input", job.input)
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inFlight[job.input] || d.skippedAt[job.input].Equal(info.ModTime()) {
+		return false
+	}
+	d.inFlight[job.input] = true
+	return true
+}
+
+// done releases the claim of ready on input
+func (d *daemon) done(input string) {
+	d.mu.Lock()
+	delete(d.inFlight, input)
+	d.mu.Unlock()
+}
+
+// process runs one image through the pipeline of its watch
+func (d *daemon) process(w DaemonWatch, job convertJob) {
+	start := time.Now()
+	warnings, err := processDaemonJob(w, job)
+	if errors.Is(err, errPipelineSkipped) {
+		// Not retried until the input changes: there is no output to compare
+		if info, err := os.Stat(job.input); err == nil {
+			d.mu.Lock()
+			d.skippedAt[job.input] = info.ModTime()
+			d.mu.Unlock()
+		}
+		d.skipped.Add(1)
+		d.logger.Info("skipped by the pipeline", "watch", w.Name, "input", job.input)
+		return
+	}
+	if err != nil {
+		d.failed.Add(1)
+		d.logger.Error("processing failed", "watch", w.Name, "input", job.input, "error", err)
+		return
+	}
+	d.processed.Add(1)
+	attrs := []any{"watch", w.Name, "input", job.input, "output", job.output, "duration_ms", time.Since(start).Milliseconds()}
+	if len(warnings) > 0 {
+		attrs = append(attrs, "warnings", warnings)
+	}
+	d.logger.Info("processed", attrs...)
+}
+
+// errPipelineSkipped is returned by processDaemonJob when a skip step of
+// the pipeline drops the image
+var errPipelineSkipped = errors.New("skipped by the pipeline")
+
+// processDaemonJob loads, processes and saves one image. The output is
+// written under a temporary name and renamed, so readers of out_dir never
+// see a partial file.
+func processDaemonJob(w DaemonWatch, job convertJob) ([]string, error) {
+	img, err := imgx.Load(job.input, imgx.Options{AutoOrient: true})
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(w.Steps) != "" {
+		ops, err := ParsePipeline(w.Steps, imgx.NewRegistry())
+		if err != nil {
+			return nil, err
+		}
+		if img = runPipeline(img, ops); img == nil {
+			return nil, errPipelineSkipped
+		}
+	}
+
+	var opts []imgx.SaveOption
+	if w.Quality > 0 {
+		opts = append(opts, imgx.WithJPEGQuality(w.Quality), imgx.WithWebPQuality(w.Quality))
+	}
+	if err := os.MkdirAll(filepath.Dir(job.output), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	tmp := filepath.Join(filepath.Dir(job.output), ".imgx-"+filepath.Base(job.output))
+	// The save hooks run once the output has its final name
+	result, err := img.SaveWithResult(tmp, append(opts, imgx.WithoutHooks())...)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, job.output); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	b := img.Bounds()
+	result, err = imgx.RunSaveHooks(job.output, imgx.SaveInfo{
+		Format:     result.Format,
+		Width:      b.Dx(),
+		Height:     b.Dy(),
+		SourcePath: job.input,
+		Warnings:   result.Warnings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	for _, w := range result.Warnings {
+		if w.Code != imgx.WarnMetadataSkipped {
+			warnings = append(warnings, w.String())
+		}
+	}
+	return warnings, nil
+}
+
+// daemonHealth is the response of /healthz
+type daemonHealth struct {
+	Status        string    `json:"status"` // ok, or stalled when polling stopped
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	Watches       int       `json:"watches"`
+	Workers       int       `json:"workers"`
+	InFlight      int       `json:"in_flight"`
+	Processed     int64     `json:"processed"`
+	Skipped       int64     `json:"skipped"` // Dropped by a skip step
+	Failed        int64     `json:"failed"`
+	LastScan      time.Time `json:"last_scan"`
+	ReloadedAt    time.Time `json:"reloaded_at"`
+}
+
+// health returns the current health of the daemon
+func (d *daemon) health() daemonHealth {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := daemonHealth{
+		Status:        "ok",
+		UptimeSeconds: int64(time.Since(d.started).Seconds()),
+		Watches:       len(d.cfg.Watches),
+		Workers:       d.cfg.Workers,
+		InFlight:      len(d.inFlight),
+		Processed:     d.processed.Load(),
+		Skipped:       d.skipped.Load(),
+		Failed:        d.failed.Load(),
+		LastScan:      d.lastScan,
+		ReloadedAt:    d.reloadedAt,
+	}
+	if time.Since(d.lastScan) > 3*d.cfg.pollInterval+d.cfg.settle {
+		h.Status = "stalled"
+	}
+	return h
+}
+
+// handler serves /healthz
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		h := d.health()
+		w.Header().Set("Content-Type", "application/json")
+		if h.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+	return mux
+}
+
+func daemonRunAction(ctx context.Context, cmd *cli.Command) error {
+	level := slog.LevelInfo
+	if cmd.Bool("verbose") {
+		level = slog.LevelDebug
+	}
+	var handler slog.Handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	if cmd.String("log-format") == "text" {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	}
+	logger := slog.New(handler)
+
+	d, err := newDaemon(cmd.String("config"), logger)
+	if err != nil {
+		return err
+	}
+	cfg := d.config()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var server *http.Server
+	if cfg.Listen != "" {
+		server = &http.Server{Addr: cfg.Listen, Handler: d.handler(), ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("health endpoint failed", "listen", cfg.Listen, "error", err)
+			}
+		}()
+	}
+	logger.Info("daemon started", "config", cmd.String("config"), "watches", len(cfg.Watches), "workers", cfg.Workers, "listen", cfg.Listen)
+
+	ticker := time.NewTicker(cfg.pollInterval)
+	defer ticker.Stop()
+	d.scan(ctx)
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-hup:
+			d.reload()
+			ticker.Reset(d.config().pollInterval)
+		case <-ticker.C:
+			d.scan(ctx)
+		}
+	}
+
+	logger.Info("stopping, waiting for the images in progress")
+	d.wg.Wait()
+	if server != nil {
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}
+	logger.Info("daemon stopped", "processed", d.processed.Load(), "skipped", d.skipped.Load(), "failed", d.failed.Load())
+	return nil
+}
+
+func daemonInstallAction(ctx context.Context, cmd *cli.Command) error {
+	config, err := filepath.Abs(cmd.String("config"))
+	if err != nil {
+		return err
+	}
+	if _, err := LoadDaemonConfig(config); err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the imgx binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	userUnit := cmd.Bool("user-unit")
+	if userUnit && cmd.String("run-as") != "" {
+		return fmt.Errorf("--run-as only applies to system units")
+	}
+	unit := systemdUnit(exe, config, cmd.String("run-as"), userUnit)
+	if cmd.Bool("print") {
+		fmt.Print(unit)
+		return nil
+	}
+
+	dir := "/etc/systemd/system"
+	systemctl := "systemctl"
+	if userUnit {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(home, ".config", "systemd", "user")
+		systemctl = "systemctl --user"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, cmd.String("name")+".service")
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+	infof("Unit written to %s", path)
+	fmt.Printf("%s: %s daemon-reload && %s enable --now %s\n", tr("Start it with"), systemctl, systemctl, cmd.String("name"))
+	return nil
+}
+
+// systemdUnit returns the systemd unit running exe as a daemon with config
+func systemdUnit(exe, config, user string, userUnit bool) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=imgx image processing daemon\n")
+	b.WriteString("After=network.target\n\n")
+	b.WriteString("[Service]\n")
+	fmt.Fprintf(&b, "ExecStart=%s daemon run --config %s\n", systemdQuote(exe), systemdQuote(config))
+	b.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	if user != "" {
+		fmt.Fprintf(&b, "User=%s\n", user)
+	}
+	b.WriteString("\n[Install]\n")
+	if userUnit {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes a path of a systemd command line when it has spaces
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
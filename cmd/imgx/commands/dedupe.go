@@ -0,0 +1,365 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// DedupeCommand creates the dedupe command
+func DedupeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "dedupe",
+		Usage:     "Find near-duplicate photos and pick a keeper per group",
+		ArgsUsage: "<file|dir>...",
+		Description: `Cluster visually similar images using perceptual hashes, image dimensions and
+EXIF capture times. For each cluster the "best" image is selected as keeper:
+the one with the most pixels, then the sharpest, then the largest file.
+
+Duplicates can be moved to a separate directory with --move-to. Nothing is ever
+deleted. Use --dry-run to see what would be moved.
+
+Examples:
+  imgx dedupe ./library -r
+  imgx dedupe ./library -r --report dupes.json
+  imgx dedupe ./library -r --move-to ./duplicates --dry-run
+  imgx dedupe ./library -r --threshold 4 --move-to ./duplicates`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.IntFlag{
+				Name:    "threshold",
+				Aliases: []string{"t"},
+				Usage:   "maximum perceptual hash distance (0-64) for two images to be duplicates",
+				Value:   8,
+				Validator: func(v int) error {
+					if v < 0 || v > 64 {
+						return fmt.Errorf("threshold must be between 0 and 64")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "report",
+				Usage: "write the clusters as JSON to this file",
+			},
+			&cli.StringFlag{
+				Name:  "move-to",
+				Usage: "move duplicates (all but the keeper) to this directory",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "report what would be moved without moving anything",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "print the report as JSON instead of text",
+			},
+		},
+		Action: dedupeAction,
+	}
+}
+
+// dedupeFile describes one scanned image in the dedupe report
+type dedupeFile struct {
+	Path      string     `json:"path"`
+	Width     int        `json:"width"`
+	Height    int        `json:"height"`
+	Size      int64      `json:"size"`
+	Hash      string     `json:"hash"`
+	Sharpness float64    `json:"sharpness"`
+	Taken     *time.Time `json:"taken,omitempty"`
+	Distance  int        `json:"distance,omitempty"` // to the keeper
+	MovedTo   string     `json:"moved_to,omitempty"`
+
+	hash imgx.Hash
+}
+
+type dedupeCluster struct {
+	Keeper     dedupeFile   `json:"keeper"`
+	Duplicates []dedupeFile `json:"duplicates"`
+}
+
+type dedupeReport struct {
+	FilesScanned     int             `json:"files_scanned"`
+	Clusters         []dedupeCluster `json:"clusters"`
+	DuplicateCount   int             `json:"duplicate_count"`
+	ReclaimableBytes int64           `json:"reclaimable_bytes"`
+	Errors           []string        `json:"errors,omitempty"`
+}
+
+func dedupeAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	files, errs := scanDedupeFiles(ctx, paths, cmd.Bool("verbose"))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	report := dedupeReport{FilesScanned: len(files), Errors: errs}
+	threshold := cmd.Int("threshold")
+	for _, group := range imgx.Cluster(len(files), func(i, j int) bool {
+		return isDuplicate(&files[i], &files[j], threshold)
+	}) {
+		members := make([]dedupeFile, len(group))
+		for k, idx := range group {
+			members[k] = files[idx]
+		}
+		sort.SliceStable(members, func(a, b int) bool {
+			return betterKeeper(&members[a], &members[b])
+		})
+
+		cluster := dedupeCluster{Keeper: members[0]}
+		for _, dup := range members[1:] {
+			dup.Distance = dup.hash.Distance(cluster.Keeper.hash)
+			cluster.Duplicates = append(cluster.Duplicates, dup)
+			report.DuplicateCount++
+			report.ReclaimableBytes += dup.Size
+		}
+		report.Clusters = append(report.Clusters, cluster)
+	}
+
+	if moveTo := cmd.String("move-to"); moveTo != "" {
+		if err := moveDuplicates(&report, moveTo, cmd.Bool("dry-run")); err != nil {
+			return err
+		}
+	}
+
+	if path := cmd.String("report"); path != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	if cmd.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printDedupeReport(&report, cmd.Bool("dry-run"))
+	return nil
+}
+
+// scanDedupeFiles loads every image and computes the values used for
+// clustering. Unreadable files are reported and skipped.
+func scanDedupeFiles(ctx context.Context, paths []string, verbose bool) ([]dedupeFile, []string) {
+	results := make([]*dedupeFile, len(paths))
+	errs := make([]error, len(paths))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = scanDedupeFile(paths[i])
+				if verbose && errs[i] == nil {
+					fmt.Printf("%s: %s (%s)\n", tr("Scanned"), paths[i], results[i].Hash)
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var files []dedupeFile
+	var messages []string
+	for i, f := range results {
+		if errs[i] != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", paths[i], errs[i]))
+			warnf("skipping %s: %v", paths[i], errs[i])
+			continue
+		}
+		if f != nil {
+			files = append(files, *f)
+		}
+	}
+	return files, messages
+}
+
+func scanDedupeFile(path string) (*dedupeFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+	if err != nil {
+		return nil, err
+	}
+
+	hash := img.PerceptualHash()
+	bounds := img.Bounds()
+	f := &dedupeFile{
+		Path:      path,
+		Width:     bounds.Dx(),
+		Height:    bounds.Dy(),
+		Size:      info.Size(),
+		Hash:      hash.String(),
+		Sharpness: img.Sharpness(),
+		hash:      hash,
+	}
+
+	if r, err := os.Open(path); err == nil {
+		exif, err := imgx.ReadEXIF(r)
+		r.Close()
+		if err == nil && !exif.DateTimeOriginal.IsZero() {
+			f.Taken = &exif.DateTimeOriginal
+		}
+	}
+	return f, nil
+}
+
+// isDuplicate reports whether two images are near-duplicates: visually
+// similar with the same aspect ratio. Images captured at the same instant
+// are allowed twice the hash distance (e.g. RAW+JPEG exports, edits).
+func isDuplicate(a, b *dedupeFile, threshold int) bool {
+	ra := float64(a.Width) / float64(a.Height)
+	rb := float64(b.Width) / float64(b.Height)
+	if ra/rb > 1.05 || rb/ra > 1.05 {
+		return false
+	}
+	limit := threshold
+	if a.Taken != nil && b.Taken != nil && a.Taken.Equal(*b.Taken) {
+		limit *= 2
+	}
+	return a.hash.Distance(b.hash) <= limit
+}
+
+// betterKeeper orders cluster members: most pixels, then sharpest, then
+// largest file, then path.
+func betterKeeper(a, b *dedupeFile) bool {
+	pa, pb := a.Width*a.Height, b.Width*b.Height
+	if pa != pb {
+		return pa > pb
+	}
+	if a.Sharpness != b.Sharpness {
+		return a.Sharpness > b.Sharpness
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	return a.Path < b.Path
+}
+
+// moveDuplicates moves all non-keepers into dir, renaming on name collisions
+func moveDuplicates(report *dedupeReport, dir string, dryRun bool) error {
+	if !dryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	taken := make(map[string]bool)
+	for c := range report.Clusters {
+		for d := range report.Clusters[c].Duplicates {
+			dup := &report.Clusters[c].Duplicates[d]
+			dst := uniquePath(filepath.Join(dir, filepath.Base(dup.Path)), taken)
+			taken[dst] = true
+			if !dryRun {
+				if err := moveFile(dup.Path, dst); err != nil {
+					return fmt.Errorf("failed to move %s: %w", dup.Path, err)
+				}
+			}
+			dup.MovedTo = dst
+		}
+	}
+	return nil
+}
+
+// uniquePath appends -1, -2, ... before the extension until path is unused
+func uniquePath(path string, taken map[string]bool) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 1; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) && !taken[candidate] {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// moveFile renames src to dst, copying across file systems when needed
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func printDedupeReport(report *dedupeReport, dryRun bool) {
+	for i, c := range report.Clusters {
+		k := c.Keeper
+		infof("Cluster %d (%d images)", i+1, len(c.Duplicates)+1)
+		fmt.Printf("  %-5s %s (%dx%d, %s, "+tr("sharpness %.1f")+")\n", tr("keep"), k.Path, k.Width, k.Height, FormatBytes(k.Size), k.Sharpness)
+		for _, d := range c.Duplicates {
+			fmt.Printf("  %-5s %s (%dx%d, %s, "+tr("distance %d")+")\n", tr("dupe"), d.Path, d.Width, d.Height, FormatBytes(d.Size), d.Distance)
+			if d.MovedTo != "" {
+				format := "moved to %s"
+				if dryRun {
+					format = "would move to %s"
+				}
+				fmt.Printf("        "+tr(format)+"\n", d.MovedTo)
+			}
+		}
+	}
+	fmt.Printf("\n"+tr("Scanned %d images: %d duplicates in %d clusters (%s reclaimable)")+"\n",
+		report.FilesScanned, report.DuplicateCount, len(report.Clusters), FormatBytes(report.ReclaimableBytes))
+}
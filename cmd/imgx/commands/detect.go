@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
@@ -14,8 +16,9 @@ import (
 // DetectCommand creates the detect command
 func DetectCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "detect",
-		Usage: "Detect objects in images using AI vision APIs",
+		Name:      "detect",
+		Usage:     "Detect objects in images using AI vision APIs",
+		ArgsUsage: "<input>",
 		Description: `Perform object detection using local Ollama models, Google Gemini,
 AWS Rekognition, or OpenAI Vision APIs.
 
@@ -28,6 +31,9 @@ text detection (OCR), face detection, and more depending on the provider.
   google          Alias for gemini
   aws             AWS Rekognition (uses AWS credential chain)
   openai          OpenAI Vision API (requires OPENAI_API_KEY)
+  vision          Google Cloud Vision API (requires GOOGLE_VISION_API_KEY)
+  yolo            YOLO/ONNX model on a local inference server (Triton, OpenVINO)
+  a,b,c           Fallback chain: each provider in turn until one succeeds
 
 Setup:
 	  Ollama:    Install Ollama (https://ollama.com/), run "ollama serve", then:
@@ -38,6 +44,8 @@ Setup:
 
   Gemini:    Get API key from https://aistudio.google.com/
              export GEMINI_API_KEY="your-api-key"
+             Optional override:
+               export IMGX_GEMINI_MODEL="gemini-1.5-pro"   # default gemini-2.0-flash
 
   AWS:       Uses standard AWS credential chain. Configure with any of:
              - Environment variables:
@@ -50,6 +58,12 @@ Setup:
 
   OpenAI:    export OPENAI_API_KEY="sk-..."
 
+  YOLO:      Serve an ONNX export (e.g. yolov8n.onnx) with a KServe v2 server
+             such as Triton, then optionally:
+               export IMGX_YOLO_HOST="http://127.0.0.1:8000"
+               export IMGX_YOLO_MODEL="yolov8n"
+               export IMGX_YOLO_LABELS="classes.txt"          # custom-trained models
+
 Examples:
   # Detect objects using the default local Ollama model
   imgx detect input.jpg
@@ -57,38 +71,85 @@ Examples:
   # Detect with Google Gemini (cloud)
   imgx detect --provider gemini input.jpg
 
+  # Pick the Gemini model for one run (overrides IMGX_GEMINI_MODEL)
+  imgx detect --provider gemini --model gemini-1.5-pro input.jpg
+
   # Using "google" alias (same as gemini)
   imgx detect --provider google input.jpg
 
   # Detect with specific features
   imgx detect --provider gemini --features labels,text input.jpg
 
+  # Estimate whether the image is AI-generated (metadata markers, model
+  # judgment and frequency artifacts combined)
+  imgx detect --provider gemini --features synthetic input.png
+
+  # Flag stock watermarks (agency text and tiled overlays)
+  imgx detect --provider vision --features watermark input.jpg
+
+  # Short, friendly caption without guesses
+  imgx detect --features description --one-line --max-words 15 --tone friendly --no-speculation input.jpg
+
   # Custom prompt (Gemini/OpenAI)
   imgx detect --provider gemini --prompt "Is there a dog in this image?" input.jpg
 
+  # Custom prompt with a JSON Schema for the answer
+  imgx detect --provider gemini --prompt "Audit this product photo" --schema audit.schema.json input.jpg
+
+  # Shared prompt template with variables (see "imgx prompts")
+  imgx detect --provider gemini --prompt-template product-audit --var brand=Acme input.jpg
+
+  # Web matches, landmarks and logos with Google Cloud Vision
+  imgx detect --provider vision --features web,landmarks,logos input.jpg
+
+  # Fast, offline boxes with a local YOLO model
+  imgx detect --provider yolo --features objects input.jpg
+
+  # Fall back to Gemini, then AWS, when the local Ollama server is down
+  imgx detect --provider ollama,gemini,aws input.jpg
+
+  # Let routing rules pick the provider (faces -> aws, custom prompts -> gemini, ...)
+  imgx detect --provider auto --features labels,faces input.jpg
+
+  # Show the prompt, model and estimated cost without calling the API
+  imgx detect --provider openai --features labels,text --show-prompt input.jpg
+
   # Output as JSON
   imgx detect --provider aws --json input.jpg
 
+  # Compare providers (latency, success rate, label agreement)
+  imgx detect bench --providers gemini,aws,ollama --image input.jpg --runs 5
+
   # Higher confidence threshold
   imgx detect --confidence 0.8 input.jpg
 
   # AWS image properties (colors, quality, sharpness)
   imgx detect --provider aws --features properties input.jpg
 
+  # Draw the objects, faces and text found on the image
+  imgx detect photo.jpg --provider vision --features objects,faces,text --annotate -o annotated.jpg
+
+  # Save every person and car found by a local YOLO model to crops/<class>/
+  imgx detect photo.jpg --provider yolo --features objects --crop person,car --out-dir crops/ --padding 10
+
+  # Bootstrap training data: object boxes of a directory as COCO or Pascal VOC
+  imgx detect ./images -r --provider vision --features objects --export coco --out annotations.json
+  imgx detect ./images -r --provider vision --features objects --export voc --out Annotations/
+
   # AWS labels + image properties together
   imgx detect --provider aws --features labels,properties --json input.jpg`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:     "provider",
 				Aliases:  []string{"p"},
-				Usage:    "Detection provider: ollama, gemini, google (alias), aws, openai",
+				Usage:    "Detection provider: ollama, gemini, google (alias), aws, openai, vision (Cloud Vision), yolo (local YOLO/ONNX), auto (routing rules), or a fallback list such as ollama,gemini",
 				Value:    detection.GetDefaultProvider(),
 				Required: false,
 			},
 			&cli.StringFlag{
 				Name:    "features",
 				Aliases: []string{"f"},
-				Usage:   "Features to detect: labels,text,faces,web,description,properties (comma-separated)",
+				Usage:   "Features to detect: labels,objects,text,faces,web,landmarks,logos,safesearch,description,properties,synthetic,watermark (comma-separated)",
 				Value:   "labels",
 			},
 			&cli.IntFlag{
@@ -102,11 +163,57 @@ Examples:
 				Aliases: []string{"c"},
 				Usage:   "Minimum confidence threshold (0.0-1.0)",
 				Value:   0.5,
+				Validator: func(f float64) error {
+					if f < 0 || f > 1 {
+						return fmt.Errorf("confidence must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "model",
+				Usage: "Gemini model for this detection, e.g. gemini-1.5-pro (default: IMGX_GEMINI_MODEL or gemini-2.0-flash)",
 			},
 			&cli.StringFlag{
 				Name:  "prompt",
 				Usage: "Custom prompt for Ollama/Gemini/OpenAI (overrides --features)",
 			},
+			&cli.StringFlag{
+				Name:  "prompt-template",
+				Usage: "Named prompt template (or template file) to use as the custom prompt",
+			},
+			&cli.StringSliceFlag{
+				Name:  "var",
+				Usage: "Prompt template variable as key=value (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "schema",
+				Usage: "JSON Schema file for the custom prompt response (validated, output as structured data)",
+			},
+			&cli.BoolFlag{
+				Name:  "one-line",
+				Usage: "Description: a single-sentence caption instead of a detailed description",
+			},
+			&cli.IntFlag{
+				Name:  "max-words",
+				Usage: "Description: maximum number of words",
+			},
+			&cli.StringFlag{
+				Name:  "tone",
+				Usage: "Description: tone, e.g. neutral, friendly, formal, playful",
+			},
+			&cli.StringFlag{
+				Name:  "audience",
+				Usage: "Description: intended audience, e.g. children, screen reader users",
+			},
+			&cli.BoolFlag{
+				Name:  "include-colors",
+				Usage: "Description: mention the main colors",
+			},
+			&cli.BoolFlag{
+				Name:  "no-speculation",
+				Usage: "Description: only describe what is visible",
+			},
 			&cli.BoolFlag{
 				Name:    "json",
 				Aliases: []string{"j"},
@@ -118,6 +225,58 @@ Examples:
 				Usage: "Include raw API response in output",
 				Value: false,
 			},
+			&cli.StringFlag{
+				Name:  "routes",
+				Usage: "Routing rules file for --provider auto (default: $IMGX_ROUTES or <config dir>/imgx/routes.json)",
+			},
+			&cli.BoolFlag{
+				Name:  "annotate",
+				Usage: "Draw the detected objects, faces and text on the image and save it to -o (default: <name>-annotated.<ext>)",
+			},
+			&cli.StringFlag{
+				Name:  "crop",
+				Usage: "Save a crop of every detected object of these classes (comma-separated, or \"all\") to --out-dir",
+			},
+			&cli.StringFlag{
+				Name:  "out-dir",
+				Usage: "With --crop: directory for the crops, saved as <class>/<name>-<n>.<ext> (default: next to the input)",
+			},
+			&cli.IntFlag{
+				Name:  "padding",
+				Usage: "With --crop: pixels added around each box",
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("padding must be non-negative")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "export",
+				Usage: "Export the bounding boxes of all inputs as training annotations: coco or voc",
+				Validator: func(v string) error {
+					if v != "coco" && v != "voc" {
+						return fmt.Errorf("export format must be coco or voc")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "With --export: COCO file (default: stdout) or VOC directory (default: next to each image)",
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "With --export: scan directories recursively",
+			},
+			&cli.BoolFlag{
+				Name:  "show-prompt",
+				Usage: "Print the request (prompt, model, image size, estimated tokens and cost) without calling the API",
+			},
+		},
+		Commands: []*cli.Command{
+			detectBenchCommand(),
 		},
 		Action: detectAction,
 	}
@@ -128,6 +287,9 @@ func detectAction(ctx context.Context, cmd *cli.Command) error {
 	if cmd.Args().Len() < 1 {
 		return fmt.Errorf("input file required")
 	}
+	if cmd.IsSet("export") {
+		return detectExportAction(ctx, cmd)
+	}
 
 	inputPath := cmd.Args().Get(0)
 	provider := cmd.String("provider")
@@ -138,21 +300,106 @@ func detectAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	prompt := cmd.String("prompt")
+	if name := cmd.String("prompt-template"); name != "" {
+		if prompt != "" {
+			return fmt.Errorf("--prompt and --prompt-template cannot be used together")
+		}
+		tmpl, err := detection.LoadPromptTemplate(name)
+		if err != nil {
+			return err
+		}
+		if prompt, err = renderPromptTemplate(tmpl, cmd.StringSlice("var")); err != nil {
+			return err
+		}
+	}
+
 	// Prepare detection options
 	opts := &detection.DetectOptions{
 		Features:           detection.ParseFeatures(cmd.String("features")),
 		MaxResults:         cmd.Int("max-results"),
 		MinConfidence:      float32(cmd.Float64("confidence")),
-		CustomPrompt:       cmd.String("prompt"),
+		CustomPrompt:       prompt,
 		IncludeRawResponse: cmd.Bool("raw"),
+		DescriptionStyle:   descriptionStyle(cmd),
+		Model:              cmd.String("model"),
+	}
+	for _, f := range opts.Features {
+		if f == detection.FeatureSynthetic {
+			// The file is searched for generator markers (C2PA, SD parameters)
+			if opts.Source, err = os.ReadFile(inputPath); err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+		}
+	}
+	if path := cmd.String("schema"); path != "" {
+		if prompt == "" {
+			return fmt.Errorf("--schema requires --prompt or --prompt-template")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %w", err)
+		}
+		if opts.ResponseSchema, err = detection.NewResponseSchema(data); err != nil {
+			return err
+		}
+	}
+
+	var router *detection.Router
+	if detection.ResolveProviderAlias(provider) == detection.AutoProvider {
+		if router, err = detection.LoadRouter(cmd.String("routes")); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Bool("show-prompt") {
+		var preview *detection.RequestPreview
+		if router != nil {
+			preview, err = router.PreviewRequest(img.ToNRGBA(), opts)
+		} else {
+			preview, err = detection.PreviewRequest(img.ToNRGBA(), provider, opts)
+		}
+		if err != nil {
+			return err
+		}
+		if cmd.Bool("json") {
+			data, err := json.MarshalIndent(preview, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		printRequestPreview(preview)
+		return nil
 	}
 
 	// Perform detection using standalone function (avoids coupling imgx root to detection)
-	result, err := detection.Detect(ctx, img.ToNRGBA(), provider, opts)
+	var result *detection.DetectionResult
+	if router != nil {
+		result, err = router.Detect(ctx, img.ToNRGBA(), opts)
+	} else {
+		result, err = detection.Detect(ctx, img.ToNRGBA(), provider, opts)
+	}
 	if err != nil {
 		return fmt.Errorf("detection failed: %w", err)
 	}
 
+	if err := reportWarnings(cmd, inputPath, result.Warnings); err != nil {
+		return err
+	}
+
+	if cmd.Bool("annotate") {
+		if err := saveDetectionAnnotations(cmd, img, inputPath, result); err != nil {
+			return err
+		}
+	}
+	if cmd.IsSet("crop") {
+		if err := saveObjectCrops(cmd, img, inputPath, result); err != nil {
+			return err
+		}
+	}
+
 	// Output results
 	if cmd.Bool("json") {
 		return outputDetectionJSON(result)
@@ -161,6 +408,57 @@ func detectAction(ctx context.Context, cmd *cli.Command) error {
 	return outputDetectionPretty(result, float32(cmd.Float64("confidence")))
 }
 
+// printRequestPreview prints a request preview for --show-prompt
+func printRequestPreview(p *detection.RequestPreview) {
+	fmt.Printf("=== %s (%s) ===\n\n", tr("Request Preview"), p.Provider)
+	if p.Model != "" {
+		fmt.Printf("%-16s %s\n", tr("Model")+":", p.Model)
+	}
+	if len(p.Operations) > 0 {
+		fmt.Printf("%-16s %s\n", tr("Operations")+":", strings.Join(p.Operations, ", "))
+	}
+	if p.ResponseFormat != "" {
+		fmt.Printf("%-16s %s\n", tr("Response format")+":", p.ResponseFormat)
+	}
+	fmt.Printf("%-16s %dx%d, %s JPEG\n", tr("Image")+":", p.ImageWidth, p.ImageHeight, FormatBytes(int64(p.ImageBytes)))
+	if p.PromptTokens > 0 || p.ImageTokens > 0 {
+		fmt.Printf("%-16s "+tr("~%d (%d prompt + %d image)")+"\n", tr("Tokens (est.)")+":", p.PromptTokens+p.ImageTokens, p.PromptTokens, p.ImageTokens)
+	}
+	fmt.Printf("%-16s ~$%.5f\n", tr("Cost (est.)")+":", p.EstimatedCost)
+	for _, note := range p.Notes {
+		fmt.Printf("  %s: %s\n", tr("note"), note)
+	}
+	if p.Prompt != "" {
+		fmt.Println("\n" + tr("Prompt") + ":")
+		for _, line := range strings.Split(p.Prompt, "\n") {
+			if line == "" {
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// descriptionStyle returns the description style set by the flags, or nil
+// when none is set
+func descriptionStyle(cmd *cli.Command) *detection.DescriptionStyle {
+	style := &detection.DescriptionStyle{
+		MaxWords:      cmd.Int("max-words"),
+		Tone:          cmd.String("tone"),
+		Audience:      cmd.String("audience"),
+		IncludeColors: cmd.Bool("include-colors"),
+		NoSpeculation: cmd.Bool("no-speculation"),
+	}
+	if cmd.Bool("one-line") {
+		style.Format = detection.DescriptionOneLine
+	}
+	if *style == (detection.DescriptionStyle{}) {
+		return nil
+	}
+	return style
+}
+
 // outputDetectionJSON outputs detection results as JSON
 func outputDetectionJSON(result *detection.DetectionResult) error {
 	data, err := json.MarshalIndent(result, "", "  ")
@@ -173,11 +471,11 @@ func outputDetectionJSON(result *detection.DetectionResult) error {
 
 // outputDetectionPretty outputs detection results in a human-readable format
 func outputDetectionPretty(result *detection.DetectionResult, minConfidence float32) error {
-	fmt.Printf("=== Object Detection Results (%s) ===\n\n", result.Provider)
+	fmt.Printf("=== %s (%s) ===\n\n", tr("Object Detection Results"), result.Provider)
 
 	// Labels
 	if len(result.Labels) > 0 {
-		fmt.Println("Labels:")
+		fmt.Println(tr("Labels") + ":")
 		count := 0
 		for _, label := range result.Labels {
 			if label.Confidence > 0 && label.Confidence < minConfidence {
@@ -185,20 +483,20 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 			}
 			count++
 			if label.Confidence > 0 {
-				fmt.Printf("  %d. %s (%.1f%% confidence)\n", count, label.Name, label.Confidence*100)
+				fmt.Printf("  %d. %s ("+tr("%.1f%% confidence")+")\n", count, label.Name, label.Confidence*100)
 			} else {
 				fmt.Printf("  %d. %s\n", count, label.Name)
 			}
 		}
 		if count == 0 {
-			fmt.Println("  (no labels above confidence threshold)")
+			fmt.Printf("  (%s)\n", tr("no labels above confidence threshold"))
 		}
 		fmt.Println()
 	}
 
 	// Description
 	if result.Description != "" {
-		fmt.Println("Description:")
+		fmt.Println(tr("Description") + ":")
 		// Word wrap description at 80 characters
 		words := strings.Fields(result.Description)
 		line := "  "
@@ -219,12 +517,30 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 		fmt.Println()
 	}
 
+	// Structured response (custom prompt with --schema)
+	if result.RawStructured != nil {
+		var out bytes.Buffer
+		if err := json.Indent(&out, result.RawStructured, "  ", "  "); err != nil {
+			out.Reset()
+			out.Write(result.RawStructured)
+		}
+		fmt.Println(tr("Structured Response") + ":")
+		fmt.Printf("  %s\n\n", out.String())
+	}
+	if len(result.SchemaErrors) > 0 {
+		fmt.Println(tr("Schema Errors") + ":")
+		for _, e := range result.SchemaErrors {
+			fmt.Printf("  - %s\n", e)
+		}
+		fmt.Println()
+	}
+
 	// Text (OCR)
 	if len(result.Text) > 0 {
-		fmt.Println("Detected Text:")
+		fmt.Println(tr("Detected Text") + ":")
 		for i, text := range result.Text {
 			if text.Confidence > 0 {
-				fmt.Printf("  %d. \"%s\" (%.1f%% confidence)\n", i+1, text.Text, text.Confidence*100)
+				fmt.Printf("  %d. \"%s\" ("+tr("%.1f%% confidence")+")\n", i+1, text.Text, text.Confidence*100)
 			} else {
 				fmt.Printf("  %d. \"%s\"\n", i+1, text.Text)
 			}
@@ -234,26 +550,26 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 
 	// Faces
 	if len(result.Faces) > 0 {
-		fmt.Printf("Faces Detected: %d\n", len(result.Faces))
+		fmt.Printf("%s: %d\n", tr("Faces Detected"), len(result.Faces))
 		for i, face := range result.Faces {
-			fmt.Printf("  Face %d:\n", i+1)
+			fmt.Printf("  "+tr("Face %d")+":\n", i+1)
 			if face.Confidence > 0 {
-				fmt.Printf("    Confidence: %.1f%%\n", face.Confidence*100)
+				fmt.Printf("    %s: %.1f%%\n", tr("Confidence"), face.Confidence*100)
 			}
 			if face.JoyLikelihood != "" {
-				fmt.Printf("    Joy: %s\n", face.JoyLikelihood)
+				fmt.Printf("    %s: %s\n", tr("Joy"), face.JoyLikelihood)
 			}
 			if face.SorrowLikelihood != "" {
-				fmt.Printf("    Sorrow: %s\n", face.SorrowLikelihood)
+				fmt.Printf("    %s: %s\n", tr("Sorrow"), face.SorrowLikelihood)
 			}
 			if face.AngerLikelihood != "" {
-				fmt.Printf("    Anger: %s\n", face.AngerLikelihood)
+				fmt.Printf("    %s: %s\n", tr("Anger"), face.AngerLikelihood)
 			}
 			if face.Gender != "" {
-				fmt.Printf("    Gender: %s\n", face.Gender)
+				fmt.Printf("    %s: %s\n", tr("Gender"), face.Gender)
 			}
 			if face.AgeRange != "" {
-				fmt.Printf("    Age Range: %s\n", face.AgeRange)
+				fmt.Printf("    %s: %s\n", tr("Age Range"), face.AgeRange)
 			}
 		}
 		fmt.Println()
@@ -262,18 +578,18 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 	// Web Detection
 	if result.Web != nil {
 		if len(result.Web.WebEntities) > 0 {
-			fmt.Println("Web Entities:")
+			fmt.Println(tr("Web Entities") + ":")
 			for i, entity := range result.Web.WebEntities {
 				if i >= 5 {
 					break // Limit to top 5
 				}
-				fmt.Printf("  - %s (score: %.2f)\n", entity.Description, entity.Score)
+				fmt.Printf("  - %s (%s: %.2f)\n", entity.Description, tr("score"), entity.Score)
 			}
 			fmt.Println()
 		}
 
 		if len(result.Web.BestGuessLabels) > 0 {
-			fmt.Println("Best Guess Labels:")
+			fmt.Println(tr("Best Guess Labels") + ":")
 			for _, label := range result.Web.BestGuessLabels {
 				fmt.Printf("  - %s\n", label)
 			}
@@ -281,9 +597,28 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 		}
 	}
 
+	// Landmarks and logos
+	for _, group := range []struct {
+		title    string
+		entities []detection.EntityAnnotation
+	}{{tr("Landmarks"), result.Landmarks}, {tr("Logos"), result.Logos}} {
+		if len(group.entities) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", group.title)
+		for _, entity := range group.entities {
+			fmt.Printf("  - %s ("+tr("%.1f%% confidence")+")", entity.Name, entity.Confidence*100)
+			for _, loc := range entity.Locations {
+				fmt.Printf(" "+tr("at %.5f, %.5f"), loc.Latitude, loc.Longitude)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
 	// Properties
 	if len(result.Properties) > 0 {
-		fmt.Println("Properties:")
+		fmt.Println(tr("Properties") + ":")
 		keys := make([]string, 0, len(result.Properties))
 		for key := range result.Properties {
 			keys = append(keys, key)
@@ -298,12 +633,12 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 
 	// Bounding Boxes
 	if len(result.BoundingBoxes) > 0 {
-		fmt.Printf("Objects with Locations: %d\n", len(result.BoundingBoxes))
+		fmt.Printf("%s: %d\n", tr("Objects with Locations"), len(result.BoundingBoxes))
 		for i, bbox := range result.BoundingBoxes {
 			if i >= 10 {
 				break // Limit to top 10
 			}
-			fmt.Printf("  %d. %s (%.1f%% confidence) at x=%.2f, y=%.2f, w=%.2f, h=%.2f\n",
+			fmt.Printf("  %d. %s ("+tr("%.1f%% confidence")+") "+tr("at x=%.2f, y=%.2f, w=%.2f, h=%.2f")+"\n",
 				i+1, bbox.Label, bbox.Confidence*100,
 				bbox.Box.X, bbox.Box.Y, bbox.Box.Width, bbox.Box.Height)
 		}
@@ -312,12 +647,12 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 
 	// Overall confidence
 	if result.Confidence > 0 {
-		fmt.Printf("Overall Confidence: %.1f%%\n", result.Confidence*100)
+		fmt.Printf("%s: %.1f%%\n", tr("Overall Confidence"), result.Confidence*100)
 	}
 
 	// Dominant colors
 	if len(result.Colors) > 0 {
-		fmt.Println("\nDominant Colors:")
+		fmt.Println("\n" + tr("Dominant Colors") + ":")
 		colors := append([]detection.ColorInfo(nil), result.Colors...)
 		sort.SliceStable(colors, func(i, j int) bool {
 			return colors[i].Percentage > colors[j].Percentage
@@ -335,7 +670,7 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 				} else if c.RGB != "" {
 					name = c.RGB
 				} else {
-					name = fmt.Sprintf("Color %d", i+1)
+					name = fmt.Sprintf(tr("Color %d"), i+1)
 				}
 			}
 			details := []string{}
@@ -362,56 +697,56 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 
 	// Image quality
 	if result.ImageQuality != nil {
-		fmt.Println("\nImage Quality:")
+		fmt.Println("\n" + tr("Image Quality") + ":")
 		q := result.ImageQuality
 		if q.Brightness != 0 {
-			fmt.Printf("  Brightness: %.2f\n", q.Brightness)
+			fmt.Printf("  %s: %.2f\n", tr("Brightness"), q.Brightness)
 		}
 		if q.Contrast != 0 {
-			fmt.Printf("  Contrast: %.2f\n", q.Contrast)
+			fmt.Printf("  %s: %.2f\n", tr("Contrast"), q.Contrast)
 		}
 		if q.Sharpness != 0 {
-			fmt.Printf("  Sharpness: %.2f\n", q.Sharpness)
+			fmt.Printf("  %s: %.2f\n", tr("Sharpness"), q.Sharpness)
 		}
 		if q.ForegroundBrightness != 0 || q.ForegroundSharpness != 0 || q.ForegroundColor != "" {
-			fmt.Println("  Foreground:")
+			fmt.Println("  " + tr("Foreground") + ":")
 			if q.ForegroundBrightness != 0 {
-				fmt.Printf("    Brightness: %.2f\n", q.ForegroundBrightness)
+				fmt.Printf("    %s: %.2f\n", tr("Brightness"), q.ForegroundBrightness)
 			}
 			if q.ForegroundSharpness != 0 {
-				fmt.Printf("    Sharpness: %.2f\n", q.ForegroundSharpness)
+				fmt.Printf("    %s: %.2f\n", tr("Sharpness"), q.ForegroundSharpness)
 			}
 			if q.ForegroundColor != "" {
-				fmt.Printf("    Color: %s\n", q.ForegroundColor)
+				fmt.Printf("    %s: %s\n", tr("Color"), q.ForegroundColor)
 			}
 		}
 		if q.BackgroundBrightness != 0 || q.BackgroundSharpness != 0 || q.BackgroundColor != "" {
-			fmt.Println("  Background:")
+			fmt.Println("  " + tr("Background") + ":")
 			if q.BackgroundBrightness != 0 {
-				fmt.Printf("    Brightness: %.2f\n", q.BackgroundBrightness)
+				fmt.Printf("    %s: %.2f\n", tr("Brightness"), q.BackgroundBrightness)
 			}
 			if q.BackgroundSharpness != 0 {
-				fmt.Printf("    Sharpness: %.2f\n", q.BackgroundSharpness)
+				fmt.Printf("    %s: %.2f\n", tr("Sharpness"), q.BackgroundSharpness)
 			}
 			if q.BackgroundColor != "" {
-				fmt.Printf("    Color: %s\n", q.BackgroundColor)
+				fmt.Printf("    %s: %s\n", tr("Color"), q.BackgroundColor)
 			}
 		}
 	}
 
 	// Moderation labels
 	if len(result.Moderation) > 0 {
-		fmt.Println("\nModeration:")
+		fmt.Println("\n" + tr("Moderation") + ":")
 		for _, label := range result.Moderation {
 			parts := []string{}
 			if label.Parent != "" {
-				parts = append(parts, fmt.Sprintf("parent: %s", label.Parent))
+				parts = append(parts, fmt.Sprintf("%s: %s", tr("parent"), label.Parent))
 			}
 			if label.Severity != "" {
-				parts = append(parts, fmt.Sprintf("severity: %s", label.Severity))
+				parts = append(parts, fmt.Sprintf("%s: %s", tr("severity"), label.Severity))
 			}
 			if label.Confidence > 0 {
-				parts = append(parts, fmt.Sprintf("confidence: %.1f%%", label.Confidence*100))
+				parts = append(parts, fmt.Sprintf("%s: %.1f%%", tr("confidence"), label.Confidence*100))
 			}
 			if len(parts) > 0 {
 				fmt.Printf("  - %s (%s)\n", label.Name, strings.Join(parts, ", "))
@@ -423,7 +758,7 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 
 	// Safe search summary
 	if result.SafeSearch != nil {
-		fmt.Println("\nSafe Search Summary:")
+		fmt.Println("\n" + tr("Safe Search Summary") + ":")
 		if len(result.SafeSearch.Labels) > 0 {
 			for _, label := range result.SafeSearch.Labels {
 				parts := []string{}
@@ -434,7 +769,7 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 					parts = append(parts, fmt.Sprintf("%.1f%%", label.Confidence*100))
 				}
 				if label.Parent != "" {
-					parts = append(parts, fmt.Sprintf("parent: %s", label.Parent))
+					parts = append(parts, fmt.Sprintf("%s: %s", tr("parent"), label.Parent))
 				}
 				if len(parts) > 0 {
 					fmt.Printf("  - %s (%s)\n", label.Name, strings.Join(parts, ", "))
@@ -444,18 +779,45 @@ func outputDetectionPretty(result *detection.DetectionResult, minConfidence floa
 			}
 		}
 		if note := strings.TrimSpace(result.SafeSearch.Notes); note != "" {
-			fmt.Printf("  Notes: %s\n", note)
+			fmt.Printf("  %s: %s\n", tr("Notes"), note)
+		}
+	}
+
+	// AI-generated image assessment
+	if result.Synthetic != nil {
+		fmt.Printf("\n%s: %.1f%%\n", tr("Synthetic (AI-generated) likelihood"), result.Synthetic.Likelihood*100)
+		for _, signal := range result.Synthetic.Signals {
+			if signal.Detail != "" {
+				fmt.Printf("  - %s: %.1f%% (%s)\n", signal.Source, signal.Score*100, signal.Detail)
+			} else {
+				fmt.Printf("  - %s: %.1f%%\n", signal.Source, signal.Score*100)
+			}
+		}
+	}
+
+	// Third-party watermark assessment
+	if result.Watermark != nil {
+		fmt.Printf("\n%s: %.1f%%\n", tr("Watermark likelihood"), result.Watermark.Likelihood*100)
+		if len(result.Watermark.Text) > 0 {
+			fmt.Printf("  %s: %s\n", tr("Text"), strings.Join(result.Watermark.Text, ", "))
+		}
+		for _, signal := range result.Watermark.Signals {
+			if signal.Detail != "" {
+				fmt.Printf("  - %s: %.1f%% (%s)\n", signal.Source, signal.Score*100, signal.Detail)
+			} else {
+				fmt.Printf("  - %s: %.1f%%\n", signal.Source, signal.Score*100)
+			}
 		}
 	}
 
 	// Raw response (if requested)
 	if result.RawResponse != "" {
-		fmt.Println("\n=== Raw API Response ===")
+		fmt.Printf("\n=== %s ===\n", tr("Raw API Response"))
 		fmt.Println(result.RawResponse)
 	}
 
 	// Timestamp
-	fmt.Printf("\nProcessed at: %s\n", result.ProcessedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("\n%s: %s\n", tr("Processed at"), result.ProcessedAt.Format("2006-01-02 15:04:05"))
 
 	return nil
 }
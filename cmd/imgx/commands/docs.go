@@ -0,0 +1,419 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// DocsCommand creates the docs command
+func DocsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "docs",
+		Usage: "Generate man pages and a markdown reference from the command definitions",
+		Description: `Render the documentation of every command (usage, description, options and
+examples) from the same definitions that drive --help, so packaged installs
+can ship manuals that never go stale.
+
+"man" writes one page per command (imgx.1, imgx-resize.1, imgx-detect-bench.1,
+...) into --out-dir; "markdown" writes a single reference document to -o or
+stdout.
+
+Examples:
+  imgx docs man --out-dir /usr/local/share/man/man1
+  imgx docs markdown -o docs/REFERENCE.md
+  man ./imgx-resize.1`,
+		Commands: []*cli.Command{
+			docsManCommand(),
+			docsMarkdownCommand(),
+		},
+	}
+}
+
+func docsManCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "man",
+		Usage: "Write a man page per command",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "out-dir",
+				Usage: "directory for the man pages",
+				Value: ".",
+			},
+			&cli.StringFlag{
+				Name:  "section",
+				Usage: "manual section of the pages",
+				Value: "1",
+			},
+		},
+		Action: docsManAction,
+	}
+}
+
+func docsMarkdownCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "markdown",
+		Usage:  "Write the command reference as markdown",
+		Action: docsMarkdownAction,
+	}
+}
+
+func docsManAction(ctx context.Context, cmd *cli.Command) error {
+	dir, section := cmd.String("out-dir"), cmd.String("section")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	root := cmd.Root()
+	pages := 0
+	var write func(c *cli.Command, path []string) error
+	write = func(c *cli.Command, path []string) error {
+		file := filepath.Join(dir, strings.Join(path, "-")+"."+section)
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to write man page: %w", err)
+		}
+		writeManPage(f, root, c, path, section)
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write man page: %w", err)
+		}
+		pages++
+		for _, sub := range documentedCommands(c) {
+			if err := write(sub, append(path[:len(path):len(path)], sub.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := write(root, []string{root.Name}); err != nil {
+		return err
+	}
+	infof("Wrote %d man pages to %s", pages, dir)
+	return nil
+}
+
+func docsMarkdownAction(ctx context.Context, cmd *cli.Command) error {
+	outputPath := cmd.String("output")
+	if outputPath == "" {
+		writeMarkdownReference(os.Stdout, cmd.Root())
+		return nil
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to write reference: %w", err)
+	}
+	writeMarkdownReference(f, cmd.Root())
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write reference: %w", err)
+	}
+	if cmd.Bool("verbose") {
+		fmt.Printf("%s: %s\n", tr("Saved"), outputPath)
+	}
+	return nil
+}
+
+// commandDoc is the documentation of a command, with the examples split
+// from its description
+type commandDoc struct {
+	Description string
+	Examples    []string
+}
+
+// parseCommandDoc splits the "Examples:" (or "Example:") block that closes
+// command descriptions from the text before it. Example lines are
+// unindented; comment lines ("# ...") are kept.
+func parseCommandDoc(c *cli.Command) commandDoc {
+	lines := strings.Split(strings.TrimSpace(c.Description), "\n")
+	for i, line := range lines {
+		if heading := strings.TrimSpace(line); heading != "Examples:" && heading != "Example:" {
+			continue
+		}
+		doc := commandDoc{Description: strings.TrimSpace(strings.Join(lines[:i], "\n"))}
+		for _, ex := range lines[i+1:] {
+			if ex = strings.TrimSpace(ex); ex != "" {
+				doc.Examples = append(doc.Examples, ex)
+			}
+		}
+		return doc
+	}
+	return commandDoc{Description: strings.Join(lines, "\n")}
+}
+
+// documentedCommands returns the visible subcommands of c, without help
+func documentedCommands(c *cli.Command) []*cli.Command {
+	var cmds []*cli.Command
+	for _, sub := range c.Commands {
+		if !sub.Hidden && sub.Name != "help" {
+			cmds = append(cmds, sub)
+		}
+	}
+	return cmds
+}
+
+// documentedFlags returns the visible flags of c, without --help
+func documentedFlags(c *cli.Command) []cli.Flag {
+	var flags []cli.Flag
+	for _, f := range c.Flags {
+		if vf, ok := f.(cli.VisibleFlag); ok && !vf.IsVisible() {
+			continue
+		}
+		if f.Names()[0] == "help" {
+			continue
+		}
+		flags = append(flags, f)
+	}
+	return flags
+}
+
+// flagDoc describes a flag: its names as typed with the value type
+// ("--width, -w int"), usage, default and environment variables
+type flagDoc struct {
+	Names    []string
+	Type     string
+	Usage    string
+	Default  string
+	Required bool
+	EnvVars  []string
+}
+
+func describeFlag(f cli.Flag) flagDoc {
+	var d flagDoc
+	for _, name := range f.Names() {
+		d.Names = append(d.Names, dashedFlag(name))
+	}
+	if rf, ok := f.(cli.RequiredFlag); ok {
+		d.Required = rf.IsRequired()
+	}
+	df, ok := f.(cli.DocGenerationFlag)
+	if !ok {
+		return d
+	}
+	d.Usage = df.GetUsage()
+	d.EnvVars = df.GetEnvVars()
+	if df.TakesValue() {
+		d.Type = df.TypeName()
+	}
+	if df.IsDefaultVisible() {
+		d.Default = df.GetDefaultText()
+		if d.Default == "" {
+			d.Default = df.GetValue()
+		}
+	}
+	if v, ok := f.Get().(bool); ok && v {
+		d.Default = "true"
+	}
+	if d.Default == `""` || d.Default == "[]" || d.Default == "false" {
+		d.Default = ""
+	}
+	return d
+}
+
+// synopsis returns the usage line of c as typed on the command line
+func synopsis(path []string, c *cli.Command) string {
+	s := strings.Join(path, " ")
+	if len(documentedFlags(c)) > 0 {
+		s += " [options]"
+	}
+	if c.ArgsUsage != "" {
+		s += " " + c.ArgsUsage
+	} else if len(documentedCommands(c)) > 0 {
+		s += " <command>"
+	}
+	return s
+}
+
+// writeMarkdownReference writes the reference of root and all of its
+// commands in the style of docs/CLI.md
+func writeMarkdownReference(w io.Writer, root *cli.Command) {
+	fmt.Fprintf(w, "# %s command reference\n\n", root.Name)
+	fmt.Fprintf(w, "%s.", root.Usage)
+	if root.Version != "" {
+		fmt.Fprintf(w, " This reference is generated from %s %s with `%s docs markdown`.", root.Name, root.Version, root.Name)
+	}
+	fmt.Fprint(w, "\n\n## Global Options\n\nGlobal options can be used with any command:\n\n")
+	writeMarkdownFlags(w, documentedFlags(root))
+	fmt.Fprint(w, "\n## Commands\n")
+
+	var write func(c *cli.Command, path []string)
+	write = func(c *cli.Command, path []string) {
+		fmt.Fprintf(w, "\n%s `%s` - %s\n\n", strings.Repeat("#", min(len(path)+1, 4)), strings.Join(path[1:], " "), c.Usage)
+		fmt.Fprintf(w, "```\n%s\n```\n", synopsis(path, c))
+
+		doc := parseCommandDoc(c)
+		if doc.Description != "" {
+			fmt.Fprintln(w)
+			writeMarkdownText(w, doc.Description)
+		}
+		if flags := documentedFlags(c); len(flags) > 0 {
+			fmt.Fprint(w, "\n**Options:**\n\n")
+			writeMarkdownFlags(w, flags)
+		}
+		if subs := documentedCommands(c); len(subs) > 0 {
+			fmt.Fprint(w, "\n**Commands:**\n\n")
+			for _, sub := range subs {
+				fmt.Fprintf(w, "- `%s` - %s\n", sub.Name, sub.Usage)
+			}
+		}
+		if len(doc.Examples) > 0 {
+			fmt.Fprintf(w, "\n**Examples:**\n\n```bash\n%s\n```\n", strings.Join(doc.Examples, "\n"))
+		}
+		for _, sub := range documentedCommands(c) {
+			write(sub, append(path[:len(path):len(path)], sub.Name))
+		}
+	}
+	for _, c := range documentedCommands(root) {
+		write(c, []string{root.Name, c.Name})
+	}
+}
+
+// writeMarkdownText writes free-form description text: indented blocks
+// (lists, tables, setup steps) are fenced so markdown keeps their layout
+func writeMarkdownText(w io.Writer, text string) {
+	for i, block := range strings.Split(text, "\n\n") {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if isIndentedBlock(block) {
+			fmt.Fprintf(w, "```text\n%s\n```\n", strings.ReplaceAll(block, "\t", "    "))
+		} else {
+			fmt.Fprintln(w, block)
+		}
+	}
+}
+
+// isIndentedBlock reports whether any line of the paragraph is indented
+func isIndentedBlock(block string) bool {
+	for _, line := range strings.Split(block, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeMarkdownFlags(w io.Writer, flags []cli.Flag) {
+	fmt.Fprint(w, "| Flag | Description | Default |\n|------|-------------|---------|\n")
+	for _, f := range flags {
+		d := describeFlag(f)
+		name := "`" + strings.Join(d.Names, ", ")
+		if d.Type != "" {
+			name += " <" + d.Type + ">"
+		}
+		name += "`"
+		usage := strings.ReplaceAll(d.Usage, "|", `\|`)
+		if d.Required {
+			usage += " (required)"
+		}
+		for _, env := range d.EnvVars {
+			usage += fmt.Sprintf(" (also `$%s`)", env)
+		}
+		def := ""
+		if d.Default != "" {
+			def = "`" + d.Default + "`"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", name, usage, def)
+	}
+}
+
+// writeManPage writes the roff man page of c, found at path under root
+func writeManPage(w io.Writer, root, c *cli.Command, path []string, section string) {
+	name := strings.Join(path, "-")
+	fmt.Fprintf(w, ".TH %s %s \"\" \"%s %s\" \"%s Manual\"\n", roffEscape(strings.ToUpper(name)), section, root.Name, root.Version, root.Name)
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(c.Usage))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n", roffEscape(synopsis(path, c)))
+
+	doc := parseCommandDoc(c)
+	if doc.Description != "" {
+		fmt.Fprint(w, ".SH DESCRIPTION\n")
+		writeRoffText(w, doc.Description)
+	}
+
+	heading := "OPTIONS"
+	if c == root {
+		heading = "GLOBAL OPTIONS"
+	}
+	if flags := documentedFlags(c); len(flags) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", heading)
+		for _, f := range flags {
+			d := describeFlag(f)
+			var names []string
+			for _, n := range d.Names {
+				names = append(names, `\fB`+roffEscape(n)+`\fR`)
+			}
+			fmt.Fprintf(w, ".TP\n%s", strings.Join(names, ", "))
+			if d.Type != "" {
+				fmt.Fprintf(w, ` \fI%s\fR`, d.Type)
+			}
+			usage := roffEscape(d.Usage)
+			if d.Required {
+				usage += " (required)"
+			}
+			if d.Default != "" {
+				usage += " (default: " + roffEscape(d.Default) + ")"
+			}
+			for _, env := range d.EnvVars {
+				usage += " [$" + roffEscape(env) + "]"
+			}
+			fmt.Fprintf(w, "\n%s\n", usage)
+		}
+	}
+
+	if subs := documentedCommands(c); len(subs) > 0 {
+		fmt.Fprint(w, ".SH COMMANDS\n")
+		for _, sub := range subs {
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR(%s)\n%s\n", roffEscape(name+"-"+sub.Name), section, roffEscape(sub.Usage))
+		}
+	}
+
+	if len(doc.Examples) > 0 {
+		fmt.Fprint(w, ".SH EXAMPLES\n.RS 4\n.nf\n")
+		for _, ex := range doc.Examples {
+			fmt.Fprintln(w, roffEscape(ex))
+		}
+		fmt.Fprint(w, ".fi\n.RE\n")
+	}
+
+	if c != root {
+		fmt.Fprintf(w, ".SH SEE ALSO\n\\fB%s\\fR(%s) for the global options", roffEscape(root.Name), section)
+		if len(path) > 2 {
+			fmt.Fprintf(w, ", \\fB%s\\fR(%s)", roffEscape(strings.Join(path[:len(path)-1], "-")), section)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeRoffText writes description text as roff paragraphs; indented
+// blocks keep their layout
+func writeRoffText(w io.Writer, text string) {
+	for i, block := range strings.Split(text, "\n\n") {
+		if i > 0 {
+			fmt.Fprint(w, ".PP\n")
+		}
+		if isIndentedBlock(block) {
+			fmt.Fprint(w, ".nf\n")
+			for _, line := range strings.Split(block, "\n") {
+				fmt.Fprintln(w, roffEscape(strings.ReplaceAll(line, "\t", "    ")))
+			}
+			fmt.Fprint(w, ".fi\n")
+			continue
+		}
+		for _, line := range strings.Split(block, "\n") {
+			fmt.Fprintln(w, roffEscape(strings.TrimSpace(line)))
+		}
+	}
+}
+
+// roffEscape escapes backslashes and hyphens, and protects lines that
+// would start with a roff control character
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// EditCommand creates the edit command
+func EditCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "edit",
+		Usage:     "Edit an image from a natural language instruction (AI)",
+		ArgsUsage: "<input> <instruction>",
+		Description: `Send the image and an instruction to the image editing model of Gemini or
+OpenAI and save the result. Uses the same API keys as 'imgx detect'
+(GEMINI_API_KEY, OPENAI_API_KEY).
+
+Models work at fixed resolutions; the result is resized back to the size of
+the input (cropping to its aspect ratio if needed) unless --keep-model-size
+is set.
+
+Examples:
+  imgx edit street.jpg "remove the power lines" -o clean.jpg
+  imgx edit beach.jpg "make the sky bluer" --provider openai
+  imgx edit product.png "put it on a white marble table" --keep-model-size`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "editing provider: gemini, google (alias), openai",
+				Value:   "gemini",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-model-size",
+				Usage: "keep the resolution returned by the model",
+			},
+		},
+		Action: editAction,
+	}
+}
+
+func editAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 2 {
+		return fmt.Errorf("input file and instruction required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	instruction := strings.Join(cmd.Args().Slice()[1:], " ")
+
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	provider := detection.ResolveProviderAlias(cmd.String("provider"))
+	edited, err := detection.Transform(ctx, img.ToNRGBA(), provider, instruction)
+	if err != nil {
+		return err
+	}
+
+	result := imgx.FromImage(edited)
+	result.GetMetadata().AddOperation("transform", fmt.Sprintf("provider=%s, instruction=%q", provider, instruction))
+	if b := img.Bounds(); !cmd.Bool("keep-model-size") && result.Bounds().Size() != b.Size() {
+		result = result.Fill(b.Dx(), b.Dy(), imgx.Center, imgx.Lanczos)
+	}
+
+	outputPath := getOutputPath(cmd, inputPath, "-edited")
+	return saveImage(cmd, result, outputPath)
+}
@@ -10,8 +10,9 @@ import (
 // BlurCommand creates the blur command
 func BlurCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "blur",
-		Usage: "Apply Gaussian blur to image",
+		Name:      "blur",
+		Usage:     "Apply Gaussian blur to image",
+		ArgsUsage: "<input>",
 		Description: `Apply a Gaussian blur effect to the image.
 The sigma parameter controls the blur strength (higher = more blur).
 
@@ -51,7 +52,7 @@ func blurAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	if cmd.Bool("verbose") {
-		fmt.Printf("Applying Gaussian blur with sigma: %.2f\n", sigma)
+		infof("Applying Gaussian blur with sigma: %.2f", sigma)
 	}
 
 	// Apply blur
@@ -65,8 +66,9 @@ func blurAction(ctx context.Context, cmd *cli.Command) error {
 // SharpenCommand creates the sharpen command
 func SharpenCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "sharpen",
-		Usage: "Sharpen image",
+		Name:      "sharpen",
+		Usage:     "Sharpen image",
+		ArgsUsage: "<input>",
 		Description: `Sharpen the image using unsharp masking.
 The sigma parameter controls the sharpening strength (higher = more sharpening).
 
@@ -106,7 +108,7 @@ func sharpenAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	if cmd.Bool("verbose") {
-		fmt.Printf("Applying sharpening with sigma: %.2f\n", sigma)
+		infof("Applying sharpening with sigma: %.2f", sigma)
 	}
 
 	// Apply sharpen
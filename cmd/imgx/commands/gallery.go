@@ -0,0 +1,311 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+)
+
+// galleryThumbSize bounds the thumbnails of the gallery report
+const galleryThumbSize = 320
+
+// galleryItem is an image saved during the run, as written to report.json
+type galleryItem struct {
+	Source      string   `json:"source,omitempty"`
+	Output      string   `json:"output"`
+	SourceBytes int64    `json:"source_bytes,omitempty"`
+	OutputBytes int64    `json:"output_bytes"`
+	SourceSize  string   `json:"source_size,omitempty"` // WIDTHxHEIGHT after auto-orientation
+	OutputSize  string   `json:"output_size"`
+	Operations  []string `json:"operations,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	SafeSearch  string   `json:"safe_search,omitempty"` // "safe" or the flagged categories
+	Flagged     bool     `json:"flagged,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+
+	// Thumbnails, relative to the report directory
+	Before string `json:"before_thumbnail,omitempty"`
+	After  string `json:"after_thumbnail,omitempty"`
+}
+
+// galleryReport is the content of report.json
+type galleryReport struct {
+	Version int           `json:"version"`
+	Command string        `json:"command"`
+	Created time.Time     `json:"created"`
+	Items   []galleryItem `json:"items"`
+	Totals  struct {
+		Images      int   `json:"images"`
+		SourceBytes int64 `json:"source_bytes"`
+		OutputBytes int64 `json:"output_bytes"`
+	} `json:"totals"`
+}
+
+// gallery collects the images saved while a report is requested
+var gallery struct {
+	mu      sync.Mutex
+	enabled bool
+	items   []galleryItem
+}
+
+// StartGallery starts recording the images saved by the commands, for
+// WriteGallery
+func StartGallery() {
+	gallery.mu.Lock()
+	defer gallery.mu.Unlock()
+	gallery.enabled = true
+	gallery.items = nil
+}
+
+// recordSave adds an image saved to path to the gallery, if one is recorded
+func recordSave(img *imgx.Image, path string, warnings []string) {
+	gallery.mu.Lock()
+	enabled := gallery.enabled
+	gallery.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	item := galleryItem{
+		Output:     path,
+		OutputSize: fmt.Sprintf("%dx%d", img.Bounds().Dx(), img.Bounds().Dy()),
+		Warnings:   warnings,
+	}
+	if info, err := os.Stat(path); err == nil {
+		item.OutputBytes = info.Size()
+	}
+	meta := img.GetMetadata()
+	if meta != nil {
+		item.Source = meta.SourcePath
+		for _, op := range meta.Operations {
+			if op.Parameters != "" {
+				item.Operations = append(item.Operations, op.Action+" "+op.Parameters)
+			} else {
+				item.Operations = append(item.Operations, op.Action)
+			}
+		}
+		if meta.File != nil {
+			item.SourceBytes = meta.File.Size
+		}
+		result, _ := meta.DetectionResult.(*detection.DetectionResult)
+		if result == nil && item.Source != "" {
+			result = readDetectionSidecar(item.Source)
+		}
+		if result != nil {
+			item.Labels = galleryLabels(result)
+			item.SafeSearch, item.Flagged = safeSearchVerdict(result)
+		}
+	}
+
+	gallery.mu.Lock()
+	gallery.items = append(gallery.items, item)
+	gallery.mu.Unlock()
+}
+
+// recordFile adds an image that a command encoded and wrote to path itself
+// to the gallery, if one is recorded. source is the file it was made from
+// and width, height its size.
+func recordFile(path, source string, width, height int, warnings []string) {
+	gallery.mu.Lock()
+	enabled := gallery.enabled
+	gallery.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	item := galleryItem{
+		Source:     source,
+		Output:     path,
+		OutputSize: fmt.Sprintf("%dx%d", width, height),
+		Warnings:   warnings,
+	}
+	if info, err := os.Stat(path); err == nil {
+		item.OutputBytes = info.Size()
+	}
+	if info, err := os.Stat(source); err == nil {
+		item.SourceBytes = info.Size()
+	}
+	if result := readDetectionSidecar(source); result != nil {
+		item.Labels = galleryLabels(result)
+		item.SafeSearch, item.Flagged = safeSearchVerdict(result)
+	}
+
+	gallery.mu.Lock()
+	gallery.items = append(gallery.items, item)
+	gallery.mu.Unlock()
+}
+
+// readDetectionSidecar returns the detection sidecar of path, or nil
+func readDetectionSidecar(path string) *detection.DetectionResult {
+	data, err := os.ReadFile(path + sidecarSuffix)
+	if err != nil {
+		return nil
+	}
+	var result detection.DetectionResult
+	if json.Unmarshal(data, &result) != nil {
+		return nil
+	}
+	return &result
+}
+
+// galleryLabels returns the labels of result by decreasing confidence, as
+// "name (92%)"
+func galleryLabels(result *detection.DetectionResult) []string {
+	labels := append([]detection.Label(nil), result.Labels...)
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Confidence > labels[j].Confidence
+	})
+	var out []string
+	for _, l := range labels {
+		if l.Confidence > 0 {
+			out = append(out, fmt.Sprintf("%s (%.0f%%)", l.Name, l.Confidence*100))
+		} else {
+			out = append(out, l.Name)
+		}
+	}
+	return out
+}
+
+// WriteGallery writes the report of the images saved since StartGallery to
+// dir: index.html, a static gallery with before/after thumbnails, and
+// report.json for scripts. Nothing is written when no image was saved.
+func WriteGallery(dir string, args []string) error {
+	gallery.mu.Lock()
+	items := append([]galleryItem(nil), gallery.items...)
+	gallery.enabled = false
+	gallery.mu.Unlock()
+	if len(items) == 0 {
+		return nil
+	}
+
+	thumbs := filepath.Join(dir, "thumbs")
+	if err := os.MkdirAll(thumbs, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	report := galleryReport{Version: 1, Command: strings.Join(args, " "), Created: time.Now()}
+	for i, item := range items {
+		if item.Source != "" {
+			if img, err := imgx.Load(item.Source, imgx.Options{AutoOrient: true, DisableMetadata: true}); err == nil {
+				b := img.Bounds()
+				item.SourceSize = fmt.Sprintf("%dx%d", b.Dx(), b.Dy())
+				item.Before = writeGalleryThumb(img, thumbs, fmt.Sprintf("%03d-before.jpg", i+1))
+			}
+		}
+		if img, err := imgx.Load(item.Output, imgx.Options{AutoOrient: true, DisableMetadata: true}); err == nil {
+			item.After = writeGalleryThumb(img, thumbs, fmt.Sprintf("%03d-after.jpg", i+1))
+		}
+		report.Items = append(report.Items, item)
+		report.Totals.Images++
+		report.Totals.SourceBytes += item.SourceBytes
+		report.Totals.OutputBytes += item.OutputBytes
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.json"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := galleryTemplate.Execute(&buf, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// writeGalleryThumb saves a JPEG thumbnail of img as thumbs/name and returns
+// its path relative to the report, or "" if it could not be written
+func writeGalleryThumb(img *imgx.Image, thumbs, name string) string {
+	thumb := img.Fit(galleryThumbSize, galleryThumbSize, imgx.Lanczos)
+	// Flatten on white, since JPEG has no alpha
+	bg := imgx.New(thumb.Bounds().Dx(), thumb.Bounds().Dy(), color.White)
+	flat := imgx.Overlay(bg, thumb.ToNRGBA(), thumb.Bounds().Min, 1)
+	f, err := os.Create(filepath.Join(thumbs, name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if err := imgx.Encode(f, flat, imgx.JPEG, imgx.JPEGQuality(85)); err != nil {
+		return ""
+	}
+	return "thumbs/" + name
+}
+
+// sizeDelta formats the change from before to after bytes as a percentage
+func sizeDelta(before, after int64) string {
+	if before <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.1f%%", float64(after-before)/float64(before)*100)
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Funcs(template.FuncMap{
+	"bytes": FormatBytes,
+	"delta": sizeDelta,
+	"base":  filepath.Base,
+	"shrunk": func(before, after int64) bool {
+		return before > 0 && after < before
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>imgx report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(340px, 1fr)); gap: 1.5em; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.8em; }
+.card h3 { font-size: 1em; margin: 0 0 0.5em; word-break: break-all; }
+.thumb { position: relative; text-align: center; }
+.thumb img { max-width: 100%; max-height: 320px; background: repeating-conic-gradient(#eee 0 25%, #fff 0 50%) 0 0 / 16px 16px; }
+.thumb .before, .toggle:checked ~ .thumb .after { display: none; }
+.toggle:checked ~ .thumb .before { display: inline; }
+.toggle { margin-left: 0; }
+.meta { font-size: 0.85em; color: #555; }
+.smaller { color: #1a7f37; } .larger { color: #cf222e; }
+.ops { font-size: 0.85em; padding-left: 1.2em; margin: 0.5em 0; }
+.label { display: inline-block; font-size: 0.8em; background: #eef; border-radius: 3px; padding: 0.1em 0.4em; margin: 0.1em; }
+.warning { font-size: 0.85em; color: #9a6700; }
+.flagged { font-size: 0.85em; color: #cf222e; font-weight: bold; } .safe { font-size: 0.85em; color: #1a7f37; }
+code { background: #f4f4f4; padding: 0.1em 0.3em; }
+</style>
+</head>
+<body>
+<h1>imgx report</h1>
+<p><code>{{.Command}}</code><br>{{.Created.Format "Mon, 02 Jan 2006 15:04:05 MST"}} &middot; {{.Totals.Images}} images &middot;
+{{bytes .Totals.SourceBytes}} &rarr; {{bytes .Totals.OutputBytes}}{{with delta .Totals.SourceBytes .Totals.OutputBytes}} ({{.}}){{end}}</p>
+<div class="grid">
+{{range $i, $item := .Items}}<div class="card">
+<h3>{{base .Output}}</h3>
+{{if .Before}}<input type="checkbox" class="toggle" id="toggle{{$i}}"><label for="toggle{{$i}}">show original</label>{{end}}
+<div class="thumb">
+{{if .After}}<img class="after" src="{{.After}}" alt="result">{{end}}
+{{if .Before}}<img class="before" src="{{.Before}}" alt="original">{{end}}
+</div>
+<p class="meta">{{if .Source}}{{.Source}}{{with .SourceSize}} &middot; {{.}}{{end}} &rarr; {{end}}{{.Output}} &middot; {{.OutputSize}}<br>
+{{if .SourceBytes}}{{bytes .SourceBytes}} &rarr; {{end}}{{bytes .OutputBytes}}{{with delta .SourceBytes .OutputBytes}} <span class="{{if shrunk $item.SourceBytes $item.OutputBytes}}smaller{{else}}larger{{end}}">{{.}}</span>{{end}}</p>
+{{if .Operations}}<ol class="ops">{{range .Operations}}<li>{{.}}</li>{{end}}</ol>{{end}}
+{{if .Labels}}<p>{{range .Labels}}<span class="label">{{.}}</span>{{end}}</p>{{end}}
+{{with .SafeSearch}}<p class="{{if $item.Flagged}}flagged{{else}}safe{{end}}">{{.}}</p>{{end}}
+{{range .Warnings}}<p class="warning">{{.}}</p>{{end}}
+</div>
+{{end}}</div>
+</body>
+</html>
+`))
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// GenerateCommand creates the generate command
+func GenerateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "generate",
+		Usage: "Generate an image from a text prompt (AI)",
+		Description: `Create an image with the image model of Gemini or OpenAI. Uses the same API
+keys as 'imgx detect' (GEMINI_API_KEY, OPENAI_API_KEY).
+
+Models produce a few fixed sizes: the closest aspect ratio is requested and
+the result is cropped and resized to --size. Without -o, the file is named
+after the prompt.
+
+Examples:
+  imgx generate --prompt "isometric office illustration" -o office.png
+  imgx generate --prompt "mountain lake at dawn, watercolor" --size 1920x1080 --provider openai
+  imgx generate -p "flat icon of a rocket" --size 512x512 --format webp`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "prompt",
+				Aliases:  []string{"p"},
+				Usage:    "description of the image to generate",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "size",
+				Usage: "output size as WIDTHxHEIGHT",
+				Value: "1024x1024",
+			},
+			&cli.StringFlag{
+				Name:  "provider",
+				Usage: "generation provider: gemini, google (alias), openai",
+				Value: "gemini",
+			},
+		},
+		Action: generateAction,
+	}
+}
+
+func generateAction(ctx context.Context, cmd *cli.Command) error {
+	width, height, err := ParseSize(cmd.String("size"))
+	if err != nil {
+		return err
+	}
+
+	prompt := cmd.String("prompt")
+	provider := detection.ResolveProviderAlias(cmd.String("provider"))
+	generated, err := detection.Generate(ctx, provider, prompt, &detection.GenerateOptions{Width: width, Height: height})
+	if err != nil {
+		return err
+	}
+
+	img := imgx.FromImage(generated)
+	img.GetMetadata().AddOperation("generate", fmt.Sprintf("provider=%s, prompt=%q", provider, prompt))
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		img = img.Fill(width, height, imgx.Center, imgx.Lanczos)
+	}
+
+	outputPath := cmd.String("output")
+	if outputPath == "" {
+		name := Slugify(prompt)
+		if len(name) > 40 {
+			name = strings.TrimRight(name[:40], "-")
+		}
+		if name == "" {
+			name = "generated"
+		}
+		outputPath = name + ".png"
+		if format := cmd.String("format"); format != "" {
+			if f, err := ParseFormat(format); err == nil {
+				outputPath = changeExtension(outputPath, f)
+			}
+		}
+	}
+	return saveImage(cmd, img, outputPath)
+}
+
+// ParseSize parses a size given as "WIDTHxHEIGHT"
+func ParseSize(s string) (int, int, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid size %q: expected WIDTHxHEIGHT", s)
+	}
+	width, errW := strconv.Atoi(parts[0])
+	height, errH := strconv.Atoi(parts[1])
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q: width and height must be positive integers", s)
+	}
+	return width, height, nil
+}
@@ -1,7 +1,13 @@
 package commands
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/razzkumar/imgx"
 	"github.com/urfave/cli/v3"
@@ -9,24 +15,19 @@ import (
 
 // loadImage loads an image from the specified path, respecting global flags
 func loadImage(cmd *cli.Command, path string) (*imgx.Image, error) {
-	autoOrient := cmd.Bool("auto-orient")
-
-	var img *imgx.Image
-	var err error
-
-	if autoOrient {
-		img, err = imgx.Load(path, imgx.Options{AutoOrient: true})
-	} else {
-		img, err = imgx.Load(path)
+	opts := imgx.Options{AutoOrient: cmd.Bool("auto-orient"), Profile: cmd.Bool("profile")}
+	if limit := cmd.Int("memory-limit"); limit > 0 {
+		opts.Decode = append(opts.Decode, imgx.WithMemoryLimit(int64(limit)<<20))
 	}
 
+	img, err := imgx.Load(path, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open image: %w", err)
 	}
 
 	if cmd.Bool("verbose") {
 		bounds := img.Bounds()
-		fmt.Printf("Loaded: %s (%dx%d)\n", path, bounds.Dx(), bounds.Dy())
+		fmt.Printf("%s: %s (%dx%d)\n", tr("Loaded"), path, bounds.Dx(), bounds.Dy())
 	}
 
 	return img, nil
@@ -34,15 +35,28 @@ func loadImage(cmd *cli.Command, path string) (*imgx.Image, error) {
 
 // saveImage saves an image to the specified path, respecting global flags
 func saveImage(cmd *cli.Command, img *imgx.Image, path string) error {
-	quality := cmd.Int("quality")
-	formatName := cmd.String("format")
+	return saveImageAs(cmd, img, path, cmd.String("format"), cmd.Int("quality"))
+}
 
+// saveImageAs is saveImage with an explicit format and JPEG quality, plus
+// extra save options
+func saveImageAs(cmd *cli.Command, img *imgx.Image, path, formatName string, quality int, extra ...imgx.SaveOption) error {
 	var opts []imgx.SaveOption
 
 	// Add quality option for JPEG
 	if quality > 0 {
 		opts = append(opts, imgx.WithJPEGQuality(quality))
 	}
+	if cmd.IsSet("effort") {
+		opts = append(opts, imgx.WithWebPEffort(cmd.Int("effort")))
+	}
+	if cmd.Bool("strict") {
+		opts = append(opts, imgx.Strict())
+	}
+	if policy, err := imgx.ParseHookPolicy(cmd.String("post-policy")); err == nil {
+		opts = append(opts, imgx.WithHookPolicy(policy))
+	}
+	opts = append(opts, extra...)
 
 	// If format is specified, ensure output path has correct extension
 	if formatName != "" {
@@ -57,21 +71,73 @@ func saveImage(cmd *cli.Command, img *imgx.Image, path string) error {
 
 	if cmd.Bool("verbose") {
 		bounds := img.Bounds()
-		fmt.Printf("Saving: %s (%dx%d)\n", path, bounds.Dx(), bounds.Dy())
+		fmt.Printf("%s: %s (%dx%d)\n", tr("Saving"), path, bounds.Dx(), bounds.Dy())
 	}
 
-	err := img.Save(path, opts...)
+	result, err := img.SaveWithResult(path, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to save image: %w", err)
 	}
 
+	// Metadata that is never carried over (missing exiftool, source EXIF)
+	// and the default quality exceeding that of a JPEG source are only noted
+	// in verbose mode; use --strict to fail on them.
+	var warnings []string
+	for _, w := range result.Warnings {
+		switch {
+		case w.Code == imgx.WarnQualityInflated && cmd.IsSet("quality"),
+			w.Code != imgx.WarnMetadataSkipped && w.Code != imgx.WarnEXIFDiscarded && w.Code != imgx.WarnQualityInflated:
+			warnings = append(warnings, w.Message)
+		case cmd.Bool("verbose"):
+			fmt.Printf("%s: %s\n", tr("Note"), w.Message)
+		}
+	}
+	recordSave(img, path, warnings)
+	printTimings(cmd, img, path)
+	if err := reportWarnings(cmd, path, warnings); err != nil {
+		return err
+	}
+
 	if cmd.Bool("verbose") {
-		fmt.Printf("Saved: %s\n", path)
+		fmt.Printf("%s: %s\n", tr("Saved"), path)
 	}
 
 	return nil
 }
 
+// writeImageFile writes data, an image the command encoded itself, to path
+// and handles it like saveImage does: the --post commands run on it under
+// --post-policy and it is recorded in the --gallery report. source is the
+// file the image was made from.
+func writeImageFile(cmd *cli.Command, path, source string, data []byte) error {
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+
+	info := imgx.SaveInfo{Size: int64(len(data)), SourcePath: source}
+	if format, err := imgx.FormatFromFilename(path); err == nil {
+		info.Format = format.String()
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		info.Width, info.Height = cfg.Width, cfg.Height
+	}
+	var opts []imgx.SaveOption
+	if policy, err := imgx.ParseHookPolicy(cmd.String("post-policy")); err == nil {
+		opts = append(opts, imgx.WithHookPolicy(policy))
+	}
+	result, err := imgx.RunSaveHooks(path, info, opts...)
+	if err != nil {
+		return err
+	}
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.Message)
+	}
+	recordFile(path, source, info.Width, info.Height, warnings)
+	return reportWarnings(cmd, path, warnings)
+}
+
 // getOutputPath determines the output path from flags or generates one
 func getOutputPath(cmd *cli.Command, inputPath, suffix string) string {
 	output := cmd.String("output")
@@ -115,3 +181,83 @@ func changeExtension(path string, format imgx.Format) string {
 
 	return base + ext
 }
+
+// losslessFlag is shared by the commands that support DCT-domain JPEG transforms
+func losslessFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "lossless",
+		Usage: "transform JPEG data without re-encoding (falls back to re-encoding with a warning when not possible)",
+	}
+}
+
+// tryLossless applies op to the raw JPEG data when --lossless is set. It
+// reports whether the output was written; when it returns false the caller
+// should continue with the regular pixel path.
+func tryLossless(cmd *cli.Command, inputPath, outputPath string, op func(io.Reader, io.Writer) error) (bool, error) {
+	if !cmd.Bool("lossless") {
+		return false, nil
+	}
+
+	inFormat, err := imgx.FormatFromFilename(inputPath)
+	if err != nil || inFormat != imgx.JPEG {
+		warnf("--lossless only applies to JPEG input, re-encoding %s", inputPath)
+		return false, nil
+	}
+	outFormat, err := imgx.FormatFromFilename(outputPath)
+	if formatName := cmd.String("format"); formatName != "" {
+		outFormat, err = ParseFormat(formatName)
+		outputPath = changeExtension(outputPath, outFormat)
+	}
+	if err != nil || outFormat != imgx.JPEG {
+		warnf("--lossless requires JPEG output, re-encoding %s", inputPath)
+		return false, nil
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open image: %w", err)
+	}
+	if cmd.Bool("auto-orient") && imgx.ReadOrientation(bytes.NewReader(data)) > 1 {
+		warnf("%s has an EXIF orientation tag, re-encoding to apply it (use --auto-orient=false to keep it)", inputPath)
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	if err := op(bytes.NewReader(data), &buf); err != nil {
+		if errors.Is(err, imgx.ErrNotLossless) {
+			warnf("%v; re-encoding %s", err, inputPath)
+			return false, nil
+		}
+		return false, fmt.Errorf("lossless transform failed: %w", err)
+	}
+
+	if err := writeImageFile(cmd, outputPath, inputPath, buf.Bytes()); err != nil {
+		return false, err
+	}
+	if cmd.Bool("verbose") {
+		fmt.Printf("%s: %s\n", tr("Saved losslessly"), outputPath)
+	}
+	return true, nil
+}
+
+// reportWarnings prints non-fatal issues for path and turns them into an
+// error with --warnings-as-errors
+func reportWarnings(cmd *cli.Command, path string, warnings []string) error {
+	for _, w := range warnings {
+		warnf("%s: %s", path, w)
+	}
+	if len(warnings) > 0 && cmd.Bool("warnings-as-errors") {
+		return fmt.Errorf("%s: %s (--warnings-as-errors)", path, strings.Join(warnings, "; "))
+	}
+	return nil
+}
+
+// warnf prints a warning to stderr; format is translated with tr
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, tr("Warning")+": "+tr(format)+"\n", args...)
+}
+
+// infof prints a status line to stdout; format is translated with tr
+func infof(format string, args ...interface{}) {
+	fmt.Printf(tr(format)+"\n", args...)
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// sparkBlocks are the characters of a terminal sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// HistogramCommand creates the histogram command
+func HistogramCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "histogram",
+		Usage:     "Show or render the RGB and luminance histograms",
+		ArgsUsage: "<input>",
+		Description: `Print the luminance and RGB histograms of an image as terminal sparklines, or
+render them as an image with --render. Bars are scaled to the tallest bin other
+than pure black and white, so clipped shadows and highlights show up as
+full-height spikes at the ends.
+
+Examples:
+  imgx histogram photo.jpg
+  imgx histogram photo.jpg --render hist.png
+  imgx histogram photo.jpg --render hist.png --channels luminance --width 256 --height 100
+  imgx histogram photo.jpg --json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "render",
+				Usage: "write the histogram image to this file",
+			},
+			&cli.StringFlag{
+				Name:  "channels",
+				Usage: "histograms to render: rgb, luminance or all",
+				Value: "all",
+				Validator: func(v string) error {
+					switch strings.ToLower(v) {
+					case "rgb", "luminance", "all":
+						return nil
+					}
+					return fmt.Errorf("channels must be rgb, luminance or all")
+				},
+			},
+			&cli.IntFlag{
+				Name:  "width",
+				Usage: "width of the rendered image (columns of the sparkline in the terminal)",
+			},
+			&cli.IntFlag{
+				Name:  "height",
+				Usage: "height of the rendered image",
+				Value: 200,
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the normalized histograms as JSON",
+			},
+		},
+		Action: histogramAction,
+	}
+}
+
+func histogramAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+
+	// Load image
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+	src := img.ToNRGBA()
+
+	if out := cmd.String("render"); out != "" {
+		channels := strings.ToLower(cmd.String("channels"))
+		hist := imgx.RenderHistogram(src, imgx.HistogramOptions{
+			Width:     cmd.Int("width"),
+			Height:    cmd.Int("height"),
+			RGB:       channels != "luminance",
+			Luminance: channels != "rgb",
+		})
+		return saveImage(cmd, imgx.FromImage(hist, imgx.Options{DisableMetadata: true}), out)
+	}
+
+	lum := imgx.Histogram(src)
+	red, green, blue := imgx.RGBHistogram(src)
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(map[string][256]float64{
+			"luminance": lum,
+			"red":       red,
+			"green":     green,
+			"blue":      blue,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	columns := cmd.Int("width")
+	if columns <= 0 {
+		columns = 64
+	}
+	fmt.Printf("luminance %s\n", Sparkline(lum, columns))
+	fmt.Printf("red       %s\n", Sparkline(red, columns))
+	fmt.Printf("green     %s\n", Sparkline(green, columns))
+	fmt.Printf("blue      %s\n", Sparkline(blue, columns))
+	return nil
+}
+
+// Sparkline renders a histogram as a line of block characters, one per
+// column, scaled to the tallest column other than the first and last (which
+// are clipped to full height). Empty columns are printed as spaces.
+func Sparkline(hist [256]float64, columns int) string {
+	columns = max(1, min(columns, 256))
+	values := make([]float64, columns)
+	for i, v := range hist {
+		values[i*columns/256] += v
+	}
+	// Like RenderHistogram, scale to the tallest column other than the ends
+	var peak float64
+	for i, v := range values {
+		if columns <= 2 || (i > 0 && i < columns-1) {
+			peak = max(peak, v)
+		}
+	}
+	if peak == 0 {
+		peak = max(values[0], values[columns-1])
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v == 0 || peak == 0 {
+			sb.WriteByte(' ')
+			continue
+		}
+		level := min(len(sparkBlocks)-1, int(v/peak*float64(len(sparkBlocks)-1)))
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Languages lists the languages of the CLI messages, selected with
+// IMGX_LANG. English is built in; the others are catalogs in locales/.
+var Languages = []string{"en", "es", "fr", "hi", "ne"}
+
+// helpHeadings are the section headings of the urfave/cli help templates
+var helpHeadings = []string{
+	"NAME:", "USAGE:", "VERSION:", "DESCRIPTION:", "COMMANDS:",
+	"GLOBAL OPTIONS:", "OPTIONS:", "CATEGORY:", "COPYRIGHT:",
+}
+
+// Catalog maps English CLI messages to their translation. Messages missing
+// from the catalog are shown in English.
+type Catalog map[string]string
+
+// messages is the catalog of the selected language; nil for English
+var messages Catalog
+
+// LoadCatalog returns the message catalog for lang, a language code like
+// "es" or a locale like "es_ES.UTF-8". English has an empty catalog.
+func LoadCatalog(lang string) (Catalog, error) {
+	code := languageCode(lang)
+	if code == "en" {
+		return Catalog{}, nil
+	}
+	data, err := localeFiles.ReadFile("locales/" + code + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid catalog for %q: %w", code, err)
+	}
+	return c, nil
+}
+
+// languageCode reduces a locale like "fr_FR.UTF-8" or "pt-BR" to its
+// lowercase language code; empty, "C" and "POSIX" are English
+func languageCode(lang string) string {
+	code := strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if code == "" || code == "c" || code == "posix" {
+		return "en"
+	}
+	return code
+}
+
+// T returns the translation of msg in the catalog, or msg itself
+func (c Catalog) T(msg string) string {
+	if s := c[msg]; s != "" {
+		return s
+	}
+	return msg
+}
+
+// Localize switches the CLI messages to the language selected by IMGX_LANG:
+// the help output of app (headings, command and flag descriptions) and the
+// messages printed by the commands. Long descriptions and examples stay in
+// English. An unsupported language leaves everything in English and
+// returns an error.
+func Localize(app *cli.Command) error {
+	c, err := LoadCatalog(os.Getenv("IMGX_LANG"))
+	if err != nil {
+		return err
+	}
+	if len(c) == 0 {
+		return nil
+	}
+	messages = c
+
+	pairs := make([]string, 0, 2*len(helpHeadings))
+	for _, h := range helpHeadings {
+		pairs = append(pairs, h, c.T(h))
+	}
+	headings := strings.NewReplacer(pairs...)
+	cli.RootCommandHelpTemplate = headings.Replace(cli.RootCommandHelpTemplate)
+	cli.CommandHelpTemplate = headings.Replace(cli.CommandHelpTemplate)
+	cli.SubcommandHelpTemplate = headings.Replace(cli.SubcommandHelpTemplate)
+
+	localizeFlag(c, cli.HelpFlag)
+	localizeFlag(c, cli.VersionFlag)
+	localizeCommand(c, app)
+
+	// The help command is only added when the app runs, so it is
+	// translated when help is printed
+	printHelp := cli.HelpPrinter
+	cli.HelpPrinter = func(w io.Writer, templ string, data any) {
+		if cmd, ok := data.(*cli.Command); ok {
+			localizeCommand(c, cmd)
+		}
+		printHelp(w, templ, data)
+	}
+	return nil
+}
+
+// localizeCommand translates the usage of cmd, its flags and its
+// subcommands in place
+func localizeCommand(c Catalog, cmd *cli.Command) {
+	cmd.Usage = c.T(cmd.Usage)
+	for _, f := range cmd.Flags {
+		localizeFlag(c, f)
+	}
+	for _, sub := range cmd.Commands {
+		localizeCommand(c, sub)
+	}
+}
+
+// localizeFlag translates the Usage field that every urfave/cli flag type
+// has
+func localizeFlag(c Catalog, f cli.Flag) {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	usage := v.Elem().FieldByName("Usage")
+	if usage.Kind() == reflect.String && usage.CanSet() {
+		usage.SetString(c.T(usage.String()))
+	}
+}
+
+// tr translates msg into the language selected with Localize
+func tr(msg string) string {
+	return messages.T(msg)
+}
+
+// ErrorMessage formats err for the terminal in the selected language. The
+// parts of the error chain ("failed to open image: open x.jpg: no such file
+// or directory") are translated one by one; file names and other details
+// stay as they are.
+func ErrorMessage(err error) string {
+	parts := strings.Split(err.Error(), ": ")
+	for i, p := range parts {
+		parts[i] = tr(p)
+	}
+	return tr("Error") + ": " + strings.Join(parts, ": ")
+}
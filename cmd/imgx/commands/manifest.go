@@ -0,0 +1,432 @@
+package commands
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// manifestAlgorithms are the hash algorithms of manifests
+var manifestAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ManifestCommand creates the manifest command
+func ManifestCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "manifest",
+		Usage: "Create and verify integrity manifests of image archives",
+		Commands: []*cli.Command{
+			manifestCreateCommand(),
+			manifestVerifyCommand(),
+		},
+	}
+}
+
+// manifestCreateCommand creates the manifest create subcommand
+func manifestCreateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "create",
+		Usage:     "Record the byte and pixel hashes of the images in a directory",
+		ArgsUsage: "<dir>",
+		Description: `Hash every image in a directory tree twice: the bytes of the file, and the
+decoded pixels (see imgx.PixelHash). The pixel hash doesn't depend on the file
+format, compression or metadata, so 'manifest verify' can tell files that were
+re-encoded losslessly (e.g. TIFF to PNG during a migration) from files whose
+pixels changed.
+
+Paths in the manifest are relative to the directory.
+
+Examples:
+  imgx manifest create ./processed --out manifest.json
+  imgx manifest create ./archive --algo sha512 --out archive.manifest.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "algo",
+				Usage: "hash algorithm: sha256, sha512, sha1 or md5",
+				Value: "sha256",
+				Validator: func(v string) error {
+					if manifestAlgorithms[v] == nil {
+						return fmt.Errorf("algo must be sha256, sha512, sha1 or md5")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "write the manifest to this file instead of stdout",
+			},
+		},
+		Action: manifestCreateAction,
+	}
+}
+
+// manifestVerifyCommand creates the manifest verify subcommand
+func manifestVerifyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "Check the images of a directory against a manifest",
+		ArgsUsage: "<manifest> [dir]",
+		Description: `Check every file of a manifest against the directory it was created from, or
+the given directory. Each file is reported as:
+
+  ok           the bytes match
+  re-encoded   the bytes differ but the pixels match (lossless re-encode)
+  modified     the pixels differ
+  missing      the file is gone
+
+A missing file that has a sibling with the same name and another image
+extension (photo.tif -> photo.png) is checked against it, so migrations that
+convert formats verify. Images that are not in the manifest are listed as
+untracked. The command fails when a file is modified or missing, and with
+--strict also when one was re-encoded.
+
+Examples:
+  imgx manifest verify manifest.json
+  imgx manifest verify manifest.json ./migrated
+  imgx manifest verify manifest.json --strict --json`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "fail on re-encoded files too",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the report as JSON",
+			},
+		},
+		Action: manifestVerifyAction,
+	}
+}
+
+// manifest is the JSON document written by manifest create
+type manifest struct {
+	Version   int             `json:"version"`
+	Algorithm string          `json:"algorithm"`
+	Created   time.Time       `json:"created"`
+	Root      string          `json:"root"`
+	Files     []manifestEntry `json:"files"`
+}
+
+// manifestEntry records one file; Path is slash-separated and relative to
+// the root
+type manifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Bytes  string `json:"bytes"`
+	Pixels string `json:"pixels"`
+}
+
+// manifestResult is the verification status of one file
+type manifestResult struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Found  string `json:"found,omitempty"` // the file checked instead of a missing one
+	Error  string `json:"error,omitempty"`
+}
+
+type manifestReport struct {
+	Files     []manifestResult `json:"files"`
+	Untracked []string         `json:"untracked,omitempty"`
+	Counts    map[string]int   `json:"counts"`
+}
+
+func manifestCreateAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+	root := cmd.Args().Get(0)
+	if info, err := os.Stat(root); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+
+	paths, err := CollectImageFiles([]string{root}, true)
+	if err != nil {
+		return err
+	}
+
+	algo := cmd.String("algo")
+	m := manifest{Version: 1, Algorithm: algo, Created: time.Now().UTC(), Root: root}
+	entries := make([]manifestEntry, len(paths))
+	errs := hashFiles(ctx, len(paths), func(i int) error {
+		e, err := hashManifestFile(paths[i], manifestAlgorithms[algo])
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, paths[i])
+		if err != nil {
+			return err
+		}
+		e.Path = filepath.ToSlash(rel)
+		entries[i] = *e
+		if cmd.Bool("verbose") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", tr("Hashed"), paths[i])
+		}
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if errs[i] != nil {
+			warnf("skipping %s: %v", paths[i], errs[i])
+			continue
+		}
+		m.Files = append(m.Files, e)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	out := cmd.String("out")
+	if out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	infof("Wrote %s: %d images (%s)", out, len(m.Files), algo)
+	return nil
+}
+
+func manifestVerifyAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("manifest file required")
+	}
+	m, err := readManifest(cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	root := m.Root
+	if cmd.Args().Len() > 1 {
+		root = cmd.Args().Get(1)
+	}
+
+	report, err := verifyManifest(ctx, m, root)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printManifestReport(report, cmd.Bool("verbose"))
+	}
+
+	failed := report.Counts["modified"] + report.Counts["missing"]
+	if cmd.Bool("strict") {
+		failed += report.Counts["re-encoded"]
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, len(report.Files))
+	}
+	return nil
+}
+
+// readManifest reads and checks a manifest file
+func readManifest(name string) (*manifest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", name, err)
+	}
+	if m.Version != 1 {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if manifestAlgorithms[m.Algorithm] == nil {
+		return nil, fmt.Errorf("unsupported manifest algorithm %q", m.Algorithm)
+	}
+	return &m, nil
+}
+
+// verifyManifest checks the files of m under root
+func verifyManifest(ctx context.Context, m *manifest, root string) (*manifestReport, error) {
+	paths, err := CollectImageFiles([]string{root}, true)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(paths))
+	relPaths := make([]string, len(paths))
+	for i, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil, err
+		}
+		relPaths[i] = filepath.ToSlash(rel)
+		present[relPaths[i]] = true
+	}
+
+	// A missing file is looked for under another image extension, once
+	claimed := make(map[string]bool, len(m.Files))
+	for _, e := range m.Files {
+		claimed[e.Path] = true
+	}
+	found := make([]string, len(m.Files))
+	for i, e := range m.Files {
+		found[i] = e.Path
+		if present[e.Path] {
+			continue
+		}
+		stem := strings.TrimSuffix(e.Path, path.Ext(e.Path))
+		for _, p := range relPaths {
+			if present[p] && !claimed[p] && strings.TrimSuffix(p, path.Ext(p)) == stem {
+				found[i] = p
+				claimed[p] = true
+				break
+			}
+		}
+	}
+
+	report := &manifestReport{Files: make([]manifestResult, len(m.Files)), Counts: make(map[string]int)}
+	newHash := manifestAlgorithms[m.Algorithm]
+	hashFiles(ctx, len(m.Files), func(i int) error {
+		e := m.Files[i]
+		r := manifestResult{Path: e.Path}
+		if !present[found[i]] {
+			r.Status = "missing"
+			report.Files[i] = r
+			return nil
+		}
+		if found[i] != e.Path {
+			r.Found = found[i]
+		}
+		switch got, err := hashManifestFile(filepath.Join(root, filepath.FromSlash(found[i])), newHash); {
+		case err != nil:
+			r.Status, r.Error = "modified", err.Error()
+		case got.Bytes == e.Bytes:
+			r.Status = "ok"
+		case got.Pixels != "" && got.Pixels == e.Pixels:
+			r.Status = "re-encoded"
+		default:
+			r.Status = "modified"
+		}
+		report.Files[i] = r
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range relPaths {
+		if !claimed[p] {
+			report.Untracked = append(report.Untracked, p)
+		}
+	}
+	for _, r := range report.Files {
+		report.Counts[r.Status]++
+	}
+	return report, nil
+}
+
+// hashManifestFile returns the size, dimensions and byte and pixel hashes
+// of the image at path
+func hashManifestFile(path string, newHash func() hash.Hash) (*manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := newHash()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	e := &manifestEntry{Size: size, Bytes: hex.EncodeToString(h.Sum(nil))}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, err := imgx.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := img.Bounds()
+	e.Width, e.Height = bounds.Dx(), bounds.Dy()
+	e.Pixels = hex.EncodeToString(imgx.PixelHash(img, newHash()))
+	return e, nil
+}
+
+// hashFiles calls fn for the indexes 0 to n-1 on all CPUs and returns the
+// errors by index
+func hashFiles(ctx context.Context, n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// printManifestReport prints the files that are not ok, or all files when
+// verbose, and the counts
+func printManifestReport(report *manifestReport, verbose bool) {
+	for _, r := range report.Files {
+		if r.Status == "ok" && !verbose {
+			continue
+		}
+		line := fmt.Sprintf("%-11s %s", r.Status, r.Path)
+		if r.Found != "" {
+			line += " -> " + r.Found
+		}
+		if r.Error != "" {
+			line += " (" + r.Error + ")"
+		}
+		fmt.Println(line)
+	}
+	for _, p := range report.Untracked {
+		fmt.Printf("%-11s %s\n", "untracked", p)
+	}
+	infof("%d files: %d ok, %d re-encoded, %d modified, %d missing, %d untracked",
+		len(report.Files), report.Counts["ok"], report.Counts["re-encoded"],
+		report.Counts["modified"], report.Counts["missing"], len(report.Untracked))
+}
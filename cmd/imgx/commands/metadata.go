@@ -12,9 +12,10 @@ import (
 // MetadataCommand creates the metadata command
 func MetadataCommand() *cli.Command {
 	return &cli.Command{
-		Name:    "metadata",
-		Aliases: []string{"info"},
-		Usage:   "Display image information and metadata",
+		Name:      "metadata",
+		Aliases:   []string{"info"},
+		Usage:     "Display image information and metadata",
+		ArgsUsage: "<input>",
 		Description: `Extract comprehensive image metadata using exiftool when available.
 
 If exiftool is installed, displays detailed EXIF, IPTC, and XMP metadata including:
@@ -40,6 +41,9 @@ Examples:
   # Output as JSON
   imgx metadata --json photo.jpg
 
+  # Also measure sharpness, exposure and borders (letterbox, scan margins)
+  imgx metadata --analyze still.png
+
 Installation:
   macOS:    brew install exiftool
   Ubuntu:   sudo apt-get install libimage-exiftool-perl
@@ -55,6 +59,11 @@ Installation:
 				Aliases: []string{"j"},
 				Usage:   "Output metadata as JSON",
 			},
+			&cli.BoolFlag{
+				Name:    "analyze",
+				Aliases: []string{"a"},
+				Usage:   "Analyze the pixels: sharpness, exposure and borders",
+			},
 		},
 		Action: metadataAction,
 	}
@@ -72,6 +81,9 @@ func metadataAction(ctx context.Context, cmd *cli.Command) error {
 	if cmd.Bool("basic") {
 		opts = append(opts, imgx.WithBasicOnly())
 	}
+	if cmd.Bool("analyze") {
+		opts = append(opts, imgx.WithAnalysis())
+	}
 
 	// Extract metadata
 	metadata, err := imgx.Metadata(inputPath, opts...)
@@ -97,80 +109,125 @@ func outputJSON(metadata *imgx.ImageMetadata) error {
 }
 
 func outputPretty(metadata *imgx.ImageMetadata) error {
-	fmt.Println("=== Image Metadata ===")
+	fmt.Printf("=== %s ===\n", tr("Image Metadata"))
 	fmt.Println()
 
 	// File Information
-	fmt.Println("File Information:")
-	fmt.Printf("  Path:           %s\n", metadata.FilePath)
-	fmt.Printf("  Format:         %s\n", metadata.Format)
+	fmt.Println(tr("File Information") + ":")
+	fmt.Printf("  %-15s %s\n", tr("Path")+":", metadata.FilePath)
+	fmt.Printf("  %-15s %s\n", tr("Format")+":", metadata.Format)
 	if metadata.ContentType != "" {
-		fmt.Printf("  Content Type:   %s\n", metadata.ContentType)
+		fmt.Printf("  %-15s %s\n", tr("Content Type")+":", metadata.ContentType)
 	}
-	fmt.Printf("  Size:           %s\n", FormatBytes(metadata.FileSize))
+	fmt.Printf("  %-15s %s\n", tr("Size")+":", FormatBytes(metadata.FileSize))
 	fmt.Println()
 
 	// Image Properties
-	fmt.Println("Image Properties:")
-	fmt.Printf("  Dimensions:     %dx%d\n", metadata.Width, metadata.Height)
-	fmt.Printf("  Aspect Ratio:   %s\n", metadata.AspectRatio)
-	fmt.Printf("  Megapixels:     %.2f MP\n", metadata.Megapixels)
-	fmt.Printf("  Color Model:    %s\n", metadata.ColorModel)
+	fmt.Println(tr("Image Properties") + ":")
+	fmt.Printf("  %-15s %dx%d\n", tr("Dimensions")+":", metadata.Width, metadata.Height)
+	fmt.Printf("  %-15s %s\n", tr("Aspect Ratio")+":", metadata.AspectRatio)
+	fmt.Printf("  %-15s %.2f MP\n", tr("Megapixels")+":", metadata.Megapixels)
+	fmt.Printf("  %-15s %s\n", tr("Color Model")+":", metadata.ColorModel)
 
-	// Extended metadata if available
-	if metadata.HasExtended {
-		// Image Technical Details
-		showTechnical := metadata.BitDepth > 0 || metadata.ColorSpace != "" ||
-			metadata.Compression != "" || metadata.XResolution > 0 || metadata.Orientation > 0
-		if showTechnical {
-			fmt.Println()
-			fmt.Println("Technical Details:")
-			if metadata.BitDepth > 0 {
-				fmt.Printf("  Bit Depth:      %d\n", metadata.BitDepth)
-			}
-			if metadata.ColorSpace != "" {
-				fmt.Printf("  Color Space:    %s\n", metadata.ColorSpace)
-			}
-			if metadata.Compression != "" {
-				fmt.Printf("  Compression:    %s\n", metadata.Compression)
-			}
-			if metadata.XResolution > 0 {
-				fmt.Printf("  Resolution:     %.0fx%.0f %s\n", metadata.XResolution, metadata.YResolution, metadata.ResolutionUnit)
-			}
-			if metadata.Orientation > 0 {
-				fmt.Printf("  Orientation:    %d\n", metadata.Orientation)
+	// Image Technical Details (from the format headers, or exiftool)
+	fmt.Println()
+	fmt.Println(tr("Technical Details") + ":")
+	if metadata.BitDepth > 0 {
+		fmt.Printf("  %-15s %d\n", tr("Bit Depth")+":", metadata.BitDepth)
+	}
+	if metadata.ColorSpace != "" {
+		fmt.Printf("  %-15s %s\n", tr("Color Space")+":", metadata.ColorSpace)
+	}
+	if metadata.Compression != "" {
+		fmt.Printf("  %-15s %s\n", tr("Compression")+":", metadata.Compression)
+	}
+	if metadata.JPEGQuality > 0 {
+		fmt.Printf("  %-15s "+tr("~%d (estimated)")+"\n", tr("JPEG Quality")+":", metadata.JPEGQuality)
+	}
+	fmt.Printf("  %-15s %s\n", tr("Interlaced")+":", yesNo(metadata.Interlaced))
+	fmt.Printf("  %-15s %s\n", tr("ICC Profile")+":", yesNo(metadata.HasICCProfile))
+	fmt.Printf("  %-15s %s\n", "EXIF:", yesNo(metadata.HasEXIF))
+	if metadata.XResolution > 0 {
+		fmt.Printf("  %-15s %.0fx%.0f %s\n", tr("Resolution")+":", metadata.XResolution, metadata.YResolution, metadata.ResolutionUnit)
+	}
+	if metadata.Orientation > 0 {
+		fmt.Printf("  %-15s %d\n", tr("Orientation")+":", metadata.Orientation)
+	}
+	if metadata.Frames > 1 {
+		fmt.Printf("  %-15s "+tr("%d frames")+"\n", tr("Animation")+":", metadata.Frames)
+	}
+
+	// Layer names of layered formats (PSD)
+	if len(metadata.Layers) > 0 {
+		fmt.Println()
+		fmt.Printf(tr("Layers (%d, topmost first)")+":\n", len(metadata.Layers))
+		for _, name := range metadata.Layers {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+
+	// Quantization tables of JPEG files, 8x8 in natural order
+	if len(metadata.QuantizationTables) > 0 {
+		fmt.Println()
+		fmt.Println(tr("Quantization Tables") + ":")
+		for _, t := range metadata.QuantizationTables {
+			fmt.Printf("  "+tr("Table %d")+":\n", t.ID)
+			for row := 0; row < 8; row++ {
+				fmt.Print("   ")
+				for _, v := range t.Values[row*8 : row*8+8] {
+					fmt.Printf(" %3d", v)
+				}
+				fmt.Println()
 			}
 		}
+	}
 
+	// Pixel analysis (--analyze)
+	if a := metadata.Analysis; a != nil {
+		fmt.Println()
+		fmt.Println(tr("Analysis") + ":")
+		fmt.Printf("  %-15s %.1f\n", tr("Sharpness")+":", a.Sharpness)
+		fmt.Printf("  %-15s %.1f\n", tr("Mean Luminance")+":", a.MeanLuminance)
+		fmt.Printf("  %-15s "+tr("%.1f%% shadows, %.1f%% highlights")+"\n", tr("Clipped")+":", a.ClippedShadowsPercent, a.ClippedHighlightsPercent)
+		if b := a.Borders; b.Found() {
+			fmt.Printf("  %-15s "+tr("top %d, right %d, bottom %d, left %d")+" (%s)\n", tr("Borders")+":", b.Top, b.Right, b.Bottom, b.Left, hexColor(b.Color))
+			fmt.Printf("  %-15s "+tr("%dx%d at %d,%d")+"\n", tr("Content")+":", b.Content.Dx(), b.Content.Dy(), b.Content.Min.X, b.Content.Min.Y)
+		} else {
+			fmt.Printf("  %-15s %s\n", tr("Borders")+":", tr("none"))
+		}
+	}
+
+	// Extended metadata if available
+	if metadata.HasExtended {
 		// Camera Information
 		showCamera := metadata.CameraMake != "" || metadata.CameraModel != "" ||
 			metadata.LensModel != "" || metadata.CameraSerialNumber != ""
 		if showCamera {
 			fmt.Println()
-			fmt.Println("Camera Information:")
+			fmt.Println(tr("Camera Information") + ":")
 			if metadata.CameraMake != "" {
-				fmt.Printf("  Make:           %s\n", metadata.CameraMake)
+				fmt.Printf("  %-15s %s\n", tr("Make")+":", metadata.CameraMake)
 			}
 			if metadata.CameraModel != "" {
-				fmt.Printf("  Model:          %s\n", metadata.CameraModel)
+				fmt.Printf("  %-15s %s\n", tr("Model")+":", metadata.CameraModel)
 			}
 			if metadata.CameraSerialNumber != "" {
-				fmt.Printf("  Serial Number:  %s\n", metadata.CameraSerialNumber)
+				fmt.Printf("  %-15s %s\n", tr("Serial Number")+":", metadata.CameraSerialNumber)
 			}
 			if metadata.LensMake != "" {
-				fmt.Printf("  Lens Make:      %s\n", metadata.LensMake)
+				fmt.Printf("  %-15s %s\n", tr("Lens Make")+":", metadata.LensMake)
 			}
 			if metadata.LensModel != "" {
-				fmt.Printf("  Lens:           %s\n", metadata.LensModel)
+				fmt.Printf("  %-15s %s\n", tr("Lens")+":", metadata.LensModel)
 			}
 			if metadata.LensSerialNumber != "" {
-				fmt.Printf("  Lens S/N:       %s\n", metadata.LensSerialNumber)
+				fmt.Printf("  %-15s %s\n", tr("Lens S/N")+":", metadata.LensSerialNumber)
 			}
 			if metadata.LensFocalLengthMin != "" && metadata.LensFocalLengthMax != "" {
-				fmt.Printf("  Lens Range:     %s-%s\n", metadata.LensFocalLengthMin, metadata.LensFocalLengthMax)
+				fmt.Printf("  %-15s %s-%s\n", tr("Lens Range")+":", metadata.LensFocalLengthMin, metadata.LensFocalLengthMax)
 			}
 			if metadata.FirmwareVersion != "" {
-				fmt.Printf("  Firmware:       %s\n", metadata.FirmwareVersion)
+				fmt.Printf("  %-15s %s\n", tr("Firmware")+":", metadata.FirmwareVersion)
 			}
 		}
 
@@ -179,45 +236,45 @@ func outputPretty(metadata *imgx.ImageMetadata) error {
 			metadata.ShutterSpeed != "" || metadata.ISO != ""
 		if showSettings {
 			fmt.Println()
-			fmt.Println("Camera Settings:")
+			fmt.Println(tr("Camera Settings") + ":")
 			if metadata.FocalLength != "" {
-				fmt.Printf("  Focal Length:   %s\n", metadata.FocalLength)
+				fmt.Printf("  %-15s %s\n", tr("Focal Length")+":", metadata.FocalLength)
 			}
 			if metadata.Aperture != "" {
-				fmt.Printf("  Aperture:       %s\n", metadata.Aperture)
+				fmt.Printf("  %-15s %s\n", tr("Aperture")+":", metadata.Aperture)
 			}
 			if metadata.ShutterSpeed != "" {
-				fmt.Printf("  Shutter Speed:  %s\n", metadata.ShutterSpeed)
+				fmt.Printf("  %-15s %s\n", tr("Shutter Speed")+":", metadata.ShutterSpeed)
 			}
 			if metadata.ISO != "" {
-				fmt.Printf("  ISO:            %s\n", metadata.ISO)
+				fmt.Printf("  %-15s %s\n", tr("ISO")+":", metadata.ISO)
 			}
 			if metadata.ExposureCompensation != "" {
-				fmt.Printf("  Exp. Comp.:     %s\n", metadata.ExposureCompensation)
+				fmt.Printf("  %-15s %s\n", tr("Exp. Comp.")+":", metadata.ExposureCompensation)
 			}
 			if metadata.ExposureMode != "" {
-				fmt.Printf("  Exposure Mode:  %s\n", metadata.ExposureMode)
+				fmt.Printf("  %-15s %s\n", tr("Exposure Mode")+":", metadata.ExposureMode)
 			}
 			if metadata.ExposureProgram != "" {
-				fmt.Printf("  Exp. Program:   %s\n", metadata.ExposureProgram)
+				fmt.Printf("  %-15s %s\n", tr("Exp. Program")+":", metadata.ExposureProgram)
 			}
 			if metadata.MeteringMode != "" {
-				fmt.Printf("  Metering:       %s\n", metadata.MeteringMode)
+				fmt.Printf("  %-15s %s\n", tr("Metering")+":", metadata.MeteringMode)
 			}
 			if metadata.WhiteBalance != "" {
-				fmt.Printf("  White Balance:  %s\n", metadata.WhiteBalance)
+				fmt.Printf("  %-15s %s\n", tr("White Balance")+":", metadata.WhiteBalance)
 			}
 			if metadata.Flash != "" {
-				fmt.Printf("  Flash:          %s\n", metadata.Flash)
+				fmt.Printf("  %-15s %s\n", tr("Flash")+":", metadata.Flash)
 			}
 			if metadata.FlashMode != "" {
-				fmt.Printf("  Flash Mode:     %s\n", metadata.FlashMode)
+				fmt.Printf("  %-15s %s\n", tr("Flash Mode")+":", metadata.FlashMode)
 			}
 			if metadata.FocusMode != "" {
-				fmt.Printf("  Focus Mode:     %s\n", metadata.FocusMode)
+				fmt.Printf("  %-15s %s\n", tr("Focus Mode")+":", metadata.FocusMode)
 			}
 			if metadata.SubjectDistance != "" {
-				fmt.Printf("  Subject Dist.:  %s\n", metadata.SubjectDistance)
+				fmt.Printf("  %-15s %s\n", tr("Subject Dist.")+":", metadata.SubjectDistance)
 			}
 		}
 
@@ -226,21 +283,21 @@ func outputPretty(metadata *imgx.ImageMetadata) error {
 			metadata.CreateDate != ""
 		if showTime {
 			fmt.Println()
-			fmt.Println("Date/Time:")
+			fmt.Println(tr("Date/Time") + ":")
 			if metadata.DateTimeOriginal != "" {
-				fmt.Printf("  Taken:          %s\n", metadata.DateTimeOriginal)
+				fmt.Printf("  %-15s %s\n", tr("Taken")+":", metadata.DateTimeOriginal)
 			}
 			if metadata.CreateDate != "" && metadata.CreateDate != metadata.DateTimeOriginal {
-				fmt.Printf("  Created:        %s\n", metadata.CreateDate)
+				fmt.Printf("  %-15s %s\n", tr("Created")+":", metadata.CreateDate)
 			}
 			if metadata.DateTime != "" {
-				fmt.Printf("  Modified:       %s\n", metadata.DateTime)
+				fmt.Printf("  %-15s %s\n", tr("Modified")+":", metadata.DateTime)
 			}
 			if metadata.DateTimeDigitized != "" && metadata.DateTimeDigitized != metadata.DateTimeOriginal {
-				fmt.Printf("  Digitized:      %s\n", metadata.DateTimeDigitized)
+				fmt.Printf("  %-15s %s\n", tr("Digitized")+":", metadata.DateTimeDigitized)
 			}
 			if metadata.TimeZone != "" {
-				fmt.Printf("  Time Zone:      %s\n", metadata.TimeZone)
+				fmt.Printf("  %-15s %s\n", tr("Time Zone")+":", metadata.TimeZone)
 			}
 		}
 
@@ -248,27 +305,27 @@ func outputPretty(metadata *imgx.ImageMetadata) error {
 		showGPS := metadata.GPSLatitude != "" || metadata.GPSLongitude != ""
 		if showGPS {
 			fmt.Println()
-			fmt.Println("GPS Location:")
+			fmt.Println(tr("GPS Location") + ":")
 			if metadata.GPSLatitude != "" {
-				fmt.Printf("  Latitude:       %s\n", metadata.GPSLatitude)
+				fmt.Printf("  %-15s %s\n", tr("Latitude")+":", metadata.GPSLatitude)
 			}
 			if metadata.GPSLongitude != "" {
-				fmt.Printf("  Longitude:      %s\n", metadata.GPSLongitude)
+				fmt.Printf("  %-15s %s\n", tr("Longitude")+":", metadata.GPSLongitude)
 			}
 			if metadata.GPSAltitude != "" {
-				fmt.Printf("  Altitude:       %s\n", metadata.GPSAltitude)
+				fmt.Printf("  %-15s %s\n", tr("Altitude")+":", metadata.GPSAltitude)
 			}
 			if metadata.GPSSpeed != "" {
-				fmt.Printf("  Speed:          %s\n", metadata.GPSSpeed)
+				fmt.Printf("  %-15s %s\n", tr("Speed")+":", metadata.GPSSpeed)
 			}
 			if metadata.GPSDirection != "" {
-				fmt.Printf("  Direction:      %s\n", metadata.GPSDirection)
+				fmt.Printf("  %-15s %s\n", tr("Direction")+":", metadata.GPSDirection)
 			}
 			if metadata.GPSTimestamp != "" {
-				fmt.Printf("  GPS Time:       %s\n", metadata.GPSTimestamp)
+				fmt.Printf("  %-15s %s\n", tr("GPS Time")+":", metadata.GPSTimestamp)
 			}
 			if metadata.GPSSatellites != "" {
-				fmt.Printf("  Satellites:     %s\n", metadata.GPSSatellites)
+				fmt.Printf("  %-15s %s\n", tr("Satellites")+":", metadata.GPSSatellites)
 			}
 		}
 
@@ -277,54 +334,54 @@ func outputPretty(metadata *imgx.ImageMetadata) error {
 			metadata.Keywords != "" || metadata.Artist != "" || metadata.Copyright != ""
 		if showContent {
 			fmt.Println()
-			fmt.Println("Content & Authorship:")
+			fmt.Println(tr("Content & Authorship") + ":")
 			if metadata.Title != "" {
-				fmt.Printf("  Title:          %s\n", metadata.Title)
+				fmt.Printf("  %-15s %s\n", tr("Title")+":", metadata.Title)
 			}
 			if metadata.Subject != "" {
-				fmt.Printf("  Subject:        %s\n", metadata.Subject)
+				fmt.Printf("  %-15s %s\n", tr("Subject")+":", metadata.Subject)
 			}
 			if metadata.Keywords != "" {
-				fmt.Printf("  Keywords:       %s\n", metadata.Keywords)
+				fmt.Printf("  %-15s %s\n", tr("Keywords")+":", metadata.Keywords)
 			}
 			if metadata.Rating > 0 {
-				fmt.Printf("  Rating:         %d stars\n", metadata.Rating)
+				fmt.Printf("  %-15s "+tr("%d stars")+"\n", tr("Rating")+":", metadata.Rating)
 			}
 			if metadata.Artist != "" {
-				fmt.Printf("  Artist:         %s\n", metadata.Artist)
+				fmt.Printf("  %-15s %s\n", tr("Artist")+":", metadata.Artist)
 			}
 			if metadata.Creator != "" && metadata.Creator != metadata.Artist {
-				fmt.Printf("  Creator:        %s\n", metadata.Creator)
+				fmt.Printf("  %-15s %s\n", tr("Creator")+":", metadata.Creator)
 			}
 			if metadata.Copyright != "" {
-				fmt.Printf("  Copyright:      %s\n", metadata.Copyright)
+				fmt.Printf("  %-15s %s\n", tr("Copyright")+":", metadata.Copyright)
 			}
 			if metadata.Software != "" {
-				fmt.Printf("  Software:       %s\n", metadata.Software)
+				fmt.Printf("  %-15s %s\n", tr("Software")+":", metadata.Software)
 			}
 			if metadata.CreatorTool != "" && metadata.CreatorTool != metadata.Software {
-				fmt.Printf("  Creator Tool:   %s\n", metadata.CreatorTool)
+				fmt.Printf("  %-15s %s\n", tr("Creator Tool")+":", metadata.CreatorTool)
 			}
 		}
 
 		// Image Description
 		if metadata.ImageDescription != "" || metadata.UserComment != "" {
 			fmt.Println()
-			fmt.Println("Description:")
+			fmt.Println(tr("Description") + ":")
 			if metadata.ImageDescription != "" {
-				fmt.Printf("  Image Desc.:    %s\n", metadata.ImageDescription)
+				fmt.Printf("  %-15s %s\n", tr("Image Desc.")+":", metadata.ImageDescription)
 			}
 			if metadata.UserComment != "" {
-				fmt.Printf("  User Comment:   %s\n", metadata.UserComment)
+				fmt.Printf("  %-15s %s\n", tr("User Comment")+":", metadata.UserComment)
 			}
 		}
 	} else {
 		fmt.Println()
 		fmt.Println("---")
 		fmt.Println()
-		fmt.Println("exiftool not found. Install exiftool for comprehensive metadata.")
+		fmt.Println(tr("exiftool not found. Install exiftool for comprehensive metadata."))
 		fmt.Println()
-		fmt.Println("Installation:")
+		fmt.Println(tr("Installation") + ":")
 		fmt.Println("  macOS:    brew install exiftool")
 		fmt.Println("  Ubuntu:   sudo apt-get install libimage-exiftool-perl")
 		fmt.Println("  Windows:  https://exiftool.org")
@@ -332,3 +389,11 @@ func outputPretty(metadata *imgx.ImageMetadata) error {
 
 	return nil
 }
+
+// yesNo formats a flag for the pretty output
+func yesNo(v bool) string {
+	if v {
+		return tr("yes")
+	}
+	return tr("no")
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// NormalizeOrientationCommand creates the normalize-orientation command
+func NormalizeOrientationCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "normalize-orientation",
+		Usage:     "Apply EXIF orientation to pixels and reset the tag",
+		ArgsUsage: "<file|dir>...",
+		Description: `Rotate/flip JPEG images according to their EXIF orientation tag and reset the
+tag to 1 (normal), so they display the same in every application. Other
+metadata (EXIF, ICC profile, XMP) is preserved.
+
+The transform is lossless when the image dimensions allow it (multiples of the
+JPEG MCU size). Otherwise the image is re-encoded with --quality and a warning
+is printed. Files are updated in place unless --output is given for a single
+file. Images that are already upright are left untouched.
+
+Examples:
+  imgx normalize-orientation photo.jpg
+  imgx normalize-orientation photo.jpg -o upright.jpg
+  imgx normalize-orientation ./photos -r
+  imgx normalize-orientation ./photos -r --dry-run`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "process directories recursively",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "only report which files would be changed",
+			},
+		},
+		Action: normalizeOrientationAction,
+	}
+}
+
+func normalizeOrientationAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+
+	files, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	output := cmd.String("output")
+	if output != "" && len(files) != 1 {
+		return fmt.Errorf("--output can only be used with a single input file")
+	}
+
+	var changed, reencoded, skipped int
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if format, _ := imgx.FormatFromFilename(path); format != imgx.JPEG {
+			skipped++
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		o := imgx.ReadOrientation(bytes.NewReader(data))
+		if o <= 1 {
+			skipped++
+			if cmd.Bool("verbose") {
+				infof("%s: already upright", path)
+			}
+			if output != "" {
+				return writeImageFile(cmd, output, path, data)
+			}
+			continue
+		}
+
+		if cmd.Bool("dry-run") {
+			infof("%s: orientation %d (%s)", path, o, imgx.OrientationTransform(o))
+			changed++
+			continue
+		}
+
+		var buf bytes.Buffer
+		lossless, err := imgx.NormalizeJPEGOrientation(bytes.NewReader(data), &buf, cmd.Int("quality"))
+		if err != nil {
+			return fmt.Errorf("failed to normalize %s: %w", path, err)
+		}
+
+		dst := path
+		if output != "" {
+			dst = output
+		}
+		if err := writeImageFile(cmd, dst, path, buf.Bytes()); err != nil {
+			return err
+		}
+
+		changed++
+		if lossless {
+			infof("%s: applied orientation %d (lossless)", path, o)
+		} else {
+			reencoded++
+			warnf("%s: lossless transform not possible, re-encoded with quality %d", path, cmd.Int("quality"))
+		}
+	}
+
+	format := "Normalized %d file(s) (%d re-encoded), %d skipped"
+	if cmd.Bool("dry-run") {
+		format = "Would normalize %d file(s) (%d re-encoded), %d skipped"
+	}
+	infof(format, changed, reencoded, skipped)
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place so an interrupted run never leaves a truncated image behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".imgx-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		_ = os.Chmod(tmp.Name(), info.Mode().Perm())
+	} else {
+		_ = os.Chmod(tmp.Name(), 0644)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,460 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// PipelineCommand creates the pipeline command
+func PipelineCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "pipeline",
+		Usage:     "Run a multi-step pipeline with named intermediate images",
+		ArgsUsage: "<input>",
+		Description: `Apply a list of steps to the input, one step per line or separated by ";".
+"save as $name" keeps the current image under a name and "load $name" goes
+back to it, so one pipeline can branch out (make a mask, blur a copy) and
+join the branches again (apply-mask, knockout, composite). Lines starting
+with "#" are comments.
+
+"if <condition> then <step>" runs a step only when the condition holds when
+the pipeline reaches it. Conditions compare a value with a number (>, >=, <,
+<=, ==, !=): width and height of the current image, or iso, exposure
+(seconds), aperture (f-number), focal-length (mm) and quality (JPEG) of the
+input file, which are false when the file does not record them.
+"safesearch flagged" asks the first configured of the vision, aws, openai,
+gemini and ollama providers for moderation labels and holds for a review or
+block verdict, "safesearch blocked" for block only; an image that cannot be
+moderated counts as flagged. "skip" ends the
+pipeline without writing the output.
+
+Steps:
+  save as $name                 keep the current image as $name
+  load $name                    continue with $name
+  apply-mask $mask <step>       apply <step> where $mask is white
+  knockout $mask                make the area of $mask transparent
+  composite $name [x,y] [opacity]  draw $name onto the image (default 0,0 and 1)
+  select-color x,y tolerance    magic-wand mask of the area around x,y
+  fit|resize|crop-center W H    fit within, resize (a 0 keeps the aspect
+                                ratio) or crop
+  blur|sharpen sigma            pixelate size
+  brightness|contrast|saturation percent
+  gamma g                       grayscale, invert
+  rotate90|rotate180|rotate270  flip-h, flip-v
+  denoise strength              edge-preserving smoothing (0-1)
+  if <condition> then <step>    run <step> only when <condition> holds
+  skip                          stop without writing the output
+
+Examples:
+  # Blur the background of a product shot, keep the product sharp
+  imgx pipeline product.jpg -o product-blur.jpg --steps 'save as $photo;
+    select-color 5,5 30; blur 2; save as $mask;
+    load $photo; apply-mask $mask blur 12'
+  # Clean up high-ISO shots, cap the size, drop flagged uploads
+  imgx pipeline upload.jpg --steps 'if safesearch flagged then skip;
+    if iso > 1600 then denoise 0.4; if width > 4000 then resize 4000 0'
+  imgx pipeline photo.jpg --file portrait.pipeline --dump masks/`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "steps",
+				Aliases: []string{"s"},
+				Usage:   "pipeline steps, separated by newlines or \";\"",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "read the steps from a file",
+			},
+			&cli.StringFlag{
+				Name:  "dump",
+				Usage: "also save every named image to this directory as <name>.png",
+			},
+		},
+		Action: pipelineAction,
+	}
+}
+
+func pipelineAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+	steps := cmd.String("steps")
+	if path := cmd.String("file"); path != "" {
+		if steps != "" {
+			return fmt.Errorf("use either --steps or --file")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read steps: %w", err)
+		}
+		steps = string(data)
+	}
+	if strings.TrimSpace(steps) == "" {
+		return fmt.Errorf("--steps or --file required")
+	}
+
+	reg := imgx.NewRegistry()
+	ops, err := ParsePipeline(steps, reg)
+	if err != nil {
+		return err
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+	result := runPipeline(img, ops)
+	if cmd.Bool("verbose") && result != nil {
+		infof("Applied %d step(s)", len(ops))
+	}
+
+	if dir := cmd.String("dump"); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create dump directory: %w", err)
+		}
+		for _, name := range reg.Names() {
+			named, _ := reg.Get(name)
+			path := filepath.Join(dir, name+".png")
+			if err := saveImageAs(cmd, named, path, "", 0); err != nil {
+				return err
+			}
+			if cmd.Bool("verbose") {
+				infof("$%s saved to: %s", name, path)
+			}
+		}
+	}
+
+	if result == nil {
+		fmt.Printf("%s: %s\n", tr("Skipped"), inputPath)
+		return nil
+	}
+	outputPath := getOutputPath(cmd, inputPath, "-pipeline")
+	return saveImage(cmd, result, outputPath)
+}
+
+// runPipeline applies the Ops of ParsePipeline to img in order. It returns
+// nil when a skip step ends the pipeline: the image must not be written.
+func runPipeline(img *imgx.Image, ops []imgx.Op) *imgx.Image {
+	for _, op := range ops {
+		if img = op(img); img == nil {
+			return nil
+		}
+	}
+	return img
+}
+
+// ParsePipeline parses pipeline steps (see the pipeline command) into Ops
+// sharing reg for their named images. Every name must be saved by an
+// earlier step than the ones using it. A skip step is an Op returning nil,
+// so the Ops are run with runPipeline, not ApplyAll.
+func ParsePipeline(src string, reg *imgx.Registry) ([]imgx.Op, error) {
+	saved := make(map[string]bool)
+	var ops []imgx.Op
+	n := 0
+	for _, line := range strings.Split(src, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, step := range strings.Split(line, ";") {
+			fields := strings.Fields(step)
+			if len(fields) == 0 {
+				continue
+			}
+			n++
+			op, err := parsePipelineStep(fields, reg, saved)
+			if err != nil {
+				return nil, fmt.Errorf("step %d (%s): %w", n, strings.Join(fields, " "), err)
+			}
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("pipeline has no steps")
+	}
+	return ops, nil
+}
+
+// parsePipelineStep parses one step; saved holds the names saved by the
+// steps before it
+func parsePipelineStep(fields []string, reg *imgx.Registry, saved map[string]bool) (imgx.Op, error) {
+	name, args := fields[0], fields[1:]
+	// ref checks that a name argument was saved by an earlier step
+	ref := func(s string) (string, error) {
+		if !strings.HasPrefix(s, "$") || len(s) == 1 {
+			return "", fmt.Errorf("image names start with $, got %q", s)
+		}
+		if !saved[s] {
+			return "", fmt.Errorf("%s is not saved by an earlier step", s)
+		}
+		return s, nil
+	}
+
+	switch name {
+	case "save":
+		if len(args) == 2 && args[0] == "as" {
+			args = args[1:]
+		}
+		if len(args) != 1 || !strings.HasPrefix(args[0], "$") || len(args[0]) == 1 {
+			return nil, fmt.Errorf("expected save as $name")
+		}
+		saved[args[0]] = true
+		return reg.OpSave(args[0]), nil
+	case "load", "knockout":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected %s $name", name)
+		}
+		n, err := ref(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if name == "load" {
+			return reg.OpLoad(n), nil
+		}
+		return reg.OpKnockout(n), nil
+	case "apply-mask":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("expected apply-mask $mask <step>")
+		}
+		mask, err := ref(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(args[1:], "skip") {
+			return nil, fmt.Errorf("skip cannot be masked")
+		}
+		op, err := parsePipelineStep(args[1:], reg, saved)
+		if err != nil {
+			return nil, err
+		}
+		return reg.OpApplyMasked(mask, op), nil
+	case "if":
+		then := slices.Index(args, "then")
+		if then < 1 || then == len(args)-1 {
+			return nil, fmt.Errorf("expected if <condition> then <step>")
+		}
+		if slices.Contains(args[then+1:], "save") {
+			// Later steps could load a name that was never saved
+			return nil, fmt.Errorf("save cannot be conditional")
+		}
+		cond, err := parsePipelineCondition(args[:then])
+		if err != nil {
+			return nil, err
+		}
+		op, err := parsePipelineStep(args[then+1:], reg, saved)
+		if err != nil {
+			return nil, err
+		}
+		return func(img *imgx.Image) *imgx.Image {
+			if cond(img) {
+				return op(img)
+			}
+			return img
+		}, nil
+	case "skip":
+		if len(args) > 0 {
+			return nil, fmt.Errorf("skip takes no arguments")
+		}
+		return func(*imgx.Image) *imgx.Image { return nil }, nil
+	case "composite":
+		if len(args) < 1 || len(args) > 3 {
+			return nil, fmt.Errorf("expected composite $name [x,y] [opacity]")
+		}
+		src, err := ref(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var pos image.Point
+		if len(args) > 1 {
+			if pos, err = ParsePoint(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		opacity := 1.0
+		if len(args) > 2 {
+			if opacity, err = strconv.ParseFloat(args[2], 64); err != nil || opacity < 0 || opacity > 1 {
+				return nil, fmt.Errorf("opacity must be between 0 and 1")
+			}
+		}
+		return reg.OpComposite(src, pos, opacity, imgx.CompositeOptions{}), nil
+	case "select-color":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected select-color x,y tolerance")
+		}
+		seed, err := ParsePoint(args[0])
+		if err != nil {
+			return nil, err
+		}
+		tolerance, err := strconv.Atoi(args[1])
+		if err != nil || tolerance < 0 || tolerance > 255 {
+			return nil, fmt.Errorf("tolerance must be between 0 and 255")
+		}
+		return imgx.OpSelectByColor(seed, tolerance), nil
+	case "fit", "resize", "crop-center":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected %s width height", name)
+		}
+		w, errW := strconv.Atoi(args[0])
+		h, errH := strconv.Atoi(args[1])
+		if name == "resize" {
+			if errW != nil || errH != nil || w < 0 || h < 0 || (w == 0 && h == 0) {
+				return nil, fmt.Errorf("width and height must be non-negative integers, not both 0")
+			}
+			return imgx.OpResize(w, h, imgx.Lanczos), nil
+		}
+		if errW != nil || errH != nil || w < 1 || h < 1 {
+			return nil, fmt.Errorf("width and height must be positive integers")
+		}
+		if name == "fit" {
+			return imgx.OpFit(w, h, imgx.Lanczos), nil
+		}
+		return imgx.OpCropCenter(w, h), nil
+	case "pixelate":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected pixelate size")
+		}
+		size, err := strconv.Atoi(args[0])
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("size must be a positive integer")
+		}
+		return imgx.OpPixelate(size), nil
+	case "blur", "sharpen", "brightness", "contrast", "saturation", "gamma", "denoise":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected %s value", name)
+		}
+		v, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", args[0])
+		}
+		switch name {
+		case "blur":
+			return imgx.OpBlur(v), nil
+		case "sharpen":
+			return imgx.OpSharpen(v), nil
+		case "brightness":
+			return imgx.OpAdjustBrightness(v), nil
+		case "contrast":
+			return imgx.OpAdjustContrast(v), nil
+		case "saturation":
+			return imgx.OpAdjustSaturation(v), nil
+		case "denoise":
+			if v < 0 || v > 1 {
+				return nil, fmt.Errorf("strength must be between 0 and 1")
+			}
+			return imgx.OpDenoise(v), nil
+		}
+		return imgx.OpAdjustGamma(v), nil
+	}
+
+	simple := map[string]imgx.Op{
+		"grayscale": imgx.OpGrayscale(),
+		"invert":    imgx.OpInvert(),
+		"rotate90":  imgx.OpRotate90(),
+		"rotate180": imgx.OpRotate180(),
+		"rotate270": imgx.OpRotate270(),
+		"flip-h":    imgx.OpFlipH(),
+		"flip-v":    imgx.OpFlipV(),
+	}
+	if op, ok := simple[name]; ok {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("%s takes no arguments", name)
+		}
+		return op, nil
+	}
+	return nil, fmt.Errorf("unknown step %q", name)
+}
+
+// pipelineFields are the values "if" steps compare, by name: the size of
+// the current image and details of the input file. ok is false when the
+// file does not record the value.
+var pipelineFields = map[string]func(img *imgx.Image) (v float64, ok bool){
+	"width":        func(img *imgx.Image) (float64, bool) { return float64(img.Bounds().Dx()), true },
+	"height":       func(img *imgx.Image) (float64, bool) { return float64(img.Bounds().Dy()), true },
+	"iso":          exifField(func(e *imgx.EXIFInfo) float64 { return float64(e.ISO) }),
+	"exposure":     exifField(func(e *imgx.EXIFInfo) float64 { return e.ExposureTime }),
+	"aperture":     exifField(func(e *imgx.EXIFInfo) float64 { return e.FNumber }),
+	"focal-length": exifField(func(e *imgx.EXIFInfo) float64 { return e.FocalLength }),
+	"quality": func(img *imgx.Image) (float64, bool) {
+		fm := img.FileMetadata()
+		if fm == nil || fm.JPEGQuality == 0 {
+			return 0, false
+		}
+		return float64(fm.JPEGQuality), true
+	},
+}
+
+// exifField returns a pipeline field reading an EXIF value of the input
+// file, where 0 means not recorded
+func exifField(get func(*imgx.EXIFInfo) float64) func(*imgx.Image) (float64, bool) {
+	return func(img *imgx.Image) (float64, bool) {
+		fm := img.FileMetadata()
+		if fm == nil || fm.EXIF == nil {
+			return 0, false
+		}
+		v := get(fm.EXIF)
+		return v, v != 0
+	}
+}
+
+// pipelineComparisons are the operators of "if" conditions
+var pipelineComparisons = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// pipelineModerate reports whether moderation flags img: a block verdict,
+// or with review also a review verdict. Images that cannot be moderated
+// are flagged. A variable, so tests need no provider.
+var pipelineModerate = func(img *imgx.Image, review bool) bool {
+	result, err := detection.Moderate(context.Background(), img.ToNRGBA(), detection.ModerateOptions{})
+	if err != nil {
+		warnf("safesearch: %v; treating the image as flagged", err)
+		return true
+	}
+	return result.Verdict == detection.VerdictBlock || (review && result.Verdict == detection.VerdictReview)
+}
+
+// parsePipelineCondition parses the condition of an "if" step: a field,
+// an operator and a number, or safesearch flagged|blocked
+func parsePipelineCondition(fields []string) (func(*imgx.Image) bool, error) {
+	if strings.EqualFold(fields[0], "safesearch") {
+		if len(fields) != 2 || (fields[1] != "flagged" && fields[1] != "blocked") {
+			return nil, fmt.Errorf("expected safesearch flagged or safesearch blocked")
+		}
+		review := fields[1] == "flagged"
+		return func(img *imgx.Image) bool { return pipelineModerate(img, review) }, nil
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("expected a condition such as iso > 1600")
+	}
+	field, ok := pipelineFields[strings.ToLower(fields[0])]
+	if !ok {
+		return nil, fmt.Errorf("unknown value %q (width, height, iso, exposure, aperture, focal-length, quality)", fields[0])
+	}
+	compare, ok := pipelineComparisons[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("unknown operator %q (>, >=, <, <=, ==, !=)", fields[1])
+	}
+	want, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[2])
+	}
+	return func(img *imgx.Image) bool {
+		v, ok := field(img)
+		return ok && compare(v, want)
+	}, nil
+}
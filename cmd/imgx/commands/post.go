@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+)
+
+// PostSaveHook returns a save hook running the --post commands after each
+// image is written. The commands are split into words like a shell would
+// (quotes group words, no expansion) and run without a shell; the
+// placeholders {path}, {dir}, {name}, {stem}, {ext}, {source}, {format} and
+// {size} in each word are replaced by the details of the saved image.
+func PostSaveHook(templates []string) (imgx.SaveHook, error) {
+	var commands [][]string
+	for _, t := range templates {
+		words, err := splitCommand(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --post command %q: %w", t, err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("invalid --post command %q: empty", t)
+		}
+		commands = append(commands, words)
+	}
+
+	return func(path string, info imgx.SaveInfo) error {
+		for _, words := range commands {
+			args := make([]string, len(words))
+			for i, w := range words {
+				args[i] = expandPost(w, path, info)
+			}
+			c := exec.Command(args[0], args[1:]...)
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			if err := c.Run(); err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// expandPost replaces the placeholders of a --post command word
+func expandPost(word, path string, info imgx.SaveInfo) string {
+	if !strings.Contains(word, "{") {
+		return word
+	}
+	ext := filepath.Ext(path)
+	return strings.NewReplacer(
+		"{path}", path,
+		"{dir}", filepath.Dir(path),
+		"{name}", filepath.Base(path),
+		"{stem}", strings.TrimSuffix(filepath.Base(path), ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{source}", info.SourcePath,
+		"{format}", strings.ToLower(info.Format),
+		"{size}", strconv.FormatInt(info.Size, 10),
+	).Replace(word)
+}
+
+// splitCommand splits s into words at unquoted spaces. Single quotes keep
+// everything literally. Inside double quotes a backslash only escapes ", \,
+// $ and `, and is kept before any other character, as in POSIX shells.
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// cpuProfile is the file of the CPU profile started by StartCPUProfile
+var cpuProfile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// StartCPUProfile writes a pprof CPU profile of the run to path until
+// StopCPUProfile
+func StartCPUProfile(path string) error {
+	cpuProfile.mu.Lock()
+	defer cpuProfile.mu.Unlock()
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	cpuProfile.file = f
+	return nil
+}
+
+// StopCPUProfile stops the CPU profile started by StartCPUProfile, if any
+func StopCPUProfile() error {
+	cpuProfile.mu.Lock()
+	defer cpuProfile.mu.Unlock()
+	if cpuProfile.file == nil {
+		return nil
+	}
+	pprof.StopCPUProfile()
+	err := cpuProfile.file.Close()
+	cpuProfile.file = nil
+	return err
+}
+
+// printTimings prints the operation timings of an image saved to path
+// with --profile: a summary, and every operation with -v
+func printTimings(cmd *cli.Command, img *imgx.Image, path string) {
+	timings := img.Timings()
+	if !cmd.Bool("profile") || len(timings) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %s: %s\n", tr("Profile"), path, timings)
+	if !cmd.Bool("verbose") {
+		return
+	}
+	for _, t := range timings {
+		fmt.Fprintf(os.Stderr, "  %-18s %10s %8d allocs %10s  %dx%d -> %dx%d\n", t.Action, t.Duration.Round(time.Microsecond),
+			t.Allocs, FormatBytes(int64(t.AllocBytes)), t.InputSize.X, t.InputSize.Y, t.OutputSize.X, t.OutputSize.Y)
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// PromptsCommand creates the prompts command
+func PromptsCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "prompts",
+		Usage:     "List or show prompt templates",
+		ArgsUsage: "[name]",
+		Description: `Prompt templates are named prompts with {{variable}} placeholders, stored as
+<name>.txt files in $IMGX_PROMPTS or the imgx/prompts directory of the user
+config directory (e.g. ~/.config/imgx/prompts). Use them with
+"imgx detect --prompt-template <name> --var key=value" so teams can share and
+version their prompts instead of pasting long strings into shell commands.
+
+Without arguments, lists the available templates and their variables. With a
+name, prints the template; with --var, prints it rendered.
+
+Examples:
+  imgx prompts
+  imgx prompts product-audit
+  imgx prompts product-audit --var brand=Acme`,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "var",
+				Usage: "template variable as key=value (repeatable)",
+			},
+		},
+		Action: promptsAction,
+	}
+}
+
+func promptsAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() > 0 {
+		tmpl, err := detection.LoadPromptTemplate(cmd.Args().First())
+		if err != nil {
+			return err
+		}
+		if !cmd.IsSet("var") {
+			fmt.Println(tmpl.Text)
+			return nil
+		}
+		prompt, err := renderPromptTemplate(tmpl, cmd.StringSlice("var"))
+		if err != nil {
+			return err
+		}
+		fmt.Println(prompt)
+		return nil
+	}
+
+	dir, err := detection.PromptTemplateDir()
+	if err != nil {
+		return err
+	}
+	templates, err := detection.ListPromptTemplates()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		infof("No prompt templates in %s", dir)
+		return nil
+	}
+
+	infof("Prompt templates in %s:", dir)
+	for _, tmpl := range templates {
+		vars := tmpl.Variables()
+		if len(vars) == 0 {
+			fmt.Printf("  %s\n", tmpl.Name)
+			continue
+		}
+		fmt.Printf("  %-24s %s: %s\n", tmpl.Name, tr("vars"), strings.Join(vars, ", "))
+	}
+	return nil
+}
+
+// renderPromptTemplate renders a template with key=value flag values
+func renderPromptTemplate(tmpl *detection.PromptTemplate, pairs []string) (string, error) {
+	vars, err := ParsePromptVars(pairs)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(vars)
+}
+
+// ParsePromptVars parses key=value pairs from --var flags. The CLI splits
+// slice flag values on commas, so a piece without "=" is rejoined with the
+// previous value ("--var tags=red,blue" gives tags = "red,blue").
+func ParsePromptVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	last := ""
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			if last == "" {
+				return nil, fmt.Errorf("invalid variable %q, expected key=value", pair)
+			}
+			vars[last] += "," + pair
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected key=value", pair)
+		}
+		vars[key] = value
+		last = key
+	}
+	return vars, nil
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// ProofCommand creates the proof command
+func ProofCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "proof",
+		Usage: "Color QA of proofs against a reference",
+		Commands: []*cli.Command{
+			proofCheckCommand(),
+		},
+	}
+}
+
+// proofCheckCommand creates the proof check subcommand
+func proofCheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "check",
+		Usage:     "Compare the colors of a proof with a reference using CIEDE2000",
+		ArgsUsage: "<proof>",
+		Description: `Compare a proof (e.g. a scan of a print) with the original image. Both are
+divided into a grid of regions and the mean colors of each pair of regions are
+compared with the CIEDE2000 color difference (ΔE00). The command fails when a
+region exceeds --max-delta-e, so it can gate print runs and brand-color checks
+in scripts.
+
+A proof of a different resolution is scaled to the reference; the aspect ratios
+must match. With --heatmap, the per-pixel difference is rendered over the
+reference: gray where the colors match, green to yellow up to the tolerance
+and red beyond.
+
+Examples:
+  imgx proof check print_scan.jpg --reference original.jpg --max-delta-e 3
+  imgx proof check print_scan.jpg --reference original.jpg --heatmap diff.png
+  imgx proof check banner.png --reference brand.png --grid 4x2 --json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "reference",
+				Aliases:  []string{"r"},
+				Usage:    "reference image the proof must match",
+				Required: true,
+			},
+			&cli.FloatFlag{
+				Name:  "max-delta-e",
+				Usage: "largest CIEDE2000 difference allowed per region",
+				Value: imgx.DefaultProofTolerance,
+				Validator: func(v float64) error {
+					if v <= 0 {
+						return fmt.Errorf("max-delta-e must be positive")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "grid",
+				Usage: "regions as COLUMNSxROWS",
+				Value: "8x8",
+			},
+			&cli.StringFlag{
+				Name:  "heatmap",
+				Usage: "write a heatmap of the differences to this file",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the report as JSON",
+			},
+		},
+		Action: proofCheckAction,
+	}
+}
+
+func proofCheckAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("proof image required")
+	}
+
+	columns, rows, err := ParseSize(cmd.String("grid"))
+	if err != nil {
+		return fmt.Errorf("invalid grid: %w", err)
+	}
+
+	proof, err := loadImage(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	reference, err := loadImage(cmd, cmd.String("reference"))
+	if err != nil {
+		return err
+	}
+
+	tolerance := cmd.Float("max-delta-e")
+	report, err := proof.CompareProof(reference.ToNRGBA(), imgx.ProofOptions{
+		Columns:   columns,
+		Rows:      rows,
+		Tolerance: tolerance,
+	})
+	if err != nil {
+		return err
+	}
+
+	if out := cmd.String("heatmap"); out != "" {
+		heatmap, err := proof.ProofHeatmap(reference.ToNRGBA(), tolerance)
+		if err != nil {
+			return err
+		}
+		if err := saveImage(cmd, heatmap, out); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printProofReport(report, columns)
+	}
+
+	if !report.Pass {
+		return fmt.Errorf("%d of %d regions exceed ΔE00 %.1f (max %.2f)", report.Failed, len(report.Regions), report.Tolerance, report.MaxDeltaE)
+	}
+	return nil
+}
+
+// printProofReport prints the ΔE00 of each region as a grid, marking the
+// regions over the tolerance
+func printProofReport(report *imgx.ProofReport, columns int) {
+	infof("ΔE00 per region (tolerance %.1f, * = over):", report.Tolerance)
+	for i, region := range report.Regions {
+		mark := " "
+		if !region.Pass {
+			mark = "*"
+		}
+		fmt.Printf(" %6.2f%s", region.DeltaE, mark)
+		if (i+1)%columns == 0 || i == len(report.Regions)-1 {
+			fmt.Println()
+		}
+	}
+	fmt.Println()
+	fmt.Printf("%-10s %.2f\n", tr("Mean ΔE00")+":", report.MeanDeltaE)
+	fmt.Printf("%-10s %.2f\n", tr("Max ΔE00")+":", report.MaxDeltaE)
+	if report.Pass {
+		fmt.Printf("%-10s PASS\n", tr("Result")+":")
+		return
+	}
+	worst := report.Worst()
+	fmt.Printf("%-10s FAIL ("+tr("%d regions over tolerance, worst at %v: proof %s vs reference %s")+")\n", tr("Result")+":",
+		report.Failed, worst.Rect, hexColor(worst.Proof), hexColor(worst.Reference))
+}
@@ -0,0 +1,341 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// RenameCommand creates the rename command
+func RenameCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "rename",
+		Usage:     "Rename photos after their capture date and detected subject",
+		ArgsUsage: "<file|dir>...",
+		Description: `Run label detection on each image and rename it from a template. Files keep
+their extension and directory.
+
+Template fields:
+  {date}       capture date (EXIF, falling back to the file time), 2006-01-02
+  {time}       capture time, 150405
+  {top_label}  most confident detected label, e.g. "golden-retriever"
+  {labels}     the top three labels joined with "-"
+  {name}       the original file name without extension
+  {n}          counter among files whose names are otherwise identical (001, 002, ...)
+
+Detection results are cached in sidecar files next to each image
+(photo.jpg.detect.json) with --sidecar, and reused on later runs so renaming
+again with another template doesn't call the provider. Sidecars are renamed
+along with their image. Use --refresh to ignore existing sidecars.
+
+Examples:
+  imgx rename ./photos --template "{date}_{top_label}_{n}" --dry-run
+  imgx rename ./photos -r --provider gemini --sidecar
+  imgx rename IMG_0042.jpg --template "{top_label}-{name}"`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "template",
+				Aliases: []string{"t"},
+				Usage:   "file name template (without extension)",
+				Value:   "{date}_{top_label}_{n}",
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.StringFlag{
+				Name:    "provider",
+				Aliases: []string{"p"},
+				Usage:   "detection provider: ollama, gemini, google (alias), aws, openai",
+				Value:   detection.GetDefaultProvider(),
+			},
+			&cli.Float64Flag{
+				Name:    "confidence",
+				Aliases: []string{"c"},
+				Usage:   "minimum label confidence (0.0-1.0)",
+				Value:   0.5,
+				Validator: func(f float64) error {
+					if f < 0 || f > 1 {
+						return fmt.Errorf("confidence must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "sidecar",
+				Usage: "save detection results to sidecar files for later runs",
+			},
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "run detection even when a sidecar file exists",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "show the new names without renaming anything",
+			},
+		},
+		Action: renameAction,
+	}
+}
+
+// sidecarSuffix is appended to an image path to name its detection sidecar
+const sidecarSuffix = ".detect.json"
+
+// renameFile is an image with the template fields derived from it
+type renameFile struct {
+	path   string
+	fields map[string]string
+	key    string // expanded template without the counter
+}
+
+func renameAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file or directory required")
+	}
+
+	template := cmd.String("template")
+	if _, err := ExpandRenameTemplate(template, renameTemplateFields(nil, "photo.jpg", time.Now())); err != nil {
+		return err
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+
+	// The provider is only needed for files without a sidecar.
+	var provider detection.Provider
+	for _, path := range paths {
+		if _, err := os.Stat(path + sidecarSuffix); err == nil && !cmd.Bool("refresh") {
+			continue
+		}
+		provider, err = detection.GetProvider(cmd.String("provider"))
+		if err != nil {
+			return err
+		}
+		if !provider.IsConfigured() {
+			return fmt.Errorf("provider %s is not configured", provider.Name())
+		}
+		break
+	}
+
+	minConfidence := float32(cmd.Float64("confidence"))
+	var files []renameFile
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := cachedDetection(ctx, path, provider, minConfidence, cmd.Bool("refresh"))
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if cmd.Bool("sidecar") && !cmd.Bool("dry-run") {
+			if err := writeSidecar(path, result); err != nil {
+				warnf("failed to write sidecar for %s: %v", path, err)
+			}
+		}
+
+		labels := topLabels(result, minConfidence, 3)
+		fields := renameTemplateFields(labels, path, captureTime(path))
+		fields["n"] = "{n}"
+		key, err := ExpandRenameTemplate(template, fields)
+		if err != nil {
+			return err
+		}
+		files = append(files, renameFile{path: path, fields: fields, key: filepath.Join(filepath.Dir(path), key)})
+	}
+
+	// Number files sharing a name in capture order.
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].key != files[j].key {
+			return files[i].key < files[j].key
+		}
+		return files[i].fields["date"]+files[i].fields["time"] < files[j].fields["date"]+files[j].fields["time"]
+	})
+	taken := make(map[string]bool)
+	renamed := 0
+	for i, f := range files {
+		n := 1
+		for k := i - 1; k >= 0 && files[k].key == f.key; k-- {
+			n++
+		}
+		name := strings.ReplaceAll(f.key, "{n}", fmt.Sprintf("%03d", n))
+		dst := name + strings.ToLower(filepath.Ext(f.path))
+		if dst == f.path {
+			taken[dst] = true
+			continue
+		}
+		dst = uniquePath(dst, taken)
+		taken[dst] = true
+
+		if cmd.Bool("dry-run") {
+			infof("would rename %s -> %s", f.path, dst)
+			continue
+		}
+		if err := os.Rename(f.path, dst); err != nil {
+			return fmt.Errorf("failed to rename %s: %w", f.path, err)
+		}
+		if _, err := os.Stat(f.path + sidecarSuffix); err == nil {
+			if err := os.Rename(f.path+sidecarSuffix, dst+sidecarSuffix); err != nil {
+				warnf("failed to rename sidecar of %s: %v", f.path, err)
+			}
+		}
+		fmt.Printf("%s -> %s\n", f.path, dst)
+		renamed++
+	}
+	if !cmd.Bool("dry-run") {
+		fmt.Printf("\n"+tr("Renamed %d of %d files")+"\n", renamed, len(files))
+	}
+	return nil
+}
+
+// cachedDetection returns the detection result stored in the sidecar of path,
+// or runs label detection when there is none (or refresh is set)
+func cachedDetection(ctx context.Context, path string, provider detection.Provider, minConfidence float32, refresh bool) (*detection.DetectionResult, error) {
+	if !refresh {
+		if data, err := os.ReadFile(path + sidecarSuffix); err == nil {
+			var result detection.DetectionResult
+			if err := json.Unmarshal(data, &result); err == nil {
+				return &result, nil
+			}
+			warnf("ignoring invalid sidecar %s", path+sidecarSuffix)
+		}
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("no sidecar and no detection provider")
+	}
+
+	img, err := imgx.Load(path, imgx.Options{AutoOrient: true, DisableMetadata: true})
+	if err != nil {
+		return nil, err
+	}
+	result, err := provider.Detect(ctx, img.ToNRGBA(), &detection.DetectOptions{
+		Features:      []detection.Feature{detection.FeatureLabels},
+		MaxResults:    10,
+		MinConfidence: minConfidence,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("detection failed: %w", err)
+	}
+	return result, nil
+}
+
+func writeSidecar(path string, result *detection.DetectionResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+sidecarSuffix, data, 0644)
+}
+
+// topLabels returns up to n label names at or above minConfidence, most
+// confident first
+func topLabels(result *detection.DetectionResult, minConfidence float32, n int) []string {
+	labels := append([]detection.Label(nil), result.Labels...)
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Confidence > labels[j].Confidence
+	})
+	var names []string
+	for _, l := range labels {
+		if len(names) == n {
+			break
+		}
+		if l.Confidence > 0 && l.Confidence < minConfidence {
+			continue
+		}
+		if slug := Slugify(l.Name); slug != "" {
+			names = append(names, slug)
+		}
+	}
+	return names
+}
+
+// captureTime returns the EXIF capture time of path, or its modification time
+func captureTime(path string) time.Time {
+	var taken time.Time
+	if info, err := os.Stat(path); err == nil {
+		taken = info.ModTime()
+	}
+	if f, err := os.Open(path); err == nil {
+		if exif, err := imgx.ReadEXIF(f); err == nil && !exif.DateTimeOriginal.IsZero() {
+			taken = exif.DateTimeOriginal
+		}
+		f.Close()
+	}
+	return taken
+}
+
+func renameTemplateFields(labels []string, path string, taken time.Time) map[string]string {
+	top := "unknown"
+	if len(labels) > 0 {
+		top = labels[0]
+	}
+	all := strings.Join(labels, "-")
+	if all == "" {
+		all = top
+	}
+	return map[string]string{
+		"date":      taken.Format("2006-01-02"),
+		"time":      taken.Format("150405"),
+		"top_label": top,
+		"labels":    all,
+		"name":      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		"n":         "001",
+	}
+}
+
+var templateField = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// ExpandRenameTemplate replaces the {field} placeholders of a rename template
+// with their values. Unknown fields and names that would leave the directory
+// are errors.
+func ExpandRenameTemplate(template string, fields map[string]string) (string, error) {
+	var unknown string
+	name := templateField.ReplaceAllStringFunc(template, func(m string) string {
+		value, ok := fields[m[1:len(m)-1]]
+		if !ok && unknown == "" {
+			unknown = m
+		}
+		return value
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("unknown template field %s", unknown)
+	}
+	if strings.ContainsAny(name, `/\`) || strings.TrimSpace(name) == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("template %q does not produce a valid file name", template)
+	}
+	return name, nil
+}
+
+// Slugify lowercases s and replaces everything but letters and digits with
+// single dashes, for use in file names
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
@@ -4,33 +4,61 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/razzkumar/imgx"
 	"github.com/urfave/cli/v3"
 )
 
 // ResizeCommand creates the resize command
 func ResizeCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "resize",
-		Usage: "Resize image to specific dimensions",
+		Name:      "resize",
+		Usage:     "Resize image to specific dimensions",
+		ArgsUsage: "<input>",
 		Description: `Resize an image to the specified width and height.
-If one dimension is 0, the aspect ratio is preserved.
+If one dimension is 0 or omitted, the aspect ratio is preserved.
+
+Sizes accept units: pixels (800 or 800px), a percentage of the source (50%),
+or physical sizes (mm, cm, in) converted with --dpi. Instead of width/height,
+--long-edge or --short-edge sizes the image by its longer or shorter side,
+whatever its orientation.
 
 Examples:
   imgx resize input.jpg -w 800 -h 600 -o output.jpg
   imgx resize input.jpg -w 800                        # preserve aspect ratio
-  imgx resize input.jpg -h 600 -f catmullrom          # with different filter`,
+  imgx resize input.jpg -h 600 -f catmullrom          # with different filter
+  imgx resize input.jpg -w 50%
+  imgx resize input.jpg --long-edge 2048
+  imgx resize input.jpg --short-edge 1080
+  imgx resize input.jpg -w 10cm --dpi 300
+  imgx resize input.jpg --long-edge 1200 --sharpen auto   # restore crispness after downscaling`,
 		Flags: []cli.Flag{
-			&cli.IntFlag{
+			&cli.StringFlag{
 				Name:    "width",
 				Aliases: []string{"w"},
-				Usage:   "target width (0 to preserve aspect ratio)",
-				Value:   0,
+				Usage:   "target width: pixels, percent (50%) or physical size with --dpi (10cm, 85mm, 4in)",
 			},
-			&cli.IntFlag{
+			&cli.StringFlag{
 				Name:    "height",
 				Aliases: []string{"h"},
-				Usage:   "target height (0 to preserve aspect ratio)",
-				Value:   0,
+				Usage:   "target height: pixels, percent or physical size with --dpi",
+			},
+			&cli.StringFlag{
+				Name:  "long-edge",
+				Usage: "size of the longer side (aspect ratio preserved)",
+			},
+			&cli.StringFlag{
+				Name:  "short-edge",
+				Usage: "size of the shorter side (aspect ratio preserved)",
+			},
+			&cli.FloatFlag{
+				Name:  "dpi",
+				Usage: "resolution used to convert mm, cm and in to pixels",
+				Validator: func(v float64) error {
+					if v < 0 {
+						return fmt.Errorf("dpi must be positive")
+					}
+					return nil
+				},
 			},
 			&cli.StringFlag{
 				Name:    "filter",
@@ -38,6 +66,7 @@ Examples:
 				Usage:   "resampling filter (nearest, box, linear, hermite, mitchellnetravali, catmullrom, bspline, gaussian, lanczos, hann, hamming, blackman, bartlett, welch, cosine)",
 				Value:   "lanczos",
 			},
+			sharpenFlag("off"),
 		},
 		Action: resizeAction,
 	}
@@ -49,12 +78,11 @@ func resizeAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	inputPath := cmd.Args().Get(0)
-	width := cmd.Int("width")
-	height := cmd.Int("height")
 	filterName := cmd.String("filter")
 
-	if width == 0 && height == 0 {
-		return fmt.Errorf("at least one dimension (width or height) must be specified")
+	spec, err := ParseResizeSpec(cmd.String("width"), cmd.String("height"), cmd.String("long-edge"), cmd.String("short-edge"), cmd.Float("dpi"))
+	if err != nil {
+		return err
 	}
 
 	// Parse filter
@@ -69,19 +97,63 @@ func resizeAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	sharpen, err := ParseSharpen(cmd.String("sharpen"))
+	if err != nil {
+		return err
+	}
+
 	// Resize
-	result := img.Resize(width, height, filter)
+	result, err := img.ResizeTo(spec, filter, sharpen...)
+	if err != nil {
+		return err
+	}
 
 	// Save
 	outputPath := getOutputPath(cmd, inputPath, "-resized")
 	return saveImage(cmd, result, outputPath)
 }
 
+// ParseResizeSpec builds a ResizeSpec from flag values. Empty values and "0"
+// are unset.
+func ParseResizeSpec(width, height, longEdge, shortEdge string, dpi float64) (imgx.ResizeSpec, error) {
+	spec := imgx.ResizeSpec{DPI: dpi}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *imgx.Length
+	}{
+		{"width", width, &spec.Width},
+		{"height", height, &spec.Height},
+		{"long-edge", longEdge, &spec.LongEdge},
+		{"short-edge", shortEdge, &spec.ShortEdge},
+	} {
+		l, err := imgx.ParseLength(f.value)
+		if err != nil {
+			return spec, fmt.Errorf("invalid --%s: %w", f.name, err)
+		}
+		*f.dst = l
+	}
+	if spec.Width.IsZero() && spec.Height.IsZero() && spec.LongEdge.IsZero() && spec.ShortEdge.IsZero() {
+		return spec, fmt.Errorf("at least one dimension (width, height, long-edge or short-edge) must be specified")
+	}
+	return spec, nil
+}
+
+// sharpenFlag is the post-resize sharpening flag shared by the resize commands
+func sharpenFlag(value string) cli.Flag {
+	return &cli.StringFlag{
+		Name:  "sharpen",
+		Usage: "sharpen after resizing: auto (scaled to the downscale factor), off, or an amount such as 0.5",
+		Value: value,
+	}
+}
+
 // FitCommand creates the fit command
 func FitCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "fit",
-		Usage: "Scale image to fit within bounds",
+		Name:      "fit",
+		Usage:     "Scale image to fit within bounds",
+		ArgsUsage: "<input>",
 		Description: `Scale the image down to fit within the specified maximum dimensions
 while preserving the aspect ratio.
 
@@ -89,16 +161,18 @@ Example:
   imgx fit input.jpg -w 800 -h 600 -o output.jpg`,
 		Flags: []cli.Flag{
 			&cli.IntFlag{
-				Name:     "width",
-				Aliases:  []string{"w"},
-				Usage:    "maximum width",
-				Required: true,
+				Name:      "width",
+				Aliases:   []string{"w"},
+				Usage:     "maximum width",
+				Required:  true,
+				Validator: validatePositive("width"),
 			},
 			&cli.IntFlag{
-				Name:     "height",
-				Aliases:  []string{"h"},
-				Usage:    "maximum height",
-				Required: true,
+				Name:      "height",
+				Aliases:   []string{"h"},
+				Usage:     "maximum height",
+				Required:  true,
+				Validator: validatePositive("height"),
 			},
 			&cli.StringFlag{
 				Name:    "filter",
@@ -106,6 +180,7 @@ Example:
 				Usage:   "resampling filter",
 				Value:   "lanczos",
 			},
+			sharpenFlag("off"),
 		},
 		Action: fitAction,
 	}
@@ -131,7 +206,12 @@ func fitAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	result := img.Fit(width, height, filter)
+	sharpen, err := ParseSharpen(cmd.String("sharpen"))
+	if err != nil {
+		return err
+	}
+
+	result := img.Fit(width, height, filter, sharpen...)
 
 	outputPath := getOutputPath(cmd, inputPath, "-fit")
 	return saveImage(cmd, result, outputPath)
@@ -140,8 +220,9 @@ func fitAction(ctx context.Context, cmd *cli.Command) error {
 // FillCommand creates the fill command
 func FillCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "fill",
-		Usage: "Crop and resize to fill exact dimensions",
+		Name:      "fill",
+		Usage:     "Crop and resize to fill exact dimensions",
+		ArgsUsage: "<input>",
 		Description: `Resize and crop the image to fill the specified dimensions.
 The image is scaled to cover the target size, then cropped to fit.
 
@@ -149,22 +230,25 @@ Example:
   imgx fill input.jpg -w 800 -h 600 --anchor center -o output.jpg`,
 		Flags: []cli.Flag{
 			&cli.IntFlag{
-				Name:     "width",
-				Aliases:  []string{"w"},
-				Usage:    "target width",
-				Required: true,
+				Name:      "width",
+				Aliases:   []string{"w"},
+				Usage:     "target width",
+				Required:  true,
+				Validator: validatePositive("width"),
 			},
 			&cli.IntFlag{
-				Name:     "height",
-				Aliases:  []string{"h"},
-				Usage:    "target height",
-				Required: true,
+				Name:      "height",
+				Aliases:   []string{"h"},
+				Usage:     "target height",
+				Required:  true,
+				Validator: validatePositive("height"),
 			},
 			&cli.StringFlag{
-				Name:    "anchor",
-				Aliases: []string{"a"},
-				Usage:   "anchor position (center, topleft, top, topright, left, right, bottomleft, bottom, bottomright)",
-				Value:   "center",
+				Name:      "anchor",
+				Aliases:   []string{"a"},
+				Usage:     "anchor position (center, topleft, top, topright, left, right, bottomleft, bottom, bottomright)",
+				Value:     "center",
+				Validator: validateAnchor,
 			},
 			&cli.StringFlag{
 				Name:    "filter",
@@ -172,6 +256,7 @@ Example:
 				Usage:   "resampling filter",
 				Value:   "lanczos",
 			},
+			sharpenFlag("off"),
 		},
 		Action: fillAction,
 	}
@@ -203,7 +288,12 @@ func fillAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	result := img.Fill(width, height, anchor, filter)
+	sharpen, err := ParseSharpen(cmd.String("sharpen"))
+	if err != nil {
+		return err
+	}
+
+	result := img.Fill(width, height, anchor, filter, sharpen...)
 
 	outputPath := getOutputPath(cmd, inputPath, "-fill")
 	return saveImage(cmd, result, outputPath)
@@ -212,19 +302,23 @@ func fillAction(ctx context.Context, cmd *cli.Command) error {
 // ThumbnailCommand creates the thumbnail command
 func ThumbnailCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "thumbnail",
-		Usage: "Create a square thumbnail",
+		Name:      "thumbnail",
+		Usage:     "Create a square thumbnail",
+		ArgsUsage: "<input>",
 		Description: `Create a square thumbnail by cropping and resizing.
 This is a convenience command equivalent to 'fill' with a square size.
+The result is sharpened to compensate for the downscale; use --sharpen off
+to disable it.
 
 Example:
   imgx thumbnail input.jpg -s 150 -o thumb.jpg`,
 		Flags: []cli.Flag{
 			&cli.IntFlag{
-				Name:     "size",
-				Aliases:  []string{"s"},
-				Usage:    "thumbnail size (width and height)",
-				Required: true,
+				Name:      "size",
+				Aliases:   []string{"s"},
+				Usage:     "thumbnail size (width and height)",
+				Required:  true,
+				Validator: validatePositive("size"),
 			},
 			&cli.StringFlag{
 				Name:    "filter",
@@ -232,6 +326,7 @@ Example:
 				Usage:   "resampling filter",
 				Value:   "lanczos",
 			},
+			sharpenFlag("auto"),
 		},
 		Action: thumbnailAction,
 	}
@@ -256,7 +351,12 @@ func thumbnailAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	result := img.Thumbnail(size, size, filter)
+	sharpen, err := ParseSharpen(cmd.String("sharpen"))
+	if err != nil {
+		return err
+	}
+
+	result := img.Thumbnail(size, size, filter, sharpen...)
 
 	outputPath := getOutputPath(cmd, inputPath, "-thumb")
 	return saveImage(cmd, result, outputPath)
@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// KnockoutCommand creates the knockout command
+func KnockoutCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "knockout",
+		Usage:     "Remove a uniform background (magic wand)",
+		ArgsUsage: "<input>",
+		Description: `Make the area connected to a seed point transparent when its colors are within
+--tolerance of the seed color, e.g. the backdrop of a product photo. Without
+--seed, the four corners are used. Pixels of the same color that are not
+connected to a seed (holes inside the object) are kept.
+
+The output is saved as PNG unless -o or --format says otherwise.
+
+Examples:
+  imgx knockout product.jpg --seed 5,5 --tolerance 12 -o product.png
+  imgx knockout product.jpg                        # seeds at the four corners
+  imgx knockout product.jpg --feather 1.5 --mask-out mask.png
+  imgx knockout logo.png --seed 0,0 --seed 300,10 -t 30`,
+		// --seed values contain commas
+		DisableSliceFlagSeparator: true,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "seed",
+				Usage: "seed point as x,y (repeatable; default: the four corners)",
+			},
+			&cli.IntFlag{
+				Name:    "tolerance",
+				Aliases: []string{"t"},
+				Usage:   "largest per-channel color difference from the seed color (0-255)",
+				Value:   12,
+				Validator: func(v int) error {
+					if v < 0 || v > 255 {
+						return fmt.Errorf("tolerance must be between 0 and 255")
+					}
+					return nil
+				},
+			},
+			&cli.FloatFlag{
+				Name:  "feather",
+				Usage: "soften the edge of the selection (blur sigma in pixels)",
+			},
+			&cli.StringFlag{
+				Name:  "mask-out",
+				Usage: "also save the selection mask to this path",
+			},
+		},
+		Action: knockoutAction,
+	}
+}
+
+func knockoutAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	var seeds []image.Point
+	for _, s := range cmd.StringSlice("seed") {
+		p, err := ParsePoint(s)
+		if err != nil {
+			return err
+		}
+		seeds = append(seeds, p)
+	}
+	if len(seeds) == 0 {
+		b := img.Bounds()
+		seeds = []image.Point{
+			b.Min,
+			{b.Max.X - 1, b.Min.Y},
+			{b.Min.X, b.Max.Y - 1},
+			b.Max.Sub(image.Pt(1, 1)),
+		}
+	}
+
+	tolerance := cmd.Int("tolerance")
+	var mask *imgx.Image
+	for _, seed := range seeds {
+		if !seed.In(img.Bounds()) {
+			warnf("seed %d,%d is outside the image", seed.X, seed.Y)
+			continue
+		}
+		m := img.SelectByColor(seed, tolerance)
+		if mask == nil {
+			mask = m
+			continue
+		}
+		// Union: take the new selection wherever it is set.
+		mask = mask.ApplyMasked(m, func(*imgx.Image) *imgx.Image { return m })
+	}
+	if mask == nil {
+		return fmt.Errorf("no seed inside the image")
+	}
+	if sigma := cmd.Float("feather"); sigma > 0 {
+		mask = mask.Blur(sigma)
+	}
+
+	if maskPath := cmd.String("mask-out"); maskPath != "" {
+		if err := saveImageAs(cmd, mask, maskPath, "", 0); err != nil {
+			return err
+		}
+		if cmd.Bool("verbose") {
+			fmt.Printf("%s: %s\n", tr("Mask saved to"), maskPath)
+		}
+	}
+
+	result := img.Knockout(mask)
+
+	outputPath := getOutputPath(cmd, inputPath, "-knockout")
+	if cmd.String("output") == "" && cmd.String("format") == "" {
+		outputPath = changeExtension(outputPath, imgx.PNG)
+	}
+	return saveImage(cmd, result, outputPath)
+}
+
+// InpaintCommand creates the inpaint command
+func InpaintCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "inpaint",
+		Usage:     "Fill a masked region from its surroundings (content-aware fill)",
+		ArgsUsage: "<input>",
+		Description: `Remove small objects, dust spots or blemishes by filling the white area of a
+mask image from the surrounding pixels (Telea's fast marching method). Works
+best on small regions; large regions come out blurry.
+
+The mask is scaled to the image size if needed. It can be painted in any
+editor or produced by 'imgx knockout --mask-out'.
+
+Examples:
+  imgx inpaint photo.jpg --mask mask.png -o clean.jpg
+  imgx inpaint scan.tif -m spots.png`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "mask",
+				Aliases:  []string{"m"},
+				Usage:    "mask image: white areas are filled",
+				Required: true,
+			},
+		},
+		Action: inpaintAction,
+	}
+}
+
+func inpaintAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+	mask, err := imgx.Load(cmd.String("mask"))
+	if err != nil {
+		return fmt.Errorf("failed to open mask: %w", err)
+	}
+
+	result := img.Inpaint(mask)
+
+	outputPath := getOutputPath(cmd, inputPath, "-inpainted")
+	return saveImage(cmd, result, outputPath)
+}
+
+// DescratchCommand creates the descratch command
+func DescratchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "descratch",
+		Usage:     "Remove dust specks and scratches from scans",
+		ArgsUsage: "<input>",
+		Description: `Detect small, high-contrast defects such as dust specks, hairs and thin
+scratches on scanned photos and film, and fill them from the surrounding
+pixels. Larger features are left alone.
+
+--sensitivity ranges from 0 (only obvious defects) to 1 (faint ones too, at the
+risk of softening fine detail). Use --preview-mask to save the repair mask
+instead (white where pixels would be repaired) and check it before repairing.
+
+Examples:
+  imgx descratch scan.tif --sensitivity 0.6
+  imgx descratch scan.tif --preview-mask           # saves scan-dustmask.png
+  imgx descratch film.jpg -s 0.3 -o clean.jpg`,
+		Flags: []cli.Flag{
+			&cli.FloatFlag{
+				Name:    "sensitivity",
+				Aliases: []string{"s"},
+				Usage:   "detection sensitivity (0-1)",
+				Value:   0.5,
+				Validator: func(v float64) error {
+					if v < 0 || v > 1 {
+						return fmt.Errorf("sensitivity must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "preview-mask",
+				Usage: "save the repair mask instead of the repaired image",
+			},
+		},
+		Action: descratchAction,
+	}
+}
+
+func descratchAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	sensitivity := cmd.Float("sensitivity")
+	if cmd.Bool("preview-mask") {
+		mask := img.DetectDust(sensitivity)
+		outputPath := getOutputPath(cmd, inputPath, "-dustmask")
+		if cmd.String("output") == "" && cmd.String("format") == "" {
+			outputPath = changeExtension(outputPath, imgx.PNG)
+		}
+		return saveImage(cmd, mask, outputPath)
+	}
+
+	result := img.RemoveDust(sensitivity)
+
+	outputPath := getOutputPath(cmd, inputPath, "-descratched")
+	return saveImage(cmd, result, outputPath)
+}
+
+// ParsePoint parses a point given as "x,y"
+func ParsePoint(s string) (image.Point, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return image.Point{}, fmt.Errorf("invalid point %q: expected x,y", s)
+	}
+	x, errX := strconv.Atoi(strings.TrimSpace(parts[0]))
+	y, errY := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errX != nil || errY != nil {
+		return image.Point{}, fmt.Errorf("invalid point %q: coordinates must be integers", s)
+	}
+	return image.Pt(x, y), nil
+}
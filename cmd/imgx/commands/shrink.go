@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// shrinkDefaultQuality is the lossy quality used by shrink-screenshot when
+// --quality is not set; the global default of 95 is meant for editing, not
+// for shrinking.
+const shrinkDefaultQuality = 85
+
+// ShrinkScreenshotCommand creates the shrink-screenshot command
+func ShrinkScreenshotCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "shrink-screenshot",
+		Usage:     "Make a screenshot as small as possible without blurring text",
+		ArgsUsage: "<input>",
+		Description: `Shrink a screenshot (or any image) for sharing, docs or bug reports. The
+content is profiled first: images with large flat areas (UI, text, diagrams)
+are saved losslessly, as an exact-palette PNG-8 when they have at most 256
+colors or as lossless WebP, whichever is smaller. Photo-like content is saved
+as JPEG, or as WebP when it has transparency. An alpha channel that is
+fully opaque is dropped.
+
+With --max-width, screenshots are downscaled by a whole factor (1/2, 1/3, ...)
+with a box filter, so text strokes stay aligned to the pixel grid; the
+result can be narrower than --max-width. Photo-like content is resized to
+exactly --max-width.
+
+The output is written next to the input with a "-min" suffix and the
+extension of the chosen format; with -o, the extension of the given path is
+replaced. --quality applies to JPEG and lossy WebP (default 85).
+
+Examples:
+  imgx shrink-screenshot shot.png
+  imgx shrink-screenshot retina-shot.png --max-width 1440
+  imgx shrink-screenshot photo-capture.png -q 80 -o docs/capture`,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "max-width",
+				Usage: "downscale images wider than this many pixels",
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("max-width must not be negative")
+					}
+					return nil
+				},
+			},
+		},
+		Action: shrinkScreenshotAction,
+	}
+}
+
+// shrinkCandidate is one encoding of the shrunk image
+type shrinkCandidate struct {
+	name   string
+	format imgx.Format
+	data   []byte
+}
+
+func shrinkScreenshotAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+	inputPath := cmd.Args().Get(0)
+
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+	profile := img.ProfileScreenshot()
+
+	src := img.ToNRGBA()
+	if maxWidth := cmd.Int("max-width"); maxWidth > 0 {
+		src = shrinkDownscale(src, maxWidth, profile.Screenshot)
+	}
+
+	quality := shrinkDefaultQuality
+	if cmd.IsSet("quality") {
+		quality = cmd.Int("quality")
+	}
+	candidates, err := shrinkEncode(src, profile, quality)
+	if err != nil {
+		return err
+	}
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if len(c.data) < len(best.data) {
+			best = c
+		}
+	}
+
+	outputPath := changeExtension(getOutputPath(cmd, inputPath, "-min"), best.format)
+	if err := writeImageFile(cmd, outputPath, inputPath, best.data); err != nil {
+		return err
+	}
+
+	kind := tr("photo-like")
+	if profile.Screenshot {
+		kind = tr("screenshot")
+	}
+	alpha := tr("alpha used")
+	if profile.Opaque {
+		alpha = tr("alpha unused")
+	}
+	infof("%s: %s (%.0f%% flat, %d colors, %s)", inputPath, kind, profile.FlatPercent, profile.Colors, alpha)
+	b := src.Bounds()
+	if info, err := os.Stat(inputPath); err == nil {
+		infof("Saved %s as %s, %dx%d: %s -> %s", outputPath, best.name, b.Dx(), b.Dy(),
+			FormatBytes(info.Size()), FormatBytes(int64(len(best.data))))
+	}
+	return nil
+}
+
+// shrinkDownscale reduces src to at most maxWidth pixels wide. Screenshots
+// are reduced by the smallest whole factor that fits, with a box filter, so
+// every output pixel averages an aligned block of input pixels.
+func shrinkDownscale(src *image.NRGBA, maxWidth int, screenshot bool) *image.NRGBA {
+	b := src.Bounds()
+	if b.Dx() <= maxWidth {
+		return src
+	}
+	if !screenshot {
+		return imgx.Resize(src, maxWidth, 0, imgx.Lanczos)
+	}
+	factor := (b.Dx() + maxWidth - 1) / maxWidth
+	return imgx.Resize(src, b.Dx()/factor, max(1, (b.Dy()+factor/2)/factor), imgx.Box)
+}
+
+// shrinkEncode encodes src in the formats suited to its profile
+func shrinkEncode(src *image.NRGBA, profile imgx.ScreenshotProfile, quality int) ([]shrinkCandidate, error) {
+	// Opaque pixels are the same premultiplied, so an RGBA view tells the
+	// encoders that there is no alpha to store
+	var out image.Image = src
+	if profile.Opaque {
+		out = &image.RGBA{Pix: src.Pix, Stride: src.Stride, Rect: src.Rect}
+	}
+
+	var candidates []shrinkCandidate
+	encode := func(name string, img image.Image, format imgx.Format, opts ...imgx.EncodeOption) error {
+		var buf bytes.Buffer
+		if err := imgx.Encode(&buf, img, format, opts...); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		candidates = append(candidates, shrinkCandidate{name: name, format: format, data: buf.Bytes()})
+		return nil
+	}
+
+	var err error
+	switch {
+	case profile.Screenshot:
+		if pal, ok := imgx.Palettize(src); ok {
+			err = encode("PNG-8", pal, imgx.PNG, imgx.PNGCompressionLevel(png.BestCompression))
+		}
+		if err == nil {
+			err = encode("lossless WebP", out, imgx.WEBP, imgx.WebPLossless(true))
+		}
+	case profile.Opaque:
+		err = encode(fmt.Sprintf("JPEG q%d", quality), out, imgx.JPEG, imgx.JPEGQuality(quality))
+	default:
+		err = encode(fmt.Sprintf("WebP q%d", quality), out, imgx.WEBP, imgx.WebPQuality(quality))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
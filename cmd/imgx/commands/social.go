@@ -0,0 +1,275 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// SocialPreset is the output spec of a social platform slot
+type SocialPreset struct {
+	Description string `json:"description,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	// Aspect is used when only one of Width and Height is set (e.g. "4:5")
+	Aspect  string `json:"aspect,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Quality int    `json:"quality,omitempty"`
+}
+
+// socialPresets are the built-in presets, extended by the presets file
+var socialPresets = map[string]SocialPreset{
+	"instagram-post":    {"Instagram portrait post", 1080, 1350, "", "jpg", 90},
+	"instagram-square":  {"Instagram square post", 1080, 1080, "", "jpg", 90},
+	"instagram-story":   {"Instagram story / reel cover", 1080, 1920, "", "jpg", 90},
+	"facebook-post":     {"Facebook link/post image", 1200, 630, "", "jpg", 85},
+	"facebook-cover":    {"Facebook page cover", 1640, 624, "", "jpg", 85},
+	"twitter-post":      {"X/Twitter in-feed image", 1600, 900, "", "jpg", 85},
+	"twitter-header":    {"X/Twitter profile header", 1500, 500, "", "jpg", 85},
+	"linkedin-post":     {"LinkedIn shared image", 1200, 627, "", "jpg", 85},
+	"linkedin-banner":   {"LinkedIn profile banner", 1584, 396, "", "jpg", 85},
+	"youtube-thumbnail": {"YouTube video thumbnail", 1280, 720, "", "jpg", 90},
+	"youtube-banner":    {"YouTube channel art", 2560, 1440, "", "jpg", 90},
+	"pinterest-pin":     {"Pinterest standard pin", 1000, 1500, "", "jpg", 85},
+	"tiktok-cover":      {"TikTok video cover", 1080, 1920, "", "jpg", 90},
+	"og-image":          {"Open Graph link preview", 1200, 630, "", "png", 0},
+}
+
+// SocialCommand creates the social command
+func SocialCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "social",
+		Usage:     "Crop and resize for social media platforms using named presets",
+		ArgsUsage: "<input>",
+		Description: `Produce images sized for social platforms. Each preset sets the size, aspect
+ratio, format and quality of the output; --preset can be repeated to produce
+several variants at once, saved as <name>-<preset>.<ext>.
+
+The image is cropped to the preset's aspect ratio first. The default anchor,
+smart, keeps the most detailed part of the image.
+
+Presets can be added or overridden with a JSON file, read from --presets,
+$IMGX_PRESETS or <user config dir>/imgx/presets.json:
+
+  {
+    "shop-banner": {"width": 1920, "height": 600, "format": "webp"},
+    "instagram-post": {"width": 1080, "height": 1350, "quality": 95}
+  }
+
+Examples:
+  imgx social photo.jpg --preset instagram-story
+  imgx social photo.jpg --preset instagram-post --preset twitter-post --preset og-image
+  imgx social photo.jpg --preset youtube-thumbnail --anchor top -o thumb.jpg
+  imgx social --list`,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "preset",
+				Aliases: []string{"p"},
+				Usage:   "preset name (repeatable; see --list)",
+			},
+			&cli.BoolFlag{
+				Name:  "list",
+				Usage: "list available presets",
+			},
+			&cli.StringFlag{
+				Name:      "anchor",
+				Aliases:   []string{"a"},
+				Usage:     "anchor position used to crop (see crop), or smart",
+				Value:     "smart",
+				Validator: validateAnchor,
+			},
+			&cli.StringFlag{
+				Name:  "presets",
+				Usage: "JSON file with additional presets",
+			},
+		},
+		Action: socialAction,
+	}
+}
+
+func socialAction(ctx context.Context, cmd *cli.Command) error {
+	presets, err := LoadSocialPresets(presetsPath(cmd.String("presets")))
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("list") {
+		printSocialPresets(presets)
+		return nil
+	}
+
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+	names := cmd.StringSlice("preset")
+	if len(names) == 0 {
+		return fmt.Errorf("at least one --preset is required (see --list)")
+	}
+	if len(names) > 1 && cmd.String("output") != "" {
+		return fmt.Errorf("--output can only be used with a single --preset")
+	}
+	anchor, err := ParseAnchor(cmd.String("anchor"))
+	if err != nil {
+		return err
+	}
+
+	inputPath := cmd.Args().Get(0)
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		preset, ok := presets[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("unknown preset %q (see --list)", name)
+		}
+		result, err := applySocialPreset(img, preset, anchor)
+		if err != nil {
+			return fmt.Errorf("preset %s: %w", name, err)
+		}
+
+		// Global --format and --quality override the preset
+		formatName, quality := preset.Format, preset.Quality
+		if cmd.IsSet("format") {
+			formatName = cmd.String("format")
+		}
+		if cmd.IsSet("quality") {
+			quality = cmd.Int("quality")
+		}
+		outputPath := getOutputPath(cmd, inputPath, "-"+strings.ToLower(name))
+		if err := saveImageAs(cmd, result, outputPath, formatName, quality); err != nil {
+			return err
+		}
+		if formatName != "" {
+			if format, err := ParseFormat(formatName); err == nil {
+				outputPath = changeExtension(outputPath, format)
+			}
+		}
+		b := result.Bounds()
+		infof("%s (%dx%d) saved to: %s", name, b.Dx(), b.Dy(), outputPath)
+	}
+	return nil
+}
+
+// applySocialPreset crops img to the preset's aspect ratio and resizes it
+func applySocialPreset(img *imgx.Image, preset SocialPreset, anchor imgx.Anchor) (*imgx.Image, error) {
+	if preset.Width > 0 && preset.Height > 0 {
+		return img.Fill(preset.Width, preset.Height, anchor, imgx.Lanczos), nil
+	}
+	if preset.Aspect != "" {
+		var err error
+		if img, err = img.CropToAspect(preset.Aspect, anchor); err != nil {
+			return nil, err
+		}
+	}
+	if preset.Width > 0 || preset.Height > 0 {
+		img = img.Resize(preset.Width, preset.Height, imgx.Lanczos)
+	}
+	return img, nil
+}
+
+// presetsPath returns the presets file to read: the flag value, then
+// $IMGX_PRESETS, then presets.json in the user config directory
+func presetsPath(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if env := os.Getenv("IMGX_PRESETS"); env != "" {
+		return env
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "imgx", "presets.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadSocialPresets returns the built-in presets merged with the presets
+// defined in the JSON file at path (if any). File presets override built-in
+// ones with the same name.
+func LoadSocialPresets(path string) (map[string]SocialPreset, error) {
+	presets := make(map[string]SocialPreset, len(socialPresets))
+	for name, p := range socialPresets {
+		presets[name] = p
+	}
+	if path == "" {
+		return presets, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	var custom map[string]SocialPreset
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("invalid presets file %s: %w", path, err)
+	}
+	for name, p := range custom {
+		if err := validateSocialPreset(p); err != nil {
+			return nil, fmt.Errorf("invalid preset %q in %s: %w", name, path, err)
+		}
+		presets[strings.ToLower(name)] = p
+	}
+	return presets, nil
+}
+
+func validateSocialPreset(p SocialPreset) error {
+	if p.Width < 0 || p.Height < 0 {
+		return fmt.Errorf("width and height must not be negative")
+	}
+	if p.Width == 0 && p.Height == 0 && p.Aspect == "" {
+		return fmt.Errorf("set width, height or aspect")
+	}
+	if p.Aspect != "" {
+		if _, err := imgx.ParseAspectRatio(p.Aspect); err != nil {
+			return err
+		}
+	}
+	if p.Format != "" {
+		if _, err := ParseFormat(p.Format); err != nil {
+			return err
+		}
+	}
+	if p.Quality < 0 || p.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	return nil
+}
+
+func printSocialPresets(presets map[string]SocialPreset) {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := presets[name]
+		dim := func(v int) string {
+			if v == 0 {
+				return "?"
+			}
+			return fmt.Sprint(v)
+		}
+		size := dim(p.Width) + "x" + dim(p.Height)
+		if p.Width == 0 || p.Height == 0 {
+			size = strings.TrimSpace(size + " " + p.Aspect)
+		}
+		format := p.Format
+		if format == "" {
+			format = "source"
+		}
+		fmt.Printf("  %-20s %-12s %-7s %s\n", name, size, format, p.Description)
+	}
+}
@@ -0,0 +1,376 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// StatsCommand creates the stats command
+func StatsCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "stats",
+		Usage:     "Aggregate metadata statistics across a photo library",
+		ArgsUsage: "<file|dir>...",
+		Description: `Summarize a photo library: counts by camera, lens, ISO, aperture and focal
+length, shots per month, average megapixels and storage by format.
+
+Only image headers and EXIF blocks are read, so large libraries are scanned
+quickly. Files without EXIF data still count towards formats, storage and
+megapixels.
+
+With --pixels, each image is decoded instead and its pixel statistics are
+printed: per-channel mean, standard deviation and range, luminance entropy
+and the percentages of clipped shadows and highlights.
+
+Examples:
+  imgx stats ./library -r
+  imgx stats ./library -r --top 5
+  imgx stats ./library -r --json > stats.json
+  imgx stats photo.jpg --pixels`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"r"},
+				Usage:   "scan directories recursively",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "number of entries to show per category in the text summary (0 for all)",
+				Value: 10,
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("top must be non-negative")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "pixels",
+				Usage: "print pixel statistics of each image instead of library metadata",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output as JSON",
+			},
+		},
+		Action: statsAction,
+	}
+}
+
+// photoInfo is the per-file data aggregated by the stats command
+type photoInfo struct {
+	Format string
+	Size   int64
+	Width  int
+	Height int
+	EXIF   *imgx.EXIFInfo // nil when the file has no EXIF block
+}
+
+// formatUsage is the number of files and bytes stored in one format
+type formatUsage struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// libraryStats is the aggregated result of the stats command
+type libraryStats struct {
+	Files             int                    `json:"files"`
+	TotalBytes        int64                  `json:"total_bytes"`
+	AverageMegapixels float64                `json:"average_megapixels"`
+	WithEXIF          int                    `json:"with_exif"`
+	Formats           map[string]formatUsage `json:"formats"`
+	Cameras           map[string]int         `json:"cameras"`
+	Lenses            map[string]int         `json:"lenses"`
+	ISO               map[string]int         `json:"iso"`
+	Apertures         map[string]int         `json:"apertures"`
+	FocalLengths      map[string]int         `json:"focal_lengths"`
+	Months            map[string]int         `json:"months"`
+	Errors            []string               `json:"errors,omitempty"`
+}
+
+func statsAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+
+	paths, err := CollectImageFiles(cmd.Args().Slice(), cmd.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("pixels") {
+		return pixelStatsAction(ctx, cmd, paths)
+	}
+
+	infos, errs := scanPhotoInfos(ctx, paths)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	stats := aggregateStats(infos)
+	stats.Errors = errs
+
+	if cmd.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	printStats(stats, cmd.Int("top"))
+	return nil
+}
+
+// pixelStats is the pixel statistics of one file
+type pixelStats struct {
+	Path string `json:"path"`
+	imgx.ImageStats
+}
+
+// pixelStatsAction decodes each image and prints its pixel statistics
+func pixelStatsAction(ctx context.Context, cmd *cli.Command, paths []string) error {
+	var results []pixelStats
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		img, err := loadImage(cmd, path)
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		results = append(results, pixelStats{Path: path, ImageStats: img.Stats()})
+	}
+
+	if cmd.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	for i, r := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		printPixelStats(r)
+	}
+	return nil
+}
+
+func printPixelStats(r pixelStats) {
+	fmt.Printf("%s (%dx%d)\n", r.Path, r.Width, r.Height)
+	fmt.Printf("  %-10s %7s %7s %4s %4s\n", "channel", "mean", "stddev", "min", "max")
+	for _, ch := range []struct {
+		name  string
+		stats imgx.ChannelStats
+	}{
+		{"red", r.Red}, {"green", r.Green}, {"blue", r.Blue}, {"alpha", r.Alpha}, {"luminance", r.Luminance},
+	} {
+		fmt.Printf("  %-10s %7.2f %7.2f %4d %4d\n", ch.name, ch.stats.Mean, ch.stats.StdDev, ch.stats.Min, ch.stats.Max)
+	}
+	fmt.Printf("  %-19s "+tr("%.3f bits")+"\n", tr("Entropy")+":", r.Entropy)
+	fmt.Printf("  %-19s %.2f%%\n", tr("Clipped shadows")+":", r.ClippedShadowsPercent)
+	fmt.Printf("  %-19s %.2f%%\n", tr("Clipped highlights")+":", r.ClippedHighlightsPercent)
+}
+
+// scanPhotoInfos reads the header and EXIF data of every file in parallel.
+// Unreadable files are reported and skipped.
+func scanPhotoInfos(ctx context.Context, paths []string) ([]photoInfo, []string) {
+	results := make([]*photoInfo, len(paths))
+	errs := make([]error, len(paths))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = scanPhotoInfo(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var infos []photoInfo
+	var messages []string
+	for i, info := range results {
+		if errs[i] != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", paths[i], errs[i]))
+			warnf("skipping %s: %v", paths[i], errs[i])
+			continue
+		}
+		if info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	return infos, messages
+}
+
+func scanPhotoInfo(path string) (*photoInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &photoInfo{
+		Format: strings.ToUpper(format),
+		Size:   st.Size(),
+		Width:  cfg.Width,
+		Height: cfg.Height,
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	if exif, err := imgx.ReadEXIF(f); err == nil {
+		info.EXIF = exif
+	}
+	return info, nil
+}
+
+// aggregateStats builds the library summary from per-file data
+func aggregateStats(infos []photoInfo) *libraryStats {
+	stats := &libraryStats{
+		Files:        len(infos),
+		Formats:      make(map[string]formatUsage),
+		Cameras:      make(map[string]int),
+		Lenses:       make(map[string]int),
+		ISO:          make(map[string]int),
+		Apertures:    make(map[string]int),
+		FocalLengths: make(map[string]int),
+		Months:       make(map[string]int),
+	}
+
+	var megapixels float64
+	for _, info := range infos {
+		stats.TotalBytes += info.Size
+		megapixels += float64(info.Width*info.Height) / 1000000.0
+
+		usage := stats.Formats[info.Format]
+		usage.Count++
+		usage.Bytes += info.Size
+		stats.Formats[info.Format] = usage
+
+		e := info.EXIF
+		if e == nil {
+			continue
+		}
+		stats.WithEXIF++
+		if camera := cameraName(e.Make, e.Model); camera != "" {
+			stats.Cameras[camera]++
+		}
+		if e.LensModel != "" {
+			stats.Lenses[e.LensModel]++
+		}
+		if e.ISO > 0 {
+			stats.ISO[strconv.Itoa(e.ISO)]++
+		}
+		if e.FNumber > 0 {
+			stats.Apertures["f/"+strconv.FormatFloat(e.FNumber, 'f', -1, 64)]++
+		}
+		if e.FocalLength > 0 {
+			stats.FocalLengths[strconv.FormatFloat(e.FocalLength, 'f', -1, 64)+"mm"]++
+		}
+		if !e.DateTimeOriginal.IsZero() {
+			stats.Months[e.DateTimeOriginal.Format("2006-01")]++
+		}
+	}
+	if len(infos) > 0 {
+		stats.AverageMegapixels = megapixels / float64(len(infos))
+	}
+	return stats
+}
+
+// cameraName joins make and model, dropping the make when the model already
+// starts with it (e.g. "Canon" + "Canon EOS R5").
+func cameraName(maker, model string) string {
+	if model == "" {
+		return maker
+	}
+	if maker == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(maker)) {
+		return model
+	}
+	return maker + " " + model
+}
+
+func printStats(stats *libraryStats, top int) {
+	fmt.Printf("%-19s "+tr("%d (%d with EXIF)")+"\n", tr("Files")+":", stats.Files, stats.WithEXIF)
+	fmt.Printf("%-19s %s\n", tr("Total size")+":", FormatBytes(stats.TotalBytes))
+	fmt.Printf("%-19s %.1f\n", tr("Average megapixels")+":", stats.AverageMegapixels)
+
+	fmt.Println("\n" + tr("Storage by format") + ":")
+	formats := make([]string, 0, len(stats.Formats))
+	for name := range stats.Formats {
+		formats = append(formats, name)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return stats.Formats[formats[i]].Bytes > stats.Formats[formats[j]].Bytes
+	})
+	for _, name := range formats {
+		usage := stats.Formats[name]
+		fmt.Printf("  %-8s "+tr("%6d files")+"  %s\n", name, usage.Count, FormatBytes(usage.Bytes))
+	}
+
+	printCounts(tr("Cameras"), stats.Cameras, top, false)
+	printCounts(tr("Lenses"), stats.Lenses, top, false)
+	printCounts("ISO", stats.ISO, top, false)
+	printCounts(tr("Apertures"), stats.Apertures, top, false)
+	printCounts(tr("Focal lengths"), stats.FocalLengths, top, false)
+	printCounts(tr("Shots per month"), stats.Months, 0, true)
+
+	if len(stats.Errors) > 0 {
+		fmt.Printf("\n"+tr("%d files could not be read")+"\n", len(stats.Errors))
+	}
+}
+
+// printCounts prints a category sorted by count (or by key when byKey is
+// set), limited to the top entries when top > 0
+func printCounts(title string, counts map[string]int, top int, byKey bool) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !byKey && counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Printf("\n%s:\n", title)
+	for i, k := range keys {
+		if top > 0 && i == top {
+			fmt.Printf("  ... "+tr("%d more")+"\n", len(keys)-top)
+			break
+		}
+		fmt.Printf("  %-30s %6d\n", k, counts[k])
+	}
+}
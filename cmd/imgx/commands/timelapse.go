@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// TimelapseCommand creates the timelapse command
+func TimelapseCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "timelapse",
+		Usage:     "Assemble sequential frames into a time-lapse, removing exposure flicker",
+		ArgsUsage: "<dir|file>...",
+		Description: `Number the frames of a time-lapse sequentially (frame_00001.jpg, ...) into the
+--output directory, ready for a video encoder. Frames of a directory are taken
+in natural name order, so IMG_9.jpg comes before IMG_10.jpg.
+
+With --deflicker, the exposure drift between consecutive frames (from auto
+exposure, aperture flicker or passing clouds) is equalized: each frame's gamma
+is adjusted so that its mean luminance matches the average of the --window
+frames around it. Slow changes such as a sunset are kept.
+
+With --video, the frames are encoded with ffmpeg (which must be in PATH); the
+codec follows the extension (H.264 for .mp4, .mov and .mkv). Without --output,
+the frames are only written to a temporary directory for the video.
+
+Examples:
+  imgx timelapse ./frames --deflicker --output frames_out/
+  imgx timelapse ./frames --deflicker --window 15 --video sunset.mp4 --fps 30
+  imgx timelapse ./frames --output frames_out/ --format png`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "deflicker",
+				Usage: "equalize the exposure drift between consecutive frames",
+			},
+			&cli.IntFlag{
+				Name:  "window",
+				Usage: "frames averaged around each frame by --deflicker; larger windows remove slower drift",
+				Value: 7,
+				Validator: func(v int) error {
+					if v < 2 {
+						return fmt.Errorf("window must be at least 2")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "video",
+				Usage: "encode the frames into this video file with ffmpeg",
+			},
+			&cli.FloatFlag{
+				Name:  "fps",
+				Usage: "frame rate of --video",
+				Value: 24,
+				Validator: func(v float64) error {
+					if v <= 0 {
+						return fmt.Errorf("fps must be positive")
+					}
+					return nil
+				},
+			},
+		},
+		Action: timelapseAction,
+	}
+}
+
+func timelapseAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("frames directory or files required")
+	}
+	outDir := cmd.String("output")
+	video := cmd.String("video")
+	if outDir == "" && video == "" {
+		return fmt.Errorf("--output directory or --video required")
+	}
+	if video != "" {
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			return fmt.Errorf("--video requires ffmpeg in PATH")
+		}
+	}
+
+	frames, err := timelapseFrames(cmd.Args().Slice())
+	if err != nil {
+		return err
+	}
+	if len(frames) < 2 {
+		return fmt.Errorf("a time-lapse needs at least 2 frames, found %d", len(frames))
+	}
+
+	if outDir == "" {
+		if outDir, err = os.MkdirTemp("", "imgx-timelapse-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(outDir)
+	} else if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// The mean luminance of every frame is measured first, so only one frame
+	// is held in memory at a time
+	var targets, lums []float64
+	if cmd.Bool("deflicker") {
+		lums = make([]float64, len(frames))
+		for i, path := range frames {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			img, err := loadImage(cmd, path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			lums[i] = imgx.MeanLuminance(img.ToNRGBA())
+		}
+		targets = imgx.DeflickerTargets(lums, imgx.DeflickerOptions{Window: cmd.Int("window")})
+	}
+
+	pattern := filepath.Join(outDir, "frame_%05d.jpg")
+	if name := cmd.String("format"); name != "" {
+		format, err := ParseFormat(name)
+		if err != nil {
+			return err
+		}
+		pattern = changeExtension(pattern, format)
+	}
+	for i, path := range frames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		img, err := loadImage(cmd, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if targets != nil {
+			img = img.MatchLuminance(targets[i])
+			if cmd.Bool("verbose") {
+				infof("%s: luminance %.1f -> %.1f", path, lums[i], targets[i])
+			}
+		}
+		if err := saveImage(cmd, img, fmt.Sprintf(pattern, i+1)); err != nil {
+			return err
+		}
+	}
+	if targets != nil {
+		infof("Deflickered %d frames: mean frame-to-frame luminance change %.2f -> %.2f",
+			len(frames), meanStep(lums), meanStep(targets))
+	}
+	if cmd.String("output") != "" {
+		fmt.Printf("%s: %s\n", tr("Frames saved to"), outDir)
+	}
+
+	if video != "" {
+		if err := encodeTimelapse(ctx, pattern, video, cmd.Float("fps")); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", tr("Video saved to"), video)
+	}
+	return nil
+}
+
+// timelapseFrames expands the arguments into frame paths: files as given,
+// the images of each directory in natural name order
+func timelapseFrames(args []string) ([]string, error) {
+	var frames []string
+	for _, arg := range args {
+		paths, err := CollectImageFiles([]string{arg}, false)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(paths, func(i, j int) bool {
+			return NaturalLess(filepath.Base(paths[i]), filepath.Base(paths[j]))
+		})
+		frames = append(frames, paths...)
+	}
+	return frames, nil
+}
+
+// NaturalLess orders names with embedded numbers by their value, so that
+// "IMG_9.jpg" comes before "IMG_10.jpg". Other characters compare
+// case-insensitively.
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, _ := strconv.ParseUint(strings.TrimLeft(da, "0"), 10, 64)
+			nb, _ := strconv.ParseUint(strings.TrimLeft(db, "0"), 10, 64)
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		ra, rb := unicode.ToLower(rune(a[0])), unicode.ToLower(rune(b[0]))
+		if ra != rb {
+			return ra < rb
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the ASCII digits at the start of s
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// meanStep returns the mean absolute difference between consecutive values
+func meanStep(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(values); i++ {
+		sum += math.Abs(values[i] - values[i-1])
+	}
+	return sum / float64(len(values)-1)
+}
+
+// encodeTimelapse encodes the numbered frames matching pattern (a printf
+// pattern such as frame_%05d.jpg) into video with ffmpeg
+func encodeTimelapse(ctx context.Context, pattern, video string, fps float64) error {
+	args := []string{"-y", "-loglevel", "error", "-framerate", strconv.FormatFloat(fps, 'f', -1, 64), "-i", pattern}
+	switch strings.ToLower(filepath.Ext(video)) {
+	case ".mp4", ".mov", ".mkv":
+		// yuv420p, which players expect, needs even dimensions
+		args = append(args, "-c:v", "libx264", "-pix_fmt", "yuv420p", "-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2")
+	}
+	args = append(args, video)
+	c := exec.CommandContext(ctx, "ffmpeg", args...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
+	"image"
+	"io"
+	"strings"
 
 	"github.com/razzkumar/imgx"
 	"github.com/urfave/cli/v3"
@@ -11,16 +14,20 @@ import (
 // RotateCommand creates the rotate command
 func RotateCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "rotate",
-		Usage: "Rotate image by specified angle",
+		Name:      "rotate",
+		Usage:     "Rotate image by specified angle",
+		ArgsUsage: "<input>",
 		Description: `Rotate an image by the specified angle in degrees (counter-clockwise).
 For 90-degree increments (90, 180, 270), the rotation is lossless.
-For other angles, bilinear interpolation is used.
+For other angles, pixels are interpolated bilinearly unless --filter is given
+(lanczos or catmullrom keep photos sharper). --auto-crop crops the result to the
+largest rectangle without background, which straightens a photo in one step.
 
 Examples:
   imgx rotate photo.jpg -a 90 -o output.jpg           # 90 degrees
   imgx rotate photo.jpg -a 45 --bg ffffff -o output.jpg  # 45 degrees with white background
-  imgx rotate photo.jpg -a -30 --bg 00000000           # -30 degrees (clockwise) with transparent background`,
+  imgx rotate photo.jpg -a -30 --bg 00000000           # -30 degrees (clockwise) with transparent background
+  imgx rotate horizon.jpg -a -2.5 --filter lanczos --auto-crop   # straighten a horizon`,
 		Flags: []cli.Flag{
 			&cli.FloatFlag{
 				Name:     "angle",
@@ -33,6 +40,16 @@ Examples:
 				Usage: "background color for empty areas in hex (RGB or RGBA, e.g., ffffff or 00000000)",
 				Value: "00000000", // Transparent by default
 			},
+			&cli.StringFlag{
+				Name:    "filter",
+				Aliases: []string{"f"},
+				Usage:   "interpolation filter for arbitrary angles (bilinear, nearest, catmullrom, lanczos, ...)",
+				Value:   "bilinear",
+			},
+			&cli.BoolFlag{
+				Name:  "auto-crop",
+				Usage: "crop to the largest rectangle that contains no background",
+			},
 		},
 		Action: rotateAction,
 	}
@@ -59,19 +76,133 @@ func rotateAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	opts, err := transformOptions(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Rotate
-	result := img.Rotate(angle, bgColor)
+	result := img.Rotate(angle, bgColor, opts...)
 
 	// Save
 	outputPath := getOutputPath(cmd, inputPath, "-rotated")
 	return saveImage(cmd, result, outputPath)
 }
 
+// ShearCommand creates the shear command
+func ShearCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "shear",
+		Usage:     "Shear (skew) image horizontally and/or vertically",
+		ArgsUsage: "<input>",
+		Description: `Shear an image by an angle in degrees. A horizontal shear slants vertical lines
+(positive angles move the top to the right, like italics); a vertical shear
+slants horizontal lines (positive angles raise the right side). When both are
+given the horizontal shear is applied first.
+
+The canvas grows to fit the sheared image and --bg fills the corners; use
+--auto-crop to keep only the largest rectangle without background.
+
+Examples:
+  imgx shear photo.jpg -x 15 -o output.jpg
+  imgx shear photo.jpg -y -10 --bg ffffff
+  imgx shear scan.jpg -x 3 --filter lanczos --auto-crop   # correct a slanted scan`,
+		Flags: []cli.Flag{
+			&cli.FloatFlag{
+				Name:    "horizontal",
+				Aliases: []string{"x"},
+				Usage:   "horizontal shear angle in degrees (-90 to 90)",
+			},
+			&cli.FloatFlag{
+				Name:    "vertical",
+				Aliases: []string{"y"},
+				Usage:   "vertical shear angle in degrees (-90 to 90)",
+			},
+			&cli.StringFlag{
+				Name:  "bg",
+				Usage: "background color for empty areas in hex (RGB or RGBA, e.g., ffffff or 00000000)",
+				Value: "00000000",
+			},
+			&cli.StringFlag{
+				Name:    "filter",
+				Aliases: []string{"f"},
+				Usage:   "interpolation filter (bilinear, nearest, catmullrom, lanczos, ...)",
+				Value:   "bilinear",
+			},
+			&cli.BoolFlag{
+				Name:  "auto-crop",
+				Usage: "crop to the largest rectangle that contains no background",
+			},
+		},
+		Action: shearAction,
+	}
+}
+
+func shearAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+	angleX := cmd.Float("horizontal")
+	angleY := cmd.Float("vertical")
+	if angleX == 0 && angleY == 0 {
+		return fmt.Errorf("at least one of --horizontal or --vertical must be specified")
+	}
+	for _, a := range []float64{angleX, angleY} {
+		if a <= -90 || a >= 90 {
+			return fmt.Errorf("shear angle must be between -90 and 90 degrees, got %g", a)
+		}
+	}
+
+	bgColor, err := ParseColor(cmd.String("bg"))
+	if err != nil {
+		return err
+	}
+	opts, err := transformOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	result := img
+	if angleX != 0 {
+		result = result.ShearH(angleX, bgColor, opts...)
+	}
+	if angleY != 0 {
+		result = result.ShearV(angleY, bgColor, opts...)
+	}
+
+	outputPath := getOutputPath(cmd, inputPath, "-sheared")
+	return saveImage(cmd, result, outputPath)
+}
+
+// transformOptions builds the interpolation options from --filter and --auto-crop
+func transformOptions(cmd *cli.Command) ([]imgx.TransformOption, error) {
+	var opts []imgx.TransformOption
+	if name := cmd.String("filter"); name != "" && !strings.EqualFold(name, "bilinear") {
+		filter, err := ParseFilter(name)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, imgx.WithInterpolation(filter))
+	}
+	if cmd.Bool("auto-crop") {
+		opts = append(opts, imgx.WithAutoCrop())
+	}
+	return opts, nil
+}
+
 // FlipCommand creates the flip command
 func FlipCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "flip",
-		Usage: "Flip image horizontally and/or vertically",
+		Name:      "flip",
+		Usage:     "Flip image horizontally and/or vertically",
+		ArgsUsage: "<input>",
 		Description: `Flip an image horizontally (left-right), vertically (top-bottom), or both.
 
 Examples:
@@ -89,6 +220,7 @@ Examples:
 				Aliases: []string{"V"},
 				Usage:   "flip vertically (top-bottom)",
 			},
+			losslessFlag(),
 		},
 		Action: flipAction,
 	}
@@ -107,6 +239,19 @@ func flipAction(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("at least one of --horizontal or --vertical must be specified")
 	}
 
+	outputPath := getOutputPath(cmd, inputPath, "-flipped")
+	transform := imgx.JPEGFlipH
+	if horizontal && vertical {
+		transform = imgx.JPEGRotate180
+	} else if vertical {
+		transform = imgx.JPEGFlipV
+	}
+	if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+		return imgx.TransformJPEG(r, w, transform)
+	}); done || err != nil {
+		return err
+	}
+
 	// Load image
 	img, err := loadImage(cmd, inputPath)
 	if err != nil {
@@ -125,34 +270,42 @@ func flipAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	// Save
-	outputPath := getOutputPath(cmd, inputPath, "-flipped")
 	return saveImage(cmd, result, outputPath)
 }
 
 // CropCommand creates the crop command
 func CropCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "crop",
-		Usage: "Crop image to specified region",
+		Name:      "crop",
+		Usage:     "Crop image to specified region",
+		ArgsUsage: "<input>",
 		Description: `Crop an image to a specific region. You can either specify an anchor position
 or exact coordinates.
 
+--aspect crops the largest region with the given aspect ratio instead of a fixed
+size. With --anchor smart the most detailed part of the image is kept.
+
 Examples:
   imgx crop photo.jpg -w 500 -h 400 --anchor center -o output.jpg
+  imgx crop photo.jpg --aspect 4:5 --anchor smart -o post.jpg
+  imgx crop photo.jpg --aspect 16:9 --anchor top
   imgx crop photo.jpg -w 500 -h 400 --anchor topleft -o output.jpg
-  imgx crop photo.jpg -x 100 -y 100 -w 500 -h 400 -o output.jpg`,
+  imgx crop photo.jpg -x 100 -y 100 -w 500 -h 400 -o output.jpg
+  imgx crop photo.jpg -x 128 -y 64 -w 500 -h 400 --lossless   # no re-encoding when aligned to the JPEG MCU grid`,
 		Flags: []cli.Flag{
 			&cli.IntFlag{
-				Name:     "width",
-				Aliases:  []string{"w"},
-				Usage:    "crop width",
-				Required: true,
+				Name:    "width",
+				Aliases: []string{"w"},
+				Usage:   "crop width",
 			},
 			&cli.IntFlag{
-				Name:     "height",
-				Aliases:  []string{"h"},
-				Usage:    "crop height",
-				Required: true,
+				Name:    "height",
+				Aliases: []string{"h"},
+				Usage:   "crop height",
+			},
+			&cli.StringFlag{
+				Name:  "aspect",
+				Usage: "crop the largest region with this aspect ratio (e.g. 16:9, 4:5, 1.91)",
 			},
 			&cli.IntFlag{
 				Name:  "x",
@@ -165,11 +318,13 @@ Examples:
 				Value: -1,
 			},
 			&cli.StringFlag{
-				Name:    "anchor",
-				Aliases: []string{"a"},
-				Usage:   "anchor position (center, topleft, top, topright, left, right, bottomleft, bottom, bottomright)",
-				Value:   "center",
+				Name:      "anchor",
+				Aliases:   []string{"a"},
+				Usage:     "anchor position (center, topleft, top, topright, left, right, bottomleft, bottom, bottomright, smart)",
+				Value:     "center",
+				Validator: validateAnchor,
 			},
+			losslessFlag(),
 		},
 		Action: cropAction,
 	}
@@ -186,6 +341,44 @@ func cropAction(ctx context.Context, cmd *cli.Command) error {
 	x := cmd.Int("x")
 	y := cmd.Int("y")
 	anchorName := cmd.String("anchor")
+	aspect := cmd.String("aspect")
+	outputPath := getOutputPath(cmd, inputPath, "-cropped")
+
+	if aspect != "" {
+		if width > 0 || height > 0 || x >= 0 || y >= 0 {
+			return fmt.Errorf("--aspect can't be combined with -w/-h or -x/-y")
+		}
+		anchor, err := ParseAnchor(anchorName)
+		if err != nil {
+			return err
+		}
+		if cmd.Bool("lossless") {
+			warnf("--lossless requires explicit -x/-y coordinates, re-encoding %s", inputPath)
+		}
+		img, err := loadImage(cmd, inputPath)
+		if err != nil {
+			return err
+		}
+		result, err := img.CropToAspect(aspect, anchor)
+		if err != nil {
+			return err
+		}
+		return saveImage(cmd, result, outputPath)
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("crop requires -w and -h, or --aspect")
+	}
+
+	if x >= 0 && y >= 0 {
+		rect := image.Rect(x, y, x+width, y+height)
+		if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+			return imgx.CropJPEG(r, w, rect)
+		}); done || err != nil {
+			return err
+		}
+	} else if cmd.Bool("lossless") {
+		warnf("--lossless requires explicit -x/-y coordinates, re-encoding %s", inputPath)
+	}
 
 	// Load image
 	img, err := loadImage(cmd, inputPath)
@@ -215,19 +408,21 @@ func cropAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	// Save
-	outputPath := getOutputPath(cmd, inputPath, "-cropped")
 	return saveImage(cmd, result, outputPath)
 }
 
 // TransposeCommand creates the transpose command
 func TransposeCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "transpose",
-		Usage: "Transpose image (flip horizontally and rotate 90° counter-clockwise)",
+		Name:      "transpose",
+		Usage:     "Transpose image (flip horizontally and rotate 90° counter-clockwise)",
+		ArgsUsage: "<input>",
 		Description: `Transpose flips the image horizontally and then rotates it 90 degrees counter-clockwise.
 
 Example:
-  imgx transpose photo.jpg -o output.jpg`,
+  imgx transpose photo.jpg -o output.jpg
+  imgx transpose photo.jpg --lossless`,
+		Flags:  []cli.Flag{losslessFlag()},
 		Action: transposeAction,
 	}
 }
@@ -238,6 +433,13 @@ func transposeAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	inputPath := cmd.Args().Get(0)
+	outputPath := getOutputPath(cmd, inputPath, "-transposed")
+
+	if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+		return imgx.TransformJPEG(r, w, imgx.JPEGTranspose)
+	}); done || err != nil {
+		return err
+	}
 
 	// Load image
 	img, err := loadImage(cmd, inputPath)
@@ -249,19 +451,21 @@ func transposeAction(ctx context.Context, cmd *cli.Command) error {
 	result := img.Transpose()
 
 	// Save
-	outputPath := getOutputPath(cmd, inputPath, "-transposed")
 	return saveImage(cmd, result, outputPath)
 }
 
 // TransverseCommand creates the transverse command
 func TransverseCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "transverse",
-		Usage: "Transverse image (flip vertically and rotate 90° counter-clockwise)",
+		Name:      "transverse",
+		Usage:     "Transverse image (flip vertically and rotate 90° counter-clockwise)",
+		ArgsUsage: "<input>",
 		Description: `Transverse flips the image vertically and then rotates it 90 degrees counter-clockwise.
 
 Example:
-  imgx transverse photo.jpg -o output.jpg`,
+  imgx transverse photo.jpg -o output.jpg
+  imgx transverse photo.jpg --lossless`,
+		Flags:  []cli.Flag{losslessFlag()},
 		Action: transverseAction,
 	}
 }
@@ -272,6 +476,13 @@ func transverseAction(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	inputPath := cmd.Args().Get(0)
+	outputPath := getOutputPath(cmd, inputPath, "-transversed")
+
+	if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+		return imgx.TransformJPEG(r, w, imgx.JPEGTransverse)
+	}); done || err != nil {
+		return err
+	}
 
 	// Load image
 	img, err := loadImage(cmd, inputPath)
@@ -283,30 +494,37 @@ func transverseAction(ctx context.Context, cmd *cli.Command) error {
 	result := img.Transverse()
 
 	// Save
-	outputPath := getOutputPath(cmd, inputPath, "-transversed")
 	return saveImage(cmd, result, outputPath)
 }
 
 // Rotate90Command creates shortcuts for common rotations
 func Rotate90Command() *cli.Command {
 	return &cli.Command{
-		Name:  "rotate90",
-		Usage: "Rotate image 90 degrees counter-clockwise",
+		Name:      "rotate90",
+		Usage:     "Rotate image 90 degrees counter-clockwise",
+		ArgsUsage: "<input>",
 		Description: `Quickly rotate an image 90 degrees counter-clockwise (lossless).
 
 Example:
-  imgx rotate90 photo.jpg -o output.jpg`,
+  imgx rotate90 photo.jpg -o output.jpg
+  imgx rotate90 photo.jpg --lossless   # rotate JPEG DCT blocks, no re-encoding`,
+		Flags: []cli.Flag{losslessFlag()},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() < 1 {
 				return fmt.Errorf("input file required")
 			}
 			inputPath := cmd.Args().Get(0)
+			outputPath := getOutputPath(cmd, inputPath, "-rot90")
+			if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+				return imgx.TransformJPEG(r, w, imgx.JPEGRotate90)
+			}); done || err != nil {
+				return err
+			}
 			img, err := loadImage(cmd, inputPath)
 			if err != nil {
 				return err
 			}
 			result := img.Rotate90()
-			outputPath := getOutputPath(cmd, inputPath, "-rot90")
 			return saveImage(cmd, result, outputPath)
 		},
 	}
@@ -314,23 +532,31 @@ Example:
 
 func Rotate180Command() *cli.Command {
 	return &cli.Command{
-		Name:  "rotate180",
-		Usage: "Rotate image 180 degrees",
+		Name:      "rotate180",
+		Usage:     "Rotate image 180 degrees",
+		ArgsUsage: "<input>",
 		Description: `Quickly rotate an image 180 degrees (lossless).
 
 Example:
-  imgx rotate180 photo.jpg -o output.jpg`,
+  imgx rotate180 photo.jpg -o output.jpg
+  imgx rotate180 photo.jpg --lossless   # rotate JPEG DCT blocks, no re-encoding`,
+		Flags: []cli.Flag{losslessFlag()},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() < 1 {
 				return fmt.Errorf("input file required")
 			}
 			inputPath := cmd.Args().Get(0)
+			outputPath := getOutputPath(cmd, inputPath, "-rot180")
+			if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+				return imgx.TransformJPEG(r, w, imgx.JPEGRotate180)
+			}); done || err != nil {
+				return err
+			}
 			img, err := loadImage(cmd, inputPath)
 			if err != nil {
 				return err
 			}
 			result := img.Rotate180()
-			outputPath := getOutputPath(cmd, inputPath, "-rot180")
 			return saveImage(cmd, result, outputPath)
 		},
 	}
@@ -338,24 +564,109 @@ Example:
 
 func Rotate270Command() *cli.Command {
 	return &cli.Command{
-		Name:  "rotate270",
-		Usage: "Rotate image 270 degrees counter-clockwise (90 clockwise)",
+		Name:      "rotate270",
+		Usage:     "Rotate image 270 degrees counter-clockwise (90 clockwise)",
+		ArgsUsage: "<input>",
 		Description: `Quickly rotate an image 270 degrees counter-clockwise / 90 degrees clockwise (lossless).
 
 Example:
-  imgx rotate270 photo.jpg -o output.jpg`,
+  imgx rotate270 photo.jpg -o output.jpg
+  imgx rotate270 photo.jpg --lossless   # rotate JPEG DCT blocks, no re-encoding`,
+		Flags: []cli.Flag{losslessFlag()},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() < 1 {
 				return fmt.Errorf("input file required")
 			}
 			inputPath := cmd.Args().Get(0)
+			outputPath := getOutputPath(cmd, inputPath, "-rot270")
+			if done, err := tryLossless(cmd, inputPath, outputPath, func(r io.Reader, w io.Writer) error {
+				return imgx.TransformJPEG(r, w, imgx.JPEGRotate270)
+			}); done || err != nil {
+				return err
+			}
 			img, err := loadImage(cmd, inputPath)
 			if err != nil {
 				return err
 			}
 			result := img.Rotate270()
-			outputPath := getOutputPath(cmd, inputPath, "-rot270")
 			return saveImage(cmd, result, outputPath)
 		},
 	}
 }
+
+// AutoRotateCommand creates the auto-rotate command
+func AutoRotateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "auto-rotate",
+		Usage:     "Rotate scans and screenshots upright based on their text",
+		ArgsUsage: "<input>",
+		Description: `Detect the orientation of text in the image (0, 90, 180 or 270 degrees) and
+rotate it upright. Unlike --auto-orient this works on images without EXIF
+orientation, such as scans and screenshots.
+
+When the detection confidence is below --min-confidence the image is saved
+unchanged.
+
+Examples:
+  imgx auto-rotate scan.jpg -o upright.jpg
+  imgx auto-rotate scan.png --min-confidence 0.3
+  imgx auto-rotate scan.jpg --dry-run`,
+		Flags: []cli.Flag{
+			&cli.FloatFlag{
+				Name:  "min-confidence",
+				Usage: "minimum confidence (0-1) required to rotate",
+				Value: 0.1,
+				Validator: func(f float64) error {
+					if f < 0 || f > 1 {
+						return fmt.Errorf("min-confidence must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "only print the detected orientation",
+			},
+		},
+		Action: autoRotateAction,
+	}
+}
+
+func autoRotateAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input file required")
+	}
+
+	inputPath := cmd.Args().Get(0)
+
+	// Load image
+	img, err := loadImage(cmd, inputPath)
+	if err != nil {
+		return err
+	}
+
+	angle, confidence := imgx.DetectContentOrientation(img.ToNRGBA())
+	infof("Detected rotation: %d° (confidence %.2f)", angle, confidence)
+	if cmd.Bool("dry-run") {
+		return nil
+	}
+
+	result := img
+	if confidence < cmd.Float("min-confidence") {
+		warnf("confidence below %.2f, leaving %s unrotated", cmd.Float("min-confidence"), inputPath)
+	} else {
+		switch angle {
+		case 90:
+			result = img.Rotate90()
+		case 180:
+			result = img.Rotate180()
+		case 270:
+			result = img.Rotate270()
+		}
+	}
+
+	// Save
+	outputPath := getOutputPath(cmd, inputPath, "-upright")
+	return saveImage(cmd, result, outputPath)
+}
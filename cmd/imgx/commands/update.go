@@ -0,0 +1,454 @@
+package commands
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// releasesURL is the GitHub API endpoint of the imgx releases
+var releasesURL = "https://api.github.com/repos/razzkumar/imgx/releases"
+
+// releaseSigningKey is the base64 ed25519 public key that signs the
+// release.json manifest of releases. Release builds set it with
+// -ldflags "-X github.com/razzkumar/imgx/cmd/imgx/commands.releaseSigningKey=...".
+var releaseSigningKey = ""
+
+// maxUpdateSize bounds the downloaded release archive
+const maxUpdateSize = 200 << 20
+
+// SelfUpdateCommand creates the self-update command
+func SelfUpdateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "self-update",
+		Usage: "Update imgx to the latest release",
+		Description: `Download the latest imgx release from GitHub and replace the running binary.
+
+The release archive for this platform is checked against the release's
+checksums.txt. That file is covered by release.json, a manifest naming the
+release tag and version whose ed25519 signature is verified with the key built
+into release binaries, so an older release can't be served in place of a newer
+one. Nothing is replaced unless every check passes, and a release older than
+the running binary is only installed with --force. The new binary is
+written next to the old one and renamed over it, so an interrupted update
+leaves the old binary intact.
+
+Builds without the release key (e.g. go install or source builds) refuse to
+self-update; update them the way they were installed. Installs managed by a
+package manager should also be updated with it.
+
+Examples:
+  imgx self-update --check             # report whether an update is available
+  imgx self-update
+  imgx self-update --channel beta      # include pre-releases`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "channel",
+				Usage: "release channel: stable or beta (pre-releases included)",
+				Value: "stable",
+				Validator: func(v string) error {
+					if v != "stable" && v != "beta" {
+						return fmt.Errorf("channel must be stable or beta")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "only report whether an update is available",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "reinstall even if the latest release is not newer, or downgrade to it",
+			},
+		},
+		Action: selfUpdateAction,
+	}
+}
+
+func selfUpdateAction(ctx context.Context, cmd *cli.Command) error {
+	if imgx.IsOffline() {
+		return fmt.Errorf("self-update: %w", imgx.ErrOffline)
+	}
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	release, err := latestRelease(ctx, client, cmd.String("channel"))
+	if err != nil {
+		return err
+	}
+	newer := compareVersions(release.Version(), imgx.Version) > 0
+	if cmd.Bool("check") {
+		if newer {
+			fmt.Printf("%s: %s -> %s (%s)\n", tr("Update available"), imgx.Version, release.Version(), release.HTMLURL)
+		} else {
+			infof("imgx %s is up to date", imgx.Version)
+		}
+		return nil
+	}
+	if !newer && !cmd.Bool("force") {
+		infof("imgx %s is up to date", imgx.Version)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the imgx binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("cannot locate the imgx binary: %w", err)
+	}
+	if err := installRelease(ctx, client, release, releaseSigningKey, cmd.Bool("force"), runtime.GOOS, runtime.GOARCH, exe); err != nil {
+		return err
+	}
+	infof("Updated imgx %s -> %s", imgx.Version, release.Version())
+	return nil
+}
+
+// githubRelease is the part of a GitHub release used by self-update
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Draft   bool   `json:"draft"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Version returns the release version without the "v" prefix
+func (r *githubRelease) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// assetURL returns the download URL of the named asset
+func (r *githubRelease) assetURL(name string) (string, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s has no %s", r.TagName, name)
+}
+
+// latestRelease returns the newest release of the channel: the latest
+// stable release, or for beta the newest release including pre-releases
+func latestRelease(ctx context.Context, client *http.Client, channel string) (*githubRelease, error) {
+	url := releasesURL + "/latest"
+	if channel == "beta" {
+		url = releasesURL + "?per_page=20"
+	}
+	body, err := httpGet(ctx, client, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if channel != "beta" {
+		var r githubRelease
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+		return &r, nil
+	}
+	var releases []githubRelease
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	var best *githubRelease
+	for i, r := range releases {
+		if !r.Draft && (best == nil || compareVersions(r.Version(), best.Version()) > 0) {
+			best = &releases[i]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no releases found")
+	}
+	return best, nil
+}
+
+// releaseArchiveName returns the name of the release archive for the
+// platform, as named by .goreleaser.yaml
+func releaseArchiveName(goos, goarch string) string {
+	arch := goarch
+	if goarch == "amd64" {
+		arch = "x86_64"
+	}
+	return "imgx_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + arch + ".tar.gz"
+}
+
+// releaseManifest is the signed release.json of a release. It ties
+// checksums.txt to the release tag and version, so that the signed files of
+// an older release can't pass for those of the latest one.
+type releaseManifest struct {
+	Tag       string `json:"tag"`
+	Version   string `json:"version"`
+	Checksums string `json:"checksums_sha256"` // SHA-256 of checksums.txt
+}
+
+// installRelease downloads the release archive for the platform, verifies
+// it and replaces the binary at exe with the one in the archive. A release
+// older than the running version is refused unless force is set.
+func installRelease(ctx context.Context, client *http.Client, release *githubRelease, signingKey string, force bool, goos, goarch, exe string) error {
+	if signingKey == "" {
+		return fmt.Errorf("this imgx build has no release signing key (built from source?); update it the way it was installed")
+	}
+	key, err := base64.StdEncoding.DecodeString(signingKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key")
+	}
+
+	// The signature covers release.json, which covers the tag, the version
+	// and checksums.txt, which covers the archive
+	manifest, err := verifiedManifest(ctx, client, release, ed25519.PublicKey(key))
+	if err != nil {
+		return err
+	}
+	if compareVersions(manifest.Version, imgx.Version) < 0 && !force {
+		return fmt.Errorf("refusing to downgrade imgx %s to %s; use --force to install it anyway", imgx.Version, manifest.Version)
+	}
+	checksumsURL, err := release.assetURL("checksums.txt")
+	if err != nil {
+		return err
+	}
+	checksums, err := httpGet(ctx, client, checksumsURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksums: %w", err)
+	}
+	if got := sha256.Sum256(checksums); hex.EncodeToString(got[:]) != strings.ToLower(manifest.Checksums) {
+		return fmt.Errorf("checksums.txt of %s does not match its signed manifest", release.TagName)
+	}
+
+	archive := releaseArchiveName(goos, goarch)
+	want, err := lookupChecksum(checksums, archive)
+	if err != nil {
+		return err
+	}
+	archiveURL, err := release.assetURL(archive)
+	if err != nil {
+		return err
+	}
+	data, err := httpGet(ctx, client, archiveURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", archive, err)
+	}
+	if got := sha256.Sum256(data); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s", archive)
+	}
+
+	binary := "imgx"
+	if goos == "windows" {
+		binary = "imgx.exe"
+	}
+	newBinary, err := extractFile(data, archive, binary)
+	if err != nil {
+		return err
+	}
+	return replaceBinary(exe, newBinary, goos)
+}
+
+// verifiedManifest downloads release.json and its signature, verifies the
+// signature with key and checks that the manifest is that of release
+func verifiedManifest(ctx context.Context, client *http.Client, release *githubRelease, key ed25519.PublicKey) (*releaseManifest, error) {
+	manifestURL, err := release.assetURL("release.json")
+	if err != nil {
+		return nil, err
+	}
+	sigURL, err := release.assetURL("release.json.sig")
+	if err != nil {
+		return nil, err
+	}
+	data, err := httpGet(ctx, client, manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download release manifest: %w", err)
+	}
+	sig, err := httpGet(ctx, client, sigURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature: %w", err)
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return nil, fmt.Errorf("signature verification failed for %s release.json", release.TagName)
+	}
+
+	var m releaseManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid release manifest of %s: %w", release.TagName, err)
+	}
+	if m.Tag != release.TagName || m.Version != release.Version() {
+		return nil, fmt.Errorf("release manifest is for %s (%s), not %s", m.Tag, m.Version, release.TagName)
+	}
+	return &m, nil
+}
+
+// lookupChecksum returns the SHA-256 of name in a checksums.txt
+// ("<hex>  <name>" lines)
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("checksums.txt has no entry for %s", name)
+}
+
+// extractFile returns the contents of the file with the given base name in
+// a .tar.gz archive
+func extractFile(data []byte, archive, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", archive, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in %s", name, archive)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", archive, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxUpdateSize))
+		}
+	}
+}
+
+// replaceBinary writes data next to exe and renames it over exe, keeping
+// the permissions of exe. Windows can't replace a running binary, so the
+// old one is moved aside to exe.old first.
+func replaceBinary(exe string, data []byte, goos string) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".imgx-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0111); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+
+	if goos == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("cannot replace %s: %w", exe, err)
+		}
+		if err := os.Rename(tmp.Name(), exe); err != nil {
+			os.Rename(old, exe)
+			return fmt.Errorf("cannot replace %s: %w", exe, err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// httpGet returns the body of url, failing on non-2xx responses
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "imgx/"+imgx.Version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxUpdateSize))
+}
+
+// compareVersions compares semantic versions like "1.4.0" and
+// "1.4.0-beta.2", returning -1, 0 or 1. A pre-release sorts before its
+// release.
+func compareVersions(a, b string) int {
+	a, preA, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, preB, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return comparePrerelease(preA, preB)
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers,
+// numerically where both are numbers
+func comparePrerelease(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < min(len(pa), len(pb)); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && pa[i] != pb[i]:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/imgx"
+	"github.com/razzkumar/imgx/detection"
+	"github.com/urfave/cli/v3"
+)
+
+// VersionCommand creates the version command
+func VersionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Show the version and capabilities of imgx",
+		Description: `Print the imgx version. With --verbose, also print what this build can do:
+the image formats it decodes and encodes, the configured detection providers,
+the external tools found in PATH (exiftool, ffmpeg, tesseract), the SIMD code
+paths in use and the build information. Include it in bug reports.
+
+Examples:
+  imgx version
+  imgx version --verbose
+  imgx version --verbose --json   # for scripts and feature checks`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "output as JSON",
+			},
+		},
+		Action: versionAction,
+	}
+}
+
+func versionAction(ctx context.Context, cmd *cli.Command) error {
+	if !cmd.Bool("verbose") {
+		if cmd.Bool("json") {
+			fmt.Printf("{\"version\": %q}\n", imgx.Version)
+		} else {
+			infof("imgx version %s", imgx.Version)
+		}
+		return nil
+	}
+
+	caps := imgx.Capabilities()
+	caps.Providers = detection.ConfiguredProviders()
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(caps, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printCapabilities(caps)
+	return nil
+}
+
+// printCapabilities prints caps for humans
+func printCapabilities(caps *imgx.CapabilityReport) {
+	fmt.Printf(tr("imgx version %s")+"\n\n", caps.Version)
+
+	b := caps.Build
+	fmt.Println(tr("Build") + ":")
+	fmt.Printf("  %-10s %s %s/%s\n", "Go:", b.GoVersion, b.OS, b.Arch)
+	if b.Module != "" {
+		fmt.Printf("  %-10s %s\n", tr("Module")+":", b.Module)
+	}
+	if b.Revision != "" {
+		modified := ""
+		if b.Modified {
+			modified = " (modified)"
+		}
+		fmt.Printf("  %-10s %s %s%s\n", tr("Revision")+":", b.Revision, b.Time, modified)
+	}
+	fmt.Printf("  %-10s %v\n", "CGO:", b.CGO)
+
+	fmt.Println()
+	fmt.Println(tr("Formats") + ":")
+	for _, f := range caps.Formats {
+		var modes []string
+		if f.Decode {
+			modes = append(modes, "decode")
+		}
+		if f.Encode {
+			modes = append(modes, "encode")
+		}
+		fmt.Printf("  %-6s %-14s (%s)\n", f.Name, strings.Join(modes, ", "), strings.Join(f.Extensions, ", "))
+	}
+
+	fmt.Println()
+	fmt.Println(tr("Detection providers") + ":")
+	if len(caps.Providers) == 0 {
+		fmt.Printf("  %s\n", tr("none configured"))
+	} else {
+		fmt.Printf("  %s\n", strings.Join(caps.Providers, ", "))
+	}
+	if caps.Offline {
+		fmt.Printf("  %s\n", tr("offline mode: cloud providers disabled"))
+	}
+
+	fmt.Println()
+	fmt.Println(tr("External tools") + ":")
+	tools := make([]string, 0, len(caps.Tools))
+	for tool := range caps.Tools {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		status := "not found"
+		if caps.Tools[tool] {
+			status = "found"
+		}
+		fmt.Printf("  %-10s %s\n", tool, status)
+	}
+
+	fmt.Println()
+	fmt.Println("SIMD:")
+	if len(caps.SIMD) == 0 {
+		fmt.Printf("  %s\n", tr("none (pure Go)"))
+	} else {
+		fmt.Printf("  %s\n", strings.Join(caps.SIMD, ", "))
+	}
+}
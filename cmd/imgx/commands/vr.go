@@ -0,0 +1,601 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/imgx"
+	"github.com/urfave/cli/v3"
+)
+
+// vrPreviewSize bounds the images embedded in vr compare reports
+const vrPreviewSize = 480
+
+// VRCommand creates the vr command
+func VRCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "vr",
+		Usage: "Visual regression testing of screenshots against approved baselines",
+		Description: `Keep a set of approved screenshots (baselines) and compare new runs against
+them. Images are matched by their path relative to the directory and compared
+with SSIM (structural similarity), so encoder noise and antialiasing changes
+too small to see pass while layout and color changes fail.
+
+Workflow:
+  imgx vr baseline ./shots                       # store the approved screenshots
+  imgx vr compare ./shots-new --report report.html
+  imgx vr approve ./shots-new header.png         # accept an intended change
+
+Baselines are stored in --baselines (default: vr-baselines), which is meant
+to be committed next to the tests.`,
+		Commands: []*cli.Command{
+			vrBaselineCommand(),
+			vrCompareCommand(),
+			vrApproveCommand(),
+		},
+	}
+}
+
+// vrBaselinesFlag is the baseline directory flag shared by the vr commands
+func vrBaselinesFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "baselines",
+		Usage: "directory of the approved baselines",
+		Value: "vr-baselines",
+	}
+}
+
+// vrBaselineCommand creates the vr baseline subcommand
+func vrBaselineCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "baseline",
+		Usage:     "Store the screenshots of a directory as the approved baselines",
+		ArgsUsage: "<dir>",
+		Description: `Copy every image of a directory tree to the baseline directory, replacing the
+previous baselines: images that are no longer in the directory are removed
+from the baselines too.
+
+Examples:
+  imgx vr baseline ./shots
+  imgx vr baseline ./shots --baselines test/visual/baselines`,
+		Flags: []cli.Flag{
+			vrBaselinesFlag(),
+		},
+		Action: vrBaselineAction,
+	}
+}
+
+// vrCompareCommand creates the vr compare subcommand
+func vrCompareCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "compare",
+		Usage:     "Compare new screenshots with the baselines",
+		ArgsUsage: "<dir>",
+		Description: `Compare every image of a directory tree with its baseline. The difference of
+an image is 1 - SSIM: 0 for identical images, growing with visible changes.
+Each image is reported as:
+
+  pass      the difference is at most --threshold
+  fail      the difference is over --threshold, or the size changed
+  new       there is no baseline for the image
+  missing   a baseline has no new image
+  error     an image could not be read
+
+The command exits with status 0 when every image passes and 1 otherwise
+(new images pass with --allow-new), so it can gate CI jobs. With --report, a
+self-contained HTML page shows the baseline, the new image and a heatmap of
+the differences side by side for every image that did not pass.
+
+Examples:
+  imgx vr compare ./shots-new
+  imgx vr compare ./shots-new --threshold 0.05 --report report.html
+  imgx vr compare ./shots-new --baselines test/visual/baselines --json`,
+		Flags: []cli.Flag{
+			vrBaselinesFlag(),
+			&cli.FloatFlag{
+				Name:  "threshold",
+				Usage: "largest difference (1 - SSIM) allowed per image",
+				Value: 0.01,
+				Validator: func(v float64) error {
+					if v < 0 || v > 1 {
+						return fmt.Errorf("threshold must be between 0 and 1")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "report",
+				Usage: "write an HTML report with side-by-side and heatmap diffs to this file",
+			},
+			&cli.BoolFlag{
+				Name:  "allow-new",
+				Usage: "do not fail on images without a baseline",
+			},
+			&cli.BoolFlag{
+				Name:    "json",
+				Aliases: []string{"j"},
+				Usage:   "output the report as JSON",
+			},
+		},
+		Action: vrCompareAction,
+	}
+}
+
+// vrApproveCommand creates the vr approve subcommand
+func vrApproveCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "approve",
+		Usage:     "Promote new screenshots to baselines",
+		ArgsUsage: "<dir> [path...]",
+		Description: `Copy new screenshots over their baselines once a change is intended. Paths are
+relative to the directory, as printed by 'vr compare'. Without paths, every
+image that is new or whose file differs from its baseline is approved. With
+--prune, baselines without a new image are removed.
+
+Examples:
+  imgx vr approve ./shots-new header.png footer/dark.png
+  imgx vr approve ./shots-new --prune`,
+		Flags: []cli.Flag{
+			vrBaselinesFlag(),
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "remove the baselines that have no new image",
+			},
+		},
+		Action: vrApproveAction,
+	}
+}
+
+// vrResult is the comparison of one image; Path is slash-separated and
+// relative to the directories
+type vrResult struct {
+	Path   string  `json:"path"`
+	Status string  `json:"status"`
+	Diff   float64 `json:"diff"`
+	Size   string  `json:"size,omitempty"` // WIDTHxHEIGHT of the new image
+	Error  string  `json:"error,omitempty"`
+
+	preview *vrPreview
+}
+
+// vrPreview holds the data URIs of the images shown in the HTML report
+type vrPreview struct {
+	Baseline, Current, Heatmap template.URL
+}
+
+type vrReport struct {
+	Threshold float64        `json:"threshold"`
+	Results   []vrResult     `json:"results"`
+	Counts    map[string]int `json:"counts"`
+}
+
+func vrBaselineAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+	dir, baselines := cmd.Args().Get(0), cmd.String("baselines")
+	current, err := vrImages(dir)
+	if err != nil {
+		return err
+	}
+	if len(current) == 0 {
+		return fmt.Errorf("no images found in %s", dir)
+	}
+	stored, err := vrImages(baselines)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, rel := range current {
+		if err := copyFile(vrPath(dir, rel), vrPath(baselines, rel)); err != nil {
+			return err
+		}
+	}
+	removed := 0
+	for _, rel := range vrOnlyIn(stored, current) {
+		if err := os.Remove(vrPath(baselines, rel)); err != nil {
+			return err
+		}
+		removed++
+	}
+	fmt.Printf(tr("Stored %d baselines in %s"), len(current), baselines)
+	if removed > 0 {
+		fmt.Printf(" ("+tr("removed %d")+")", removed)
+	}
+	fmt.Println()
+	return nil
+}
+
+func vrCompareAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+	dir := cmd.Args().Get(0)
+	reportPath := cmd.String("report")
+
+	report, err := compareVR(ctx, cmd.String("baselines"), dir, cmd.Float("threshold"), reportPath != "")
+	if err != nil {
+		return err
+	}
+
+	if reportPath != "" {
+		if err := writeVRReport(reportPath, report, dir, cmd.String("baselines")); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", tr("Report"), reportPath)
+	}
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printVRReport(report, cmd.Bool("verbose"))
+	}
+
+	failed := report.Counts["fail"] + report.Counts["missing"] + report.Counts["error"]
+	if !cmd.Bool("allow-new") {
+		failed += report.Counts["new"]
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d screenshots differ from their baselines", failed, len(report.Results))
+	}
+	return nil
+}
+
+func vrApproveAction(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("input directory required")
+	}
+	dir, baselines := cmd.Args().Get(0), cmd.String("baselines")
+	approved, pruned, err := approveVR(baselines, dir, cmd.Args().Slice()[1:], cmd.Bool("prune"))
+	if err != nil {
+		return err
+	}
+	for _, rel := range approved {
+		fmt.Printf("%-9s %s\n", tr("Approved")+":", rel)
+	}
+	for _, rel := range pruned {
+		fmt.Printf("%-9s %s\n", tr("Removed")+":", rel)
+	}
+	if len(approved)+len(pruned) == 0 {
+		fmt.Println(tr("Baselines are up to date"))
+	}
+	return nil
+}
+
+// compareVR compares the images of dir with their baselines. With
+// previews, the results that did not pass carry the images of the HTML
+// report.
+func compareVR(ctx context.Context, baselines, dir string, threshold float64, previews bool) (*vrReport, error) {
+	current, err := vrImages(dir)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := vrImages(baselines)
+	if err != nil {
+		return nil, fmt.Errorf("no baselines: %w (run 'imgx vr baseline' first)", err)
+	}
+
+	inBaselines := make(map[string]bool, len(stored))
+	for _, rel := range stored {
+		inBaselines[rel] = true
+	}
+	report := &vrReport{Threshold: threshold, Counts: make(map[string]int)}
+	for _, rel := range current {
+		report.Results = append(report.Results, vrResult{Path: rel})
+	}
+	for _, rel := range vrOnlyIn(stored, current) {
+		report.Results = append(report.Results, vrResult{Path: rel, Status: "missing"})
+	}
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].Path < report.Results[j].Path })
+
+	hashFiles(ctx, len(report.Results), func(i int) error {
+		r := &report.Results[i]
+		if r.Status == "missing" {
+			if previews {
+				if img, err := imgx.Load(vrPath(baselines, r.Path)); err == nil {
+					r.preview = &vrPreview{Baseline: vrDataURI(img)}
+				}
+			}
+			return nil
+		}
+		if !inBaselines[r.Path] {
+			r.Status = "new"
+			if previews {
+				if img, err := imgx.Load(vrPath(dir, r.Path)); err == nil {
+					r.preview = &vrPreview{Current: vrDataURI(img)}
+				}
+			}
+			return nil
+		}
+		compareVRImage(r, vrPath(baselines, r.Path), vrPath(dir, r.Path), threshold, previews)
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range report.Results {
+		report.Counts[r.Status]++
+	}
+	return report, nil
+}
+
+// compareVRImage compares one image with its baseline into r
+func compareVRImage(r *vrResult, baselinePath, currentPath string, threshold float64, previews bool) {
+	baseline, err := imgx.Load(baselinePath)
+	if err != nil {
+		r.Status, r.Error = "error", fmt.Sprintf("baseline: %v", err)
+		return
+	}
+	current, err := imgx.Load(currentPath)
+	if err != nil {
+		r.Status, r.Error = "error", err.Error()
+		return
+	}
+	bb, cb := baseline.Bounds(), current.Bounds()
+	r.Size = fmt.Sprintf("%dx%d", cb.Dx(), cb.Dy())
+
+	var heatmap *imgx.Image
+	if bb.Size() != cb.Size() {
+		r.Status, r.Diff = "fail", 1
+		r.Error = fmt.Sprintf("size changed from %dx%d to %dx%d", bb.Dx(), bb.Dy(), cb.Dx(), cb.Dy())
+	} else {
+		score, err := current.SSIM(baseline.ToNRGBA())
+		if err != nil {
+			r.Status, r.Error = "error", err.Error()
+			return
+		}
+		r.Diff = max(0, 1-score)
+		r.Status = "pass"
+		if r.Diff > threshold {
+			r.Status = "fail"
+			if previews {
+				heatmap, _ = baseline.DiffHeatmap(current.ToNRGBA())
+			}
+		}
+	}
+
+	if previews && r.Status != "pass" {
+		r.preview = &vrPreview{Baseline: vrDataURI(baseline), Current: vrDataURI(current)}
+		if heatmap != nil {
+			r.preview.Heatmap = vrDataURI(heatmap)
+		}
+	}
+}
+
+// approveVR copies the given images of dir, or all new and changed ones,
+// over their baselines and with prune removes the baselines without an
+// image. It returns the approved and removed paths.
+func approveVR(baselines, dir string, paths []string, prune bool) (approved, pruned []string, err error) {
+	current, err := vrImages(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, err := vrImages(baselines)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	if len(paths) == 0 {
+		for _, rel := range current {
+			a, errA := os.ReadFile(vrPath(dir, rel))
+			b, errB := os.ReadFile(vrPath(baselines, rel))
+			if errA != nil || errB != nil || !bytes.Equal(a, b) {
+				paths = append(paths, rel)
+			}
+		}
+	}
+	for _, rel := range paths {
+		rel = filepath.ToSlash(filepath.Clean(rel))
+		if rel == ".." || strings.HasPrefix(rel, "../") || filepath.IsAbs(rel) {
+			return approved, pruned, fmt.Errorf("%s is outside %s", rel, dir)
+		}
+		if err := copyFile(vrPath(dir, rel), vrPath(baselines, rel)); err != nil {
+			return approved, pruned, err
+		}
+		approved = append(approved, rel)
+	}
+	if prune {
+		for _, rel := range vrOnlyIn(stored, current) {
+			if err := os.Remove(vrPath(baselines, rel)); err != nil {
+				return approved, pruned, err
+			}
+			pruned = append(pruned, rel)
+		}
+	}
+	return approved, pruned, nil
+}
+
+// vrImages returns the slash-separated paths of the images under dir,
+// relative to it
+func vrImages(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	paths, err := CollectImageFiles([]string{dir}, true)
+	if err != nil {
+		return nil, err
+	}
+	rels := make([]string, len(paths))
+	for i, p := range paths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil, err
+		}
+		rels[i] = filepath.ToSlash(rel)
+	}
+	return rels, nil
+}
+
+// vrOnlyIn returns the paths of a that are not in b
+func vrOnlyIn(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, p := range b {
+		inB[p] = true
+	}
+	var only []string
+	for _, p := range a {
+		if !inB[p] {
+			only = append(only, p)
+		}
+	}
+	return only
+}
+
+// vrBaselinesArg returns the --baselines argument of the commands printed
+// for baselines, empty for the default
+func vrBaselinesArg(baselines string) string {
+	if baselines == "vr-baselines" {
+		return ""
+	}
+	return " --baselines " + baselines
+}
+
+// vrPath joins dir and a slash-separated relative path
+func vrPath(dir, rel string) string {
+	return filepath.Join(dir, filepath.FromSlash(rel))
+}
+
+// copyFile copies src to dst, creating the directories of dst
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// vrDataURI returns img scaled down to the preview size as a PNG data URI
+func vrDataURI(img *imgx.Image) template.URL {
+	b := img.Bounds()
+	var preview image.Image = img.ToNRGBA()
+	if b.Dx() > vrPreviewSize || b.Dy() > vrPreviewSize {
+		preview = img.Fit(vrPreviewSize, vrPreviewSize, imgx.Lanczos).ToNRGBA()
+	}
+	var buf bytes.Buffer
+	if err := imgx.Encode(&buf, preview, imgx.PNG); err != nil {
+		return ""
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// printVRReport prints the images that did not pass, or all with verbose,
+// and the counts
+func printVRReport(report *vrReport, verbose bool) {
+	for _, r := range report.Results {
+		if r.Status == "pass" && !verbose {
+			continue
+		}
+		line := fmt.Sprintf("%-8s %s", strings.ToUpper(r.Status), r.Path)
+		if r.Status == "pass" || r.Status == "fail" {
+			line += fmt.Sprintf("  (diff %.4f)", r.Diff)
+		}
+		if r.Error != "" {
+			line += "  " + r.Error
+		}
+		fmt.Println(line)
+	}
+	var parts []string
+	for _, status := range []string{"pass", "fail", "new", "missing", "error"} {
+		if n := report.Counts[status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	fmt.Printf("%d screenshots: %s (threshold %.4f)\n", len(report.Results), strings.Join(parts, ", "), report.Threshold)
+}
+
+// writeVRReport writes the HTML report of a comparison of dir with
+// baselines
+func writeVRReport(path string, report *vrReport, dir, baselines string) error {
+	var changed, passed []vrResult
+	for _, r := range report.Results {
+		if r.Status == "pass" {
+			passed = append(passed, r)
+		} else {
+			changed = append(changed, r)
+		}
+	}
+	type row struct {
+		vrResult
+		Preview *vrPreview
+	}
+	var rows []row
+	for _, r := range changed {
+		rows = append(rows, row{r, r.preview})
+	}
+
+	var buf bytes.Buffer
+	err := vrReportTemplate.Execute(&buf, map[string]any{
+		"Report":  report,
+		"Changed": rows,
+		"Passed":  passed,
+		"Dir":     dir,
+		"Flags":   vrBaselinesArg(baselines),
+		"Created": time.Now().Format(time.RFC1123),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+var vrReportTemplate = template.Must(template.New("vr").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>imgx visual regression report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+h2 { margin-top: 2em; }
+.counts span { margin-right: 1.5em; }
+.pass { color: #1a7f37; } .fail, .missing, .error { color: #cf222e; } .new { color: #9a6700; }
+.entry { border-top: 1px solid #ddd; padding: 1em 0; }
+.images { display: flex; gap: 1em; flex-wrap: wrap; }
+figure { margin: 0; }
+figcaption { font-size: 0.85em; color: #666; }
+img { max-width: 480px; border: 1px solid #ccc; background: repeating-conic-gradient(#eee 0 25%, #fff 0 50%) 0 0 / 16px 16px; }
+code { background: #f4f4f4; padding: 0.1em 0.3em; }
+td, th { padding: 0.2em 1em 0.2em 0; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Visual regression report</h1>
+<p>{{.Created}} &middot; threshold {{printf "%.4f" .Report.Threshold}} (1 - SSIM)</p>
+<p class="counts">{{range $status, $n := .Report.Counts}}<span class="{{$status}}">{{$n}} {{$status}}</span>{{end}}</p>
+{{if .Changed}}<h2>Changes</h2>{{end}}
+{{range .Changed}}<div class="entry">
+<h3><span class="{{.Status}}">{{.Status}}</span> {{.Path}}{{if or (eq .Status "fail") (eq .Status "pass")}} &middot; diff {{printf "%.4f" .Diff}}{{end}}</h3>
+{{if .Error}}<p>{{.Error}}</p>{{end}}
+{{with .Preview}}<div class="images">
+{{if .Baseline}}<figure><img src="{{.Baseline}}" alt="baseline"><figcaption>baseline</figcaption></figure>{{end}}
+{{if .Current}}<figure><img src="{{.Current}}" alt="new"><figcaption>new</figcaption></figure>{{end}}
+{{if .Heatmap}}<figure><img src="{{.Heatmap}}" alt="difference"><figcaption>difference</figcaption></figure>{{end}}
+</div>{{end}}
+{{if ne .Status "missing"}}<p>Approve: <code>imgx vr approve {{$.Dir}} {{.Path}}{{$.Flags}}</code></p>{{end}}
+</div>{{end}}
+{{if .Passed}}<h2>Passed</h2>
+<table><tr><th>Image</th><th>Size</th><th>Diff</th></tr>
+{{range .Passed}}<tr><td>{{.Path}}</td><td>{{.Size}}</td><td>{{printf "%.4f" .Diff}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
@@ -6,6 +6,7 @@ require (
 	github.com/razzkumar/imgx v1.2.4
 	github.com/razzkumar/imgx/detection v0.0.0
 	github.com/urfave/cli/v3 v3.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
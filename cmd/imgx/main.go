@@ -7,11 +7,26 @@ import (
 
 	"github.com/razzkumar/imgx"
 	"github.com/razzkumar/imgx/cmd/imgx/commands"
+	"github.com/razzkumar/imgx/detection"
 	"github.com/urfave/cli/v3"
 )
 
 func main() {
-	app := &cli.Command{
+	app := newApp()
+	commands.EnableCompletion(app)
+	if err := commands.Localize(app); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: IMGX_LANG: %v\n", err)
+	}
+
+	if err := app.Run(context.Background(), os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, commands.ErrorMessage(err))
+		os.Exit(1)
+	}
+}
+
+// newApp returns the imgx command with its global flags and subcommands
+func newApp() *cli.Command {
+	return &cli.Command{
 		Name:                  "imgx",
 		Usage:                 "A powerful command-line image processing tool",
 		Version:               imgx.Version,
@@ -38,6 +53,23 @@ Examples:
 				Aliases: []string{"q"},
 				Usage:   "JPEG quality 1-100 (default: 95)",
 				Value:   95,
+				Validator: func(v int) error {
+					if v < 1 || v > 100 {
+						return fmt.Errorf("quality must be between 1 and 100")
+					}
+					return nil
+				},
+			},
+			&cli.IntFlag{
+				Name:  "effort",
+				Usage: "WebP encoding effort 0-6: 0 is fastest, 6 gives the smallest files (default: 4)",
+				Value: imgx.DefaultWebPEffort,
+				Validator: func(v int) error {
+					if v < 0 || v > 6 {
+						return fmt.Errorf("effort must be between 0 and 6")
+					}
+					return nil
+				},
 			},
 			&cli.BoolFlag{
 				Name:  "auto-orient",
@@ -54,34 +86,181 @@ Examples:
 				Usage:   "verbose output",
 				Value:   false,
 			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "refuse to save when data would be lost (transparency, palette, bit depth, ICC profile, EXIF, animation) or a JPEG re-encoded above its quality",
+			},
+			&cli.BoolFlag{
+				Name:  "warnings-as-errors",
+				Usage: "fail when an image is saved or analyzed with warnings (e.g. transparency or ICC profile dropped)",
+			},
+			&cli.IntFlag{
+				Name:    "memory-limit",
+				Usage:   "refuse to load images whose decoding needs more than this many MB (0: no limit)",
+				Sources: cli.EnvVars("IMGX_MEMORY_LIMIT"),
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("memory-limit must not be negative")
+					}
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:  "gallery",
+				Usage: "write an HTML gallery and a JSON report of the saved images to this directory",
+			},
+			&cli.StringSliceFlag{
+				Name:  "post",
+				Usage: "run a command after each image is saved, e.g. \"aws s3 cp {path} s3://bucket/\" (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "post-policy",
+				Usage: "when a --post command fails: fail, warn or ignore",
+				Value: "fail",
+				Validator: func(v string) error {
+					_, err := imgx.ParseHookPolicy(v)
+					return err
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "profile",
+				Usage: "print the time, allocations and sizes of every operation (decode, each step, encode) to stderr; with -v per operation",
+			},
+			&cli.StringFlag{
+				Name:  "pprof",
+				Usage: "write a CPU profile of the run to this file, for go tool pprof",
+			},
+			&cli.BoolFlag{
+				Name:    "offline",
+				Usage:   "forbid network access: cloud detection providers fail fast, local processing and Ollama on localhost still work",
+				Sources: cli.EnvVars("IMGX_OFFLINE"),
+			},
+			&cli.IntFlag{
+				Name:    "max-retries",
+				Usage:   "retry detection provider requests that are rate limited (429), fail with a server error or a network error, with exponential backoff honoring Retry-After",
+				Sources: cli.EnvVars("IMGX_DETECTION_RETRIES"),
+				Validator: func(v int) error {
+					if v < 0 {
+						return fmt.Errorf("max-retries must not be negative")
+					}
+					return nil
+				},
+			},
+			&cli.FloatFlag{
+				Name:    "rate-limit",
+				Usage:   "send at most this many requests per second to each detection provider (0: unlimited)",
+				Sources: cli.EnvVars("IMGX_DETECTION_RATE_LIMIT"),
+				Validator: func(v float64) error {
+					if v < 0 {
+						return fmt.Errorf("rate-limit must not be negative")
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:    "debug-http",
+				Usage:   "log detection provider requests and responses to stderr (API keys and image data redacted)",
+				Sources: cli.EnvVars("IMGX_DEBUG_HTTP"),
+			},
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if cmd.Bool("offline") {
+				imgx.OfflineMode(true)
+				detection.SetOffline(true)
+			}
+			if cmd.Bool("debug-http") {
+				detection.SetDebugWriter(os.Stderr)
+			}
+			if retries := cmd.Int("max-retries"); retries > 0 {
+				detection.SetRetryPolicy(detection.RetryPolicy{MaxAttempts: retries + 1})
+			}
+			if rate := cmd.Float("rate-limit"); rate > 0 {
+				detection.SetRateLimit("", detection.RateLimit{RequestsPerSecond: rate})
+			}
+			if posts := cmd.StringSlice("post"); len(posts) > 0 {
+				hook, err := commands.PostSaveHook(posts)
+				if err != nil {
+					return ctx, err
+				}
+				imgx.OnSave(hook)
+			}
+			if cmd.String("gallery") != "" {
+				commands.StartGallery()
+			}
+			if path := cmd.String("pprof"); path != "" {
+				if err := commands.StartCPUProfile(path); err != nil {
+					return ctx, err
+				}
+			}
+			return ctx, nil
+		},
+		After: func(ctx context.Context, cmd *cli.Command) error {
+			if err := commands.StopCPUProfile(); err != nil {
+				return err
+			}
+			if dir := cmd.String("gallery"); dir != "" {
+				return commands.WriteGallery(dir, os.Args)
+			}
+			return nil
 		},
 		Commands: []*cli.Command{
+			commands.A11yCommand(),
 			commands.AdjustCommand(),
+			commands.AltTextCommand(),
+			commands.AnnotateCommand(),
+			commands.AnonymizeCommand(),
+			commands.AskCommand(),
+			commands.AutoRotateCommand(),
+			commands.BestShotCommand(),
 			commands.BlurCommand(),
+			commands.CompareCommand(),
 			commands.CompletionsCommand(),
+			commands.ContactSheetCommand(),
+			commands.ConvertCommand(),
 			commands.CropCommand(),
+			commands.DaemonCommand(),
+			commands.DedupeCommand(),
+			commands.DescratchCommand(),
 			commands.DetectCommand(),
+			commands.DocsCommand(),
+			commands.EditCommand(),
+			commands.ExtractObjectsCommand(),
 			commands.FillCommand(),
 			commands.FitCommand(),
 			commands.FlipCommand(),
+			commands.GenerateCommand(),
 			commands.GrayscaleCommand(),
+			commands.GuidesCommand(),
+			commands.HistogramCommand(),
+			commands.InpaintCommand(),
 			commands.InvertCommand(),
+			commands.KnockoutCommand(),
+			commands.ManifestCommand(),
 			commands.MetadataCommand(),
+			commands.NormalizeOrientationCommand(),
+			commands.PipelineCommand(),
+			commands.PromptsCommand(),
+			commands.ProofCommand(),
+			commands.RenameCommand(),
 			commands.ResizeCommand(),
 			commands.RotateCommand(),
 			commands.Rotate180Command(),
 			commands.Rotate270Command(),
 			commands.Rotate90Command(),
+			commands.ScanEnhanceCommand(),
+			commands.SelfUpdateCommand(),
 			commands.SharpenCommand(),
+			commands.ShearCommand(),
+			commands.ShrinkScreenshotCommand(),
+			commands.SocialCommand(),
+			commands.StatsCommand(),
 			commands.ThumbnailCommand(),
+			commands.TimelapseCommand(),
 			commands.TransposeCommand(),
 			commands.TransverseCommand(),
+			commands.VersionCommand(),
+			commands.VRCommand(),
 			commands.WatermarkCommand(),
 		},
 	}
-
-	if err := app.Run(context.Background(), os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
 }
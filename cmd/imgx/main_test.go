@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/razzkumar/imgx/cmd/imgx/commands"
+	"github.com/urfave/cli/v3"
+)
+
+// flagUsage returns the Usage field of a urfave/cli flag
+func flagUsage(f cli.Flag) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	if usage := v.Elem().FieldByName("Usage"); usage.Kind() == reflect.String {
+		return usage.String()
+	}
+	return ""
+}
+
+// commandUsages collects the usage of cmd, its flags and its subcommands
+func commandUsages(cmd *cli.Command, usages map[string]string) {
+	if cmd.Usage != "" {
+		usages[cmd.Usage] = cmd.Name
+	}
+	for _, f := range cmd.Flags {
+		if u := flagUsage(f); u != "" {
+			usages[u] = cmd.Name + " --" + f.Names()[0]
+		}
+	}
+	for _, sub := range cmd.Commands {
+		commandUsages(sub, usages)
+	}
+}
+
+func TestCatalogsCoverCommands(t *testing.T) {
+	usages := map[string]string{}
+	commandUsages(newApp(), usages)
+
+	for _, lang := range commands.Languages[1:] {
+		c, err := commands.LoadCatalog(lang)
+		if err != nil {
+			t.Fatalf("LoadCatalog(%q): %v", lang, err)
+		}
+		for usage, where := range usages {
+			if _, ok := c[usage]; !ok {
+				t.Errorf("%s: no translation for %s usage %q", lang, where, usage)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package imgx
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// CoalesceStats counts the calls of a Coalescer.
+type CoalesceStats struct {
+	Calls     int64 // Calls to Do
+	Coalesced int64 // Calls that waited for another caller's result
+	InFlight  int64 // Keys being computed now
+}
+
+// Coalescer deduplicates concurrent identical work ("single flight"): while
+// a call for a key runs, further calls for the same key wait for it and
+// share its result instead of starting again. A server rendering the same
+// variant for many simultaneous requests, or a batch run with repeated
+// inputs, then decodes, transforms and calls detection providers once per
+// key. Results are not cached: a call arriving after the first finished
+// runs again.
+//
+// The zero value is ready to use. Results are shared between callers, so
+// they must not be modified; images returned by the imgx functions are new
+// values and are safe to share.
+//
+// Example:
+//
+//	var renders imgx.Coalescer[*imgx.Image]
+//
+//	img, err, shared := renders.Do(source+"|"+spec, func() (*imgx.Image, error) {
+//		return render(source, spec)
+//	})
+type Coalescer[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall[T]
+
+	total, coalesced atomic.Int64
+}
+
+// coalescedCall is a running call and its result
+type coalescedCall[T any] struct {
+	done chan struct{}
+	dups int // callers waiting for the result, guarded by Coalescer.mu
+	val  T
+	err  error
+}
+
+// Do runs fn once for all concurrent calls with the same key and returns
+// its result to each of them. shared reports whether the result went to
+// more than one caller. A panic in fn is returned as an error to every
+// caller.
+func (c *Coalescer[T]) Do(key string, fn func() (T, error)) (v T, err error, shared bool) {
+	c.total.Add(1)
+	c.mu.Lock()
+	if c.calls == nil {
+		c.calls = make(map[string]*coalescedCall[T])
+	}
+	if call, ok := c.calls[key]; ok {
+		call.dups++
+		c.mu.Unlock()
+		c.coalesced.Add(1)
+		<-call.done
+		return call.val, call.err, true
+	}
+	call := &coalescedCall[T]{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				call.err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		call.val, call.err = fn()
+	}()
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	shared = call.dups > 0
+	c.mu.Unlock()
+	close(call.done)
+	return call.val, call.err, shared
+}
+
+// Stats returns the call counters.
+func (c *Coalescer[T]) Stats() CoalesceStats {
+	c.mu.Lock()
+	inFlight := int64(len(c.calls))
+	c.mu.Unlock()
+	return CoalesceStats{
+		Calls:     c.total.Load(),
+		Coalesced: c.coalesced.Load(),
+		InFlight:  inFlight,
+	}
+}
@@ -0,0 +1,73 @@
+package imgx
+
+import (
+	"errors"
+	"image/color"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCoalescer(t *testing.T) {
+	var c Coalescer[*Image]
+	var runs atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	const callers = 8
+	var wg sync.WaitGroup
+	results := make([]*Image, callers)
+	shared := make([]bool, callers)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _, shared[0] = c.Do("cat.jpg|fit=100", func() (*Image, error) {
+			runs.Add(1)
+			close(started)
+			<-release
+			return NewImage(100, 100, color.White), nil
+		})
+	}()
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _, shared[i] = c.Do("cat.jpg|fit=100", func() (*Image, error) {
+				runs.Add(1)
+				return nil, errors.New("should not run")
+			})
+		}()
+	}
+	// Wait until every caller is waiting for the first one
+	for c.Stats().Coalesced < callers-1 {
+		runtime.Gosched()
+	}
+	if got := c.Stats().InFlight; got != 1 {
+		t.Errorf("InFlight = %d, want 1", got)
+	}
+	close(release)
+	wg.Wait()
+
+	if runs.Load() != 1 {
+		t.Errorf("work ran %d times, want 1", runs.Load())
+	}
+	for i := range results {
+		if results[i] == nil || results[i] != results[0] || !shared[i] {
+			t.Errorf("caller %d: result %p shared=%v, want %p shared", i, results[i], shared[i], results[0])
+		}
+	}
+	if s := c.Stats(); s.Calls != callers || s.Coalesced != callers-1 || s.InFlight != 0 {
+		t.Errorf("Stats() = %+v", s)
+	}
+
+	// Finished calls are not cached; errors and panics reach the caller
+	fail := errors.New("decode failed")
+	if _, err, shared := c.Do("cat.jpg|fit=100", func() (*Image, error) { return nil, fail }); err != fail || shared {
+		t.Errorf("Do() = %v shared=%v, want the new error, not shared", err, shared)
+	}
+	if _, err, _ := c.Do("bad", func() (*Image, error) { panic("boom") }); err == nil {
+		t.Error("panic was not returned as an error")
+	}
+}
@@ -0,0 +1,215 @@
+// Package colorspace converts colors and images between sRGB and the CIE
+// XYZ, CIE L*a*b*, HSL and HSV color spaces, and measures color differences
+// with Delta E (CIE76 and CIEDE2000).
+//
+// All color types implement color.Color, so they can be passed to any
+// image/color API and converted back with the standard models:
+//
+//	lab := colorspace.ToLab(color.NRGBA{R: 200, G: 30, B: 40, A: 255})
+//	lab.L += 10 // lighter
+//	c := color.NRGBAModel.Convert(lab).(color.NRGBA)
+//
+// Conversions use the sRGB primaries and the D65 white point.
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// D65 is the CIE XYZ of the D65 reference white, normalized to Y = 1.
+var D65 = XYZ{X: 0.95047, Y: 1, Z: 1.08883}
+
+// CIE L*a*b* constants
+const (
+	labEpsilon = 216.0 / 24389
+	labKappa   = 24389.0 / 27
+)
+
+// Models for the color types of this package.
+var (
+	RGBModel = color.ModelFunc(func(c color.Color) color.Color { return ToRGB(c) })
+	XYZModel = color.ModelFunc(func(c color.Color) color.Color { return ToXYZ(c) })
+	LabModel = color.ModelFunc(func(c color.Color) color.Color { return ToLab(c) })
+	HSLModel = color.ModelFunc(func(c color.Color) color.Color { return ToHSL(c) })
+	HSVModel = color.ModelFunc(func(c color.Color) color.Color { return ToHSV(c) })
+)
+
+// ToLinear converts a gamma-encoded sRGB component in [0, 1] to linear light.
+func ToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// ToSRGB converts a linear light component in [0, 1] to gamma-encoded sRGB.
+func ToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// RGB is a gamma-encoded sRGB color with components in [0, 1]. Components
+// outside that range (out-of-gamut results of conversions) are clamped when
+// the color is used as a color.Color.
+type RGB struct {
+	R, G, B float64
+}
+
+// ToRGB converts c to RGB, ignoring its alpha.
+func ToRGB(c color.Color) RGB {
+	switch c := c.(type) {
+	case RGB:
+		return c
+	case XYZ:
+		return c.RGB()
+	case Lab:
+		return c.RGB()
+	case HSL:
+		return c.RGB()
+	case HSV:
+		return c.RGB()
+	case color.NRGBA:
+		return RGB{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}
+	}
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	return RGB{float64(n.R) / 0xffff, float64(n.G) / 0xffff, float64(n.B) / 0xffff}
+}
+
+// RGBA implements color.Color. The color is opaque.
+func (c RGB) RGBA() (r, g, b, a uint32) {
+	return to16(c.R), to16(c.G), to16(c.B), 0xffff
+}
+
+// to16 converts a component in [0, 1] to a 16-bit value, clamping it.
+func to16(v float64) uint32 {
+	return uint32(math.Max(0, math.Min(1, v))*0xffff + 0.5)
+}
+
+// XYZ converts c to CIE XYZ.
+func (c RGB) XYZ() XYZ {
+	return linearToXYZ(ToLinear(c.R), ToLinear(c.G), ToLinear(c.B))
+}
+
+// linearToXYZ converts linear light sRGB components to XYZ.
+func linearToXYZ(r, g, b float64) XYZ {
+	return XYZ{
+		X: 0.4124564*r + 0.3575761*g + 0.1804375*b,
+		Y: 0.2126729*r + 0.7151522*g + 0.0721750*b,
+		Z: 0.0193339*r + 0.1191920*g + 0.9503041*b,
+	}
+}
+
+// Lab converts c to CIE L*a*b*.
+func (c RGB) Lab() Lab {
+	return c.XYZ().Lab()
+}
+
+// XYZ is a CIE 1931 XYZ color relative to D65, with Y = 1 for white.
+type XYZ struct {
+	X, Y, Z float64
+}
+
+// ToXYZ converts c to XYZ, ignoring its alpha.
+func ToXYZ(c color.Color) XYZ {
+	switch c := c.(type) {
+	case XYZ:
+		return c
+	case Lab:
+		return c.XYZ()
+	}
+	return ToRGB(c).XYZ()
+}
+
+// RGBA implements color.Color. The color is opaque.
+func (c XYZ) RGBA() (r, g, b, a uint32) {
+	return c.RGB().RGBA()
+}
+
+// RGB converts c to sRGB. Out-of-gamut colors have components outside
+// [0, 1].
+func (c XYZ) RGB() RGB {
+	r := 3.2404542*c.X - 1.5371385*c.Y - 0.4985314*c.Z
+	g := -0.9692660*c.X + 1.8760108*c.Y + 0.0415560*c.Z
+	b := 0.0556434*c.X - 0.2040259*c.Y + 1.0572252*c.Z
+	return RGB{linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)}
+}
+
+// linearToSRGB is ToSRGB extended to negative values, so out-of-gamut
+// colors survive a round trip.
+func linearToSRGB(v float64) float64 {
+	if v < 0 {
+		return -ToSRGB(-v)
+	}
+	return ToSRGB(v)
+}
+
+// Lab converts c to CIE L*a*b*.
+func (c XYZ) Lab() Lab {
+	f := func(t float64) float64 {
+		if t > labEpsilon {
+			return math.Cbrt(t)
+		}
+		return (labKappa*t + 16) / 116
+	}
+	fx, fy, fz := f(c.X/D65.X), f(c.Y/D65.Y), f(c.Z/D65.Z)
+	return Lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// Lab is a CIE L*a*b* color relative to D65. L ranges from 0 (black) to
+// 100 (white); A and B are roughly within [-128, 127].
+type Lab struct {
+	L, A, B float64
+}
+
+// ToLab converts c to Lab, ignoring its alpha.
+func ToLab(c color.Color) Lab {
+	if c, ok := c.(Lab); ok {
+		return c
+	}
+	return ToXYZ(c).Lab()
+}
+
+// RGBA implements color.Color. The color is opaque.
+func (c Lab) RGBA() (r, g, b, a uint32) {
+	return c.RGB().RGBA()
+}
+
+// RGB converts c to sRGB.
+func (c Lab) RGB() RGB {
+	return c.XYZ().RGB()
+}
+
+// XYZ converts c to CIE XYZ.
+func (c Lab) XYZ() XYZ {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+	finv := func(f float64) float64 {
+		if t := f * f * f; t > labEpsilon {
+			return t
+		}
+		return (116*f - 16) / labKappa
+	}
+	y := c.L / labKappa
+	if c.L > labKappa*labEpsilon {
+		y = fy * fy * fy
+	}
+	return XYZ{X: finv(fx) * D65.X, Y: y * D65.Y, Z: finv(fz) * D65.Z}
+}
+
+// Chroma returns the CIE LCh chroma of c.
+func (c Lab) Chroma() float64 {
+	return math.Hypot(c.A, c.B)
+}
+
+// Hue returns the CIE LCh hue angle of c in degrees, in [0, 360).
+func (c Lab) Hue() float64 {
+	h := math.Atan2(c.B, c.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
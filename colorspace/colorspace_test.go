@@ -0,0 +1,99 @@
+package colorspace
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestLab(t *testing.T) {
+	tests := []struct {
+		c    color.Color
+		want Lab
+	}{
+		{color.White, Lab{100, 0, 0}},
+		{color.Black, Lab{0, 0, 0}},
+		{color.NRGBA{255, 0, 0, 255}, Lab{53.24, 80.09, 67.20}},
+		{color.NRGBA{0, 0, 255, 255}, Lab{32.30, 79.19, -107.86}},
+		{color.NRGBA{128, 128, 128, 255}, Lab{53.59, 0, 0}},
+		{color.Gray16{0x8080}, Lab{53.59, 0, 0}},
+	}
+	for _, tt := range tests {
+		got := ToLab(tt.c)
+		if !near(got.L, tt.want.L, 0.01) || !near(got.A, tt.want.A, 0.01) || !near(got.B, tt.want.B, 0.01) {
+			t.Errorf("ToLab(%v) = %.2f, want %.2f", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	models := map[string]color.Model{
+		"rgb": RGBModel, "xyz": XYZModel, "lab": LabModel, "hsl": HSLModel, "hsv": HSVModel,
+	}
+	for name, model := range models {
+		for r := 0; r < 256; r += 15 {
+			for g := 0; g < 256; g += 15 {
+				for b := 0; b < 256; b += 15 {
+					c := color.NRGBA{uint8(r), uint8(g), uint8(b), 255}
+					if got := color.NRGBAModel.Convert(model.Convert(c)); got != c {
+						t.Fatalf("%s round trip of %v = %v", name, c, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestHSLAndHSV(t *testing.T) {
+	orange := color.NRGBA{255, 128, 0, 255}
+	if got := ToHSL(orange); !near(got.H, 30.1, 0.1) || !near(got.S, 1, 1e-9) || !near(got.L, 0.5, 1e-9) {
+		t.Errorf("ToHSL(orange) = %.3f", got)
+	}
+	if got := ToHSV(orange); !near(got.H, 30.1, 0.1) || !near(got.S, 1, 1e-9) || !near(got.V, 1, 1e-9) {
+		t.Errorf("ToHSV(orange) = %.3f", got)
+	}
+	// Hues wrap around
+	if got := color.NRGBAModel.Convert(HSL{H: -240, S: 1, L: 0.5}); got != (color.NRGBA{0, 255, 0, 255}) {
+		t.Errorf("HSL{-240, 1, 0.5} = %v, want green", got)
+	}
+	if got := color.NRGBAModel.Convert(HSV{H: 660, S: 1, V: 1}); got != (color.NRGBA{255, 0, 255, 255}) {
+		t.Errorf("HSV{660, 1, 1} = %v, want magenta", got)
+	}
+}
+
+func TestLabImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(2, 3, 6, 5))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 7)
+	}
+	lab := ToLabImage(src)
+	generic := ToLabImage(image.Image(&image.RGBA64{Pix: make([]uint8, 8*8), Stride: 32, Rect: image.Rect(0, 0, 4, 2)}))
+
+	if lab.Bounds() != src.Bounds() || generic.Bounds().Dx() != 4 {
+		t.Fatalf("bounds = %v, want %v", lab.Bounds(), src.Bounds())
+	}
+	for y := 3; y < 5; y++ {
+		for x := 2; x < 6; x++ {
+			want := ToLab(src.NRGBAAt(x, y))
+			if got := lab.LabAt(x, y); DeltaE76(got, want) > 1e-9 {
+				t.Errorf("LabAt(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	back := lab.ToNRGBA()
+	for y := 3; y < 5; y++ {
+		for x := 2; x < 6; x++ {
+			want := src.NRGBAAt(x, y)
+			want.A = 255
+			if got := back.NRGBAAt(x, y); got != want {
+				t.Errorf("ToNRGBA() at (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package colorspace
+
+import "math"
+
+// DeltaE76 returns the CIE76 color difference between a and b: their
+// Euclidean distance in L*a*b*. A difference of about 2.3 is just
+// noticeable.
+func DeltaE76(a, b Lab) float64 {
+	return math.Sqrt((a.L-b.L)*(a.L-b.L) + (a.A-b.A)*(a.A-b.A) + (a.B-b.B)*(a.B-b.B))
+}
+
+// DeltaE2000 returns the CIEDE2000 color difference between a and b, with
+// the parametric factors kL, kC and kH set to 1. It corrects CIE76 for the
+// eye's lower sensitivity to chroma and hue differences of saturated colors
+// and is the usual metric for print and brand color tolerances: below 1 is
+// imperceptible, 2-3 is noticeable on close inspection.
+func DeltaE2000(a, b Lab) float64 {
+	const deg = math.Pi / 180
+
+	c1, c2 := a.Chroma(), b.Chroma()
+	cMean := (c1 + c2) / 2
+	cMean7 := math.Pow(cMean, 7)
+	g := 0.5 * (1 - math.Sqrt(cMean7/(cMean7+math.Pow(25, 7))))
+
+	a1, a2 := a.A*(1+g), b.A*(1+g)
+	c1p, c2p := math.Hypot(a1, a.B), math.Hypot(a2, b.B)
+	h1p, h2p := primeHue(a.B, a1), primeHue(b.B, a2)
+
+	dL := b.L - a.L
+	dC := c2p - c1p
+	var dh float64
+	if c1p*c2p != 0 {
+		dh = h2p - h1p
+		switch {
+		case dh > 180:
+			dh -= 360
+		case dh < -180:
+			dh += 360
+		}
+	}
+	dH := 2 * math.Sqrt(c1p*c2p) * math.Sin(dh/2*deg)
+
+	lMean := (a.L + b.L) / 2
+	cMeanP := (c1p + c2p) / 2
+	hMean := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hMean /= 2
+		case hMean < 360:
+			hMean = (hMean + 360) / 2
+		default:
+			hMean = (hMean - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos((hMean-30)*deg) + 0.24*math.Cos(2*hMean*deg) +
+		0.32*math.Cos((3*hMean+6)*deg) - 0.20*math.Cos((4*hMean-63)*deg)
+	dTheta := 30 * math.Exp(-((hMean-275)/25)*((hMean-275)/25))
+	cMeanP7 := math.Pow(cMeanP, 7)
+	rC := 2 * math.Sqrt(cMeanP7/(cMeanP7+math.Pow(25, 7)))
+	l50 := (lMean - 50) * (lMean - 50)
+	sL := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sC := 1 + 0.045*cMeanP
+	sH := 1 + 0.015*cMeanP*t
+	rT := -math.Sin(2*dTheta*deg) * rC
+
+	l, c, h := dL/sL, dC/sC, dH/sH
+	return math.Sqrt(l*l + c*c + h*h + rT*c*h)
+}
+
+// primeHue returns the hue angle in degrees of the adjusted a' and b.
+func primeHue(b, aPrime float64) float64 {
+	if b == 0 && aPrime == 0 {
+		return 0
+	}
+	h := math.Atan2(b, aPrime) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
@@ -0,0 +1,41 @@
+package colorspace
+
+import "testing"
+
+func TestDeltaE2000(t *testing.T) {
+	// Test data from Sharma, Wu and Dalal, "The CIEDE2000 Color-Difference
+	// Formula: Implementation Notes, Supplementary Test Data, and
+	// Mathematical Observations" (2005)
+	tests := []struct {
+		a, b Lab
+		want float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 3.1571, -77.2803}, Lab{50, 0, -82.7485}, 2.8615},
+		{Lab{50, 2.8361, -74.0200}, Lab{50, 0, -82.7485}, 3.4412},
+		{Lab{50, -1.3802, -84.2814}, Lab{50, 0, -82.7485}, 1.0000},
+		{Lab{50, 0, 0}, Lab{50, -1, 2}, 2.3669},
+		{Lab{50, 2.49, -0.001}, Lab{50, -2.49, 0.0011}, 7.2195},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+		{Lab{22.7233, 20.0904, -46.6940}, Lab{23.0331, 14.9730, -42.5619}, 2.0373},
+		{Lab{2.0776, 0.0795, -1.1350}, Lab{0.9033, -0.0636, -0.5514}, 0.9082},
+	}
+	for _, tt := range tests {
+		if got := DeltaE2000(tt.a, tt.b); !near(got, tt.want, 0.0001) {
+			t.Errorf("DeltaE2000(%v, %v) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+		if got := DeltaE2000(tt.b, tt.a); !near(got, tt.want, 0.0001) {
+			t.Errorf("DeltaE2000(%v, %v) = %.4f, want %.4f (symmetric)", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestDeltaE76(t *testing.T) {
+	if got := DeltaE76(Lab{50, 0, 0}, Lab{53, 4, 0}); got != 5 {
+		t.Errorf("DeltaE76() = %v, want 5", got)
+	}
+	if got := DeltaE76(Lab{50, 10, -10}, Lab{50, 10, -10}); got != 0 {
+		t.Errorf("DeltaE76() of equal colors = %v, want 0", got)
+	}
+}
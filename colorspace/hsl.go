@@ -0,0 +1,157 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// HSL is a color as hue, saturation and lightness. H is in degrees in
+// [0, 360); S and L are in [0, 1].
+type HSL struct {
+	H, S, L float64
+}
+
+// ToHSL converts c to HSL, ignoring its alpha.
+func ToHSL(c color.Color) HSL {
+	if c, ok := c.(HSL); ok {
+		return c
+	}
+	return ToRGB(c).HSL()
+}
+
+// RGBA implements color.Color. The color is opaque.
+func (c HSL) RGBA() (r, g, b, a uint32) {
+	return c.RGB().RGBA()
+}
+
+// HSL converts c to HSL.
+func (c RGB) HSL() HSL {
+	hi := math.Max(c.R, math.Max(c.G, c.B))
+	lo := math.Min(c.R, math.Min(c.G, c.B))
+	l := (hi + lo) / 2
+	if hi == lo {
+		return HSL{L: l}
+	}
+
+	d := hi - lo
+	s := d / (hi + lo)
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	}
+	return HSL{H: hue(c, hi, d), S: s, L: l}
+}
+
+// hue returns the hue in degrees of c, whose largest component is hi and
+// whose range is d > 0.
+func hue(c RGB, hi, d float64) float64 {
+	var h float64
+	switch hi {
+	case c.R:
+		h = (c.G - c.B) / d
+		if c.G < c.B {
+			h += 6
+		}
+	case c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	return h * 60
+}
+
+// RGB converts c to sRGB. H is taken modulo 360.
+func (c HSL) RGB() RGB {
+	if c.S == 0 {
+		return RGB{c.L, c.L, c.L}
+	}
+	h := normalizeHue(c.H) / 360
+
+	q := c.L + c.S - c.L*c.S
+	if c.L < 0.5 {
+		q = c.L * (1 + c.S)
+	}
+	p := 2*c.L - q
+	return RGB{hueToRGB(p, q, h+1.0/3), hueToRGB(p, q, h), hueToRGB(p, q, h-1.0/3)}
+}
+
+func hueToRGB(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	}
+	return p
+}
+
+// normalizeHue maps h to [0, 360).
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// HSV is a color as hue, saturation and value. H is in degrees in [0, 360);
+// S and V are in [0, 1].
+type HSV struct {
+	H, S, V float64
+}
+
+// ToHSV converts c to HSV, ignoring its alpha.
+func ToHSV(c color.Color) HSV {
+	if c, ok := c.(HSV); ok {
+		return c
+	}
+	return ToRGB(c).HSV()
+}
+
+// RGBA implements color.Color. The color is opaque.
+func (c HSV) RGBA() (r, g, b, a uint32) {
+	return c.RGB().RGBA()
+}
+
+// HSV converts c to HSV.
+func (c RGB) HSV() HSV {
+	hi := math.Max(c.R, math.Max(c.G, c.B))
+	lo := math.Min(c.R, math.Min(c.G, c.B))
+	if hi == lo {
+		return HSV{V: hi}
+	}
+	d := hi - lo
+	return HSV{H: hue(c, hi, d), S: d / hi, V: hi}
+}
+
+// RGB converts c to sRGB. H is taken modulo 360.
+func (c HSV) RGB() RGB {
+	h := normalizeHue(c.H) / 60
+	chroma := c.V * c.S
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	m := c.V - chroma
+
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = chroma, x
+	case 1:
+		r, g = x, chroma
+	case 2:
+		g, b = chroma, x
+	case 3:
+		g, b = x, chroma
+	case 4:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	return RGB{r + m, g + m, b + m}
+}
@@ -0,0 +1,107 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestComposite(t *testing.T) {
+	black := New(4, 4, color.NRGBA{0, 0, 0, 255})
+	white := New(2, 2, color.NRGBA{255, 255, 255, 255})
+
+	tests := []struct {
+		name    string
+		opacity float64
+		opts    CompositeOptions
+		want    uint8
+	}{
+		{"opaque", 1, CompositeOptions{}, 255},
+		{"half linear", 0.5, CompositeOptions{}, 188},
+		{"half gamma", 0.5, CompositeOptions{GammaSpace: true}, 128},
+		{"transparent", 0, CompositeOptions{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Composite(black, white, image.Pt(1, 1), tt.opacity, tt.opts)
+			if c := got.NRGBAAt(1, 1); c.R != tt.want || c.A != 255 {
+				t.Errorf("composited pixel = %v, want R=%d", c, tt.want)
+			}
+			if c := got.NRGBAAt(0, 0); c.R != 0 {
+				t.Errorf("pixel outside the overlay = %v, want black", c)
+			}
+		})
+	}
+
+	// Per-pixel alpha of the overlay is kept
+	mark := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	mark.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 0})
+	mark.SetNRGBA(1, 0, color.NRGBA{255, 255, 255, 128})
+	got := Composite(black, mark, image.Pt(0, 0), 1, CompositeOptions{})
+	if c := got.NRGBAAt(0, 0); c.R != 0 {
+		t.Errorf("transparent overlay pixel = %v, want the background", c)
+	}
+	if c := got.NRGBAAt(1, 0); c.R != 188 {
+		t.Errorf("half transparent overlay pixel = %v, want R=188", c)
+	}
+
+	// On a transparent background, the overlay keeps its color and alpha
+	empty := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	got = Composite(empty, New(2, 2, color.NRGBA{200, 100, 50, 255}), image.Pt(0, 0), 0.5, CompositeOptions{Blend: BlendMultiply})
+	if c := got.NRGBAAt(0, 0); c != (color.NRGBA{200, 100, 50, 128}) {
+		t.Errorf("overlay on transparent background = %v", c)
+	}
+}
+
+func TestCompositeBlendModes(t *testing.T) {
+	bg := New(1, 1, color.NRGBA{100, 150, 200, 255})
+	tests := []struct {
+		mode    BlendMode
+		overlay color.NRGBA
+		want    color.NRGBA
+	}{
+		{BlendMultiply, color.NRGBA{255, 255, 255, 255}, color.NRGBA{100, 150, 200, 255}},
+		{BlendMultiply, color.NRGBA{0, 0, 0, 255}, color.NRGBA{0, 0, 0, 255}},
+		{BlendScreen, color.NRGBA{0, 0, 0, 255}, color.NRGBA{100, 150, 200, 255}},
+		{BlendScreen, color.NRGBA{255, 255, 255, 255}, color.NRGBA{255, 255, 255, 255}},
+		{BlendDarken, color.NRGBA{120, 120, 120, 255}, color.NRGBA{100, 120, 120, 255}},
+		{BlendLighten, color.NRGBA{120, 120, 120, 255}, color.NRGBA{120, 150, 200, 255}},
+		{BlendSoftLight, color.NRGBA{128, 128, 128, 255}, color.NRGBA{100, 150, 200, 255}},
+	}
+	for _, tt := range tests {
+		got := Composite(bg, New(1, 1, tt.overlay), image.Pt(0, 0), 1, CompositeOptions{Blend: tt.mode, GammaSpace: true})
+		if c := got.NRGBAAt(0, 0); !closeNRGBA(c, tt.want, 1) {
+			t.Errorf("%s of %v = %v, want %v", tt.mode, tt.overlay, c, tt.want)
+		}
+	}
+
+	if BlendSoftLight.String() != "soft-light" || BlendMode(99).String() != "BlendMode(99)" {
+		t.Errorf("BlendMode.String() = %q, %q", BlendSoftLight, BlendMode(99))
+	}
+
+	composited := NewImage(4, 4, color.White).Composite(NewImage(2, 2, color.Black), image.Pt(1, 1), 1, CompositeOptions{Blend: BlendMultiply})
+	ops := composited.GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Action != "composite" {
+		t.Errorf("operations = %+v, want one composite record", ops)
+	}
+}
+
+// closeNRGBA reports whether the channels of a and b differ by at most tol
+func closeNRGBA(a, b color.NRGBA, tol int) bool {
+	diff := func(x, y uint8) bool { return int(x)-int(y) <= tol && int(y)-int(x) <= tol }
+	return diff(a.R, b.R) && diff(a.G, b.G) && diff(a.B, b.B) && diff(a.A, b.A)
+}
+
+func TestParseBlendMode(t *testing.T) {
+	for name, want := range map[string]BlendMode{
+		"normal": BlendNormal, "Multiply": BlendMultiply, " screen ": BlendScreen,
+		"soft-light": BlendSoftLight, "softlight": BlendSoftLight, "soft_light": BlendSoftLight,
+	} {
+		if got, err := ParseBlendMode(name); err != nil || got != want {
+			t.Errorf("ParseBlendMode(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseBlendMode("dissolve"); err == nil {
+		t.Error("ParseBlendMode(dissolve) should fail")
+	}
+}
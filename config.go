@@ -1,6 +1,7 @@
 package imgx
 
 import (
+	"errors"
 	"os"
 	"sync"
 )
@@ -9,6 +10,7 @@ import (
 type Config struct {
 	AddMetadata   bool
 	DefaultAuthor string
+	Offline       bool
 	mu            sync.RWMutex
 }
 
@@ -27,8 +29,17 @@ func init() {
 	if env := os.Getenv("IMGX_DEFAULT_AUTHOR"); env != "" {
 		globalConfig.DefaultAuthor = env
 	}
+
+	// Check environment variable for offline mode
+	if env := os.Getenv("IMGX_OFFLINE"); env == "true" || env == "1" {
+		globalConfig.Offline = true
+	}
 }
 
+// ErrOffline is returned by operations that need the network while offline
+// mode is enabled
+var ErrOffline = errors.New("imgx: network access disabled (offline mode)")
+
 // SetAddMetadata configures whether to add metadata globally
 func SetAddMetadata(enabled bool) {
 	globalConfig.mu.Lock()
@@ -58,3 +69,20 @@ func GetDefaultAuthor() string {
 	defer globalConfig.mu.RUnlock()
 	return globalConfig.DefaultAuthor
 }
+
+// OfflineMode enables or disables offline mode globally. In offline mode,
+// operations that would use the network fail fast with ErrOffline while
+// local processing keeps working. It can also be enabled with IMGX_OFFLINE=1,
+// which the detection package honors as well (see detection.SetOffline).
+func OfflineMode(enabled bool) {
+	globalConfig.mu.Lock()
+	defer globalConfig.mu.Unlock()
+	globalConfig.Offline = enabled
+}
+
+// IsOffline returns whether offline mode is enabled
+func IsOffline() bool {
+	globalConfig.mu.RLock()
+	defer globalConfig.mu.RUnlock()
+	return globalConfig.Offline
+}
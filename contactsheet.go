@@ -0,0 +1,170 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ContactSheetItem is one cell of a contact sheet.
+type ContactSheetItem struct {
+	// Image is scaled down to fit the thumbnail size. A nil image leaves
+	// the thumbnail area blank, e.g. for a file that failed to load.
+	Image image.Image
+
+	// Caption lines are printed beneath the thumbnail, e.g. the file name,
+	// then detected labels. Lines wider than the thumbnail are shortened
+	// with "...".
+	Caption []string
+
+	// CaptionColors optionally sets the color of each caption line, e.g.
+	// red for a flagged safe search verdict. Lines without one use
+	// ContactSheetOptions.TextColor.
+	CaptionColors []color.Color
+}
+
+// ContactSheetOptions configures ContactSheet.
+type ContactSheetOptions struct {
+	// Columns is the number of thumbnails per row.
+	// Default is 5.
+	Columns int
+
+	// ThumbSize is the size of the square the thumbnails are fitted in.
+	// Default is 200.
+	ThumbSize int
+
+	// Spacing is the gap between cells and around the sheet in pixels.
+	// Default is 10.
+	Spacing int
+
+	// MaxCaptionLines limits the caption lines of each cell; all cells of
+	// the sheet reserve the height of the longest caption.
+	// Default is 4.
+	MaxCaptionLines int
+
+	// Background is the color of the sheet.
+	// Default is white.
+	Background color.Color
+
+	// TextColor is the default color of the captions.
+	// Default is black.
+	TextColor color.Color
+
+	// Font is the font face of the captions.
+	// If nil, basicfont.Face7x13 is used.
+	Font font.Face
+}
+
+// ContactSheet lays out thumbnails of the items in a grid with their
+// captions beneath, so that a large set of images can be reviewed at a
+// glance.
+//
+// Example:
+//
+//	sheet := imgx.ContactSheet([]imgx.ContactSheetItem{
+//		{Image: img, Caption: []string{"beach.jpg", "sea 97%, sand 91%", "safe"}},
+//	}, imgx.ContactSheetOptions{Columns: 6})
+func ContactSheet(items []ContactSheetItem, opts ContactSheetOptions) *image.NRGBA {
+	columns := opts.Columns
+	if columns <= 0 {
+		columns = 5
+	}
+	columns = max(1, min(columns, len(items)))
+	thumbSize := opts.ThumbSize
+	if thumbSize <= 0 {
+		thumbSize = 200
+	}
+	spacing := opts.Spacing
+	if spacing <= 0 {
+		spacing = 10
+	}
+	maxLines := opts.MaxCaptionLines
+	if maxLines <= 0 {
+		maxLines = 4
+	}
+	bg := opts.Background
+	if bg == nil {
+		bg = color.White
+	}
+	textColor := opts.TextColor
+	if textColor == nil {
+		textColor = color.Black
+	}
+	face := opts.Font
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+	metrics := face.Metrics()
+	ascent, lineHeight := metrics.Ascent.Ceil(), metrics.Height.Ceil()
+
+	lines := 0
+	for _, item := range items {
+		lines = max(lines, min(len(item.Caption), maxLines))
+	}
+	captionHeight := 0
+	if lines > 0 {
+		captionHeight = spacing/2 + lines*lineHeight
+	}
+	cellHeight := thumbSize + captionHeight
+	rows := (len(items) + columns - 1) / columns
+
+	width := spacing + columns*(thumbSize+spacing)
+	height := spacing + rows*(cellHeight+spacing)
+	if len(items) == 0 {
+		width, height = spacing*2, spacing*2
+	}
+	dst := New(width, height, bg)
+
+	for i, item := range items {
+		x := spacing + (i%columns)*(thumbSize+spacing)
+		y := spacing + (i/columns)*(cellHeight+spacing)
+
+		if item.Image != nil && !item.Image.Bounds().Empty() {
+			thumb := item.Image
+			b := thumb.Bounds()
+			if b.Dx() > thumbSize || b.Dy() > thumbSize {
+				thumb = Fit(thumb, thumbSize, thumbSize, Lanczos)
+				b = thumb.Bounds()
+			}
+			pos := image.Pt(x+(thumbSize-b.Dx())/2, y+(thumbSize-b.Dy())/2)
+			draw.Draw(dst, image.Rectangle{pos, pos.Add(b.Size())}, thumb, b.Min, draw.Over)
+		}
+
+		for j, line := range item.Caption {
+			if j == maxLines {
+				break
+			}
+			c := textColor
+			if j < len(item.CaptionColors) && item.CaptionColors[j] != nil {
+				c = item.CaptionColors[j]
+			}
+			drawer := &font.Drawer{
+				Dst:  dst,
+				Src:  image.NewUniform(c),
+				Face: face,
+				Dot:  fixed.P(x, y+thumbSize+spacing/2+j*lineHeight+ascent),
+			}
+			drawer.DrawString(fitCaption(line, face, thumbSize))
+		}
+	}
+	return dst
+}
+
+// fitCaption shortens line with "..." to at most width pixels in face
+func fitCaption(line string, face font.Face, width int) string {
+	if font.MeasureString(face, line).Ceil() <= width {
+		return line
+	}
+	runes := []rune(line)
+	for n := len(runes) - 1; n > 0; n-- {
+		short := string(runes[:n]) + "..."
+		if font.MeasureString(face, short).Ceil() <= width {
+			return short
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package imgx
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestContactSheet(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	sheet := ContactSheet([]ContactSheetItem{
+		{Image: New(200, 100, red), Caption: []string{"a.jpg", "flagged: Adult"}, CaptionColors: []color.Color{nil, blue}},
+		{Image: New(20, 20, red), Caption: []string{"b.jpg"}},
+		{Caption: []string{"missing.jpg"}},
+	}, ContactSheetOptions{Columns: 2, ThumbSize: 50, Spacing: 10})
+
+	// 2 columns of 50 pixels; rows of 50 pixels plus two caption lines
+	lineHeight := basicfont.Face7x13.Metrics().Height.Ceil()
+	wantHeight := 10 + 2*(50+5+2*lineHeight+10)
+	if b := sheet.Bounds(); b.Dx() != 130 || b.Dy() != wantHeight {
+		t.Fatalf("sheet size = %v, want 130x%d", b.Size(), wantHeight)
+	}
+
+	// The wide image is fitted to 50x25 and centered in its cell
+	if c := sheet.NRGBAAt(35, 35); c != red {
+		t.Errorf("thumbnail pixel = %v, want red", c)
+	}
+	if c := sheet.NRGBAAt(35, 15); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel above the fitted thumbnail = %v, want white", c)
+	}
+	// Small images are not enlarged
+	if c := sheet.NRGBAAt(70+15, 15); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel beside the small thumbnail = %v, want white", c)
+	}
+	if c := sheet.NRGBAAt(70+25, 35); c != red {
+		t.Errorf("small thumbnail pixel = %v, want red", c)
+	}
+
+	// Caption lines are drawn beneath the thumbnails, in their colors
+	var black, blues int
+	for y := 65; y < 65+2*lineHeight; y++ {
+		for x := 10; x < 60; x++ {
+			switch sheet.NRGBAAt(x, y) {
+			case color.NRGBA{0, 0, 0, 255}:
+				black++
+			case blue:
+				blues++
+			}
+		}
+	}
+	if black == 0 || blues == 0 {
+		t.Errorf("caption pixels: %d black, %d blue, want both", black, blues)
+	}
+}
+
+func TestContactSheetEmpty(t *testing.T) {
+	sheet := ContactSheet(nil, ContactSheetOptions{})
+	if sheet.Bounds().Empty() {
+		t.Error("ContactSheet(nil) returned an empty image")
+	}
+}
+
+func TestFitCaption(t *testing.T) {
+	face := basicfont.Face7x13
+	if got := fitCaption("short", face, 100); got != "short" {
+		t.Errorf("fitCaption(short) = %q, want unchanged", got)
+	}
+	got := fitCaption("person 98%, bicycle 91%, helmet 80%", face, 100)
+	if !strings.HasSuffix(got, "...") || font.MeasureString(face, got).Ceil() > 100 {
+		t.Errorf("fitCaption(long) = %q, want shortened with ... to 100 pixels", got)
+	}
+}
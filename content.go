@@ -0,0 +1,176 @@
+package imgx
+
+import (
+	"image"
+	"image/png"
+)
+
+// ContentClass is the kind of content of an image, as told by ClassifyContent
+type ContentClass string
+
+const (
+	ContentPhoto        ContentClass = "photo"         // Camera images: noise, gradients, many colors
+	ContentIllustration ContentClass = "illustration"  // Drawings, logos and charts: flat areas and smooth edges
+	ContentScreenshot   ContentClass = "screenshot"    // UI captures: long runs of identical pixels, few colors
+	ContentDocument     ContentClass = "text-document" // Pages of text: dark ink on a light, colorless background
+)
+
+// Content classification thresholds
+const (
+	// contentLightLuma and contentDarkLuma bound the paper and ink of
+	// documents on the 0-255 luma scale
+	contentLightLuma = 200
+	contentDarkLuma  = 110
+	// contentGrayChroma is the largest channel spread (max - min) of a
+	// colorless pixel
+	contentGrayChroma = 24
+	// contentDocumentLight is the least fraction of light pixels of a
+	// document, and contentDocumentMidtones the largest fraction of pixels
+	// between ink and paper
+	contentDocumentLight    = 0.55
+	contentDocumentMidtones = 0.2
+	// contentDocumentGray is the least fraction of colorless pixels of a
+	// document
+	contentDocumentGray = 0.9
+	// contentIllustrationFlat is the least fraction of flat pixels (see
+	// ScreenshotProfile) of an illustration, and contentIllustrationColors
+	// the most distinct colors per pixel
+	contentIllustrationFlat   = 0.2
+	contentIllustrationColors = 0.02
+	// contentEdgeLuma is the least luma step between neighboring pixels
+	// that counts as a sharp edge, and contentScreenshotEdges the least
+	// fraction of such edges of a screenshot: its text, which flat
+	// illustrations lack
+	contentEdgeLuma        = 64
+	contentScreenshotEdges = 0.01
+)
+
+// ContentAnalysis is the result of ClassifyContent: the class and the
+// statistics it was told from.
+type ContentAnalysis struct {
+	Class ContentClass `json:"class"`
+
+	// Screenshot holds the flat-pixel and color counts (see
+	// ProfileScreenshot)
+	Screenshot ScreenshotProfile `json:"screenshot"`
+
+	// LightPercent, DarkPercent and MidtonePercent are the percentages of
+	// pixels with a luma above 200, below 110 and in between
+	LightPercent   float64 `json:"light_percent"`
+	DarkPercent    float64 `json:"dark_percent"`
+	MidtonePercent float64 `json:"midtone_percent"`
+
+	// GrayPercent is the percentage of colorless pixels, whose channels
+	// differ by at most 24
+	GrayPercent float64 `json:"gray_percent"`
+
+	// EdgePercent is the percentage of pixels whose luma differs from their
+	// right neighbor by at least 64, as along text and UI borders
+	EdgePercent float64 `json:"edge_percent"`
+}
+
+// ClassifyContent tells photos, illustrations, screenshots and text
+// documents apart from local statistics, without any model: documents are
+// mostly light and colorless with dark ink and few midtones, screenshots
+// have long runs of identical pixels, few colors (see ProfileScreenshot)
+// and the sharp edges of text, illustrations have some flat areas and few
+// colors per pixel, and everything else is a photo. It is a heuristic meant to pick
+// encoder settings; see SaveOptionsFor.
+//
+// Example:
+//
+//	a := imgx.ClassifyContent(img)
+//	if a.Class == imgx.ContentDocument {
+//		img = img.Grayscale()
+//	}
+func ClassifyContent(img image.Image) ContentAnalysis {
+	src := toNRGBA(img)
+	analysis := ContentAnalysis{Class: ContentPhoto, Screenshot: ProfileScreenshot(src)}
+	b := src.Bounds()
+	if b.Empty() {
+		return analysis
+	}
+
+	var light, dark, gray, edges int
+	for y := 0; y < b.Dy(); y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+b.Dx()*4]
+		prev := -1
+		for i := 0; i < len(row); i += 4 {
+			r, g, bl := int(row[i]), int(row[i+1]), int(row[i+2])
+			luma := (299*r + 587*g + 114*bl) / 1000
+			if prev >= 0 && (luma-prev >= contentEdgeLuma || prev-luma >= contentEdgeLuma) {
+				edges++
+			}
+			prev = luma
+			switch {
+			case luma > contentLightLuma:
+				light++
+			case luma < contentDarkLuma:
+				dark++
+			}
+			if max(r, g, bl)-min(r, g, bl) <= contentGrayChroma {
+				gray++
+			}
+		}
+	}
+	n := float64(b.Dx() * b.Dy())
+	analysis.LightPercent = float64(light) / n * 100
+	analysis.DarkPercent = float64(dark) / n * 100
+	analysis.MidtonePercent = 100 - analysis.LightPercent - analysis.DarkPercent
+	analysis.GrayPercent = float64(gray) / n * 100
+	analysis.EdgePercent = float64(edges) / n * 100
+
+	profile := analysis.Screenshot
+	switch {
+	case analysis.LightPercent >= contentDocumentLight*100 && analysis.DarkPercent > 0.5 &&
+		analysis.MidtonePercent <= contentDocumentMidtones*100 && analysis.GrayPercent >= contentDocumentGray*100:
+		analysis.Class = ContentDocument
+	case profile.Screenshot && analysis.EdgePercent >= contentScreenshotEdges*100:
+		analysis.Class = ContentScreenshot
+	case profile.FlatPercent >= contentIllustrationFlat*100 && float64(profile.Colors) <= n*contentIllustrationColors:
+		analysis.Class = ContentIllustration
+	}
+	return analysis
+}
+
+// ClassifyContent tells the kind of content of the image (see ClassifyContent)
+func (img *Image) ClassifyContent() ContentAnalysis {
+	return ClassifyContent(img.data)
+}
+
+// SaveOptionsFor returns the encoder settings that suit a class of content
+// in format: photos get a lower lossy quality, which hides in their noise;
+// illustrations a higher one, as artifacts show around flat areas;
+// screenshots and documents keep sharp text with lossless WebP or a higher
+// JPEG quality. PNG gets the best compression except for photos. Formats
+// without such settings get none. Options given after these override them.
+//
+// Example:
+//
+//	opts := imgx.SaveOptionsFor(imgx.ClassifyContent(img).Class, imgx.WEBP)
+//	err := img.Save("out.webp", opts...)
+func SaveOptionsFor(class ContentClass, format Format) []SaveOption {
+	switch format {
+	case JPEG:
+		quality := map[ContentClass]int{ContentPhoto: 82, ContentIllustration: 90, ContentScreenshot: 92, ContentDocument: 88}[class]
+		if quality == 0 {
+			return nil
+		}
+		return []SaveOption{WithJPEGQuality(quality)}
+	case WEBP:
+		switch class {
+		case ContentPhoto:
+			return []SaveOption{WithWebPQuality(78)}
+		case ContentIllustration:
+			return []SaveOption{WithWebPQuality(90)}
+		case ContentScreenshot, ContentDocument:
+			return []SaveOption{WithWebPLossless()}
+		}
+	case PNG:
+		switch class {
+		case ContentIllustration, ContentScreenshot, ContentDocument:
+			return []SaveOption{WithPNGCompression(png.BestCompression)}
+		}
+	}
+	return nil
+}
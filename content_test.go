@@ -0,0 +1,112 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// contentImages returns synthetic images of each content class
+func contentImages() map[ContentClass]*image.NRGBA {
+	const w, h = 200, 150
+	photo := image.NewNRGBA(image.Rect(0, 0, w, h))
+	illustration := image.NewNRGBA(image.Rect(0, 0, w, h))
+	screenshot := image.NewNRGBA(image.Rect(0, 0, w, h))
+	document := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	noise := func(n int) int {
+		seed = seed*1664525 + 1013904223
+		return int(seed>>16)%(2*n+1) - n
+	}
+	clamp := func(v int) uint8 { return uint8(max(0, min(255, v))) }
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Photo: a sky gradient over darker ground, with sensor noise
+			base := 200 - y
+			photo.SetNRGBA(x, y, color.NRGBA{clamp(base/2 + noise(12)), clamp(base*3/4 + noise(12)), clamp(base + noise(12)), 255})
+
+			// Illustration: a flat background, a shaded band and an
+			// anti-aliased disc
+			c := color.NRGBA{250, 240, 210, 255}
+			if y >= 40 && y < 80 {
+				c = color.NRGBA{uint8(x), 140, 180, 255}
+			}
+			if d := math.Hypot(float64(x-150), float64(y-110)); d < 31 {
+				t := min(1, 31-d)
+				c.R = uint8(float64(c.R)*(1-t) + 220*t)
+				c.G = uint8(float64(c.G)*(1-t) + 60*t)
+				c.B = uint8(float64(c.B)*(1-t) + 40*t)
+			}
+			illustration.SetNRGBA(x, y, c)
+
+			// Screenshot: a title bar, a sidebar and a window with lines of colored text
+			c = color.NRGBA{255, 255, 255, 255}
+			switch {
+			case y < 20:
+				c = color.NRGBA{40, 44, 52, 255}
+			case x < 50:
+				c = color.NRGBA{230, 235, 245, 255}
+			case y%12 < 6 && x%7 < 4 && x < 180:
+				c = color.NRGBA{uint8(20 * (y / 12)), 90, 200, 255}
+			}
+			screenshot.SetNRGBA(x, y, c)
+
+			// Document: lines of dark strokes on scanned paper
+			v := 245 + noise(6)
+			if y%14 < 5 && y > 10 && x > 15 && x < 185 && (x/3)%3 != 0 {
+				v = 30 + noise(10)
+			}
+			document.SetNRGBA(x, y, color.NRGBA{clamp(v), clamp(v), clamp(v - 4), 255})
+		}
+	}
+	return map[ContentClass]*image.NRGBA{
+		ContentPhoto:        photo,
+		ContentIllustration: illustration,
+		ContentScreenshot:   screenshot,
+		ContentDocument:     document,
+	}
+}
+
+func TestClassifyContent(t *testing.T) {
+	for want, img := range contentImages() {
+		got := ClassifyContent(img)
+		if got.Class != want {
+			t.Errorf("ClassifyContent(%s) = %+v", want, got)
+		}
+		if FromImage(img).ClassifyContent().Class != want {
+			t.Errorf("Image.ClassifyContent(%s) differs", want)
+		}
+	}
+	if got := ClassifyContent(image.NewNRGBA(image.Rectangle{})); got.Class != ContentPhoto {
+		t.Errorf("ClassifyContent(empty) = %v, want photo", got.Class)
+	}
+}
+
+func TestSaveOptionsFor(t *testing.T) {
+	config := func(opts []SaveOption) SaveConfig {
+		var c SaveConfig
+		for _, opt := range opts {
+			opt(&c)
+		}
+		return c
+	}
+	if c := config(SaveOptionsFor(ContentPhoto, JPEG)); c.JPEGQuality != 82 {
+		t.Errorf("photo JPEG quality = %d, want 82", c.JPEGQuality)
+	}
+	if c := config(SaveOptionsFor(ContentScreenshot, JPEG)); c.JPEGQuality <= 82 {
+		t.Errorf("screenshot JPEG quality = %d, want above that of photos", c.JPEGQuality)
+	}
+	if c := config(SaveOptionsFor(ContentDocument, WEBP)); !c.WebPLossless {
+		t.Error("documents should be lossless WebP")
+	}
+	if c := config(SaveOptionsFor(ContentPhoto, WEBP)); c.WebPLossless || c.WebPQuality == 0 {
+		t.Errorf("photo WebP config = %+v, want lossy", c)
+	}
+	if opts := SaveOptionsFor(ContentPhoto, PNG); opts != nil {
+		t.Errorf("photo PNG options = %d, want none", len(opts))
+	}
+	if opts := SaveOptionsFor(ContentIllustration, TIFF); opts != nil {
+		t.Errorf("TIFF options = %d, want none", len(opts))
+	}
+}
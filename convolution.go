@@ -145,7 +145,7 @@ func (img *Image) Convolve3x3(kernel [9]float64, options *ConvolveOptions) *Imag
 		opts = fmt.Sprintf("normalize=%v, abs=%v, bias=%d", options.Normalize, options.Abs, options.Bias)
 	}
 	newMeta.AddOperation("convolve3x3", opts)
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Convolve5x5 applies a 5x5 convolution kernel to the image
@@ -157,5 +157,5 @@ func (img *Image) Convolve5x5(kernel [25]float64, options *ConvolveOptions) *Ima
 		opts = fmt.Sprintf("normalize=%v, abs=%v, bias=%d", options.Normalize, options.Abs, options.Bias)
 	}
 	newMeta.AddOperation("convolve5x5", opts)
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
@@ -0,0 +1,107 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// DeflickerOptions configures DeflickerTargets.
+type DeflickerOptions struct {
+	// Window is the number of consecutive frames whose mean luminance is
+	// averaged into the target of the middle one. Exposure changes faster
+	// than the window (flicker) are removed, slower ones (a sunset) are
+	// kept. Default is 7.
+	Window int
+}
+
+// MeanLuminance returns the mean luminance of img, from 0 (black) to 255
+// (white).
+func MeanLuminance(img image.Image) float64 {
+	h := Histogram(img)
+	var mean float64
+	for i, p := range h {
+		mean += float64(i) * p
+	}
+	return mean
+}
+
+// DeflickerTargets returns the mean luminance each frame of a time-lapse
+// should have to remove flicker: the moving average of the mean luminances
+// of the frames (see MeanLuminance) over a centered window, shortened at
+// the ends of the sequence. Pass each frame and its target to
+// MatchLuminance.
+//
+// Example:
+//
+//	lums := make([]float64, len(frames))
+//	for i, f := range frames {
+//		lums[i] = imgx.MeanLuminance(f)
+//	}
+//	targets := imgx.DeflickerTargets(lums, imgx.DeflickerOptions{Window: 9})
+//	for i, f := range frames {
+//		frames[i] = imgx.MatchLuminance(f, targets[i])
+//	}
+func DeflickerTargets(lums []float64, opts DeflickerOptions) []float64 {
+	window := opts.Window
+	if window <= 0 {
+		window = 7
+	}
+	half := window / 2
+	targets := make([]float64, len(lums))
+	for i := range lums {
+		lo, hi := max(0, i-half), min(len(lums), i+half+1)
+		var sum float64
+		for _, l := range lums[lo:hi] {
+			sum += l
+		}
+		targets[i] = sum / float64(hi-lo)
+	}
+	return targets
+}
+
+// MatchLuminance adjusts the gamma of img so that its mean luminance is
+// target (0-255) and returns the adjusted image. Gamma keeps black and
+// white points, so highlights are not clipped as with a plain gain.
+// Images that are uniformly black or white are returned unchanged.
+func MatchLuminance(img image.Image, target float64) *image.NRGBA {
+	return AdjustGamma(img, matchGamma(Histogram(img), target))
+}
+
+// matchGamma returns the gamma for AdjustGamma mapping the mean of the
+// luminance histogram h to target, found by bisection of its logarithm
+func matchGamma(h [256]float64, target float64) float64 {
+	target = math.Max(0, math.Min(255, target))
+	mean := func(gamma float64) float64 {
+		e := 1 / gamma
+		var m float64
+		for i, p := range h {
+			if p > 0 {
+				m += p * math.Pow(float64(i)/255, e) * 255
+			}
+		}
+		return m
+	}
+	lo, hi := math.Log(1.0/16), math.Log(16.0)
+	if mean(math.Exp(hi))-mean(math.Exp(lo)) < 0.5 {
+		return 1 // No midtones to adjust
+	}
+	for range 40 {
+		mid := (lo + hi) / 2
+		if mean(math.Exp(mid)) < target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// MatchLuminance adjusts the gamma of the image to a mean luminance of
+// target (see MatchLuminance)
+func (img *Image) MatchLuminance(target float64) *Image {
+	newData := MatchLuminance(img.data, target)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("matchLuminance", fmt.Sprintf("target=%.1f", target))
+	return newImage(newData, newMeta)
+}
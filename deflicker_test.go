@@ -0,0 +1,79 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// gradientImage returns a horizontal gray ramp from black to white
+func gradientImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / (w - 1))
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestMeanLuminance(t *testing.T) {
+	if got := MeanLuminance(New(10, 10, color.NRGBA{100, 100, 100, 255})); math.Abs(got-100) > 0.5 {
+		t.Errorf("MeanLuminance(gray 100) = %.2f, want 100", got)
+	}
+	if got := MeanLuminance(gradientImage(256, 4)); math.Abs(got-127.5) > 0.5 {
+		t.Errorf("MeanLuminance(ramp) = %.2f, want 127.5", got)
+	}
+}
+
+func TestDeflickerTargets(t *testing.T) {
+	lums := []float64{100, 130, 100, 130, 100, 130}
+	targets := DeflickerTargets(lums, DeflickerOptions{Window: 3})
+	want := []float64{115, 110, 120, 110, 120, 115}
+	for i := range want {
+		if math.Abs(targets[i]-want[i]) > 1e-9 {
+			t.Errorf("DeflickerTargets() = %v, want %v", targets, want)
+			break
+		}
+	}
+
+	// A steady trend is kept
+	ramp := []float64{50, 60, 70, 80, 90, 100, 110, 120, 130}
+	targets = DeflickerTargets(ramp, DeflickerOptions{})
+	if targets[4] != 90 {
+		t.Errorf("target of the middle of a ramp = %v, want 90", targets[4])
+	}
+}
+
+func TestMatchLuminance(t *testing.T) {
+	img := gradientImage(256, 8)
+	for _, target := range []float64{80, 127.5, 170} {
+		out := MatchLuminance(img, target)
+		if got := MeanLuminance(out); math.Abs(got-target) > 1.5 {
+			t.Errorf("MatchLuminance(%v) mean = %.2f", target, got)
+		}
+		// Black and white points are kept
+		if c := out.NRGBAAt(0, 0); c.R != 0 {
+			t.Errorf("MatchLuminance(%v) black = %v, want 0", target, c)
+		}
+		if c := out.NRGBAAt(255, 0); c.R != 255 {
+			t.Errorf("MatchLuminance(%v) white = %v, want 255", target, c)
+		}
+	}
+
+	black := New(8, 8, color.Black)
+	if c := MatchLuminance(black, 128).NRGBAAt(4, 4); c.R != 0 {
+		t.Errorf("MatchLuminance(black) = %v, want unchanged", c)
+	}
+
+	matched := NewImage(64, 4, color.NRGBA{60, 60, 60, 255}).MatchLuminance(120)
+	ops := matched.GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Action != "matchLuminance" {
+		t.Errorf("operations = %+v, want one matchLuminance record", ops)
+	}
+	if got := MeanLuminance(matched.ToNRGBA()); math.Abs(got-120) > 1.5 {
+		t.Errorf("Image.MatchLuminance(120) mean = %.2f", got)
+	}
+}
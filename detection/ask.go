@@ -0,0 +1,282 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnswerType is the expected type of the answer to a question
+type AnswerType string
+
+const (
+	// AnswerAuto infers the type from the question: yes/no questions are
+	// boolean, "how many" questions are numbers, questions with choices are
+	// enums and everything else is a string
+	AnswerAuto AnswerType = ""
+
+	// AnswerBoolean is a yes/no answer
+	AnswerBoolean AnswerType = "boolean"
+
+	// AnswerNumber is a numeric answer (counts, measurements, percentages)
+	AnswerNumber AnswerType = "number"
+
+	// AnswerString is a short free-text answer
+	AnswerString AnswerType = "string"
+
+	// AnswerEnum is one of AskOptions.Choices
+	AnswerEnum AnswerType = "enum"
+)
+
+// AskOptions configures a question
+type AskOptions struct {
+	// Type is the expected answer type (default: inferred from the question)
+	Type AnswerType `json:"type,omitempty"`
+
+	// Choices lists the allowed answers for AnswerEnum
+	Choices []string `json:"choices,omitempty"`
+}
+
+// Answer is the typed answer to a question about an image
+type Answer struct {
+	Question    string     `json:"question"`
+	Type        AnswerType `json:"type"`
+	Value       any        `json:"answer"`                // bool, float64 or string depending on Type; nil if unknown
+	Confidence  float32    `json:"confidence"`            // 0.0-1.0, 0 if the provider didn't say
+	Explanation string     `json:"explanation,omitempty"` // Short justification from the model
+	Provider    string     `json:"provider"`
+	Warnings    []string   `json:"warnings,omitempty"` // Non-fatal issues (e.g. fallback parsing used)
+	ProcessedAt time.Time  `json:"processed_at"`
+}
+
+// Bool returns the answer of a boolean question, false if unknown
+func (a *Answer) Bool() bool {
+	v, _ := a.Value.(bool)
+	return v
+}
+
+// Number returns the answer of a numeric question, 0 if unknown
+func (a *Answer) Number() float64 {
+	v, _ := a.Value.(float64)
+	return v
+}
+
+// String returns the answer formatted as text
+func (a *Answer) String() string {
+	switch v := a.Value.(type) {
+	case nil:
+		return "unknown"
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Ask asks a question about an image and parses the answer into a typed
+// value with a confidence score, instead of free text in Description.
+// Only LLM providers (Ollama, Gemini, OpenAI) can answer questions.
+//
+// Example:
+//
+//	answer, err := detection.Ask(ctx, img.ToNRGBA(), "gemini", "Is there a person wearing a helmet?")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if answer.Bool() && answer.Confidence > 0.8 {
+//		fmt.Println("helmet found")
+//	}
+func Ask(ctx context.Context, img *image.NRGBA, provider, question string, opts ...*AskOptions) (*Answer, error) {
+	prov, err := GetProvider(ResolveProviderAlias(provider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get detection provider: %w", err)
+	}
+	var opt *AskOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return AskProvider(ctx, prov, img, question, opt)
+}
+
+// AskProvider is like Ask with an existing provider instance. opts may be nil.
+func AskProvider(ctx context.Context, provider Provider, img *image.NRGBA, question string, opts *AskOptions) (*Answer, error) {
+	if opts == nil {
+		opts = &AskOptions{}
+	}
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question is empty")
+	}
+	if name := provider.Name(); name == "aws" || name == "vision" || name == "yolo" {
+		return nil, NewDetectionError(name, "questions require an LLM provider (ollama, gemini, openai)", ErrInvalidFeature)
+	}
+
+	answerType := opts.Type
+	if answerType == AnswerAuto {
+		answerType = inferAnswerType(question, opts.Choices)
+	}
+	if answerType == AnswerEnum && len(opts.Choices) == 0 {
+		return nil, fmt.Errorf("enum answers require choices")
+	}
+
+	result, err := provider.Detect(ctx, img, &DetectOptions{
+		Features:           []Feature{FeatureDescription},
+		CustomPrompt:       buildAskPrompt(question, answerType, opts.Choices),
+		IncludeRawResponse: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("question failed: %w", err)
+	}
+
+	text := result.RawResponse
+	if text == "" {
+		text = result.Description
+	}
+	answer := parseAnswer(text, answerType, opts.Choices)
+	answer.Question = question
+	answer.Provider = result.Provider
+	answer.ProcessedAt = result.ProcessedAt
+	return answer, nil
+}
+
+var (
+	yesNoQuestion = regexp.MustCompile(`^(is|are|am|does|do|did|can|could|was|were|has|have|had|will|would|should|shall|may|might|must)\b`)
+	countQuestion = regexp.MustCompile(`^(how (many|much|old|far|tall|long|wide|high)|what (percentage|percent|fraction|number))\b`)
+	numberPattern = regexp.MustCompile(`-?\d+(\.\d+)?`)
+)
+
+// inferAnswerType guesses the answer type from the wording of a question
+func inferAnswerType(question string, choices []string) AnswerType {
+	if len(choices) > 0 {
+		return AnswerEnum
+	}
+	q := strings.ToLower(strings.TrimSpace(question))
+	switch {
+	case yesNoQuestion.MatchString(q):
+		return AnswerBoolean
+	case countQuestion.MatchString(q):
+		return AnswerNumber
+	default:
+		return AnswerString
+	}
+}
+
+// buildAskPrompt constructs the question prompt for the expected answer type
+func buildAskPrompt(question string, answerType AnswerType, choices []string) string {
+	var format string
+	switch answerType {
+	case AnswerBoolean:
+		format = `"answer": true or false`
+	case AnswerNumber:
+		format = `"answer": a number (no units)`
+	case AnswerEnum:
+		quoted := make([]string, len(choices))
+		for i, c := range choices {
+			quoted[i] = strconv.Quote(c)
+		}
+		format = `"answer": exactly one of ` + strings.Join(quoted, ", ")
+	default:
+		format = `"answer": a short answer of a few words`
+	}
+	return "Answer the following question about this image.\n" +
+		"Question: " + question + "\n\n" +
+		"Format the response strictly as JSON (no markdown fences) with the keys " +
+		format + `, "confidence": your confidence in the answer from 0.0 to 1.0, ` +
+		`and "explanation": one short sentence justifying it. ` +
+		"Base the answer only on what is visible in the image."
+}
+
+// parseAnswer extracts a typed answer from a model response. JSON responses
+// are preferred; plain text falls back to heuristics with a warning.
+func parseAnswer(text string, answerType AnswerType, choices []string) *Answer {
+	answer := &Answer{Type: answerType}
+
+	var raw struct {
+		Answer      any    `json:"answer"`
+		Confidence  any    `json:"confidence"`
+		Explanation string `json:"explanation"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONFromMarkdown(text)), &raw); err == nil && raw.Answer != nil {
+		answer.Value = coerceAnswer(raw.Answer, answerType, choices)
+		if conf, ok := toFloat32(raw.Confidence); ok {
+			if conf > 1 {
+				conf /= 100
+			}
+			answer.Confidence = conf
+		}
+		answer.Explanation = strings.TrimSpace(raw.Explanation)
+	} else {
+		answer.Warnings = append(answer.Warnings, "response was not valid JSON, the answer was extracted from plain text")
+		answer.Value = coerceAnswer(strings.TrimSpace(text), answerType, choices)
+		answer.Explanation = strings.TrimSpace(text)
+	}
+
+	if answer.Value == nil {
+		answer.Warnings = append(answer.Warnings, fmt.Sprintf("could not interpret the answer as %s", answerType))
+	}
+	return answer
+}
+
+// coerceAnswer converts a JSON value or text into the expected answer type,
+// returning nil when that is not possible
+func coerceAnswer(value any, answerType AnswerType, choices []string) any {
+	switch answerType {
+	case AnswerBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			words := strings.Fields(strings.ToLower(v))
+			if len(words) == 0 {
+				break
+			}
+			switch strings.Trim(words[0], ".,!;:\"'") {
+			case "yes", "true", "y":
+				return true
+			case "no", "false", "n":
+				return false
+			}
+		}
+	case AnswerNumber:
+		switch v := value.(type) {
+		case float64:
+			return v
+		case string:
+			if m := numberPattern.FindString(v); m != "" {
+				f, _ := strconv.ParseFloat(m, 64)
+				return f
+			}
+		}
+	case AnswerEnum:
+		s := strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+		for _, c := range choices {
+			if strings.ToLower(c) == s {
+				return c
+			}
+		}
+		// Otherwise accept the longest choice mentioned in the text.
+		match := ""
+		for _, c := range choices {
+			if strings.Contains(s, strings.ToLower(c)) && len(c) > len(match) {
+				match = c
+			}
+		}
+		if match != "" {
+			return match
+		}
+	default:
+		if s := strings.TrimSpace(fmt.Sprint(value)); s != "" {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+)
+
+// TestInferAnswerType tests answer type inference from the question wording
+func TestInferAnswerType(t *testing.T) {
+	tests := []struct {
+		question string
+		choices  []string
+		want     AnswerType
+	}{
+		{"Is there a person wearing a helmet?", nil, AnswerBoolean},
+		{"Does the car have a roof rack?", nil, AnswerBoolean},
+		{"How many people are in the photo?", nil, AnswerNumber},
+		{"What percentage of the sky is cloudy?", nil, AnswerNumber},
+		{"What color is the car?", nil, AnswerString},
+		{"Which season is it?", []string{"spring", "summer"}, AnswerEnum},
+		{"Island or mainland?", nil, AnswerString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			if got := inferAnswerType(tt.question, tt.choices); got != tt.want {
+				t.Errorf("inferAnswerType(%q) = %q, want %q", tt.question, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseAnswer tests typed answer extraction from model responses
+func TestParseAnswer(t *testing.T) {
+	choices := []string{"indoor", "outdoor"}
+	tests := []struct {
+		name           string
+		text           string
+		answerType     AnswerType
+		want           any
+		wantConfidence float32
+		wantWarning    bool
+	}{
+		{"boolean JSON", `{"answer": true, "confidence": 0.92, "explanation": "A yellow helmet is visible."}`, AnswerBoolean, true, 0.92, false},
+		{"boolean fenced", "```json\n{\"answer\": \"no\", \"confidence\": 80}\n```", AnswerBoolean, false, 0.8, false},
+		{"boolean plain text", "Yes, the cyclist wears a helmet.", AnswerBoolean, true, 0, true},
+		{"number JSON", `{"answer": 3, "confidence": 0.7}`, AnswerNumber, 3.0, 0.7, false},
+		{"number in string", `{"answer": "about 12 people"}`, AnswerNumber, 12.0, 0, false},
+		{"enum case-insensitive", `{"answer": "Outdoor", "confidence": 0.99}`, AnswerEnum, "outdoor", 0.99, false},
+		{"enum in sentence", "It looks like an indoor scene.", AnswerEnum, "indoor", 0, true},
+		{"enum unknown", `{"answer": "underwater"}`, AnswerEnum, nil, 0, true},
+		{"string", `{"answer": "red", "confidence": 0.6}`, AnswerString, "red", 0.6, false},
+		{"empty", "", AnswerBoolean, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAnswer(tt.text, tt.answerType, choices)
+			if got.Value != tt.want {
+				t.Errorf("parseAnswer() value = %#v, want %#v", got.Value, tt.want)
+			}
+			if got.Confidence != tt.wantConfidence {
+				t.Errorf("parseAnswer() confidence = %v, want %v", got.Confidence, tt.wantConfidence)
+			}
+			if (len(got.Warnings) > 0) != tt.wantWarning {
+				t.Errorf("parseAnswer() warnings = %v, want warning %v", got.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// TestAskProvider tests the question round trip through a provider
+func TestAskProvider(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	var prompt string
+	provider := &MockProvider{
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			prompt = opts.CustomPrompt
+			return &DetectionResult{
+				Provider:    "mock",
+				RawResponse: `{"answer": true, "confidence": 0.92, "explanation": "A helmet is visible."}`,
+			}, nil
+		},
+	}
+
+	answer, err := AskProvider(context.Background(), provider, img, "Is there a person wearing a helmet?", nil)
+	if err != nil {
+		t.Fatalf("AskProvider() error: %v", err)
+	}
+	if !answer.Bool() || answer.Confidence != 0.92 || answer.Type != AnswerBoolean || answer.Provider != "mock" {
+		t.Errorf("AskProvider() = %+v, want a confident yes", answer)
+	}
+	if !strings.Contains(prompt, "Is there a person wearing a helmet?") || !strings.Contains(prompt, "true or false") {
+		t.Errorf("prompt does not ask for a boolean answer: %q", prompt)
+	}
+
+	if _, err := AskProvider(context.Background(), provider, img, "Which?", &AskOptions{Type: AnswerEnum}); err == nil {
+		t.Error("AskProvider() with an enum type and no choices should fail")
+	}
+
+	aws := &MockProvider{NameFunc: func() string { return "aws" }}
+	if _, err := AskProvider(context.Background(), aws, img, "Is it red?", nil); !errors.Is(err, ErrInvalidFeature) {
+		t.Errorf("AskProvider() with aws error = %v, want ErrInvalidFeature", err)
+	}
+}
+
+// TestAnswerString tests answer formatting
+func TestAnswerString(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{true, "yes"},
+		{false, "no"},
+		{3.0, "3"},
+		{2.5, "2.5"},
+		{"red", "red"},
+		{nil, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := (&Answer{Value: tt.value}).String(); got != tt.want {
+			t.Errorf("Answer{%v}.String() = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"net/http"
 	"strings"
 	"time"
 
@@ -39,6 +40,26 @@ func NewAWSProvider() (*AWSProvider, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to load AWS config. Ensure you have AWS credentials configured via environment variables (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION) or AWS CLI (aws configure): %v", ErrProviderNotConfigured, err)
 	}
+	return NewAWSProviderWithConfig(cfg)
+}
+
+// NewAWSProviderWithConfig creates an AWS Rekognition provider from an
+// explicit AWS config instead of the default credential chain, e.g. one per
+// customer with their own credentials, region or assumed role. The
+// credentials are retrieved once to fail fast when they are missing.
+//
+// Example:
+//
+//	cfg := aws.Config{
+//		Region:      tenant.Region,
+//		Credentials: credentials.NewStaticCredentialsProvider(tenant.KeyID, tenant.Secret, ""),
+//	}
+//	provider, err := detection.NewAWSProviderWithConfig(cfg)
+func NewAWSProviderWithConfig(cfg aws.Config) (*AWSProvider, error) {
+	ctx := context.Background()
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("%w: the AWS config has no credentials", ErrProviderNotConfigured)
+	}
 
 	// Verify credentials are available by retrieving them
 	// This ensures we fail fast if credentials are not properly configured
@@ -58,7 +79,15 @@ func NewAWSProvider() (*AWSProvider, error) {
 	}
 
 	// Create Rekognition client
-	client := rekognition.NewFromConfig(cfg)
+	// A config built by hand may have no HTTP client
+	send := http.DefaultClient.Do
+	if cfg.HTTPClient != nil {
+		send = cfg.HTTPClient.Do
+	}
+	client := rekognition.NewFromConfig(cfg, func(o *rekognition.Options) {
+		debug := &debugTransport{provider: "aws", send: send}
+		o.HTTPClient = &retryTransport{provider: "aws", send: debug.Do}
+	})
 
 	// Store credential source info for debugging
 	credSource := creds.Source
@@ -89,6 +118,7 @@ func (a *AWSProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Detect
 	if opts == nil {
 		opts = DefaultDetectOptions()
 	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
 
 	startTime := time.Now()
 
@@ -148,6 +178,14 @@ func (a *AWSProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Detect
 				return nil, err
 			}
 
+		case FeatureWatermark:
+			// Watermark text is read by OCR, unless text was requested too
+			if !containsFeature(opts.Features, FeatureText) {
+				if err := a.detectText(ctx, imgBytes, result); err != nil {
+					return nil, err
+				}
+			}
+
 		case FeatureFaces:
 			if err := a.detectFaces(ctx, imgBytes, result); err != nil {
 				return nil, err
@@ -227,6 +265,20 @@ func (a *AWSProvider) detectLabels(ctx context.Context, imgBytes []byte, result
 			}
 
 			result.Labels = append(result.Labels, l)
+
+			// Instances locate the label in the image, relative to its size
+			for _, instance := range label.Instances {
+				bb := instance.BoundingBox
+				if bb == nil || bb.Left == nil || bb.Top == nil || bb.Width == nil || bb.Height == nil {
+					continue
+				}
+				box := BoundingBox{Label: *label.Name, Confidence: l.Confidence,
+					Box: Box{X: *bb.Left, Y: *bb.Top, Width: *bb.Width, Height: *bb.Height}}
+				if instance.Confidence != nil {
+					box.Confidence = *instance.Confidence / 100.0
+				}
+				result.BoundingBoxes = append(result.BoundingBoxes, box)
+			}
 		}
 	}
 
@@ -339,6 +391,12 @@ func (a *AWSProvider) detectFaces(ctx context.Context, imgBytes []byte, result *
 			}
 		}
 
+		// Eyes open
+		if faceDetail.EyesOpen != nil {
+			eyesOpen := faceDetail.EyesOpen.Value
+			face.EyesOpen = &eyesOpen
+		}
+
 		// Bounding box
 		if faceDetail.BoundingBox != nil {
 			bb := faceDetail.BoundingBox
@@ -2,10 +2,16 @@ package detection
 
 import (
 	"context"
+	"fmt"
 	"image/color"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
 // TestAWSProviderName tests the Name method
@@ -430,3 +436,48 @@ func BenchmarkAWSProviderDetect(b *testing.B) {
 		}
 	}
 }
+
+// TestNewAWSProviderWithConfig tests a provider created from an explicit
+// AWS config, without the default credential chain
+func TestNewAWSProviderWithConfig(t *testing.T) {
+	creds := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDTENANT", SecretAccessKey: "secret", Source: "tenant"}, nil
+	})
+	if _, err := NewAWSProviderWithConfig(aws.Config{Region: "us-east-1"}); !IsNotConfigured(err) {
+		t.Errorf("NewAWSProviderWithConfig() without credentials error = %v, want ErrProviderNotConfigured", err)
+	}
+	if _, err := NewAWSProviderWithConfig(aws.Config{Credentials: creds}); !IsNotConfigured(err) {
+		t.Errorf("NewAWSProviderWithConfig() without a region error = %v, want ErrProviderNotConfigured", err)
+	}
+
+	var target, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, auth = r.Header.Get("X-Amz-Target"), r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"Labels": [{"Name": "Cat", "Confidence": 95}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewAWSProviderWithConfig(aws.Config{
+		Region:       "eu-west-1",
+		Credentials:  creds,
+		BaseEndpoint: aws.String(server.URL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.credSource != "tenant" {
+		t.Errorf("credSource = %q, want tenant", provider.credSource)
+	}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	result, err := provider.Detect(context.Background(), img, &DetectOptions{Features: []Feature{FeatureLabels}})
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if target != "RekognitionService.DetectLabels" || !strings.Contains(auth, "AKIDTENANT/") || !strings.Contains(auth, "/eu-west-1/") {
+		t.Errorf("request %q signed with %q, want DetectLabels with the tenant credentials and region", target, auth)
+	}
+	if len(result.Labels) != 1 || result.Labels[0].Name != "Cat" {
+		t.Errorf("Labels = %v", result.Labels)
+	}
+}
@@ -0,0 +1,179 @@
+package detection
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+)
+
+// BatchOptions configures DetectBatch
+type BatchOptions struct {
+	// Concurrency is the number of detections in flight (default 4)
+	Concurrency int `json:"concurrency"`
+
+	// Retry is the retry policy of failed detections
+	Retry RetryPolicy `json:"retry"`
+
+	// OnResult, if set, is called as each image finishes, in completion
+	// order, with the number of finished images. Calls are serialized, so it
+	// may update shared state (progress bars, counters) without locking.
+	OnResult func(done, total int, result BatchResult) `json:"-"`
+}
+
+// BatchResult is the outcome of one image of DetectBatch
+type BatchResult struct {
+	Index    int              `json:"index"`            // Index of the image in the input
+	Result   *DetectionResult `json:"result,omitempty"` // nil if the detection failed
+	Err      error            `json:"-"`                // Error of the last attempt
+	Attempts int              `json:"attempts"`         // Number of detection calls made
+}
+
+// detectFunc runs one detection
+type detectFunc func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error)
+
+// DetectBatch runs Detect on every image with bounded concurrency and
+// returns the results in input order. The provider is created once for the
+// whole batch; failed detections are retried according to the retry policy
+// and each result holds its own error. The returned error is only set when
+// the batch can't start (invalid options, unknown or unconfigured
+// provider). When ctx is canceled, the remaining images fail with the
+// context error.
+//
+// Example:
+//
+//	results, err := detection.DetectBatch(ctx, imgs, "gemini", nil, detection.BatchOptions{
+//		Concurrency: 8,
+//		Retry:       detection.RetryPolicy{MaxAttempts: 3},
+//		OnResult: func(done, total int, r detection.BatchResult) {
+//			fmt.Printf("\r%d/%d", done, total)
+//		},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, r := range results {
+//		if r.Err != nil {
+//			log.Printf("image %d: %v", r.Index, r.Err)
+//		}
+//	}
+func DetectBatch(ctx context.Context, imgs []*image.NRGBA, provider string, opts *DetectOptions, batch BatchOptions) ([]BatchResult, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	detect, err := newDetectFunc(provider)
+	if err != nil {
+		return nil, err
+	}
+	return detectBatch(ctx, imgs, detect, opts, batch), nil
+}
+
+// detectBatch runs detect on every image with the concurrency of batch
+func detectBatch(ctx context.Context, imgs []*image.NRGBA, detect detectFunc, opts *DetectOptions, batch BatchOptions) []BatchResult {
+	workers := batch.Concurrency
+	if workers <= 0 {
+		workers = 4
+	}
+	workers = min(workers, len(imgs))
+
+	results := make([]BatchResult, len(imgs))
+	var mu sync.Mutex // serializes OnResult
+	done := 0
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = detectWithRetry(ctx, detect, imgs[i], opts, batch.Retry)
+				results[i].Index = i
+				if batch.OnResult != nil {
+					mu.Lock()
+					done++
+					batch.OnResult(done, len(imgs), results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range imgs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// newDetectFunc creates the provider once and returns a function running
+// detections like Detect
+func newDetectFunc(provider string) (detectFunc, error) {
+	name := ResolveProviderAlias(provider)
+	if name == AutoProvider {
+		router, err := LoadRouter("")
+		if err != nil {
+			return nil, err
+		}
+		return router.Detect, nil
+	}
+	prov, err := GetProvider(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get detection provider: %w", err)
+	}
+	if !prov.IsConfigured() {
+		return nil, NewDetectionError(prov.Name(), "provider is not configured", ErrProviderNotConfigured)
+	}
+	return providerDetectFunc(prov), nil
+}
+
+// providerDetectFunc runs detections with prov, adding the synthetic
+// assessment like Detect
+func providerDetectFunc(prov Provider) detectFunc {
+	return func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+		result, err := prov.Detect(ctx, img, opts)
+		if err != nil {
+			return nil, err
+		}
+		addSyntheticAssessment(img, opts, result)
+		addWatermarkAssessment(img, opts, result)
+		return result, nil
+	}
+}
+
+// detectWithRetry runs one detection, retrying failures with exponential
+// backoff
+func detectWithRetry(ctx context.Context, detect detectFunc, img *image.NRGBA, opts *DetectOptions, policy RetryPolicy) BatchResult {
+	if img == nil {
+		return BatchResult{Err: fmt.Errorf("%w: nil image", ErrInvalidImage)}
+	}
+	policy = policy.withDefaults()
+	attempts, backoff := policy.MaxAttempts, policy.Backoff
+
+	var res BatchResult
+	for res.Attempts < attempts {
+		if err := ctx.Err(); err != nil {
+			res.Err = err
+			return res
+		}
+		res.Attempts++
+		res.Result, res.Err = detect(ctx, img, opts)
+		if res.Err == nil || res.Attempts == attempts || !policy.Retryable(res.Err) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			res.Err = ctx.Err()
+			return res
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, policy.MaxBackoff)
+	}
+	if res.Err != nil {
+		res.Err = fmt.Errorf("detection failed: %w", res.Err)
+	}
+	return res
+}
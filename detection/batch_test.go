@@ -0,0 +1,122 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDetectBatch tests the concurrency limit, the result order, retries
+// and progress callbacks
+func TestDetectBatch(t *testing.T) {
+	imgs := make([]*image.NRGBA, 10)
+	for i := range imgs {
+		imgs[i] = image.NewNRGBA(image.Rect(0, 0, i+1, 1))
+	}
+	imgs[7] = nil
+
+	var inFlight, maxInFlight, calls atomic.Int32
+	provider := &MockProvider{
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			switch w := img.Bounds().Dx(); {
+			case w == 3 && calls.Add(1) == 1:
+				return nil, NewDetectionError("mock", "busy", ErrRateLimit) // retried
+			case w == 5:
+				return nil, NewDetectionError("mock", "bad key", ErrInvalidAPIKey) // not retried
+			default:
+				return &DetectionResult{Provider: "mock", Properties: map[string]string{"width": strconv.Itoa(w)}}, nil
+			}
+		},
+	}
+
+	var progress []int
+	results := detectBatch(context.Background(), imgs, providerDetectFunc(provider), DefaultDetectOptions(), BatchOptions{
+		Concurrency: 3,
+		Retry:       RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		OnResult: func(done, total int, r BatchResult) {
+			if total != len(imgs) {
+				t.Errorf("OnResult total = %d, want %d", total, len(imgs))
+			}
+			progress = append(progress, done)
+		},
+	})
+
+	if got := maxInFlight.Load(); got > 3 {
+		t.Errorf("max concurrent detections = %d, want <= 3", got)
+	}
+	if len(progress) != len(imgs) || progress[len(progress)-1] != len(imgs) {
+		t.Errorf("progress = %v", progress)
+	}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("results[%d].Index = %d", i, r.Index)
+		}
+		switch i {
+		case 2:
+			if r.Err != nil || r.Attempts != 2 {
+				t.Errorf("rate limited image: err = %v, attempts = %d, want success after 2", r.Err, r.Attempts)
+			}
+		case 4:
+			if !errors.Is(r.Err, ErrInvalidAPIKey) || r.Attempts != 1 {
+				t.Errorf("failing image: err = %v, attempts = %d, want ErrInvalidAPIKey after 1", r.Err, r.Attempts)
+			}
+		case 7:
+			if !errors.Is(r.Err, ErrInvalidImage) || r.Attempts != 0 {
+				t.Errorf("nil image: err = %v, attempts = %d", r.Err, r.Attempts)
+			}
+		default:
+			if r.Err != nil || r.Result.Properties["width"] != strconv.Itoa(i+1) {
+				t.Errorf("results[%d] = %+v, %v", i, r.Result, r.Err)
+			}
+		}
+	}
+}
+
+// TestDetectBatchCanceled tests that a canceled batch stops retrying and
+// fails the remaining images with the context error
+func TestDetectBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	provider := &MockProvider{
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			cancel()
+			return nil, NewDetectionError("mock", "unavailable", ErrAPIError)
+		},
+	}
+	imgs := []*image.NRGBA{image.NewNRGBA(image.Rect(0, 0, 1, 1)), image.NewNRGBA(image.Rect(0, 0, 1, 1))}
+	results := detectBatch(ctx, imgs, providerDetectFunc(provider), nil, BatchOptions{
+		Concurrency: 1,
+		Retry:       RetryPolicy{MaxAttempts: 5, Backoff: time.Hour},
+	})
+	if !errors.Is(results[0].Err, context.Canceled) || results[0].Attempts != 1 {
+		t.Errorf("results[0]: err = %v, attempts = %d", results[0].Err, results[0].Attempts)
+	}
+	if !errors.Is(results[1].Err, context.Canceled) || results[1].Attempts != 0 {
+		t.Errorf("results[1]: err = %v, attempts = %d", results[1].Err, results[1].Attempts)
+	}
+}
+
+// TestDetectBatchSetupErrors tests that DetectBatch fails before starting
+// on invalid options or an unknown provider
+func TestDetectBatchSetupErrors(t *testing.T) {
+	imgs := []*image.NRGBA{image.NewNRGBA(image.Rect(0, 0, 1, 1))}
+	if _, err := DetectBatch(context.Background(), imgs, "ollama", &DetectOptions{MaxResults: -1}, BatchOptions{}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("DetectBatch(invalid options) error = %v, want ErrInvalidOption", err)
+	}
+	if _, err := DetectBatch(context.Background(), imgs, "no-such-provider", nil, BatchOptions{}); err == nil {
+		t.Error("DetectBatch(unknown provider) error = nil")
+	}
+}
@@ -0,0 +1,183 @@
+package detection
+
+import (
+	"context"
+	"image"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchmarkOptions configures a provider benchmark
+type BenchmarkOptions struct {
+	// Runs is the number of detections per provider (default 3)
+	Runs int `json:"runs"`
+
+	// Detect are the options of each detection (default: DefaultDetectOptions)
+	Detect *DetectOptions `json:"detect,omitempty"`
+}
+
+// BenchmarkResult holds the measurements of one provider
+type BenchmarkResult struct {
+	Provider    string        `json:"provider"`
+	Runs        int           `json:"runs"`
+	Successes   int           `json:"successes"`
+	SuccessRate float64       `json:"success_rate"` // 0.0-1.0
+	Min         time.Duration `json:"min_ns"`
+	Mean        time.Duration `json:"mean_ns"`
+	P50         time.Duration `json:"p50_ns"`
+	P90         time.Duration `json:"p90_ns"`
+	P99         time.Duration `json:"p99_ns"`
+	Max         time.Duration `json:"max_ns"`
+	Labels      []string      `json:"labels,omitempty"` // Labels of the last successful run, lowercased
+	Errors      []string      `json:"errors,omitempty"` // Distinct error messages
+}
+
+// LabelAgreement is the label overlap of two providers: the Jaccard index
+// of their label sets (1.0 = same labels, 0.0 = nothing in common)
+type LabelAgreement struct {
+	A         string  `json:"a"`
+	B         string  `json:"b"`
+	Agreement float64 `json:"agreement"`
+}
+
+// BenchmarkReport compares providers on the same image
+type BenchmarkReport struct {
+	ImageWidth  int               `json:"image_width"`
+	ImageHeight int               `json:"image_height"`
+	Results     []BenchmarkResult `json:"results"`
+	Agreement   []LabelAgreement  `json:"agreement,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+}
+
+// Benchmark runs the same detection several times with each provider and
+// reports latency percentiles, success rate and how much the providers'
+// labels agree, to choose a default provider empirically. Providers run
+// one after the other so their latencies don't interfere.
+//
+// Example:
+//
+//	var providers []detection.Provider
+//	for _, name := range []string{"gemini", "aws", "ollama"} {
+//		if p, err := detection.GetProvider(name); err == nil {
+//			providers = append(providers, p)
+//		}
+//	}
+//	report := detection.Benchmark(ctx, img.ToNRGBA(), providers, &detection.BenchmarkOptions{Runs: 5})
+//	for _, r := range report.Results {
+//		fmt.Printf("%s: p50 %v, %.0f%% ok\n", r.Provider, r.P50, r.SuccessRate*100)
+//	}
+func Benchmark(ctx context.Context, img *image.NRGBA, providers []Provider, opts *BenchmarkOptions) *BenchmarkReport {
+	if opts == nil {
+		opts = &BenchmarkOptions{}
+	}
+	runs := opts.Runs
+	if runs <= 0 {
+		runs = 3
+	}
+	detectOpts := opts.Detect
+	if detectOpts == nil {
+		detectOpts = DefaultDetectOptions()
+	}
+
+	report := &BenchmarkReport{
+		ImageWidth:  img.Bounds().Dx(),
+		ImageHeight: img.Bounds().Dy(),
+		StartedAt:   time.Now(),
+	}
+	for _, p := range providers {
+		report.Results = append(report.Results, benchmarkProvider(ctx, img, p, runs, detectOpts))
+	}
+
+	for i := 0; i < len(report.Results); i++ {
+		for j := i + 1; j < len(report.Results); j++ {
+			a, b := report.Results[i], report.Results[j]
+			if a.Successes == 0 || b.Successes == 0 {
+				continue
+			}
+			report.Agreement = append(report.Agreement, LabelAgreement{
+				A:         a.Provider,
+				B:         b.Provider,
+				Agreement: jaccard(a.Labels, b.Labels),
+			})
+		}
+	}
+	return report
+}
+
+// benchmarkProvider measures one provider
+func benchmarkProvider(ctx context.Context, img *image.NRGBA, p Provider, runs int, opts *DetectOptions) BenchmarkResult {
+	result := BenchmarkResult{Provider: p.Name()}
+	var latencies []time.Duration
+	seen := make(map[string]bool)
+
+	for i := 0; i < runs && ctx.Err() == nil; i++ {
+		start := time.Now()
+		res, err := p.Detect(ctx, img, opts)
+		elapsed := time.Since(start)
+		result.Runs++
+
+		if err != nil {
+			if msg := err.Error(); !seen[msg] {
+				seen[msg] = true
+				result.Errors = append(result.Errors, msg)
+			}
+			continue
+		}
+		result.Successes++
+		latencies = append(latencies, elapsed)
+		result.Labels = result.Labels[:0]
+		for _, l := range res.Labels {
+			result.Labels = append(result.Labels, strings.ToLower(l.Name))
+		}
+	}
+
+	if result.Runs > 0 {
+		result.SuccessRate = float64(result.Successes) / float64(result.Runs)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, d := range latencies {
+			total += d
+		}
+		result.Min = latencies[0]
+		result.Max = latencies[len(latencies)-1]
+		result.Mean = total / time.Duration(len(latencies))
+		result.P50 = percentile(latencies, 50)
+		result.P90 = percentile(latencies, 90)
+		result.P99 = percentile(latencies, 99)
+	}
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// jaccard returns the Jaccard index of two label sets
+func jaccard(a, b []string) float64 {
+	set := make(map[string]int)
+	for _, l := range a {
+		set[l] |= 1
+	}
+	for _, l := range b {
+		set[l] |= 2
+	}
+	if len(set) == 0 {
+		return 1
+	}
+	both := 0
+	for _, v := range set {
+		if v == 3 {
+			both++
+		}
+	}
+	return float64(both) / float64(len(set))
+}
@@ -0,0 +1,81 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// TestBenchmark tests latency, success rate and label agreement
+func TestBenchmark(t *testing.T) {
+	img := createTestImage(16, 16, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+
+	labels := func(names ...string) []Label {
+		var out []Label
+		for _, n := range names {
+			out = append(out, Label{Name: n, Confidence: 0.9})
+		}
+		return out
+	}
+	calls := 0
+	flaky := &MockProvider{
+		NameFunc: func() string { return "flaky" },
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			calls++
+			if calls%2 == 1 {
+				return nil, errors.New("rate limited")
+			}
+			return &DetectionResult{Labels: labels("Dog", "Grass")}, nil
+		},
+	}
+	steady := &MockProvider{
+		NameFunc: func() string { return "steady" },
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			time.Sleep(2 * time.Millisecond)
+			return &DetectionResult{Labels: labels("dog", "ball")}, nil
+		},
+	}
+	broken := &MockProvider{
+		NameFunc: func() string { return "broken" },
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+
+	report := Benchmark(context.Background(), img, []Provider{flaky, steady, broken}, &BenchmarkOptions{Runs: 4})
+	if len(report.Results) != 3 || report.ImageWidth != 16 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	f, s, b := report.Results[0], report.Results[1], report.Results[2]
+	if f.Runs != 4 || f.Successes != 2 || f.SuccessRate != 0.5 || len(f.Errors) != 1 {
+		t.Errorf("flaky = %+v, want 2/4 successes and one distinct error", f)
+	}
+	if s.SuccessRate != 1 || s.P50 < 2*time.Millisecond || s.Min > s.P50 || s.P50 > s.P99 || s.P99 > s.Max {
+		t.Errorf("steady = %+v, want ordered latencies of at least 2ms", s)
+	}
+	if b.Successes != 0 || b.P50 != 0 {
+		t.Errorf("broken = %+v", b)
+	}
+
+	// dog/grass vs dog/ball: 1 shared label of 3; broken is left out.
+	if len(report.Agreement) != 1 {
+		t.Fatalf("Agreement = %+v, want one pair", report.Agreement)
+	}
+	if a := report.Agreement[0]; a.A != "flaky" || a.B != "steady" || a.Agreement < 0.33 || a.Agreement > 0.34 {
+		t.Errorf("Agreement = %+v, want flaky/steady at 1/3", a)
+	}
+}
+
+// TestPercentile tests nearest-rank percentiles
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for p, want := range map[float64]time.Duration{50: 5, 90: 9, 99: 10, 0: 1} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// ChainProvider tries providers in order and returns the result of the
+// first that succeeds, e.g. a local Ollama server with cloud providers as
+// fallback when it is down or its response can't be parsed.
+type ChainProvider struct {
+	providers []Provider
+	skipped   []string // Providers of the chain that could not be created
+}
+
+// Chain returns a provider trying providers in order. The result's Provider
+// field names the provider that served it; Properties["fallback_from"] and
+// Warnings list the providers that failed before it.
+//
+// Example:
+//
+//	var providers []detection.Provider
+//	for _, name := range []string{"ollama", "gemini", "aws"} {
+//		if p, err := detection.GetProvider(name); err == nil {
+//			providers = append(providers, p)
+//		}
+//	}
+//	result, err := detection.Chain(providers).Detect(ctx, img.ToNRGBA(), opts)
+func Chain(providers []Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// newChainProvider creates the chain of a comma-separated provider list.
+// Providers that can't be created (missing credentials, offline mode) are
+// skipped and reported as warnings of the results.
+func newChainProvider(names string) (*ChainProvider, error) {
+	chain := &ChainProvider{}
+	var errs []error
+	for _, name := range strings.Split(names, ",") {
+		name = ResolveProviderAlias(name)
+		if name == "" {
+			continue
+		}
+		if name == AutoProvider || strings.Contains(name, ",") {
+			return nil, fmt.Errorf("%w: %s can't be part of a provider chain", ErrInvalidOption, name)
+		}
+		prov, err := GetProvider(name)
+		if err != nil {
+			errs = append(errs, err)
+			chain.skipped = append(chain.skipped, fmt.Sprintf("%s skipped: %v", name, err))
+			continue
+		}
+		chain.providers = append(chain.providers, prov)
+	}
+	if len(chain.providers) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("%w: empty provider chain %q", ErrInvalidOption, names)
+		}
+		return nil, NewDetectionError("chain", "no provider of the chain is available", errors.Join(errs...))
+	}
+	return chain, nil
+}
+
+// Name returns the provider names of the chain, e.g. "ollama,gemini"
+func (c *ChainProvider) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// IsConfigured reports whether any provider of the chain is configured
+func (c *ChainProvider) IsConfigured() bool {
+	for _, p := range c.providers {
+		if p.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect runs the providers in order until one succeeds. Unconfigured
+// providers are skipped; cancellation of ctx stops the chain. When all
+// fail, the error joins the error of every provider.
+func (c *ChainProvider) Detect(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+	warnings := append([]string(nil), c.skipped...)
+	var failed []string
+	var errs []error
+	for _, p := range c.providers {
+		if !p.IsConfigured() {
+			warnings = append(warnings, fmt.Sprintf("%s skipped: not configured", p.Name()))
+			continue
+		}
+		result, err := p.Detect(ctx, img, opts)
+		if err == nil {
+			if len(failed) > 0 {
+				if result.Properties == nil {
+					result.Properties = make(map[string]string)
+				}
+				result.Properties["fallback_from"] = strings.Join(failed, ",")
+			}
+			result.Warnings = append(warnings, result.Warnings...)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		failed = append(failed, p.Name())
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		warnings = append(warnings, fmt.Sprintf("%s failed, trying the next provider: %v", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, NewDetectionError("chain", "no provider of the chain is configured", ErrProviderNotConfigured)
+	}
+	return nil, NewDetectionError("chain", "all providers failed", errors.Join(errs...))
+}
@@ -0,0 +1,99 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+)
+
+// TestChainProvider tests the fallback to the next provider and the record
+// of the provider that served the result
+func TestChainProvider(t *testing.T) {
+	failing := &MockProvider{
+		NameFunc: func() string { return "ollama" },
+		DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+			return nil, NewDetectionError("ollama", "failed to parse response", ErrAPIError)
+		},
+	}
+	unconfigured := &MockProvider{
+		NameFunc:         func() string { return "aws" },
+		IsConfiguredFunc: func() bool { return false },
+	}
+	serving := &MockProvider{NameFunc: func() string { return "gemini" }}
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+
+	chain := Chain([]Provider{failing, unconfigured, serving})
+	if chain.Name() != "ollama,aws,gemini" || !chain.IsConfigured() {
+		t.Errorf("Name() = %q, IsConfigured() = %v", chain.Name(), chain.IsConfigured())
+	}
+	result, err := chain.Detect(context.Background(), img, nil)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if result.Provider != "gemini" || result.Properties["fallback_from"] != "ollama" {
+		t.Errorf("Provider = %q, fallback_from = %q, want gemini after ollama", result.Provider, result.Properties["fallback_from"])
+	}
+	if len(result.Warnings) != 2 || !strings.Contains(result.Warnings[0], "failed to parse response") {
+		t.Errorf("Warnings = %q", result.Warnings)
+	}
+
+	// The first provider that succeeds ends the chain without warnings
+	result, err = Chain([]Provider{serving, failing}).Detect(context.Background(), img, nil)
+	if err != nil || len(result.Warnings) != 0 || result.Properties != nil {
+		t.Errorf("Detect() = %+v, %v", result, err)
+	}
+
+	_, err = Chain([]Provider{failing, failing}).Detect(context.Background(), img, nil)
+	if !errors.Is(err, ErrAPIError) || !strings.Contains(err.Error(), "all providers failed") {
+		t.Errorf("Detect() with failing providers error = %v", err)
+	}
+	_, err = Chain([]Provider{unconfigured}).Detect(context.Background(), img, nil)
+	if !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("Detect() with unconfigured providers error = %v, want ErrProviderNotConfigured", err)
+	}
+
+	// Cancellation stops the chain
+	ctx, cancel := context.WithCancel(context.Background())
+	canceling := &MockProvider{DetectFunc: func(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+		cancel()
+		return nil, ctx.Err()
+	}}
+	if _, err := Chain([]Provider{canceling, serving}).Detect(ctx, img, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Detect() canceled error = %v, want context.Canceled", err)
+	}
+}
+
+// TestGetProviderChain tests provider lists given to GetProvider
+func TestGetProviderChain(t *testing.T) {
+	t.Setenv("IMGX_YOLO_HOST", "")
+	t.Setenv("IMGX_OLLAMA_HOST", "")
+	t.Setenv("OLLAMA_HOST", "")
+
+	prov, err := GetProvider("yolov8, local")
+	if err != nil {
+		t.Fatalf("GetProvider() error = %v", err)
+	}
+	if _, ok := prov.(*ChainProvider); !ok || prov.Name() != "yolo,ollama" {
+		t.Errorf("GetProvider() = %T %q, want chain yolo,ollama", prov, prov.Name())
+	}
+
+	if _, err := GetProvider("ollama,auto"); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("GetProvider(ollama,auto) error = %v, want ErrInvalidOption", err)
+	}
+	if _, err := GetProvider("nope,nada"); err == nil {
+		t.Error("GetProvider(unknown providers) error = nil")
+	}
+
+	// Providers that can't be created are skipped with a warning
+	SetOffline(true)
+	defer SetOffline(false)
+	chain, err := GetProvider("gemini,ollama")
+	if err != nil {
+		t.Fatalf("GetProvider() offline error = %v", err)
+	}
+	if chain.Name() != "ollama" || len(chain.(*ChainProvider).skipped) != 1 {
+		t.Errorf("offline chain = %q, skipped %q", chain.Name(), chain.(*ChainProvider).skipped)
+	}
+}
@@ -23,6 +23,10 @@ type Config struct {
 	// Timeout specifies API request timeout in seconds
 	Timeout int
 
+	// Offline forbids network calls: cloud providers fail with ErrOffline
+	// and Ollama only works on a local host
+	Offline bool
+
 	mu sync.RWMutex
 }
 
@@ -55,6 +59,10 @@ func init() {
 	if cache := os.Getenv("IMGX_DETECTION_CACHE"); cache != "" {
 		globalConfig.CacheResults = cache == "true" || cache == "1"
 	}
+
+	if offline := os.Getenv("IMGX_OFFLINE"); offline != "" {
+		globalConfig.Offline = offline == "true" || offline == "1"
+	}
 }
 
 // GetDefaultProvider returns the default provider name
@@ -98,3 +106,19 @@ func SetTimeout(timeout int) {
 	defer globalConfig.mu.Unlock()
 	globalConfig.Timeout = timeout
 }
+
+// IsOffline returns whether offline mode is enabled
+func IsOffline() bool {
+	globalConfig.mu.RLock()
+	defer globalConfig.mu.RUnlock()
+	return globalConfig.Offline
+}
+
+// SetOffline enables or disables offline mode. In offline mode the cloud
+// providers (gemini, openai, aws) fail fast with ErrOffline, Ollama only
+// works with a local host, and the "auto" router applies its offline rules.
+func SetOffline(offline bool) {
+	globalConfig.mu.Lock()
+	defer globalConfig.mu.Unlock()
+	globalConfig.Offline = offline
+}
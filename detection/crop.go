@@ -0,0 +1,80 @@
+package detection
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// CropOptions selects and pads the objects cut out by CropObjects
+type CropOptions struct {
+	Labels        []string // Only crop objects with one of these labels (case-insensitive); all when empty
+	MinConfidence float32  // Only crop objects with at least this confidence
+	Padding       int      // Pixels added around each box, clamped to the image
+}
+
+// ObjectCrop is a detected object cut out of its image
+type ObjectCrop struct {
+	BoundingBox
+	Rect  image.Rectangle // Cropped area in image coordinates, padding included
+	Image *image.NRGBA
+}
+
+// CropObjects cuts the bounding boxes of the result that match opts out of
+// img, the image the detection ran on, most confident first: crops[0] of
+// CropOptions{Labels: []string{"dog"}} is the dog. Boxes outside the image
+// are skipped.
+//
+// Example:
+//
+//	for i, crop := range result.CropObjects(img, detection.CropOptions{Labels: []string{"product"}, Padding: 16}) {
+//		imgx.Save(crop.Image, fmt.Sprintf("product-%d.jpg", i+1))
+//	}
+func (r *DetectionResult) CropObjects(img image.Image, opts CropOptions) []ObjectCrop {
+	boxes := r.FilterBoxes(opts.Labels, opts.MinConfidence)
+	sort.SliceStable(boxes, func(i, j int) bool { return boxes[i].Confidence > boxes[j].Confidence })
+
+	b := img.Bounds()
+	var crops []ObjectCrop
+	for _, box := range boxes {
+		rect := box.Box.Rect(b.Dx(), b.Dy()).Add(b.Min)
+		if rect.Empty() {
+			continue
+		}
+		rect = rect.Inset(-opts.Padding).Intersect(b)
+		dst := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+		draw.Draw(dst, dst.Rect, img, rect.Min, draw.Src)
+		crops = append(crops, ObjectCrop{BoundingBox: box, Rect: rect, Image: dst})
+	}
+	return crops
+}
+
+// CropDetectedObjects detects the objects of img with provider
+// (FeatureObjects) and returns the crops of those matching opts, most
+// confident first (see CropObjects). It returns ErrNoObjects when none
+// match.
+//
+// Example:
+//
+//	// Thumbnail of the dog in the photo
+//	crops, err := detection.CropDetectedObjects(ctx, img, "yolo", detection.CropOptions{Labels: []string{"dog"}})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	thumb := imgx.Fit(crops[0].Image, 256, 256, imgx.Lanczos)
+func CropDetectedObjects(ctx context.Context, img image.Image, provider string, opts CropOptions) ([]ObjectCrop, error) {
+	detectOpts := DefaultDetectOptions()
+	detectOpts.Features = []Feature{FeatureObjects}
+	detectOpts.MinConfidence = opts.MinConfidence
+	result, err := DetectImage(ctx, img, provider, detectOpts)
+	if err != nil {
+		return nil, err
+	}
+	crops := result.CropObjects(img, opts)
+	if len(crops) == 0 {
+		return nil, fmt.Errorf("%w (%d found by %s)", ErrNoObjects, len(result.BoundingBoxes), result.Provider)
+	}
+	return crops, nil
+}
@@ -0,0 +1,74 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCropObjects tests cutting filtered boxes out of an image not at the
+// origin, most confident first
+func TestCropObjects(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(10, 10, 210, 110))
+	img.SetNRGBA(70, 35, color.NRGBA{255, 0, 0, 255}) // Top-left pixel of the dog
+	result := &DetectionResult{BoundingBoxes: []BoundingBox{
+		{Label: "dog", Confidence: 0.6, Box: Box{X: 0.3, Y: 0.25, Width: 0.25, Height: 0.5}},
+		{Label: "cat", Confidence: 0.9, Box: Box{X: 0, Y: 0, Width: 0.1, Height: 0.1}},
+		{Label: "Dog", Confidence: 0.8, Box: Box{X: 0.9, Y: 0.9, Width: 0.5, Height: 0.5}},
+		{Label: "dog", Confidence: 0.3, Box: Box{X: 0, Y: 0, Width: 1, Height: 1}},
+		{Label: "dog", Confidence: 0.7, Box: Box{X: 2, Y: 2, Width: 0.1, Height: 0.1}}, // Outside
+	}}
+
+	crops := result.CropObjects(img, CropOptions{Labels: []string{"dog"}, MinConfidence: 0.5})
+	if len(crops) != 2 {
+		t.Fatalf("CropObjects() = %d crops, want 2", len(crops))
+	}
+	if crops[0].Confidence != 0.8 || crops[0].Rect != image.Rect(190, 100, 210, 110) {
+		t.Errorf("crops[0] = %+v at %v, want the clamped 0.8 dog", crops[0].BoundingBox, crops[0].Rect)
+	}
+	dog := crops[1]
+	if dog.Label != "dog" || dog.Rect != image.Rect(70, 35, 120, 85) || dog.Image.Bounds() != image.Rect(0, 0, 50, 50) {
+		t.Errorf("crops[1] = %+v at %v, %v", dog.BoundingBox, dog.Rect, dog.Image.Bounds())
+	}
+	if c := dog.Image.NRGBAAt(0, 0); c.R != 255 {
+		t.Errorf("crop top-left pixel = %v, want the red one", c)
+	}
+
+	padded := result.CropObjects(img, CropOptions{Labels: []string{"cat"}, Padding: 5})
+	if len(padded) != 1 || padded[0].Rect != image.Rect(10, 10, 35, 25) {
+		t.Errorf("padded cat crop = %+v", padded)
+	}
+	if all := result.CropObjects(img, CropOptions{}); len(all) != 4 {
+		t.Errorf("CropObjects() without filters = %d crops, want 4", len(all))
+	}
+}
+
+// TestCropDetectedObjects tests detecting and cropping with a YOLO stub
+func TestCropDetectedObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A dog in the middle of the 640x640 tensor
+		output := yoloV8Output(len(cocoClassNames), [][6]float32{{320, 320, 320, 320, 16, 0.9}})
+		json.NewEncoder(w).Encode(&yoloInferResponse{Outputs: []yoloTensor{output}})
+	}))
+	defer server.Close()
+	t.Setenv("IMGX_YOLO_HOST", server.URL)
+	t.Setenv("IMGX_YOLO_MODEL", "")
+	ctx := context.Background()
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+
+	crops, err := CropDetectedObjects(ctx, img, "yolo", CropOptions{Labels: []string{"dog"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crops) != 1 || crops[0].Rect != image.Rect(16, 16, 48, 48) {
+		t.Errorf("CropDetectedObjects() = %+v", crops)
+	}
+	if _, err := CropDetectedObjects(ctx, img, "yolo", CropOptions{Labels: []string{"cat"}}); !errors.Is(err, ErrNoObjects) {
+		t.Errorf("CropDetectedObjects(cat) error = %v, want ErrNoObjects", err)
+	}
+}
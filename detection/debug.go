@@ -0,0 +1,231 @@
+package detection
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugBodyLimit is the number of bytes of a request or response body
+// logged after redaction; the rest is truncated
+const debugBodyLimit = 16 * 1024
+
+var debugLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetDebugWriter logs the HTTP requests sent to the providers and their raw
+// responses to w, or disables logging if w is nil. API keys, credentials
+// and signatures are redacted, image payloads (base64 data) are replaced by
+// their size, and long bodies are truncated, so the log can be attached to
+// a bug report. Use it to see why a response could not be parsed.
+//
+// Example:
+//
+//	detection.SetDebugWriter(os.Stderr)
+//	result, err := detection.Detect(ctx, img.ToNRGBA(), "gemini")
+func SetDebugWriter(w io.Writer) {
+	debugLog.mu.Lock()
+	defer debugLog.mu.Unlock()
+	debugLog.w = w
+}
+
+func debugWriter() io.Writer {
+	debugLog.mu.Lock()
+	defer debugLog.mu.Unlock()
+	return debugLog.w
+}
+
+// debugHTTPClient returns a copy of base whose requests are logged while a
+// debug writer is set, rate limited and retried (see SetRetryPolicy); each
+// attempt is logged
+func debugHTTPClient(provider string, base *http.Client) *http.Client {
+	client := *base
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	debug := &debugTransport{provider: provider, send: transport.RoundTrip}
+	client.Transport = &retryTransport{provider: provider, send: debug.RoundTrip}
+	return &client
+}
+
+// debugTransport logs the requests and responses of send to the debug
+// writer. It is an http.RoundTripper, and also an AWS SDK HTTP client
+// wrapping the client of the AWS config.
+type debugTransport struct {
+	provider string
+	send     func(*http.Request) (*http.Response, error)
+}
+
+// Do sends req, for the AWS SDK
+func (t *debugTransport) Do(req *http.Request) (*http.Response, error) {
+	return t.RoundTrip(req)
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	w := debugWriter()
+	if w == nil {
+		return t.send(req)
+	}
+
+	secrets := requestSecrets(req.Header)
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> [%s] %s %s\n", t.provider, req.Method, redactURL(req, secrets))
+	writeDebugHeaders(&b, req.Header, secrets)
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		writeDebugBody(&b, body, secrets)
+	}
+
+	start := time.Now()
+	resp, err := t.send(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(&b, "<-- [%s] error after %s: %s\n\n", t.provider, elapsed, redactSecrets(err.Error(), secrets))
+		writeDebug(w, b.String())
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "<-- [%s] %s (%s)\n", t.provider, resp.Status, elapsed)
+	writeDebugHeaders(&b, resp.Header, secrets)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	writeDebugBody(&b, body, secrets)
+	if err != nil {
+		fmt.Fprintf(&b, "(failed to read body: %v)\n\n", err)
+		writeDebug(w, b.String())
+		return nil, err
+	}
+	b.WriteString("\n")
+	writeDebug(w, b.String())
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// writeDebug writes an entry in one call, so concurrent requests don't
+// interleave
+func writeDebug(w io.Writer, entry string) {
+	debugLog.mu.Lock()
+	defer debugLog.mu.Unlock()
+	io.WriteString(w, entry)
+}
+
+// debugSecretHeaders are the headers carrying credentials
+var debugSecretHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"X-Goog-Api-Key":       true,
+	"X-Api-Key":            true,
+	"Api-Key":              true,
+	"X-Amz-Security-Token": true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+}
+
+// requestSecrets returns the credentials sent in the headers of a request,
+// so that they are redacted wherever else they appear. This covers keys
+// given to a provider in code as well as those read from the environment.
+func requestSecrets(header http.Header) []string {
+	var secrets []string
+	for name, values := range header {
+		if !debugSecretHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for _, v := range values {
+			// "Bearer sk-..." also sends the key on its own
+			if _, token, ok := strings.Cut(v, " "); ok && !strings.Contains(token, " ") {
+				secrets = append(secrets, token)
+			}
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+func writeDebugHeaders(b *strings.Builder, header http.Header, secrets []string) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if debugSecretHeaders[http.CanonicalHeaderKey(name)] {
+				value = "[REDACTED]"
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, redactSecrets(value, secrets))
+		}
+	}
+}
+
+func writeDebugBody(b *strings.Builder, body []byte, secrets []string) {
+	if len(body) == 0 {
+		return
+	}
+	text := redactSecrets(redactPayloads(string(body)), secrets)
+	if len(text) > debugBodyLimit {
+		text = fmt.Sprintf("%s... [%d bytes truncated]", text[:debugBodyLimit], len(text)-debugBodyLimit)
+	}
+	b.WriteString("\n")
+	b.WriteString(text)
+	b.WriteString("\n")
+}
+
+// redactURL returns the URL of req with the key query parameter redacted
+func redactURL(req *http.Request, secrets []string) string {
+	u := *req.URL
+	q := u.Query()
+	for _, name := range []string{"key", "api_key", "X-Amz-Signature", "X-Amz-Credential", "X-Amz-Security-Token"} {
+		if q.Has(name) {
+			q.Set(name, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	return redactSecrets(u.String(), secrets)
+}
+
+// base64Payload matches the base64 data of images in request and response
+// bodies
+var base64Payload = regexp.MustCompile(`[A-Za-z0-9+/_-]{512,}={0,2}`)
+
+// redactPayloads replaces base64 data by its size
+func redactPayloads(s string) string {
+	return base64Payload.ReplaceAllStringFunc(s, func(data string) string {
+		return fmt.Sprintf("[%d bytes of base64 data]", len(data))
+	})
+}
+
+// debugSecretEnvVars hold credentials that must never be logged, wherever
+// they appear
+var debugSecretEnvVars = []string{"GEMINI_API_KEY", "OPENAI_API_KEY", "GOOGLE_VISION_API_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
+
+// redactSecrets replaces the values of the credential environment variables
+// and the given secrets, e.g. those of requestSecrets
+func redactSecrets(s string, secrets []string) string {
+	redact := func(v string) {
+		if len(v) >= 8 {
+			s = strings.ReplaceAll(s, v, "[REDACTED]")
+		}
+	}
+	for _, name := range debugSecretEnvVars {
+		redact(os.Getenv(name))
+	}
+	for _, v := range secrets {
+		redact(v)
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package detection
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Contains(body, []byte("describe")) {
+			t.Errorf("server got body %q, want the original request", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"labels": [{"name": "cat"}]}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("GEMINI_API_KEY", "secret-gemini-key")
+	var log bytes.Buffer
+	SetDebugWriter(&log)
+	defer SetDebugWriter(nil)
+
+	imageData := strings.Repeat("iVBORw0KGgo", 100)
+	payload := `{"prompt": "describe", "key": "secret-gemini-key", "images": ["` + imageData + `"]}`
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api?key=secret-gemini-key&alt=json", strings.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer sk-12345")
+	req.Header.Set("X-Goog-Api-Key", "secret-gemini-key")
+
+	client := debugHTTPClient("gemini", &http.Client{})
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"labels": [{"name": "cat"}]}` {
+		t.Errorf("response body = %q, want the server response", body)
+	}
+
+	out := log.String()
+	for _, leak := range []string{"secret-gemini-key", "sk-12345", imageData} {
+		if strings.Contains(out, leak) {
+			t.Errorf("debug log leaks %.20q:\n%s", leak, out)
+		}
+	}
+	for _, want := range []string{
+		"--> [gemini] POST", "alt=json", "Authorization: [REDACTED]", `"prompt": "describe"`,
+		"[1100 bytes of base64 data]", "<-- [gemini] 200 OK", `{"labels": [{"name": "cat"}]}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("debug log misses %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestDebugTransportConfiguredKey tests that keys that are not in the
+// environment, e.g. set with a provider config, are redacted too
+func TestDebugTransportConfiguredKey(t *testing.T) {
+	const key = "configured-tenant-key"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid key `+key+`"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var log bytes.Buffer
+	SetDebugWriter(&log)
+	defer SetDebugWriter(nil)
+
+	for _, header := range []string{"Authorization", "X-Goog-Api-Key"} {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"echo": "`+key+`"}`))
+		if header == "Authorization" {
+			req.Header.Set(header, "Bearer "+key)
+		} else {
+			req.Header.Set(header, key)
+		}
+		resp, err := debugHTTPClient("openai", &http.Client{}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if out := log.String(); strings.Contains(out, key) || !strings.Contains(out, "invalid key [REDACTED]") {
+		t.Errorf("debug log does not redact the configured key:\n%s", out)
+	}
+}
+
+func TestDebugTransportDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	var log bytes.Buffer
+	SetDebugWriter(&log)
+	SetDebugWriter(nil)
+	resp, err := debugHTTPClient("ollama", &http.Client{}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if log.Len() != 0 {
+		t.Errorf("debug log written after SetDebugWriter(nil):\n%s", log.String())
+	}
+}
+
+func TestDebugBodyTruncated(t *testing.T) {
+	var b strings.Builder
+	writeDebugBody(&b, []byte(strings.Repeat("word ", debugBodyLimit)), nil)
+	if b.Len() > debugBodyLimit+100 || !strings.Contains(b.String(), "bytes truncated]") {
+		t.Errorf("body of %d bytes logged as %d bytes, want truncation", 5*debugBodyLimit, b.Len())
+	}
+}
@@ -1,9 +1,12 @@
 package detection
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"image"
+	_ "image/gif" // decoded by DetectReader
+	"io"
 )
 
 // Detect performs object detection on an image using the specified provider
@@ -17,6 +20,11 @@ import (
 //   - "gemini" or "google" - Google Gemini API (requires GEMINI_API_KEY)
 //   - "aws" - AWS Rekognition (uses AWS credential chain)
 //   - "openai" - OpenAI Vision (requires OPENAI_API_KEY)
+//   - "vision" or "gcv" - Google Cloud Vision (requires GOOGLE_VISION_API_KEY)
+//   - "yolo" or "onnx" - YOLO model on a local inference server (see NewYOLOProvider)
+//   - "auto" - Chosen by routing rules (see LoadRouter)
+//   - A comma-separated list such as "ollama,gemini,aws" - Each provider in
+//     turn until one succeeds (see Chain)
 //
 // Example:
 //
@@ -42,10 +50,26 @@ func Detect(ctx context.Context, img *image.NRGBA, provider string, opts ...*Det
 	} else {
 		opt = DefaultDetectOptions()
 	}
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Resolve provider alias ("google" -> "gemini")
 	resolvedProvider := ResolveProviderAlias(provider)
 
+	// "auto" picks the provider with the routing rules (see LoadRouter)
+	if resolvedProvider == AutoProvider {
+		router, err := LoadRouter("")
+		if err != nil {
+			return nil, err
+		}
+		result, err := router.Detect(ctx, img, opt)
+		if err != nil {
+			return nil, fmt.Errorf("detection failed: %w", err)
+		}
+		return result, nil
+	}
+
 	// Get provider instance via factory
 	prov, err := GetProvider(resolvedProvider)
 	if err != nil {
@@ -57,6 +81,54 @@ func Detect(ctx context.Context, img *image.NRGBA, provider string, opts ...*Det
 	if err != nil {
 		return nil, fmt.Errorf("detection failed: %w", err)
 	}
+	addSyntheticAssessment(img, opt, result)
+	addWatermarkAssessment(img, opt, result)
 
 	return result, nil
 }
+
+// DetectImage is Detect for any image.Image, e.g. one decoded by the
+// caller or generated in memory. Images other than *image.NRGBA are
+// converted first.
+func DetectImage(ctx context.Context, img image.Image, provider string, opts ...*DetectOptions) (*DetectionResult, error) {
+	if img == nil {
+		return nil, fmt.Errorf("%w: nil image", ErrInvalidImage)
+	}
+	return Detect(ctx, toNRGBA(img), provider, opts...)
+}
+
+// DetectReader is Detect for an encoded PNG, JPEG or GIF image read from r,
+// e.g. an HTTP upload, without writing it to a file. EXIF orientation is
+// not applied; decode with imgx.Decode and use DetectImage for photos that
+// need it. With FeatureSynthetic the encoded bytes are searched for
+// generator markers, as the CLI does with the input file.
+//
+// Example:
+//
+//	func upload(w http.ResponseWriter, r *http.Request) {
+//		result, err := detection.DetectReader(r.Context(), http.MaxBytesReader(w, r.Body, 10<<20), "ollama")
+//		...
+//	}
+func DetectReader(ctx context.Context, r io.Reader, provider string, opts ...*DetectOptions) (*DetectionResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	var opt *DetectOptions
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	} else {
+		opt = DefaultDetectOptions()
+	}
+	if opt.Source == nil {
+		copied := *opt
+		copied.Source = data
+		opt = &copied
+	}
+	return Detect(ctx, toNRGBA(src), provider, opt)
+}
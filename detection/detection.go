@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -12,22 +16,29 @@ import (
 
 // DetectionResult contains all detection results from a provider
 type DetectionResult struct {
-	Provider      string             `json:"provider"`                 // Provider name
-	Labels        []Label            `json:"labels,omitempty"`         // Detected objects/labels
-	Description   string             `json:"description,omitempty"`    // Natural language description
-	Text          []TextBlock        `json:"text,omitempty"`           // OCR results
-	Faces         []Face             `json:"faces,omitempty"`          // Face detection
-	Web           *WebDetection      `json:"web,omitempty"`            // Web search results
-	BoundingBoxes []BoundingBox      `json:"bounding_boxes,omitempty"` // Object locations
-	Colors        []ColorInfo        `json:"colors,omitempty"`         // Dominant colors and palettes
-	ImageQuality  *ImageQuality      `json:"image_quality,omitempty"`  // Brightness/contrast metrics
-	Moderation    []ModerationLabel  `json:"moderation,omitempty"`     // Safe-search or moderation labels
-	Properties    map[string]string  `json:"properties,omitempty"`     // Provider-specific data
-	SafeSearch    *SafeSearchSummary `json:"safe_search,omitempty"`    // Provider-safe-search summary
-	Confidence    float32            `json:"confidence"`               // Overall confidence 0.0-1.0
-	Error         string             `json:"error,omitempty"`          // Error message if detection failed
-	RawResponse   string             `json:"raw_response,omitempty"`   // Raw API response for debugging
-	ProcessedAt   time.Time          `json:"processed_at"`             // When detection ran
+	Provider      string               `json:"provider"`                 // Provider name
+	Labels        []Label              `json:"labels,omitempty"`         // Detected objects/labels
+	Description   string               `json:"description,omitempty"`    // Natural language description
+	Text          []TextBlock          `json:"text,omitempty"`           // OCR results
+	Faces         []Face               `json:"faces,omitempty"`          // Face detection
+	Web           *WebDetection        `json:"web,omitempty"`            // Web search results
+	Landmarks     []EntityAnnotation   `json:"landmarks,omitempty"`      // Recognized places and monuments
+	Logos         []EntityAnnotation   `json:"logos,omitempty"`          // Recognized brand logos
+	BoundingBoxes []BoundingBox        `json:"bounding_boxes,omitempty"` // Object locations
+	Colors        []ColorInfo          `json:"colors,omitempty"`         // Dominant colors and palettes
+	ImageQuality  *ImageQuality        `json:"image_quality,omitempty"`  // Brightness/contrast metrics
+	Moderation    []ModerationLabel    `json:"moderation,omitempty"`     // Safe-search or moderation labels
+	Properties    map[string]string    `json:"properties,omitempty"`     // Provider-specific data
+	SafeSearch    *SafeSearchSummary   `json:"safe_search,omitempty"`    // Provider-safe-search summary
+	Synthetic     *SyntheticAssessment `json:"synthetic,omitempty"`      // AI-generated image likelihood
+	Watermark     *WatermarkAssessment `json:"watermark,omitempty"`      // Third-party watermark likelihood
+	Confidence    float32              `json:"confidence"`               // Overall confidence 0.0-1.0
+	Error         string               `json:"error,omitempty"`          // Error message if detection failed
+	Warnings      []string             `json:"warnings,omitempty"`       // Non-fatal issues (e.g. fallback parsing used)
+	RawResponse   string               `json:"raw_response,omitempty"`   // Raw API response for debugging
+	RawStructured json.RawMessage      `json:"raw_structured,omitempty"` // Custom-prompt response parsed with ResponseSchema
+	SchemaErrors  []string             `json:"schema_errors,omitempty"`  // ResponseSchema violations of RawStructured
+	ProcessedAt   time.Time            `json:"processed_at"`             // When detection ran
 }
 
 // Label represents a detected object or label
@@ -59,6 +70,7 @@ type Face struct {
 	SurpriseLikelihood string     `json:"surprise_likelihood,omitempty"` // Emotion: surprise
 	Gender             string     `json:"gender,omitempty"`              // Male/Female
 	AgeRange           string     `json:"age_range,omitempty"`           // Age range estimate
+	EyesOpen           *bool      `json:"eyes_open,omitempty"`           // Whether both eyes are open, nil if unknown
 	Landmarks          []Landmark `json:"landmarks,omitempty"`           // Facial landmarks
 }
 
@@ -102,6 +114,21 @@ type WebPage struct {
 	PartialMatchingImages []WebImage `json:"partial_matching_images,omitempty"`
 }
 
+// EntityAnnotation represents a recognized landmark or logo
+type EntityAnnotation struct {
+	Name        string     `json:"name"`                   // e.g. "Eiffel Tower", "Google"
+	Confidence  float32    `json:"confidence"`             // 0.0-1.0 confidence score
+	MID         string     `json:"mid,omitempty"`          // Machine ID (Google Knowledge Graph)
+	BoundingBox *Box       `json:"bounding_box,omitempty"` // Location in the image
+	Locations   []GeoPoint `json:"locations,omitempty"`    // Geographic coordinates (landmarks)
+}
+
+// GeoPoint is a latitude/longitude pair in degrees
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 // BoundingBox represents object location in the image
 type BoundingBox struct {
 	Label      string  `json:"label"`      // Object label
@@ -117,6 +144,149 @@ type Box struct {
 	Height float32 `json:"height"` // Box height
 }
 
+// Rect returns the box in pixel coordinates of a width x height image,
+// clamped to the image
+func (b Box) Rect(width, height int) image.Rectangle {
+	x0, y0, w, h := pixelBox(b, width, height)
+	return image.Rect(int(math.Round(x0)), int(math.Round(y0)),
+		int(math.Round(x0+w)), int(math.Round(y0+h)))
+}
+
+// FilterBoxes returns the bounding boxes with one of labels (compared
+// case-insensitively; all labels when empty) and at least minConfidence
+func (r *DetectionResult) FilterBoxes(labels []string, minConfidence float32) []BoundingBox {
+	var boxes []BoundingBox
+	for _, box := range r.BoundingBoxes {
+		if box.Confidence < minConfidence {
+			continue
+		}
+		if len(labels) > 0 && !slices.ContainsFunc(labels, func(l string) bool {
+			return strings.EqualFold(strings.TrimSpace(l), box.Label)
+		}) {
+			continue
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
+
+// RegionKind is the kind of result a Region locates
+type RegionKind string
+
+const (
+	RegionObject   RegionKind = "object"   // A bounding box
+	RegionFace     RegionKind = "face"     // A face
+	RegionText     RegionKind = "text"     // An OCR text block
+	RegionLandmark RegionKind = "landmark" // A recognized landmark
+	RegionLogo     RegionKind = "logo"     // A recognized logo
+)
+
+// Region is a located result in pixel coordinates, as returned by Regions
+type Region struct {
+	Kind       RegionKind      `json:"kind"`
+	Label      string          `json:"label"` // Object label, "face", the text, or the landmark or logo name
+	Confidence float32         `json:"confidence"`
+	Rect       image.Rectangle `json:"rect"`
+}
+
+// Regions returns every result with a location (bounding boxes, faces, OCR
+// text, landmarks and logos) in pixel coordinates of a width x height image,
+// e.g. to draw them on the image with imgx.Annotate. Locations that are
+// empty once clamped to the image are skipped.
+//
+// Example:
+//
+//	var boxes []imgx.BoxAnnotation
+//	for _, r := range result.Regions(img.Bounds().Dx(), img.Bounds().Dy()) {
+//		boxes = append(boxes, imgx.BoxAnnotation{Rect: r.Rect, Label: r.Label})
+//	}
+//	annotated := img.Annotate(boxes, imgx.AnnotateOptions{})
+func (r *DetectionResult) Regions(width, height int) []Region {
+	var regions []Region
+	add := func(kind RegionKind, label string, confidence float32, box *Box) {
+		if box == nil {
+			return
+		}
+		if rect := box.Rect(width, height); !rect.Empty() {
+			regions = append(regions, Region{Kind: kind, Label: label, Confidence: confidence, Rect: rect})
+		}
+	}
+	for _, b := range r.BoundingBoxes {
+		add(RegionObject, b.Label, b.Confidence, &b.Box)
+	}
+	for _, f := range r.Faces {
+		add(RegionFace, "face", f.Confidence, f.BoundingBox)
+	}
+	for _, t := range r.Text {
+		add(RegionText, t.Text, t.Confidence, t.BoundingBox)
+	}
+	for _, l := range r.Landmarks {
+		add(RegionLandmark, l.Name, l.Confidence, l.BoundingBox)
+	}
+	for _, l := range r.Logos {
+		add(RegionLogo, l.Name, l.Confidence, l.BoundingBox)
+	}
+	return regions
+}
+
+// regionFeatures are the features that locate each kind of region
+var regionFeatures = map[RegionKind]Feature{
+	RegionObject:   FeatureObjects,
+	RegionFace:     FeatureFaces,
+	RegionText:     FeatureText,
+	RegionLandmark: FeatureLandmarks,
+	RegionLogo:     FeatureLogos,
+}
+
+// DetectRegions detects the given kinds of regions of img with provider and
+// returns them in pixel coordinates of img (see Regions). With no kinds,
+// faces are detected. Use it with imgx.Redact to hide faces or text.
+//
+// Example:
+//
+//	// Pixelate the faces of a photo
+//	faces, err := detection.DetectRegions(ctx, img, "vision", detection.RegionFace)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	rects := make([]image.Rectangle, len(faces))
+//	for i, f := range faces {
+//		rects[i] = f.Rect
+//	}
+//	redacted := imgx.Redact(img, rects, imgx.RedactOptions{Mode: imgx.RedactPixelate, Padding: 8})
+func DetectRegions(ctx context.Context, img image.Image, provider string, kinds ...RegionKind) ([]Region, error) {
+	if len(kinds) == 0 {
+		kinds = []RegionKind{RegionFace}
+	}
+	opts := DefaultDetectOptions()
+	opts.Features = nil
+	want := make(map[RegionKind]bool, len(kinds))
+	for _, kind := range kinds {
+		feature, ok := regionFeatures[kind]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown region kind %q", ErrInvalidOption, kind)
+		}
+		if !want[kind] {
+			opts.Features = append(opts.Features, feature)
+		}
+		want[kind] = true
+	}
+	result, err := DetectImage(ctx, img, provider, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	var regions []Region
+	for _, region := range result.Regions(b.Dx(), b.Dy()) {
+		if want[region.Kind] {
+			region.Rect = region.Rect.Add(b.Min)
+			regions = append(regions, region)
+		}
+	}
+	return regions, nil
+}
+
 // ColorInfo describes a dominant color detected in the image
 type ColorInfo struct {
 	Name       string  `json:"name,omitempty"`       // Human-friendly color name
@@ -152,7 +322,7 @@ type SafeSearchSummary struct {
 	Notes  string            `json:"notes,omitempty"`
 }
 
-const responseSchemaPrompt = "Format the response strictly as JSON (no markdown fences). Use keys like `labels` (array of {name, confidence}), `description` (string), `text` (array of {text, confidence}), `colors` (array of {name, hex, rgb, percentage}), `image_quality` (object with brightness, sharpness, contrast, foreground_*, background_* fields), `moderation` (array of {name, parent, confidence, severity}), `safe_search` (object with labels array and optional notes), and `properties` (object of additional key/value strings). Omit keys that you cannot populate."
+const responseSchemaPrompt = "Format the response strictly as JSON (no markdown fences). Use keys like `labels` (array of {name, confidence}), `description` (string), `text` (array of {text, confidence}), `colors` (array of {name, hex, rgb, percentage}), `image_quality` (object with brightness, sharpness, contrast, foreground_*, background_* fields), `moderation` (array of {name, parent, confidence, severity}), `safe_search` (object with labels array and optional notes), `faces` (array of {confidence, eyes_open, bounding_box: {x, y, width, height} normalized 0-1}), and `properties` (object of additional key/value strings). Omit keys that you cannot populate."
 
 // Provider defines the interface all detection providers must implement
 type Provider interface {
@@ -183,8 +353,52 @@ type DetectOptions struct {
 	// Language hint for text detection
 	Language string `json:"language,omitempty"`
 
+	// Model overrides the Gemini model for this detection, e.g.
+	// "gemini-1.5-pro". Default the model of the provider. Other providers
+	// ignore it.
+	Model string `json:"model,omitempty"`
+
 	// IncludeRawResponse includes raw API response in result
 	IncludeRawResponse bool `json:"include_raw_response,omitempty"`
+
+	// DescriptionStyle controls the description generated for
+	// FeatureDescription by the LLM providers (Ollama, Gemini, OpenAI)
+	DescriptionStyle *DescriptionStyle `json:"description_style,omitempty"`
+
+	// ResponseSchema is the JSON structure expected from CustomPrompt. The
+	// response is validated and returned in DetectionResult.RawStructured
+	// instead of Description. Ignored without CustomPrompt.
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+
+	// Source is the encoded image file, searched for generator markers by
+	// FeatureSynthetic. Optional: without it only the pixels are analyzed.
+	Source []byte `json:"-"`
+
+	// Retry overrides the retry policy of the provider requests set by
+	// SetRetryPolicy for this detection
+	Retry *RetryPolicy `json:"-"`
+}
+
+// DescriptionFormat selects the shape of a generated description
+type DescriptionFormat string
+
+const (
+	// DescriptionDetailed is a detailed, multi-sentence description (default)
+	DescriptionDetailed DescriptionFormat = "detailed"
+
+	// DescriptionOneLine is a single-sentence caption
+	DescriptionOneLine DescriptionFormat = "one-line"
+)
+
+// DescriptionStyle controls the tone, length and content of descriptions.
+// Zero values leave the corresponding aspect to the model.
+type DescriptionStyle struct {
+	Format        DescriptionFormat `json:"format,omitempty"`         // One-line caption or detailed description
+	MaxWords      int               `json:"max_words,omitempty"`      // Upper bound on the description length
+	Tone          string            `json:"tone,omitempty"`           // e.g. neutral, friendly, formal, playful
+	Audience      string            `json:"audience,omitempty"`       // e.g. children, screen reader users, art buyers
+	IncludeColors bool              `json:"include_colors,omitempty"` // Mention the main colors
+	NoSpeculation bool              `json:"no_speculation,omitempty"` // Only describe what is visible
 }
 
 // Feature represents a detection feature type
@@ -220,6 +434,14 @@ const (
 
 	// FeatureSafeSearch detects adult/violent content
 	FeatureSafeSearch Feature = "safesearch"
+
+	// FeatureSynthetic estimates the likelihood that the image is
+	// AI-generated (see SyntheticAssessment)
+	FeatureSynthetic Feature = "synthetic"
+
+	// FeatureWatermark estimates the likelihood that the image carries a
+	// third-party watermark or stock overlay (see WatermarkAssessment)
+	FeatureWatermark Feature = "watermark"
 )
 
 // String returns the string representation of a Feature
@@ -257,15 +479,36 @@ func ResolveProviderAlias(name string) string {
 		return "ollama"
 	case "gemma3", "gemma-3":
 		return "ollama"
+	case "gcv", "cloud-vision", "google-vision":
+		return "vision" // Google Cloud Vision API
+	case "onnx", "yolov8", "yolo11":
+		return "yolo" // YOLO models on a local inference server
 	default:
 		return name
 	}
 }
 
-// GetProvider returns a provider instance by name
+// GetProvider returns a provider instance by name, built in or added with
+// RegisterProvider. A comma-separated list ("ollama,gemini,aws") returns a
+// ChainProvider falling back from each provider to the next.
 func GetProvider(name string) (Provider, error) {
 	name = strings.ToLower(strings.TrimSpace(name))
 
+	if strings.Contains(name, ",") {
+		chain, err := newChainProvider(name)
+		if err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+
+	if IsOffline() {
+		switch name {
+		case "gemini", "aws", "rekognition", "openai", "gpt4vision", "gpt-4-vision", "vision", "gcv":
+			return nil, NewDetectionError(name, "cloud provider unavailable (use ollama or yolo)", ErrOffline)
+		}
+	}
+
 	switch name {
 	case "gemini":
 		return NewGeminiProvider()
@@ -275,9 +518,73 @@ func GetProvider(name string) (Provider, error) {
 		return NewAWSProvider()
 	case "openai", "gpt4vision", "gpt-4-vision":
 		return NewOpenAIProvider()
+	case "vision", "gcv":
+		return NewVisionProvider()
+	case "yolo", "onnx":
+		return NewYOLOProvider()
 	default:
-		return nil, fmt.Errorf("unknown provider: %s (valid: gemini, google, ollama, aws, openai)", name)
+		if factory, ok := registeredProvider(name); ok {
+			return factory()
+		}
+		valid := strings.Join(append([]string{"gemini", "google", "ollama", "aws", "openai", "vision", "yolo"}, RegisteredProviders()...), ", ")
+		return nil, fmt.Errorf("unknown provider: %s (valid: %s)", name, valid)
+	}
+}
+
+// ConfiguredProviders returns the names of the providers that have their
+// credentials configured, without contacting them: Ollama (unless offline
+// with a remote host), YOLO when IMGX_YOLO_HOST is set, Gemini, OpenAI and
+// Cloud Vision with an API key, and AWS with credentials in the environment
+// or the shared AWS files. In offline mode only the local built-in providers
+// can be returned. Registered providers follow, when their factory succeeds
+// and the provider reports IsConfigured.
+func ConfiguredProviders() []string {
+	var names []string
+	if _, err := NewOllamaProvider(); err == nil {
+		names = append(names, "ollama")
+	}
+	if os.Getenv("IMGX_YOLO_HOST") != "" {
+		if _, err := NewYOLOProvider(); err == nil {
+			names = append(names, "yolo")
+		}
+	}
+	if IsOffline() {
+		return append(names, configuredRegisteredProviders()...)
+	}
+	if os.Getenv("GEMINI_API_KEY") != "" {
+		names = append(names, "gemini")
+	}
+	if os.Getenv("OPENAI_API_KEY") != "" {
+		names = append(names, "openai")
+	}
+	if os.Getenv("GOOGLE_VISION_API_KEY") != "" {
+		names = append(names, "vision")
 	}
+	if awsConfigured() {
+		names = append(names, "aws")
+	}
+	return append(names, configuredRegisteredProviders()...)
+}
+
+// awsConfigured reports whether AWS credentials are set in the environment
+// or a shared credentials/config file exists
+func awsConfigured() bool {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" || os.Getenv("AWS_PROFILE") != "" {
+		return true
+	}
+	files := []string{os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), os.Getenv("AWS_CONFIG_FILE")}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".aws", "credentials"), filepath.Join(home, ".aws", "config"))
+	}
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultDetectOptions returns default detection options
@@ -289,6 +596,36 @@ func DefaultDetectOptions() *DetectOptions {
 	}
 }
 
+// WithModel returns a copy of the options that detects with the Gemini
+// model (see DetectOptions.Model)
+//
+// Example:
+//
+//	opts := detection.DefaultDetectOptions().WithModel("gemini-1.5-pro")
+//	result, err := detection.Detect(ctx, img, "gemini", opts)
+func (o *DetectOptions) WithModel(model string) *DetectOptions {
+	opts := DefaultDetectOptions()
+	if o != nil {
+		*opts = *o
+	}
+	opts.Model = strings.TrimSpace(model)
+	return opts
+}
+
+// Validate checks the options that have a valid range: MinConfidence must be
+// between 0 and 1 and MaxResults must not be negative. Detect and
+// Router.Detect return its error, which matches ErrInvalidOption, before
+// calling a provider.
+func (o *DetectOptions) Validate() error {
+	if !(o.MinConfidence >= 0 && o.MinConfidence <= 1) {
+		return fmt.Errorf("%w: min confidence %v must be between 0 and 1", ErrInvalidOption, o.MinConfidence)
+	}
+	if o.MaxResults < 0 {
+		return fmt.Errorf("%w: max results %d must not be negative", ErrInvalidOption, o.MaxResults)
+	}
+	return nil
+}
+
 // --- Shared parsing helpers -------------------------------------------------
 
 func extractJSONFromMarkdown(text string) string {
@@ -308,12 +645,15 @@ func extractJSONFromMarkdown(text string) string {
 	return strings.TrimSpace(text)
 }
 
+// toFloat32 reads a number or numeric string; NaN and values beyond float32
+// are rejected so that results stay encodable as JSON
 func toFloat32(value interface{}) (float32, bool) {
 	switch v := value.(type) {
 	case float32:
-		return v, true
+		return v, !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
 	case float64:
-		return float32(v), true
+		f := float32(v)
+		return f, !math.IsNaN(v) && !math.IsInf(float64(f), 0)
 	case int:
 		return float32(v), true
 	case int32:
@@ -325,7 +665,7 @@ func toFloat32(value interface{}) (float32, bool) {
 		if clean == "" {
 			return 0, false
 		}
-		if parsed, err := strconv.ParseFloat(clean, 32); err == nil {
+		if parsed, err := strconv.ParseFloat(clean, 32); err == nil && !math.IsNaN(parsed) && !math.IsInf(parsed, 0) {
 			return float32(parsed), true
 		}
 	}
@@ -415,6 +755,38 @@ func parseImageQualityFromInterface(value interface{}) *ImageQuality {
 	return quality
 }
 
+func parseFacesFromInterface(value interface{}) []Face {
+	rawSlice, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	faces := make([]Face, 0, len(rawSlice))
+	for _, item := range rawSlice {
+		elem, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		face := Face{Confidence: 1}
+		if confidence, ok := toFloat32(elem["confidence"]); ok {
+			face.Confidence = confidence
+		}
+		if eyesOpen, ok := elem["eyes_open"].(bool); ok {
+			face.EyesOpen = &eyesOpen
+		}
+		if box, ok := elem["bounding_box"].(map[string]interface{}); ok {
+			b := &Box{}
+			b.X, _ = toFloat32(box["x"])
+			b.Y, _ = toFloat32(box["y"])
+			b.Width, _ = toFloat32(box["width"])
+			b.Height, _ = toFloat32(box["height"])
+			face.BoundingBox = b
+		}
+		faces = append(faces, face)
+	}
+	return faces
+}
+
 func parseColorsFromInterface(value interface{}) []ColorInfo {
 	rawSlice, ok := value.([]interface{})
 	if !ok {
@@ -550,6 +922,9 @@ func parseSafeSearchFromInterface(value interface{}) *SafeSearchSummary {
 // All three LLM providers (Ollama, Gemini, OpenAI) delegate to this function.
 func buildDetectionPrompt(opts *DetectOptions) string {
 	if opts.CustomPrompt != "" {
+		if opts.ResponseSchema != nil {
+			return opts.CustomPrompt + "\n\n" + opts.ResponseSchema.prompt()
+		}
 		return opts.CustomPrompt
 	}
 
@@ -557,26 +932,45 @@ func buildDetectionPrompt(opts *DetectOptions) string {
 
 	for _, feature := range opts.Features {
 		switch feature {
-		case FeatureLabels, FeatureObjects:
+		case FeatureLabels:
 			prompts = append(prompts, fmt.Sprintf(
 				"Identify all objects in this image and provide labels with confidence scores (0.0-1.0). "+
 					"Return JSON: {\"labels\": [{\"name\": \"object\", \"confidence\": 0.95}]}. "+
 					"Return at most %d labels with confidence >= %.2f.",
 				opts.MaxResults, opts.MinConfidence,
 			))
+		case FeatureObjects:
+			prompts = append(prompts, fmt.Sprintf(
+				"Locate each distinct object in this image with a tight bounding box. "+
+					"Return JSON: {\"bounding_boxes\": [{\"label\": \"dog\", \"confidence\": 0.95, \"box_2d\": [ymin, xmin, ymax, xmax]}]} "+
+					"with box_2d coordinates normalized to 0-1000. "+
+					"Return at most %d objects with confidence >= %.2f.",
+				opts.MaxResults, opts.MinConfidence,
+			))
 		case FeatureDescription:
-			prompts = append(prompts, "Provide a detailed description of this image.")
+			prompts = append(prompts, buildDescriptionPrompt(opts.DescriptionStyle))
 		case FeatureText:
 			prompts = append(prompts, "Extract all visible text from this image. "+
 				"Return JSON: {\"text\": [{\"text\": \"extracted text\", \"confidence\": 0.95}]}")
 		case FeatureFaces:
-			prompts = append(prompts, "Detect any faces and describe their count, expressions, and emotions.")
+			prompts = append(prompts, "Detect any faces and describe their count, expressions, and emotions. "+
+				"Return JSON: {\"faces\": [{\"confidence\": 0.95, \"eyes_open\": true}]}")
 		case FeatureProperties:
 			prompts = append(prompts, "Analyze image properties: dominant colors, lighting, mood, style.")
 		case FeatureLandmarks:
 			prompts = append(prompts, "Identify any landmarks, monuments, or famous locations.")
 		case FeatureSafeSearch:
 			prompts = append(prompts, "Analyze if the image contains any adult, violent, or inappropriate content.")
+		case FeatureSynthetic:
+			prompts = append(prompts, "Assess whether this image was generated or heavily edited by AI "+
+				"(e.g. malformed hands or text, inconsistent lighting or reflections, overly smooth textures). "+
+				"Return JSON: {\"synthetic\": {\"likelihood\": 0.15, \"reason\": \"short explanation\"}} "+
+				"where likelihood is 0.0 for a camera photo and 1.0 for certainly AI-generated.")
+		case FeatureWatermark:
+			prompts = append(prompts, "Check whether this image carries a third-party watermark or stock photo overlay "+
+				"(e.g. agency names like Shutterstock or Getty Images, repeated semi-transparent logos or text, copyright notices). "+
+				"Return JSON: {\"watermark\": {\"likelihood\": 0.05, \"text\": \"watermark text, if any\", \"reason\": \"short explanation\"}} "+
+				"where likelihood is 0.0 for no watermark and 1.0 for certainly watermarked.")
 		}
 	}
 
@@ -592,79 +986,84 @@ func buildDetectionPrompt(opts *DetectOptions) string {
 	return strings.Join(prompts, "\n\n")
 }
 
+// buildDescriptionPrompt returns the instruction for FeatureDescription
+func buildDescriptionPrompt(style *DescriptionStyle) string {
+	if style == nil {
+		return "Provide a detailed description of this image."
+	}
+
+	var b strings.Builder
+	if style.Format == DescriptionOneLine {
+		b.WriteString("Describe this image in a single sentence.")
+	} else {
+		b.WriteString("Provide a detailed description of this image.")
+	}
+	if style.MaxWords > 0 {
+		fmt.Fprintf(&b, " Use at most %d words.", style.MaxWords)
+	}
+	if style.Tone != "" {
+		fmt.Fprintf(&b, " Write in a %s tone.", style.Tone)
+	}
+	if style.Audience != "" {
+		fmt.Fprintf(&b, " The audience is %s.", style.Audience)
+	}
+	if style.IncludeColors {
+		b.WriteString(" Mention the main colors.")
+	}
+	if style.NoSpeculation {
+		b.WriteString(" Only describe what is visible; do not guess identities, emotions, intentions or locations.")
+	}
+	b.WriteString(" Put it in the `description` key.")
+	return b.String()
+}
+
 // --- H1b: Shared JSON response parser -----------------------------------------
 
 // parseJSONDetectionResponse parses a JSON string into result.
 // It handles all fields including Ollama-specific label fields (Score, MID,
 // Categories, TopicID). Gemini, Ollama, and OpenAI all delegate to this.
+// Responses that drift from the requested shape are coerced to it (see
+// detectionResponseVersion) with a warning, and unknown keys are kept in
+// result.Properties.
 func parseJSONDetectionResponse(text string, result *DetectionResult) error {
 	text = extractJSONFromMarkdown(text)
 
-	var raw map[string]interface{}
-	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
 		return err
 	}
+	var coercions responseCoercions
+	raw, unknown := normalizeDetectionResponse(decoded, &coercions)
+	if raw == nil {
+		return fmt.Errorf("response is a JSON %T, not an object", decoded)
+	}
 
 	if provider, ok := raw["provider"].(string); ok {
 		result.Provider = provider
 	}
 
-	if labels, ok := raw["labels"].([]interface{}); ok {
-		for _, item := range labels {
-			if labelMap, ok := item.(map[string]interface{}); ok {
-				label := Label{}
-				if name, ok := labelMap["name"].(string); ok {
-					label.Name = name
-				}
-				if confidence, ok := toFloat32(labelMap["confidence"]); ok {
-					label.Confidence = confidence
-				}
-				// Ollama / extended fields
-				if score, ok := toFloat32(labelMap["score"]); ok {
-					label.Score = score
-				}
-				if mid, ok := labelMap["mid"].(string); ok {
-					label.MID = mid
-				}
-				if categories, ok := labelMap["categories"].([]interface{}); ok {
-					for _, c := range categories {
-						if s, ok := c.(string); ok {
-							label.Categories = append(label.Categories, s)
-						}
-					}
-				}
-				if topicID, ok := labelMap["topic_id"].(string); ok {
-					label.TopicID = topicID
-				}
-				if label.Name != "" {
-					result.Labels = append(result.Labels, label)
-				}
-			}
+	result.Labels = append(result.Labels, parseLabelsFromInterface(raw["labels"], &coercions)...)
+
+	boxes := parseBoundingBoxesFromInterface(raw["bounding_boxes"], &coercions)
+	if len(boxes) == 0 {
+		// Labels that carry boxes, e.g. {"objects": [{"label": ..., "box_2d": ...}]}
+		boxes = parseBoundingBoxesFromInterface(raw["labels"], &coercions)
+	}
+	if len(boxes) > 0 {
+		result.BoundingBoxes = append(result.BoundingBoxes, boxes...)
+		if len(result.Labels) == 0 {
+			result.Labels = labelsFromBoxes(boxes)
 		}
 	}
 
-	if description, ok := raw["description"].(string); ok {
+	if description := parseDescriptionFromInterface(raw["description"], &coercions); description != "" {
 		result.Description = description
 	}
 
-	if textBlocks, ok := raw["text"].([]interface{}); ok {
-		for _, item := range textBlocks {
-			if textMap, ok := item.(map[string]interface{}); ok {
-				block := TextBlock{}
-				if textValue, ok := textMap["text"].(string); ok {
-					block.Text = textValue
-				}
-				if confidence, ok := toFloat32(textMap["confidence"]); ok {
-					block.Confidence = confidence
-				}
-				if language, ok := textMap["language"].(string); ok {
-					block.Language = language
-				}
-				if block.Text != "" {
-					result.Text = append(result.Text, block)
-				}
-			}
-		}
+	result.Text = append(result.Text, parseTextBlocksFromInterface(raw["text"], &coercions)...)
+
+	if faces := parseFacesFromInterface(raw["faces"]); len(faces) > 0 {
+		result.Faces = append(result.Faces, faces...)
 	}
 
 	if colors := parseColorsFromInterface(raw["colors"]); len(colors) > 0 {
@@ -691,9 +1090,20 @@ func parseJSONDetectionResponse(text string, result *DetectionResult) error {
 		result.SafeSearch = safe
 	}
 
+	if synthetic := parseSyntheticFromInterface(raw["synthetic"]); synthetic != nil {
+		result.Synthetic = synthetic
+	}
+
+	if watermark := parseWatermarkFromInterface(raw["watermark"]); watermark != nil {
+		result.Watermark = watermark
+	}
+
 	if propsVal, ok := raw["properties"]; ok {
 		result.Properties = parsePropertiesFromInterface(result.Properties, propsVal)
 	}
+	if len(unknown) > 0 {
+		result.Properties = parsePropertiesFromInterface(result.Properties, unknown)
+	}
 
 	if confidence, ok := toFloat32(raw["confidence"]); ok {
 		result.Confidence = confidence
@@ -705,6 +1115,10 @@ func parseJSONDetectionResponse(text string, result *DetectionResult) error {
 		result.Confidence = total / float32(len(result.Labels))
 	}
 
+	if len(coercions) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("response JSON differs from version %d of the requested shape: %s",
+			detectionResponseVersion, strings.Join(coercions, "; ")))
+	}
 	return nil
 }
 
@@ -767,7 +1181,7 @@ func extractLabelsFromPlainText(text string, opts *DetectOptions) []Label {
 		}
 
 		name := line
-		confidence := float32(0.7)
+		confidence := float32(unscoredConfidence)
 
 		// Parse trailing confidence annotation, e.g. "cat (92%)" or "cat (0.92)"
 		if idx := strings.LastIndex(line, "("); idx != -1 && strings.HasSuffix(line, ")") {
@@ -810,7 +1224,7 @@ func extractLabelsFromPlainText(text string, opts *DetectOptions) []Label {
 				key := obj
 				if _, exists := added[key]; !exists {
 					added[key] = struct{}{}
-					labels = append(labels, Label{Name: obj, Confidence: 0.7})
+					labels = append(labels, Label{Name: obj, Confidence: unscoredConfidence})
 					if len(labels) >= opts.MaxResults {
 						return labels
 					}
@@ -843,11 +1257,23 @@ func parseTextResponse(responseText string, opts *DetectOptions) *DetectionResul
 		return result
 	}
 
+	if opts.CustomPrompt != "" && opts.ResponseSchema != nil {
+		result.RawStructured, result.SchemaErrors = opts.ResponseSchema.parse(responseText)
+		if result.RawStructured == nil {
+			result.Description = responseText
+		}
+		if len(result.SchemaErrors) > 0 {
+			result.Warnings = append(result.Warnings, "response does not match the response schema")
+		}
+		return result
+	}
+
 	if err := parseJSONDetectionResponse(responseText, result); err == nil {
 		return result
 	}
 
 	// Fallback: treat full response as description and extract labels heuristically
+	result.Warnings = append(result.Warnings, "response was not valid JSON, labels were extracted from plain text")
 	result.Description = responseText
 	labels := extractLabelsFromPlainText(responseText, opts)
 	result.Labels = append(result.Labels, labels...)
@@ -1,8 +1,17 @@
 package detection
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -349,6 +358,41 @@ func TestDefaultDetectOptions(t *testing.T) {
 	}
 }
 
+// TestDetectOptionsValidate tests the range checks of DetectOptions.Validate
+func TestDetectOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    DetectOptions
+		wantErr bool
+	}{
+		{"defaults", *DefaultDetectOptions(), false},
+		{"zero value", DetectOptions{}, false},
+		{"confidence 1", DetectOptions{MinConfidence: 1}, false},
+		{"negative confidence", DetectOptions{MinConfidence: -0.1}, true},
+		{"confidence above 1", DetectOptions{MinConfidence: 50}, true},
+		{"NaN confidence", DetectOptions{MinConfidence: float32(math.NaN())}, true},
+		{"negative max results", DetectOptions{MaxResults: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("Validate() error = %v, want ErrInvalidOption", err)
+			}
+		})
+	}
+
+	// Detect rejects the options before looking up the provider
+	_, err := Detect(context.Background(), image.NewNRGBA(image.Rect(0, 0, 1, 1)), "no-such-provider", &DetectOptions{MinConfidence: 2})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Detect() error = %v, want ErrInvalidOption", err)
+	}
+}
+
 // TestFeatureString tests Feature.String() method
 func TestFeatureString(t *testing.T) {
 	tests := []struct {
@@ -620,6 +664,7 @@ func TestDetectOptionsJSON(t *testing.T) {
 		CustomPrompt:       "What is in this image?",
 		Language:           "en",
 		IncludeRawResponse: true,
+		DescriptionStyle:   &DescriptionStyle{Format: DescriptionOneLine, MaxWords: 30, Tone: "formal"},
 	}
 
 	data, err := json.Marshal(opts)
@@ -669,6 +714,21 @@ func TestBuildDetectionPrompt(t *testing.T) {
 			},
 			contains: []string{"description"},
 		},
+		{
+			name: "description style",
+			opts: &DetectOptions{
+				Features: []Feature{FeatureDescription},
+				DescriptionStyle: &DescriptionStyle{
+					Format:        DescriptionOneLine,
+					MaxWords:      20,
+					Tone:          "friendly",
+					Audience:      "children",
+					NoSpeculation: true,
+				},
+			},
+			contains:    []string{"single sentence", "at most 20 words", "friendly tone", "audience is children", "only describe what is visible"},
+			notContains: []string{"detailed", "main colors"},
+		},
 		{
 			name: "text feature",
 			opts: &DetectOptions{
@@ -713,6 +773,11 @@ func TestBuildDetectionPrompt(t *testing.T) {
 					t.Errorf("buildDetectionPrompt() result does not contain %q; got: %q", want, result)
 				}
 			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(lower, strings.ToLower(unwanted)) {
+					t.Errorf("buildDetectionPrompt() result contains %q; got: %q", unwanted, result)
+				}
+			}
 		})
 	}
 }
@@ -747,6 +812,26 @@ func TestParseJSONDetectionResponse(t *testing.T) {
 		}
 	})
 
+	t.Run("faces with eyes open", func(t *testing.T) {
+		input := `{"faces":[{"confidence":0.9,"eyes_open":true,"bounding_box":{"x":0.1,"y":0.2,"width":0.3,"height":0.4}},{"eyes_open":false}]}`
+		result := &DetectionResult{Labels: []Label{}, Text: []TextBlock{}, Properties: make(map[string]string)}
+		if err := parseJSONDetectionResponse(input, result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Faces) != 2 {
+			t.Fatalf("expected 2 faces, got %d", len(result.Faces))
+		}
+		if result.Faces[0].EyesOpen == nil || !*result.Faces[0].EyesOpen {
+			t.Errorf("Faces[0].EyesOpen = %v, want true", result.Faces[0].EyesOpen)
+		}
+		if result.Faces[1].EyesOpen == nil || *result.Faces[1].EyesOpen {
+			t.Errorf("Faces[1].EyesOpen = %v, want false", result.Faces[1].EyesOpen)
+		}
+		if box := result.Faces[0].BoundingBox; box == nil || math.Abs(float64(box.Width)-0.3) > 1e-5 {
+			t.Errorf("Faces[0].BoundingBox = %+v, want width 0.3", box)
+		}
+	})
+
 	t.Run("ollama extended fields", func(t *testing.T) {
 		input := `{"labels":[{"name":"cat","confidence":0.9,"score":0.85,"mid":"/m/01yrx","categories":["animal"],"topic_id":"123"}]}`
 		result := &DetectionResult{Labels: []Label{}, Text: []TextBlock{}, Properties: make(map[string]string)}
@@ -889,6 +974,9 @@ func TestParseTextResponse(t *testing.T) {
 		if result.Description != "A cat" {
 			t.Errorf("Description = %q, want %q", result.Description, "A cat")
 		}
+		if len(result.Warnings) != 0 {
+			t.Errorf("Warnings = %v, want none", result.Warnings)
+		}
 	})
 
 	t.Run("plain text input", func(t *testing.T) {
@@ -904,6 +992,9 @@ func TestParseTextResponse(t *testing.T) {
 		if result.Description != responseText {
 			t.Errorf("Description = %q, want %q", result.Description, responseText)
 		}
+		if len(result.Warnings) != 1 {
+			t.Errorf("expected a fallback warning, got %v", result.Warnings)
+		}
 	})
 
 	t.Run("empty input", func(t *testing.T) {
@@ -917,3 +1008,173 @@ func TestParseTextResponse(t *testing.T) {
 		}
 	})
 }
+
+// TestConfiguredProviders tests provider availability from the environment
+func TestConfiguredProviders(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, env := range []string{"GEMINI_API_KEY", "OPENAI_API_KEY", "GOOGLE_VISION_API_KEY", "AWS_ACCESS_KEY_ID", "AWS_PROFILE", "AWS_SHARED_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "IMGX_OLLAMA_HOST", "OLLAMA_HOST", "IMGX_YOLO_HOST"} {
+		t.Setenv(env, "")
+	}
+
+	if got := ConfiguredProviders(); !reflect.DeepEqual(got, []string{"ollama"}) {
+		t.Errorf("ConfiguredProviders() = %v, want [ollama]", got)
+	}
+
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_VISION_API_KEY", "test-key")
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte("[default]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ConfiguredProviders(), []string{"ollama", "gemini", "openai", "vision", "aws"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfiguredProviders() = %v, want %v", got, want)
+	}
+
+	t.Setenv("IMGX_YOLO_HOST", "127.0.0.1:8000")
+	if got, want := ConfiguredProviders(), []string{"ollama", "yolo", "gemini", "openai", "vision", "aws"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfiguredProviders() with IMGX_YOLO_HOST = %v, want %v", got, want)
+	}
+	t.Setenv("IMGX_YOLO_HOST", "")
+
+	SetOffline(true)
+	defer SetOffline(false)
+	t.Setenv("IMGX_OLLAMA_HOST", "gpu-box.internal:11434")
+	if got := ConfiguredProviders(); len(got) != 0 {
+		t.Errorf("ConfiguredProviders() offline with a remote Ollama = %v, want none", got)
+	}
+}
+
+// TestFilterBoxes tests filtering bounding boxes by label and confidence
+func TestFilterBoxes(t *testing.T) {
+	result := &DetectionResult{BoundingBoxes: []BoundingBox{
+		{Label: "Person", Confidence: 0.9},
+		{Label: "car", Confidence: 0.4},
+		{Label: "car", Confidence: 0.8},
+		{Label: "dog", Confidence: 0.95},
+	}}
+
+	boxes := result.FilterBoxes([]string{"person", " Car"}, 0.5)
+	if len(boxes) != 2 || boxes[0].Label != "Person" || boxes[1].Confidence != 0.8 {
+		t.Errorf("FilterBoxes(person,car) = %+v", boxes)
+	}
+	if boxes := result.FilterBoxes(nil, 0); len(boxes) != 4 {
+		t.Errorf("FilterBoxes(nil) = %d boxes, want all 4", len(boxes))
+	}
+}
+
+// TestRegions tests locating boxes, faces, text, landmarks and logos in
+// pixel coordinates
+func TestRegions(t *testing.T) {
+	result := &DetectionResult{
+		BoundingBoxes: []BoundingBox{{Label: "dog", Confidence: 0.9, Box: Box{X: 0.1, Y: 0.2, Width: 0.5, Height: 0.5}}},
+		Faces: []Face{
+			{Confidence: 0.8, BoundingBox: &Box{X: 0.5, Y: 0, Width: 0.25, Height: 0.25}},
+			{Confidence: 0.7}, // No location
+		},
+		Text:      []TextBlock{{Text: "EXIT", Confidence: 0.95, BoundingBox: &Box{X: 0.9, Y: 0.9, Width: 0.5, Height: 0.5}}},
+		Landmarks: []EntityAnnotation{{Name: "Eiffel Tower", Confidence: 0.6, BoundingBox: &Box{X: 2, Y: 2, Width: 0.1, Height: 0.1}}},
+		Logos:     []EntityAnnotation{{Name: "Acme", Confidence: 0.5, BoundingBox: &Box{X: 0, Y: 0, Width: 0.1, Height: 0.1}}},
+	}
+
+	want := []Region{
+		{Kind: RegionObject, Label: "dog", Confidence: 0.9, Rect: image.Rect(20, 20, 120, 70)},
+		{Kind: RegionFace, Label: "face", Confidence: 0.8, Rect: image.Rect(100, 0, 150, 25)},
+		{Kind: RegionText, Label: "EXIT", Confidence: 0.95, Rect: image.Rect(180, 90, 200, 100)},
+		{Kind: RegionLogo, Label: "Acme", Confidence: 0.5, Rect: image.Rect(0, 0, 20, 10)},
+	}
+	if got := result.Regions(200, 100); !reflect.DeepEqual(got, want) {
+		t.Errorf("Regions() = %+v, want %+v", got, want)
+	}
+}
+
+// TestDetectRegions tests detecting regions of an image not at the origin
+// with a YOLO stub
+func TestDetectRegions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A dog in the middle of the 640x640 tensor
+		output := yoloV8Output(len(cocoClassNames), [][6]float32{{320, 320, 320, 320, 16, 0.9}})
+		json.NewEncoder(w).Encode(&yoloInferResponse{Outputs: []yoloTensor{output}})
+	}))
+	defer server.Close()
+	t.Setenv("IMGX_YOLO_HOST", server.URL)
+	t.Setenv("IMGX_YOLO_MODEL", "")
+	ctx := context.Background()
+	img := image.NewNRGBA(image.Rect(100, 100, 164, 164))
+
+	regions, err := DetectRegions(ctx, img, "yolo", RegionObject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 1 || regions[0].Label != "dog" || regions[0].Rect != image.Rect(116, 116, 148, 148) {
+		t.Errorf("DetectRegions(object) = %+v", regions)
+	}
+	// YOLO finds no faces
+	if regions, err := DetectRegions(ctx, img, "yolo", RegionFace, RegionObject, RegionFace); err != nil || len(regions) != 1 {
+		t.Errorf("DetectRegions(face, object) = %+v, %v", regions, err)
+	}
+	if _, err := DetectRegions(ctx, img, "yolo", "plate"); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("DetectRegions(plate) error = %v, want ErrInvalidOption", err)
+	}
+}
+
+// TestDetectImageAndReader tests detection on an image.Image and on encoded
+// image data, using a YOLO server stub that checks the image it receives
+func TestDetectImageAndReader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req yoloInferRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The 64x64 image fills the whole 640x640 tensor
+		if got := req.Inputs[0].Data[320*640+320]; math.Abs(float64(got)-1) > 1e-3 {
+			t.Errorf("image red value = %v, want 1", got)
+		}
+		json.NewEncoder(w).Encode(&yoloInferResponse{Outputs: []yoloTensor{yoloV8Output(len(cocoClassNames), nil)}})
+	}))
+	defer server.Close()
+	t.Setenv("IMGX_YOLO_HOST", server.URL)
+	t.Setenv("IMGX_YOLO_MODEL", "")
+	ctx := context.Background()
+
+	// An RGBA image not at the origin is converted
+	rgba := image.NewRGBA(image.Rect(10, 10, 74, 74))
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		rgba.Pix[i], rgba.Pix[i+3] = 255, 255
+	}
+	result, err := DetectImage(ctx, rgba, "yolo")
+	if err != nil {
+		t.Fatalf("DetectImage() error = %v", err)
+	}
+	if result.Provider != "yolo" {
+		t.Errorf("DetectImage() provider = %q, want yolo", result.Provider)
+	}
+	if _, err := DetectImage(ctx, nil, "yolo"); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("DetectImage(nil) error = %v, want ErrInvalidImage", err)
+	}
+
+	var buf strings.Builder
+	if err := png.Encode(&buf, CreateTestImage(64, 64, color.NRGBA{R: 255, A: 255})); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultDetectOptions()
+	opts.Features = []Feature{FeatureObjects, FeatureSynthetic}
+	result, err = DetectReader(ctx, strings.NewReader(buf.String()), "yolo", opts)
+	if err != nil {
+		t.Fatalf("DetectReader() error = %v", err)
+	}
+	if result.Synthetic == nil {
+		t.Error("DetectReader() with FeatureSynthetic has no synthetic assessment")
+	}
+	if opts.Source != nil {
+		t.Error("DetectReader() modified the options")
+	}
+	if _, err := DetectReader(ctx, strings.NewReader("not an image"), "yolo"); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("DetectReader(invalid) error = %v, want ErrInvalidImage", err)
+	}
+}
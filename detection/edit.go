@@ -0,0 +1,63 @@
+package detection
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// ImageEditor is implemented by providers that can edit an image from a
+// natural language instruction (Gemini, OpenAI)
+type ImageEditor interface {
+	// EditImage applies instruction to img and returns the edited image
+	EditImage(ctx context.Context, img *image.NRGBA, instruction string) (*image.NRGBA, error)
+
+	// Name returns the provider name
+	Name() string
+
+	// IsConfigured returns true if provider has required credentials
+	IsConfigured() bool
+}
+
+// Compile-time checks for providers that support image editing
+var (
+	_ ImageEditor = (*GeminiProvider)(nil)
+	_ ImageEditor = (*OpenAIProvider)(nil)
+)
+
+// Transform edits an image following a natural language instruction such as
+// "remove the power lines" or "make the sky bluer", using the image editing
+// models of Gemini or OpenAI. It uses the same credentials as detection.
+//
+// The edited image may not have exactly the size of the input: models work
+// at fixed resolutions. Resize the result if needed.
+//
+// Example:
+//
+//	img, _ := imgx.Load("street.jpg")
+//	edited, err := detection.Transform(ctx, img.ToNRGBA(), "gemini", "remove the power lines")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	result := imgx.FromImage(edited)
+func Transform(ctx context.Context, img *image.NRGBA, provider, instruction string) (*image.NRGBA, error) {
+	if strings.TrimSpace(instruction) == "" {
+		return nil, fmt.Errorf("instruction is empty")
+	}
+
+	prov, err := GetProvider(ResolveProviderAlias(provider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get detection provider: %w", err)
+	}
+	editor, ok := prov.(ImageEditor)
+	if !ok {
+		return nil, NewDetectionError(prov.Name(), "provider does not support image editing (use gemini or openai)", ErrInvalidFeature)
+	}
+
+	edited, err := editor.EditImage(ctx, img, instruction)
+	if err != nil {
+		return nil, fmt.Errorf("transform failed: %w", err)
+	}
+	return edited, nil
+}
@@ -0,0 +1,126 @@
+package detection
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"google.golang.org/genai"
+)
+
+func encodeTestPNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestDecodeImageBytes tests decoding of provider image output
+func TestDecodeImageBytes(t *testing.T) {
+	src := createTestImage(8, 6, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	got, err := decodeImageBytes(encodeTestPNG(t, src))
+	if err != nil {
+		t.Fatalf("decodeImageBytes(png) error: %v", err)
+	}
+	if got.Bounds() != src.Bounds() || got.NRGBAAt(3, 3) != src.NRGBAAt(3, 3) {
+		t.Errorf("decodeImageBytes(png) = %v %v, want %v %v", got.Bounds(), got.NRGBAAt(3, 3), src.Bounds(), src.NRGBAAt(3, 3))
+	}
+
+	jpegBytes, err := imageToJPEGBytes(src)
+	if err != nil {
+		t.Fatalf("imageToJPEGBytes() error: %v", err)
+	}
+	if got, err := decodeImageBytes(jpegBytes); err != nil || got.Bounds() != src.Bounds() {
+		t.Errorf("decodeImageBytes(jpeg) = %v, %v", got, err)
+	}
+
+	if _, err := decodeImageBytes([]byte("not an image")); err == nil {
+		t.Error("decodeImageBytes() with invalid data should fail")
+	}
+}
+
+// TestDecodeGeminiImage tests image extraction from Gemini responses
+func TestDecodeGeminiImage(t *testing.T) {
+	src := createTestImage(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{
+		{Text: "Here is the edited image."},
+		{InlineData: &genai.Blob{Data: encodeTestPNG(t, src), MIMEType: "image/png"}},
+	}}}}}
+	got, err := decodeGeminiImage(resp)
+	if err != nil {
+		t.Fatalf("decodeGeminiImage() error: %v", err)
+	}
+	if got.NRGBAAt(1, 1) != src.NRGBAAt(1, 1) {
+		t.Errorf("decodeGeminiImage() pixel = %v, want %v", got.NRGBAAt(1, 1), src.NRGBAAt(1, 1))
+	}
+
+	refusal := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{
+		{Text: "I can't edit this image."},
+	}}}}}
+	if _, err := decodeGeminiImage(refusal); err == nil || !strings.Contains(err.Error(), "I can't edit this image.") {
+		t.Errorf("decodeGeminiImage() refusal error = %v, want the model text", err)
+	}
+	if _, err := decodeGeminiImage(&genai.GenerateContentResponse{}); !errors.Is(err, ErrAPIError) {
+		t.Errorf("decodeGeminiImage() empty error = %v, want ErrAPIError", err)
+	}
+}
+
+// TestOpenAIProviderEditImage tests the image edit request against a fake API
+func TestOpenAIProviderEditImage(t *testing.T) {
+	edited := createTestImage(16, 16, color.NRGBA{R: 0, G: 0, B: 255, A: 255})
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/edits" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		prompt = r.FormValue("prompt")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"created": 1, "data": [{"b64_json": %q}]}`, base64.StdEncoding.EncodeToString(encodeTestPNG(t, edited)))
+	}))
+	defer server.Close()
+
+	client := openai.NewClient(option.WithAPIKey("test"), option.WithBaseURL(server.URL))
+	provider := &OpenAIProvider{client: &client}
+
+	src := createTestImage(8, 8, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+	got, err := provider.EditImage(context.Background(), src, "make it blue")
+	if err != nil {
+		t.Fatalf("EditImage() error: %v", err)
+	}
+	if prompt != "make it blue" {
+		t.Errorf("request prompt = %q, want %q", prompt, "make it blue")
+	}
+	if got.Bounds().Dx() != 16 || got.NRGBAAt(0, 0) != edited.NRGBAAt(0, 0) {
+		t.Errorf("EditImage() = %v %v, want the edited image", got.Bounds(), got.NRGBAAt(0, 0))
+	}
+}
+
+// TestTransformValidation tests Transform argument errors
+func TestTransformValidation(t *testing.T) {
+	img := createTestImage(4, 4, color.NRGBA{A: 255})
+	if _, err := Transform(context.Background(), img, "gemini", "  "); err == nil {
+		t.Error("Transform() with an empty instruction should fail")
+	}
+	if _, err := Transform(context.Background(), img, "unknown", "make it blue"); err == nil {
+		t.Error("Transform() with an unknown provider should fail")
+	}
+}
@@ -1,8 +1,11 @@
 package detection
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 )
 
 // DetectionError wraps provider-specific errors
@@ -55,8 +58,20 @@ var (
 	// ErrInvalidImage indicates the image data is invalid
 	ErrInvalidImage = errors.New("invalid image data")
 
+	// ErrNoObjects indicates that no detected object matched a crop request
+	ErrNoObjects = errors.New("no matching objects detected")
+
 	// ErrContextCanceled indicates the context was canceled
 	ErrContextCanceled = errors.New("detection canceled by context")
+
+	// ErrOffline indicates a network call was attempted in offline mode
+	ErrOffline = errors.New("network access disabled (offline mode)")
+
+	// ErrInvalidOption indicates a DetectOptions value outside its valid range
+	ErrInvalidOption = errors.New("invalid detection option")
+
+	// ErrSchemaMismatch indicates a custom-prompt response did not match its ResponseSchema
+	ErrSchemaMismatch = errors.New("response does not match the response schema")
 )
 
 // NewDetectionError creates a new DetectionError
@@ -78,6 +93,38 @@ func IsRateLimit(err error) bool {
 	return errors.Is(err, ErrRateLimit)
 }
 
+// IsRetryable checks if a detection error is likely temporary: rate limits,
+// provider API errors and network errors. Context cancellation is not
+// retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimit) || errors.Is(err, ErrAPIError) || errors.Is(err, ErrNetworkError) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// httpStatusError maps the HTTP status of a failed provider response to a
+// detection error: ErrRateLimit for 429, ErrAPIError for server errors and
+// nil otherwise
+func httpStatusError(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case status >= 500:
+		return ErrAPIError
+	}
+	return nil
+}
+
+// IsOfflineError checks if error is ErrOffline
+func IsOfflineError(err error) bool {
+	return errors.Is(err, ErrOffline)
+}
+
 // IsInvalidAPIKey checks if error is ErrInvalidAPIKey
 func IsInvalidAPIKey(err error) bool {
 	return errors.Is(err, ErrInvalidAPIKey)
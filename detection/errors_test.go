@@ -1,8 +1,10 @@
 package detection
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"testing"
 )
@@ -175,6 +177,7 @@ func TestErrorConstants(t *testing.T) {
 		{"ErrNetworkError", ErrNetworkError},
 		{"ErrInvalidImage", ErrInvalidImage},
 		{"ErrContextCanceled", ErrContextCanceled},
+		{"ErrInvalidOption", ErrInvalidOption},
 	}
 
 	for _, c := range constants {
@@ -296,6 +299,31 @@ func TestIsRateLimit(t *testing.T) {
 	}
 }
 
+// TestIsRetryable tests IsRetryable helper function
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", NewDetectionError("gemini", "rate limited", ErrRateLimit), true},
+		{"api error", NewDetectionError("yolo", "server returned 503", ErrAPIError), true},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"invalid api key", NewDetectionError("openai", "unauthorized", ErrInvalidAPIKey), false},
+		{"canceled", fmt.Errorf("request: %w", context.Canceled), false},
+		{"generic error", errors.New("some error"), false},
+		{"nil error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestIsInvalidAPIKey tests IsInvalidAPIKey helper function
 func TestIsInvalidAPIKey(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,236 @@
+package detection
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// AnnotatedImage is an image file and its detection result, the input of
+// the annotation exporters. Width and Height are the pixel size of the
+// image the result was computed on; the bounding boxes of the result, which
+// are relative to the image size (0.0-1.0), are scaled to it.
+type AnnotatedImage struct {
+	Path   string
+	Width  int
+	Height int
+	Result *DetectionResult
+}
+
+// cocoDataset is the subset of the COCO object detection format written by
+// WriteCOCO
+type cocoDataset struct {
+	Info        cocoInfo         `json:"info"`
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoInfo struct {
+	Description string `json:"description"`
+	DateCreated string `json:"date_created"`
+}
+
+type cocoImage struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoAnnotation struct {
+	ID         int        `json:"id"`
+	ImageID    int        `json:"image_id"`
+	CategoryID int        `json:"category_id"`
+	BBox       [4]float64 `json:"bbox"` // x, y, width, height in pixels
+	Area       float64    `json:"area"`
+	IsCrowd    int        `json:"iscrowd"`
+	Score      float32    `json:"score,omitempty"`
+}
+
+type cocoCategory struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// WriteCOCO writes the bounding boxes of images as a COCO object detection
+// dataset (JSON). Categories are the box labels, numbered from 1 in
+// alphabetical order; the detection confidence is kept as "score". Images
+// without boxes are listed with no annotations.
+//
+// Example:
+//
+//	f, _ := os.Create("annotations.json")
+//	defer f.Close()
+//	err := detection.WriteCOCO(f, []detection.AnnotatedImage{
+//		{Path: "images/street.jpg", Width: 1024, Height: 768, Result: result},
+//	})
+func WriteCOCO(w io.Writer, images []AnnotatedImage) error {
+	dataset := cocoDataset{
+		Info: cocoInfo{
+			Description: "imgx detection export",
+			DateCreated: time.Now().UTC().Format(time.RFC3339),
+		},
+		Images:      []cocoImage{},
+		Annotations: []cocoAnnotation{},
+		Categories:  []cocoCategory{},
+	}
+
+	var names []string
+	for _, img := range images {
+		for _, box := range exportBoxes(img) {
+			if !slices.Contains(names, box.Label) {
+				names = append(names, box.Label)
+			}
+		}
+	}
+	slices.Sort(names)
+	categoryIDs := make(map[string]int, len(names))
+	for i, name := range names {
+		categoryIDs[name] = i + 1
+		dataset.Categories = append(dataset.Categories, cocoCategory{ID: i + 1, Name: name})
+	}
+
+	for i, img := range images {
+		imageID := i + 1
+		dataset.Images = append(dataset.Images, cocoImage{
+			ID:       imageID,
+			FileName: filepath.ToSlash(img.Path),
+			Width:    img.Width,
+			Height:   img.Height,
+		})
+		for _, box := range exportBoxes(img) {
+			x, y, width, height := pixelBox(box.Box, img.Width, img.Height)
+			dataset.Annotations = append(dataset.Annotations, cocoAnnotation{
+				ID:         len(dataset.Annotations) + 1,
+				ImageID:    imageID,
+				CategoryID: categoryIDs[box.Label],
+				BBox:       [4]float64{x, y, width, height},
+				Area:       width * height,
+				Score:      box.Confidence,
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&dataset); err != nil {
+		return fmt.Errorf("failed to write COCO annotations: %w", err)
+	}
+	return nil
+}
+
+// vocAnnotation is a Pascal VOC annotation file
+type vocAnnotation struct {
+	XMLName   xml.Name    `xml:"annotation"`
+	Folder    string      `xml:"folder"`
+	Filename  string      `xml:"filename"`
+	Path      string      `xml:"path"`
+	Source    vocSource   `xml:"source"`
+	Size      vocSize     `xml:"size"`
+	Segmented int         `xml:"segmented"`
+	Objects   []vocObject `xml:"object"`
+}
+
+type vocSource struct {
+	Database string `xml:"database"`
+}
+
+type vocSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+type vocObject struct {
+	Name      string    `xml:"name"`
+	Pose      string    `xml:"pose"`
+	Truncated int       `xml:"truncated"`
+	Difficult int       `xml:"difficult"`
+	BndBox    vocBndBox `xml:"bndbox"`
+}
+
+type vocBndBox struct {
+	XMin int `xml:"xmin"`
+	YMin int `xml:"ymin"`
+	XMax int `xml:"xmax"`
+	YMax int `xml:"ymax"`
+}
+
+// WriteVOC writes the bounding boxes of img as a Pascal VOC annotation
+// (XML), the one-file-per-image format of the VOC "Annotations" directory.
+// Coordinates are 1-based pixels, as in the VOC datasets. Boxes touching
+// the image border are marked truncated.
+func WriteVOC(w io.Writer, img AnnotatedImage) error {
+	annotation := vocAnnotation{
+		Folder:   filepath.Base(filepath.Dir(img.Path)),
+		Filename: filepath.Base(img.Path),
+		Path:     filepath.ToSlash(img.Path),
+		Source:   vocSource{Database: "imgx"},
+		Size:     vocSize{Width: img.Width, Height: img.Height, Depth: 3},
+	}
+	for _, box := range exportBoxes(img) {
+		x, y, width, height := pixelBox(box.Box, img.Width, img.Height)
+		bndBox := vocBndBox{
+			XMin: int(math.Round(x)) + 1,
+			YMin: int(math.Round(y)) + 1,
+			XMax: int(math.Round(x + width)),
+			YMax: int(math.Round(y + height)),
+		}
+		truncated := 0
+		if bndBox.XMin <= 1 || bndBox.YMin <= 1 || bndBox.XMax >= img.Width || bndBox.YMax >= img.Height {
+			truncated = 1
+		}
+		annotation.Objects = append(annotation.Objects, vocObject{
+			Name:      box.Label,
+			Pose:      "Unspecified",
+			Truncated: truncated,
+			BndBox:    bndBox,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write VOC annotation: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&annotation); err != nil {
+		return fmt.Errorf("failed to write VOC annotation: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// exportBoxes returns the labeled, non-empty bounding boxes of img
+func exportBoxes(img AnnotatedImage) []BoundingBox {
+	if img.Result == nil {
+		return nil
+	}
+	var boxes []BoundingBox
+	for _, box := range img.Result.BoundingBoxes {
+		if box.Label != "" && box.Box.Width > 0 && box.Box.Height > 0 {
+			boxes = append(boxes, box)
+		}
+	}
+	return boxes
+}
+
+// pixelBox converts a box relative to the image size (0.0-1.0) to pixels,
+// clamped to the image
+func pixelBox(box Box, width, height int) (x, y, w, h float64) {
+	clamp := func(v float32) float64 {
+		return math.Min(math.Max(float64(v), 0), 1)
+	}
+	x0, y0 := clamp(box.X), clamp(box.Y)
+	x1, y1 := clamp(box.X+box.Width), clamp(box.Y+box.Height)
+	round := func(v float64) float64 {
+		return math.Round(v*100) / 100
+	}
+	return round(x0 * float64(width)), round(y0 * float64(height)),
+		round((x1 - x0) * float64(width)), round((y1 - y0) * float64(height))
+}
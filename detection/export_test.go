@@ -0,0 +1,73 @@
+package detection
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func exportTestImages() []AnnotatedImage {
+	return []AnnotatedImage{
+		{Path: "images/street.jpg", Width: 200, Height: 100, Result: &DetectionResult{
+			BoundingBoxes: []BoundingBox{
+				{Label: "Person", Confidence: 0.9, Box: Box{X: 0.25, Y: 0.5, Width: 0.25, Height: 0.5}},
+				{Label: "Car", Confidence: 0.8, Box: Box{X: 0.5, Y: 0.25, Width: 0.5, Height: 0.25}},
+				{Label: "", Box: Box{Width: 0.5, Height: 0.5}}, // unlabeled, skipped
+			},
+		}},
+		{Path: "images/empty.jpg", Width: 50, Height: 50, Result: &DetectionResult{}},
+	}
+}
+
+// TestWriteCOCO tests the COCO dataset written for detection results
+func TestWriteCOCO(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCOCO(&buf, exportTestImages()); err != nil {
+		t.Fatalf("WriteCOCO() error = %v", err)
+	}
+
+	var dataset cocoDataset
+	if err := json.Unmarshal(buf.Bytes(), &dataset); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(dataset.Images) != 2 || dataset.Images[0].FileName != "images/street.jpg" || dataset.Images[1].Width != 50 {
+		t.Errorf("images = %+v", dataset.Images)
+	}
+	if len(dataset.Categories) != 2 || dataset.Categories[0] != (cocoCategory{ID: 1, Name: "Car"}) {
+		t.Errorf("categories = %+v, want Car and Person sorted", dataset.Categories)
+	}
+	if len(dataset.Annotations) != 2 {
+		t.Fatalf("annotations = %+v, want 2", dataset.Annotations)
+	}
+	person := dataset.Annotations[0]
+	if person.ImageID != 1 || person.CategoryID != 2 || person.BBox != [4]float64{50, 50, 50, 50} || person.Area != 2500 || person.Score != 0.9 {
+		t.Errorf("person annotation = %+v", person)
+	}
+}
+
+// TestWriteVOC tests the Pascal VOC annotation written for one image
+func TestWriteVOC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVOC(&buf, exportTestImages()[0]); err != nil {
+		t.Fatalf("WriteVOC() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		"<filename>street.jpg</filename>",
+		"<width>200</width>",
+		"<name>Person</name>",
+		"<xmin>51</xmin>",
+		"<ymax>100</ymax>",
+		"<truncated>1</truncated>",
+		"<name>Car</name>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("VOC output lacks %s:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "<object>"); n != 2 {
+		t.Errorf("VOC output has %d objects, want 2", n)
+	}
+}
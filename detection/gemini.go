@@ -2,8 +2,10 @@ package detection
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"image"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -11,22 +13,73 @@ import (
 	"google.golang.org/genai"
 )
 
+// geminiDetectModel is the Gemini model used for detection
+const geminiDetectModel = "gemini-2.0-flash"
+
 // GeminiProvider implements the Provider interface for Google Gemini API
 type GeminiProvider struct {
 	client *genai.Client
+	model  string // Detection model; "" is geminiDetectModel
 }
 
-// NewGeminiProvider creates a new Gemini provider instance
+// GeminiConfig configures a Gemini provider created with
+// NewGeminiProviderWithConfig
+type GeminiConfig struct {
+	// APIKey is the Gemini API key (required)
+	APIKey string
+
+	// Model is the detection model, e.g. "gemini-2.5-flash". Default
+	// gemini-2.0-flash.
+	Model string
+
+	// Endpoint is the base URL of the API, e.g. of a proxy or gateway.
+	// Default the Gemini API.
+	Endpoint string
+
+	// HTTPClient sends the requests. Default a new client.
+	HTTPClient *http.Client
+}
+
+// NewGeminiProvider creates a new Gemini provider instance from the
+// GEMINI_API_KEY environment variable. IMGX_GEMINI_MODEL selects the
+// detection model (default gemini-2.0-flash).
 func NewGeminiProvider() (*GeminiProvider, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("%w: GEMINI_API_KEY environment variable not set", ErrProviderNotConfigured)
 	}
+	return NewGeminiProviderWithConfig(GeminiConfig{
+		APIKey: apiKey,
+		Model:  strings.TrimSpace(os.Getenv("IMGX_GEMINI_MODEL")),
+	})
+}
+
+// NewGeminiProviderWithConfig creates a Gemini provider with explicit
+// settings instead of environment variables, e.g. one per customer with
+// their own API key.
+//
+// Example:
+//
+//	provider, err := detection.NewGeminiProviderWithConfig(detection.GeminiConfig{
+//		APIKey: tenant.GeminiKey,
+//		Model:  "gemini-2.5-flash",
+//	})
+//	result, err := provider.Detect(ctx, img, detection.DefaultDetectOptions())
+func NewGeminiProviderWithConfig(cfg GeminiConfig) (*GeminiProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%w: Gemini API key not set", ErrProviderNotConfigured)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
 
 	ctx := context.Background()
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
+		APIKey:      cfg.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  debugHTTPClient("gemini", httpClient),
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.Endpoint},
 	})
 	if err != nil {
 		return nil, NewDetectionError("gemini", "failed to create client", err)
@@ -34,9 +87,22 @@ func NewGeminiProvider() (*GeminiProvider, error) {
 
 	return &GeminiProvider{
 		client: client,
+		model:  cfg.Model,
 	}, nil
 }
 
+// detectModel returns the model used for a detection with opts: the
+// per-call DetectOptions.Model, else the model of the provider
+func (g *GeminiProvider) detectModel(opts *DetectOptions) string {
+	if opts != nil && opts.Model != "" {
+		return opts.Model
+	}
+	if g.model != "" {
+		return g.model
+	}
+	return geminiDetectModel
+}
+
 // Name returns the provider name
 func (g *GeminiProvider) Name() string {
 	return "gemini"
@@ -52,6 +118,7 @@ func (g *GeminiProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 	if opts == nil {
 		opts = DefaultDetectOptions()
 	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
 
 	startTime := time.Now()
 
@@ -75,16 +142,17 @@ func (g *GeminiProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 
 	contents := []*genai.Content{{Parts: parts}}
 
-	// Configure for JSON response if detecting labels
-	var config *genai.GenerateContentConfig
-	if opts.CustomPrompt == "" && containsFeature(opts.Features, FeatureLabels) {
-		config = &genai.GenerateContentConfig{
-			ResponseMIMEType: "application/json",
-		}
+	// Request structured output so the response is valid JSON of the
+	// requested shape
+	config := g.responseConfig(opts)
+	resp, err := g.client.Models.GenerateContent(ctx, g.detectModel(opts), contents, config)
+	var warning string
+	if err != nil && config != nil && geminiBadRequest(err) {
+		// Models without schema support reject it; the prompt asks for the
+		// same JSON, read by the heuristic parser
+		warning = "the model rejected the response schema; parsed the JSON asked for in the prompt"
+		resp, err = g.client.Models.GenerateContent(ctx, g.detectModel(opts), contents, nil)
 	}
-
-	// Generate content using gemini-2.0-flash model
-	resp, err := g.client.Models.GenerateContent(ctx, "gemini-2.0-flash", contents, config)
 	if err != nil {
 		return nil, NewDetectionError("gemini", "API request failed", err)
 	}
@@ -95,6 +163,9 @@ func (g *GeminiProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 		return nil, NewDetectionError("gemini", "failed to parse response", err)
 	}
 
+	if warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
 	result.Provider = "gemini"
 	result.ProcessedAt = startTime
 
@@ -106,6 +177,31 @@ func (g *GeminiProvider) buildPrompt(opts *DetectOptions) string {
 	return buildDetectionPrompt(opts)
 }
 
+// responseConfig returns the generation config of a detection: a JSON
+// schema of the requested features (see detectionResponseSchema), the
+// ResponseSchema of a custom prompt, or nil for plain text
+func (g *GeminiProvider) responseConfig(opts *DetectOptions) *genai.GenerateContentConfig {
+	switch {
+	case opts.CustomPrompt == "":
+		return &genai.GenerateContentConfig{
+			ResponseMIMEType:   "application/json",
+			ResponseJsonSchema: detectionResponseSchema(opts),
+		}
+	case opts.ResponseSchema != nil:
+		return &genai.GenerateContentConfig{
+			ResponseMIMEType:   "application/json",
+			ResponseJsonSchema: opts.ResponseSchema.Schema,
+		}
+	}
+	return nil
+}
+
+// geminiBadRequest reports whether err is a 400 response of the Gemini API
+func geminiBadRequest(err error) bool {
+	var apiErr genai.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest
+}
+
 // parseResponse parses Gemini API response into DetectionResult
 func (g *GeminiProvider) parseResponse(resp *genai.GenerateContentResponse, opts *DetectOptions) (*DetectionResult, error) {
 	empty := &DetectionResult{
@@ -161,3 +257,71 @@ func containsFeature(features []Feature, target Feature) bool {
 	}
 	return false
 }
+
+// geminiImageModel is the Gemini model used for image editing
+const geminiImageModel = "gemini-2.5-flash-image"
+
+// EditImage edits an image following instruction using Gemini's image model
+func (g *GeminiProvider) EditImage(ctx context.Context, img *image.NRGBA, instruction string) (*image.NRGBA, error) {
+	imgBytes, err := imageToPNGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError("gemini", "failed to encode image", err)
+	}
+
+	contents := []*genai.Content{{Parts: []*genai.Part{
+		{Text: instruction},
+		{InlineData: &genai.Blob{Data: imgBytes, MIMEType: "image/png"}},
+	}}}
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{string(genai.ModalityText), string(genai.ModalityImage)},
+	}
+
+	resp, err := g.client.Models.GenerateContent(ctx, geminiImageModel, contents, config)
+	if err != nil {
+		return nil, NewDetectionError("gemini", "API request failed", err)
+	}
+	return decodeGeminiImage(resp)
+}
+
+// decodeGeminiImage returns the first image of a Gemini response. When the
+// model answers with text only (e.g. a refusal), the text is the error.
+func decodeGeminiImage(resp *genai.GenerateContentResponse) (*image.NRGBA, error) {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, NewDetectionError("gemini", "empty response from API", ErrAPIError)
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+			edited, err := decodeImageBytes(part.InlineData.Data)
+			if err != nil {
+				return nil, NewDetectionError("gemini", "failed to decode image", err)
+			}
+			return edited, nil
+		}
+		text.WriteString(part.Text)
+	}
+	msg := "no image in response"
+	if t := strings.TrimSpace(text.String()); t != "" {
+		msg += ": " + t
+	}
+	return nil, NewDetectionError("gemini", msg, ErrAPIError)
+}
+
+// geminiAspectRatios are the aspect ratios supported by the image model
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "9:16", "16:9", "21:9"}
+
+// GenerateImage creates an image from prompt using Gemini's image model
+func (g *GeminiProvider) GenerateImage(ctx context.Context, prompt string, opts *GenerateOptions) (*image.NRGBA, error) {
+	contents := []*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}}
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{string(genai.ModalityText), string(genai.ModalityImage)},
+		ImageConfig:        &genai.ImageConfig{AspectRatio: closestAspect(opts.aspectRatio(), geminiAspectRatios)},
+	}
+
+	resp, err := g.client.Models.GenerateContent(ctx, geminiImageModel, contents, config)
+	if err != nil {
+		return nil, NewDetectionError("gemini", "API request failed", err)
+	}
+	return decodeGeminiImage(resp)
+}
@@ -2,10 +2,18 @@ package detection
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"image/color"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"google.golang.org/genai"
 )
 
 // TestGeminiProviderName tests the Name method
@@ -770,3 +778,151 @@ func containsString(s, substr string) bool {
 			return false
 		}())
 }
+
+// TestGeminiProviderStructuredOutput tests the response schema of detection
+// requests and the fallback when the model rejects it
+func TestGeminiProviderStructuredOutput(t *testing.T) {
+	var schemas []string
+	reject := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			GenerationConfig *struct {
+				ResponseJSONSchema map[string]any `json:"responseJsonSchema"`
+			} `json:"generationConfig"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var required []string
+		if req.GenerationConfig != nil && req.GenerationConfig.ResponseJSONSchema != nil {
+			for _, key := range req.GenerationConfig.ResponseJSONSchema["required"].([]any) {
+				required = append(required, key.(string))
+			}
+			if reject {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error": {"code": 400, "message": "responseJsonSchema is not supported", "status": "INVALID_ARGUMENT"}}`)
+				schemas = append(schemas, strings.Join(required, ","))
+				return
+			}
+		}
+		schemas = append(schemas, strings.Join(required, ","))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"labels\": [{\"name\": \"cat\", \"confidence\": 0.9}], \"description\": \"A cat.\"}"}]}}]}`)
+	}))
+	defer server.Close()
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &GeminiProvider{client: client}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	opts := &DetectOptions{Features: []Feature{FeatureLabels, FeatureDescription}, MaxResults: 5}
+
+	result, err := provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if want := []string{"description,labels", ""}; !reflect.DeepEqual(schemas, want) {
+		t.Errorf("response schemas = %q, want %q", schemas, want)
+	}
+	if len(result.Labels) != 1 || result.Description != "A cat." || len(result.Warnings) != 1 {
+		t.Errorf("fallback result = %+v, want the label, the description and a warning", result)
+	}
+
+	schemas, reject = nil, false
+	result, err = provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if len(schemas) != 1 || len(result.Labels) != 1 || len(result.Warnings) != 0 {
+		t.Errorf("structured result = %+v after %q", result, schemas)
+	}
+}
+
+// TestNewGeminiProviderWithConfig tests the API key, model and endpoint of
+// an explicitly configured provider
+func TestNewGeminiProviderWithConfig(t *testing.T) {
+	if _, err := NewGeminiProviderWithConfig(GeminiConfig{}); !IsNotConfigured(err) {
+		t.Errorf("NewGeminiProviderWithConfig() without a key error = %v, want ErrProviderNotConfigured", err)
+	}
+
+	var path, key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, key = r.URL.Path, r.Header.Get("x-goog-api-key")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"labels\": [{\"name\": \"cat\", \"confidence\": 0.9}]}"}]}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewGeminiProviderWithConfig(GeminiConfig{APIKey: "tenant-key", Model: "gemini-2.5-flash", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	result, err := provider.Detect(context.Background(), img, &DetectOptions{Features: []Feature{FeatureLabels}})
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !strings.HasSuffix(path, "/models/gemini-2.5-flash:generateContent") || key != "tenant-key" {
+		t.Errorf("request to %s with key %q, want the configured model and key", path, key)
+	}
+	if len(result.Labels) != 1 {
+		t.Errorf("Labels = %v", result.Labels)
+	}
+}
+
+func TestGeminiProviderModelSelection(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"labels\": []}"}]}}]}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("IMGX_GEMINI_MODEL", " gemini-1.5-pro ")
+	provider, err := NewGeminiProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.detectModel(nil); got != "gemini-1.5-pro" {
+		t.Errorf("detectModel() with IMGX_GEMINI_MODEL = %q", got)
+	}
+
+	provider, err = NewGeminiProviderWithConfig(GeminiConfig{APIKey: "test-key", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	tests := []struct {
+		name string
+		opts *DetectOptions
+		want string
+	}{
+		{"default", &DetectOptions{Features: []Feature{FeatureLabels}}, geminiDetectModel},
+		{"per call", DefaultDetectOptions().WithModel("gemini-2.5-flash"), "gemini-2.5-flash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.Detect(context.Background(), img, tt.opts); err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if !strings.HasSuffix(path, "/models/"+tt.want+":generateContent") {
+				t.Errorf("request to %s, want model %s", path, tt.want)
+			}
+		})
+	}
+
+	defaults := DefaultDetectOptions()
+	if opts := defaults.WithModel("gemini-1.5-pro"); opts.Model != "gemini-1.5-pro" || defaults.Model != "" || opts.MaxResults != defaults.MaxResults {
+		t.Errorf("WithModel() = %+v, changed the receiver or dropped options", opts)
+	}
+	if opts := (*DetectOptions)(nil).WithModel("gemini-1.5-pro"); opts.Model != "gemini-1.5-pro" || len(opts.Features) == 0 {
+		t.Errorf("WithModel() on nil = %+v, want the defaults", opts)
+	}
+}
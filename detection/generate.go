@@ -0,0 +1,103 @@
+package detection
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GenerateOptions configures text-to-image generation
+type GenerateOptions struct {
+	// Width and Height request an output size. Models only produce a few
+	// fixed sizes; the closest aspect ratio is used and the exact size is
+	// up to the caller (e.g. imgx.Fill). Zero means the model default
+	// (square).
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// ImageGenerator is implemented by providers that can generate images from
+// a text prompt (Gemini, OpenAI)
+type ImageGenerator interface {
+	// GenerateImage creates an image from prompt. opts may be nil.
+	GenerateImage(ctx context.Context, prompt string, opts *GenerateOptions) (*image.NRGBA, error)
+
+	// Name returns the provider name
+	Name() string
+
+	// IsConfigured returns true if provider has required credentials
+	IsConfigured() bool
+}
+
+// Compile-time checks for providers that support image generation
+var (
+	_ ImageGenerator = (*GeminiProvider)(nil)
+	_ ImageGenerator = (*OpenAIProvider)(nil)
+)
+
+// Generate creates an image from a text prompt with the image models of
+// Gemini or OpenAI, using the same credentials as detection.
+//
+// Example:
+//
+//	img, err := detection.Generate(ctx, "openai", "isometric office illustration",
+//		&detection.GenerateOptions{Width: 1536, Height: 1024})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	imgx.FromImage(img).Save("office.png")
+func Generate(ctx context.Context, provider, prompt string, opts ...*GenerateOptions) (*image.NRGBA, error) {
+	if strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	var opt *GenerateOptions
+	if len(opts) > 0 && opts[0] != nil {
+		opt = opts[0]
+	} else {
+		opt = &GenerateOptions{}
+	}
+	if opt.Width < 0 || opt.Height < 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", opt.Width, opt.Height)
+	}
+
+	prov, err := GetProvider(ResolveProviderAlias(provider))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get detection provider: %w", err)
+	}
+	generator, ok := prov.(ImageGenerator)
+	if !ok {
+		return nil, NewDetectionError(prov.Name(), "provider does not support image generation (use gemini or openai)", ErrInvalidFeature)
+	}
+
+	img, err := generator.GenerateImage(ctx, prompt, opt)
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+	return img, nil
+}
+
+// aspectRatio returns the requested width/height ratio, 1 when unset
+func (o *GenerateOptions) aspectRatio() float64 {
+	if o == nil || o.Width <= 0 || o.Height <= 0 {
+		return 1
+	}
+	return float64(o.Width) / float64(o.Height)
+}
+
+// closestAspect returns the candidate ("W:H" or "WxH") whose aspect ratio
+// is closest to ratio
+func closestAspect(ratio float64, candidates []string) string {
+	best, bestDiff := candidates[0], math.Inf(1)
+	for _, c := range candidates {
+		parts := strings.FieldsFunc(c, func(r rune) bool { return r == ':' || r == 'x' })
+		w, _ := strconv.ParseFloat(parts[0], 64)
+		h, _ := strconv.ParseFloat(parts[1], 64)
+		if diff := math.Abs(math.Log(ratio) - math.Log(w/h)); diff < bestDiff {
+			best, bestDiff = c, diff
+		}
+	}
+	return best
+}
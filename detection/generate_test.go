@@ -0,0 +1,86 @@
+package detection
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// TestClosestAspect tests the mapping of requested sizes to model sizes
+func TestClosestAspect(t *testing.T) {
+	tests := []struct {
+		opts       *GenerateOptions
+		candidates []string
+		want       string
+	}{
+		{nil, openAIImageSizes, "1024x1024"},
+		{&GenerateOptions{Width: 1920, Height: 1080}, openAIImageSizes, "1536x1024"},
+		{&GenerateOptions{Width: 1080, Height: 1350}, openAIImageSizes, "1024x1536"},
+		{&GenerateOptions{Width: 1920, Height: 1080}, geminiAspectRatios, "16:9"},
+		{&GenerateOptions{Width: 1080, Height: 1350}, geminiAspectRatios, "3:4"},
+		{&GenerateOptions{Width: 2560, Height: 1080}, geminiAspectRatios, "21:9"},
+		{&GenerateOptions{Width: 500}, geminiAspectRatios, "1:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt.opts), func(t *testing.T) {
+			if got := closestAspect(tt.opts.aspectRatio(), tt.candidates); got != tt.want {
+				t.Errorf("closestAspect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOpenAIProviderGenerateImage tests the generation request against a fake API
+func TestOpenAIProviderGenerateImage(t *testing.T) {
+	generated := createTestImage(12, 8, color.NRGBA{R: 0, G: 128, B: 0, A: 255})
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"created": 1, "data": [{"b64_json": %q}]}`, base64.StdEncoding.EncodeToString(encodeTestPNG(t, generated)))
+	}))
+	defer server.Close()
+
+	client := openai.NewClient(option.WithAPIKey("test"), option.WithBaseURL(server.URL))
+	provider := &OpenAIProvider{client: &client}
+
+	got, err := provider.GenerateImage(context.Background(), "isometric office", &GenerateOptions{Width: 1600, Height: 900})
+	if err != nil {
+		t.Fatalf("GenerateImage() error: %v", err)
+	}
+	if body["prompt"] != "isometric office" || body["size"] != "1536x1024" || body["model"] != "gpt-image-1" {
+		t.Errorf("request body = %v", body)
+	}
+	if got.Bounds() != generated.Bounds() || got.NRGBAAt(0, 0) != generated.NRGBAAt(0, 0) {
+		t.Errorf("GenerateImage() = %v %v, want the generated image", got.Bounds(), got.NRGBAAt(0, 0))
+	}
+}
+
+// TestGenerateValidation tests Generate argument errors
+func TestGenerateValidation(t *testing.T) {
+	if _, err := Generate(context.Background(), "openai", ""); err == nil {
+		t.Error("Generate() with an empty prompt should fail")
+	}
+	if _, err := Generate(context.Background(), "openai", "a cat", &GenerateOptions{Width: -1}); err == nil {
+		t.Error("Generate() with a negative size should fail")
+	}
+	if _, err := Generate(context.Background(), "unknown", "a cat"); err == nil {
+		t.Error("Generate() with an unknown provider should fail")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"image"
+	"image/draw"
 	"image/jpeg"
+	"image/png"
 )
 
 // imageToJPEGBytes converts image.NRGBA to JPEG bytes
@@ -16,6 +18,36 @@ func imageToJPEGBytes(img *image.NRGBA) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// imageToPNGBytes converts image.NRGBA to PNG bytes, for edits where JPEG
+// artifacts would be amplified
+func imageToPNGBytes(img *image.NRGBA) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeImageBytes decodes a PNG or JPEG image returned by a provider
+func decodeImageBytes(data []byte) (*image.NRGBA, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return toNRGBA(src), nil
+}
+
+// toNRGBA returns src as *image.NRGBA, converting other image types
+func toNRGBA(src image.Image) *image.NRGBA {
+	if nrgba, ok := src.(*image.NRGBA); ok {
+		return nrgba
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, src, b.Min, draw.Src)
+	return dst
+}
+
 // parseJSON parses JSON bytes into a Go value
 func parseJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
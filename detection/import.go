@@ -0,0 +1,222 @@
+package detection
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadAnnotations reads a COCO (JSON), Pascal VOC (XML) or labelme (JSON)
+// annotation file, recognized by its content. Image paths are resolved
+// relative to the directory of the annotation file.
+//
+// Example:
+//
+//	images, err := detection.ReadAnnotations("annotations.json")
+//	for _, img := range images {
+//		fmt.Println(img.Path, len(img.Result.BoundingBoxes))
+//	}
+func ReadAnnotations(path string) ([]AnnotatedImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	dir := filepath.Dir(path)
+
+	var images []AnnotatedImage
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		img, err := ReadVOC(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		// VOC files name the image and usually its original location
+		img.Path = resolveAnnotationPath(dir, img.Path)
+		images = []AnnotatedImage{img}
+	case bytes.Contains(trimmed, []byte(`"shapes"`)) && !bytes.Contains(trimmed, []byte(`"annotations"`)):
+		img, err := ReadLabelme(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		images = []AnnotatedImage{img}
+	default:
+		if images, err = ReadCOCO(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range images {
+		if !filepath.IsAbs(images[i].Path) {
+			images[i].Path = filepath.Join(dir, filepath.FromSlash(images[i].Path))
+		}
+	}
+	return images, nil
+}
+
+// resolveAnnotationPath returns the image path of a VOC annotation in dir:
+// path when it names an existing file, else its base name, which VOC
+// datasets keep next to the annotation or in the sibling JPEGImages
+// directory
+func resolveAnnotationPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	} else if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err == nil {
+		return path
+	}
+	name := filepath.Base(filepath.FromSlash(path))
+	if _, err := os.Stat(filepath.Join(dir, "..", "JPEGImages", name)); err == nil {
+		return filepath.Join("..", "JPEGImages", name)
+	}
+	return name
+}
+
+// ReadCOCO reads a COCO object detection dataset, as written by WriteCOCO.
+// Bounding boxes are converted to the 0.0-1.0 range of DetectionResult;
+// annotation scores become the box confidence (1 when absent). Image paths
+// are the file_name values, unresolved.
+func ReadCOCO(r io.Reader) ([]AnnotatedImage, error) {
+	var dataset cocoDataset
+	if err := json.NewDecoder(r).Decode(&dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse COCO annotations: %w", err)
+	}
+
+	categories := make(map[int]string, len(dataset.Categories))
+	for _, category := range dataset.Categories {
+		categories[category.ID] = category.Name
+	}
+	index := make(map[int]int, len(dataset.Images))
+	images := make([]AnnotatedImage, 0, len(dataset.Images))
+	for _, img := range dataset.Images {
+		index[img.ID] = len(images)
+		images = append(images, AnnotatedImage{
+			Path:   img.FileName,
+			Width:  img.Width,
+			Height: img.Height,
+			Result: &DetectionResult{Provider: "coco"},
+		})
+	}
+
+	for _, a := range dataset.Annotations {
+		i, ok := index[a.ImageID]
+		if !ok {
+			return nil, fmt.Errorf("COCO annotation %d refers to unknown image %d", a.ID, a.ImageID)
+		}
+		img := &images[i]
+		label, ok := categories[a.CategoryID]
+		if !ok {
+			return nil, fmt.Errorf("COCO annotation %d refers to unknown category %d", a.ID, a.CategoryID)
+		}
+		confidence := a.Score
+		if confidence == 0 {
+			confidence = 1
+		}
+		img.Result.BoundingBoxes = append(img.Result.BoundingBoxes, BoundingBox{
+			Label:      label,
+			Confidence: confidence,
+			Box:        relativeBox(a.BBox[0], a.BBox[1], a.BBox[2], a.BBox[3], img.Width, img.Height),
+		})
+	}
+	return images, nil
+}
+
+// ReadVOC reads a Pascal VOC annotation, as written by WriteVOC. The image
+// path is the path element when present, else the filename.
+func ReadVOC(r io.Reader) (AnnotatedImage, error) {
+	var annotation vocAnnotation
+	if err := xml.NewDecoder(r).Decode(&annotation); err != nil {
+		return AnnotatedImage{}, fmt.Errorf("failed to parse VOC annotation: %w", err)
+	}
+
+	img := AnnotatedImage{
+		Path:   annotation.Path,
+		Width:  annotation.Size.Width,
+		Height: annotation.Size.Height,
+		Result: &DetectionResult{Provider: "voc"},
+	}
+	if img.Path == "" {
+		img.Path = annotation.Filename
+	}
+	for _, object := range annotation.Objects {
+		b := object.BndBox
+		img.Result.BoundingBoxes = append(img.Result.BoundingBoxes, BoundingBox{
+			Label:      object.Name,
+			Confidence: 1,
+			Box: relativeBox(float64(b.XMin-1), float64(b.YMin-1),
+				float64(b.XMax-b.XMin+1), float64(b.YMax-b.YMin+1), img.Width, img.Height),
+		})
+	}
+	return img, nil
+}
+
+// labelmeFile is the subset of the labelme JSON format read by ReadLabelme
+type labelmeFile struct {
+	ImagePath   string         `json:"imagePath"`
+	ImageWidth  int            `json:"imageWidth"`
+	ImageHeight int            `json:"imageHeight"`
+	Shapes      []labelmeShape `json:"shapes"`
+}
+
+type labelmeShape struct {
+	Label     string       `json:"label"`
+	Points    [][2]float64 `json:"points"`
+	ShapeType string       `json:"shape_type"`
+}
+
+// ReadLabelme reads a labelme annotation. Rectangles and polygons become
+// their bounding boxes; points, lines and circles are skipped.
+func ReadLabelme(r io.Reader) (AnnotatedImage, error) {
+	var file labelmeFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return AnnotatedImage{}, fmt.Errorf("failed to parse labelme annotation: %w", err)
+	}
+
+	img := AnnotatedImage{
+		Path:   file.ImagePath,
+		Width:  file.ImageWidth,
+		Height: file.ImageHeight,
+		Result: &DetectionResult{Provider: "labelme"},
+	}
+	for _, shape := range file.Shapes {
+		shapeType := strings.ToLower(shape.ShapeType)
+		if shapeType != "" && shapeType != "rectangle" && shapeType != "polygon" {
+			continue
+		}
+		if len(shape.Points) < 2 {
+			continue
+		}
+		minX, minY := shape.Points[0][0], shape.Points[0][1]
+		maxX, maxY := minX, minY
+		for _, p := range shape.Points[1:] {
+			minX, maxX = min(minX, p[0]), max(maxX, p[0])
+			minY, maxY = min(minY, p[1]), max(maxY, p[1])
+		}
+		img.Result.BoundingBoxes = append(img.Result.BoundingBoxes, BoundingBox{
+			Label:      shape.Label,
+			Confidence: 1,
+			Box:        relativeBox(minX, minY, maxX-minX, maxY-minY, img.Width, img.Height),
+		})
+	}
+	return img, nil
+}
+
+// relativeBox converts a pixel box to the 0.0-1.0 range; images of unknown
+// size yield a zero box
+func relativeBox(x, y, w, h float64, width, height int) Box {
+	if width <= 0 || height <= 0 {
+		return Box{}
+	}
+	return Box{
+		X:      float32(x / float64(width)),
+		Y:      float32(y / float64(height)),
+		Width:  float32(w / float64(width)),
+		Height: float32(h / float64(height)),
+	}
+}
@@ -0,0 +1,124 @@
+package detection
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadCOCO tests that WriteCOCO output reads back to the same boxes
+func TestReadCOCO(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCOCO(&buf, exportTestImages()); err != nil {
+		t.Fatalf("WriteCOCO() error = %v", err)
+	}
+	images, err := ReadCOCO(&buf)
+	if err != nil {
+		t.Fatalf("ReadCOCO() error = %v", err)
+	}
+	if len(images) != 2 || images[0].Path != "images/street.jpg" || images[0].Width != 200 {
+		t.Fatalf("images = %+v", images)
+	}
+	boxes := images[0].Result.BoundingBoxes
+	if len(boxes) != 2 || boxes[0].Label != "Person" || boxes[0].Confidence != 0.9 ||
+		boxes[0].Box != (Box{X: 0.25, Y: 0.5, Width: 0.25, Height: 0.5}) {
+		t.Errorf("boxes = %+v", boxes)
+	}
+	if len(images[1].Result.BoundingBoxes) != 0 {
+		t.Errorf("empty image boxes = %+v", images[1].Result.BoundingBoxes)
+	}
+
+	bad := `{"images":[{"id":1,"file_name":"a.jpg","width":10,"height":10}],"annotations":[{"id":1,"image_id":2,"category_id":1,"bbox":[0,0,1,1]}]}`
+	if _, err := ReadCOCO(strings.NewReader(bad)); err == nil {
+		t.Error("ReadCOCO() accepted an annotation of an unknown image")
+	}
+}
+
+// TestReadVOC tests that WriteVOC output reads back to the same boxes
+func TestReadVOC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVOC(&buf, exportTestImages()[0]); err != nil {
+		t.Fatalf("WriteVOC() error = %v", err)
+	}
+	img, err := ReadVOC(&buf)
+	if err != nil {
+		t.Fatalf("ReadVOC() error = %v", err)
+	}
+	if img.Path != "images/street.jpg" || img.Width != 200 || img.Height != 100 {
+		t.Errorf("image = %+v", img)
+	}
+	boxes := img.Result.BoundingBoxes
+	if len(boxes) != 2 || boxes[1].Label != "Car" || boxes[1].Box != (Box{X: 0.5, Y: 0.25, Width: 0.5, Height: 0.25}) {
+		t.Errorf("boxes = %+v", boxes)
+	}
+}
+
+// TestReadLabelme tests rectangles and polygons of a labelme file
+func TestReadLabelme(t *testing.T) {
+	data := `{"imagePath":"dog.jpg","imageWidth":100,"imageHeight":50,"shapes":[
+		{"label":"dog","points":[[60,40],[10,5]],"shape_type":"rectangle"},
+		{"label":"ball","points":[[0,0],[20,10],[10,20]],"shape_type":"polygon"},
+		{"label":"nose","points":[[30,30]],"shape_type":"point"}
+	]}`
+	img, err := ReadLabelme(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadLabelme() error = %v", err)
+	}
+	boxes := img.Result.BoundingBoxes
+	if len(boxes) != 2 {
+		t.Fatalf("boxes = %+v, want the rectangle and the polygon", boxes)
+	}
+	if got := boxes[0].Box.Rect(img.Width, img.Height); got != image.Rect(10, 5, 60, 40) {
+		t.Errorf("dog box = %v", got)
+	}
+	if got := boxes[1].Box.Rect(img.Width, img.Height); got != image.Rect(0, 0, 20, 20) {
+		t.Errorf("ball box = %v", got)
+	}
+}
+
+// TestReadAnnotations tests format detection and image path resolution
+func TestReadAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var coco bytes.Buffer
+	if err := WriteCOCO(&coco, exportTestImages()); err != nil {
+		t.Fatal(err)
+	}
+	images, err := ReadAnnotations(write("coco.json", coco.String()))
+	if err != nil || len(images) != 2 || images[0].Path != filepath.Join(dir, "images", "street.jpg") {
+		t.Errorf("COCO images = %+v, %v", images, err)
+	}
+
+	// A VOC dataset: the image is in the sibling JPEGImages directory
+	write("JPEGImages/street.jpg", "")
+	var voc bytes.Buffer
+	if err := WriteVOC(&voc, exportTestImages()[0]); err != nil {
+		t.Fatal(err)
+	}
+	images, err = ReadAnnotations(write("Annotations/street.xml", voc.String()))
+	if err != nil || len(images) != 1 || images[0].Path != filepath.Join(dir, "JPEGImages", "street.jpg") {
+		t.Errorf("VOC images = %+v, %v", images, err)
+	}
+
+	images, err = ReadAnnotations(write("dog.json", `{"imagePath":"dog.jpg","imageWidth":10,"imageHeight":10,"shapes":[]}`))
+	if err != nil || len(images) != 1 || images[0].Path != filepath.Join(dir, "dog.jpg") || images[0].Result.Provider != "labelme" {
+		t.Errorf("labelme images = %+v, %v", images, err)
+	}
+
+	if _, err := ReadAnnotations(write("broken.json", "{")); err == nil {
+		t.Error("ReadAnnotations() accepted invalid JSON")
+	}
+}
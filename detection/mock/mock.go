@@ -0,0 +1,231 @@
+// Package mock provides a scriptable detection.Provider for deterministic
+// tests of applications that use imgx detection.
+//
+// Results can be canned per image (by pixel hash), and scripted steps
+// replay sequences of latencies, errors and results, e.g. a rate limit
+// followed by a success:
+//
+//	provider := mock.New("gemini").
+//		OnImage(dogImg, &detection.DetectionResult{Labels: []detection.Label{{Name: "dog", Confidence: 0.9}}}).
+//		Script(mock.RateLimited(), mock.Succeed(nil))
+//
+//	_, err := provider.Detect(ctx, dogImg, nil)    // rate limit error
+//	result, err := provider.Detect(ctx, dogImg, nil) // canned "dog" result
+package mock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/razzkumar/imgx/detection"
+)
+
+// Step is one scripted call: wait Latency, then return Err or Result. A
+// nil Result with a nil Err falls back to the canned result for the image.
+type Step struct {
+	Latency time.Duration
+	Result  *detection.DetectionResult
+	Err     error
+}
+
+// Succeed returns a step that succeeds with result (nil for the canned result)
+func Succeed(result *detection.DetectionResult) Step {
+	return Step{Result: result}
+}
+
+// Fail returns a step that fails with err
+func Fail(err error) Step {
+	return Step{Err: err}
+}
+
+// RateLimited returns a step that fails like a provider rate limit
+// (detection.IsRateLimit reports true)
+func RateLimited() Step {
+	return Step{Err: detection.NewDetectionError("mock", "rate limited", detection.ErrRateLimit)}
+}
+
+// Delay returns a step that succeeds with the canned result after d
+func Delay(d time.Duration) Step {
+	return Step{Latency: d}
+}
+
+// Call records a Detect call
+type Call struct {
+	ImageHash string
+	Options   *detection.DetectOptions
+	Time      time.Time
+	Err       error
+}
+
+// Provider is a scriptable detection.Provider. Configure it before use;
+// Detect is safe for concurrent use.
+type Provider struct {
+	mu         sync.Mutex
+	name       string
+	configured bool
+	latency    time.Duration
+	byHash     map[string]*detection.DetectionResult
+	fallback   *detection.DetectionResult
+	script     []Step
+	calls      []Call
+}
+
+var _ detection.Provider = (*Provider)(nil)
+
+// New creates a mock provider reporting name ("mock" if empty). Without
+// configuration, Detect returns a single "test" label.
+func New(name string) *Provider {
+	if name == "" {
+		name = "mock"
+	}
+	return &Provider{
+		name:       name,
+		configured: true,
+		byHash:     make(map[string]*detection.DetectionResult),
+		fallback: &detection.DetectionResult{
+			Labels:     []detection.Label{{Name: "test", Confidence: 0.9}},
+			Confidence: 0.9,
+		},
+	}
+}
+
+// OnImage cans the result returned for img (matched by pixel content)
+func (p *Provider) OnImage(img *image.NRGBA, result *detection.DetectionResult) *Provider {
+	return p.OnHash(ImageHash(img), result)
+}
+
+// OnHash cans the result returned for images with the given ImageHash
+func (p *Provider) OnHash(hash string, result *detection.DetectionResult) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byHash[hash] = result
+	return p
+}
+
+// Default sets the result returned for images without a canned result
+func (p *Provider) Default(result *detection.DetectionResult) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallback = result
+	return p
+}
+
+// Latency sets a delay added to every call
+func (p *Provider) Latency(d time.Duration) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+	return p
+}
+
+// Script appends steps consumed one per call, in order. Once the script is
+// exhausted, calls return the canned results.
+func (p *Provider) Script(steps ...Step) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = append(p.script, steps...)
+	return p
+}
+
+// Unconfigured makes IsConfigured report false
+func (p *Provider) Unconfigured() *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configured = false
+	return p
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// IsConfigured returns false after Unconfigured
+func (p *Provider) IsConfigured() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.configured
+}
+
+// Detect returns the next scripted step or the canned result for img. The
+// returned result is a shallow copy with Provider and ProcessedAt set, so
+// tests can reuse canned results.
+func (p *Provider) Detect(ctx context.Context, img *image.NRGBA, opts *detection.DetectOptions) (*detection.DetectionResult, error) {
+	hash := ImageHash(img)
+
+	p.mu.Lock()
+	var step Step
+	if len(p.script) > 0 {
+		step, p.script = p.script[0], p.script[1:]
+	}
+	latency := p.latency + step.Latency
+	result := step.Result
+	if result == nil {
+		if canned, ok := p.byHash[hash]; ok {
+			result = canned
+		} else {
+			result = p.fallback
+		}
+	}
+	p.mu.Unlock()
+
+	err := step.Err
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		case <-timer.C:
+		}
+	} else if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	p.mu.Lock()
+	p.calls = append(p.calls, Call{ImageHash: hash, Options: opts, Time: time.Now(), Err: err})
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	out := *result
+	out.Provider = p.name
+	out.ProcessedAt = time.Now()
+	return &out, nil
+}
+
+// Calls returns the Detect calls made so far
+func (p *Provider) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Call(nil), p.calls...)
+}
+
+// CallCount returns the number of Detect calls made so far
+func (p *Provider) CallCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.calls)
+}
+
+// ImageHash returns the hash used to match canned results: a SHA-256 of
+// the image size and pixels, so equal images match regardless of origin
+func ImageHash(img *image.NRGBA) string {
+	h := sha256.New()
+	b := img.Bounds()
+	var size [8]byte
+	binary.LittleEndian.PutUint32(size[:4], uint32(b.Dx()))
+	binary.LittleEndian.PutUint32(size[4:], uint32(b.Dy()))
+	h.Write(size[:])
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		h.Write(img.Pix[i : i+b.Dx()*4])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,116 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/razzkumar/imgx/detection"
+)
+
+func solidImage(c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// TestProviderCannedResults tests results matched by image hash
+func TestProviderCannedResults(t *testing.T) {
+	red := solidImage(color.NRGBA{R: 255, A: 255})
+	blue := solidImage(color.NRGBA{B: 255, A: 255})
+	dog := &detection.DetectionResult{Labels: []detection.Label{{Name: "dog", Confidence: 0.95}}}
+
+	p := New("gemini").OnImage(red, dog)
+	ctx := context.Background()
+
+	result, err := p.Detect(ctx, solidImage(color.NRGBA{R: 255, A: 255}), nil)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if result.Provider != "gemini" || len(result.Labels) != 1 || result.Labels[0].Name != "dog" || result.ProcessedAt.IsZero() {
+		t.Errorf("Detect(red) = %+v, want the canned dog result", result)
+	}
+	if result == dog {
+		t.Error("Detect() returned the canned result itself, want a copy")
+	}
+
+	result, err = p.Detect(ctx, blue, nil)
+	if err != nil || result.Labels[0].Name != "test" {
+		t.Errorf("Detect(blue) = %+v, %v, want the default result", result, err)
+	}
+
+	if ImageHash(red) == ImageHash(blue) {
+		t.Error("ImageHash() is equal for different images")
+	}
+	if calls := p.Calls(); len(calls) != 2 || calls[0].ImageHash != ImageHash(red) {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
+
+// TestProviderScript tests scripted error sequences and latencies
+func TestProviderScript(t *testing.T) {
+	img := solidImage(color.NRGBA{G: 255, A: 255})
+	boom := errors.New("boom")
+	p := New("").Script(RateLimited(), Fail(boom), Succeed(&detection.DetectionResult{Description: "scripted"}))
+	ctx := context.Background()
+
+	if _, err := p.Detect(ctx, img, nil); !detection.IsRateLimit(err) {
+		t.Errorf("call 1 error = %v, want a rate limit", err)
+	}
+	if _, err := p.Detect(ctx, img, nil); !errors.Is(err, boom) {
+		t.Errorf("call 2 error = %v, want %v", err, boom)
+	}
+	if result, err := p.Detect(ctx, img, nil); err != nil || result.Description != "scripted" {
+		t.Errorf("call 3 = %+v, %v, want the scripted result", result, err)
+	}
+	if result, err := p.Detect(ctx, img, nil); err != nil || result.Provider != "mock" || result.Labels[0].Name != "test" {
+		t.Errorf("call 4 = %+v, %v, want the default result", result, err)
+	}
+	if p.CallCount() != 4 {
+		t.Errorf("CallCount() = %d, want 4", p.CallCount())
+	}
+}
+
+// TestProviderLatency tests injected latency and context cancellation
+func TestProviderLatency(t *testing.T) {
+	img := solidImage(color.NRGBA{A: 255})
+	p := New("slow").Latency(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := p.Detect(context.Background(), img, nil); err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Detect() took %v, want at least 20ms", elapsed)
+	}
+
+	p.Script(Delay(time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Detect(ctx, img, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Detect() with a short deadline error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if !p.IsConfigured() || p.Unconfigured().IsConfigured() {
+		t.Error("Unconfigured() should make IsConfigured() false")
+	}
+}
+
+// TestProviderWithAsk tests the mock behind a higher-level API
+func TestProviderWithAsk(t *testing.T) {
+	p := New("gemini").Default(&detection.DetectionResult{
+		RawResponse: `{"answer": true, "confidence": 0.9, "explanation": "a helmet is visible"}`,
+	})
+	answer, err := detection.AskProvider(context.Background(), p, solidImage(color.NRGBA{A: 255}), "Is there a helmet?", nil)
+	if err != nil {
+		t.Fatalf("AskProvider() error: %v", err)
+	}
+	if !answer.Bool() || answer.Provider != "gemini" {
+		t.Errorf("AskProvider() = %+v", answer)
+	}
+}
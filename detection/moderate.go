@@ -0,0 +1,276 @@
+package detection
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Verdict is the outcome of Moderate
+type Verdict string
+
+const (
+	VerdictAllow  Verdict = "allow"  // Nothing above the review threshold
+	VerdictReview Verdict = "review" // Needs a human look
+	VerdictBlock  Verdict = "block"  // A blocking category is above the threshold
+)
+
+// ModerationCategory groups the moderation labels of the providers
+type ModerationCategory string
+
+const (
+	CategoryAdult      ModerationCategory = "adult"      // Nudity and sexual content
+	CategorySuggestive ModerationCategory = "suggestive" // Racy content, swimwear and underwear
+	CategoryViolence   ModerationCategory = "violence"   // Violence, weapons, gore and disturbing content
+	CategoryHate       ModerationCategory = "hate"       // Hate symbols, extremism and rude gestures
+	CategoryDrugs      ModerationCategory = "drugs"      // Drugs, tobacco and alcohol
+	CategoryMedical    ModerationCategory = "medical"    // Medical imagery (Cloud Vision)
+	CategorySpoof      ModerationCategory = "spoof"      // Spoofed or altered content (Cloud Vision)
+	CategoryOther      ModerationCategory = "other"      // Labels of no known category
+)
+
+// moderationKeywords map words of label names to categories, checked in
+// order so that AWS "Non-Explicit Nudity" is suggestive, not adult
+var moderationKeywords = []struct {
+	word     string
+	category ModerationCategory
+}{
+	{"non-explicit", CategorySuggestive},
+	{"suggestive", CategorySuggestive},
+	{"racy", CategorySuggestive},
+	{"swimwear", CategorySuggestive},
+	{"underwear", CategorySuggestive},
+	{"revealing", CategorySuggestive},
+	{"nudity", CategoryAdult},
+	{"explicit", CategoryAdult},
+	{"adult", CategoryAdult},
+	{"sexual", CategoryAdult},
+	{"nsfw", CategoryAdult},
+	{"porn", CategoryAdult},
+	{"violen", CategoryViolence},
+	{"weapon", CategoryViolence},
+	{"gore", CategoryViolence},
+	{"blood", CategoryViolence},
+	{"disturbing", CategoryViolence},
+	{"self-harm", CategoryViolence},
+	{"hate", CategoryHate},
+	{"extremis", CategoryHate},
+	{"rude gesture", CategoryHate},
+	{"drug", CategoryDrugs},
+	{"tobacco", CategoryDrugs},
+	{"alcohol", CategoryDrugs},
+	{"smoking", CategoryDrugs},
+	{"medical", CategoryMedical},
+	{"spoof", CategorySpoof},
+}
+
+// safeModerationLabels are labels LLM providers use to say nothing was found
+var safeModerationLabels = []string{"safe", "none", "clean", "sfw"}
+
+// moderationSeverities are the confidences of labels without one, by
+// severity (Cloud Vision likelihoods are already converted)
+var moderationSeverities = map[string]float32{
+	"very_likely": 0.9,
+	"likely":      0.7,
+	"possible":    0.5,
+	"unlikely":    0.3,
+	"high":        0.9,
+	"medium":      0.6,
+	"low":         0.3,
+}
+
+// CategorizeModerationLabel returns the category of a provider moderation
+// label, looking at its parent first (AWS "Graphic Male Nudity" is under
+// "Explicit Nudity"). ok is false for labels meaning nothing was found,
+// such as "safe".
+func CategorizeModerationLabel(label ModerationLabel) (category ModerationCategory, ok bool) {
+	name := strings.ToLower(strings.TrimSpace(label.Name))
+	if slices.Contains(safeModerationLabels, name) {
+		return "", false
+	}
+	for _, s := range []string{strings.ToLower(label.Parent), name} {
+		for _, k := range moderationKeywords {
+			if s != "" && strings.Contains(s, k.word) {
+				return k.category, true
+			}
+		}
+	}
+	return CategoryOther, true
+}
+
+// ModerateOptions configures Moderate
+type ModerateOptions struct {
+	// Threshold is the score at or above which a blocking category blocks
+	// the image. Default 0.8 (Cloud Vision VERY_LIKELY).
+	Threshold float32
+
+	// ReviewThreshold is the score at or above which any category sends
+	// the image to review. Default 0.6 (Cloud Vision LIKELY).
+	ReviewThreshold float32
+
+	// Block are the categories that can block; the others can only send
+	// an image to review. Default adult, violence and hate.
+	Block []ModerationCategory
+
+	// Providers are the providers to ask; the highest score of each
+	// category wins. Default the first configured of vision, aws, openai,
+	// gemini and ollama.
+	Providers []string
+}
+
+// moderationProviders are the providers tried by default, dedicated
+// moderation APIs first
+var moderationProviders = []string{"vision", "aws", "openai", "gemini", "ollama"}
+
+// ModerationResult is the verdict of Moderate with its evidence
+type ModerationResult struct {
+	Verdict Verdict                        `json:"verdict"`
+	Scores  map[ModerationCategory]float32 `json:"scores,omitempty"`  // Highest score of each category found
+	Reasons []string                       `json:"reasons,omitempty"` // Categories over a threshold, e.g. "adult 0.92 (aws: Explicit Nudity)"
+	Labels  map[string][]ModerationLabel   `json:"labels,omitempty"`  // Labels by provider
+	Errors  map[string]string              `json:"errors,omitempty"`  // Providers that failed
+}
+
+// Moderate asks providers for moderation labels (FeatureSafeSearch), maps
+// their taxonomies to ModerationCategory and returns a verdict: block when
+// a blocking category scores at least Threshold, review when any category
+// scores at least ReviewThreshold, allow otherwise. Labels without a
+// confidence score their severity ("LIKELY", "high"), or ReviewThreshold
+// when they have none. When some providers fail, the verdict of the others
+// is raised to at least review; when all fail, the error is returned.
+//
+// Example:
+//
+//	result, err := detection.Moderate(ctx, img, detection.ModerateOptions{Providers: []string{"vision", "aws"}})
+//	if err != nil {
+//		return err
+//	}
+//	switch result.Verdict {
+//	case detection.VerdictBlock:
+//		return errRejected
+//	case detection.VerdictReview:
+//		queue.Add(upload, result.Reasons)
+//	}
+func Moderate(ctx context.Context, img image.Image, opts ModerateOptions) (*ModerationResult, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.8
+	}
+	if opts.ReviewThreshold <= 0 {
+		opts.ReviewThreshold = 0.6
+	}
+	if opts.Block == nil {
+		opts.Block = []ModerationCategory{CategoryAdult, CategoryViolence, CategoryHate}
+	}
+	providers := opts.Providers
+	if len(providers) == 0 {
+		configured := ConfiguredProviders()
+		for _, p := range moderationProviders {
+			if slices.Contains(configured, p) {
+				providers = []string{p}
+				break
+			}
+		}
+		if len(providers) == 0 {
+			return nil, fmt.Errorf("%w: no moderation provider (vision, aws, openai, gemini or ollama)", ErrProviderNotConfigured)
+		}
+	}
+
+	detectOpts := DefaultDetectOptions()
+	detectOpts.Features = []Feature{FeatureSafeSearch}
+	detectOpts.MinConfidence = 0 // The thresholds judge
+	result := &ModerationResult{Labels: make(map[string][]ModerationLabel)}
+	var lastErr error
+	for _, provider := range providers {
+		detected, err := DetectImage(ctx, img, provider, detectOpts)
+		if err != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[provider] = err.Error()
+			lastErr = err
+			continue
+		}
+		result.Labels[provider] = moderationLabels(detected)
+	}
+	if len(result.Labels) == 0 {
+		return nil, lastErr
+	}
+	result.judge(opts)
+	return result, nil
+}
+
+// moderationLabels returns the safe-search and moderation labels of a
+// result, without duplicates
+func moderationLabels(result *DetectionResult) []ModerationLabel {
+	var labels []ModerationLabel
+	if result.SafeSearch != nil {
+		labels = append(labels, result.SafeSearch.Labels...)
+	}
+	labels = append(labels, result.Moderation...)
+	seen := make(map[string]bool)
+	return slices.DeleteFunc(labels, func(l ModerationLabel) bool {
+		key := strings.ToLower(l.Parent + "/" + l.Name)
+		dup := seen[key]
+		seen[key] = true
+		return dup
+	})
+}
+
+// judge scores the labels of r by category and sets the verdict
+func (r *ModerationResult) judge(opts ModerateOptions) {
+	r.Scores = make(map[ModerationCategory]float32)
+	sources := make(map[ModerationCategory]string)
+	providers := make([]string, 0, len(r.Labels))
+	for p := range r.Labels {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		for _, label := range r.Labels[provider] {
+			category, ok := CategorizeModerationLabel(label)
+			if !ok {
+				continue
+			}
+			score := label.Confidence
+			if score == 0 {
+				score = opts.ReviewThreshold
+				if s, ok := moderationSeverities[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(label.Severity), " ", "_"))]; ok {
+					score = s
+				}
+			}
+			if score > r.Scores[category] {
+				r.Scores[category] = score
+				sources[category] = provider + ": " + label.Name
+			}
+		}
+	}
+
+	categories := make([]ModerationCategory, 0, len(r.Scores))
+	for c := range r.Scores {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool { return r.Scores[categories[i]] > r.Scores[categories[j]] })
+
+	r.Verdict = VerdictAllow
+	for _, c := range categories {
+		score := r.Scores[c]
+		switch {
+		case score >= opts.Threshold && slices.Contains(opts.Block, c):
+			r.Verdict = VerdictBlock
+		case score >= opts.ReviewThreshold:
+			if r.Verdict == VerdictAllow {
+				r.Verdict = VerdictReview
+			}
+		default:
+			continue
+		}
+		r.Reasons = append(r.Reasons, fmt.Sprintf("%s %.2f (%s)", c, score, sources[c]))
+	}
+	if len(r.Errors) > 0 && r.Verdict == VerdictAllow {
+		r.Verdict = VerdictReview
+		r.Reasons = append(r.Reasons, "a provider failed")
+	}
+}
@@ -0,0 +1,105 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCategorizeModerationLabel(t *testing.T) {
+	tests := []struct {
+		label ModerationLabel
+		want  ModerationCategory
+	}{
+		{ModerationLabel{Name: "Adult"}, CategoryAdult},                                          // Cloud Vision
+		{ModerationLabel{Name: "Racy"}, CategorySuggestive},                                      // Cloud Vision
+		{ModerationLabel{Name: "Graphic Male Nudity", Parent: "Explicit Nudity"}, CategoryAdult}, // AWS
+		{ModerationLabel{Name: "Kissing on the Lips", Parent: "Non-Explicit Nudity of Intimate parts and Kissing"}, CategorySuggestive},
+		{ModerationLabel{Name: "Female Swimwear or Underwear"}, CategorySuggestive},
+		{ModerationLabel{Name: "Visually Disturbing"}, CategoryViolence},
+		{ModerationLabel{Name: "Middle Finger", Parent: "Rude Gestures"}, CategoryHate},
+		{ModerationLabel{Name: "Drugs & Tobacco"}, CategoryDrugs},
+		{ModerationLabel{Name: "nsfw"}, CategoryAdult}, // LLM
+		{ModerationLabel{Name: "Gambling"}, CategoryOther},
+	}
+	for _, tt := range tests {
+		if got, ok := CategorizeModerationLabel(tt.label); !ok || got != tt.want {
+			t.Errorf("CategorizeModerationLabel(%+v) = %q, %v, want %q", tt.label, got, ok, tt.want)
+		}
+	}
+	if _, ok := CategorizeModerationLabel(ModerationLabel{Name: "Safe"}); ok {
+		t.Error("CategorizeModerationLabel(Safe) ok = true")
+	}
+}
+
+func TestModerationVerdict(t *testing.T) {
+	opts := ModerateOptions{Threshold: 0.8, ReviewThreshold: 0.6, Block: []ModerationCategory{CategoryAdult, CategoryViolence}}
+	tests := []struct {
+		name   string
+		labels map[string][]ModerationLabel
+		errors map[string]string
+		want   Verdict
+	}{
+		{"safe", map[string][]ModerationLabel{"vision": {{Name: "Adult", Confidence: 0.1}, {Name: "Racy", Confidence: 0.5}}}, nil, VerdictAllow},
+		{"racy only reviews", map[string][]ModerationLabel{"vision": {{Name: "Racy", Confidence: 0.9}}}, nil, VerdictReview},
+		{"adult blocks", map[string][]ModerationLabel{"aws": {{Name: "Explicit Nudity", Confidence: 0.93}}}, nil, VerdictBlock},
+		{"highest provider wins", map[string][]ModerationLabel{
+			"aws":    {{Name: "Violence", Confidence: 0.4}},
+			"vision": {{Name: "Violence", Severity: "VERY_LIKELY"}},
+		}, nil, VerdictBlock},
+		{"no confidence reviews", map[string][]ModerationLabel{"ollama": {{Name: "weapon"}}}, nil, VerdictReview},
+		{"failed provider reviews", map[string][]ModerationLabel{"vision": nil}, map[string]string{"aws": "timeout"}, VerdictReview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ModerationResult{Labels: tt.labels, Errors: tt.errors}
+			r.judge(opts)
+			if r.Verdict != tt.want {
+				t.Errorf("verdict = %s (%v), want %s", r.Verdict, r.Reasons, tt.want)
+			}
+		})
+	}
+
+	r := &ModerationResult{Labels: map[string][]ModerationLabel{"aws": {{Name: "Graphic Violence", Parent: "Violence", Confidence: 0.85}}}}
+	r.judge(opts)
+	if want := []string{"violence 0.85 (aws: Graphic Violence)"}; !reflect.DeepEqual(r.Reasons, want) {
+		t.Errorf("reasons = %q, want %q", r.Reasons, want)
+	}
+}
+
+// TestModerate tests moderation with an Ollama stub and a provider that
+// fails
+func TestModerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `{"moderation":[{"name":"violence","confidence":0.95}],"safe_search":{"notes":"a fight"}}`
+		json.NewEncoder(w).Encode(map[string]any{"model": "test", "response": response, "done": true})
+	}))
+	defer server.Close()
+	t.Setenv("IMGX_OLLAMA_HOST", server.URL)
+	t.Setenv("OLLAMA_HOST", "")
+	ctx := context.Background()
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+
+	result, err := Moderate(ctx, img, ModerateOptions{Providers: []string{"ollama"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verdict != VerdictBlock || result.Scores[CategoryViolence] != 0.95 {
+		t.Errorf("Moderate() = %+v, want block for violence", result)
+	}
+
+	result, err = Moderate(ctx, img, ModerateOptions{Providers: []string{"ollama", "nonexistent"}, Threshold: 0.99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verdict != VerdictReview || result.Errors["nonexistent"] == "" {
+		t.Errorf("Moderate() with a failing provider = %+v, want review", result)
+	}
+	if _, err := Moderate(ctx, img, ModerateOptions{Providers: []string{"nonexistent"}}); err == nil {
+		t.Error("Moderate() with only failing providers succeeded")
+	}
+}
@@ -0,0 +1,50 @@
+package detection
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestOfflineMode tests that cloud providers fail fast in offline mode
+func TestOfflineMode(t *testing.T) {
+	SetOffline(true)
+	defer SetOffline(false)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	for _, name := range []string{"gemini", "openai", "aws"} {
+		if _, err := GetProvider(name); !IsOfflineError(err) {
+			t.Errorf("GetProvider(%q) error = %v, want ErrOffline", name, err)
+		}
+	}
+
+	t.Setenv("IMGX_OLLAMA_HOST", "http://localhost:11434")
+	if _, err := GetProvider("ollama"); err != nil {
+		t.Errorf("GetProvider(ollama) with a local host error = %v", err)
+	}
+	t.Setenv("IMGX_OLLAMA_HOST", "gpu-box.internal:11434")
+	if _, err := GetProvider("ollama"); !IsOfflineError(err) {
+		t.Errorf("GetProvider(ollama) with a remote host error = %v, want ErrOffline", err)
+	}
+
+	// The auto router applies its offline rule.
+	img := createTestImage(10, 10, color.NRGBA{A: 255})
+	if got, rule := DefaultRouter().Route(img, &DetectOptions{Features: []Feature{FeatureFaces}}); got != "ollama" || rule.Name != "offline" {
+		t.Errorf("Route() in offline mode = %q, want ollama", got)
+	}
+}
+
+// TestIsLocalHost tests loopback detection of Ollama endpoints
+func TestIsLocalHost(t *testing.T) {
+	for endpoint, want := range map[string]bool{
+		"http://127.0.0.1:11434":  true,
+		"http://localhost:11434":  true,
+		"http://[::1]:11434":      true,
+		"http://192.168.1.5:1143": false,
+		"https://ollama.example":  false,
+	} {
+		if got := isLocalHost(endpoint); got != want {
+			t.Errorf("isLocalHost(%q) = %v, want %v", endpoint, got, want)
+		}
+	}
+}
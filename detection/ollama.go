@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"image"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -30,7 +32,7 @@ type ollamaGenerateRequest struct {
 	Model  string   `json:"model"`
 	Prompt string   `json:"prompt"`
 	Images []string `json:"images"`
-	Format string   `json:"format"`
+	Format any      `json:"format"` // "json" or a JSON schema
 	Stream bool     `json:"stream"`
 }
 
@@ -57,6 +59,10 @@ func NewOllamaProvider() (*OllamaProvider, error) {
 	}
 	host = strings.TrimRight(host, "/")
 
+	if IsOffline() && !isLocalHost(host) {
+		return nil, NewDetectionError("ollama", fmt.Sprintf("host %s is not local", host), ErrOffline)
+	}
+
 	model := strings.TrimSpace(os.Getenv("IMGX_OLLAMA_MODEL"))
 	if model == "" {
 		model = defaultOllamaModel
@@ -70,9 +76,9 @@ func NewOllamaProvider() (*OllamaProvider, error) {
 	return &OllamaProvider{
 		endpoint: host,
 		model:    model,
-		client: &http.Client{
+		client: debugHTTPClient("ollama", &http.Client{
 			Timeout: time.Duration(timeoutSeconds) * time.Second,
-		},
+		}),
 	}, nil
 }
 
@@ -91,6 +97,7 @@ func (o *OllamaProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 	if opts == nil {
 		opts = DefaultDetectOptions()
 	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
 
 	startTime := time.Now()
 
@@ -109,6 +116,10 @@ func (o *OllamaProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 		Format: "json",
 		Stream: false,
 	}
+	if opts.CustomPrompt != "" && opts.ResponseSchema != nil {
+		// Ollama constrains the output to a JSON schema given as format.
+		reqBody.Format = opts.ResponseSchema.Schema
+	}
 
 	endpoint := o.endpoint + "/api/generate"
 
@@ -140,7 +151,7 @@ func (o *OllamaProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 		if message == "" {
 			message = resp.Status
 		}
-		return nil, NewDetectionError("ollama", fmt.Sprintf("API returned %s: %s", resp.Status, message), nil)
+		return nil, NewDetectionError("ollama", fmt.Sprintf("API returned %s: %s", resp.Status, message), httpStatusError(resp.StatusCode))
 	}
 
 	var parsed ollamaGenerateResponse
@@ -189,3 +200,17 @@ func (o *OllamaProvider) parseJSONResponse(text string, result *DetectionResult)
 func (o *OllamaProvider) extractLabelsFromText(text string, opts *DetectOptions) []Label {
 	return extractLabelsFromPlainText(text, opts)
 }
+
+// isLocalHost reports whether an Ollama endpoint URL points at this machine
+func isLocalHost(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
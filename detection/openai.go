@@ -1,10 +1,13 @@
 package detection
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -13,27 +16,96 @@ import (
 	"github.com/openai/openai-go/option"
 )
 
+// openAIDetectModel is the OpenAI model used for detection
+const openAIDetectModel = openai.ChatModelGPT4o
+
 // OpenAIProvider implements the Provider interface for OpenAI Vision
 type OpenAIProvider struct {
 	client *openai.Client
+	model  string // Detection model; "" is openAIDetectModel
+}
+
+// OpenAIConfig configures an OpenAI provider created with
+// NewOpenAIProviderWithConfig
+type OpenAIConfig struct {
+	// APIKey is the OpenAI API key (required)
+	APIKey string
+
+	// Model is the detection model, e.g. "gpt-4o-mini". Default gpt-4o.
+	Model string
+
+	// Endpoint is the base URL of the API, e.g. of Azure OpenAI or an
+	// OpenAI-compatible gateway. Default the OpenAI API.
+	Endpoint string
+
+	// Organization and Project are sent with every request, for keys of
+	// several organizations or projects
+	Organization string
+	Project      string
+
+	// HTTPClient sends the requests. Default a new client.
+	HTTPClient *http.Client
 }
 
-// NewOpenAIProvider creates a new OpenAI Vision provider instance
+// NewOpenAIProvider creates a new OpenAI Vision provider instance from the
+// OPENAI_API_KEY environment variable
 func NewOpenAIProvider() (*OpenAIProvider, error) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("%w: OPENAI_API_KEY environment variable not set", ErrProviderNotConfigured)
 	}
+	return NewOpenAIProviderWithConfig(OpenAIConfig{APIKey: apiKey})
+}
+
+// NewOpenAIProviderWithConfig creates an OpenAI provider with explicit
+// settings instead of environment variables, e.g. one per customer with
+// their own API key.
+//
+// Example:
+//
+//	provider, err := detection.NewOpenAIProviderWithConfig(detection.OpenAIConfig{
+//		APIKey:   tenant.OpenAIKey,
+//		Model:    "gpt-4o-mini",
+//		Endpoint: "https://gateway.example.com/v1",
+//	})
+func NewOpenAIProviderWithConfig(cfg OpenAIConfig) (*OpenAIProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%w: OpenAI API key not set", ErrProviderNotConfigured)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
 
-	client := openai.NewClient(
-		option.WithAPIKey(apiKey),
-	)
+	opts := []option.RequestOption{
+		option.WithAPIKey(cfg.APIKey),
+		option.WithHTTPClient(debugHTTPClient("openai", httpClient)),
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithBaseURL(cfg.Endpoint))
+	}
+	if cfg.Organization != "" {
+		opts = append(opts, option.WithOrganization(cfg.Organization))
+	}
+	if cfg.Project != "" {
+		opts = append(opts, option.WithProject(cfg.Project))
+	}
+	client := openai.NewClient(opts...)
 
 	return &OpenAIProvider{
 		client: &client,
+		model:  cfg.Model,
 	}, nil
 }
 
+// detectModel returns the model used for detection
+func (o *OpenAIProvider) detectModel() string {
+	if o.model != "" {
+		return o.model
+	}
+	return openAIDetectModel
+}
+
 // Name returns the provider name
 func (o *OpenAIProvider) Name() string {
 	return "openai"
@@ -49,6 +121,7 @@ func (o *OpenAIProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 	if opts == nil {
 		opts = DefaultDetectOptions()
 	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
 
 	startTime := time.Now()
 
@@ -64,8 +137,9 @@ func (o *OpenAIProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 	// Build prompt based on features or use custom prompt
 	prompt := o.buildPrompt(opts)
 
-	// Create chat completion request with vision
-	chatCompletion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+	// Create chat completion request with vision, with structured output so
+	// the response is valid JSON of the requested shape
+	params := openai.ChatCompletionNewParams{
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.UserMessage([]openai.ChatCompletionContentPartUnionParam{
 				openai.TextContentPart(prompt),
@@ -75,10 +149,19 @@ func (o *OpenAIProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 				}),
 			}),
 		},
-		Model:     openai.ChatModelGPT4o,
-		MaxTokens: openai.Int(500),
-	})
-
+		Model:          o.detectModel(),
+		MaxTokens:      openai.Int(500),
+		ResponseFormat: o.responseFormat(opts),
+	}
+	chatCompletion, err := o.client.Chat.Completions.New(ctx, params)
+	var warning string
+	if err != nil && params.ResponseFormat.OfJSONSchema != nil && openAIBadRequest(err) {
+		// Models without structured outputs reject the schema; the prompt
+		// asks for the same JSON, read by the heuristic parser
+		warning = "the model rejected the response schema; parsed the JSON asked for in the prompt"
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{}
+		chatCompletion, err = o.client.Chat.Completions.New(ctx, params)
+	}
 	if err != nil {
 		return nil, NewDetectionError("openai", "API request failed", err)
 	}
@@ -89,6 +172,9 @@ func (o *OpenAIProvider) Detect(ctx context.Context, img *image.NRGBA, opts *Det
 		return nil, NewDetectionError("openai", "failed to parse response", err)
 	}
 
+	if warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
 	result.Provider = "openai"
 	result.ProcessedAt = startTime
 
@@ -100,6 +186,38 @@ func (o *OpenAIProvider) buildPrompt(opts *DetectOptions) string {
 	return buildDetectionPrompt(opts)
 }
 
+// responseFormat returns the response format of a detection: a strict JSON
+// schema of the requested features (see detectionResponseSchema), the
+// ResponseSchema of a custom prompt, or plain text
+func (o *OpenAIProvider) responseFormat(opts *DetectOptions) openai.ChatCompletionNewParamsResponseFormatUnion {
+	var schema openai.ResponseFormatJSONSchemaJSONSchemaParam
+	switch {
+	case opts.CustomPrompt == "":
+		schema = openai.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   "detection_result",
+			Schema: detectionResponseSchema(opts),
+			Strict: openai.Bool(true),
+		}
+	case opts.ResponseSchema != nil:
+		// Custom schemas need not follow the subset of strict mode
+		schema = openai.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   "custom_response",
+			Schema: opts.ResponseSchema.Schema,
+		}
+	default:
+		return openai.ChatCompletionNewParamsResponseFormatUnion{}
+	}
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{JSONSchema: schema},
+	}
+}
+
+// openAIBadRequest reports whether err is a 400 response of the OpenAI API
+func openAIBadRequest(err error) bool {
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
 // parseResponse parses OpenAI API response into DetectionResult
 func (o *OpenAIProvider) parseResponse(resp *openai.ChatCompletion, opts *DetectOptions) (*DetectionResult, error) {
 	empty := &DetectionResult{
@@ -131,3 +249,60 @@ func (o *OpenAIProvider) Close() error {
 	// OpenAI client doesn't require explicit cleanup
 	return nil
 }
+
+// EditImage edits an image following instruction using gpt-image-1
+func (o *OpenAIProvider) EditImage(ctx context.Context, img *image.NRGBA, instruction string) (*image.NRGBA, error) {
+	imgBytes, err := imageToPNGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError("openai", "failed to encode image", err)
+	}
+
+	resp, err := o.client.Images.Edit(ctx, openai.ImageEditParams{
+		Image: openai.ImageEditParamsImageUnion{
+			OfFile: openai.File(bytes.NewReader(imgBytes), "image.png", "image/png"),
+		},
+		Prompt:        instruction,
+		Model:         openai.ImageModelGPTImage1,
+		InputFidelity: openai.ImageEditParamsInputFidelityHigh,
+		OutputFormat:  openai.ImageEditParamsOutputFormatPNG,
+		Size:          openai.ImageEditParamsSizeAuto,
+	})
+	if err != nil {
+		return nil, NewDetectionError("openai", "API request failed", err)
+	}
+	return decodeOpenAIImage(resp)
+}
+
+// decodeOpenAIImage returns the first image of an images API response
+func decodeOpenAIImage(resp *openai.ImagesResponse) (*image.NRGBA, error) {
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
+		return nil, NewDetectionError("openai", "no image in response", ErrAPIError)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil {
+		return nil, NewDetectionError("openai", "failed to decode image", err)
+	}
+	img, err := decodeImageBytes(data)
+	if err != nil {
+		return nil, NewDetectionError("openai", "failed to decode image", err)
+	}
+	return img, nil
+}
+
+// openAIImageSizes are the output sizes supported by gpt-image-1
+var openAIImageSizes = []string{"1024x1024", "1536x1024", "1024x1536"}
+
+// GenerateImage creates an image from prompt using gpt-image-1
+func (o *OpenAIProvider) GenerateImage(ctx context.Context, prompt string, opts *GenerateOptions) (*image.NRGBA, error) {
+	resp, err := o.client.Images.Generate(ctx, openai.ImageGenerateParams{
+		Prompt:       prompt,
+		Model:        openai.ImageModelGPTImage1,
+		OutputFormat: openai.ImageGenerateParamsOutputFormatPNG,
+		Size:         openai.ImageGenerateParamsSize(closestAspect(opts.aspectRatio(), openAIImageSizes)),
+	})
+	if err != nil {
+		return nil, NewDetectionError("openai", "API request failed", err)
+	}
+	return decodeOpenAIImage(resp)
+}
@@ -2,10 +2,18 @@ package detection
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"image/color"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
 // TestOpenAIProviderName tests the Name method
@@ -783,3 +791,112 @@ func BenchmarkOpenAIProviderDetect(b *testing.B) {
 		}
 	}
 }
+
+// TestOpenAIProviderStructuredOutput tests the response format of detection
+// requests and the fallback when the model rejects it
+func TestOpenAIProviderStructuredOutput(t *testing.T) {
+	var formats []string
+	reject := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResponseFormat *struct {
+				Type       string `json:"type"`
+				JSONSchema struct {
+					Name   string `json:"name"`
+					Strict bool   `json:"strict"`
+				} `json:"json_schema"`
+			} `json:"response_format"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		format := "text"
+		if req.ResponseFormat != nil {
+			format = fmt.Sprintf("%s/%s/%v", req.ResponseFormat.Type, req.ResponseFormat.JSONSchema.Name, req.ResponseFormat.JSONSchema.Strict)
+		}
+		formats = append(formats, format)
+		if reject && req.ResponseFormat != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "response_format is not supported", "type": "invalid_request_error"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "1", "object": "chat.completion", "choices": [{"index": 0, "finish_reason": "stop",
+			"message": {"role": "assistant", "content": "{\"labels\": [{\"name\": \"cat\", \"confidence\": 0.9}]}"}}]}`)
+	}))
+	defer server.Close()
+
+	client := openai.NewClient(option.WithAPIKey("test"), option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	provider := &OpenAIProvider{client: &client}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	opts := &DetectOptions{Features: []Feature{FeatureLabels}, MaxResults: 5}
+
+	result, err := provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if want := []string{"json_schema/detection_result/true", "text"}; !reflect.DeepEqual(formats, want) {
+		t.Errorf("response formats = %v, want %v", formats, want)
+	}
+	if len(result.Labels) != 1 || len(result.Warnings) != 1 {
+		t.Errorf("fallback result = %+v, want the label and a warning", result)
+	}
+
+	formats, reject = nil, false
+	result, err = provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if len(formats) != 1 || len(result.Labels) != 1 || len(result.Warnings) != 0 {
+		t.Errorf("structured result = %+v after %v", result, formats)
+	}
+
+	formats = nil
+	schema, _ := NewResponseSchema([]byte(`{"type": "object", "properties": {"cat": {"type": "boolean"}}}`))
+	provider.Detect(context.Background(), img, &DetectOptions{CustomPrompt: "Is there a cat?", ResponseSchema: schema})
+	provider.Detect(context.Background(), img, &DetectOptions{CustomPrompt: "Is there a cat?"})
+	if want := []string{"json_schema/custom_response/false", "text"}; !reflect.DeepEqual(formats, want) {
+		t.Errorf("custom prompt response formats = %v, want %v", formats, want)
+	}
+}
+
+// TestNewOpenAIProviderWithConfig tests the API key, model, endpoint and
+// organization of an explicitly configured provider
+func TestNewOpenAIProviderWithConfig(t *testing.T) {
+	if _, err := NewOpenAIProviderWithConfig(OpenAIConfig{}); !IsNotConfigured(err) {
+		t.Errorf("NewOpenAIProviderWithConfig() without a key error = %v, want ErrProviderNotConfigured", err)
+	}
+
+	var path, auth, org, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		path, auth, org, model = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("OpenAI-Organization"), req.Model
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "1", "object": "chat.completion", "choices": [{"index": 0, "finish_reason": "stop",
+			"message": {"role": "assistant", "content": "{\"labels\": [{\"name\": \"cat\", \"confidence\": 0.9}]}"}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProviderWithConfig(OpenAIConfig{
+		APIKey:       "tenant-key",
+		Model:        "gpt-4o-mini",
+		Endpoint:     server.URL + "/v1",
+		Organization: "org-tenant",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := createTestImage(8, 8, color.NRGBA{R: 255, A: 255})
+	result, err := provider.Detect(context.Background(), img, &DetectOptions{Features: []Feature{FeatureLabels}})
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if path != "/v1/chat/completions" || auth != "Bearer tenant-key" || org != "org-tenant" || model != "gpt-4o-mini" {
+		t.Errorf("request to %s with %q, %q, model %q, want the configured endpoint, key, organization and model", path, auth, org, model)
+	}
+	if len(result.Labels) != 1 {
+		t.Errorf("Labels = %v", result.Labels)
+	}
+}
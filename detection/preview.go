@@ -0,0 +1,316 @@
+package detection
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// List prices used for cost estimates, in USD. They are approximate and
+// only cover the request side; check the provider's pricing page for
+// current rates.
+const (
+	geminiInputPricePerMillion = 0.10   // gemini-2.0-flash input tokens
+	openAIInputPricePerMillion = 2.50   // gpt-4o input tokens
+	awsPricePerCall            = 0.001  // Rekognition image APIs, first 1M images/month
+	visionPricePerFeature      = 0.0015 // Cloud Vision, per feature and image, first 5M units/month
+)
+
+// RequestPreview describes the request a provider would send for a
+// detection, without calling the API. Use it to debug prompts and to
+// estimate cost before running a batch.
+type RequestPreview struct {
+	Provider       string   `json:"provider"`
+	Model          string   `json:"model,omitempty"`
+	Prompt         string   `json:"prompt,omitempty"`          // Exact prompt text (LLM providers)
+	Operations     []string `json:"operations,omitempty"`      // API calls or features requested (AWS, Cloud Vision)
+	ResponseFormat string   `json:"response_format,omitempty"` // "json", "json_schema" or "text"
+	ImageWidth     int      `json:"image_width"`
+	ImageHeight    int      `json:"image_height"`
+	ImageBytes     int      `json:"image_bytes"`             // Size of the encoded image sent
+	PromptTokens   int      `json:"estimated_prompt_tokens"` // Estimated tokens of the prompt text
+	ImageTokens    int      `json:"estimated_image_tokens"`  // Estimated tokens of the image
+	EstimatedCost  float64  `json:"estimated_cost_usd"`      // Estimated request cost in USD, 0 for local models
+	Notes          []string `json:"notes,omitempty"`         // Caveats about the estimate
+}
+
+// RequestPreviewer is implemented by providers that can describe the
+// request they would send (all built-in providers)
+type RequestPreviewer interface {
+	// BuildRequestPreview returns the request that Detect would send for img
+	BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error)
+}
+
+// Compile-time checks for providers that support request previews
+var (
+	_ RequestPreviewer = (*OllamaProvider)(nil)
+	_ RequestPreviewer = (*GeminiProvider)(nil)
+	_ RequestPreviewer = (*OpenAIProvider)(nil)
+	_ RequestPreviewer = (*AWSProvider)(nil)
+	_ RequestPreviewer = (*VisionProvider)(nil)
+	_ RequestPreviewer = (*YOLOProvider)(nil)
+)
+
+// PreviewRequest returns the request the provider would send to detect img
+// with opts: prompt, model, image size and estimated tokens and cost. It
+// makes no API call and does not need credentials. For a provider chain the
+// request of the first provider is returned.
+//
+// Example:
+//
+//	preview, err := detection.PreviewRequest(img.ToNRGBA(), "gemini", opts)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(preview.Prompt)
+//	fmt.Printf("~%d tokens, ~$%.5f\n", preview.PromptTokens+preview.ImageTokens, preview.EstimatedCost)
+func PreviewRequest(img *image.NRGBA, provider string, opts *DetectOptions) (*RequestPreview, error) {
+	// A provider chain sends the request of its first provider
+	provider, _, _ = strings.Cut(provider, ",")
+	var prov RequestPreviewer
+	switch ResolveProviderAlias(provider) {
+	case AutoProvider:
+		router, err := LoadRouter("")
+		if err != nil {
+			return nil, err
+		}
+		return router.PreviewRequest(img, opts)
+	case "gemini":
+		prov = &GeminiProvider{}
+	case "ollama":
+		o, err := NewOllamaProvider()
+		if err != nil {
+			return nil, err
+		}
+		prov = o
+	case "aws", "rekognition":
+		prov = &AWSProvider{}
+	case "openai", "gpt4vision", "gpt-4-vision":
+		prov = &OpenAIProvider{}
+	case "vision":
+		prov = &VisionProvider{}
+	case "yolo":
+		y, err := NewYOLOProvider()
+		if err != nil {
+			return nil, err
+		}
+		prov = y
+	default:
+		return nil, fmt.Errorf("unknown provider: %s (valid: gemini, google, ollama, aws, openai, vision, yolo)", provider)
+	}
+	return prov.BuildRequestPreview(img, opts)
+}
+
+// BuildRequestPreview returns the request Detect would send to Ollama
+func (o *OllamaProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	preview, err := newLLMPreview("ollama", o.model, img, o.buildPrompt(opts))
+	if err != nil {
+		return nil, err
+	}
+	preview.ResponseFormat = "json"
+	if opts.CustomPrompt != "" && opts.ResponseSchema != nil {
+		preview.ResponseFormat = "json_schema"
+	}
+	// Vision encoders of local models use a fixed budget per image (256 for gemma3).
+	preview.ImageTokens = 256
+	preview.Notes = append(preview.Notes, "local model: no API cost; image tokens depend on the model")
+	return preview, nil
+}
+
+// BuildRequestPreview returns the request Detect would send to Gemini
+func (g *GeminiProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	preview, err := newLLMPreview("gemini", g.detectModel(opts), img, g.buildPrompt(opts))
+	if err != nil {
+		return nil, err
+	}
+	preview.ResponseFormat = "text"
+	if g.responseConfig(opts) != nil {
+		preview.ResponseFormat = "json_schema"
+	}
+	preview.ImageTokens = geminiImageTokens(preview.ImageWidth, preview.ImageHeight)
+	preview.EstimatedCost = float64(preview.PromptTokens+preview.ImageTokens) * geminiInputPricePerMillion / 1e6
+	preview.Notes = append(preview.Notes, "cost covers input tokens only; output tokens are billed separately")
+	if preview.Model != geminiDetectModel {
+		preview.Notes = append(preview.Notes, fmt.Sprintf("cost uses %s prices; %s may be billed differently", geminiDetectModel, preview.Model))
+	}
+	return preview, nil
+}
+
+// BuildRequestPreview returns the request Detect would send to OpenAI
+func (o *OpenAIProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	preview, err := newLLMPreview("openai", o.detectModel(), img, o.buildPrompt(opts))
+	if err != nil {
+		return nil, err
+	}
+	preview.ResponseFormat = "text"
+	if o.responseFormat(opts).OfJSONSchema != nil {
+		preview.ResponseFormat = "json_schema"
+	}
+	preview.ImageTokens = openAIImageTokens(preview.ImageWidth, preview.ImageHeight)
+	preview.EstimatedCost = float64(preview.PromptTokens+preview.ImageTokens) * openAIInputPricePerMillion / 1e6
+	preview.Notes = append(preview.Notes, "cost covers input tokens only; output tokens are billed separately")
+	return preview, nil
+}
+
+// BuildRequestPreview returns the Rekognition calls Detect would make
+func (a *AWSProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	imgBytes, err := imageToJPEGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError("aws", "failed to encode image", err)
+	}
+
+	preview := &RequestPreview{
+		Provider:    "aws",
+		ImageWidth:  img.Bounds().Dx(),
+		ImageHeight: img.Bounds().Dy(),
+		ImageBytes:  len(imgBytes),
+	}
+
+	labels := containsFeature(opts.Features, FeatureLabels) || containsFeature(opts.Features, FeatureObjects)
+	properties := containsFeature(opts.Features, FeatureProperties)
+	switch {
+	case labels && properties:
+		preview.Operations = append(preview.Operations, "DetectLabels (GENERAL_LABELS, IMAGE_PROPERTIES)")
+	case labels:
+		preview.Operations = append(preview.Operations, "DetectLabels")
+	case properties:
+		preview.Operations = append(preview.Operations, "DetectLabels (IMAGE_PROPERTIES)")
+	}
+	if containsFeature(opts.Features, FeatureText) {
+		preview.Operations = append(preview.Operations, "DetectText")
+	}
+	if containsFeature(opts.Features, FeatureFaces) {
+		preview.Operations = append(preview.Operations, "DetectFaces")
+	}
+	if containsFeature(opts.Features, FeatureSafeSearch) {
+		preview.Operations = append(preview.Operations, "DetectModerationLabels")
+	}
+
+	preview.EstimatedCost = float64(len(preview.Operations)) * awsPricePerCall
+	if properties {
+		preview.Notes = append(preview.Notes, "image properties are billed in addition to labels")
+	}
+	if opts.CustomPrompt != "" {
+		preview.Notes = append(preview.Notes, "custom prompts are ignored by AWS Rekognition")
+	}
+	return preview, nil
+}
+
+// BuildRequestPreview returns the Cloud Vision features Detect would request
+func (v *VisionProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	imgBytes, err := imageToJPEGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError("vision", "failed to encode image", err)
+	}
+
+	preview := &RequestPreview{
+		Provider:    "vision",
+		ImageWidth:  img.Bounds().Dx(),
+		ImageHeight: img.Bounds().Dy(),
+		ImageBytes:  len(imgBytes),
+	}
+	features, unsupported := v.buildFeatures(opts)
+	for _, feature := range features {
+		preview.Operations = append(preview.Operations, feature.Type)
+	}
+	preview.EstimatedCost = float64(len(features)) * visionPricePerFeature
+	for _, feature := range unsupported {
+		preview.Notes = append(preview.Notes, fmt.Sprintf("feature %q is not supported by Cloud Vision", feature))
+	}
+	if opts.CustomPrompt != "" {
+		preview.Notes = append(preview.Notes, "custom prompts are ignored by Cloud Vision")
+	}
+	return preview, nil
+}
+
+// BuildRequestPreview returns the inference request Detect would send to
+// the YOLO model server
+func (y *YOLOProvider) BuildRequestPreview(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	preview := &RequestPreview{
+		Provider:    "yolo",
+		Model:       y.model,
+		Operations:  []string{fmt.Sprintf("POST %s/v2/models/%s/infer", y.endpoint, y.model)},
+		ImageWidth:  img.Bounds().Dx(),
+		ImageHeight: img.Bounds().Dy(),
+		ImageBytes:  3 * y.inputSize * y.inputSize * 4, // FP32 input tensor
+		Notes: []string{
+			fmt.Sprintf("local model: no API cost; input letterboxed to %dx%d", y.inputSize, y.inputSize),
+		},
+	}
+	for _, feature := range opts.Features {
+		if feature != FeatureLabels && feature != FeatureObjects {
+			preview.Notes = append(preview.Notes, fmt.Sprintf("feature %q is not supported by YOLO models", feature))
+		}
+	}
+	if opts.CustomPrompt != "" {
+		preview.Notes = append(preview.Notes, "custom prompts are ignored by YOLO models")
+	}
+	return preview, nil
+}
+
+// newLLMPreview fills the fields shared by the prompt-based providers
+func newLLMPreview(provider, model string, img *image.NRGBA, prompt string) (*RequestPreview, error) {
+	imgBytes, err := imageToJPEGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError(provider, "failed to encode image", err)
+	}
+	return &RequestPreview{
+		Provider:     provider,
+		Model:        model,
+		Prompt:       prompt,
+		ImageWidth:   img.Bounds().Dx(),
+		ImageHeight:  img.Bounds().Dy(),
+		ImageBytes:   len(imgBytes),
+		PromptTokens: estimateTextTokens(prompt),
+	}, nil
+}
+
+// estimateTextTokens approximates the token count of English text
+// (about 4 characters per token)
+func estimateTextTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// geminiImageTokens estimates the tokens of an image sent to Gemini: 258
+// for images up to 384px, otherwise 258 per 768x768 tile
+func geminiImageTokens(width, height int) int {
+	if width <= 384 && height <= 384 {
+		return 258
+	}
+	tiles := int(math.Ceil(float64(width)/768) * math.Ceil(float64(height)/768))
+	return tiles * 258
+}
+
+// openAIImageTokens estimates the tokens of an image sent to GPT-4o with
+// high detail: the image is scaled to fit 2048x2048, then its short side
+// to 768px, and costs 85 tokens plus 170 per 512x512 tile
+func openAIImageTokens(width, height int) int {
+	w, h := float64(width), float64(height)
+	if s := 2048 / math.Max(w, h); s < 1 {
+		w, h = w*s, h*s
+	}
+	if s := 768 / math.Min(w, h); s < 1 {
+		w, h = w*s, h*s
+	}
+	tiles := int(math.Ceil(w/512) * math.Ceil(h/512))
+	return 85 + 170*tiles
+}
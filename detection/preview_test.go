@@ -0,0 +1,94 @@
+package detection
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// TestPreviewRequest tests request previews without credentials
+func TestPreviewRequest(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	img := createTestImage(1024, 768, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	opts := &DetectOptions{CustomPrompt: "Is there a dog in this image?"}
+	for _, provider := range []string{"ollama", "gemini", "google", "openai"} {
+		t.Run(provider, func(t *testing.T) {
+			preview, err := PreviewRequest(img, provider, opts)
+			if err != nil {
+				t.Fatalf("PreviewRequest() error: %v", err)
+			}
+			if preview.Prompt != opts.CustomPrompt {
+				t.Errorf("Prompt = %q, want %q", preview.Prompt, opts.CustomPrompt)
+			}
+			if preview.Model == "" {
+				t.Error("Model is empty")
+			}
+			if preview.ImageWidth != 1024 || preview.ImageHeight != 768 || preview.ImageBytes == 0 {
+				t.Errorf("image = %dx%d, %d bytes", preview.ImageWidth, preview.ImageHeight, preview.ImageBytes)
+			}
+			if preview.PromptTokens == 0 || preview.ImageTokens == 0 {
+				t.Errorf("tokens = %d prompt, %d image, want both > 0", preview.PromptTokens, preview.ImageTokens)
+			}
+			if (provider == "ollama") != (preview.EstimatedCost == 0) {
+				t.Errorf("EstimatedCost = %v", preview.EstimatedCost)
+			}
+		})
+	}
+
+	preview, err := PreviewRequest(img, "gemini", &DetectOptions{Features: []Feature{FeatureLabels}, MaxResults: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ResponseFormat != "json_schema" || !strings.Contains(preview.Prompt, "at most 5 labels") {
+		t.Errorf("gemini labels preview = %+v", preview)
+	}
+	preview, err = PreviewRequest(img, "gemini", DefaultDetectOptions().WithModel("gemini-1.5-pro"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Model != "gemini-1.5-pro" {
+		t.Errorf("gemini preview Model = %q, want the per-call model", preview.Model)
+	}
+	preview, err = PreviewRequest(img, "openai", &DetectOptions{CustomPrompt: "Is there a cat?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ResponseFormat != "text" {
+		t.Errorf("openai custom prompt ResponseFormat = %q, want text", preview.ResponseFormat)
+	}
+
+	preview, err = PreviewRequest(img, "aws", &DetectOptions{Features: []Feature{FeatureLabels, FeatureProperties, FeatureText}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Operations) != 2 || preview.Prompt != "" || preview.EstimatedCost != 2*awsPricePerCall {
+		t.Errorf("aws preview = %+v", preview)
+	}
+
+	if _, err := PreviewRequest(img, "unknown", opts); err == nil {
+		t.Error("PreviewRequest() with an unknown provider should fail")
+	}
+}
+
+// TestImageTokenEstimates tests the per-provider image token formulas
+func TestImageTokenEstimates(t *testing.T) {
+	tests := []struct {
+		width, height int
+		gemini        int
+		openai        int
+	}{
+		{256, 256, 258, 255},
+		{1024, 1024, 4 * 258, 765},
+		{2048, 4096, 18 * 258, 1105},
+	}
+	for _, tt := range tests {
+		if got := geminiImageTokens(tt.width, tt.height); got != tt.gemini {
+			t.Errorf("geminiImageTokens(%d, %d) = %d, want %d", tt.width, tt.height, got, tt.gemini)
+		}
+		if got := openAIImageTokens(tt.width, tt.height); got != tt.openai {
+			t.Errorf("openAIImageTokens(%d, %d) = %d, want %d", tt.width, tt.height, got, tt.openai)
+		}
+	}
+}
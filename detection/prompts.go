@@ -0,0 +1,133 @@
+package detection
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// promptTemplateExt is the file extension of prompt templates
+const promptTemplateExt = ".txt"
+
+var promptVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// PromptTemplate is a named prompt with {{variable}} placeholders
+type PromptTemplate struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	Text string `json:"text"`
+}
+
+// Variables returns the names of the variables used in the template, in
+// order of first use
+func (t *PromptTemplate) Variables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range promptVariable.FindAllStringSubmatch(t.Text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Render replaces the {{variable}} placeholders of the template with vars.
+// Every variable used in the template must have a value.
+//
+// Example:
+//
+//	tmpl, err := detection.LoadPromptTemplate("product-audit")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	prompt, err := tmpl.Render(map[string]string{"brand": "Acme"})
+//	result, err := detection.Detect(ctx, img.ToNRGBA(), "gemini", &detection.DetectOptions{CustomPrompt: prompt})
+func (t *PromptTemplate) Render(vars map[string]string) (string, error) {
+	var missing []string
+	for _, name := range t.Variables() {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("prompt template %q: missing value for %s", t.Name, strings.Join(missing, ", "))
+	}
+	return promptVariable.ReplaceAllStringFunc(t.Text, func(m string) string {
+		return vars[promptVariable.FindStringSubmatch(m)[1]]
+	}), nil
+}
+
+// PromptTemplateDir returns the directory holding named prompt templates:
+// $IMGX_PROMPTS, or "imgx/prompts" in the user config directory
+// (e.g. ~/.config/imgx/prompts). Each template is a <name>.txt file.
+func PromptTemplateDir() (string, error) {
+	if dir := os.Getenv("IMGX_PROMPTS"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "imgx", "prompts"), nil
+}
+
+// LoadPromptTemplate loads a prompt template by name from PromptTemplateDir,
+// or from a file when name is a path (contains a path separator or ends in
+// .txt)
+func LoadPromptTemplate(name string) (*PromptTemplate, error) {
+	path := name
+	if !strings.ContainsRune(name, os.PathSeparator) && !strings.Contains(name, "/") && filepath.Ext(name) != promptTemplateExt {
+		dir, err := PromptTemplateDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, name+promptTemplateExt)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("prompt template %q not found (looked for %s)", name, path)
+		}
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	return &PromptTemplate{
+		Name: strings.TrimSuffix(filepath.Base(path), promptTemplateExt),
+		Path: path,
+		Text: strings.TrimSpace(string(data)),
+	}, nil
+}
+
+// ListPromptTemplates returns the templates in PromptTemplateDir sorted by
+// name. A missing directory is not an error.
+func ListPromptTemplates() ([]*PromptTemplate, error) {
+	dir, err := PromptTemplateDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+
+	var templates []*PromptTemplate
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != promptTemplateExt {
+			continue
+		}
+		t, err := LoadPromptTemplate(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
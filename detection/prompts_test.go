@@ -0,0 +1,82 @@
+package detection
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestPromptTemplateRender tests variable substitution
+func TestPromptTemplateRender(t *testing.T) {
+	tmpl := &PromptTemplate{
+		Name: "product-audit",
+		Text: "Check that the {{brand}} logo is visible. Compare with {{ brand }} guidelines for {{product_line}}.",
+	}
+
+	if got, want := tmpl.Variables(), []string{"brand", "product_line"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+
+	got, err := tmpl.Render(map[string]string{"brand": "Acme", "product_line": "kettles", "unused": "x"})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	want := "Check that the Acme logo is visible. Compare with Acme guidelines for kettles."
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := tmpl.Render(map[string]string{"brand": "Acme"}); err == nil || !strings.Contains(err.Error(), "product_line") {
+		t.Errorf("Render() with a missing variable error = %v, want it to name product_line", err)
+	}
+}
+
+// TestLoadPromptTemplates tests loading templates from the template directory
+func TestLoadPromptTemplates(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("IMGX_PROMPTS", dir)
+	files := map[string]string{
+		"product-audit.txt": "Audit the {{brand}} product photo.\n",
+		"alt.txt":           "Write alt text.",
+		"notes.md":          "ignored",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmpl, err := LoadPromptTemplate("product-audit")
+	if err != nil {
+		t.Fatalf("LoadPromptTemplate() error: %v", err)
+	}
+	if tmpl.Name != "product-audit" || tmpl.Text != "Audit the {{brand}} product photo." {
+		t.Errorf("LoadPromptTemplate() = %+v", tmpl)
+	}
+
+	if tmpl, err := LoadPromptTemplate(filepath.Join(dir, "alt.txt")); err != nil || tmpl.Name != "alt" {
+		t.Errorf("LoadPromptTemplate(path) = %+v, %v", tmpl, err)
+	}
+	if _, err := LoadPromptTemplate("missing"); err == nil {
+		t.Error("LoadPromptTemplate() with an unknown name should fail")
+	}
+
+	templates, err := ListPromptTemplates()
+	if err != nil {
+		t.Fatalf("ListPromptTemplates() error: %v", err)
+	}
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	if want := []string{"alt", "product-audit"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListPromptTemplates() names = %v, want %v", names, want)
+	}
+
+	t.Setenv("IMGX_PROMPTS", filepath.Join(dir, "none"))
+	if templates, err := ListPromptTemplates(); err != nil || len(templates) != 0 {
+		t.Errorf("ListPromptTemplates() with a missing directory = %v, %v", templates, err)
+	}
+}
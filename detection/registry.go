@@ -0,0 +1,99 @@
+package detection
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProviderFactory creates a provider. It should return an error, without
+// contacting the service, when the provider is not configured.
+type ProviderFactory func() (Provider, error)
+
+// builtinProviders are the names GetProvider knows without registration
+var builtinProviders = []string{
+	"gemini", "ollama", "aws", "rekognition", "openai", "gpt4vision", "gpt-4-vision",
+	"vision", "gcv", "yolo", "onnx", AutoProvider,
+}
+
+// providerRegistry holds the providers added with RegisterProvider
+var providerRegistry = struct {
+	sync.RWMutex
+	factories map[string]ProviderFactory
+}{factories: make(map[string]ProviderFactory)}
+
+// RegisterProvider makes a custom provider available under name to
+// GetProvider, and so to chains ("acme,gemini"), DetectImage and the
+// --provider flag of the CLI. Names are case-insensitive. Register from an
+// init function of the package defining the provider, before any lookup.
+//
+// RegisterProvider panics if name is empty, contains a comma, is a built-in
+// provider or alias, or is already registered, or if factory is nil. The
+// offline mode does not apply to registered providers; a factory of a
+// cloud service should check IsOffline itself.
+//
+// Example:
+//
+//	func init() {
+//		detection.RegisterProvider("acme", func() (detection.Provider, error) {
+//			key := os.Getenv("ACME_VISION_KEY")
+//			if key == "" {
+//				return nil, fmt.Errorf("%w: ACME_VISION_KEY not set", detection.ErrProviderNotConfigured)
+//			}
+//			return acme.NewProvider(key), nil
+//		})
+//	}
+func RegisterProvider(name string, factory func() (Provider, error)) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case key == "" || strings.Contains(key, ","):
+		panic(fmt.Sprintf("detection: invalid provider name %q", name))
+	case factory == nil:
+		panic(fmt.Sprintf("detection: nil factory for provider %q", name))
+	case slices.Contains(builtinProviders, key) || ResolveProviderAlias(key) != key:
+		panic(fmt.Sprintf("detection: provider %q is built in", name))
+	}
+
+	providerRegistry.Lock()
+	defer providerRegistry.Unlock()
+	if _, ok := providerRegistry.factories[key]; ok {
+		panic(fmt.Sprintf("detection: provider %q registered twice", name))
+	}
+	providerRegistry.factories[key] = factory
+}
+
+// RegisteredProviders returns the names of the providers added with
+// RegisterProvider, sorted.
+func RegisteredProviders() []string {
+	providerRegistry.RLock()
+	defer providerRegistry.RUnlock()
+	names := make([]string, 0, len(providerRegistry.factories))
+	for name := range providerRegistry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registeredProvider returns the factory registered under name, if any
+func registeredProvider(name string) (ProviderFactory, bool) {
+	providerRegistry.RLock()
+	defer providerRegistry.RUnlock()
+	factory, ok := providerRegistry.factories[name]
+	return factory, ok
+}
+
+// configuredRegisteredProviders returns the registered providers whose
+// factory succeeds and that report IsConfigured
+func configuredRegisteredProviders() []string {
+	var names []string
+	for _, name := range RegisteredProviders() {
+		factory, _ := registeredProvider(name)
+		if p, err := factory(); err == nil && p.IsConfigured() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// unregisterProvider removes a provider registered by a test
+func unregisterProvider(name string) {
+	providerRegistry.Lock()
+	defer providerRegistry.Unlock()
+	delete(providerRegistry.factories, name)
+}
+
+func TestRegisterProvider(t *testing.T) {
+	RegisterProvider("Acme-Test", func() (Provider, error) {
+		return &MockProvider{NameFunc: func() string { return "acme-test" }}, nil
+	})
+	RegisterProvider("acme-off", func() (Provider, error) {
+		return nil, ErrProviderNotConfigured
+	})
+	defer unregisterProvider("acme-test")
+	defer unregisterProvider("acme-off")
+
+	if names := RegisteredProviders(); !slices.Equal(names, []string{"acme-off", "acme-test"}) {
+		t.Errorf("RegisteredProviders() = %v", names)
+	}
+	p, err := GetProvider(" ACME-test ")
+	if err != nil || p.Name() != "acme-test" {
+		t.Fatalf("GetProvider() = %v, %v", p, err)
+	}
+	if _, err := GetProvider("acme-off"); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("GetProvider(acme-off) error = %v, want ErrProviderNotConfigured", err)
+	}
+	if _, err := GetProvider("acme-missing"); err == nil || !strings.Contains(err.Error(), "acme-test") {
+		t.Errorf("GetProvider(unknown) error = %v, want the registered names", err)
+	}
+
+	// Registered providers work in chains and with Detect
+	if _, err := GetProvider("acme-off,acme-test"); err != nil {
+		t.Errorf("GetProvider(chain) error = %v", err)
+	}
+	result, err := DetectImage(context.Background(), image.NewNRGBA(image.Rect(0, 0, 4, 4)), "acme-test")
+	if err != nil || result == nil {
+		t.Errorf("DetectImage() = %v, %v", result, err)
+	}
+
+	configured := ConfiguredProviders()
+	if !slices.Contains(configured, "acme-test") || slices.Contains(configured, "acme-off") {
+		t.Errorf("ConfiguredProviders() = %v, want acme-test only", configured)
+	}
+}
+
+func TestRegisterProviderPanics(t *testing.T) {
+	RegisterProvider("acme-dup", func() (Provider, error) { return &MockProvider{}, nil })
+	defer unregisterProvider("acme-dup")
+
+	factory := func() (Provider, error) { return &MockProvider{}, nil }
+	tests := []struct {
+		name     string
+		provider string
+		factory  func() (Provider, error)
+	}{
+		{"empty name", " ", factory},
+		{"chain", "a,b", factory},
+		{"built in", "Gemini", factory},
+		{"alias", "google", factory},
+		{"auto", "auto", factory},
+		{"duplicate", "ACME-DUP", factory},
+		{"nil factory", "acme-nil", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterProvider(%q) did not panic", tt.provider)
+				}
+			}()
+			RegisterProvider(tt.provider, tt.factory)
+		})
+	}
+}
@@ -0,0 +1,561 @@
+package detection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// detectionResponseVersion is the version of the JSON shape that
+// responseSchemaPrompt asks the LLM providers for. Models follow it loosely:
+// keys are renamed or camelCased, labels come as strings, confidences as
+// percentages, and the object is wrapped in an envelope. The coercion rules
+// below map these variants to the version 1 shape; the fixtures in
+// testdata/responses/drift pin them per provider and model. When the prompt
+// asks for a new shape, bump the version and keep the rules of the old one,
+// as older models keep answering with it.
+const detectionResponseVersion = 1
+
+// detectionResponseKeys maps the keys of version 1 of the response to the
+// aliases models use instead. Keys are compared after normalizeResponseKey,
+// so "imageQuality" and "Image-Quality" match "image_quality". Keys that
+// are neither canonical nor aliases are kept in Properties.
+var detectionResponseKeys = map[string][]string{
+	"provider":       nil,
+	"labels":         {"label", "objects", "tags", "items", "detections", "detected_objects"},
+	"description":    {"caption", "summary", "image_description", "alt_text"},
+	"text":           {"texts", "ocr", "ocr_text", "extracted_text", "text_blocks"},
+	"faces":          {"detected_faces"},
+	"bounding_boxes": {"boxes", "object_boxes", "localized_objects"},
+	"colors":         {"colours", "dominant_colors", "palette"},
+	"image_quality":  {"quality"},
+	"moderation":     {"moderation_labels", "content_moderation"},
+	"safe_search":    {"safesearch", "safety"},
+	"synthetic":      {"ai_generated"},
+	"watermark":      {"watermarks", "stock_overlay"},
+	"properties":     {"attributes"},
+	"confidence":     {"overall_confidence"},
+}
+
+// responseEnvelopeKeys are the keys of objects that wrap the response
+var responseEnvelopeKeys = map[string]bool{
+	"result": true, "results": true, "response": true, "data": true, "analysis": true, "output": true,
+}
+
+// unscoredConfidence is the confidence of labels returned without one, as
+// for labels extracted from plain text
+const unscoredConfidence = 0.7
+
+// responseKeyAliases is the reverse of detectionResponseKeys
+var responseKeyAliases = func() map[string]string {
+	aliases := make(map[string]string)
+	for key, names := range detectionResponseKeys {
+		aliases[key] = key
+		for _, name := range names {
+			aliases[name] = key
+		}
+	}
+	return aliases
+}()
+
+// normalizeResponseKey turns camelCase, kebab-case and spaced keys into
+// snake_case
+func normalizeResponseKey(key string) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range strings.TrimSpace(key) {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+			prevLower = false
+		case unicode.IsUpper(r):
+			if prevLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		default:
+			b.WriteRune(r)
+			prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+		}
+	}
+	return b.String()
+}
+
+// responseCoercions records the coercion rules applied to a response, for
+// the warning of parseJSONDetectionResponse
+type responseCoercions []string
+
+func (c *responseCoercions) add(format string, args ...interface{}) {
+	note := fmt.Sprintf(format, args...)
+	for _, n := range *c {
+		if n == note {
+			return
+		}
+	}
+	*c = append(*c, note)
+}
+
+// normalizeDetectionResponse maps a decoded response to the keys of
+// version 1: envelopes are unwrapped, a top-level array is read as labels
+// and aliases are renamed. Unknown keys are returned separately. It returns
+// nil if decoded is not an object or array.
+func normalizeDetectionResponse(decoded interface{}, coercions *responseCoercions) (raw, unknown map[string]interface{}) {
+	switch v := decoded.(type) {
+	case []interface{}:
+		if len(v) == 1 {
+			if obj, ok := v[0].(map[string]interface{}); ok && !looksLikeLabel(obj) {
+				coercions.add("unwrapped a one-element array")
+				return normalizeDetectionResponse(obj, coercions)
+			}
+		}
+		coercions.add("read a top-level array as labels")
+		return map[string]interface{}{"labels": v}, nil
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for key, inner := range v {
+				if _, ok := inner.(map[string]interface{}); ok && responseEnvelopeKeys[normalizeResponseKey(key)] {
+					coercions.add("unwrapped the %q envelope", key)
+					return normalizeDetectionResponse(inner, coercions)
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		raw = make(map[string]interface{}, len(v))
+		for _, key := range keys {
+			value := v[key]
+			canonical, ok := responseKeyAliases[normalizeResponseKey(key)]
+			if !ok {
+				if unknown == nil {
+					unknown = make(map[string]interface{})
+				}
+				unknown[key] = value
+				continue
+			}
+			if canonical != key {
+				coercions.add("read %q as %q", key, canonical)
+			}
+			if _, exists := raw[canonical]; !exists || canonical == key {
+				raw[canonical] = value
+			}
+		}
+		return raw, unknown
+	}
+	return nil, nil
+}
+
+// looksLikeLabel reports whether obj is a label rather than a response
+func looksLikeLabel(obj map[string]interface{}) bool {
+	_, hasName := firstValue(obj, labelNameKeys)
+	_, hasLabels := obj["labels"]
+	return hasName && !hasLabels
+}
+
+var (
+	labelNameKeys       = []string{"name", "label", "object", "class", "title"}
+	labelConfidenceKeys = []string{"confidence", "probability", "certainty", "score"}
+)
+
+// firstValue returns the value of the first of keys present in obj
+func firstValue(obj map[string]interface{}, keys []string) (interface{}, bool) {
+	for _, key := range keys {
+		if v, ok := obj[key]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// toConfidence reads a confidence as a number or numeric string, and moves
+// percentages ("92%", 92) to 0-1
+func toConfidence(value interface{}, coercions *responseCoercions) (float32, bool) {
+	conf, ok := toFloat32(value)
+	if !ok {
+		return 0, false
+	}
+	if s, isString := value.(string); (isString && strings.HasSuffix(strings.TrimSpace(s), "%")) || conf > 1 {
+		coercions.add("read percentages as 0-1 confidences")
+		conf /= 100
+	}
+	return conf, true
+}
+
+// parseLabelsFromInterface reads labels given as objects (version 1),
+// strings, a comma-separated string, a single object or an object of
+// name-confidence pairs
+func parseLabelsFromInterface(value interface{}, coercions *responseCoercions) []Label {
+	var labels []Label
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			switch elem := item.(type) {
+			case map[string]interface{}:
+				if label, ok := parseLabelObject(elem, coercions); ok {
+					labels = append(labels, label)
+				}
+			case string:
+				coercions.add("read labels given as strings")
+				if name := strings.TrimSpace(elem); name != "" {
+					labels = append(labels, Label{Name: name, Confidence: unscoredConfidence})
+				}
+			}
+		}
+	case string:
+		coercions.add("read labels given as strings")
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				labels = append(labels, Label{Name: name, Confidence: unscoredConfidence})
+			}
+		}
+	case map[string]interface{}:
+		if label, ok := parseLabelObject(v, coercions); ok {
+			coercions.add("read a single label object")
+			return []Label{label}
+		}
+		coercions.add("read labels given as name-confidence pairs")
+		for name, raw := range v {
+			conf, ok := toConfidence(raw, coercions)
+			if !ok {
+				conf = unscoredConfidence
+			}
+			labels = append(labels, Label{Name: name, Confidence: conf})
+		}
+		sort.SliceStable(labels, func(i, j int) bool {
+			if labels[i].Confidence != labels[j].Confidence {
+				return labels[i].Confidence > labels[j].Confidence
+			}
+			return labels[i].Name < labels[j].Name
+		})
+	}
+	return labels
+}
+
+// parseLabelObject reads one label object, accepting the usual aliases of
+// name and confidence
+func parseLabelObject(elem map[string]interface{}, coercions *responseCoercions) (Label, bool) {
+	label := Label{}
+	if name, ok := firstValue(elem, labelNameKeys); ok {
+		label.Name, _ = name.(string)
+	}
+	if label.Name == "" {
+		return label, false
+	}
+	label.Confidence = unscoredConfidence
+	if raw, ok := firstValue(elem, labelConfidenceKeys); ok {
+		if confidence, ok := toConfidence(raw, coercions); ok {
+			label.Confidence = confidence
+		}
+	}
+	// Ollama / extended fields
+	if score, ok := toFloat32(elem["score"]); ok {
+		label.Score = score
+	}
+	if mid, ok := elem["mid"].(string); ok {
+		label.MID = mid
+	}
+	if categories, ok := elem["categories"].([]interface{}); ok {
+		for _, c := range categories {
+			if s, ok := c.(string); ok {
+				label.Categories = append(label.Categories, s)
+			}
+		}
+	}
+	if topicID, ok := elem["topic_id"].(string); ok {
+		label.TopicID = topicID
+	}
+	return label, true
+}
+
+// parseTextBlocksFromInterface reads text given as objects (version 1),
+// strings or a single string
+func parseTextBlocksFromInterface(value interface{}, coercions *responseCoercions) []TextBlock {
+	var blocks []TextBlock
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			switch elem := item.(type) {
+			case map[string]interface{}:
+				block := TextBlock{}
+				if textValue, ok := elem["text"].(string); ok {
+					block.Text = textValue
+				}
+				if confidence, ok := toFloat32(elem["confidence"]); ok {
+					block.Confidence = confidence
+				}
+				if language, ok := elem["language"].(string); ok {
+					block.Language = language
+				}
+				if block.Text != "" {
+					blocks = append(blocks, block)
+				}
+			case string:
+				coercions.add("read text given as strings")
+				if elem != "" {
+					blocks = append(blocks, TextBlock{Text: elem})
+				}
+			}
+		}
+	case string:
+		coercions.add("read text given as strings")
+		if v != "" {
+			blocks = append(blocks, TextBlock{Text: v})
+		}
+	}
+	return blocks
+}
+
+// parseBoundingBoxesFromInterface reads object boxes given as box_2d
+// arrays of [ymin, xmin, ymax, xmax] normalized to 0-1000 (version 1, the
+// convention of Gemini), or as box objects of x, y, width and height
+// relative to the image. Boxes are returned relative to the image, like
+// those of the other providers.
+func parseBoundingBoxesFromInterface(value interface{}, coercions *responseCoercions) []BoundingBox {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var boxes []BoundingBox
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := firstValue(obj, labelNameKeys)
+		label, _ := name.(string)
+		box, ok := parseBox2D(obj["box_2d"])
+		if !ok {
+			if v, found := firstValue(obj, []string{"bounding_box", "box", "bbox"}); found {
+				box, ok = parseBoxObject(v, coercions)
+			}
+		}
+		if strings.TrimSpace(label) == "" || !ok {
+			continue
+		}
+		b := BoundingBox{Label: strings.TrimSpace(label), Confidence: unscoredConfidence, Box: box}
+		if v, found := firstValue(obj, labelConfidenceKeys); found {
+			if conf, ok := toConfidence(v, coercions); ok {
+				b.Confidence = conf
+			}
+		}
+		boxes = append(boxes, b)
+	}
+	return boxes
+}
+
+// parseBox2D reads a [ymin, xmin, ymax, xmax] array normalized to 0-1000
+func parseBox2D(value interface{}) (Box, bool) {
+	coords, ok := value.([]interface{})
+	if !ok || len(coords) != 4 {
+		return Box{}, false
+	}
+	var v [4]float32
+	for i, c := range coords {
+		if v[i], ok = toFloat32(c); !ok {
+			return Box{}, false
+		}
+		v[i] = clamp01(v[i] / 1000)
+	}
+	x0, x1 := min(v[1], v[3]), max(v[1], v[3])
+	y0, y1 := min(v[0], v[2]), max(v[0], v[2])
+	box := Box{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+	return box, box.Width > 0 && box.Height > 0
+}
+
+// parseBoxObject reads a box object of x, y, width and height relative to
+// the image, or normalized to 0-1000 when a coordinate exceeds 1
+func parseBoxObject(value interface{}, coercions *responseCoercions) (Box, bool) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return Box{}, false
+	}
+	var box Box
+	for _, field := range []struct {
+		dst  *float32
+		keys []string
+	}{
+		{&box.X, []string{"x", "left"}},
+		{&box.Y, []string{"y", "top"}},
+		{&box.Width, []string{"width", "w"}},
+		{&box.Height, []string{"height", "h"}},
+	} {
+		v, found := firstValue(obj, field.keys)
+		if !found {
+			return Box{}, false
+		}
+		if *field.dst, ok = toFloat32(v); !ok {
+			return Box{}, false
+		}
+	}
+	if max(box.X, box.Y, box.Width, box.Height) > 1 {
+		coercions.add("read box coordinates normalized to 0-1000")
+		box = Box{X: box.X / 1000, Y: box.Y / 1000, Width: box.Width / 1000, Height: box.Height / 1000}
+	}
+	return box, box.Width > 0 && box.Height > 0
+}
+
+// labelsFromBoxes returns one label per box label with its best confidence,
+// in order of confidence
+func labelsFromBoxes(boxes []BoundingBox) []Label {
+	var labels []Label
+	index := make(map[string]int)
+	for _, b := range boxes {
+		key := strings.ToLower(b.Label)
+		if i, ok := index[key]; ok {
+			labels[i].Confidence = max(labels[i].Confidence, b.Confidence)
+			continue
+		}
+		index[key] = len(labels)
+		labels = append(labels, Label{Name: b.Label, Confidence: b.Confidence})
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].Confidence > labels[j].Confidence })
+	return labels
+}
+
+// parseDescriptionFromInterface reads a description given as a string
+// (version 1), an array of sentences or an object with a text field
+func parseDescriptionFromInterface(value interface{}, coercions *responseCoercions) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				parts = append(parts, strings.TrimSpace(s))
+			}
+		}
+		coercions.add("joined a description given as an array")
+		return strings.Join(parts, " ")
+	case map[string]interface{}:
+		if s, ok := firstValue(v, []string{"text", "description", "caption", "summary"}); ok {
+			if text, ok := s.(string); ok {
+				coercions.add("read a description given as an object")
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// detectionResponseSchema returns the JSON Schema of version 1 of the
+// response for the features of opts, for the structured output of Gemini
+// and OpenAI. It follows the subset of OpenAI's strict mode: every object
+// lists all its properties as required and allows no others, so the model
+// returns each requested key, possibly empty.
+func detectionResponseSchema(opts *DetectOptions) map[string]any {
+	labels := arraySchema(objectSchema(map[string]any{
+		"name":       map[string]any{"type": "string"},
+		"confidence": map[string]any{"type": "number", "description": "0.0-1.0"},
+	}))
+	props := make(map[string]any)
+	var extra map[string]any // Keys of the properties object
+	for _, feature := range opts.Features {
+		switch feature {
+		case FeatureLabels:
+			props["labels"] = labels
+		case FeatureObjects:
+			props["bounding_boxes"] = arraySchema(objectSchema(map[string]any{
+				"label":      map[string]any{"type": "string"},
+				"confidence": map[string]any{"type": "number", "description": "0.0-1.0"},
+				"box_2d": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "integer"},
+					"description": "[ymin, xmin, ymax, xmax] normalized to 0-1000",
+				},
+			}))
+		case FeatureDescription:
+			props["description"] = map[string]any{"type": "string"}
+		case FeatureText:
+			props["text"] = arraySchema(objectSchema(map[string]any{
+				"text":       map[string]any{"type": "string"},
+				"confidence": map[string]any{"type": "number", "description": "0.0-1.0"},
+			}))
+		case FeatureFaces:
+			props["faces"] = arraySchema(objectSchema(map[string]any{
+				"confidence": map[string]any{"type": "number", "description": "0.0-1.0"},
+				"eyes_open":  map[string]any{"type": "boolean"},
+				"bounding_box": objectSchema(map[string]any{
+					"x":      map[string]any{"type": "number"},
+					"y":      map[string]any{"type": "number"},
+					"width":  map[string]any{"type": "number"},
+					"height": map[string]any{"type": "number"},
+				}),
+			}))
+		case FeatureProperties:
+			props["colors"] = arraySchema(objectSchema(map[string]any{
+				"name":       map[string]any{"type": "string"},
+				"hex":        map[string]any{"type": "string", "description": "#rrggbb"},
+				"percentage": map[string]any{"type": "number", "description": "share of the image, 0-100"},
+			}))
+			extra = addStringProperties(extra, "lighting", "mood", "style")
+		case FeatureLandmarks:
+			extra = addStringProperties(extra, "landmarks")
+		case FeatureSafeSearch:
+			props["moderation"] = arraySchema(objectSchema(map[string]any{
+				"name":       map[string]any{"type": "string", "description": "category, e.g. Violence or Explicit Nudity"},
+				"parent":     map[string]any{"type": "string"},
+				"confidence": map[string]any{"type": "number", "description": "0.0-1.0"},
+				"severity": map[string]any{"type": "string", "enum": []string{
+					"very_unlikely", "unlikely", "possible", "likely", "very_likely",
+				}},
+			}))
+			props["safe_search"] = objectSchema(map[string]any{"notes": map[string]any{"type": "string"}})
+		case FeatureSynthetic:
+			props["synthetic"] = objectSchema(map[string]any{
+				"likelihood": map[string]any{"type": "number", "description": "0.0 for a camera photo, 1.0 for certainly AI-generated"},
+				"reason":     map[string]any{"type": "string"},
+			})
+		case FeatureWatermark:
+			props["watermark"] = objectSchema(map[string]any{
+				"likelihood": map[string]any{"type": "number", "description": "0.0 for no watermark, 1.0 for certainly watermarked"},
+				"text":       map[string]any{"type": "string"},
+				"reason":     map[string]any{"type": "string"},
+			})
+		}
+	}
+	if extra != nil {
+		props["properties"] = objectSchema(extra)
+	}
+	if len(props) == 0 {
+		// The default prompt asks for labels and a description
+		props["labels"] = labels
+		props["description"] = map[string]any{"type": "string"}
+	}
+	return objectSchema(props)
+}
+
+// objectSchema returns the schema of an object with all of props required
+func objectSchema(props map[string]any) map[string]any {
+	required := make([]string, 0, len(props))
+	for key := range props {
+		required = append(required, key)
+	}
+	sort.Strings(required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// arraySchema returns the schema of an array of items
+func arraySchema(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+// addStringProperties adds string schemas for keys to props
+func addStringProperties(props map[string]any, keys ...string) map[string]any {
+	if props == nil {
+		props = make(map[string]any)
+	}
+	for _, key := range keys {
+		props[key] = map[string]any{"type": "string"}
+	}
+	return props
+}
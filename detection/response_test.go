@@ -0,0 +1,234 @@
+package detection
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestResponseFixtures parses the responses recorded per provider and model
+// in testdata/responses/drift and compares the keys of "want" with the
+// result
+func TestResponseFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "responses", "drift", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture struct {
+				Provider string                     `json:"provider"`
+				Model    string                     `json:"model"`
+				Response string                     `json:"response"`
+				Want     map[string]json.RawMessage `json:"want"`
+			}
+			if err := json.Unmarshal(data, &fixture); err != nil {
+				t.Fatalf("invalid fixture: %v", err)
+			}
+
+			result := parseTextResponse(fixture.Response, DefaultDetectOptions())
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := fixture.Want["warnings"]; !ok && len(result.Warnings) > 0 {
+				t.Errorf("%s/%s: unexpected warnings %q", fixture.Provider, fixture.Model, result.Warnings)
+			}
+			for key, raw := range fixture.Want {
+				var want interface{}
+				if err := json.Unmarshal(raw, &want); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got[key], want) {
+					t.Errorf("%s/%s: %s = %v, want %v", fixture.Provider, fixture.Model, key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeResponseKey(t *testing.T) {
+	tests := map[string]string{
+		"labels":        "labels",
+		"imageQuality":  "image_quality",
+		"Image-Quality": "image_quality",
+		"safe search":   "safe_search",
+		"OCR":           "ocr",
+		"alt_text":      "alt_text",
+	}
+	for in, want := range tests {
+		if got := normalizeResponseKey(in); got != want {
+			t.Errorf("normalizeResponseKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseJSONDetectionResponseNotObject(t *testing.T) {
+	result := parseTextResponse(`"just a string"`, DefaultDetectOptions())
+	if result.Description != `"just a string"` {
+		t.Errorf("Description = %q, want the plain-text fallback", result.Description)
+	}
+}
+
+func FuzzParseTextResponse(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "responses", "drift", "*.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		var fixture struct {
+			Response string `json:"response"`
+		}
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			f.Fatalf("%s: invalid fixture: %v", file, err)
+		}
+		f.Add(fixture.Response)
+	}
+	f.Add(`{"labels": [{"name": "cat", "confidence": "NaN"}]}`)
+	f.Add("```json\n{\"description\": \"a cat\"}\n```")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		result := parseTextResponse(text, DefaultDetectOptions())
+		if result == nil {
+			t.Fatal("parseTextResponse returned nil")
+		}
+		if _, err := json.Marshal(result); err != nil {
+			t.Errorf("result does not encode: %v", err)
+		}
+	})
+}
+
+// TestDetectionResponseSchema tests the structured output schema of the
+// requested features
+func TestDetectionResponseSchema(t *testing.T) {
+	opts := &DetectOptions{Features: []Feature{FeatureLabels, FeatureObjects, FeatureText, FeatureProperties, FeatureLandmarks, FeatureSafeSearch, FeatureWatermark, FeatureWeb}}
+	schema := detectionResponseSchema(opts)
+	props := schema["properties"].(map[string]any)
+	want := []string{"bounding_boxes", "colors", "labels", "moderation", "properties", "safe_search", "text", "watermark"}
+	if !reflect.DeepEqual(schema["required"], want) {
+		t.Errorf("required = %v, want %v", schema["required"], want)
+	}
+	extra := props["properties"].(map[string]any)["required"]
+	if !reflect.DeepEqual(extra, []string{"landmarks", "lighting", "mood", "style"}) {
+		t.Errorf("properties keys = %v", extra)
+	}
+
+	// Every object follows OpenAI's strict mode
+	var check func(path string, s map[string]any)
+	check = func(path string, s map[string]any) {
+		switch s["type"] {
+		case "object":
+			p := s["properties"].(map[string]any)
+			if s["additionalProperties"] != false || len(s["required"].([]string)) != len(p) {
+				t.Errorf("%s is not strict: %v", path, s)
+			}
+			for key, sub := range p {
+				check(path+"."+key, sub.(map[string]any))
+			}
+		case "array":
+			check(path+"[]", s["items"].(map[string]any))
+		}
+	}
+	check("$", schema)
+
+	// A response of the schema parses without coercions
+	response := `{"labels": [{"name": "cat", "confidence": 0.9}], "bounding_boxes": [{"label": "cat", "confidence": 0.9, "box_2d": [0, 0, 500, 500]}], "text": [], "colors": [{"name": "orange", "hex": "#ff8800", "percentage": 40}],
+		"properties": {"landmarks": "", "lighting": "soft", "mood": "calm", "style": "photo"},
+		"moderation": [], "safe_search": {"notes": "none"}, "watermark": {"likelihood": 0.1, "text": "", "reason": "no overlay"}}`
+	var decoded any
+	if err := json.Unmarshal([]byte(response), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	validateSchema(schema, decoded, "$", &errs)
+	if len(errs) > 0 {
+		t.Errorf("response does not match the schema: %v", errs)
+	}
+	result := &DetectionResult{Properties: map[string]string{}}
+	if err := parseJSONDetectionResponse(response, result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) > 0 || len(result.Labels) != 1 || len(result.BoundingBoxes) != 1 || result.Properties["mood"] != "calm" || result.Watermark == nil {
+		t.Errorf("parsed result = %+v", result)
+	}
+
+	// Without LLM features, labels and a description are asked for
+	schema = detectionResponseSchema(&DetectOptions{Features: []Feature{FeatureWeb}})
+	if !reflect.DeepEqual(schema["required"], []string{"description", "labels"}) {
+		t.Errorf("default required = %v", schema["required"])
+	}
+}
+
+// TestParseBoundingBoxes tests object boxes of LLM responses
+func TestParseBoundingBoxes(t *testing.T) {
+	response := `{"bounding_boxes": [
+		{"label": "dog", "confidence": 0.9, "box_2d": [100, 200, 600, 500]},
+		{"label": "dog", "confidence": 0.6, "box_2d": [500, 800, 400, 900]},
+		{"label": "cat", "box_2d": [0, 0, 0, 100]},
+		{"name": "ball", "score": 80, "box": {"x": 0.5, "y": 0.25, "width": 0.1, "height": 0.2}}
+	]}`
+	result := &DetectionResult{Properties: map[string]string{}}
+	if err := parseJSONDetectionResponse(response, result); err != nil {
+		t.Fatal(err)
+	}
+	want := []BoundingBox{
+		{Label: "dog", Confidence: 0.9, Box: Box{X: 0.2, Y: 0.1, Width: 0.3, Height: 0.5}},
+		{Label: "dog", Confidence: 0.6, Box: Box{X: 0.8, Y: 0.4, Width: 0.1, Height: 0.1}},
+		{Label: "ball", Confidence: 0.8, Box: Box{X: 0.5, Y: 0.25, Width: 0.1, Height: 0.2}},
+	}
+	if len(result.BoundingBoxes) != len(want) {
+		t.Fatalf("BoundingBoxes = %+v, want %+v", result.BoundingBoxes, want)
+	}
+	for i, b := range result.BoundingBoxes {
+		w := want[i]
+		if b.Label != w.Label || math.Abs(float64(b.Confidence-w.Confidence)) > 1e-6 || !closeBox(b.Box, w.Box) {
+			t.Errorf("box %d = %+v, want %+v", i, b, w)
+		}
+	}
+	// Labels come from the boxes, with the best confidence per label
+	if len(result.Labels) != 2 || result.Labels[0].Name != "dog" || result.Labels[0].Confidence != 0.9 || result.Labels[1].Name != "ball" {
+		t.Errorf("Labels = %+v, want dog and ball", result.Labels)
+	}
+	if r := result.BoundingBoxes[0].Box.Rect(1000, 500); r != image.Rect(200, 50, 500, 300) {
+		t.Errorf("Rect() = %v", r)
+	}
+
+	// Labels carrying boxes, with coordinates of 0-1000
+	result = &DetectionResult{Properties: map[string]string{}}
+	if err := parseJSONDetectionResponse(`{"objects": [{"label": "car", "confidence": 0.7, "bounding_box": {"x": 100, "y": 200, "width": 300, "height": 400}}]}`, result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.BoundingBoxes) != 1 || !closeBox(result.BoundingBoxes[0].Box, Box{X: 0.1, Y: 0.2, Width: 0.3, Height: 0.4}) || len(result.Labels) != 1 {
+		t.Errorf("result = %+v, want one car box and label", result)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "0-1000") {
+		t.Errorf("Warnings = %v, want the 0-1000 coercion", result.Warnings)
+	}
+}
+
+// closeBox reports whether the coordinates of a and b differ by less than 1e-5
+func closeBox(a, b Box) bool {
+	near := func(x, y float32) bool { return math.Abs(float64(x-y)) < 1e-5 }
+	return near(a.X, b.X) && near(a.Y, b.Y) && near(a.Width, b.Width) && near(a.Height, b.Height)
+}
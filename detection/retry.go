@@ -0,0 +1,309 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures the retries of failed provider requests
+// (SetRetryPolicy, DetectOptions.Retry) and of failed detections in
+// DetectBatch
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts (default 1: no retries)
+	MaxAttempts int `json:"max_attempts"`
+
+	// Backoff is the wait before the first retry, doubled after each retry
+	// (default 1s). A Retry-After header of a 429 or 503 response replaces
+	// it.
+	Backoff time.Duration `json:"backoff_ns"`
+
+	// MaxBackoff caps the wait between retries (default 30s). Requests are
+	// not retried when the provider asks to wait longer.
+	MaxBackoff time.Duration `json:"max_backoff_ns"`
+
+	// Retryable reports whether a failure is retried (default: IsRetryable).
+	// Provider responses are passed as ErrRateLimit (429) or ErrAPIError
+	// (5xx), transport failures as ErrNetworkError.
+	Retryable func(err error) bool `json:"-"`
+}
+
+// withDefaults returns p with the defaults of its zero fields
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	p.MaxAttempts = max(p.MaxAttempts, 1)
+	if p.Backoff <= 0 {
+		p.Backoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// RateLimit bounds the requests sent to a provider
+type RateLimit struct {
+	// RequestsPerSecond is the sustained request rate; 0 is unlimited.
+	// Use fractions for per-minute quotas: 60 requests per minute is 1.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+
+	// Burst is the number of requests sent at once before the rate applies
+	// (default 1)
+	Burst int `json:"burst"`
+}
+
+// requestLimits holds the retry policy and rate limits of provider requests
+var requestLimits struct {
+	mu      sync.Mutex
+	retry   RetryPolicy
+	limits  map[string]RateLimit // By provider name, "" for all providers
+	buckets map[string]*rateBucket
+}
+
+// SetRetryPolicy sets the retry policy of the HTTP requests of all
+// providers. Rate limited (429) and unavailable (5xx) responses and network
+// failures are retried with exponential backoff, waiting as long as the
+// Retry-After header asks. DetectOptions.Retry overrides it per detection.
+// The zero policy disables retries (the default).
+//
+// Example:
+//
+//	detection.SetRetryPolicy(detection.RetryPolicy{MaxAttempts: 5, Backoff: 2 * time.Second})
+func SetRetryPolicy(policy RetryPolicy) {
+	requestLimits.mu.Lock()
+	defer requestLimits.mu.Unlock()
+	requestLimits.retry = policy
+}
+
+// GetRetryPolicy returns the retry policy set by SetRetryPolicy
+func GetRetryPolicy() RetryPolicy {
+	requestLimits.mu.Lock()
+	defer requestLimits.mu.Unlock()
+	return requestLimits.retry
+}
+
+// SetRateLimit limits the requests sent to provider, e.g. to stay within the
+// quota of an API key during long batch jobs; requests wait for their turn.
+// An empty provider sets the limit of the providers without their own. A
+// zero limit removes it.
+//
+// Example:
+//
+//	detection.SetRateLimit("gemini", detection.RateLimit{RequestsPerSecond: 0.25}) // 15 per minute
+func SetRateLimit(provider string, limit RateLimit) {
+	requestLimits.mu.Lock()
+	defer requestLimits.mu.Unlock()
+	provider = ResolveProviderAlias(provider)
+	if requestLimits.limits == nil {
+		requestLimits.limits = make(map[string]RateLimit)
+	}
+	if limit.RequestsPerSecond > 0 {
+		requestLimits.limits[provider] = limit
+	} else {
+		delete(requestLimits.limits, provider)
+	}
+	requestLimits.buckets = nil
+}
+
+// rateBucket is the token bucket of a provider. A provider answering 429
+// with Retry-After pauses it, so concurrent requests wait too.
+type rateBucket struct {
+	limit       RateLimit
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// providerBucket returns the token bucket of provider
+func providerBucket(provider string) *rateBucket {
+	if requestLimits.buckets == nil {
+		requestLimits.buckets = make(map[string]*rateBucket)
+	}
+	b := requestLimits.buckets[provider]
+	if b == nil {
+		limit, ok := requestLimits.limits[provider]
+		if !ok {
+			limit = requestLimits.limits[""]
+		}
+		limit.Burst = max(limit.Burst, 1)
+		b = &rateBucket{limit: limit, tokens: float64(limit.Burst)}
+		requestLimits.buckets[provider] = b
+	}
+	return b
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *rateBucket) reserve(now time.Time) time.Duration {
+	var wait time.Duration
+	if rate := b.limit.RequestsPerSecond; rate > 0 {
+		if !b.last.IsZero() {
+			b.tokens = min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / rate * float64(time.Second))
+		}
+	}
+	return max(wait, b.pausedUntil.Sub(now))
+}
+
+// waitTurn waits until provider may send a request
+func waitTurn(ctx context.Context, provider string) error {
+	requestLimits.mu.Lock()
+	wait := providerBucket(provider).reserve(time.Now())
+	requestLimits.mu.Unlock()
+	return sleepContext(ctx, wait)
+}
+
+// pauseProvider holds the requests to provider until the time given by a
+// Retry-After header
+func pauseProvider(provider string, until time.Time) {
+	requestLimits.mu.Lock()
+	defer requestLimits.mu.Unlock()
+	if b := providerBucket(provider); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type retryPolicyKey struct{}
+
+// withRetryPolicy returns ctx carrying policy for the requests of a
+// detection, or ctx itself when policy is nil
+func withRetryPolicy(ctx context.Context, policy *RetryPolicy) context.Context {
+	if policy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryPolicyKey{}, *policy)
+}
+
+// requestRetryPolicy returns the retry policy of ctx, or the one set by
+// SetRetryPolicy
+func requestRetryPolicy(ctx context.Context) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return policy.withDefaults()
+	}
+	return GetRetryPolicy().withDefaults()
+}
+
+// retryAfter parses the Retry-After header of resp, in seconds or as an
+// HTTP date, and returns how long to wait (0 if absent)
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return max(0, time.Duration(seconds*float64(time.Second)))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(0, t.Sub(now))
+	}
+	return 0
+}
+
+// responseError classifies a failed request for RetryPolicy.Retryable: nil
+// for responses that are not rate limits or server errors
+func responseError(resp *http.Response, err error) error {
+	switch {
+	case err != nil:
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrNetworkError, err)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s", ErrAPIError, resp.Status)
+	}
+	return nil
+}
+
+// retryTransport applies the rate limit of the provider to its requests and
+// retries them according to the retry policy of the request context
+type retryTransport struct {
+	provider string
+	send     func(*http.Request) (*http.Response, error)
+}
+
+// Do sends req, for the AWS SDK
+func (t *retryTransport) Do(req *http.Request) (*http.Response, error) {
+	return t.RoundTrip(req)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	policy := requestRetryPolicy(ctx)
+	// Requests whose body can't be read again are sent once
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		policy.MaxAttempts = 1
+	}
+
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		if err := waitTurn(ctx, t.provider); err != nil {
+			return nil, err
+		}
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.send(req)
+		failure := responseError(resp, err)
+		if failure == nil {
+			return resp, err
+		}
+		wait := backoff
+		if resp != nil {
+			if d := retryAfter(resp, time.Now()); d > 0 && d <= policy.MaxBackoff {
+				wait = d
+				if resp.StatusCode == http.StatusTooManyRequests {
+					// Other requests to the provider wait too; this one
+					// waits for its turn below
+					pauseProvider(t.provider, time.Now().Add(d))
+					wait = 0
+				}
+			} else if d > policy.MaxBackoff {
+				return resp, err
+			}
+		}
+		if attempt >= policy.MaxAttempts || !policy.Retryable(failure) || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		backoff = min(backoff*2, policy.MaxBackoff)
+	}
+}
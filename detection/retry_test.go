@@ -0,0 +1,226 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingServer answers the first failures requests with status and
+// Retry-After retryAfter (if set), then 200 with the request body
+func failingServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, "try again", status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// postBody posts body to url with the client of provider and returns the
+// status and response body
+func postBody(t *testing.T, ctx context.Context, provider, url, body string) (int, string) {
+	t.Helper()
+	client := debugHTTPClient(provider, &http.Client{})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request error = %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Cleanup(func() { SetRetryPolicy(RetryPolicy{}) })
+	ctx := context.Background()
+
+	// Without a policy, requests are sent once
+	server, calls := failingServer(t, 1, http.StatusTooManyRequests, "")
+	if status, _ := postBody(t, ctx, "retry-test", server.URL, "payload"); status != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("no policy: status %d after %d calls, want 429 after 1", status, calls.Load())
+	}
+
+	// Rate limits and server errors are retried with the body
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		server, calls := failingServer(t, 2, status, "")
+		got, body := postBody(t, ctx, "retry-test", server.URL, "payload")
+		if got != http.StatusOK || body != "payload" || calls.Load() != 3 {
+			t.Errorf("status %d: got %d %q after %d calls, want 200 \"payload\" after 3", status, got, body, calls.Load())
+		}
+	}
+
+	// Client errors are not retried, and attempts are bounded
+	server, calls = failingServer(t, 5, http.StatusBadRequest, "")
+	if status, _ := postBody(t, ctx, "retry-test", server.URL, "payload"); status != http.StatusBadRequest || calls.Load() != 1 {
+		t.Errorf("400: status %d after %d calls, want 400 after 1", status, calls.Load())
+	}
+	server, calls = failingServer(t, 5, http.StatusBadGateway, "")
+	if status, _ := postBody(t, ctx, "retry-test", server.URL, "payload"); status != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("502: status %d after %d calls, want 502 after 3", status, calls.Load())
+	}
+
+	// The context policy overrides the global one
+	server, calls = failingServer(t, 1, http.StatusTooManyRequests, "")
+	noRetry := withRetryPolicy(ctx, &RetryPolicy{MaxAttempts: 1})
+	if status, _ := postBody(t, noRetry, "retry-test", server.URL, "payload"); status != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("override: status %d after %d calls, want 429 after 1", status, calls.Load())
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	t.Cleanup(func() { SetRetryPolicy(RetryPolicy{}) })
+	ctx := context.Background()
+	SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Second})
+
+	// Retry-After sets the wait
+	server, calls := failingServer(t, 1, http.StatusTooManyRequests, "0.2")
+	start := time.Now()
+	if status, _ := postBody(t, ctx, "retry-after-test", server.URL, "payload"); status != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("status %d after %d calls, want 200 after 2", status, calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("retried after %v, want the 200ms of Retry-After", elapsed)
+	}
+
+	// Longer than MaxBackoff: the response is returned
+	server, calls = failingServer(t, 1, http.StatusTooManyRequests, "120")
+	if status, _ := postBody(t, ctx, "retry-after-long", server.URL, "payload"); status != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("long Retry-After: status %d after %d calls, want 429 after 1", status, calls.Load())
+	}
+
+	// Cancellation stops the wait
+	server, _ = failingServer(t, 1, http.StatusServiceUnavailable, "1")
+	canceled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	client := debugHTTPClient("retry-after-cancel", &http.Client{})
+	req, _ := http.NewRequestWithContext(canceled, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("canceled wait error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	t.Cleanup(func() { SetRateLimit("rate-test", RateLimit{}) })
+	server, calls := failingServer(t, 0, http.StatusOK, "")
+	ctx := context.Background()
+
+	SetRateLimit("rate-test", RateLimit{RequestsPerSecond: 20, Burst: 2})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		postBody(t, ctx, "rate-test", server.URL, "payload")
+	}
+	// 2 at once, then one every 50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 requests at 20/s with burst 2 took %v, want at least 100ms", elapsed)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("server got %d requests, want 4", calls.Load())
+	}
+
+	// Other providers are not limited
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		postBody(t, ctx, "rate-other", server.URL, "payload")
+	}
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Errorf("unlimited provider took %v", elapsed)
+	}
+}
+
+func TestRateBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := &rateBucket{limit: RateLimit{RequestsPerSecond: 2, Burst: 1}, tokens: 1}
+	if wait := b.reserve(now); wait != 0 {
+		t.Errorf("first request waits %v, want 0", wait)
+	}
+	if wait := b.reserve(now); wait != 500*time.Millisecond {
+		t.Errorf("second request waits %v, want 500ms", wait)
+	}
+	if wait := b.reserve(now.Add(2 * time.Second)); wait != 0 {
+		t.Errorf("request after 2s waits %v, want 0", wait)
+	}
+
+	// A token is available, but the provider asked to wait until 4s
+	b.pausedUntil = now.Add(4 * time.Second)
+	if wait := b.reserve(now.Add(5 * time.Second / 2)); wait != 3*time.Second/2 {
+		t.Errorf("paused request waits %v, want 1.5s", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"Mon, 04 May 2026 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 04 May 2026 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(resp, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDetectOptionsRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"error": "busy"}`, http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(&yoloInferResponse{Outputs: []yoloTensor{yoloV8Output(len(cocoClassNames), nil)}})
+	}))
+	defer server.Close()
+	t.Setenv("IMGX_YOLO_HOST", server.URL)
+	t.Setenv("IMGX_YOLO_MODEL", "")
+	img := CreateTestImage(64, 64, color.NRGBA{R: 255, A: 255})
+
+	// Without retries the rate limit fails the detection
+	opts := DefaultDetectOptions()
+	_, err := Detect(context.Background(), img, "yolo", opts)
+	if !IsRateLimit(err) {
+		t.Fatalf("Detect() error = %v, want ErrRateLimit", err)
+	}
+
+	calls.Store(0)
+	opts.Retry = &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+	if _, err := Detect(context.Background(), img, "yolo", opts); err != nil {
+		t.Fatalf("Detect() with retries error = %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", calls.Load())
+	}
+}
@@ -0,0 +1,273 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// AutoProvider is the provider name that selects a provider with routing
+// rules (see Router)
+const AutoProvider = "auto"
+
+// RouteCondition is the condition of a routing rule. All set fields must
+// match; a condition with no fields set always matches.
+type RouteCondition struct {
+	// Features matches when any of these features is requested
+	Features []Feature `json:"features,omitempty"`
+
+	// CustomPrompt matches detections with a CustomPrompt
+	CustomPrompt bool `json:"custom_prompt,omitempty"`
+
+	// Offline matches when the router is in offline mode
+	Offline bool `json:"offline,omitempty"`
+
+	// MinMegapixels matches images of at least this many megapixels
+	MinMegapixels float64 `json:"min_megapixels,omitempty"`
+}
+
+// RouteRule sends detections matching When to Provider
+type RouteRule struct {
+	Name     string         `json:"name,omitempty"`
+	When     RouteCondition `json:"when"`
+	Provider string         `json:"provider"`
+
+	// MaxMegapixels downscales larger images before detection (0 = never)
+	MaxMegapixels float64 `json:"max_megapixels,omitempty"`
+}
+
+// Router picks a detection provider from rules, so the choice is made in
+// one place instead of in every caller. The first matching rule wins;
+// Default is used when none matches.
+type Router struct {
+	Rules   []RouteRule `json:"rules"`
+	Default string      `json:"default,omitempty"` // default: GetDefaultProvider()
+	Offline bool        `json:"offline,omitempty"` // Enables rules with When.Offline (also on with SetOffline)
+}
+
+// DefaultRouter returns the built-in rules: offline → ollama, faces → aws,
+// custom prompts → gemini, images over 8MP → downscale to 8MP then gemini
+func DefaultRouter() *Router {
+	return &Router{
+		Rules: []RouteRule{
+			{Name: "offline", When: RouteCondition{Offline: true}, Provider: "ollama"},
+			{Name: "faces", When: RouteCondition{Features: []Feature{FeatureFaces}}, Provider: "aws"},
+			{Name: "custom-prompt", When: RouteCondition{CustomPrompt: true}, Provider: "gemini"},
+			{Name: "large-image", When: RouteCondition{MinMegapixels: 8}, Provider: "gemini", MaxMegapixels: 8},
+		},
+	}
+}
+
+// RoutesPath returns the routing rules file: $IMGX_ROUTES, or
+// "imgx/routes.json" in the user config directory
+// (e.g. ~/.config/imgx/routes.json)
+func RoutesPath() (string, error) {
+	if path := os.Getenv("IMGX_ROUTES"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "imgx", "routes.json"), nil
+}
+
+// LoadRouter loads routing rules from a JSON file. An empty path uses
+// RoutesPath; when that file doesn't exist, DefaultRouter is returned.
+//
+// Example routes.json:
+//
+//	{
+//	  "rules": [
+//	    {"name": "faces", "when": {"features": ["faces"]}, "provider": "aws"},
+//	    {"name": "large", "when": {"min_megapixels": 8}, "provider": "gemini", "max_megapixels": 8}
+//	  ],
+//	  "default": "ollama"
+//	}
+func LoadRouter(path string) (*Router, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = RoutesPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return DefaultRouter(), nil
+		}
+		return nil, fmt.Errorf("failed to read routing rules: %w", err)
+	}
+
+	var router Router
+	if err := json.Unmarshal(data, &router); err != nil {
+		return nil, fmt.Errorf("invalid routing rules %s: %w", path, err)
+	}
+	for i, rule := range router.Rules {
+		if rule.Provider == "" || ResolveProviderAlias(rule.Provider) == AutoProvider {
+			return nil, fmt.Errorf("invalid routing rules %s: rule %d needs a provider other than auto", path, i+1)
+		}
+	}
+	if ResolveProviderAlias(router.Default) == AutoProvider {
+		return nil, fmt.Errorf("invalid routing rules %s: default cannot be auto", path)
+	}
+	return &router, nil
+}
+
+// Route returns the provider for detecting img with opts and the rule that
+// chose it (nil when the default was used)
+func (r *Router) Route(img *image.NRGBA, opts *DetectOptions) (string, *RouteRule) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	megapixels := float64(img.Bounds().Dx()*img.Bounds().Dy()) / 1e6
+
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.When.matches(opts, megapixels, r.Offline || IsOffline()) {
+			return ResolveProviderAlias(rule.Provider), rule
+		}
+	}
+
+	provider := r.Default
+	if provider == "" {
+		provider = GetDefaultProvider()
+	}
+	if provider = ResolveProviderAlias(provider); provider == AutoProvider {
+		provider = "ollama"
+	}
+	return provider, nil
+}
+
+// Detect routes the detection, downscales the image if the rule asks for
+// it, and runs it. Properties["route"] records the rule that matched.
+func (r *Router) Detect(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	name, rule := r.Route(img, opts)
+	prov, err := GetProvider(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get detection provider: %w", err)
+	}
+
+	scaled := img
+	if rule != nil && rule.MaxMegapixels > 0 {
+		scaled = downscaleToMegapixels(img, rule.MaxMegapixels)
+	}
+	result, err := prov.Detect(ctx, scaled, opts)
+	if err != nil {
+		return nil, err
+	}
+	addSyntheticAssessment(img, opts, result)
+	addWatermarkAssessment(img, opts, result)
+
+	if result.Properties == nil {
+		result.Properties = make(map[string]string)
+	}
+	result.Properties["route"] = rule.label()
+	return result, nil
+}
+
+// PreviewRequest routes the detection like Detect and returns the request
+// the chosen provider would send, without calling the API
+func (r *Router) PreviewRequest(img *image.NRGBA, opts *DetectOptions) (*RequestPreview, error) {
+	name, rule := r.Route(img, opts)
+	if rule != nil && rule.MaxMegapixels > 0 {
+		img = downscaleToMegapixels(img, rule.MaxMegapixels)
+	}
+	preview, err := PreviewRequest(img, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	preview.Notes = append(preview.Notes, "routed by rule "+rule.label())
+	return preview, nil
+}
+
+// label names the rule for reports: its name, "rule:<provider>" when
+// unnamed, or "default" for a nil rule
+func (rule *RouteRule) label() string {
+	switch {
+	case rule == nil:
+		return "default"
+	case rule.Name != "":
+		return rule.Name
+	default:
+		return "rule:" + rule.Provider
+	}
+}
+
+// matches reports whether the condition holds for a detection
+func (c *RouteCondition) matches(opts *DetectOptions, megapixels float64, offline bool) bool {
+	if c.Offline && !offline {
+		return false
+	}
+	if c.CustomPrompt && opts.CustomPrompt == "" {
+		return false
+	}
+	if c.MinMegapixels > 0 && megapixels < c.MinMegapixels {
+		return false
+	}
+	if len(c.Features) > 0 {
+		found := false
+		for _, f := range c.Features {
+			if containsFeature(opts.Features, f) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// downscaleToMegapixels shrinks img to at most maxMegapixels with a box
+// filter, keeping the aspect ratio. Smaller images are returned as is.
+func downscaleToMegapixels(img *image.NRGBA, maxMegapixels float64) *image.NRGBA {
+	b := img.Bounds()
+	pixels := float64(b.Dx() * b.Dy())
+	if pixels <= maxMegapixels*1e6 {
+		return img
+	}
+	scale := math.Sqrt(maxMegapixels * 1e6 / pixels)
+	w := max(1, int(float64(b.Dx())*scale))
+	h := max(1, int(float64(b.Dy())*scale))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy0 := b.Min.Y + y*b.Dy()/h
+		sy1 := max(sy0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			sx0 := b.Min.X + x*b.Dx()/w
+			sx1 := max(sx0+1, b.Min.X+(x+1)*b.Dx()/w)
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				i := img.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(img.Pix[i])
+					g += uint32(img.Pix[i+1])
+					bl += uint32(img.Pix[i+2])
+					a += uint32(img.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
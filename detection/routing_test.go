@@ -0,0 +1,106 @@
+package detection
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRouterRoute tests rule matching of the default router
+func TestRouterRoute(t *testing.T) {
+	small := createTestImage(100, 100, color.NRGBA{A: 255})
+	large := createTestImage(4000, 2500, color.NRGBA{A: 255}) // 10MP
+
+	tests := []struct {
+		name     string
+		router   *Router
+		img      bool // large image
+		opts     *DetectOptions
+		want     string
+		wantRule string
+	}{
+		{"faces", DefaultRouter(), false, &DetectOptions{Features: []Feature{FeatureLabels, FeatureFaces}}, "aws", "faces"},
+		{"custom prompt", DefaultRouter(), false, &DetectOptions{CustomPrompt: "Is it a dog?"}, "gemini", "custom-prompt"},
+		{"large image", DefaultRouter(), true, &DetectOptions{Features: []Feature{FeatureLabels}}, "gemini", "large-image"},
+		{"offline wins", &Router{Rules: DefaultRouter().Rules, Offline: true}, false, &DetectOptions{Features: []Feature{FeatureFaces}}, "ollama", "offline"},
+		{"default", &Router{Rules: DefaultRouter().Rules, Default: "google"}, false, &DetectOptions{Features: []Feature{FeatureLabels}}, "gemini", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := small
+			if tt.img {
+				img = large
+			}
+			got, rule := tt.router.Route(img, tt.opts)
+			if got != tt.want {
+				t.Errorf("Route() provider = %q, want %q", got, tt.want)
+			}
+			gotRule := ""
+			if rule != nil {
+				gotRule = rule.Name
+			}
+			if gotRule != tt.wantRule {
+				t.Errorf("Route() rule = %q, want %q", gotRule, tt.wantRule)
+			}
+		})
+	}
+}
+
+// TestLoadRouter tests loading and validating routing rules
+func TestLoadRouter(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("IMGX_ROUTES", filepath.Join(dir, "missing.json"))
+
+	router, err := LoadRouter("")
+	if err != nil || len(router.Rules) != len(DefaultRouter().Rules) {
+		t.Fatalf("LoadRouter() without a file = %+v, %v, want the default rules", router, err)
+	}
+
+	path := filepath.Join(dir, "routes.json")
+	config := `{"rules": [{"name": "text", "when": {"features": ["text"]}, "provider": "openai"}], "default": "aws"}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IMGX_ROUTES", path)
+	router, err = LoadRouter("")
+	if err != nil {
+		t.Fatalf("LoadRouter() error: %v", err)
+	}
+	img := createTestImage(10, 10, color.NRGBA{A: 255})
+	if got, _ := router.Route(img, &DetectOptions{Features: []Feature{FeatureText}}); got != "openai" {
+		t.Errorf("Route(text) = %q, want openai", got)
+	}
+	if got, _ := router.Route(img, &DetectOptions{Features: []Feature{FeatureLabels}}); got != "aws" {
+		t.Errorf("Route(labels) = %q, want aws", got)
+	}
+
+	for _, bad := range []string{`{"rules": [{"when": {}}]}`, `{"default": "auto"}`, `{`} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRouter(path); err == nil {
+			t.Errorf("LoadRouter(%s) should fail", bad)
+		}
+	}
+	if _, err := LoadRouter(filepath.Join(dir, "none.json")); err == nil {
+		t.Error("LoadRouter() with a missing explicit path should fail")
+	}
+}
+
+// TestDownscaleToMegapixels tests the pre-detection downscale
+func TestDownscaleToMegapixels(t *testing.T) {
+	img := createTestImage(400, 200, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	out := downscaleToMegapixels(img, 0.02)
+	if w, h := out.Bounds().Dx(), out.Bounds().Dy(); w != 200 || h != 100 {
+		t.Errorf("downscaled size = %dx%d, want 200x100", w, h)
+	}
+	if c := out.NRGBAAt(50, 50); c != (color.NRGBA{R: 200, G: 100, B: 50, A: 255}) {
+		t.Errorf("downscaled color = %v", c)
+	}
+	if downscaleToMegapixels(img, 1) != img {
+		t.Error("images under the limit should be returned as is")
+	}
+}
@@ -0,0 +1,349 @@
+package detection
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseSchema describes the JSON structure expected from a CustomPrompt.
+// It holds a JSON Schema; the supported keywords are type, properties,
+// required, items, enum, minimum, maximum and description.
+type ResponseSchema struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+// SchemaError lists the ways a custom-prompt response violated its
+// ResponseSchema
+type SchemaError struct {
+	Errors []string // One entry per violation, prefixed with the JSON path
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	return "response does not match schema: " + strings.Join(e.Errors, "; ")
+}
+
+// Unwrap returns ErrSchemaMismatch
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaMismatch
+}
+
+// NewResponseSchema creates a ResponseSchema from a JSON Schema document
+//
+// Example:
+//
+//	schema, err := detection.NewResponseSchema([]byte(`{
+//		"type": "object",
+//		"properties": {"logo_visible": {"type": "boolean"}, "defects": {"type": "array", "items": {"type": "string"}}},
+//		"required": ["logo_visible"]
+//	}`))
+func NewResponseSchema(schema []byte) (*ResponseSchema, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	return &ResponseSchema{Schema: json.RawMessage(schema)}, nil
+}
+
+// SchemaFromStruct creates a ResponseSchema from a Go struct (or pointer to
+// one). Field names come from json tags; fields without omitempty are
+// required. A jsonschema tag adds constraints, e.g.
+// `jsonschema:"enum=red,enum=green,minimum=0,maximum=10"`, and a
+// jsonschema_description tag describes the field to the model.
+//
+// Example:
+//
+//	type Audit struct {
+//		LogoVisible bool     `json:"logo_visible" jsonschema_description:"the brand logo is fully visible"`
+//		Background  string   `json:"background" jsonschema:"enum=white,enum=other"`
+//		Defects     []string `json:"defects,omitempty"`
+//	}
+//
+//	schema, err := detection.SchemaFromStruct(Audit{})
+//	result, err := detection.Detect(ctx, img.ToNRGBA(), "gemini", &detection.DetectOptions{
+//		CustomPrompt:   "Audit this product photo.",
+//		ResponseSchema: schema,
+//	})
+//	var audit Audit
+//	err = result.DecodeStructured(&audit)
+func SchemaFromStruct(v any) (*ResponseSchema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("response schema requires a struct, got %T", v)
+	}
+	doc, err := schemaForType(t)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response schema: %w", err)
+	}
+	return &ResponseSchema{Schema: data}, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType builds the JSON Schema of a Go type
+func schemaForType(t reflect.Type) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaForType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("response schema: map keys must be strings, got %s", t.Key())
+		}
+		return map[string]any{"type": "object"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			prop, err := schemaForType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			if desc := field.Tag.Get("jsonschema_description"); desc != "" {
+				prop["description"] = desc
+			}
+			if err := applySchemaTag(prop, field.Tag.Get("jsonschema")); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			properties[name] = prop
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}, nil
+	default:
+		return nil, fmt.Errorf("response schema: unsupported type %s", t)
+	}
+}
+
+// applySchemaTag adds the constraints of a jsonschema struct tag to prop
+func applySchemaTag(prop map[string]any, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	var enum []any
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "enum":
+			if prop["type"] == "string" {
+				enum = append(enum, value)
+				continue
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid enum value %q", value)
+			}
+			enum = append(enum, n)
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+			prop[key] = n
+		case "description":
+			prop["description"] = value
+		default:
+			return fmt.Errorf("unknown jsonschema tag %q", key)
+		}
+	}
+	if enum != nil {
+		prop["enum"] = enum
+	}
+	return nil
+}
+
+// DecodeStructured unmarshals RawStructured into v. It returns a
+// *SchemaError (matching ErrSchemaMismatch) when the response violated the
+// ResponseSchema, so callers never act on unvalidated data.
+func (r *DetectionResult) DecodeStructured(v any) error {
+	if len(r.SchemaErrors) > 0 {
+		return &SchemaError{Errors: r.SchemaErrors}
+	}
+	if r.RawStructured == nil {
+		return fmt.Errorf("no structured response (set DetectOptions.ResponseSchema with CustomPrompt)")
+	}
+	if err := json.Unmarshal(r.RawStructured, v); err != nil {
+		return fmt.Errorf("failed to decode structured response: %w", err)
+	}
+	return nil
+}
+
+// prompt returns the instruction appended to a custom prompt
+func (s *ResponseSchema) prompt() string {
+	return "Respond only with a JSON value (no markdown fences, no extra text) " +
+		"that matches this JSON Schema:\n" + string(s.Schema)
+}
+
+// parse extracts the structured response from text and validates it,
+// returning the JSON and the schema violations
+func (s *ResponseSchema) parse(text string) (json.RawMessage, []string) {
+	text = extractJSONFromMarkdown(text)
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		// Models sometimes wrap the JSON in prose; try the outermost object.
+		start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+		if start == -1 || end <= start || json.Unmarshal([]byte(text[start:end+1]), &value) != nil {
+			return nil, []string{"$: response is not valid JSON"}
+		}
+		text = text[start : end+1]
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(s.Schema, &doc); err != nil {
+		return json.RawMessage(text), []string{"$: invalid response schema: " + err.Error()}
+	}
+	var errs []string
+	validateSchema(doc, value, "$", &errs)
+	return json.RawMessage(text), errs
+}
+
+// validateSchema checks value against a JSON Schema subset, appending
+// violations to errs
+func validateSchema(schema map[string]any, value any, path string, errs *[]string) {
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not one of %s", path, jsonString(value), jsonString(enum)))
+			return
+		}
+	}
+
+	typ, _ := schema["type"].(string)
+	if typ != "" && !schemaTypeMatches(typ, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, typ, jsonTypeName(value)))
+		return
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if lo, ok := schema["minimum"].(float64); ok && v < lo {
+			*errs = append(*errs, fmt.Sprintf("%s: %v is less than the minimum %v", path, v, lo))
+		}
+		if hi, ok := schema["maximum"].(float64); ok && v > hi {
+			*errs = append(*errs, fmt.Sprintf("%s: %v is greater than the maximum %v", path, v, hi))
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := v[name]; !present {
+						*errs = append(*errs, fmt.Sprintf("%s.%s: required field is missing", path, name))
+					}
+				}
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				prop, ok := properties[name].(map[string]any)
+				if field, present := v[name]; ok && present {
+					validateSchema(prop, field, path+"."+name, errs)
+				}
+			}
+		}
+	}
+}
+
+// schemaTypeMatches reports whether a decoded JSON value has the JSON Schema type
+func schemaTypeMatches(typ string, value any) bool {
+	switch typ {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeName(value) == typ
+	}
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
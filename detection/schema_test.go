@@ -0,0 +1,186 @@
+package detection
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAudit struct {
+	LogoVisible bool     `json:"logo_visible" jsonschema_description:"the brand logo is visible"`
+	Background  string   `json:"background" jsonschema:"enum=white,enum=other"`
+	Score       int      `json:"score" jsonschema:"minimum=0,maximum=10"`
+	Defects     []string `json:"defects,omitempty"`
+	internal    string
+}
+
+// TestSchemaFromStruct tests JSON Schema generation from struct tags
+func TestSchemaFromStruct(t *testing.T) {
+	schema, err := SchemaFromStruct(&testAudit{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct() error: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(schema.Schema, &doc); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if doc["type"] != "object" {
+		t.Errorf("type = %v, want object", doc["type"])
+	}
+	if got, want := doc["required"], []any{"logo_visible", "background", "score"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %v, want %v", got, want)
+	}
+
+	props := doc["properties"].(map[string]any)
+	if len(props) != 4 {
+		t.Errorf("got %d properties, want 4 (unexported fields skipped)", len(props))
+	}
+	logo := props["logo_visible"].(map[string]any)
+	if logo["type"] != "boolean" || logo["description"] != "the brand logo is visible" {
+		t.Errorf("logo_visible = %v", logo)
+	}
+	if got := props["background"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []any{"white", "other"}) {
+		t.Errorf("background enum = %v", got)
+	}
+	score := props["score"].(map[string]any)
+	if score["type"] != "integer" || score["minimum"] != 0.0 || score["maximum"] != 10.0 {
+		t.Errorf("score = %v", score)
+	}
+	if got := props["defects"].(map[string]any)["items"]; !reflect.DeepEqual(got, map[string]any{"type": "string"}) {
+		t.Errorf("defects items = %v", got)
+	}
+
+	if _, err := SchemaFromStruct("not a struct"); err == nil {
+		t.Error("SchemaFromStruct() with a string should fail")
+	}
+	if _, err := NewResponseSchema([]byte("{")); err == nil {
+		t.Error("NewResponseSchema() with invalid JSON should fail")
+	}
+}
+
+// TestResponseSchemaParse tests validation of structured responses
+func TestResponseSchemaParse(t *testing.T) {
+	schema, err := SchemaFromStruct(testAudit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		response   string
+		wantErrors []string
+	}{
+		{
+			name:     "valid",
+			response: `{"logo_visible": true, "background": "white", "score": 8, "defects": ["scratch"]}`,
+		},
+		{
+			name:     "markdown fenced",
+			response: "```json\n{\"logo_visible\": false, \"background\": \"other\", \"score\": 0}\n```",
+		},
+		{
+			name:     "wrapped in prose",
+			response: `Here is the audit: {"logo_visible": true, "background": "white", "score": 3}`,
+		},
+		{
+			name:       "missing and wrong fields",
+			response:   `{"logo_visible": "yes", "background": "grey", "score": 11.5, "defects": [1]}`,
+			wantErrors: []string{"$.background: \"grey\" is not one of", "$.defects[0]: expected string, got number", "$.logo_visible: expected boolean, got string", "$.score: expected integer, got number"},
+		},
+		{
+			name:       "out of range",
+			response:   `{"background": "white", "score": 12}`,
+			wantErrors: []string{"$.logo_visible: required field is missing", "$.score: 12 is greater than the maximum 10"},
+		},
+		{
+			name:       "not JSON",
+			response:   "The logo is visible.",
+			wantErrors: []string{"$: response is not valid JSON"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := schema.parse(tt.response)
+			if len(errs) != len(tt.wantErrors) {
+				t.Fatalf("parse() errors = %q, want %d errors", errs, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.HasPrefix(errs[i], want) {
+					t.Errorf("error %d = %q, want prefix %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestParseTextResponseWithSchema tests that custom-prompt responses land in RawStructured
+func TestParseTextResponseWithSchema(t *testing.T) {
+	schema, err := SchemaFromStruct(testAudit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &DetectOptions{CustomPrompt: "Audit this product photo.", ResponseSchema: schema}
+
+	prompt := buildDetectionPrompt(opts)
+	if !strings.HasPrefix(prompt, opts.CustomPrompt) || !strings.Contains(prompt, string(schema.Schema)) {
+		t.Errorf("buildDetectionPrompt() = %q, want the custom prompt followed by the schema", prompt)
+	}
+
+	result := parseTextResponse(`{"logo_visible": true, "background": "white", "score": 9}`, opts)
+	if result.Description != "" || len(result.SchemaErrors) != 0 {
+		t.Errorf("result = %+v, want only RawStructured", result)
+	}
+	var audit testAudit
+	if err := result.DecodeStructured(&audit); err != nil {
+		t.Fatalf("DecodeStructured() error: %v", err)
+	}
+	if !audit.LogoVisible || audit.Background != "white" || audit.Score != 9 {
+		t.Errorf("DecodeStructured() = %+v", audit)
+	}
+
+	result = parseTextResponse(`{"logo_visible": true}`, opts)
+	err = result.DecodeStructured(&audit)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || !errors.Is(err, ErrSchemaMismatch) || len(schemaErr.Errors) != 2 {
+		t.Errorf("DecodeStructured() error = %v, want a SchemaError with 2 violations", err)
+	}
+	if len(result.Warnings) == 0 {
+		t.Error("expected a warning for the schema mismatch")
+	}
+
+	result = parseTextResponse("no JSON here", opts)
+	if result.Description != "no JSON here" || result.RawStructured != nil {
+		t.Errorf("non-JSON response: Description = %q, RawStructured = %s", result.Description, result.RawStructured)
+	}
+}
+
+func FuzzResponseSchemaParse(f *testing.F) {
+	schema, err := SchemaFromStruct(&testAudit{})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(string(schema.Schema), `{"logo_visible": true, "background": "white", "score": 7}`)
+	f.Add(string(schema.Schema), "Here you go: {\"score\": 11, \"defects\": [1]} hope it helps")
+	f.Add(`{"type": "array", "items": {"type": "number", "minimum": 0}}`, "```json\n[1, -2, \"x\"]\n```")
+
+	f.Fuzz(func(t *testing.T, schemaText, text string) {
+		s, err := NewResponseSchema([]byte(schemaText))
+		if err != nil {
+			return
+		}
+		raw, violations := s.parse(text)
+		if raw == nil {
+			if len(violations) == 0 {
+				t.Error("parse returned no JSON and no violations")
+			}
+			return
+		}
+		if !json.Valid(raw) {
+			t.Errorf("parse returned invalid JSON %q", raw)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package detection
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Sources of synthetic image signals
+const (
+	SignalMetadata  = "metadata"  // generator markers in the file
+	SignalProvider  = "provider"  // judgment of the provider's model
+	SignalFrequency = "frequency" // periodic upsampling artifacts in the spectrum
+)
+
+// SyntheticAssessment combines the available signals into the likelihood
+// that an image is AI-generated. It is a heuristic: a missing marker proves
+// nothing, since metadata is easily stripped, and the model and frequency
+// signals are evidence, not proof.
+type SyntheticAssessment struct {
+	Likelihood float32           `json:"likelihood"` // 0.0-1.0
+	Signals    []SyntheticSignal `json:"signals"`
+}
+
+// SyntheticSignal is one piece of evidence of a SyntheticAssessment
+type SyntheticSignal struct {
+	Source string  `json:"source"` // SignalMetadata, SignalProvider or SignalFrequency
+	Score  float32 `json:"score"`  // 0.0 (camera) to 1.0 (generated)
+	Detail string  `json:"detail,omitempty"`
+}
+
+// syntheticWeights weight the signals in the likelihood. A generator marker
+// in the metadata is near-conclusive and also sets a lower bound.
+var syntheticWeights = map[string]float32{
+	SignalMetadata:  3,
+	SignalProvider:  2,
+	SignalFrequency: 1,
+}
+
+// syntheticMarkers are byte patterns left in files by generators: C2PA and
+// IPTC digital source types, and the parameters written by Stable Diffusion
+// front ends
+var syntheticMarkers = []struct {
+	pattern string
+	detail  string
+}{
+	{"trainedAlgorithmicMedia", "IPTC/C2PA digital source type: trained algorithmic media"},
+	{"compositeWithTrainedAlgorithmicMedia", "IPTC/C2PA digital source type: composite with trained algorithmic media"},
+	{"algorithmicMedia", "IPTC/C2PA digital source type: algorithmic media"},
+	{"Negative prompt:", "Stable Diffusion generation parameters"},
+	{"Steps: ", "Stable Diffusion generation parameters"},
+	{"\"class_type\"", "ComfyUI workflow"},
+	{"sd-metadata", "InvokeAI metadata"},
+	{"NovelAI", "NovelAI"},
+	{"Midjourney", "Midjourney"},
+	{"DALL-E", "DALL-E"},
+	{"DALL·E", "DALL-E"},
+	{"Adobe Firefly", "Adobe Firefly"},
+	{"Stable Diffusion", "Stable Diffusion"},
+}
+
+// SyntheticMetadataMarkers returns the generator markers found in an encoded
+// image file: C2PA/IPTC digital source types declaring AI generation and
+// the parameters embedded by Stable Diffusion front ends (Automatic1111,
+// ComfyUI, InvokeAI) and other generators. A C2PA manifest without an AI
+// source type is reported as "C2PA manifest".
+func SyntheticMetadataMarkers(data []byte) []string {
+	var markers []string
+	seen := make(map[string]bool)
+	for _, m := range syntheticMarkers {
+		if !seen[m.detail] && bytes.Contains(data, []byte(m.pattern)) {
+			seen[m.detail] = true
+			markers = append(markers, m.detail)
+		}
+	}
+	if len(markers) == 0 && bytes.Contains(data, []byte("c2pa")) && bytes.Contains(data, []byte("jumb")) {
+		markers = append(markers, "C2PA manifest")
+	}
+	return markers
+}
+
+// metadataSignal scores the markers of source
+func metadataSignal(source []byte) *SyntheticSignal {
+	markers := SyntheticMetadataMarkers(source)
+	if len(markers) == 0 {
+		return nil
+	}
+	if len(markers) == 1 && markers[0] == "C2PA manifest" {
+		// Content credentials without an AI source type, e.g. from a camera
+		return &SyntheticSignal{Source: SignalMetadata, Score: 0.2, Detail: "C2PA manifest without an AI source type"}
+	}
+	detail := markers[0]
+	for _, m := range markers[1:] {
+		detail += "; " + m
+	}
+	return &SyntheticSignal{Source: SignalMetadata, Score: 0.98, Detail: detail}
+}
+
+// spectrumSize is the largest side of the luminance crop analyzed by
+// frequencySignal
+const spectrumSize = 256
+
+// frequencySignal looks for the periodic peaks that the upsampling layers of
+// generators leave in the power spectrum, at multiples of 1/8 of the
+// sampling frequency. JPEG blocks leave peaks at the same frequencies, so
+// the score of JPEG sources is halved. Images smaller than 64x64 give no
+// signal.
+func frequencySignal(img *image.NRGBA, jpeg bool) *SyntheticSignal {
+	b := img.Bounds()
+	n := spectrumSize
+	for n > b.Dx() || n > b.Dy() {
+		n /= 2
+	}
+	if n < 64 {
+		return nil
+	}
+
+	// Hann-windowed luminance of the center crop
+	x0, y0 := b.Min.X+(b.Dx()-n)/2, b.Min.Y+(b.Dy()-n)/2
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	data := make([][]complex128, n)
+	for y := 0; y < n; y++ {
+		data[y] = make([]complex128, n)
+		for x := 0; x < n; x++ {
+			p := img.PixOffset(x0+x, y0+y)
+			lum := 0.299*float64(img.Pix[p]) + 0.587*float64(img.Pix[p+1]) + 0.114*float64(img.Pix[p+2])
+			data[y][x] = complex(lum*window[x]*window[y], 0)
+		}
+	}
+	fft2(data)
+
+	power := func(u, v int) float64 {
+		c := data[(v+n)%n][(u+n)%n]
+		return real(c)*real(c) + imag(c)*imag(c)
+	}
+
+	// Strongest peak at the multiples of n/8 against its neighbors. The
+	// reference is the larger median of the horizontal and vertical
+	// neighbors, so the strong axes of photos (edges, horizon, window) are
+	// not mistaken for peaks.
+	median := func(v []float64) float64 {
+		sort.Float64s(v)
+		return (v[len(v)/2-1] + v[len(v)/2]) / 2
+	}
+	var peak float64
+	step := n / 8
+	for v := -n / 2; v < n/2; v += step {
+		for u := -n / 2; u < n/2; u += step {
+			if u == 0 && v == 0 {
+				continue
+			}
+			var horizontal, vertical []float64
+			for _, d := range []int{-4, -3, -2, 2, 3, 4} {
+				horizontal = append(horizontal, power(u+d, v))
+				vertical = append(vertical, power(u, v+d))
+			}
+			ref := math.Max(median(horizontal), median(vertical))
+			if ref > 0 {
+				peak = math.Max(peak, power(u, v)/ref)
+			}
+		}
+	}
+
+	// Noise alone gives peaks up to ~10x; 100x and more is a strong artifact
+	score := math.Log10(math.Max(peak, 1)) - 1
+	score = math.Max(0, math.Min(1, score))
+	detail := fmt.Sprintf("periodic spectral peak %.1fx its neighbors", peak)
+	if jpeg {
+		score /= 2
+		detail += " (halved for JPEG)"
+	}
+	return &SyntheticSignal{Source: SignalFrequency, Score: float32(score), Detail: detail}
+}
+
+// fft2 transforms a square power-of-two matrix in place
+func fft2(data [][]complex128) {
+	n := len(data)
+	for _, row := range data {
+		fft(row)
+	}
+	col := make([]complex128, n)
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			col[y] = data[y][x]
+		}
+		fft(col)
+		for y := 0; y < n; y++ {
+			data[y][x] = col[y]
+		}
+	}
+}
+
+// fft is an in-place radix-2 Cooley-Tukey FFT; len(a) is a power of two
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := a[start+k], a[start+k+size/2]*wk
+				a[start+k] = even + odd
+				a[start+k+size/2] = even - odd
+				wk *= w
+			}
+		}
+	}
+}
+
+// AssessSynthetic combines the signals available for an image into a
+// SyntheticAssessment: the generator markers of source (the encoded file,
+// may be nil), the judgment of a provider (nil if none) and the frequency
+// heuristic on img. The likelihood is the weighted mean of the signal
+// scores, and at least the metadata score when generator markers were
+// found.
+func AssessSynthetic(img *image.NRGBA, source []byte, provider *SyntheticSignal) *SyntheticAssessment {
+	a := &SyntheticAssessment{}
+	if s := metadataSignal(source); s != nil {
+		a.Signals = append(a.Signals, *s)
+	}
+	if provider != nil {
+		a.Signals = append(a.Signals, *provider)
+	}
+	if img != nil {
+		jpeg := len(source) > 2 && source[0] == 0xFF && source[1] == 0xD8
+		if s := frequencySignal(img, jpeg); s != nil {
+			a.Signals = append(a.Signals, *s)
+		}
+	}
+
+	var sum, weights float32
+	for _, s := range a.Signals {
+		w := syntheticWeights[s.Source]
+		sum += w * s.Score
+		weights += w
+	}
+	if weights > 0 {
+		a.Likelihood = sum / weights
+	}
+	for _, s := range a.Signals {
+		if s.Source == SignalMetadata && s.Score > a.Likelihood {
+			a.Likelihood = s.Score
+		}
+	}
+	return a
+}
+
+// addSyntheticAssessment completes result for FeatureSynthetic: the
+// provider's judgment, parsed into result.Synthetic, is combined with the
+// local signals. img is the full-resolution image, since downscaling
+// removes the frequency artifacts.
+func addSyntheticAssessment(img *image.NRGBA, opts *DetectOptions, result *DetectionResult) {
+	if opts == nil || !containsFeature(opts.Features, FeatureSynthetic) {
+		return
+	}
+	var provider *SyntheticSignal
+	if result.Synthetic != nil {
+		for i, s := range result.Synthetic.Signals {
+			if s.Source == SignalProvider {
+				provider = &result.Synthetic.Signals[i]
+			}
+		}
+	}
+	result.Synthetic = AssessSynthetic(img, opts.Source, provider)
+}
+
+// parseSyntheticFromInterface parses the {"likelihood", "reason"} object
+// the LLM providers return for FeatureSynthetic
+func parseSyntheticFromInterface(value interface{}) *SyntheticAssessment {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	likelihood, ok := toFloat32(m["likelihood"])
+	if !ok {
+		return nil
+	}
+	likelihood = float32(math.Max(0, math.Min(1, float64(likelihood))))
+	reason, _ := m["reason"].(string)
+	return &SyntheticAssessment{
+		Likelihood: likelihood,
+		Signals:    []SyntheticSignal{{Source: SignalProvider, Score: likelihood, Detail: reason}},
+	}
+}
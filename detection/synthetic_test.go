@@ -0,0 +1,146 @@
+package detection
+
+import (
+	"image"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// noiseImage returns smooth random noise, like the texture of a photo.
+// With period > 0, a checkerboard of that period is added, like the
+// artifacts of upsampling layers.
+func noiseImage(size, period int) *image.NRGBA {
+	r := rand.New(rand.NewPCG(1, 2))
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	v := 128.0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			v += r.NormFloat64() * 4
+			v = max(20, min(235, v))
+			c := v
+			if period > 0 && (x/(period/2)+y/(period/2))%2 == 0 {
+				c += 6
+			}
+			p := img.PixOffset(x, y)
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = uint8(c), uint8(c), uint8(c), 255
+		}
+	}
+	return img
+}
+
+func TestSyntheticMetadataMarkers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"none", "\x89PNG plain file", nil},
+		{"iptc", `<Iptc4xmpExt:DigitalSourceType>http://cv.iptc.org/newscodes/digitalsourcetype/trainedAlgorithmicMedia</Iptc4xmpExt:DigitalSourceType>`,
+			[]string{"IPTC/C2PA digital source type: trained algorithmic media"}},
+		{"a1111", "tEXtparameters\x00a cat\nNegative prompt: blurry\nSteps: 20, Sampler: Euler a",
+			[]string{"Stable Diffusion generation parameters"}},
+		{"comfyui", `tEXtprompt{"3": {"class_type": "KSampler"}}`, []string{"ComfyUI workflow"}},
+		{"c2pa camera", "jumb....c2pa.claim", []string{"C2PA manifest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SyntheticMetadataMarkers([]byte(tt.data))
+			if len(got) != len(tt.want) {
+				t.Fatalf("SyntheticMetadataMarkers = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("marker %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFrequencySignal(t *testing.T) {
+	photo := frequencySignal(noiseImage(256, 0), false)
+	generated := frequencySignal(noiseImage(256, 8), false)
+	if photo == nil || generated == nil {
+		t.Fatal("frequencySignal returned nil for a 256x256 image")
+	}
+	if photo.Score > 0.3 {
+		t.Errorf("photo score = %.2f (%s), want <= 0.3", photo.Score, photo.Detail)
+	}
+	if generated.Score < 0.7 {
+		t.Errorf("periodic score = %.2f (%s), want >= 0.7", generated.Score, generated.Detail)
+	}
+	if jpeg := frequencySignal(noiseImage(256, 8), true); jpeg.Score > generated.Score/2+1e-6 {
+		t.Errorf("JPEG score = %.2f, want half of %.2f", jpeg.Score, generated.Score)
+	}
+	if s := frequencySignal(noiseImage(32, 0), false); s != nil {
+		t.Errorf("frequencySignal of a 32x32 image = %+v, want nil", s)
+	}
+}
+
+func TestAssessSynthetic(t *testing.T) {
+	img := noiseImage(128, 0)
+
+	a := AssessSynthetic(img, nil, nil)
+	if len(a.Signals) != 1 || a.Signals[0].Source != SignalFrequency {
+		t.Fatalf("signals = %+v, want only the frequency signal", a.Signals)
+	}
+
+	// Generator markers set a lower bound
+	source := []byte("Negative prompt: blurry\nSteps: 20")
+	a = AssessSynthetic(img, source, &SyntheticSignal{Source: SignalProvider, Score: 0.1})
+	if len(a.Signals) != 3 {
+		t.Fatalf("signals = %+v, want 3", a.Signals)
+	}
+	if a.Likelihood < 0.98 {
+		t.Errorf("likelihood with generator markers = %.2f, want >= 0.98", a.Likelihood)
+	}
+
+	// Weighted mean of the provider and frequency signals
+	a = AssessSynthetic(nil, nil, &SyntheticSignal{Source: SignalProvider, Score: 0.6})
+	if a.Likelihood != 0.6 {
+		t.Errorf("likelihood = %.2f, want 0.6", a.Likelihood)
+	}
+}
+
+func TestParseSyntheticResponse(t *testing.T) {
+	result := &DetectionResult{}
+	err := parseJSONDetectionResponse(`{"synthetic": {"likelihood": 0.8, "reason": "six fingers"}}`, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Synthetic == nil || result.Synthetic.Likelihood != 0.8 {
+		t.Fatalf("Synthetic = %+v, want likelihood 0.8", result.Synthetic)
+	}
+
+	opts := &DetectOptions{Features: []Feature{FeatureSynthetic}}
+	addSyntheticAssessment(nil, opts, result)
+	s := result.Synthetic.Signals
+	if len(s) != 1 || s[0].Source != SignalProvider || s[0].Detail != "six fingers" {
+		t.Errorf("signals = %+v, want the provider signal", s)
+	}
+}
+
+func TestBuildDetectionPromptSynthetic(t *testing.T) {
+	prompt := buildDetectionPrompt(&DetectOptions{Features: []Feature{FeatureSynthetic}})
+	if !strings.Contains(prompt, `"synthetic"`) {
+		t.Errorf("prompt does not ask for the synthetic key:\n%s", prompt)
+	}
+}
+
+func FuzzSyntheticMetadataMarkers(f *testing.F) {
+	for _, m := range syntheticMarkers {
+		f.Add([]byte(m.pattern))
+	}
+	f.Add([]byte("\x89PNG plain file"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		seen := make(map[string]bool)
+		for _, marker := range SyntheticMetadataMarkers(data) {
+			if seen[marker] {
+				t.Errorf("marker %q reported twice", marker)
+			}
+			seen[marker] = true
+		}
+	})
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 )
 
-// MockProvider is a mock implementation of the Provider interface for testing
+// MockProvider is a mock implementation of the Provider interface for testing.
+// Applications testing their own code should use the scriptable provider in
+// the detection/mock package.
 type MockProvider struct {
 	NameFunc         func() string
 	IsConfiguredFunc func() bool
@@ -277,6 +279,10 @@ func SkipIfNoCredentials(t *testing.T, provider string) {
 		if os.Getenv("OPENAI_API_KEY") == "" {
 			t.Skip("Skipping test: OPENAI_API_KEY not set")
 		}
+	case "vision":
+		if os.Getenv("GOOGLE_VISION_API_KEY") == "" {
+			t.Skip("Skipping test: GOOGLE_VISION_API_KEY not set")
+		}
 	case "aws":
 		// AWS uses credential chain, harder to check directly
 		// Provider constructor will fail if not configured
@@ -0,0 +1,516 @@
+package detection
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const visionEndpoint = "https://vision.googleapis.com/v1/images:annotate"
+
+// visionFeatureTypes maps detection features to Cloud Vision feature types
+var visionFeatureTypes = map[Feature]string{
+	FeatureLabels:     "LABEL_DETECTION",
+	FeatureObjects:    "OBJECT_LOCALIZATION",
+	FeatureText:       "TEXT_DETECTION",
+	FeatureFaces:      "FACE_DETECTION",
+	FeatureWeb:        "WEB_DETECTION",
+	FeatureLandmarks:  "LANDMARK_DETECTION",
+	FeatureLogos:      "LOGO_DETECTION",
+	FeatureSafeSearch: "SAFE_SEARCH_DETECTION",
+	FeatureProperties: "IMAGE_PROPERTIES",
+	FeatureWatermark:  "TEXT_DETECTION", // Watermark text, see AssessWatermark
+}
+
+// visionLikelihoods maps Cloud Vision likelihoods to confidence scores
+var visionLikelihoods = map[string]float32{
+	"VERY_UNLIKELY": 0.1,
+	"UNLIKELY":      0.3,
+	"POSSIBLE":      0.5,
+	"LIKELY":        0.7,
+	"VERY_LIKELY":   0.9,
+}
+
+// VisionProvider implements the Provider interface for the Google Cloud
+// Vision API. Unlike the LLM providers it returns the structured results of
+// the API: web detection, landmarks, logos and localized objects.
+type VisionProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+type visionRequest struct {
+	Requests []visionImageRequest `json:"requests"`
+}
+
+type visionImageRequest struct {
+	Image        visionImage         `json:"image"`
+	Features     []visionFeature     `json:"features"`
+	ImageContext *visionImageContext `json:"imageContext,omitempty"`
+}
+
+type visionImage struct {
+	Content string `json:"content"`
+}
+
+type visionFeature struct {
+	Type       string `json:"type"`
+	MaxResults int    `json:"maxResults,omitempty"`
+}
+
+type visionImageContext struct {
+	LanguageHints []string `json:"languageHints,omitempty"`
+}
+
+type visionResponse struct {
+	Responses []visionImageResponse `json:"responses"`
+	Error     *visionStatus         `json:"error"`
+}
+
+type visionStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type visionImageResponse struct {
+	LabelAnnotations           []visionEntity         `json:"labelAnnotations"`
+	LocalizedObjectAnnotations []visionObject         `json:"localizedObjectAnnotations"`
+	TextAnnotations            []visionEntity         `json:"textAnnotations"`
+	FaceAnnotations            []visionFace           `json:"faceAnnotations"`
+	LandmarkAnnotations        []visionEntity         `json:"landmarkAnnotations"`
+	LogoAnnotations            []visionEntity         `json:"logoAnnotations"`
+	SafeSearchAnnotation       map[string]string      `json:"safeSearchAnnotation"`
+	ImagePropertiesAnnotation  *visionImageProperties `json:"imagePropertiesAnnotation"`
+	WebDetection               *visionWebDetection    `json:"webDetection"`
+	Error                      *visionStatus          `json:"error"`
+}
+
+type visionEntity struct {
+	MID          string           `json:"mid"`
+	Locale       string           `json:"locale"`
+	Description  string           `json:"description"`
+	Score        float32          `json:"score"`
+	Topicality   float32          `json:"topicality"`
+	BoundingPoly *visionPoly      `json:"boundingPoly"`
+	Locations    []visionLocation `json:"locations"`
+}
+
+type visionObject struct {
+	MID          string      `json:"mid"`
+	Name         string      `json:"name"`
+	Score        float32     `json:"score"`
+	BoundingPoly *visionPoly `json:"boundingPoly"`
+}
+
+type visionPoly struct {
+	Vertices           []visionVertex `json:"vertices"`
+	NormalizedVertices []visionVertex `json:"normalizedVertices"`
+}
+
+type visionVertex struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+	Z float32 `json:"z"`
+}
+
+type visionLocation struct {
+	LatLng GeoPoint `json:"latLng"`
+}
+
+type visionFace struct {
+	BoundingPoly        *visionPoly `json:"boundingPoly"`
+	DetectionConfidence float32     `json:"detectionConfidence"`
+	Landmarks           []struct {
+		Type     string       `json:"type"`
+		Position visionVertex `json:"position"`
+	} `json:"landmarks"`
+	JoyLikelihood      string `json:"joyLikelihood"`
+	SorrowLikelihood   string `json:"sorrowLikelihood"`
+	AngerLikelihood    string `json:"angerLikelihood"`
+	SurpriseLikelihood string `json:"surpriseLikelihood"`
+}
+
+type visionImageProperties struct {
+	DominantColors struct {
+		Colors []struct {
+			Color struct {
+				Red   float32 `json:"red"`
+				Green float32 `json:"green"`
+				Blue  float32 `json:"blue"`
+			} `json:"color"`
+			Score         float32 `json:"score"`
+			PixelFraction float32 `json:"pixelFraction"`
+		} `json:"colors"`
+	} `json:"dominantColors"`
+}
+
+type visionWebDetection struct {
+	WebEntities []struct {
+		EntityID    string  `json:"entityId"`
+		Score       float32 `json:"score"`
+		Description string  `json:"description"`
+	} `json:"webEntities"`
+	FullMatchingImages      []WebImage `json:"fullMatchingImages"`
+	PartialMatchingImages   []WebImage `json:"partialMatchingImages"`
+	VisuallySimilarImages   []WebImage `json:"visuallySimilarImages"`
+	PagesWithMatchingImages []struct {
+		URL                   string     `json:"url"`
+		Score                 float32    `json:"score"`
+		PageTitle             string     `json:"pageTitle"`
+		FullMatchingImages    []WebImage `json:"fullMatchingImages"`
+		PartialMatchingImages []WebImage `json:"partialMatchingImages"`
+	} `json:"pagesWithMatchingImages"`
+	BestGuessLabels []struct {
+		Label string `json:"label"`
+	} `json:"bestGuessLabels"`
+}
+
+// NewVisionProvider creates a new Google Cloud Vision provider instance
+func NewVisionProvider() (*VisionProvider, error) {
+	apiKey := os.Getenv("GOOGLE_VISION_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: GOOGLE_VISION_API_KEY environment variable not set", ErrProviderNotConfigured)
+	}
+
+	timeoutSeconds := GetTimeout()
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+
+	return &VisionProvider{
+		apiKey: apiKey,
+		client: debugHTTPClient("vision", &http.Client{
+			Timeout: time.Duration(timeoutSeconds) * time.Second,
+		}),
+	}, nil
+}
+
+// Name returns the provider name
+func (v *VisionProvider) Name() string {
+	return "vision"
+}
+
+// IsConfigured checks if the provider is properly configured
+func (v *VisionProvider) IsConfigured() bool {
+	return v.apiKey != ""
+}
+
+// Detect annotates the image with one Cloud Vision request covering all
+// requested features. Description, synthetic and custom prompts need an LLM
+// provider and are reported as warnings.
+func (v *VisionProvider) Detect(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
+
+	startTime := time.Now()
+
+	imgBytes, err := imageToJPEGBytes(img)
+	if err != nil {
+		return nil, NewDetectionError("vision", "failed to encode image", err)
+	}
+
+	result := &DetectionResult{
+		Provider:    "vision",
+		Properties:  make(map[string]string),
+		ProcessedAt: startTime,
+	}
+
+	features, unsupported := v.buildFeatures(opts)
+	for _, feature := range unsupported {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("feature %q is not supported by Cloud Vision", feature))
+	}
+	if opts.CustomPrompt != "" {
+		result.Warnings = append(result.Warnings, "custom prompts are ignored by Cloud Vision")
+	}
+	if len(features) == 0 {
+		return result, nil
+	}
+
+	annotateReq := visionImageRequest{
+		Image:    visionImage{Content: base64.StdEncoding.EncodeToString(imgBytes)},
+		Features: features,
+	}
+	if opts.Language != "" {
+		annotateReq.ImageContext = &visionImageContext{LanguageHints: []string{opts.Language}}
+	}
+
+	payload, err := json.Marshal(&visionRequest{Requests: []visionImageRequest{annotateReq}})
+	if err != nil {
+		return nil, NewDetectionError("vision", "failed to marshal request", err)
+	}
+
+	// The key goes in a header: in the URL it would end up in *url.Error
+	// messages, and from there in warnings and logs
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, visionEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, NewDetectionError("vision", "failed to create request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goog-Api-Key", v.apiKey)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, NewDetectionError("vision", "API request failed", err)
+	}
+	defer resp.Body.Close()
+
+	const maxResponseSize = 10 << 20 // 10 MB
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, NewDetectionError("vision", "failed to read response", err)
+	}
+
+	var parsed visionResponse
+	if err := json.Unmarshal(body, &parsed); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, NewDetectionError("vision", "failed to decode response", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(body))
+		if parsed.Error != nil && parsed.Error.Message != "" {
+			message = parsed.Error.Message
+		}
+		if message == "" {
+			message = resp.Status
+		}
+		return nil, NewDetectionError("vision", fmt.Sprintf("API returned %s: %s", resp.Status, message), visionStatusError(resp.StatusCode))
+	}
+	if len(parsed.Responses) == 0 {
+		return nil, NewDetectionError("vision", "empty response", ErrAPIError)
+	}
+	annotations := parsed.Responses[0]
+	if annotations.Error != nil && annotations.Error.Message != "" {
+		return nil, NewDetectionError("vision", annotations.Error.Message, ErrAPIError)
+	}
+
+	if opts.IncludeRawResponse {
+		result.RawResponse = string(body)
+	}
+
+	bounds := img.Bounds()
+	v.parseAnnotations(&annotations, result, opts, float32(bounds.Dx()), float32(bounds.Dy()))
+
+	return result, nil
+}
+
+// buildFeatures returns the Cloud Vision features for opts and the
+// requested features it cannot annotate
+func (v *VisionProvider) buildFeatures(opts *DetectOptions) ([]visionFeature, []Feature) {
+	var features []visionFeature
+	var unsupported []Feature
+	seen := make(map[string]bool)
+	for _, feature := range opts.Features {
+		typ, ok := visionFeatureTypes[feature]
+		if !ok {
+			unsupported = append(unsupported, feature)
+			continue
+		}
+		if seen[typ] {
+			continue
+		}
+		seen[typ] = true
+		features = append(features, visionFeature{Type: typ, MaxResults: opts.MaxResults})
+	}
+	return features, unsupported
+}
+
+// parseAnnotations fills result from the annotations of one image of size
+// width x height. Pixel coordinates are converted to the 0.0-1.0 range used
+// by the other providers.
+func (v *VisionProvider) parseAnnotations(a *visionImageResponse, result *DetectionResult, opts *DetectOptions, width, height float32) {
+	for _, label := range a.LabelAnnotations {
+		if label.Score < opts.MinConfidence {
+			continue
+		}
+		result.Labels = append(result.Labels, Label{
+			Name:       label.Description,
+			Confidence: label.Score,
+			Score:      label.Topicality,
+			MID:        label.MID,
+		})
+	}
+
+	for _, object := range a.LocalizedObjectAnnotations {
+		if object.Score < opts.MinConfidence {
+			continue
+		}
+		bbox := BoundingBox{Label: object.Name, Confidence: object.Score}
+		if box := visionBox(object.BoundingPoly, width, height); box != nil {
+			bbox.Box = *box
+		}
+		result.BoundingBoxes = append(result.BoundingBoxes, bbox)
+	}
+
+	// The first text annotation is the full text, the others its words
+	for i, text := range a.TextAnnotations {
+		block := TextBlock{
+			Text:        strings.TrimSpace(text.Description),
+			Language:    text.Locale,
+			BoundingBox: visionBox(text.BoundingPoly, width, height),
+			Type:        "WORD",
+		}
+		if i == 0 {
+			block.Type = "TEXT"
+		}
+		result.Text = append(result.Text, block)
+	}
+
+	for _, f := range a.FaceAnnotations {
+		face := Face{
+			Confidence:         f.DetectionConfidence,
+			BoundingBox:        visionBox(f.BoundingPoly, width, height),
+			JoyLikelihood:      f.JoyLikelihood,
+			SorrowLikelihood:   f.SorrowLikelihood,
+			AngerLikelihood:    f.AngerLikelihood,
+			SurpriseLikelihood: f.SurpriseLikelihood,
+		}
+		for _, lm := range f.Landmarks {
+			face.Landmarks = append(face.Landmarks, Landmark{
+				Type: lm.Type,
+				X:    lm.Position.X / width,
+				Y:    lm.Position.Y / height,
+				Z:    lm.Position.Z,
+			})
+		}
+		result.Faces = append(result.Faces, face)
+	}
+
+	result.Landmarks = visionEntities(a.LandmarkAnnotations, opts.MinConfidence, width, height)
+	result.Logos = visionEntities(a.LogoAnnotations, opts.MinConfidence, width, height)
+
+	if len(a.SafeSearchAnnotation) > 0 {
+		summary := &SafeSearchSummary{}
+		for _, category := range []string{"adult", "spoof", "medical", "violence", "racy"} {
+			likelihood, ok := a.SafeSearchAnnotation[category]
+			if !ok {
+				continue
+			}
+			label := ModerationLabel{
+				Name:       strings.ToUpper(category[:1]) + category[1:],
+				Confidence: visionLikelihoods[likelihood],
+				Severity:   likelihood,
+			}
+			summary.Labels = append(summary.Labels, label)
+			if likelihood == "LIKELY" || likelihood == "VERY_LIKELY" {
+				result.Moderation = append(result.Moderation, label)
+			}
+		}
+		result.SafeSearch = summary
+	}
+
+	if a.ImagePropertiesAnnotation != nil {
+		for _, c := range a.ImagePropertiesAnnotation.DominantColors.Colors {
+			r, g, b := int(c.Color.Red), int(c.Color.Green), int(c.Color.Blue)
+			result.Colors = append(result.Colors, ColorInfo{
+				Hex:        fmt.Sprintf("#%02X%02X%02X", r, g, b),
+				RGB:        fmt.Sprintf("rgb(%d,%d,%d)", r, g, b),
+				Percentage: c.PixelFraction * 100,
+			})
+		}
+	}
+
+	if w := a.WebDetection; w != nil {
+		web := &WebDetection{
+			FullMatchingImages:    w.FullMatchingImages,
+			PartialMatchingImages: w.PartialMatchingImages,
+			VisuallySimilarImages: w.VisuallySimilarImages,
+		}
+		for _, entity := range w.WebEntities {
+			web.WebEntities = append(web.WebEntities, WebEntity{
+				EntityID:    entity.EntityID,
+				Score:       entity.Score,
+				Description: entity.Description,
+			})
+		}
+		for _, page := range w.PagesWithMatchingImages {
+			web.PagesWithMatchingImages = append(web.PagesWithMatchingImages, WebPage{
+				URL:                   page.URL,
+				Score:                 page.Score,
+				PageTitle:             page.PageTitle,
+				FullMatchingImages:    page.FullMatchingImages,
+				PartialMatchingImages: page.PartialMatchingImages,
+			})
+		}
+		for _, guess := range w.BestGuessLabels {
+			web.BestGuessLabels = append(web.BestGuessLabels, guess.Label)
+		}
+		result.Web = web
+	}
+
+	if len(result.Labels) > 0 {
+		var totalConf float32
+		for _, label := range result.Labels {
+			totalConf += label.Confidence
+		}
+		result.Confidence = totalConf / float32(len(result.Labels))
+	}
+}
+
+// visionEntities converts landmark or logo annotations, dropping those
+// below minConfidence
+func visionEntities(annotations []visionEntity, minConfidence, width, height float32) []EntityAnnotation {
+	var entities []EntityAnnotation
+	for _, a := range annotations {
+		if a.Score < minConfidence {
+			continue
+		}
+		entity := EntityAnnotation{
+			Name:        a.Description,
+			Confidence:  a.Score,
+			MID:         a.MID,
+			BoundingBox: visionBox(a.BoundingPoly, width, height),
+		}
+		for _, loc := range a.Locations {
+			entity.Locations = append(entity.Locations, loc.LatLng)
+		}
+		entities = append(entities, entity)
+	}
+	return entities
+}
+
+// visionBox returns the bounding rectangle of poly relative to the image
+// size, or nil if poly has no vertices
+func visionBox(poly *visionPoly, width, height float32) *Box {
+	if poly == nil {
+		return nil
+	}
+	vertices := poly.NormalizedVertices
+	if len(vertices) == 0 && len(poly.Vertices) > 0 && width > 0 && height > 0 {
+		for _, vertex := range poly.Vertices {
+			vertices = append(vertices, visionVertex{X: vertex.X / width, Y: vertex.Y / height})
+		}
+	}
+	if len(vertices) == 0 {
+		return nil
+	}
+	minX, minY, maxX, maxY := vertices[0].X, vertices[0].Y, vertices[0].X, vertices[0].Y
+	for _, vertex := range vertices[1:] {
+		minX, maxX = min(minX, vertex.X), max(maxX, vertex.X)
+		minY, maxY = min(minY, vertex.Y), max(maxY, vertex.Y)
+	}
+	return &Box{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// visionStatusError maps an HTTP status of the API to a detection error
+func visionStatusError(status int) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidAPIKey
+	case http.StatusTooManyRequests:
+		return ErrRateLimit
+	case http.StatusRequestEntityTooLarge:
+		return ErrImageTooLarge
+	default:
+		return ErrAPIError
+	}
+}
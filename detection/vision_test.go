@@ -0,0 +1,212 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const visionTestResponse = `{"responses":[{
+	"labelAnnotations":[
+		{"mid":"/m/0d4v4","description":"Tower","score":0.94,"topicality":0.9},
+		{"mid":"/m/01","description":"Sky","score":0.3}
+	],
+	"landmarkAnnotations":[{"mid":"/m/02j81","description":"Eiffel Tower","score":0.88,
+		"boundingPoly":{"vertices":[{"x":25},{"x":75},{"x":75,"y":50},{"x":25,"y":50}]},
+		"locations":[{"latLng":{"latitude":48.8584,"longitude":2.2945}}]}],
+	"logoAnnotations":[{"mid":"/m/045c7b","description":"Google","score":0.71,
+		"boundingPoly":{"vertices":[{"x":0,"y":0},{"x":50,"y":0},{"x":50,"y":20},{"x":0,"y":20}]}}],
+	"localizedObjectAnnotations":[{"name":"Person","score":0.8,
+		"boundingPoly":{"normalizedVertices":[{"x":0.25,"y":0.25},{"x":0.75,"y":0.25},{"x":0.75,"y":1},{"x":0.25,"y":1}]}}],
+	"textAnnotations":[{"locale":"fr","description":"Paris\n"},{"description":"Paris"}],
+	"safeSearchAnnotation":{"adult":"VERY_UNLIKELY","spoof":"UNLIKELY","medical":"VERY_UNLIKELY","violence":"LIKELY","racy":"POSSIBLE"},
+	"webDetection":{
+		"webEntities":[{"entityId":"/m/02j81","score":1.2,"description":"Eiffel Tower"}],
+		"fullMatchingImages":[{"url":"https://example.com/eiffel.jpg"}],
+		"pagesWithMatchingImages":[{"url":"https://example.com/paris","pageTitle":"Paris"}],
+		"bestGuessLabels":[{"label":"eiffel tower","languageCode":"en"}]
+	}
+}]}`
+
+// TestVisionDetect tests the request sent to Cloud Vision and the parsing
+// of its annotations
+func TestVisionDetect(t *testing.T) {
+	t.Setenv("GOOGLE_VISION_API_KEY", "test-key")
+	provider, err := NewVisionProvider()
+	if err != nil {
+		t.Fatalf("NewVisionProvider() error = %v", err)
+	}
+
+	var captured visionRequest
+	provider.client = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if got := r.Header.Get("X-Goog-Api-Key"); got != "test-key" || r.URL.RawQuery != "" {
+				t.Errorf("key header = %q, query = %q, want the key in the header only", got, r.URL.RawQuery)
+			}
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &captured); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(visionTestResponse)),
+			}, nil
+		}),
+	}
+
+	img := CreateTestImage(100, 100, color.NRGBA{R: 120, G: 160, B: 220, A: 255})
+	opts := &DetectOptions{
+		Features:      []Feature{FeatureLabels, FeatureObjects, FeatureText, FeatureWeb, FeatureLandmarks, FeatureLogos, FeatureSafeSearch, FeatureDescription},
+		MaxResults:    5,
+		MinConfidence: 0.5,
+	}
+	result, err := provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if len(captured.Requests) != 1 || captured.Requests[0].Image.Content == "" {
+		t.Fatalf("request = %+v, want one image", captured)
+	}
+	var types []string
+	for _, f := range captured.Requests[0].Features {
+		types = append(types, f.Type)
+		if f.MaxResults != 5 {
+			t.Errorf("%s maxResults = %d, want 5", f.Type, f.MaxResults)
+		}
+	}
+	if got, want := strings.Join(types, ","), "LABEL_DETECTION,OBJECT_LOCALIZATION,TEXT_DETECTION,WEB_DETECTION,LANDMARK_DETECTION,LOGO_DETECTION,SAFE_SEARCH_DETECTION"; got != want {
+		t.Errorf("features = %s, want %s", got, want)
+	}
+
+	if result.Provider != "vision" {
+		t.Errorf("Provider = %q, want vision", result.Provider)
+	}
+	if len(result.Labels) != 1 || result.Labels[0].Name != "Tower" || result.Labels[0].MID != "/m/0d4v4" {
+		t.Errorf("Labels = %+v, want Tower only (Sky is below the minimum confidence)", result.Labels)
+	}
+	if len(result.Landmarks) != 1 {
+		t.Fatalf("Landmarks = %+v, want 1", result.Landmarks)
+	}
+	landmark := result.Landmarks[0]
+	if landmark.Name != "Eiffel Tower" || len(landmark.Locations) != 1 || landmark.Locations[0].Latitude != 48.8584 {
+		t.Errorf("landmark = %+v", landmark)
+	}
+	if box := landmark.BoundingBox; box == nil || box.X != 0.25 || box.Y != 0 || box.Width != 0.5 || box.Height != 0.5 {
+		t.Errorf("landmark box = %+v, want {0.25 0 0.5 0.5}", landmark.BoundingBox)
+	}
+	if len(result.Logos) != 1 || result.Logos[0].Name != "Google" {
+		t.Errorf("Logos = %+v, want Google", result.Logos)
+	}
+	if len(result.BoundingBoxes) != 1 || result.BoundingBoxes[0].Label != "Person" || result.BoundingBoxes[0].Box.Width != 0.5 {
+		t.Errorf("BoundingBoxes = %+v, want Person 0.5 wide", result.BoundingBoxes)
+	}
+	if len(result.Text) != 2 || result.Text[0].Text != "Paris" || result.Text[0].Type != "TEXT" || result.Text[0].Language != "fr" {
+		t.Errorf("Text = %+v", result.Text)
+	}
+	if result.Web == nil || len(result.Web.WebEntities) != 1 || len(result.Web.FullMatchingImages) != 1 ||
+		len(result.Web.PagesWithMatchingImages) != 1 || result.Web.BestGuessLabels[0] != "eiffel tower" {
+		t.Errorf("Web = %+v", result.Web)
+	}
+	if result.SafeSearch == nil || len(result.SafeSearch.Labels) != 5 {
+		t.Errorf("SafeSearch = %+v, want 5 categories", result.SafeSearch)
+	}
+	if len(result.Moderation) != 1 || result.Moderation[0].Name != "Violence" || result.Moderation[0].Severity != "LIKELY" {
+		t.Errorf("Moderation = %+v, want Violence", result.Moderation)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "description") {
+		t.Errorf("Warnings = %v, want the unsupported description feature", result.Warnings)
+	}
+}
+
+// TestVisionDetectErrors tests that API errors map to detection errors
+func TestVisionDetectErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"invalid key", http.StatusForbidden, `{"error":{"code":403,"message":"API key not valid","status":"PERMISSION_DENIED"}}`, ErrInvalidAPIKey},
+		{"rate limit", http.StatusTooManyRequests, `{"error":{"code":429,"message":"Quota exceeded"}}`, ErrRateLimit},
+		{"image error", http.StatusOK, `{"responses":[{"error":{"code":3,"message":"Bad image data."}}]}`, ErrAPIError},
+		{"empty", http.StatusOK, `{"responses":[]}`, ErrAPIError},
+	}
+
+	img := CreateTestImage(8, 8, color.NRGBA{A: 255})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &VisionProvider{apiKey: "test-key", client: &http.Client{
+				Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: tt.status,
+						Status:     http.StatusText(tt.status),
+						Body:       io.NopCloser(strings.NewReader(tt.body)),
+					}, nil
+				}),
+			}}
+			_, err := provider.Detect(context.Background(), img, DefaultDetectOptions())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Detect() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestVisionErrorHidesKey tests that the API key never shows in errors
+func TestVisionErrorHidesKey(t *testing.T) {
+	const key = "secret-vision-key"
+	t.Setenv("GOOGLE_VISION_API_KEY", key)
+	provider, err := NewVisionProvider()
+	if err != nil {
+		t.Fatalf("NewVisionProvider() error = %v", err)
+	}
+	provider.client = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}),
+	}
+
+	img := CreateTestImage(10, 10, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	_, err = provider.Detect(context.Background(), img, &DetectOptions{Features: []Feature{FeatureLabels}})
+	if err == nil {
+		t.Fatal("Detect() error = nil, want the network error")
+	}
+	if strings.Contains(err.Error(), key) {
+		t.Errorf("Detect() error = %q contains the API key", err)
+	}
+}
+
+// TestVisionProviderRegistration tests the provider names and offline mode
+func TestVisionProviderRegistration(t *testing.T) {
+	t.Setenv("GOOGLE_VISION_API_KEY", "test-key")
+	for _, name := range []string{"vision", "gcv"} {
+		p, err := GetProvider(name)
+		if err != nil {
+			t.Fatalf("GetProvider(%q) error = %v", name, err)
+		}
+		if p.Name() != "vision" || !p.IsConfigured() {
+			t.Errorf("GetProvider(%q) = %s, configured %v", name, p.Name(), p.IsConfigured())
+		}
+	}
+	if got := ResolveProviderAlias("gcv"); got != "vision" {
+		t.Errorf("ResolveProviderAlias(gcv) = %q, want vision", got)
+	}
+
+	t.Setenv("GOOGLE_VISION_API_KEY", "")
+	if _, err := GetProvider("vision"); !IsNotConfigured(err) {
+		t.Errorf("GetProvider(vision) without key error = %v, want not configured", err)
+	}
+
+	SetOffline(true)
+	defer SetOffline(false)
+	if _, err := GetProvider("gcv"); !errors.Is(err, ErrOffline) {
+		t.Errorf("GetProvider(gcv) offline error = %v, want ErrOffline", err)
+	}
+}
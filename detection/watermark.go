@@ -0,0 +1,299 @@
+package detection
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Sources of watermark signals (SignalProvider is shared with
+// SyntheticAssessment)
+const (
+	SignalText    = "text"    // stock agency or copyright text read in the image
+	SignalPattern = "pattern" // overlay repeated across the image
+)
+
+// WatermarkAssessment combines the available signals into the likelihood
+// that an image carries a third-party watermark or stock overlay, so that
+// ingestion pipelines can reject it. It flags; it does not locate or remove
+// the watermark.
+type WatermarkAssessment struct {
+	Likelihood float32           `json:"likelihood"`     // 0.0-1.0
+	Text       []string          `json:"text,omitempty"` // Watermark text found, e.g. "shutterstock"
+	Signals    []WatermarkSignal `json:"signals"`
+}
+
+// WatermarkSignal is one piece of evidence of a WatermarkAssessment
+type WatermarkSignal struct {
+	Source string  `json:"source"` // SignalText, SignalProvider or SignalPattern
+	Score  float32 `json:"score"`  // 0.0 (clean) to 1.0 (watermarked)
+	Detail string  `json:"detail,omitempty"`
+}
+
+// watermarkWeights weight the signals in the likelihood. Stock agency text
+// is near-conclusive and also sets a lower bound.
+var watermarkWeights = map[string]float32{
+	SignalText:     3,
+	SignalProvider: 2,
+	SignalPattern:  1,
+}
+
+// watermarkTerms are text found on stock previews and watermark overlays,
+// matched lowercased with spaces removed. Agency names score higher than
+// generic terms, which also appear in photos of signs.
+var watermarkTerms = []struct {
+	term  string
+	score float32
+}{
+	{"shutterstock", 0.95},
+	{"gettyimages", 0.95},
+	{"istock", 0.95},
+	{"adobestock", 0.95},
+	{"alamy", 0.95},
+	{"dreamstime", 0.95},
+	{"depositphotos", 0.95},
+	{"123rf", 0.95},
+	{"bigstock", 0.95},
+	{"fotolia", 0.95},
+	{"canstock", 0.95},
+	{"vectorstock", 0.95},
+	{"pond5", 0.9},
+	{"envato", 0.9},
+	{"freepik", 0.9},
+	{"stockphoto", 0.8},
+	{"watermark", 0.7},
+	{"©", 0.6},
+	{"copyright", 0.6},
+}
+
+// WatermarkTerms returns the stock agency names and watermark terms found in
+// text, in the order of the known terms, and the score of the strongest.
+// Case and spaces are ignored, so "Getty Images" and "SHUTTER STOCK" match.
+func WatermarkTerms(text string) ([]string, float32) {
+	compact := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(text))
+	var terms []string
+	var score float32
+	for _, t := range watermarkTerms {
+		if strings.Contains(compact, t.term) {
+			terms = append(terms, t.term)
+			score = max(score, t.score)
+		}
+	}
+	return terms, score
+}
+
+// textWatermarkSignal scores the watermark terms of the text read in the
+// image
+func textWatermarkSignal(texts []string) (*WatermarkSignal, []string) {
+	terms, score := WatermarkTerms(strings.Join(texts, "\n"))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return &WatermarkSignal{Source: SignalText, Score: score, Detail: "found " + strings.Join(terms, ", ")}, terms
+}
+
+// patternWatermarkSignal looks for an overlay tiled across the image, the
+// usual stock preview watermark: the autocorrelation of the image detail
+// (luminance minus its local mean) has strong peaks at the tile offsets in
+// two directions. Offsets below 24 pixels are ignored, since textures and
+// JPEG blocks repeat at those, and tiles must be at most about half the
+// analyzed crop (256x256 at most). Images smaller than 128x128 give no
+// signal.
+func patternWatermarkSignal(img *image.NRGBA) *WatermarkSignal {
+	b := img.Bounds()
+	n := spectrumSize
+	for n > b.Dx() || n > b.Dy() {
+		n /= 2
+	}
+	if n < 128 {
+		return nil
+	}
+
+	// Detail of the center crop: each pixel minus the mean of its row and
+	// column neighbors
+	x0, y0 := b.Min.X+(b.Dx()-n)/2, b.Min.Y+(b.Dy()-n)/2
+	lum := func(x, y int) float64 {
+		x = min(max(x, b.Min.X), b.Max.X-1)
+		y = min(max(y, b.Min.Y), b.Max.Y-1)
+		p := img.PixOffset(x, y)
+		return 0.299*float64(img.Pix[p]) + 0.587*float64(img.Pix[p+1]) + 0.114*float64(img.Pix[p+2])
+	}
+	data := make([][]complex128, n)
+	for y := 0; y < n; y++ {
+		data[y] = make([]complex128, n)
+		for x := 0; x < n; x++ {
+			px, py := x0+x, y0+y
+			detail := lum(px, py) - (lum(px-1, py)+lum(px+1, py)+lum(px, py-1)+lum(px, py+1))/4
+			data[y][x] = complex(detail, 0)
+		}
+	}
+
+	// Autocorrelation: inverse transform of the power spectrum
+	fft2(data)
+	for _, row := range data {
+		for x, c := range row {
+			row[x] = complex(real(c)*real(c)+imag(c)*imag(c), 0)
+		}
+	}
+	fft2(data) // the power spectrum is real and symmetric: forward = inverse
+	zero := real(data[0][0])
+	if zero <= 0 {
+		return nil
+	}
+
+	// The strongest repeat, and the strongest in another direction: tiled
+	// overlays repeat in two directions, while text lines and stripes only
+	// repeat in one.
+	type repeat struct {
+		u, v int
+		r    float64
+	}
+	var repeats []repeat
+	var first repeat
+	const minOffset = 24
+	for v := 0; v < n/2; v++ {
+		for u := -n / 2; u < n/2; u++ {
+			if u*u+v*v < minOffset*minOffset || (v == 0 && u < 0) {
+				continue
+			}
+			// The circular autocorrelation overlaps the whole crop only
+			// partly at large offsets; scale by the overlap, and skip
+			// offsets where it is too small to be reliable
+			overlap := float64((n-abs(u))*(n-v)) / float64(n*n)
+			if overlap < 0.5 {
+				continue
+			}
+			rp := repeat{u, v, real(data[v][(u+n)%n]) / zero / overlap}
+			repeats = append(repeats, rp)
+			if rp.r > first.r {
+				first = rp
+			}
+		}
+	}
+	var second repeat
+	for _, rp := range repeats {
+		// At least 30 degrees apart: |cross product| >= |a||b|/2
+		cross := float64(first.u*rp.v - first.v*rp.u)
+		norms := math.Hypot(float64(first.u), float64(first.v)) * math.Hypot(float64(rp.u), float64(rp.v))
+		if math.Abs(cross) >= norms/2 && rp.r > second.r {
+			second = rp
+		}
+	}
+	peak := second.r
+
+	// Photos stay below ~0.1; a tiled overlay gives 0.2 and more
+	score := math.Max(0, math.Min(1, (peak-0.1)/0.3))
+	return &WatermarkSignal{
+		Source: SignalPattern,
+		Score:  float32(score),
+		Detail: fmt.Sprintf("detail repeats every (%d, %d) and (%d, %d) pixels, autocorrelation %.2f",
+			first.u, first.v, second.u, second.v, peak),
+	}
+}
+
+// abs returns the absolute value of x
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// AssessWatermark combines the signals available for an image into a
+// WatermarkAssessment: the watermark terms in texts (text read by the
+// provider), the judgment of a provider (nil if none) and the pattern
+// heuristic on img. The likelihood is the weighted mean of the signal
+// scores, and at least the text score when watermark terms were found.
+func AssessWatermark(img *image.NRGBA, texts []string, provider *WatermarkSignal) *WatermarkAssessment {
+	a := &WatermarkAssessment{}
+	if s, terms := textWatermarkSignal(texts); s != nil {
+		a.Signals = append(a.Signals, *s)
+		a.Text = terms
+	}
+	if provider != nil {
+		a.Signals = append(a.Signals, *provider)
+	}
+	if img != nil {
+		if s := patternWatermarkSignal(img); s != nil {
+			a.Signals = append(a.Signals, *s)
+		}
+	}
+
+	var sum, weights float32
+	for _, s := range a.Signals {
+		w := watermarkWeights[s.Source]
+		sum += w * s.Score
+		weights += w
+	}
+	if weights > 0 {
+		a.Likelihood = sum / weights
+	}
+	for _, s := range a.Signals {
+		if s.Source == SignalText && s.Score > a.Likelihood {
+			a.Likelihood = s.Score
+		}
+	}
+	return a
+}
+
+// addWatermarkAssessment completes result for FeatureWatermark: the
+// provider's judgment and the watermark text it read, parsed into
+// result.Watermark, and the text of result.Text are combined with the
+// pattern signal. img is the full-resolution image.
+func addWatermarkAssessment(img *image.NRGBA, opts *DetectOptions, result *DetectionResult) {
+	if opts == nil || !containsFeature(opts.Features, FeatureWatermark) {
+		return
+	}
+	var texts []string
+	for _, t := range result.Text {
+		texts = append(texts, t.Text)
+	}
+	var provider *WatermarkSignal
+	if result.Watermark != nil {
+		texts = append(texts, result.Watermark.Text...)
+		for i, s := range result.Watermark.Signals {
+			if s.Source == SignalProvider {
+				provider = &result.Watermark.Signals[i]
+			}
+		}
+	}
+	result.Watermark = AssessWatermark(img, texts, provider)
+}
+
+// parseWatermarkFromInterface parses the {"likelihood", "text", "reason"}
+// object the LLM providers return for FeatureWatermark
+func parseWatermarkFromInterface(value interface{}) *WatermarkAssessment {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	likelihood, ok := toFloat32(m["likelihood"])
+	if !ok {
+		return nil
+	}
+	likelihood = float32(math.Max(0, math.Min(1, float64(likelihood))))
+	reason, _ := m["reason"].(string)
+	a := &WatermarkAssessment{
+		Likelihood: likelihood,
+		Signals:    []WatermarkSignal{{Source: SignalProvider, Score: likelihood, Detail: reason}},
+	}
+	switch text := m["text"].(type) {
+	case string:
+		if text != "" {
+			a.Text = []string{text}
+		}
+	case []interface{}:
+		for _, t := range text {
+			if s, ok := t.(string); ok && s != "" {
+				a.Text = append(a.Text, s)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,152 @@
+package detection
+
+import (
+	"image"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// photoImage returns blurred random noise, with the short-range detail of
+// a photo
+func photoImage(size int) *image.NRGBA {
+	r := rand.New(rand.NewPCG(1, 2))
+	noise := make([]float64, size*size)
+	for i := range noise {
+		noise[i] = r.Float64() * 255
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var sum float64
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					sum += noise[(y+dy+size)%size*size+(x+dx+size)%size]
+				}
+			}
+			c := uint8(sum / 9)
+			p := img.PixOffset(x, y)
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = c, c, c, 255
+		}
+	}
+	return img
+}
+
+// tiledOverlay returns img with a random stamp lightened in every tile of
+// width x height pixels, like the overlay of a stock preview
+func tiledOverlay(img *image.NRGBA, width, height int) *image.NRGBA {
+	r := rand.New(rand.NewPCG(3, 4))
+	stamp := make([]bool, 50*14)
+	for i := range stamp {
+		stamp[i] = r.IntN(3) == 0
+	}
+	dst := image.NewNRGBA(img.Rect)
+	copy(dst.Pix, img.Pix)
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			tx, ty := x%width, y%height
+			if tx < 50 && ty < 14 && stamp[ty*50+tx] {
+				p := dst.PixOffset(x, y)
+				for c := 0; c < 3; c++ {
+					dst.Pix[p+c] = uint8(float64(dst.Pix[p+c])*0.7 + 255*0.3)
+				}
+			}
+		}
+	}
+	return dst
+}
+
+func TestWatermarkTerms(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  []string
+		score float32
+	}{
+		{"Getty Images", []string{"gettyimages"}, 0.95},
+		{"SHUTTER STOCK\nImage ID: 12345", []string{"shutterstock"}, 0.95},
+		{"© 2024 Jane Doe", []string{"©"}, 0.6},
+		{"Open 9-5", nil, 0},
+	}
+	for _, tt := range tests {
+		terms, score := WatermarkTerms(tt.text)
+		if strings.Join(terms, ",") != strings.Join(tt.want, ",") || score != tt.score {
+			t.Errorf("WatermarkTerms(%q) = %q, %.2f, want %q, %.2f", tt.text, terms, score, tt.want, tt.score)
+		}
+	}
+}
+
+func TestPatternWatermarkSignal(t *testing.T) {
+	photo := photoImage(256)
+	clean := patternWatermarkSignal(photo)
+	tiled := patternWatermarkSignal(tiledOverlay(photo, 96, 64))
+	if clean == nil || tiled == nil {
+		t.Fatal("patternWatermarkSignal returned nil for a 256x256 image")
+	}
+	if clean.Score > 0.2 {
+		t.Errorf("photo score = %.2f (%s), want <= 0.2", clean.Score, clean.Detail)
+	}
+	if tiled.Score < 0.5 {
+		t.Errorf("tiled overlay score = %.2f (%s), want >= 0.5", tiled.Score, tiled.Detail)
+	}
+
+	// Lines repeat in one direction only
+	lines := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	r := rand.New(rand.NewPCG(5, 6))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			c := uint8(200)
+			if y%32 < 10 && r.IntN(2) == 0 {
+				c = 30
+			}
+			p := lines.PixOffset(x, y)
+			lines.Pix[p], lines.Pix[p+1], lines.Pix[p+2], lines.Pix[p+3] = c, c, c, 255
+		}
+	}
+	if s := patternWatermarkSignal(lines); s.Score > 0.2 {
+		t.Errorf("text lines score = %.2f (%s), want <= 0.2", s.Score, s.Detail)
+	}
+	if s := patternWatermarkSignal(photoImage(64)); s != nil {
+		t.Errorf("patternWatermarkSignal of a 64x64 image = %+v, want nil", s)
+	}
+}
+
+func TestAssessWatermark(t *testing.T) {
+	img := photoImage(128)
+
+	a := AssessWatermark(img, nil, nil)
+	if len(a.Signals) != 1 || a.Signals[0].Source != SignalPattern {
+		t.Fatalf("signals = %+v, want only the pattern signal", a.Signals)
+	}
+
+	// Agency text sets a lower bound
+	a = AssessWatermark(img, []string{"Sale", "iStock by Getty Images"}, &WatermarkSignal{Source: SignalProvider, Score: 0.1})
+	if len(a.Signals) != 3 || a.Likelihood < 0.95 {
+		t.Errorf("assessment = %+v, want 3 signals and likelihood >= 0.95", a)
+	}
+	if strings.Join(a.Text, ",") != "gettyimages,istock" {
+		t.Errorf("Text = %q", a.Text)
+	}
+}
+
+func TestParseWatermarkResponse(t *testing.T) {
+	result := &DetectionResult{Text: []TextBlock{{Text: "Alamy"}}}
+	err := parseJSONDetectionResponse(`{"watermark": {"likelihood": 0.9, "text": "a", "reason": "diagonal logo"}}`, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Watermark == nil || result.Watermark.Likelihood != 0.9 || result.Watermark.Text[0] != "a" {
+		t.Fatalf("Watermark = %+v, want likelihood 0.9", result.Watermark)
+	}
+
+	// The text read by the provider is searched for agency names
+	addWatermarkAssessment(nil, &DetectOptions{Features: []Feature{FeatureWatermark}}, result)
+	s := result.Watermark.Signals
+	if len(s) != 2 || s[0].Source != SignalText || s[1].Detail != "diagonal logo" {
+		t.Errorf("signals = %+v, want the text and provider signals", s)
+	}
+
+	prompt := buildDetectionPrompt(&DetectOptions{Features: []Feature{FeatureWatermark}})
+	if !strings.Contains(prompt, `"watermark"`) {
+		t.Errorf("prompt does not ask for the watermark key:\n%s", prompt)
+	}
+}
@@ -0,0 +1,454 @@
+package detection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultYOLOHost      = "http://127.0.0.1:8000"
+	defaultYOLOModel     = "yolov8n"
+	defaultYOLOInputSize = 640
+	yoloInputName        = "images" // input tensor of Ultralytics ONNX exports
+	yoloMinConfidence    = 0.25     // default score threshold when MinConfidence is 0
+	yoloIoUThreshold     = 0.45     // boxes of a class overlapping more are suppressed
+)
+
+// cocoClassNames are the 80 classes of the COCO-trained YOLO models, in
+// model output order
+var cocoClassNames = []string{
+	"person", "bicycle", "car", "motorcycle", "airplane", "bus", "train", "truck", "boat", "traffic light",
+	"fire hydrant", "stop sign", "parking meter", "bench", "bird", "cat", "dog", "horse", "sheep", "cow",
+	"elephant", "bear", "zebra", "giraffe", "backpack", "umbrella", "handbag", "tie", "suitcase", "frisbee",
+	"skis", "snowboard", "sports ball", "kite", "baseball bat", "baseball glove", "skateboard", "surfboard", "tennis racket", "bottle",
+	"wine glass", "cup", "fork", "knife", "spoon", "bowl", "banana", "apple", "sandwich", "orange",
+	"broccoli", "carrot", "hot dog", "pizza", "donut", "cake", "chair", "couch", "potted plant", "bed",
+	"dining table", "toilet", "tv", "laptop", "mouse", "remote", "keyboard", "cell phone", "microwave", "oven",
+	"toaster", "sink", "refrigerator", "book", "clock", "vase", "scissors", "teddy bear", "hair drier", "toothbrush",
+}
+
+// YOLOProvider implements the Provider interface for YOLO object detection
+// models (ONNX exports of YOLOv5, YOLOv8 and later) served by a local
+// inference server speaking the KServe v2 / Triton HTTP protocol, such as
+// Triton Inference Server or OpenVINO Model Server. Unlike the LLM
+// providers it returns calibrated scores and tight bounding boxes, and
+// never calls a cloud API.
+//
+// Letterboxing, output decoding and non-maximum suppression run in Go; the
+// server only runs the model.
+type YOLOProvider struct {
+	endpoint  string
+	model     string
+	inputSize int
+	classes   []string
+	client    *http.Client
+}
+
+type yoloTensor struct {
+	Name     string    `json:"name"`
+	Shape    []int     `json:"shape"`
+	Datatype string    `json:"datatype"`
+	Data     []float32 `json:"data"`
+}
+
+type yoloInferRequest struct {
+	Inputs []yoloTensor `json:"inputs"`
+}
+
+type yoloInferResponse struct {
+	ModelName string       `json:"model_name"`
+	Outputs   []yoloTensor `json:"outputs"`
+	Error     string       `json:"error"`
+}
+
+// yoloDetection is a decoded box in input tensor pixels
+type yoloDetection struct {
+	class          int
+	score          float32
+	x0, y0, x1, y1 float32
+}
+
+// NewYOLOProvider creates a new YOLO provider instance. The server, model,
+// input size and class names are read from IMGX_YOLO_HOST (default
+// http://127.0.0.1:8000), IMGX_YOLO_MODEL (default yolov8n),
+// IMGX_YOLO_INPUT_SIZE (default 640) and IMGX_YOLO_LABELS (a file with one
+// class name per line; default the 80 COCO classes).
+func NewYOLOProvider() (*YOLOProvider, error) {
+	host := strings.TrimSpace(os.Getenv("IMGX_YOLO_HOST"))
+	if host == "" {
+		host = defaultYOLOHost
+	}
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		host = "http://" + host
+	}
+	host = strings.TrimRight(host, "/")
+
+	if IsOffline() && !isLocalHost(host) {
+		return nil, NewDetectionError("yolo", fmt.Sprintf("host %s is not local", host), ErrOffline)
+	}
+
+	model := strings.TrimSpace(os.Getenv("IMGX_YOLO_MODEL"))
+	if model == "" {
+		model = defaultYOLOModel
+	}
+
+	inputSize := defaultYOLOInputSize
+	if s := strings.TrimSpace(os.Getenv("IMGX_YOLO_INPUT_SIZE")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 32 || n%32 != 0 {
+			return nil, fmt.Errorf("%w: IMGX_YOLO_INPUT_SIZE must be a multiple of 32, got %q", ErrProviderNotConfigured, s)
+		}
+		inputSize = n
+	}
+
+	classes := cocoClassNames
+	if path := strings.TrimSpace(os.Getenv("IMGX_YOLO_LABELS")); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read IMGX_YOLO_LABELS: %v", ErrProviderNotConfigured, err)
+		}
+		classes = nil
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				classes = append(classes, line)
+			}
+		}
+		if len(classes) == 0 {
+			return nil, fmt.Errorf("%w: IMGX_YOLO_LABELS file %s has no class names", ErrProviderNotConfigured, path)
+		}
+	}
+
+	timeoutSeconds := GetTimeout()
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+
+	return &YOLOProvider{
+		endpoint:  host,
+		model:     model,
+		inputSize: inputSize,
+		classes:   classes,
+		client: debugHTTPClient("yolo", &http.Client{
+			Timeout: time.Duration(timeoutSeconds) * time.Second,
+		}),
+	}, nil
+}
+
+// Name returns the provider name
+func (y *YOLOProvider) Name() string {
+	return "yolo"
+}
+
+// IsConfigured checks if the provider is properly configured
+func (y *YOLOProvider) IsConfigured() bool {
+	return y.endpoint != "" && y.model != "" && len(y.classes) > 0
+}
+
+// Detect runs the model on the image. Labels (one per detected class, with
+// its best score) and BoundingBoxes are returned for the labels and
+// objects features; the other features need another provider and are
+// reported as warnings.
+func (y *YOLOProvider) Detect(ctx context.Context, img *image.NRGBA, opts *DetectOptions) (*DetectionResult, error) {
+	if opts == nil {
+		opts = DefaultDetectOptions()
+	}
+	ctx = withRetryPolicy(ctx, opts.Retry)
+
+	startTime := time.Now()
+	result := &DetectionResult{
+		Provider:    "yolo",
+		Properties:  map[string]string{"model": y.model},
+		ProcessedAt: startTime,
+	}
+
+	supported := false
+	for _, feature := range opts.Features {
+		if feature == FeatureLabels || feature == FeatureObjects {
+			supported = true
+			continue
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("feature %q is not supported by YOLO models", feature))
+	}
+	if opts.CustomPrompt != "" {
+		result.Warnings = append(result.Warnings, "custom prompts are ignored by YOLO models")
+	}
+	if !supported {
+		return result, nil
+	}
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, NewDetectionError("yolo", "empty image", ErrInvalidImage)
+	}
+	input, scale, padX, padY := letterbox(img, y.inputSize)
+
+	payload, err := json.Marshal(&yoloInferRequest{Inputs: []yoloTensor{{
+		Name:     yoloInputName,
+		Shape:    []int{1, 3, y.inputSize, y.inputSize},
+		Datatype: "FP32",
+		Data:     input,
+	}}})
+	if err != nil {
+		return nil, NewDetectionError("yolo", "failed to marshal request", err)
+	}
+
+	endpoint := y.endpoint + "/v2/models/" + url.PathEscape(y.model) + "/infer"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, NewDetectionError("yolo", "failed to create request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := y.client.Do(req)
+	if err != nil {
+		return nil, NewDetectionError("yolo", "inference request failed", err)
+	}
+	defer resp.Body.Close()
+
+	const maxResponseSize = 64 << 20 // 64 MB: YOLOv5 outputs are 25200x85 floats
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, NewDetectionError("yolo", "failed to read response", err)
+	}
+
+	var parsed yoloInferResponse
+	if err := json.Unmarshal(body, &parsed); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, NewDetectionError("yolo", "failed to decode response", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := parsed.Error
+		if message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		if message == "" {
+			message = resp.Status
+		}
+		cause := httpStatusError(resp.StatusCode)
+		if cause == nil {
+			cause = ErrAPIError
+		}
+		return nil, NewDetectionError("yolo", fmt.Sprintf("server returned %s: %s", resp.Status, message), cause)
+	}
+	if len(parsed.Outputs) == 0 {
+		return nil, NewDetectionError("yolo", "response has no outputs", ErrAPIError)
+	}
+
+	minConfidence := opts.MinConfidence
+	if minConfidence <= 0 {
+		minConfidence = yoloMinConfidence
+	}
+	detections, err := decodeYOLOOutput(parsed.Outputs[0], len(y.classes), minConfidence)
+	if err != nil {
+		return nil, NewDetectionError("yolo", err.Error(), ErrAPIError)
+	}
+	detections = nonMaxSuppression(detections, yoloIoUThreshold)
+	if opts.MaxResults > 0 && len(detections) > opts.MaxResults {
+		detections = detections[:opts.MaxResults]
+	}
+
+	width, height := float32(bounds.Dx()), float32(bounds.Dy())
+	best := make(map[int]float32)
+	for _, d := range detections {
+		// Undo the letterbox, then make the box relative to the image
+		x0 := clamp01(((d.x0 - padX) / scale) / width)
+		y0 := clamp01(((d.y0 - padY) / scale) / height)
+		x1 := clamp01(((d.x1 - padX) / scale) / width)
+		y1 := clamp01(((d.y1 - padY) / scale) / height)
+		result.BoundingBoxes = append(result.BoundingBoxes, BoundingBox{
+			Label:      y.classes[d.class],
+			Confidence: d.score,
+			Box:        Box{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0},
+		})
+		best[d.class] = max(best[d.class], d.score)
+	}
+
+	for class, score := range best {
+		result.Labels = append(result.Labels, Label{Name: y.classes[class], Confidence: score})
+	}
+	slices.SortFunc(result.Labels, func(a, b Label) int {
+		if a.Confidence != b.Confidence {
+			if a.Confidence > b.Confidence {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(result.Labels) > 0 {
+		var totalConf float32
+		for _, label := range result.Labels {
+			totalConf += label.Confidence
+		}
+		result.Confidence = totalConf / float32(len(result.Labels))
+	}
+
+	return result, nil
+}
+
+// letterbox scales img to fit a size x size square, keeping the aspect
+// ratio and padding with gray as the YOLO models were trained, and returns
+// it as a 1x3xSxS tensor of RGB values in [0, 1] with the scale and the
+// left and top padding
+func letterbox(img *image.NRGBA, size int) (tensor []float32, scale, padX, padY float32) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale = min(float32(size)/float32(w), float32(size)/float32(h))
+	nw, nh := int(math.Round(float64(float32(w)*scale))), int(math.Round(float64(float32(h)*scale)))
+	px, py := (size-nw)/2, (size-nh)/2
+
+	plane := size * size
+	tensor = make([]float32, 3*plane)
+	const gray = 114.0 / 255
+	for i := range tensor {
+		tensor[i] = gray
+	}
+
+	// Bilinear sampling of the source for each pixel of the scaled image
+	for ty := 0; ty < nh; ty++ {
+		sy := (float32(ty)+0.5)/scale - 0.5
+		y0 := int(math.Floor(float64(sy)))
+		fy := sy - float32(y0)
+		y0c, y1c := min(max(y0, 0), h-1), min(max(y0+1, 0), h-1)
+		for tx := 0; tx < nw; tx++ {
+			sx := (float32(tx)+0.5)/scale - 0.5
+			x0 := int(math.Floor(float64(sx)))
+			fx := sx - float32(x0)
+			x0c, x1c := min(max(x0, 0), w-1), min(max(x0+1, 0), w-1)
+			i00 := img.PixOffset(b.Min.X+x0c, b.Min.Y+y0c)
+			i01 := img.PixOffset(b.Min.X+x1c, b.Min.Y+y0c)
+			i10 := img.PixOffset(b.Min.X+x0c, b.Min.Y+y1c)
+			i11 := img.PixOffset(b.Min.X+x1c, b.Min.Y+y1c)
+			dst := (ty+py)*size + tx + px
+			for c := 0; c < 3; c++ {
+				top := float32(img.Pix[i00+c])*(1-fx) + float32(img.Pix[i01+c])*fx
+				bottom := float32(img.Pix[i10+c])*(1-fx) + float32(img.Pix[i11+c])*fx
+				tensor[c*plane+dst] = (top*(1-fy) + bottom*fy) / 255
+			}
+		}
+	}
+	return tensor, scale, float32(px), float32(py)
+}
+
+// decodeYOLOOutput decodes the output tensor of a YOLO model with
+// numClasses classes. YOLOv8 and later output 4+numClasses rows (center x,
+// center y, width, height, class scores) per candidate; YOLOv5 adds an
+// objectness score after the box. Both layouts are accepted in either
+// orientation.
+func decodeYOLOOutput(out yoloTensor, numClasses int, minConfidence float32) ([]yoloDetection, error) {
+	shape := out.Shape
+	if len(shape) == 3 && shape[0] == 1 {
+		shape = shape[1:]
+	}
+	if len(shape) != 2 || shape[0]*shape[1] != len(out.Data) {
+		return nil, fmt.Errorf("unexpected output shape %v with %d values", out.Shape, len(out.Data))
+	}
+
+	var attrs, candidates int
+	var attrMajor, objectness bool
+	switch {
+	case shape[0] == 4+numClasses:
+		attrs, candidates, attrMajor = shape[0], shape[1], true
+	case shape[1] == 4+numClasses:
+		attrs, candidates = shape[1], shape[0]
+	case shape[1] == 5+numClasses:
+		attrs, candidates, objectness = shape[1], shape[0], true
+	case shape[0] == 5+numClasses:
+		attrs, candidates, attrMajor, objectness = shape[0], shape[1], true, true
+	default:
+		return nil, fmt.Errorf("output shape %v does not match %d classes (set IMGX_YOLO_LABELS)", out.Shape, numClasses)
+	}
+	at := func(i, a int) float32 {
+		if attrMajor {
+			return out.Data[a*candidates+i]
+		}
+		return out.Data[i*attrs+a]
+	}
+
+	first := 4
+	if objectness {
+		first = 5
+	}
+	var detections []yoloDetection
+	for i := 0; i < candidates; i++ {
+		class, score := 0, float32(0)
+		for c := 0; c < numClasses; c++ {
+			if s := at(i, first+c); s > score {
+				class, score = c, s
+			}
+		}
+		if objectness {
+			score *= at(i, 4)
+		}
+		if score < minConfidence {
+			continue
+		}
+		cx, cy, w, h := at(i, 0), at(i, 1), at(i, 2), at(i, 3)
+		detections = append(detections, yoloDetection{
+			class: class,
+			score: score,
+			x0:    cx - w/2, y0: cy - h/2,
+			x1: cx + w/2, y1: cy + h/2,
+		})
+	}
+	return detections, nil
+}
+
+// nonMaxSuppression keeps the best scoring detection of each group of
+// same-class detections overlapping by more than iouThreshold, and returns
+// the kept detections by descending score
+func nonMaxSuppression(detections []yoloDetection, iouThreshold float32) []yoloDetection {
+	slices.SortStableFunc(detections, func(a, b yoloDetection) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		return 0
+	})
+	var kept []yoloDetection
+	for _, d := range detections {
+		suppressed := false
+		for _, k := range kept {
+			if k.class == d.class && iou(k, d) > iouThreshold {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// iou returns the intersection over union of two detections
+func iou(a, b yoloDetection) float32 {
+	w := min(a.x1, b.x1) - max(a.x0, b.x0)
+	h := min(a.y1, b.y1) - max(a.y0, b.y0)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := w * h
+	union := (a.x1-a.x0)*(a.y1-a.y0) + (b.x1-b.x0)*(b.y1-b.y0) - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+func clamp01(v float32) float32 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,185 @@
+package detection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// yoloV8Output builds a YOLOv8 style [1, 4+classes, n] output tensor from
+// candidates given as cx, cy, w, h, class, score in input tensor pixels
+func yoloV8Output(classes int, candidates [][6]float32) yoloTensor {
+	n := len(candidates)
+	data := make([]float32, (4+classes)*n)
+	for i, c := range candidates {
+		for a := 0; a < 4; a++ {
+			data[a*n+i] = c[a]
+		}
+		data[(4+int(c[4]))*n+i] = c[5]
+	}
+	return yoloTensor{Name: "output0", Shape: []int{1, 4 + classes, n}, Datatype: "FP32", Data: data}
+}
+
+// TestYOLODetect tests the inference request and the decoding of a YOLOv8
+// output back to boxes relative to the image
+func TestYOLODetect(t *testing.T) {
+	t.Setenv("IMGX_YOLO_HOST", "")
+	t.Setenv("IMGX_YOLO_MODEL", "")
+	provider, err := NewYOLOProvider()
+	if err != nil {
+		t.Fatalf("NewYOLOProvider() error = %v", err)
+	}
+
+	// A 200x100 image is scaled by 3.2 and padded by 160 pixels at the top.
+	// The person at (50,25)-(100,75) is at (160,240)-(320,400) in the tensor.
+	output := yoloV8Output(len(cocoClassNames), [][6]float32{
+		{240, 320, 160, 160, 0, 0.9},  // person
+		{244, 322, 160, 156, 0, 0.8},  // same person, suppressed
+		{480, 320, 160, 160, 16, 0.6}, // dog
+		{100, 300, 20, 20, 2, 0.1},    // car below the threshold
+	})
+	provider.client = &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Path != "/v2/models/yolov8n/infer" {
+				t.Errorf("path = %s", r.URL.Path)
+			}
+			var req yoloInferRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			in := req.Inputs[0]
+			if in.Name != "images" || len(in.Data) != 3*640*640 || in.Shape[2] != 640 {
+				t.Errorf("input = %s %v with %d values", in.Name, in.Shape, len(in.Data))
+			}
+			if got := in.Data[0]; math.Abs(float64(got)-114.0/255) > 1e-6 {
+				t.Errorf("padding value = %v, want 114/255", got)
+			}
+			if got := in.Data[320*640+320]; math.Abs(float64(got)-200.0/255) > 1e-3 {
+				t.Errorf("image red value = %v, want 200/255", got)
+			}
+			body, _ := json.Marshal(&yoloInferResponse{ModelName: "yolov8n", Outputs: []yoloTensor{output}})
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(string(body))),
+			}, nil
+		}),
+	}
+
+	img := CreateTestImage(200, 100, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	opts := &DetectOptions{Features: []Feature{FeatureObjects, FeatureDescription}, MaxResults: 10, MinConfidence: 0.5}
+	result, err := provider.Detect(context.Background(), img, opts)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	if len(result.BoundingBoxes) != 2 {
+		t.Fatalf("BoundingBoxes = %+v, want person and dog", result.BoundingBoxes)
+	}
+	person := result.BoundingBoxes[0]
+	want := Box{X: 0.25, Y: 0.25, Width: 0.25, Height: 0.5}
+	if person.Label != "person" || person.Confidence != 0.9 || !boxNear(person.Box, want) {
+		t.Errorf("person = %+v, want box %+v", person, want)
+	}
+	if result.BoundingBoxes[1].Label != "dog" {
+		t.Errorf("second box = %+v, want dog", result.BoundingBoxes[1])
+	}
+	if len(result.Labels) != 2 || result.Labels[0].Name != "person" || result.Labels[1].Name != "dog" {
+		t.Errorf("Labels = %+v, want person, dog", result.Labels)
+	}
+	if result.Provider != "yolo" || result.Properties["model"] != "yolov8n" {
+		t.Errorf("Provider = %q, model = %q", result.Provider, result.Properties["model"])
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "description") {
+		t.Errorf("Warnings = %v, want the unsupported description feature", result.Warnings)
+	}
+}
+
+func boxNear(a, b Box) bool {
+	near := func(x, y float32) bool { return math.Abs(float64(x-y)) < 1e-3 }
+	return near(a.X, b.X) && near(a.Y, b.Y) && near(a.Width, b.Width) && near(a.Height, b.Height)
+}
+
+// TestDecodeYOLOOutput tests the YOLOv5 layout with an objectness score
+func TestDecodeYOLOOutput(t *testing.T) {
+	// [1, 2, 5+2]: cx, cy, w, h, objectness, class 0, class 1
+	out := yoloTensor{Shape: []int{1, 2, 7}, Data: []float32{
+		10, 10, 4, 4, 0.9, 0.1, 0.8,
+		20, 20, 4, 4, 0.2, 0.9, 0.1,
+	}}
+	detections, err := decodeYOLOOutput(out, 2, 0.5)
+	if err != nil {
+		t.Fatalf("decodeYOLOOutput() error = %v", err)
+	}
+	if len(detections) != 1 || detections[0].class != 1 || math.Abs(float64(detections[0].score)-0.72) > 1e-6 || detections[0].x0 != 8 {
+		t.Errorf("detections = %+v, want class 1 with score 0.72", detections)
+	}
+
+	if _, err := decodeYOLOOutput(out, 80, 0.5); err == nil {
+		t.Error("decodeYOLOOutput() accepted an output of another class count")
+	}
+}
+
+// TestYOLODetectErrors tests that server errors map to detection errors
+func TestYOLODetectErrors(t *testing.T) {
+	provider := &YOLOProvider{endpoint: "http://127.0.0.1:8000", model: "missing", inputSize: 64, classes: cocoClassNames,
+		client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+				Body:       io.NopCloser(strings.NewReader(`{"error":"Request for unknown model: 'missing' is not found"}`)),
+			}, nil
+		})},
+	}
+	_, err := provider.Detect(context.Background(), CreateTestImage(8, 8, color.NRGBA{A: 255}), DefaultDetectOptions())
+	if !errors.Is(err, ErrAPIError) || !strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("Detect() error = %v, want API error with the server message", err)
+	}
+}
+
+// TestYOLOProviderConfig tests the provider names and environment settings
+func TestYOLOProviderConfig(t *testing.T) {
+	t.Setenv("IMGX_YOLO_HOST", "localhost:9000")
+	labels := filepath.Join(t.TempDir(), "labels.txt")
+	if err := os.WriteFile(labels, []byte("scratch\ndent\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IMGX_YOLO_LABELS", labels)
+	t.Setenv("IMGX_YOLO_INPUT_SIZE", "320")
+
+	p, err := GetProvider("onnx")
+	if err != nil {
+		t.Fatalf("GetProvider(onnx) error = %v", err)
+	}
+	y := p.(*YOLOProvider)
+	if y.endpoint != "http://localhost:9000" || y.inputSize != 320 || len(y.classes) != 2 || y.classes[1] != "dent" {
+		t.Errorf("provider = %s, %d, %v", y.endpoint, y.inputSize, y.classes)
+	}
+	if got := ResolveProviderAlias("onnx"); got != "yolo" {
+		t.Errorf("ResolveProviderAlias(onnx) = %q, want yolo", got)
+	}
+
+	t.Setenv("IMGX_YOLO_INPUT_SIZE", "100")
+	if _, err := NewYOLOProvider(); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("NewYOLOProvider() with input size 100 error = %v", err)
+	}
+	t.Setenv("IMGX_YOLO_INPUT_SIZE", "")
+
+	SetOffline(true)
+	defer SetOffline(false)
+	if _, err := GetProvider("yolo"); err != nil {
+		t.Errorf("GetProvider(yolo) offline with a local host error = %v", err)
+	}
+	t.Setenv("IMGX_YOLO_HOST", "http://gpu-box:8000")
+	if _, err := GetProvider("yolo"); !errors.Is(err, ErrOffline) {
+		t.Errorf("GetProvider(yolo) offline with a remote host error = %v, want ErrOffline", err)
+	}
+}
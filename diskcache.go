@@ -0,0 +1,340 @@
+package imgx
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CacheStatus tells where DiskCache.Fetch got its result from.
+type CacheStatus string
+
+const (
+	CacheHit   CacheStatus = "hit"   // Fresh entry from the cache
+	CacheStale CacheStatus = "stale" // Expired entry, refreshed in the background
+	CacheMiss  CacheStatus = "miss"  // Rendered now and stored
+)
+
+// CacheStats counts the lookups and the content of a DiskCache.
+type CacheStats struct {
+	Hits      int64
+	Stale     int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Bytes     int64
+}
+
+// DiskCacheOptions configures OpenDiskCache.
+type DiskCacheOptions struct {
+	// MaxBytes bounds the total size of the entries; the least recently
+	// used are removed beyond it. 0 means no limit.
+	MaxBytes int64
+
+	// TTL is how long an entry is fresh. 0 keeps entries fresh forever.
+	TTL time.Duration
+
+	// StaleTTL is how long after TTL an expired entry is still served while
+	// it is rendered again in the background (stale-while-revalidate).
+	// Older entries are rendered before they are returned.
+	StaleTTL time.Duration
+
+	// OnError, if set, receives the errors of background refreshes.
+	OnError func(key string, err error)
+}
+
+// DiskCache stores rendered variants on disk, keyed by CacheKey, with LRU
+// eviction beyond a size limit and stale-while-revalidate expiry. Entries
+// written by an earlier process are picked up by OpenDiskCache, oldest
+// first in the LRU order. It is safe for concurrent use, and concurrent
+// renders of the same key run once.
+type DiskCache struct {
+	dir  string
+	opts DiskCacheOptions
+
+	mu      sync.Mutex
+	lru     *list.List // front is the most recently used
+	entries map[string]*list.Element
+	bytes   int64
+	stats   CacheStats
+
+	renders Coalescer[[]byte]
+}
+
+// cacheEntry is a file of the cache
+type cacheEntry struct {
+	key     string
+	size    int64
+	created time.Time
+}
+
+// CacheKey returns the cache key of a variant: a hash of the source content
+// (e.g. the SHA-256 of the file or its ETag) and the transform spec.
+func CacheKey(sourceHash, spec string) string {
+	sum := sha256.Sum256([]byte(sourceHash + "\x00" + spec))
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenDiskCache opens the cache in dir, creating it if needed, and indexes
+// the entries already there. Entries beyond MaxBytes are evicted.
+//
+// Example:
+//
+//	cache, err := imgx.OpenDiskCache("/var/cache/imgx", imgx.DiskCacheOptions{
+//		MaxBytes: 10 << 30,
+//		TTL:      24 * time.Hour,
+//		StaleTTL: time.Hour,
+//	})
+//	data, status, err := cache.Fetch(imgx.CacheKey(etag, spec), func() ([]byte, error) {
+//		return render(spec)
+//	})
+func OpenDiskCache(dir string, opts DiskCacheOptions) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("imgx: cache: %w", err)
+	}
+	c := &DiskCache{dir: dir, opts: opts, lru: list.New(), entries: make(map[string]*list.Element)}
+
+	var found []cacheEntry
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !isCacheKey(d.Name()) {
+			if filepath.Ext(d.Name()) == ".tmp" {
+				os.Remove(path) // left over by an interrupted Put
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		found = append(found, cacheEntry{key: d.Name(), size: info.Size(), created: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("imgx: cache: %w", err)
+	}
+	// Oldest first, so that the newest end up at the front
+	slices.SortFunc(found, func(a, b cacheEntry) int {
+		return a.created.Compare(b.created)
+	})
+	for _, e := range found {
+		c.entries[e.key] = c.lru.PushFront(&e)
+		c.bytes += e.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get returns the entry of key if it is in the cache, fresh or not.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(el)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data under key, replacing the previous entry, and evicts the
+// least recently used entries beyond MaxBytes.
+func (c *DiskCache) Put(key string, data []byte) error {
+	if !isCacheKey(key) {
+		return fmt.Errorf("imgx: cache: invalid key %q (use CacheKey)", key)
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("imgx: cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("imgx: cache: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("imgx: cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.bytes -= el.Value.(*cacheEntry).size
+		c.lru.Remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: int64(len(data)), created: time.Now()})
+	c.bytes += int64(len(data))
+	c.evict()
+	return nil
+}
+
+// Fetch returns the entry of key, rendering and storing it when it is
+// missing or expired beyond StaleTTL. An entry expired within StaleTTL is
+// returned at once with CacheStale while it is rendered again in the
+// background. Concurrent renders of the same key run once. A result that
+// cannot be stored is still returned.
+func (c *DiskCache) Fetch(key string, render func() ([]byte, error)) ([]byte, CacheStatus, error) {
+	c.mu.Lock()
+	var age time.Duration
+	el, ok := c.entries[key]
+	if ok {
+		age = time.Since(el.Value.(*cacheEntry).created)
+	}
+	c.mu.Unlock()
+
+	fresh := c.opts.TTL <= 0 || age <= c.opts.TTL
+	if ok && (fresh || age <= c.opts.TTL+c.opts.StaleTTL) {
+		if data, found := c.Get(key); found {
+			if fresh {
+				c.count(func(s *CacheStats) { s.Hits++ })
+				return data, CacheHit, nil
+			}
+			c.count(func(s *CacheStats) { s.Stale++ })
+			go func() {
+				if _, err := c.render(key, render); err != nil && c.opts.OnError != nil {
+					c.opts.OnError(key, err)
+				}
+			}()
+			return data, CacheStale, nil
+		}
+	}
+
+	c.count(func(s *CacheStats) { s.Misses++ })
+	data, err := c.render(key, render)
+	if err != nil {
+		return nil, CacheMiss, err
+	}
+	return data, CacheMiss, nil
+}
+
+// Warm renders the keys missing from the cache, e.g. the variants of a
+// manifest before a deployment, with up to workers renders at a time. It
+// returns the errors joined, after trying every key.
+func (c *DiskCache) Warm(ctx context.Context, keys []string, workers int, render func(key string) ([]byte, error)) error {
+	workers = max(workers, 1)
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for _, key := range keys {
+		c.mu.Lock()
+		_, cached := c.entries[key]
+		c.mu.Unlock()
+		if cached {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if _, err := c.render(key, func() ([]byte, error) { return render(key) }); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Stats returns the lookup counters and the size of the cache.
+func (c *DiskCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = len(c.entries)
+	s.Bytes = c.bytes
+	return s
+}
+
+// render runs render once for concurrent callers and stores the result
+func (c *DiskCache) render(key string, render func() ([]byte, error)) ([]byte, error) {
+	data, err, _ := c.renders.Do(key, func() ([]byte, error) {
+		data, err := render()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Put(key, data); err != nil && c.opts.OnError != nil {
+			c.opts.OnError(key, err)
+		}
+		return data, nil
+	})
+	return data, err
+}
+
+// evict removes the least recently used entries beyond MaxBytes. c.mu must
+// be held.
+func (c *DiskCache) evict() {
+	for c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes && c.lru.Len() > 0 {
+		e := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, e.key)
+		c.bytes -= e.size
+		c.stats.Evictions++
+		os.Remove(c.path(e.key))
+	}
+}
+
+// remove drops the entry of key, whose file disappeared
+func (c *DiskCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.bytes -= el.Value.(*cacheEntry).size
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// count updates the counters under c.mu
+func (c *DiskCache) count(update func(*CacheStats)) {
+	c.mu.Lock()
+	update(&c.stats)
+	c.mu.Unlock()
+}
+
+// path returns the file of key, in a subdirectory named after its first
+// two characters to keep directories small
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// isCacheKey reports whether name is a key made by CacheKey
+func isCacheKey(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,141 @@
+package imgx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheLRU(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenDiskCache(dir, DiskCacheOptions{MaxBytes: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := CacheKey("src", "a"), CacheKey("src", "b"), CacheKey("src", "c")
+	if a == b || CacheKey("src", "a") != a {
+		t.Fatal("CacheKey is not a deterministic function of source and spec")
+	}
+	for _, key := range []string{a, b} {
+		if err := cache.Put(key, bytes.Repeat([]byte{'x'}, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading a makes b the least recently used
+	if data, ok := cache.Get(a); !ok || len(data) != 10 {
+		t.Fatalf("Get(a) = %q, %v", data, ok)
+	}
+	if err := cache.Put(c, bytes.Repeat([]byte{'y'}, 15)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(b); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if _, ok := cache.Get(a); !ok {
+		t.Error("recently used entry was evicted")
+	}
+	if s := cache.Stats(); s.Entries != 2 || s.Bytes != 25 || s.Evictions != 1 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	if err := cache.Put("../../etc/passwd", nil); err == nil {
+		t.Error("Put accepted a key that is not from CacheKey")
+	}
+
+	// A new process finds the entries
+	reopened, err := OpenDiskCache(dir, DiskCacheOptions{MaxBytes: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := reopened.Get(c); !ok || string(data) != string(bytes.Repeat([]byte{'y'}, 15)) {
+		t.Errorf("reopened Get(c) = %q, %v", data, ok)
+	}
+	if s := reopened.Stats(); s.Entries != 2 || s.Bytes != 25 {
+		t.Errorf("reopened Stats() = %+v", s)
+	}
+}
+
+func TestDiskCacheFetch(t *testing.T) {
+	refreshed := make(chan struct{}, 1)
+	cache, err := OpenDiskCache(t.TempDir(), DiskCacheOptions{TTL: 50 * time.Millisecond, StaleTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := CacheKey("etag-1", "fit/100x100")
+	version := 0
+	render := func() ([]byte, error) {
+		version++
+		if version > 1 {
+			refreshed <- struct{}{}
+		}
+		return []byte(fmt.Sprint("v", version)), nil
+	}
+
+	data, status, err := cache.Fetch(key, render)
+	if err != nil || status != CacheMiss || string(data) != "v1" {
+		t.Fatalf("first Fetch() = %q, %s, %v", data, status, err)
+	}
+	if data, status, _ = cache.Fetch(key, render); status != CacheHit || string(data) != "v1" {
+		t.Errorf("second Fetch() = %q, %s, want a hit", data, status)
+	}
+
+	// Once expired, the old entry is served while it is rendered again
+	time.Sleep(60 * time.Millisecond)
+	if data, status, _ = cache.Fetch(key, render); status != CacheStale || string(data) != "v1" {
+		t.Errorf("expired Fetch() = %q, %s, want the stale entry", data, status)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale entry was not refreshed")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, _ := cache.Get(key); string(data) == "v2" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if data, status, _ = cache.Fetch(key, render); status != CacheHit || string(data) != "v2" {
+		t.Errorf("Fetch() after refresh = %q, %s", data, status)
+	}
+
+	fail := errors.New("source gone")
+	if _, _, err := cache.Fetch(CacheKey("x", "y"), func() ([]byte, error) { return nil, fail }); err != fail {
+		t.Errorf("Fetch() error = %v, want %v", err, fail)
+	}
+	if s := cache.Stats(); s.Hits != 2 || s.Stale != 1 || s.Misses != 2 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
+
+func TestDiskCacheWarm(t *testing.T) {
+	cache, err := OpenDiskCache(t.TempDir(), DiskCacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{CacheKey("a", "1"), CacheKey("a", "2"), CacheKey("b", "1")}
+	if err := cache.Put(keys[0], []byte("cached")); err != nil {
+		t.Fatal(err)
+	}
+	rendered := make(chan string, len(keys))
+	err = cache.Warm(context.Background(), keys, 2, func(key string) ([]byte, error) {
+		rendered <- key
+		if key == keys[2] {
+			return nil, errors.New("broken source")
+		}
+		return []byte(key), nil
+	})
+	if err == nil {
+		t.Error("Warm() did not report the failed render")
+	}
+	close(rendered)
+	if len(rendered) != 2 {
+		t.Errorf("Warm() rendered %d keys, want the 2 missing ones", len(rendered))
+	}
+	if data, ok := cache.Get(keys[1]); !ok || string(data) != keys[1] {
+		t.Errorf("warmed entry = %q, %v", data, ok)
+	}
+}
@@ -0,0 +1,267 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// maxSkewAngle bounds the skew found by DetectSkew, in degrees.
+	maxSkewAngle = 15
+	// maxSkewAnalysisSize bounds the size of the image analyzed by
+	// DetectSkew. Larger images are downscaled first.
+	maxSkewAnalysisSize = 1000
+	// maxSkewPoints bounds the number of ink pixels projected per angle.
+	maxSkewPoints = 50000
+	// backgroundAnalysisSize is the length of the short side of the
+	// low-resolution copy WhitenBackground estimates the paper on.
+	backgroundAnalysisSize = 128
+)
+
+// DetectSkew estimates the small rotation of a scanned or photographed page
+// of text. It returns the counter-clockwise angle in degrees, within ±15,
+// that levels the text lines when passed to Rotate; 0 if no text lines are
+// found.
+//
+// The strokes of the text are projected onto the vertical axis at
+// candidate angles; at the right angle text lines and the gaps between them
+// fall into separate bins, which maximizes the sum of squared bin counts.
+//
+// Example:
+//
+//	angle := imgx.DetectSkew(scan)
+//	level := imgx.Rotate(scan, angle, color.White)
+func DetectSkew(img image.Image) float64 {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	if b.Dx() < 16 || b.Dy() < 16 {
+		return 0
+	}
+	if b.Dx() > maxSkewAnalysisSize || b.Dy() > maxSkewAnalysisSize {
+		src = Fit(src, maxSkewAnalysisSize, maxSkewAnalysisSize, Box)
+	}
+
+	// Only the starts of horizontal ink runs are projected, so text with
+	// its many strokes outweighs large dark areas like shadows or the
+	// background around a photographed page
+	ink := inkMask(src)
+	var points []image.Point
+	for y := 0; y < ink.h; y++ {
+		row := ink.pix[y*ink.w : (y+1)*ink.w]
+		for x, on := range row {
+			if on && (x == 0 || !row[x-1]) {
+				points = append(points, image.Pt(x, y))
+			}
+		}
+	}
+	if len(points) == 0 {
+		return 0
+	}
+	if step := len(points)/maxSkewPoints + 1; step > 1 {
+		sampled := points[:0]
+		for i := 0; i < len(points); i += step {
+			sampled = append(sampled, points[i])
+		}
+		points = sampled
+	}
+
+	// score returns the sum of squared bin counts of the ink rotated
+	// counter-clockwise by angle degrees
+	bins := make([]float64, 2*ink.w+ink.h+2)
+	score := func(angle float64) float64 {
+		clear(bins)
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		for _, p := range points {
+			y := -float64(p.X)*sin + float64(p.Y)*cos
+			bins[int(y)+ink.w]++
+		}
+		var s float64
+		for _, n := range bins {
+			s += n * n
+		}
+		return s
+	}
+	search := func(from, to, step float64) float64 {
+		best, bestScore := 0.0, score(0)
+		for a := from; a <= to+step/2; a += step {
+			if s := score(a); s > bestScore {
+				best, bestScore = a, s
+			}
+		}
+		return best
+	}
+
+	coarse := search(-maxSkewAngle, maxSkewAngle, 0.5)
+	fine := search(coarse-0.5, coarse+0.5, 0.05)
+	return math.Round(fine*100) / 100
+}
+
+// Deskew levels the text lines of a scanned or photographed page (see
+// DetectSkew). The page is rotated about its center and keeps its size;
+// the uncovered corners are filled with bgColor.
+//
+// Example:
+//
+//	level := imgx.Deskew(scan, color.White)
+func Deskew(img image.Image, bgColor color.Color) *image.NRGBA {
+	return deskew(img, DetectSkew(img), bgColor)
+}
+
+// Deskew levels the text lines of the image (see Deskew)
+func (img *Image) Deskew(bgColor color.Color) *Image {
+	angle := DetectSkew(img.data)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("deskew", fmt.Sprintf("angle=%.2f", angle))
+	return newImage(deskew(img.data, angle, bgColor), newMeta)
+}
+
+func deskew(img image.Image, angle float64, bgColor color.Color) *image.NRGBA {
+	if angle == 0 {
+		return Clone(img)
+	}
+	b := img.Bounds()
+	return CropCenter(Rotate(img, angle, bgColor), b.Dx(), b.Dy())
+}
+
+// WhitenBackground evens out the lighting of a photographed or scanned
+// document and turns its paper white. The paper color is estimated across
+// the page from the brightest pixels around each point, which skips over
+// text, and every pixel is divided by it: shadows, light falloff and the
+// tint of yellowed paper disappear while ink keeps its contrast.
+//
+// Example:
+//
+//	clean := imgx.WhitenBackground(photo)
+func WhitenBackground(img image.Image) *image.NRGBA {
+	src := Clone(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return src
+	}
+
+	// Estimate the paper at low resolution: the maximum filter replaces
+	// text with the paper around it, the blur smooths the estimate
+	scale := math.Min(1, float64(backgroundAnalysisSize)/float64(min(w, h)))
+	small := Resize(src, max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5)), Box)
+	paper := Resize(Blur(maxFilter(small, 3), 2), w, h, Linear)
+
+	parallel(0, h, func(ys <-chan int) {
+		for y := range ys {
+			i, j := y*src.Stride, y*paper.Stride
+			for x := 0; x < w; x++ {
+				px := src.Pix[i : i+3 : i+3]
+				bg := paper.Pix[j : j+3 : j+3]
+				for c := range px {
+					px[c] = clamp(float64(px[c]) * 255 / math.Max(1, float64(bg[c])))
+				}
+				i += 4
+				j += 4
+			}
+		}
+	})
+	return src
+}
+
+// WhitenBackground evens out the lighting of the document and turns its paper white (see WhitenBackground)
+func (img *Image) WhitenBackground() *Image {
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("whitenBackground", "")
+	return newImage(WhitenBackground(img.data), newMeta)
+}
+
+// maxFilter returns the per-channel maximum over the (2r+1)x(2r+1)
+// neighborhood of each pixel of img, which has bounds starting at (0, 0).
+func maxFilter(img *image.NRGBA, r int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	pass := func(src *image.NRGBA, dx, dy int) *image.NRGBA {
+		dst := image.NewNRGBA(src.Rect)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				o := dst.PixOffset(x, y)
+				copy(dst.Pix[o:o+4], src.Pix[o:o+4])
+				for k := -r; k <= r; k++ {
+					sx, sy := x+k*dx, y+k*dy
+					if sx < 0 || sy < 0 || sx >= w || sy >= h {
+						continue
+					}
+					s := src.PixOffset(sx, sy)
+					for c := 0; c < 3; c++ {
+						dst.Pix[o+c] = max(dst.Pix[o+c], src.Pix[s+c])
+					}
+				}
+			}
+		}
+		return dst
+	}
+	return pass(pass(img, 1, 0), 0, 1)
+}
+
+// AdaptiveThreshold converts img to black and white, comparing each pixel
+// with the mean luminance of the surrounding (2*radius+1)-pixel square
+// (Bradley's method): pixels more than percent darker than their
+// surroundings become black. Unlike a global threshold, this keeps text
+// legible under uneven lighting. A radius <= 0 uses 1/16 of the larger
+// side of the image; a percent <= 0 uses 15.
+//
+// Example:
+//
+//	bw := imgx.AdaptiveThreshold(page, 0, 0)
+func AdaptiveThreshold(img image.Image, radius int, percent float64) *image.NRGBA {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if w == 0 || h == 0 {
+		return dst
+	}
+	if radius <= 0 {
+		radius = max(1, max(w, h)/16)
+	}
+	if percent <= 0 {
+		percent = 15
+	}
+
+	// Integral image of the luminance, with a zero row and column
+	lum := make([]float64, w*h)
+	sum := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var row float64
+		for x := 0; x < w; x++ {
+			i := y*src.Stride + x*4
+			px := src.Pix[i : i+3 : i+3]
+			l := luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+			lum[y*w+x] = l
+			row += l
+			sum[(y+1)*(w+1)+x+1] = sum[y*(w+1)+x+1] + row
+		}
+	}
+
+	factor := 1 - percent/100
+	parallel(0, h, func(ys <-chan int) {
+		for y := range ys {
+			y0, y1 := max(0, y-radius), min(h, y+radius+1)
+			for x := 0; x < w; x++ {
+				x0, x1 := max(0, x-radius), min(w, x+radius+1)
+				area := sum[y1*(w+1)+x1] - sum[y0*(w+1)+x1] - sum[y1*(w+1)+x0] + sum[y0*(w+1)+x0]
+				n := float64((y1 - y0) * (x1 - x0))
+				var v uint8 = 0xff
+				if lum[y*w+x]*n <= area*factor {
+					v = 0
+				}
+				i := dst.PixOffset(x, y)
+				dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = v, v, v, 0xff
+			}
+		}
+	})
+	return dst
+}
+
+// AdaptiveThreshold converts the image to black and white (see AdaptiveThreshold)
+func (img *Image) AdaptiveThreshold(radius int, percent float64) *Image {
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("adaptiveThreshold", fmt.Sprintf("radius=%d percent=%.1f", radius, percent))
+	return newImage(AdaptiveThreshold(img.data, radius, percent), newMeta)
+}
@@ -0,0 +1,132 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testPage returns a w x h page of paper color with lines of dark "words"
+func testPage(w, h int, paper, ink color.NRGBA) *image.NRGBA {
+	page := New(w, h, paper)
+	rng := rand.New(rand.NewSource(1))
+	for y := h / 10; y+12 < h-h/10; y += 24 {
+		for x := w / 10; x < w-w/10; {
+			word := 10 + rng.Intn(40)
+			draw.Draw(page, image.Rect(x, y, min(x+word, w-w/10), y+12), &image.Uniform{ink}, image.Point{}, draw.Src)
+			x += word + 8
+		}
+	}
+	return page
+}
+
+func TestDetectSkew(t *testing.T) {
+	page := testPage(600, 800, color.NRGBA{255, 255, 255, 255}, color.NRGBA{0, 0, 0, 255})
+	if got := DetectSkew(page); got != 0 {
+		t.Errorf("DetectSkew(level) = %v, want 0", got)
+	}
+
+	for _, skew := range []float64{-7, -2.5, 4} {
+		tilted := Rotate(page, skew, color.White)
+		got := DetectSkew(tilted)
+		if math.Abs(got+skew) > 0.2 {
+			t.Errorf("DetectSkew(rotated by %v) = %v, want %v", skew, got, -skew)
+		}
+
+		// Dark wedges around the page, as in a photo on a table
+		if got := DetectSkew(Rotate(page, skew, color.Black)); math.Abs(got+skew) > 0.2 {
+			t.Errorf("DetectSkew(rotated by %v on black) = %v, want %v", skew, got, -skew)
+		}
+
+		level := Deskew(tilted, color.White)
+		if level.Bounds() != tilted.Bounds() {
+			t.Errorf("Deskew() bounds = %v, want %v", level.Bounds(), tilted.Bounds())
+		}
+		if got := DetectSkew(level); math.Abs(got) > 0.2 {
+			t.Errorf("DetectSkew(Deskew(rotated by %v)) = %v, want 0", skew, got)
+		}
+	}
+
+	if got := DetectSkew(New(100, 100, color.White)); got != 0 {
+		t.Errorf("DetectSkew(blank) = %v, want 0", got)
+	}
+}
+
+func TestWhitenBackground(t *testing.T) {
+	// Yellowish paper lit from the left, falling off to the right
+	page := testPage(400, 300, color.NRGBA{255, 255, 255, 255}, color.NRGBA{20, 20, 20, 255})
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 400; x++ {
+			light := 1 - 0.45*float64(x)/400
+			c := page.NRGBAAt(x, y)
+			page.SetNRGBA(x, y, color.NRGBA{
+				uint8(float64(c.R) * light * 0.95),
+				uint8(float64(c.G) * light * 0.9),
+				uint8(float64(c.B) * light * 0.7),
+				255,
+			})
+		}
+	}
+
+	got := WhitenBackground(page)
+	paper, ink := got.NRGBAAt(5, 5), got.NRGBAAt(40, 35)
+	for _, pt := range []image.Point{{5, 5}, {395, 5}, {395, 295}, {200, 290}} {
+		c := got.NRGBAAt(pt.X, pt.Y)
+		if c.R < 240 || c.G < 240 || c.B < 240 {
+			t.Errorf("paper at %v = %v, want white", pt, c)
+		}
+	}
+	if ink.R > 60 || ink.G > 60 || ink.B > 60 {
+		t.Errorf("ink = %v, want dark (paper %v)", ink, paper)
+	}
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// Text on a background that darkens below the text's own level on the
+	// right, so no global threshold separates them
+	page := testPage(400, 300, color.NRGBA{255, 255, 255, 255}, color.NRGBA{0, 0, 0, 255})
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 400; x++ {
+			light := 1 - 0.7*float64(x)/400
+			c := page.NRGBAAt(x, y)
+			v := uint8(float64(c.R)*0.6*light + 60*light)
+			page.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+
+	got := AdaptiveThreshold(page, 0, 0)
+	if got.Bounds() != page.Bounds() {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), page.Bounds())
+	}
+	for _, tc := range []struct {
+		pt   image.Point
+		want uint8
+	}{
+		{image.Pt(5, 5), 0xff},     // bright paper
+		{image.Pt(395, 295), 0xff}, // dark paper
+		{image.Pt(40, 35), 0},      // ink on bright paper
+	} {
+		if c := got.NRGBAAt(tc.pt.X, tc.pt.Y); c.R != tc.want || c.A != 0xff {
+			t.Errorf("pixel at %v = %v, want %d", tc.pt, c, tc.want)
+		}
+	}
+	// Ink on the dark side is darker than the paper on the bright side
+	var inkRight image.Point
+	for x := 399; x > 300 && inkRight == (image.Point{}); x-- {
+		if page.NRGBAAt(x, 35).R < page.NRGBAAt(x, 5).R {
+			inkRight = image.Pt(x, 35)
+		}
+	}
+	if c := got.NRGBAAt(inkRight.X, inkRight.Y); c.R != 0 {
+		t.Errorf("ink at %v on dark paper = %v, want black", inkRight, c)
+	}
+
+	for i := 0; i < len(got.Pix); i += 4 {
+		if v := got.Pix[i]; v != 0 && v != 0xff {
+			t.Fatalf("AdaptiveThreshold() has gray value %d", v)
+		}
+	}
+}
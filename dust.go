@@ -0,0 +1,181 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// dustRadius bounds the size of the defects found by DetectDust: specks and
+// scratches narrower than 2*dustRadius+1 pixels.
+const dustRadius = 3
+
+// DetectDust finds dust specks, hairs and thin scratches on scanned photos
+// and film: small features that are much brighter or darker than their
+// surroundings. It returns a repair mask, white where a defect was found,
+// suitable for Inpaint. sensitivity ranges from 0 (only obvious defects) to
+// 1 (faint ones too, at the risk of flagging fine detail); 0.5 is a good
+// start.
+//
+// Example:
+//
+//	mask := imgx.DetectDust(scan, 0.6)
+//	clean := imgx.Inpaint(scan, mask)
+func DetectDust(img image.Image, sensitivity float64) *image.NRGBA {
+	sensitivity = math.Max(0, math.Min(1, sensitivity))
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	mask := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 3; i < len(mask.Pix); i += 4 {
+		mask.Pix[i] = 0xff
+	}
+	if w < 2*dustRadius+1 || h < 2*dustRadius+1 {
+		return mask
+	}
+
+	lum := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := src.Pix[src.PixOffset(x, y):]
+			lum[y*w+x] = uint8(luminanceRedWeight*float64(p[0]) + luminanceGreenWeight*float64(p[1]) + luminanceBlueWeight*float64(p[2]) + 0.5)
+		}
+	}
+
+	// Top-hat transforms: bright features removed by an opening and dark
+	// ones removed by a closing are narrower than the structuring element.
+	opened := rankFilter(rankFilter(lum, w, h, dustRadius, false), w, h, dustRadius, true)
+	closed := rankFilter(rankFilter(lum, w, h, dustRadius, true), w, h, dustRadius, false)
+	residual := make([]uint8, w*h)
+	for i, v := range lum {
+		residual[i] = max(v-opened[i], closed[i]-v)
+	}
+
+	// The threshold adapts to the noise and texture of the image.
+	sample := make([]uint8, 0, 4096)
+	for i := 0; i < len(residual); i += max(1, len(residual)/4096) {
+		sample = append(sample, residual[i])
+	}
+	sort.Slice(sample, func(i, j int) bool { return sample[i] < sample[j] })
+	noise := 1.4826 * float64(sample[len(sample)/2])
+	threshold := math.Max(12+(1-sensitivity)*60, (8-5*sensitivity)*noise)
+
+	candidate := make([]bool, w*h)
+	for i, v := range residual {
+		candidate[i] = float64(v) >= threshold
+	}
+
+	// Keep small specks and thin scratches; large components are image
+	// content (text, fine patterns) rather than defects.
+	maxArea := 16 + int(240*sensitivity)
+	visited := make([]bool, w*h)
+	var stack, component []int
+	for start := range candidate {
+		if !candidate[start] || visited[start] {
+			continue
+		}
+		component = component[:0]
+		stack = append(stack[:0], start)
+		visited[start] = true
+		minX, minY, maxX, maxY := w, h, 0, 0
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			component = append(component, i)
+			x, y := i%w, i/w
+			minX, maxX = min(minX, x), max(maxX, x)
+			minY, maxY = min(minY, y), max(maxY, y)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					if j := ny*w + nx; candidate[j] && !visited[j] {
+						visited[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+		length := max(maxX-minX, maxY-minY) + 1
+		thin := length >= 4*dustRadius && float64(len(component))/float64(length) <= 3
+		if len(component) > maxArea && !thin {
+			continue
+		}
+		// Mark the defect and a 1px margin around its soft edge.
+		for _, i := range component {
+			x, y := i%w, i/w
+			for ny := max(0, y-1); ny <= min(h-1, y+1); ny++ {
+				for nx := max(0, x-1); nx <= min(w-1, x+1); nx++ {
+					j := mask.PixOffset(nx, ny)
+					mask.Pix[j], mask.Pix[j+1], mask.Pix[j+2] = 0xff, 0xff, 0xff
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// RemoveDust detects dust specks and scratches with DetectDust and inpaints
+// them.
+//
+// Example:
+//
+//	clean := imgx.RemoveDust(scan, 0.5)
+func RemoveDust(img image.Image, sensitivity float64) *image.NRGBA {
+	return Inpaint(img, DetectDust(img, sensitivity))
+}
+
+// DetectDust returns a repair mask of the dust specks and scratches in the image
+func (img *Image) DetectDust(sensitivity float64) *Image {
+	newData := DetectDust(img.data, sensitivity)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("detectDust", fmt.Sprintf("sensitivity=%.2f", sensitivity))
+	return newImage(newData, newMeta)
+}
+
+// RemoveDust removes dust specks and scratches from the image
+func (img *Image) RemoveDust(sensitivity float64) *Image {
+	newData := RemoveDust(img.data, sensitivity)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("removeDust", fmt.Sprintf("sensitivity=%.2f", sensitivity))
+	return newImage(newData, newMeta)
+}
+
+// rankFilter returns the minimum (or maximum) of each (2r+1)x(2r+1) square
+// neighborhood of a w x h grayscale image, computed separably.
+func rankFilter(src []uint8, w, h, r int, maximum bool) []uint8 {
+	pick := func(a, b uint8) uint8 {
+		if maximum {
+			return max(a, b)
+		}
+		return min(a, b)
+	}
+	tmp := make([]uint8, w*h)
+	parallel(0, h, func(ys <-chan int) {
+		for y := range ys {
+			row := src[y*w : (y+1)*w]
+			for x := 0; x < w; x++ {
+				v := row[x]
+				for k := max(0, x-r); k <= min(w-1, x+r); k++ {
+					v = pick(v, row[k])
+				}
+				tmp[y*w+x] = v
+			}
+		}
+	})
+	dst := make([]uint8, w*h)
+	parallel(0, h, func(ys <-chan int) {
+		for y := range ys {
+			for x := 0; x < w; x++ {
+				v := tmp[y*w+x]
+				for k := max(0, y-r); k <= min(h-1, y+r); k++ {
+					v = pick(v, tmp[k*w+x])
+				}
+				dst[y*w+x] = v
+			}
+		}
+	})
+	return dst
+}
@@ -0,0 +1,65 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDetectDust(t *testing.T) {
+	// A smooth scan with dust specks, a hair and a large dark object.
+	src := image.NewNRGBA(image.Rect(0, 0, 80, 60))
+	bg := func(x, y int) color.NRGBA { return color.NRGBA{uint8(150 + x/2), uint8(140 + y/2), 120, 255} }
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 80; x++ {
+			src.SetNRGBA(x, y, bg(x, y))
+		}
+	}
+	specks := []image.Point{{10, 10}, {40, 12}, {65, 50}}
+	for _, p := range specks {
+		for _, d := range []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			src.SetNRGBA(p.X+d.X, p.Y+d.Y, color.NRGBA{20, 20, 20, 255})
+		}
+	}
+	src.SetNRGBA(25, 40, color.NRGBA{255, 255, 255, 255}) // bright speck
+	for x := 5; x < 45; x++ {
+		src.SetNRGBA(x, 30+x/8, color.NRGBA{30, 30, 30, 255}) // hair
+	}
+	for y := 5; y < 25; y++ {
+		for x := 55; x < 75; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{10, 10, 10, 255}) // object
+		}
+	}
+
+	mask := DetectDust(src, 0.5)
+	selected := func(x, y int) bool { return mask.NRGBAAt(x, y).R == 255 }
+	for _, p := range append(specks, image.Pt(25, 40), image.Pt(20, 32)) {
+		if !selected(p.X, p.Y) {
+			t.Errorf("defect at %v not detected", p)
+		}
+	}
+	for _, p := range []image.Point{{65, 15}, {55, 5}, {30, 5}, {70, 40}} {
+		if selected(p.X, p.Y) {
+			t.Errorf("clean pixel %v flagged as a defect", p)
+		}
+	}
+
+	clean := FromImage(src).RemoveDust(0.5).ToNRGBA()
+	for _, p := range specks {
+		got, want := clean.NRGBAAt(p.X, p.Y), bg(p.X, p.Y)
+		if absint(int(got.R)-int(want.R)) > 10 || absint(int(got.G)-int(want.G)) > 10 {
+			t.Errorf("speck at %v repaired to %v, want about %v", p, got, want)
+		}
+	}
+	if got := clean.NRGBAAt(65, 15); got != (color.NRGBA{10, 10, 10, 255}) {
+		t.Errorf("object pixel changed to %v", got)
+	}
+
+	// Lower sensitivity flags a subset of the defects.
+	strict := DetectDust(src, 0)
+	for i := 0; i < len(strict.Pix); i += 4 {
+		if strict.Pix[i] == 255 && mask.Pix[i] != 255 {
+			t.Fatal("sensitivity 0 flagged a pixel that sensitivity 0.5 did not")
+		}
+	}
+}
@@ -12,6 +12,7 @@ func gaussianBlurKernel(x, sigma float64) float64 {
 
 // Blur produces a blurred version of the image using a Gaussian function.
 // Sigma parameter must be positive and indicates how much the image will be blurred.
+// A copy of the image is returned otherwise; ValidateSigma reports it as an error.
 //
 // Example:
 //
@@ -157,7 +158,7 @@ func (img *Image) Blur(sigma float64) *Image {
 	newData := Blur(img.data, sigma)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("blur", fmt.Sprintf("sigma=%.2f", sigma))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Sharpen sharpens the image
@@ -165,5 +166,5 @@ func (img *Image) Sharpen(sigma float64) *Image {
 	newData := Sharpen(img.data, sigma)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("sharpen", fmt.Sprintf("sigma=%.2f", sigma))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
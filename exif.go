@@ -0,0 +1,239 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoEXIF is returned by ReadEXIF when the data carries no EXIF block.
+var ErrNoEXIF = errors.New("imgx: no EXIF data")
+
+// EXIFInfo holds the commonly used EXIF fields. It is read natively, without
+// exiftool, so it's cheap enough to call for every file in a large library.
+type EXIFInfo struct {
+	Make             string    `json:"make,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	LensModel        string    `json:"lens_model,omitempty"`
+	Software         string    `json:"software,omitempty"`
+	Orientation      int       `json:"orientation,omitempty"`
+	DateTimeOriginal time.Time `json:"date_time_original,omitzero"`
+	ExposureTime     float64   `json:"exposure_time,omitempty"` // seconds
+	FNumber          float64   `json:"f_number,omitempty"`
+	FocalLength      float64   `json:"focal_length,omitempty"` // millimeters
+	ISO              int       `json:"iso,omitempty"`
+	PixelWidth       int       `json:"pixel_width,omitempty"`  // PixelXDimension, as recorded by the camera
+	PixelHeight      int       `json:"pixel_height,omitempty"` // PixelYDimension
+}
+
+// EXIF tag IDs used by ReadEXIF.
+const (
+	exifTagMake             = 0x010f
+	exifTagModel            = 0x0110
+	exifTagSoftware         = 0x0131
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagExposureTime     = 0x829a
+	exifTagFNumber          = 0x829d
+	exifTagISO              = 0x8827
+	exifTagDateTimeOriginal = 0x9003
+	exifTagOffsetOriginal   = 0x9011
+	exifTagFocalLength      = 0x920a
+	exifTagPixelXDimension  = 0xa002
+	exifTagPixelYDimension  = 0xa003
+	exifTagLensModel        = 0xa434
+)
+
+// ReadEXIF reads the EXIF block of a JPEG or TIFF file from r.
+// It returns ErrNoEXIF if none is present.
+//
+// Example:
+//
+//	f, _ := os.Open("photo.jpg")
+//	info, err := imgx.ReadEXIF(f)
+//	if err == nil && !info.DateTimeOriginal.IsZero() {
+//		fmt.Println("taken", info.DateTimeOriginal)
+//	}
+func ReadEXIF(r io.Reader) (*EXIFInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tiff := findEXIFBlock(data)
+	if tiff == nil {
+		return nil, ErrNoEXIF
+	}
+
+	e, ok := newEXIFReader(tiff)
+	if !ok {
+		return nil, ErrNoEXIF
+	}
+	ifd0 := e.ifd(int(e.order.Uint32(tiff[4:])))
+	if ifd0 == nil {
+		return nil, ErrNoEXIF
+	}
+
+	info := &EXIFInfo{
+		Make:        e.ascii(ifd0[exifTagMake]),
+		Model:       e.ascii(ifd0[exifTagModel]),
+		Software:    e.ascii(ifd0[exifTagSoftware]),
+		Orientation: e.integer(ifd0[orientationTag]),
+	}
+	modified := e.ascii(ifd0[exifTagDateTime])
+
+	if ptr, ok := ifd0[exifTagExifIFD]; ok {
+		sub := e.ifd(e.integer(ptr))
+		info.LensModel = e.ascii(sub[exifTagLensModel])
+		info.ExposureTime = e.rational(sub[exifTagExposureTime])
+		info.FNumber = e.rational(sub[exifTagFNumber])
+		info.FocalLength = e.rational(sub[exifTagFocalLength])
+		info.ISO = e.integer(sub[exifTagISO])
+		info.PixelWidth = e.integer(sub[exifTagPixelXDimension])
+		info.PixelHeight = e.integer(sub[exifTagPixelYDimension])
+		info.DateTimeOriginal = parseEXIFTime(e.ascii(sub[exifTagDateTimeOriginal]), e.ascii(sub[exifTagOffsetOriginal]))
+	}
+	if info.DateTimeOriginal.IsZero() {
+		info.DateTimeOriginal = parseEXIFTime(modified, "")
+	}
+	return info, nil
+}
+
+// findEXIFBlock returns the TIFF structure holding the EXIF data: the payload
+// of a JPEG APP1 "Exif" segment, or the file itself for TIFF input.
+func findEXIFBlock(data []byte) []byte {
+	if len(data) >= 8 && (bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))) {
+		return data
+	}
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil
+	}
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		seg := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:]
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+type exifEntry struct {
+	typ   uint16
+	value []byte // raw value bytes, resolved from the offset when not inline
+}
+
+type exifReader struct {
+	tiff  []byte
+	order binary.ByteOrder
+}
+
+func newEXIFReader(tiff []byte) (*exifReader, bool) {
+	if len(tiff) < 8 {
+		return nil, false
+	}
+	switch binary.BigEndian.Uint16(tiff) {
+	case byteOrderBE:
+		return &exifReader{tiff: tiff, order: binary.BigEndian}, true
+	case byteOrderLE:
+		return &exifReader{tiff: tiff, order: binary.LittleEndian}, true
+	}
+	return nil, false
+}
+
+// exifTypeSizes maps TIFF field types to their size in bytes.
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// ifd parses the image file directory at offset. Malformed entries are skipped.
+func (e *exifReader) ifd(offset int) map[uint16]exifEntry {
+	if offset < 8 || offset+2 > len(e.tiff) {
+		return nil
+	}
+	n := int(e.order.Uint16(e.tiff[offset:]))
+	entries := make(map[uint16]exifEntry, n)
+	for i := 0; i < n; i++ {
+		p := offset + 2 + 12*i
+		if p+12 > len(e.tiff) {
+			break
+		}
+		typ := e.order.Uint16(e.tiff[p+2:])
+		count := e.order.Uint32(e.tiff[p+4:])
+		size, ok := exifTypeSizes[typ]
+		if !ok || count > 1<<20 {
+			continue
+		}
+		total := size * int(count)
+		value := e.tiff[p+8 : p+12]
+		if total > 4 {
+			off := int(e.order.Uint32(value))
+			if off < 0 || off+total > len(e.tiff) {
+				continue
+			}
+			value = e.tiff[off : off+total]
+		} else {
+			value = value[:total]
+		}
+		entries[e.order.Uint16(e.tiff[p:])] = exifEntry{typ: typ, value: value}
+	}
+	return entries
+}
+
+func (e *exifReader) ascii(en exifEntry) string {
+	if en.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(en.value), "\x00"))
+}
+
+func (e *exifReader) integer(en exifEntry) int {
+	switch {
+	case en.typ == 3 && len(en.value) >= 2:
+		return int(e.order.Uint16(en.value))
+	case (en.typ == 4 || en.typ == 9) && len(en.value) >= 4:
+		return int(e.order.Uint32(en.value))
+	}
+	return 0
+}
+
+func (e *exifReader) rational(en exifEntry) float64 {
+	if (en.typ != 5 && en.typ != 10) || len(en.value) < 8 {
+		return 0
+	}
+	num, den := e.order.Uint32(en.value), e.order.Uint32(en.value[4:])
+	if den == 0 {
+		return 0
+	}
+	if en.typ == 10 {
+		return float64(int32(num)) / float64(int32(den))
+	}
+	return float64(num) / float64(den)
+}
+
+// parseEXIFTime parses an EXIF "2006:01:02 15:04:05" timestamp with an
+// optional "+07:00" offset. Times without an offset are returned in UTC.
+func parseEXIFTime(s, offset string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", s+offset); err == nil {
+			return t
+		}
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
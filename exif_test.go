@@ -0,0 +1,127 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// buildEXIF builds a little-endian TIFF structure with IFD0 and an EXIF
+// sub-IFD holding a few common tags.
+func buildEXIF() []byte {
+	le := binary.LittleEndian
+	var b []byte
+	u16 := func(v uint16) { b = le.AppendUint16(b, v) }
+	u32 := func(v uint32) { b = le.AppendUint32(b, v) }
+
+	// Layout: header (8) | IFD0 (2+3*12+4=42) | ExifIFD (2+4*12+4=54) | data
+	const ifd0, exifIFD, data = 8, 50, 104
+	make_ := "Canon\x00"
+	date := "2024:05:06 07:08:09\x00"
+
+	b = append(b, 'I', 'I')
+	u16(42)
+	u32(ifd0)
+
+	u16(3)
+	u16(exifTagMake)
+	u16(2)
+	u32(uint32(len(make_)))
+	u32(data)
+	u16(orientationTag)
+	u16(3)
+	u32(1)
+	u32(6)
+	u16(exifTagExifIFD)
+	u16(4)
+	u32(1)
+	u32(exifIFD)
+	u32(0)
+
+	u16(4)
+	u16(exifTagFNumber)
+	u16(5)
+	u32(1)
+	u32(data + uint32(len(make_)))
+	u16(exifTagISO)
+	u16(3)
+	u32(1)
+	u32(400)
+	u16(exifTagDateTimeOriginal)
+	u16(2)
+	u32(uint32(len(date)))
+	u32(data + uint32(len(make_)) + 8)
+	u16(exifTagOffsetOriginal)
+	u16(2)
+	u32(7)
+	u32(data + uint32(len(make_)) + 8 + uint32(len(date)))
+	u32(0)
+
+	b = append(b, make_...)
+	u32(28)
+	u32(10)
+	b = append(b, date...)
+	b = append(b, "+02:00\x00"...)
+	return b
+}
+
+func TestReadEXIF(t *testing.T) {
+	tiff := buildEXIF()
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	jpg := spliceJPEGSegments(encodeTestJPEG(t, 8, 8, false), [][]byte{seg})
+
+	for name, data := range map[string][]byte{"jpeg": jpg, "tiff": tiff} {
+		t.Run(name, func(t *testing.T) {
+			info, err := ReadEXIF(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadEXIF() error = %v", err)
+			}
+			if info.Make != "Canon" {
+				t.Errorf("Make = %q, want %q", info.Make, "Canon")
+			}
+			if info.Orientation != 6 {
+				t.Errorf("Orientation = %d, want 6", info.Orientation)
+			}
+			if info.ISO != 400 {
+				t.Errorf("ISO = %d, want 400", info.ISO)
+			}
+			if info.FNumber != 2.8 {
+				t.Errorf("FNumber = %v, want 2.8", info.FNumber)
+			}
+			want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("", 2*3600))
+			if !info.DateTimeOriginal.Equal(want) {
+				t.Errorf("DateTimeOriginal = %v, want %v", info.DateTimeOriginal, want)
+			}
+		})
+	}
+}
+
+func TestReadEXIFMissing(t *testing.T) {
+	_, err := ReadEXIF(bytes.NewReader(encodeTestJPEG(t, 8, 8, false)))
+	if !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("ReadEXIF() error = %v, want ErrNoEXIF", err)
+	}
+	_, err = ReadEXIF(bytes.NewReader([]byte("plain text")))
+	if !errors.Is(err, ErrNoEXIF) {
+		t.Errorf("ReadEXIF() error = %v, want ErrNoEXIF", err)
+	}
+}
+
+func FuzzReadEXIF(f *testing.F) {
+	tiff := buildEXIF()
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	f.Add(tiff)
+	f.Add(spliceJPEGSegments(encodeTestJPEG(f, 8, 8, false), [][]byte{seg}))
+	f.Add(withEXIF(encodeTestJPEG(f, 8, 8, false), 3))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := ReadEXIF(bytes.NewReader(data))
+		if err == nil && info == nil {
+			t.Error("ReadEXIF returned neither info nor error")
+		}
+	})
+}
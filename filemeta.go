@@ -0,0 +1,60 @@
+package imgx
+
+import "bytes"
+
+// FileMetadata describes the encoded file an Image was decoded from. It is
+// captured at decode time, so servers that load images from bytes or
+// readers can inspect it without re-reading the source.
+type FileMetadata struct {
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`   // Encoded size in bytes
+	Width       int    `json:"width"`  // Stored width, before EXIF rotation
+	Height      int    `json:"height"` // Stored height, before EXIF rotation
+	Orientation int    `json:"orientation,omitempty"`
+
+	// Technical details read from the format headers
+	BitDepth      int      `json:"bit_depth,omitempty"`
+	Compression   string   `json:"compression,omitempty"`
+	Interlaced    bool     `json:"interlaced,omitempty"`
+	HasICCProfile bool     `json:"has_icc_profile"`
+	Layers        []string `json:"layers,omitempty"` // PSD layer names, topmost first
+	Frames        int      `json:"frames,omitempty"` // Frames of animated GIF and WebP files (0 for still images)
+
+	// JPEGQuality is the quality a JPEG was encoded with, estimated from its
+	// QuantizationTables (see EstimateJPEGQuality)
+	JPEGQuality        int                 `json:"jpeg_quality,omitempty"`
+	QuantizationTables []QuantizationTable `json:"quantization_tables,omitempty"`
+
+	// EXIF holds the EXIF fields of JPEG and TIFF files (nil if none)
+	EXIF *EXIFInfo `json:"exif,omitempty"`
+}
+
+// readFileMetadata builds the FileMetadata of an encoded image
+func readFileMetadata(data []byte) *FileMetadata {
+	fm := &FileMetadata{Size: int64(len(data))}
+	if cfg, format, err := imageDecodeConfig(bytes.NewReader(data)); err == nil {
+		fm.Format = normalizeDecodedFormat(format)
+		fm.ContentType = mimeFromDecodedFormat(format)
+		fm.Width = cfg.Width
+		fm.Height = cfg.Height
+	}
+
+	header := readFormatHeader(data)
+	fm.BitDepth = header.bitDepth
+	fm.Compression = header.compression
+	fm.Interlaced = header.interlaced
+	fm.HasICCProfile = header.icc
+	fm.Layers = header.layers
+	fm.QuantizationTables = header.quantTables
+	fm.JPEGQuality = estimateJPEGQuality(header.quantTables)
+	if header.frames > 1 {
+		fm.Frames = header.frames
+	}
+
+	if info, err := ReadEXIF(bytes.NewReader(data)); err == nil {
+		fm.EXIF = info
+		fm.Orientation = info.Orientation
+	}
+	return fm
+}
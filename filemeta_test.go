@@ -0,0 +1,87 @@
+package imgx
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeWithMetadata(t *testing.T) {
+	payload := append([]byte("Exif\x00\x00"), buildEXIF()...)
+	seg := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	jpg := spliceJPEGSegments(encodeTestJPEG(t, 16, 8, false), [][]byte{seg})
+
+	img, err := DecodeWithMetadata(bytes.NewReader(jpg), Options{AutoOrient: true})
+	if err != nil {
+		t.Fatalf("DecodeWithMetadata() error = %v", err)
+	}
+	fm := img.FileMetadata()
+	if fm == nil {
+		t.Fatal("FileMetadata() = nil")
+	}
+	if fm.Format != "JPEG" || fm.ContentType != "image/jpeg" {
+		t.Errorf("Format/ContentType = %q/%q, want JPEG/image/jpeg", fm.Format, fm.ContentType)
+	}
+	if fm.Size != int64(len(jpg)) {
+		t.Errorf("Size = %d, want %d", fm.Size, len(jpg))
+	}
+	// Orientation 6 rotates the decoded image; the stored size is kept.
+	if fm.Width != 16 || fm.Height != 8 {
+		t.Errorf("stored size = %dx%d, want 16x8", fm.Width, fm.Height)
+	}
+	if b := img.Bounds(); b.Dx() != 8 || b.Dy() != 16 {
+		t.Errorf("decoded size = %dx%d, want 8x16", b.Dx(), b.Dy())
+	}
+	if fm.Orientation != 6 {
+		t.Errorf("Orientation = %d, want 6", fm.Orientation)
+	}
+	if fm.EXIF == nil || fm.EXIF.Make != "Canon" || fm.EXIF.DateTimeOriginal.IsZero() {
+		t.Errorf("EXIF = %+v, want Canon with a capture time", fm.EXIF)
+	}
+	if fm.BitDepth != 8 || fm.Compression != "Baseline DCT, Huffman coding" {
+		t.Errorf("BitDepth/Compression = %d/%q", fm.BitDepth, fm.Compression)
+	}
+
+	// Derived images keep the file metadata.
+	if got := img.Resize(4, 0, Lanczos).FileMetadata(); got != fm {
+		t.Errorf("Resize().FileMetadata() = %p, want %p", got, fm)
+	}
+}
+
+func TestLoadFileMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.png")
+	if err := NewImage(10, 6, color.White).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	fm := img.FileMetadata()
+	if fm == nil || fm.Format != "PNG" || fm.Width != 10 || fm.Height != 6 {
+		t.Fatalf("FileMetadata() = %+v, want a 10x6 PNG", fm)
+	}
+	info, _ := os.Stat(path)
+	if fm.Size != info.Size() {
+		t.Errorf("Size = %d, want %d", fm.Size, info.Size())
+	}
+	if fm.EXIF != nil {
+		t.Errorf("EXIF = %+v, want nil", fm.EXIF)
+	}
+
+	if NewImage(2, 2, color.White).FileMetadata() != nil {
+		t.Error("NewImage().FileMetadata() should be nil")
+	}
+}
+
+func FuzzReadFileMetadata(f *testing.F) {
+	for _, seed := range fuzzSeedImages(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		readFileMetadata(data)
+	})
+}
@@ -0,0 +1,213 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// maxHorizonAnalysisSize bounds the size of the image analyzed by
+// EstimateHorizon. Larger images are downscaled first.
+const maxHorizonAnalysisSize = 800
+
+// maxHorizonTilt is the largest tilt in degrees EstimateHorizon considers;
+// steeper edges are not treated as horizon candidates.
+const maxHorizonTilt = 20
+
+// GuideOptions selects the composition guides drawn by DrawGuides.
+type GuideOptions struct {
+	// RuleOfThirds draws lines at 1/3 and 2/3 of the width and height.
+	RuleOfThirds bool
+
+	// GoldenRatio draws the phi grid: lines at 0.382 and 0.618 of the width
+	// and height.
+	GoldenRatio bool
+
+	// Horizon draws the horizon estimated by EstimateHorizon through the
+	// center of the image.
+	Horizon bool
+
+	// Color is the color of the rule of thirds lines.
+	// Default is white at 60% opacity.
+	Color color.Color
+
+	// GoldenColor is the color of the golden ratio lines.
+	// Default is gold at 60% opacity.
+	GoldenColor color.Color
+
+	// HorizonColor is the color of the horizon line.
+	// Default is red at 80% opacity.
+	HorizonColor color.Color
+
+	// Width is the line width in pixels.
+	// Default is 1/500 of the shorter side, at least 1 pixel.
+	Width int
+}
+
+// EstimateHorizon estimates the tilt of the horizon from the dominant
+// near-horizontal edges of the image. It returns the angle in degrees,
+// positive when the horizon rises to the right, and a confidence in the
+// range [0, 1]. Pass -angle to Rotate to level the image. Only tilts up to 20
+// degrees are considered; images without strong edges return 0 with
+// confidence 0.
+//
+// Example:
+//
+//	angle, confidence := imgx.EstimateHorizon(img)
+//	if confidence > 0.3 && math.Abs(angle) > 0.5 {
+//		img = imgx.Rotate(img, -angle, color.Black, imgx.WithAutoCrop())
+//	}
+func EstimateHorizon(img image.Image) (angle, confidence float64) {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	if b.Dx() < 8 || b.Dy() < 8 {
+		return 0, 0
+	}
+	if b.Dx() > maxHorizonAnalysisSize || b.Dy() > maxHorizonAnalysisSize {
+		src = Fit(src, maxHorizonAnalysisSize, maxHorizonAnalysisSize, Box)
+		b = src.Bounds()
+	}
+	w, h := b.Dx(), b.Dy()
+
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*src.Stride + x*4
+			px := src.Pix[i : i+3 : i+3]
+			lum[y*w+x] = luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+		}
+	}
+
+	// Sobel gradients; the tilt of the edge through a pixel is atan(gx/gy).
+	const binsPerDegree = 4
+	const nbins = 2*maxHorizonTilt*binsPerDegree + 1
+	var hist [nbins]float64
+	var total float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			gx := (lum[i-w+1] + 2*lum[i+1] + lum[i+w+1]) - (lum[i-w-1] + 2*lum[i-1] + lum[i+w-1])
+			gy := (lum[i+w-1] + 2*lum[i+w] + lum[i+w+1]) - (lum[i-w-1] + 2*lum[i-w] + lum[i-w+1])
+			mag := math.Hypot(gx, gy)
+			if mag < 64 { // ignore noise and texture
+				continue
+			}
+			total += mag
+			if gy == 0 {
+				continue
+			}
+			tilt := math.Atan(gx/gy) * 180 / math.Pi
+			if math.Abs(tilt) > maxHorizonTilt {
+				continue
+			}
+			hist[int(math.Round((tilt+maxHorizonTilt)*binsPerDegree))] += mag
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	// The peak of the histogram summed over a one degree window.
+	best, bestWeight := 0, 0.0
+	for i := range hist {
+		var weight float64
+		for j := max(0, i-binsPerDegree/2); j <= min(nbins-1, i+binsPerDegree/2); j++ {
+			weight += hist[j]
+		}
+		if weight > bestWeight {
+			best, bestWeight = i, weight
+		}
+	}
+	if bestWeight == 0 {
+		return 0, 0
+	}
+	angle = float64(best)/binsPerDegree - maxHorizonTilt
+	return angle, math.Min(1, bestWeight/total)
+}
+
+// EstimateHorizon estimates the tilt of the horizon (see EstimateHorizon)
+func (img *Image) EstimateHorizon() (angle, confidence float64) {
+	return EstimateHorizon(img.data)
+}
+
+// DrawGuides overlays composition guides on a copy of img.
+//
+// Example:
+//
+//	annotated := imgx.DrawGuides(img, imgx.GuideOptions{RuleOfThirds: true, Horizon: true})
+func DrawGuides(img image.Image, opts GuideOptions) *image.NRGBA {
+	dst := Clone(img)
+	b := dst.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	if w == 0 || h == 0 {
+		return dst
+	}
+
+	width := opts.Width
+	if width <= 0 {
+		width = max(1, min(b.Dx(), b.Dy())/500)
+	}
+
+	gridLines := func(fractions []float64, c color.Color) {
+		mask := image.NewAlpha(b)
+		for _, f := range fractions {
+			x := b.Min.X + int(math.Round(w*f)) - width/2
+			y := b.Min.Y + int(math.Round(h*f)) - width/2
+			draw.Draw(mask, image.Rect(x, b.Min.Y, x+width, b.Max.Y), image.Opaque, image.Point{}, draw.Src)
+			draw.Draw(mask, image.Rect(b.Min.X, y, b.Max.X, y+width), image.Opaque, image.Point{}, draw.Src)
+		}
+		draw.DrawMask(dst, b, image.NewUniform(c), image.Point{}, mask, b.Min, draw.Over)
+	}
+
+	if opts.RuleOfThirds {
+		c := opts.Color
+		if c == nil {
+			c = color.NRGBA{255, 255, 255, 153}
+		}
+		gridLines([]float64{1.0 / 3, 2.0 / 3}, c)
+	}
+	if opts.GoldenRatio {
+		c := opts.GoldenColor
+		if c == nil {
+			c = color.NRGBA{255, 200, 0, 153}
+		}
+		gridLines([]float64{1 - 1/math.Phi, 1 / math.Phi}, c)
+	}
+	if opts.Horizon {
+		c := opts.HorizonColor
+		if c == nil {
+			c = color.NRGBA{255, 48, 48, 204}
+		}
+		angle, _ := EstimateHorizon(img)
+		slope := -math.Tan(angle * math.Pi / 180) // image y grows downwards
+		mask := image.NewAlpha(b)
+		cx, cy := w/2, h/2
+		for x := 0; x < b.Dx(); x++ {
+			y := b.Min.Y + int(math.Round(cy+(float64(x)+0.5-cx)*slope)) - width/2
+			draw.Draw(mask, image.Rect(b.Min.X+x, y, b.Min.X+x+1, y+width), image.Opaque, image.Point{}, draw.Src)
+		}
+		draw.DrawMask(dst, b, image.NewUniform(c), image.Point{}, mask, b.Min, draw.Over)
+	}
+	return dst
+}
+
+// DrawGuides overlays composition guides on the image (see DrawGuides)
+func (img *Image) DrawGuides(opts GuideOptions) *Image {
+	newData := DrawGuides(img.data, opts)
+	newMeta := img.metadata.Clone()
+	var guides []string
+	if opts.RuleOfThirds {
+		guides = append(guides, "rule-of-thirds")
+	}
+	if opts.GoldenRatio {
+		guides = append(guides, "golden-ratio")
+	}
+	if opts.Horizon {
+		guides = append(guides, "horizon")
+	}
+	newMeta.AddOperation("guides", fmt.Sprintf("guides=%s", strings.Join(guides, ",")))
+	return newImage(newData, newMeta)
+}
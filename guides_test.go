@@ -0,0 +1,56 @@
+package imgx
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestEstimateHorizon(t *testing.T) {
+	for _, want := range []float64{0, 5, -3} {
+		// Sky above a straight horizon, then rotated by want degrees.
+		img := New(400, 300, color.NRGBA{60, 60, 60, 255})
+		for y := 0; y < 150; y++ {
+			for x := 0; x < 400; x++ {
+				img.Set(x, y, color.NRGBA{200, 220, 255, 255})
+			}
+		}
+		tilted := CropCenter(Rotate(img, want, color.Black), 300, 200)
+
+		angle, confidence := EstimateHorizon(tilted)
+		if math.Abs(angle-want) > 0.5 {
+			t.Errorf("EstimateHorizon() angle = %v, want %v", angle, want)
+		}
+		if confidence < 0.5 {
+			t.Errorf("EstimateHorizon() confidence = %v for a clean horizon", confidence)
+		}
+	}
+
+	if angle, confidence := EstimateHorizon(New(50, 50, color.White)); angle != 0 || confidence != 0 {
+		t.Errorf("EstimateHorizon(flat) = %v, %v, want 0, 0", angle, confidence)
+	}
+}
+
+func TestDrawGuides(t *testing.T) {
+	img := NewImage(90, 60, color.Black)
+	out := img.DrawGuides(GuideOptions{RuleOfThirds: true, Color: color.White, Width: 1})
+
+	data := out.ToNRGBA()
+	if c := data.NRGBAAt(30, 10); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel on the 1/3 line = %v, want white", c)
+	}
+	if c := data.NRGBAAt(10, 20); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel on the horizontal 1/3 line = %v, want white", c)
+	}
+	if c := data.NRGBAAt(15, 10); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("pixel off the guides = %v, want black", c)
+	}
+	if c := img.ToNRGBA().NRGBAAt(30, 10); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Error("DrawGuides modified the source image")
+	}
+
+	ops := out.GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Action != "guides" {
+		t.Errorf("operations = %+v, want one guides record", ops)
+	}
+}
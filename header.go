@@ -0,0 +1,372 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// formatHeader holds technical details read directly from a file's format
+// headers, so they are available without exiftool.
+type formatHeader struct {
+	bitDepth    int // bits per sample (per palette index for indexed images)
+	compression string
+	interlaced  bool // Adam7 PNG, progressive JPEG or interlaced GIF
+	icc         bool
+	exif        bool
+	layers      []string // layer names of layered formats, topmost first
+	frames      int      // frames of animated GIF and WebP files
+
+	quantTables []QuantizationTable // JPEG quantization tables
+}
+
+// inspectFormatHeader reads the format header of the file at path.
+// Unreadable or unrecognized files yield a zero formatHeader.
+func inspectFormatHeader(path string) formatHeader {
+	f, err := fs.Open(path)
+	if err != nil {
+		return formatHeader{}
+	}
+	defer f.Close()
+
+	// Headers and metadata chunks of most formats precede the image data,
+	// but TIFF directories may sit at the end of the file.
+	data, err := io.ReadAll(io.LimitReader(f, 256<<10))
+	if err != nil {
+		return formatHeader{}
+	}
+	// Frames of animated GIF and WebP files run to the end of the file.
+	if isTIFFHeader(data) || isWebPHeader(data) || bytes.HasPrefix(data, []byte("GIF8")) {
+		rest, _ := io.ReadAll(f)
+		data = append(data, rest...)
+	}
+	// PSD layer records follow the image resources, which may hold large
+	// thumbnails and XMP packets.
+	if bytes.HasPrefix(data, []byte("8BPS")) {
+		rest, _ := io.ReadAll(io.LimitReader(f, 8<<20))
+		data = append(data, rest...)
+	}
+	return readFormatHeader(data)
+}
+
+// readFormatHeader parses the PNG, JPEG, GIF, WebP, BMP, TIFF or PSD header at
+// the start of data.
+func readFormatHeader(data []byte) formatHeader {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return readPNGHeader(data)
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return readJPEGHeader(data)
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return readGIFHeader(data)
+	case isWebPHeader(data):
+		return readWebPHeader(data)
+	case bytes.HasPrefix(data, []byte("BM")):
+		return readBMPHeader(data)
+	case isTIFFHeader(data):
+		return readTIFFHeader(data)
+	case bytes.HasPrefix(data, []byte("8BPS")):
+		return readPSDHeader(data)
+	}
+	return formatHeader{}
+}
+
+func isTIFFHeader(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))
+}
+
+// readPNGHeader reads the IHDR chunk and looks for iCCP and eXIf chunks.
+func readPNGHeader(data []byte) formatHeader {
+	h := formatHeader{compression: "Deflate"}
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		body := data[pos+8:]
+		if length < 0 || length > len(body) {
+			break
+		}
+		body = body[:length]
+		switch typ {
+		case "IHDR":
+			if len(body) >= 13 {
+				h.bitDepth = int(body[8])
+				h.interlaced = body[12] == 1
+			}
+		case "iCCP":
+			h.icc = true
+		case "eXIf":
+			h.exif = true
+		case "IEND":
+			return h
+		}
+		pos += 12 + length
+	}
+	return h
+}
+
+// jpegProcesses names the coding process of each JPEG SOF marker.
+var jpegProcesses = map[byte]string{
+	0xc0: "Baseline DCT, Huffman coding",
+	0xc1: "Extended sequential DCT, Huffman coding",
+	0xc2: "Progressive DCT, Huffman coding",
+	0xc3: "Lossless, Huffman coding",
+	0xc5: "Differential sequential DCT, Huffman coding",
+	0xc6: "Differential progressive DCT, Huffman coding",
+	0xc7: "Differential lossless, Huffman coding",
+	0xc9: "Extended sequential DCT, arithmetic coding",
+	0xca: "Progressive DCT, arithmetic coding",
+	0xcb: "Lossless, arithmetic coding",
+	0xcd: "Differential sequential DCT, arithmetic coding",
+	0xce: "Differential progressive DCT, arithmetic coding",
+	0xcf: "Differential lossless, arithmetic coding",
+}
+
+// readJPEGHeader reads the SOF and DQT segments and looks for APP1 EXIF and
+// APP2 ICC segments before the scan.
+func readJPEGHeader(data []byte) formatHeader {
+	var h formatHeader
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xff {
+			pos++ // fill byte
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		seg := data[pos+4 : pos+2+length]
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")):
+			h.exif = true
+		case marker == 0xe2 && bytes.HasPrefix(seg, []byte("ICC_PROFILE\x00")):
+			h.icc = true
+		case marker == 0xdb:
+			h.quantTables = append(h.quantTables, parseJPEGQuantTables(seg)...)
+		case jpegProcesses[marker] != "" && len(seg) >= 1:
+			h.bitDepth = int(seg[0])
+			h.compression = jpegProcesses[marker]
+			h.interlaced = marker == 0xc2 || marker == 0xc6 || marker == 0xca || marker == 0xce
+		}
+		pos += 2 + length
+	}
+	return h
+}
+
+// readGIFHeader reads the color table size and the interlace flag of the
+// first frame, looks for an ICC application extension and counts the
+// frames.
+func readGIFHeader(data []byte) formatHeader {
+	h := formatHeader{compression: "LZW"}
+	if len(data) < 13 {
+		return h
+	}
+	packed := data[10]
+	pos := 13
+	if packed&0x80 != 0 {
+		h.bitDepth = int(packed&0x07) + 1
+		pos += 3 << (packed&0x07 + 1)
+	}
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: label, then data sub-blocks
+			if pos+2 >= len(data) {
+				return h
+			}
+			if data[pos+1] == 0xff && bytes.HasPrefix(data[pos+2:], []byte("\x0bICCRGBG1012")) {
+				h.icc = true
+			}
+			pos += 2
+			for pos < len(data) && data[pos] != 0 {
+				pos += int(data[pos]) + 1
+			}
+			pos++
+		case 0x2c: // image descriptor, local color table, LZW data sub-blocks
+			if pos+10 > len(data) {
+				return h
+			}
+			local := data[pos+9]
+			if h.frames == 0 {
+				h.interlaced = local&0x40 != 0
+				if local&0x80 != 0 && h.bitDepth == 0 {
+					h.bitDepth = int(local&0x07) + 1
+				}
+			}
+			h.frames++
+			pos += 10
+			if local&0x80 != 0 {
+				pos += 3 << (local&0x07 + 1)
+			}
+			pos++ // LZW minimum code size
+			for pos < len(data) && data[pos] != 0 {
+				pos += int(data[pos]) + 1
+			}
+			pos++
+		default:
+			return h
+		}
+	}
+	return h
+}
+
+// readWebPHeader reads the VP8X feature flags and the bitstream chunk type,
+// and counts the frames of animations.
+func readWebPHeader(data []byte) formatHeader {
+	h := formatHeader{bitDepth: 8}
+	pos := 12
+	for pos+8 <= len(data) {
+		fourcc := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		switch fourcc {
+		case "ANMF":
+			h.frames++
+		case "VP8X":
+			if pos+9 <= len(data) {
+				flags := data[pos+8]
+				h.icc = flags&0x20 != 0
+				h.exif = flags&0x08 != 0
+			}
+		case "VP8 ":
+			h.compression = "VP8 (lossy)"
+			return h
+		case "VP8L":
+			h.compression = "VP8L (lossless)"
+			return h
+		}
+		if size < 0 {
+			break
+		}
+		pos += 8 + size + size&1
+	}
+	return h
+}
+
+func isWebPHeader(data []byte) bool {
+	return len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WEBP"
+}
+
+// checkWebPFrameSize returns an error if the VP8X canvas of an extended
+// WebP file differs from the size of its VP8 or VP8L frame. Simple files
+// have no canvas and are not checked.
+func checkWebPFrameSize(data []byte) error {
+	var canvasW, canvasH int
+	pos := 12
+	for pos+8 <= len(data) {
+		fourcc := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		chunk := data[pos+8:]
+		if size >= 0 && size < len(chunk) {
+			chunk = chunk[:size]
+		}
+		var frameW, frameH int
+		switch fourcc {
+		case "VP8X":
+			if len(chunk) < 10 {
+				return nil
+			}
+			canvasW = (int(chunk[4]) | int(chunk[5])<<8 | int(chunk[6])<<16) + 1
+			canvasH = (int(chunk[7]) | int(chunk[8])<<8 | int(chunk[9])<<16) + 1
+		case "VP8 ":
+			if len(chunk) < 10 {
+				return nil
+			}
+			frameW = int(binary.LittleEndian.Uint16(chunk[6:]) & 0x3fff)
+			frameH = int(binary.LittleEndian.Uint16(chunk[8:]) & 0x3fff)
+		case "VP8L":
+			if len(chunk) < 5 {
+				return nil
+			}
+			bits := binary.LittleEndian.Uint32(chunk[1:])
+			frameW = int(bits&0x3fff) + 1
+			frameH = int(bits>>14&0x3fff) + 1
+		}
+		if frameW != 0 || frameH != 0 {
+			if canvasW != 0 && (frameW != canvasW || frameH != canvasH) {
+				return fmt.Errorf("imgx: webp: frame size %dx%d differs from canvas size %dx%d", frameW, frameH, canvasW, canvasH)
+			}
+			return nil
+		}
+		if size < 0 {
+			break
+		}
+		pos += 8 + size + size&1
+	}
+	return nil
+}
+
+// bmpCompressions names the BMP compression methods.
+var bmpCompressions = map[uint32]string{
+	0: "None",
+	1: "RLE8",
+	2: "RLE4",
+	3: "Bitfields",
+	4: "JPEG",
+	5: "PNG",
+	6: "Alpha bitfields",
+}
+
+// readBMPHeader reads the bit count and compression of the info header.
+func readBMPHeader(data []byte) formatHeader {
+	var h formatHeader
+	if len(data) < 30 {
+		return h
+	}
+	switch bpp := int(binary.LittleEndian.Uint16(data[28:])); bpp {
+	case 16:
+		h.bitDepth = 5
+	case 24, 32:
+		h.bitDepth = 8
+	default:
+		h.bitDepth = bpp
+	}
+	h.compression = "None"
+	if len(data) >= 34 && binary.LittleEndian.Uint32(data[14:]) >= 40 {
+		h.compression = bmpCompressions[binary.LittleEndian.Uint32(data[30:])]
+	}
+	return h
+}
+
+// tiffCompressions names the common TIFF Compression tag values.
+var tiffCompressions = map[int]string{
+	1:     "None",
+	2:     "CCITT RLE",
+	3:     "CCITT Group 3",
+	4:     "CCITT Group 4",
+	5:     "LZW",
+	6:     "JPEG (old-style)",
+	7:     "JPEG",
+	8:     "Deflate",
+	32773: "PackBits",
+	32946: "Deflate",
+}
+
+// readTIFFHeader reads BitsPerSample, Compression and the ICC and EXIF
+// pointers of the first image directory.
+func readTIFFHeader(data []byte) formatHeader {
+	var h formatHeader
+	e, ok := newEXIFReader(data)
+	if !ok {
+		return h
+	}
+	ifd0 := e.ifd(int(e.order.Uint32(data[4:])))
+	if en, ok := ifd0[258]; ok {
+		h.bitDepth = e.integer(en)
+	} else if ifd0 != nil {
+		h.bitDepth = 1 // TIFF default for bilevel images
+	}
+	if en, ok := ifd0[259]; ok {
+		h.compression = tiffCompressions[e.integer(en)]
+	} else if ifd0 != nil {
+		h.compression = "None"
+	}
+	_, h.icc = ifd0[34675]
+	_, h.exif = ifd0[34665]
+	return h
+}
@@ -0,0 +1,133 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFormatHeader(t *testing.T) {
+	encodePNG := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	png8 := encodePNG(image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	png16 := encodePNG(image.NewNRGBA64(image.Rect(0, 0, 4, 4)))
+
+	// Mark the PNG as Adam7 interlaced (IHDR data starts at offset 16).
+	interlaced := append([]byte{}, png8...)
+	interlaced[16+12] = 1
+
+	// A progressive 12-bit JPEG with EXIF and ICC segments.
+	exif := append([]byte("Exif\x00\x00"), buildEXIF()...)
+	progressive := []byte{0xff, 0xd8, 0xff, 0xe1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}
+	progressive = append(progressive, exif...)
+	progressive = append(progressive, 0xff, 0xe2, 0, 16)
+	progressive = append(progressive, "ICC_PROFILE\x00\x01\x01"...)
+	progressive = append(progressive, 0xff, 0xc2, 0, 11, 12, 0, 4, 0, 4, 1, 1, 0x11, 0)
+	progressive = append(progressive, 0xff, 0xda)
+
+	var gifBuf bytes.Buffer
+	if err := gif.Encode(&gifBuf, image.NewNRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var animatedGIF bytes.Buffer
+	frame := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})
+	if err := gif.EncodeAll(&animatedGIF, &gif.GIF{Image: []*image.Paletted{frame, frame, frame}, Delay: []int{10, 10, 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An animated WebP: VP8X with the animation flag, ANIM and two ANMF chunks
+	animatedWebP := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x02\x00\x00\x00\x03\x00\x00\x03\x00\x00")
+	animatedWebP = append(animatedWebP, "ANIM\x06\x00\x00\x00\x00\x00\x00\x00\x00\x00"...)
+	animatedWebP = append(animatedWebP, "ANMF\x02\x00\x00\x00\x00\x00ANMF\x02\x00\x00\x00\x00\x00"...)
+
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x28\x00\x00\x00\x00\x00\x00\x00\x00\x00VP8L")
+	webp = append(webp, 0, 0, 0, 0)
+
+	bmp := make([]byte, 54)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[14:], 40)
+	binary.LittleEndian.PutUint16(bmp[28:], 24)
+
+	testCases := []struct {
+		name string
+		data []byte
+		want formatHeader
+	}{
+		{"png", png8, formatHeader{bitDepth: 8, compression: "Deflate"}},
+		{"png 16-bit", png16, formatHeader{bitDepth: 16, compression: "Deflate"}},
+		{"png interlaced", interlaced, formatHeader{bitDepth: 8, compression: "Deflate", interlaced: true}},
+		{"jpeg baseline", encodeTestJPEG(t, 8, 8, false), formatHeader{bitDepth: 8, compression: "Baseline DCT, Huffman coding"}},
+		{"jpeg progressive", progressive, formatHeader{bitDepth: 12, compression: "Progressive DCT, Huffman coding", interlaced: true, icc: true, exif: true}},
+		{"gif", gifBuf.Bytes(), formatHeader{bitDepth: 8, compression: "LZW", frames: 1}},
+		{"gif animated", animatedGIF.Bytes(), formatHeader{bitDepth: 1, compression: "LZW", frames: 3}},
+		{"webp animated", animatedWebP, formatHeader{bitDepth: 8, frames: 2}},
+		{"webp", webp, formatHeader{bitDepth: 8, compression: "VP8L (lossless)", icc: true, exif: true}},
+		{"bmp", bmp, formatHeader{bitDepth: 8, compression: "None"}},
+		{"tiff", buildEXIF(), formatHeader{bitDepth: 1, compression: "None", exif: true}},
+		{"psd", buildPSD(psdSpec{width: 2, height: 2, mode: psdRGB, channels: 3, rle: true, layers: []string{"Background", "Logo"}}),
+			formatHeader{bitDepth: 8, compression: "RLE (PackBits)", layers: []string{"Logo", "Background"}}},
+		{"garbage", []byte("not an image"), formatHeader{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := readFormatHeader(tc.data)
+			// The tables themselves are checked by TestEstimateJPEGQuality
+			if tc.name == "jpeg baseline" && len(got.quantTables) != 2 {
+				t.Errorf("readFormatHeader() read %d quantization tables, want 2", len(got.quantTables))
+			}
+			got.quantTables = nil
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("readFormatHeader() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMetadataFormatHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA64(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := Metadata(path, WithBasicOnly())
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if metadata.BitDepth != 16 {
+		t.Errorf("BitDepth = %d, want 16", metadata.BitDepth)
+	}
+	if metadata.Compression != "Deflate" {
+		t.Errorf("Compression = %q, want %q", metadata.Compression, "Deflate")
+	}
+	if metadata.Interlaced || metadata.HasICCProfile || metadata.HasEXIF {
+		t.Errorf("Interlaced/HasICCProfile/HasEXIF = %v/%v/%v, want all false",
+			metadata.Interlaced, metadata.HasICCProfile, metadata.HasEXIF)
+	}
+}
+
+func FuzzReadFormatHeader(f *testing.F) {
+	for _, seed := range fuzzSeedImages(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		readFormatHeader(data)
+	})
+}
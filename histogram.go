@@ -2,6 +2,7 @@ package imgx
 
 import (
 	"image"
+	"image/color"
 	"sync"
 )
 
@@ -50,3 +51,163 @@ func Histogram(img image.Image) [256]float64 {
 	}
 	return histogram
 }
+
+// RGBHistogram returns normalized histograms of the red, green and blue
+// channels of an image, in the same form as Histogram.
+func RGBHistogram(img image.Image) (red, green, blue [256]float64) {
+	var mu sync.Mutex
+	var total float64
+
+	src := newScanner(img)
+	if src.w == 0 || src.h == 0 {
+		return
+	}
+
+	parallel(0, src.h, func(ys <-chan int) {
+		var tmp [3][256]float64
+		var tmpTotal float64
+		scanLine := make([]uint8, src.w*4)
+		for y := range ys {
+			src.scan(0, y, src.w, y+1, scanLine)
+			for i := 0; i < len(scanLine); i += 4 {
+				tmp[0][scanLine[i]]++
+				tmp[1][scanLine[i+1]]++
+				tmp[2][scanLine[i+2]]++
+				tmpTotal++
+			}
+		}
+		mu.Lock()
+		for i := range 256 {
+			red[i] += tmp[0][i]
+			green[i] += tmp[1][i]
+			blue[i] += tmp[2][i]
+		}
+		total += tmpTotal
+		mu.Unlock()
+	})
+
+	for i := range 256 {
+		red[i] /= total
+		green[i] /= total
+		blue[i] /= total
+	}
+	return
+}
+
+// HistogramOptions contains options for rendering a histogram image.
+type HistogramOptions struct {
+	// Width and Height are the size of the rendered image.
+	// Default is 512x200.
+	Width, Height int
+
+	// RGB draws the red, green and blue histograms. Overlapping areas are
+	// blended additively, so tones present in all channels show as gray.
+	RGB bool
+
+	// Luminance draws the luminance histogram: as a filled area on its own,
+	// or as a white outline on top of the RGB histograms.
+	// When neither RGB nor Luminance is set, both are drawn.
+	Luminance bool
+
+	// Background is the background color.
+	// Default is dark gray (32, 32, 32).
+	Background color.Color
+}
+
+// RenderHistogram renders the histograms of img as an image, so reports and
+// UIs can show the exposure at a glance. Bars are scaled to the tallest bin
+// other than pure black and white; clipped shadows and highlights show as
+// full-height bars at the ends.
+//
+// Example:
+//
+//	hist := imgx.RenderHistogram(img, imgx.HistogramOptions{Width: 256, Height: 100})
+//	imgx.Save(hist, "hist.png")
+func RenderHistogram(img image.Image, opts HistogramOptions) *image.NRGBA {
+	if opts.Width <= 0 {
+		opts.Width = 512
+	}
+	if opts.Height <= 0 {
+		opts.Height = 200
+	}
+	if !opts.RGB && !opts.Luminance {
+		opts.RGB, opts.Luminance = true, true
+	}
+	if opts.Background == nil {
+		opts.Background = color.NRGBA{32, 32, 32, 255}
+	}
+	w, h := opts.Width, opts.Height
+	dst := New(w, h, opts.Background)
+
+	lum := Histogram(img)
+	red, green, blue := RGBHistogram(img)
+
+	// Scale to the tallest bin other than pure black and white, so clipping
+	// spikes don't flatten the rest of the histogram.
+	var peak, endPeak float64
+	for i := range 256 {
+		var v float64
+		if opts.RGB {
+			v = max(red[i], green[i], blue[i])
+		}
+		if opts.Luminance {
+			v = max(v, lum[i])
+		}
+		if i == 0 || i == 255 {
+			endPeak = max(endPeak, v)
+		} else {
+			peak = max(peak, v)
+		}
+	}
+	if peak == 0 {
+		peak = endPeak
+	}
+	if peak == 0 {
+		return dst
+	}
+
+	// heightOf returns the bar height of column x: the largest bin it covers
+	heightOf := func(hist *[256]float64, x int) int {
+		lo, hi := x*256/w, max(x*256/w, (x+1)*256/w-1)
+		var v float64
+		for i := lo; i <= hi && i < 256; i++ {
+			v = max(v, hist[i])
+		}
+		return min(h, int(v/peak*float64(h)+0.5))
+	}
+
+	prevLum := heightOf(&lum, 0)
+	for x := 0; x < w; x++ {
+		lh := heightOf(&lum, x)
+		// The outline connects to the previous column so steep slopes have no gaps
+		lineLo, lineHi := min(lh, prevLum), max(lh, prevLum)
+		prevLum = lh
+		var rh, gh, bh int
+		if opts.RGB {
+			rh, gh, bh = heightOf(&red, x), heightOf(&green, x), heightOf(&blue, x)
+		}
+		for y := 0; y < h; y++ {
+			above := h - y // height of the pixel above the baseline
+			i := dst.PixOffset(x, y)
+			px := dst.Pix[i : i+4 : i+4]
+			switch {
+			case opts.RGB:
+				if rh >= above || gh >= above || bh >= above {
+					var c [3]int
+					for ch, ht := range [3]int{rh, gh, bh} {
+						if ht >= above {
+							c[ch] = 200
+						}
+					}
+					px[0], px[1], px[2] = uint8(c[0]), uint8(c[1]), uint8(c[2])
+				}
+				if opts.Luminance && above >= lineLo && above <= lineHi && above > 0 {
+					px[0], px[1], px[2] = 255, 255, 255
+				}
+			case lh >= above:
+				px[0], px[1], px[2] = 200, 200, 200
+			}
+		}
+	}
+	return dst
+}
@@ -2,6 +2,7 @@ package imgx
 
 import (
 	"image"
+	"image/color"
 	"testing"
 )
 
@@ -57,3 +58,48 @@ func BenchmarkHistogram(b *testing.B) {
 		Histogram(testdataBranchJPG.ToNRGBA())
 	}
 }
+
+func TestRGBHistogram(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	copy(img.Pix, []uint8{0xff, 0x00, 0x80, 0xff, 0xff, 0x40, 0x80, 0xff})
+
+	red, green, blue := RGBHistogram(img)
+	if red[0xff] != 1 || green[0x00] != 0.5 || green[0x40] != 0.5 || blue[0x80] != 1 {
+		t.Errorf("RGBHistogram() = red[255] %v, green[0] %v, green[64] %v, blue[128] %v",
+			red[0xff], green[0x00], green[0x40], blue[0x80])
+	}
+
+	red, _, _ = RGBHistogram(&image.NRGBA{})
+	if red != ([256]float64{}) {
+		t.Error("RGBHistogram(empty) should be all zeros")
+	}
+}
+
+func TestRenderHistogram(t *testing.T) {
+	// Half black, half white: two full-height bars at the ends.
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := 8; i < 16; i++ {
+		img.Pix[i] = 0xff
+	}
+
+	hist := RenderHistogram(img, HistogramOptions{Width: 256, Height: 100, Luminance: true})
+	if b := hist.Bounds(); b.Dx() != 256 || b.Dy() != 100 {
+		t.Fatalf("size = %v, want 256x100", b.Size())
+	}
+	bar := color.NRGBA{200, 200, 200, 255}
+	background := color.NRGBA{32, 32, 32, 255}
+	if c := hist.NRGBAAt(0, 0); c != bar {
+		t.Errorf("black bar top = %v, want %v", c, bar)
+	}
+	if c := hist.NRGBAAt(255, 50); c != bar {
+		t.Errorf("white bar = %v, want %v", c, bar)
+	}
+	if c := hist.NRGBAAt(128, 99); c != background {
+		t.Errorf("empty bin = %v, want background", c)
+	}
+
+	rgb := RenderHistogram(img, HistogramOptions{Width: 256, Height: 100, RGB: true})
+	if c := rgb.NRGBAAt(0, 50); c != bar {
+		t.Errorf("gray tones in RGB mode = %v, want %v", c, bar)
+	}
+}
@@ -0,0 +1,166 @@
+package imgx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrHookFailed is returned by Save when a save hook fails under the
+// default HookFail policy. The image has been written. Use errors.Is to
+// detect it.
+var ErrHookFailed = errors.New("imgx: save hook failed")
+
+// SaveInfo describes an image written by Save, for save hooks.
+type SaveInfo struct {
+	Format     string    // Output format, e.g. "JPEG"
+	Size       int64     // Encoded size in bytes
+	Width      int       // Width in pixels
+	Height     int       // Height in pixels
+	SourcePath string    // File the image was loaded from ("" if none)
+	Warnings   []Warning // Warnings of the save
+}
+
+// SaveHook is called after an image has been written to path.
+type SaveHook func(path string, info SaveInfo) error
+
+// HookPolicy decides what a failing save hook does to the save.
+type HookPolicy int
+
+const (
+	// HookFail makes Save return ErrHookFailed (the default).
+	HookFail HookPolicy = iota
+	// HookWarn records a WarnHookFailed warning in the Result.
+	HookWarn
+	// HookIgnore ignores hook errors.
+	HookIgnore
+)
+
+// ParseHookPolicy parses "fail", "warn" or "ignore".
+func ParseHookPolicy(s string) (HookPolicy, error) {
+	switch s {
+	case "fail", "":
+		return HookFail, nil
+	case "warn":
+		return HookWarn, nil
+	case "ignore":
+		return HookIgnore, nil
+	}
+	return HookFail, &ValidationError{Op: "save", Param: "hook policy", Value: s, Reason: "must be fail, warn or ignore"}
+}
+
+// saveHooks are the hooks registered with OnSave, in order. Entries are
+// pointers so that a hook can be removed even if the same function was
+// registered twice.
+var saveHooks struct {
+	sync.RWMutex
+	list []*SaveHook
+}
+
+// OnSave registers a hook that runs after every Save and SaveWithResult,
+// once the image and its metadata are written: uploads, cache purges or
+// notifications. Hooks run in the order they were registered, on the
+// goroutine that saves; the first error stops the remaining hooks and is
+// handled according to WithHookPolicy. The returned function removes the
+// hook.
+//
+// Example:
+//
+//	remove := imgx.OnSave(func(path string, info imgx.SaveInfo) error {
+//		return upload(path)
+//	})
+//	defer remove()
+func OnSave(hook SaveHook) (remove func()) {
+	entry := &hook
+	saveHooks.Lock()
+	saveHooks.list = append(saveHooks.list, entry)
+	saveHooks.Unlock()
+
+	return func() {
+		saveHooks.Lock()
+		defer saveHooks.Unlock()
+		for i, e := range saveHooks.list {
+			if e == entry {
+				saveHooks.list = append(saveHooks.list[:i:i], saveHooks.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// WithHookPolicy sets what a failing save hook does to this save: HookFail
+// (the default) returns ErrHookFailed, HookWarn records a WarnHookFailed
+// warning and HookIgnore drops the error.
+func WithHookPolicy(policy HookPolicy) SaveOption {
+	return func(c *SaveConfig) {
+		c.HookPolicy = policy
+	}
+}
+
+// WithoutHooks skips the save hooks for this save
+func WithoutHooks() SaveOption {
+	return func(c *SaveConfig) {
+		c.DisableHooks = true
+	}
+}
+
+// RunSaveHooks runs the hooks registered with OnSave for a file that was
+// written without Save: data copied or transformed losslessly, or a
+// temporary file saved WithoutHooks and then renamed to path. info
+// describes the file; a zero Size is read from path. Of opts, only
+// WithHookPolicy and WithoutHooks apply. A hook failing under HookWarn is
+// recorded in the returned Result.
+func RunSaveHooks(path string, info SaveInfo, opts ...SaveOption) (*Result, error) {
+	config := &SaveConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	result := &Result{Path: path, Format: info.Format, Warnings: info.Warnings}
+	if err := runHooks(path, info, result, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runSaveHooks runs the registered hooks for the image written to path
+func (img *Image) runSaveHooks(path string, result *Result, config *SaveConfig) error {
+	info := SaveInfo{
+		Format:     result.Format,
+		Width:      img.data.Rect.Dx(),
+		Height:     img.data.Rect.Dy(),
+		SourcePath: img.metadata.SourcePath,
+		Warnings:   result.Warnings,
+	}
+	return runHooks(path, info, result, config)
+}
+
+// runHooks calls the registered hooks in order, handling the first error
+// according to config.HookPolicy
+func runHooks(path string, info SaveInfo, result *Result, config *SaveConfig) error {
+	saveHooks.RLock()
+	hooks := saveHooks.list
+	saveHooks.RUnlock()
+	if len(hooks) == 0 || config.DisableHooks {
+		return nil
+	}
+
+	if info.Size == 0 {
+		if fi, err := os.Stat(path); err == nil {
+			info.Size = fi.Size()
+		}
+	}
+	for _, hook := range hooks {
+		if err := (*hook)(path, info); err != nil {
+			switch config.HookPolicy {
+			case HookWarn:
+				result.Warn(WarnHookFailed, "save hook failed: %v", err)
+			case HookIgnore:
+			default:
+				return fmt.Errorf("%w: %s: %w", ErrHookFailed, path, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package imgx
+
+import (
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOnSave(t *testing.T) {
+	dir := t.TempDir()
+	img := NewImage(40, 30, color.White)
+
+	var calls []string
+	var got SaveInfo
+	removeFirst := OnSave(func(path string, info SaveInfo) error {
+		calls = append(calls, "first")
+		got = info
+		return nil
+	})
+	fail := errors.New("upload failed")
+	removeSecond := OnSave(func(path string, info SaveInfo) error {
+		calls = append(calls, "second")
+		return fail
+	})
+	defer removeFirst()
+
+	path := filepath.Join(dir, "out.png")
+	err := img.Save(path)
+	if !errors.Is(err, ErrHookFailed) || !errors.Is(err, fail) {
+		t.Fatalf("Save() error = %v, want ErrHookFailed wrapping the hook error", err)
+	}
+	if len(calls) != 2 || calls[0] != "first" {
+		t.Errorf("hooks ran as %q, want first then second", calls)
+	}
+	if got.Format != "PNG" || got.Width != 40 || got.Height != 30 || got.Size == 0 {
+		t.Errorf("SaveInfo = %+v", got)
+	}
+
+	result, err := img.SaveWithResult(path, WithHookPolicy(HookWarn))
+	if err != nil || !result.HasWarning(WarnHookFailed) {
+		t.Errorf("HookWarn: err = %v, warnings = %v", err, result)
+	}
+	if result, err := img.SaveWithResult(path, WithHookPolicy(HookIgnore)); err != nil || result.HasWarning(WarnHookFailed) {
+		t.Errorf("HookIgnore: err = %v, result = %+v", err, result)
+	}
+
+	calls = nil
+	if err := img.Save(path, WithoutHooks()); err != nil || len(calls) != 0 {
+		t.Errorf("WithoutHooks: err = %v, hooks ran %q", err, calls)
+	}
+
+	removeSecond()
+	removeSecond()
+	calls = nil
+	if err := img.Save(path); err != nil || len(calls) != 1 {
+		t.Errorf("after remove: err = %v, hooks ran %q", err, calls)
+	}
+}
+
+func TestRunSaveHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jpg")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got SaveInfo
+	fail := errors.New("upload failed")
+	remove := OnSave(func(p string, info SaveInfo) error {
+		got = info
+		return fail
+	})
+	defer remove()
+
+	_, err := RunSaveHooks(path, SaveInfo{Format: "JPEG", Width: 4, Height: 3})
+	if !errors.Is(err, ErrHookFailed) || !errors.Is(err, fail) {
+		t.Fatalf("RunSaveHooks() error = %v, want ErrHookFailed wrapping the hook error", err)
+	}
+	if got.Format != "JPEG" || got.Width != 4 || got.Size != 4 {
+		t.Errorf("SaveInfo = %+v, want the size read from the file", got)
+	}
+	if result, err := RunSaveHooks(path, SaveInfo{}, WithHookPolicy(HookWarn)); err != nil || !result.HasWarning(WarnHookFailed) {
+		t.Errorf("HookWarn: err = %v, result = %+v", err, result)
+	}
+	got = SaveInfo{}
+	if _, err := RunSaveHooks(path, SaveInfo{Format: "JPEG"}, WithoutHooks()); err != nil || got.Format != "" {
+		t.Errorf("WithoutHooks: err = %v, hooks ran with %+v", err, got)
+	}
+}
+
+func TestParseHookPolicy(t *testing.T) {
+	for s, want := range map[string]HookPolicy{"": HookFail, "fail": HookFail, "warn": HookWarn, "ignore": HookIgnore} {
+		if got, err := ParseHookPolicy(s); err != nil || got != want {
+			t.Errorf("ParseHookPolicy(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	var verr *ValidationError
+	if _, err := ParseHookPolicy("retry"); !errors.As(err, &verr) {
+		t.Errorf("ParseHookPolicy(retry) error = %v, want *ValidationError", err)
+	}
+}
@@ -22,10 +22,17 @@ type ProcessingMetadata struct {
 	ProjectURL  string // Fixed: project URL
 	AddMetadata bool
 
+	// File describes the encoded source (nil for images not decoded by
+	// Load or DecodeWithMetadata). It is shared by derived images.
+	File *FileMetadata
+
 	// DetectionResult holds detection output. Concrete type is *detection.DetectionResult
 	// when populated via detection.Detect(). Use type assertion to access.
 	// Requires: go get github.com/razzkumar/imgx/detection
 	DetectionResult any `json:"detection_result,omitempty"`
+
+	profile *profile          // Set by Profile, shared by derived images
+	timings []OperationTiming // Operation costs of a profiled image
 }
 
 // OperationRecord represents a single image processing operation
@@ -47,11 +54,25 @@ func (m *ProcessingMetadata) Clone() *ProcessingMetadata {
 		Author:      m.Author,
 		ProjectURL:  m.ProjectURL,
 		AddMetadata: m.AddMetadata,
+		File:        m.File,
 
 		DetectionResult: deepCloneDetectionResult(m.DetectionResult),
+
+		profile: m.profile,
+		timings: m.cloneTimings(),
 	}
 }
 
+// cloneTimings returns a copy of the timings of a profiled image
+func (m *ProcessingMetadata) cloneTimings() []OperationTiming {
+	if m.profile == nil {
+		return nil
+	}
+	m.profile.mu.Lock()
+	defer m.profile.mu.Unlock()
+	return append([]OperationTiming(nil), m.timings...)
+}
+
 // deepCloneDetectionResult performs a deep copy of the DetectionResult via JSON round-trip.
 // Falls back to shallow copy if marshaling fails.
 func deepCloneDetectionResult(v any) any {
@@ -93,6 +114,23 @@ func (img *Image) GetMetadata() *ProcessingMetadata {
 	return img.metadata
 }
 
+// FileMetadata returns the metadata of the file the image was decoded from:
+// format, stored dimensions, header details and EXIF. It returns nil for
+// images created with FromImage or NewImage.
+//
+// Example:
+//
+//	img, _ := imgx.DecodeWithMetadata(req.Body)
+//	if fm := img.FileMetadata(); fm != nil && fm.EXIF != nil {
+//		fmt.Println("taken", fm.EXIF.DateTimeOriginal)
+//	}
+func (img *Image) FileMetadata() *FileMetadata {
+	if img.metadata == nil {
+		return nil
+	}
+	return img.metadata.File
+}
+
 // SetAuthor sets the artist/creator name for the image metadata
 // This overrides the default author but keeps creator_tool unchanged
 func (img *Image) SetAuthor(author string) *Image {
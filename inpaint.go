@@ -0,0 +1,195 @@
+package imgx
+
+import (
+	"container/heap"
+	"fmt"
+	"image"
+	"math"
+)
+
+// inpaintRadius is the neighborhood, in pixels, averaged for each filled pixel.
+const inpaintRadius = 5
+
+// Inpaint fills the region selected by mask (white) from the surrounding
+// pixels and returns the result, using the fast marching method of Telea
+// (2004): the region is filled from its border inwards, each pixel being a
+// weighted average of the known pixels around it, favoring close pixels
+// along the direction of the fill. It works best on small regions such as
+// dust spots, scratches, power lines or small objects; large regions come
+// out blurry.
+//
+// Mask pixels with a luminance of 50% or more (and that are not transparent)
+// are filled. The mask is scaled to the image size when the sizes differ.
+//
+// Example:
+//
+//	spots := imgx.SelectByColor(scan, image.Pt(120, 80), 20)
+//	dst := imgx.Inpaint(scan, spots)
+func Inpaint(img, mask image.Image) *image.NRGBA {
+	dst := Clone(img)
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	if w == 0 || h == 0 {
+		return dst
+	}
+	m := toNRGBA(mask)
+	if m.Rect.Dx() != w || m.Rect.Dy() != h {
+		m = Resize(m, w, h, NearestNeighbor)
+	}
+
+	const (
+		known = iota
+		band
+		inside
+	)
+	flags := make([]uint8, w*h)
+	dist := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := m.Pix[m.PixOffset(x, y):]
+			lum := luminanceRedWeight*float64(p[0]) + luminanceGreenWeight*float64(p[1]) + luminanceBlueWeight*float64(p[2])
+			if lum >= 128 && p[3] >= 128 {
+				flags[y*w+x] = inside
+				dist[y*w+x] = math.Inf(1)
+			}
+		}
+	}
+
+	// The known pixels bordering the region form the initial narrow band.
+	q := &inpaintQueue{}
+	neighbors := [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if flags[y*w+x] != known {
+				continue
+			}
+			for _, d := range neighbors {
+				nx, ny := x+d.X, y+d.Y
+				if nx >= 0 && nx < w && ny >= 0 && ny < h && flags[ny*w+nx] == inside {
+					flags[y*w+x] = band
+					heap.Push(q, inpaintPixel{x, y, 0})
+					break
+				}
+			}
+		}
+	}
+
+	at := func(x, y int) float64 {
+		if x < 0 || x >= w || y < 0 || y >= h || flags[y*w+x] == inside {
+			return math.Inf(1)
+		}
+		return dist[y*w+x]
+	}
+	// solve returns the arrival time at (x, y) from its neighbors (eikonal
+	// equation |grad T| = 1).
+	solve := func(x, y int) float64 {
+		t1 := math.Min(at(x-1, y), at(x+1, y))
+		t2 := math.Min(at(x, y-1), at(x, y+1))
+		if math.IsInf(t1, 1) || math.IsInf(t2, 1) || math.Abs(t1-t2) >= 1 {
+			return math.Min(t1, t2) + 1
+		}
+		return (t1 + t2 + math.Sqrt(2-(t1-t2)*(t1-t2))) / 2
+	}
+	gradient := func(x, y int) (float64, float64) {
+		diff := func(a, b, c float64) float64 {
+			switch {
+			case !math.IsInf(a, 1) && !math.IsInf(c, 1):
+				return (c - a) / 2
+			case !math.IsInf(c, 1):
+				return c - b
+			case !math.IsInf(a, 1):
+				return b - a
+			}
+			return 0
+		}
+		t := dist[y*w+x]
+		return diff(at(x-1, y), t, at(x+1, y)), diff(at(x, y-1), t, at(x, y+1))
+	}
+
+	fill := func(x, y int) {
+		gx, gy := gradient(x, y)
+		t := dist[y*w+x]
+		var sum [4]float64
+		var wsum float64
+		for ny := max(0, y-inpaintRadius); ny <= min(h-1, y+inpaintRadius); ny++ {
+			for nx := max(0, x-inpaintRadius); nx <= min(w-1, x+inpaintRadius); nx++ {
+				if flags[ny*w+nx] == inside || (nx == x && ny == y) {
+					continue
+				}
+				rx, ry := float64(x-nx), float64(y-ny)
+				d2 := rx*rx + ry*ry
+				if d2 > inpaintRadius*inpaintRadius {
+					continue
+				}
+				dir := math.Abs(rx*gx+ry*gy) / math.Sqrt(d2)
+				if dir < 1e-6 {
+					dir = 1e-6
+				}
+				lev := 1 / (1 + math.Abs(dist[ny*w+nx]-t))
+				weight := dir * lev / d2
+				i := dst.PixOffset(nx, ny)
+				a := float64(dst.Pix[i+3])
+				for c := 0; c < 3; c++ {
+					sum[c] += weight * a * float64(dst.Pix[i+c])
+				}
+				sum[3] += weight * a
+				wsum += weight
+			}
+		}
+		if wsum == 0 {
+			return
+		}
+		i := dst.PixOffset(x, y)
+		if sum[3] > 0 {
+			for c := 0; c < 3; c++ {
+				dst.Pix[i+c] = clamp(sum[c] / sum[3])
+			}
+		}
+		dst.Pix[i+3] = clamp(sum[3] / wsum)
+	}
+
+	for q.Len() > 0 {
+		p := heap.Pop(q).(inpaintPixel)
+		if flags[p.y*w+p.x] == known {
+			continue
+		}
+		flags[p.y*w+p.x] = known
+		for _, d := range neighbors {
+			nx, ny := p.x+d.X, p.y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h || flags[ny*w+nx] != inside {
+				continue
+			}
+			dist[ny*w+nx] = solve(nx, ny)
+			fill(nx, ny)
+			flags[ny*w+nx] = band
+			heap.Push(q, inpaintPixel{nx, ny, dist[ny*w+nx]})
+		}
+	}
+	return dst
+}
+
+// Inpaint fills the white area of mask from the surrounding pixels
+func (img *Image) Inpaint(mask *Image) *Image {
+	newData := Inpaint(img.data, mask.data)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("inpaint", fmt.Sprintf("method=telea, radius=%d", inpaintRadius))
+	return newImage(newData, newMeta)
+}
+
+type inpaintPixel struct {
+	x, y int
+	t    float64
+}
+
+// inpaintQueue is a min-heap of narrow band pixels ordered by arrival time.
+type inpaintQueue []inpaintPixel
+
+func (q inpaintQueue) Len() int           { return len(q) }
+func (q inpaintQueue) Less(i, j int) bool { return q[i].t < q[j].t }
+func (q inpaintQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *inpaintQueue) Push(x any)        { *q = append(*q, x.(inpaintPixel)) }
+func (q *inpaintQueue) Pop() any {
+	old := *q
+	p := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return p
+}
@@ -0,0 +1,52 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestInpaint(t *testing.T) {
+	// A horizontal gradient with a red blotch to remove.
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	want := image.NewNRGBA(src.Rect)
+	mask := image.NewNRGBA(src.Rect)
+	hole := image.Rect(15, 10, 22, 16)
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.NRGBA{uint8(x * 5), 100, 200, 255}
+			want.SetNRGBA(x, y, c)
+			if image.Pt(x, y).In(hole) {
+				c = color.NRGBA{255, 0, 0, 255}
+				mask.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	img := FromImage(src).Inpaint(FromImage(mask))
+	got := img.ToNRGBA()
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			g, w := got.NRGBAAt(x, y), want.NRGBAAt(x, y)
+			if !image.Pt(x, y).In(hole) {
+				if g != w {
+					t.Fatalf("pixel (%d,%d) outside the mask changed: %v, want %v", x, y, g, w)
+				}
+				continue
+			}
+			if absint(int(g.R)-int(w.R)) > 12 || absint(int(g.G)-int(w.G)) > 1 || absint(int(g.B)-int(w.B)) > 1 || g.A != 255 {
+				t.Errorf("filled pixel (%d,%d) = %v, want about %v", x, y, g, w)
+			}
+		}
+	}
+	ops := img.GetMetadata().Operations
+	if ops[len(ops)-1].Action != "inpaint" {
+		t.Errorf("Inpaint() did not record the operation: %v", ops)
+	}
+
+	// An empty mask leaves the image unchanged.
+	if !compareNRGBA(Inpaint(src, image.NewNRGBA(image.Rect(0, 0, 4, 4))), src, 0) {
+		t.Error("Inpaint() with an empty mask changed the image")
+	}
+}
@@ -0,0 +1,70 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestInscribedSize(t *testing.T) {
+	testCases := []struct {
+		name         string
+		w, h         int
+		angle        float64
+		wantW, wantH float64
+	}{
+		{"no rotation", 200, 100, 0, 200, 100},
+		{"quarter turn", 200, 100, 90, 100, 200},
+		{"square 45", 100, 100, 45, 50 * math.Sqrt2, 50 * math.Sqrt2},
+		{"half constrained", 400, 100, 30, 100, 100 / math.Sqrt(3)},
+		{"empty", 0, 100, 10, 0, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, h := inscribedSize(tc.w, tc.h, tc.angle)
+			if math.Abs(w-tc.wantW) > 1e-6 || math.Abs(h-tc.wantH) > 1e-6 {
+				t.Errorf("inscribedSize() = %.3fx%.3f, want %.3fx%.3f", w, h, tc.wantW, tc.wantH)
+			}
+		})
+	}
+}
+
+func TestRotateOptions(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y * 2), 128, 255})
+		}
+	}
+	bg := color.NRGBA{0, 0, 0, 0}
+
+	// A linear kernel is bilinear interpolation.
+	if !compareNRGBA(Rotate(src, 17, bg, WithInterpolation(Linear)), Rotate(src, 17, bg), 1) {
+		t.Error("Rotate() with a Linear filter differs from the default bilinear interpolation")
+	}
+
+	for _, filter := range []ResampleFilter{NearestNeighbor, CatmullRom, Lanczos} {
+		t.Run(filter.Name, func(t *testing.T) {
+			dst := Rotate(src, 12, bg, WithInterpolation(filter), WithAutoCrop())
+			b := dst.Bounds()
+			if b.Dx() >= 200 || b.Dy() >= 100 || b.Dx() < 100 || b.Dy() < 40 {
+				t.Fatalf("auto-cropped size = %dx%d", b.Dx(), b.Dy())
+			}
+			// No background may remain after auto-crop.
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					if a := dst.NRGBAAt(x, y).A; a != 255 {
+						t.Fatalf("pixel (%d,%d) alpha = %d, want 255", x, y, a)
+					}
+				}
+			}
+		})
+	}
+
+	img := FromImage(src).Rotate(12, bg, WithInterpolation(Lanczos), WithAutoCrop())
+	ops := img.GetMetadata().Operations
+	if got, want := ops[len(ops)-1].Parameters, "12.00° counter-clockwise, filter=Lanczos, auto-crop"; got != want {
+		t.Errorf("recorded parameters = %q, want %q", got, want)
+	}
+}
@@ -1,9 +1,13 @@
 package imgx
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"image/gif"
 	"image/jpeg"
@@ -16,7 +20,7 @@ import (
 	gowebp "github.com/gen2brain/webp"
 	"golang.org/x/image/bmp"
 	"golang.org/x/image/tiff"
-	_ "golang.org/x/image/webp"
+	"golang.org/x/image/webp"
 )
 
 type fileSystem interface {
@@ -32,7 +36,12 @@ func (localFS) Open(name string) (io.ReadCloser, error)    { return os.Open(name
 var fs fileSystem = localFS{}
 
 type decodeConfig struct {
-	autoOrientation bool
+	autoOrientation   bool
+	ignoreOrientation bool
+	colorModel        color.Model
+	background        color.Color
+	memoryLimit       int64
+	nrgba             bool // the result is copied to NRGBA, as by Load
 }
 
 var defaultDecodeConfig = decodeConfig{
@@ -51,6 +60,44 @@ func AutoOrientation(enabled bool) DecodeOption {
 	}
 }
 
+// WithIgnoreOrientation returns a DecodeOption that ignores the EXIF
+// orientation tag, even when AutoOrientation or Options.AutoOrient enable
+// it. Use it for pipelines that handle rotation themselves.
+func WithIgnoreOrientation() DecodeOption {
+	return func(c *decodeConfig) {
+		c.ignoreOrientation = true
+	}
+}
+
+// WithGray16 returns a DecodeOption that converts the decoded image to
+// 16-bit grayscale (*image.Gray16).
+func WithGray16() DecodeOption {
+	return func(c *decodeConfig) {
+		c.colorModel = color.Gray16Model
+	}
+}
+
+// WithNRGBA64 returns a DecodeOption that converts the decoded image to
+// 16-bit non-premultiplied RGBA (*image.NRGBA64), keeping the precision of
+// 16-bit sources.
+func WithNRGBA64() DecodeOption {
+	return func(c *decodeConfig) {
+		c.colorModel = color.NRGBA64Model
+	}
+}
+
+// WithBackground returns a DecodeOption that flattens transparent images
+// onto bg, so the decoded image is opaque.
+//
+// Example:
+//
+//	img, err := imgx.Decode(r, imgx.WithBackground(color.White))
+func WithBackground(bg color.Color) DecodeOption {
+	return func(c *decodeConfig) {
+		c.background = bg
+	}
+}
+
 // Decode reads an image from r.
 func Decode(r io.Reader, opts ...DecodeOption) (image.Image, error) {
 	cfg := defaultDecodeConfig
@@ -58,9 +105,23 @@ func Decode(r io.Reader, opts ...DecodeOption) (image.Image, error) {
 		option(&cfg)
 	}
 
-	if !cfg.autoOrientation {
-		img, _, err := image.Decode(r)
-		return img, err
+	if cfg.memoryLimit > 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := cfg.checkMemory(data); err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	if !cfg.autoOrientation || cfg.ignoreOrientation {
+		img, _, err := imageDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.convert(img), nil
 	}
 
 	var orient orientation
@@ -73,14 +134,114 @@ func Decode(r io.Reader, opts ...DecodeOption) (image.Image, error) {
 		io.Copy(io.Discard, pr)
 	}()
 
-	img, _, err := image.Decode(r)
+	img, _, err := imageDecode(r)
 	pw.Close()
 	<-done
 	if err != nil {
 		return nil, err
 	}
 
-	return fixOrientation(img, orient), nil
+	return cfg.convert(fixOrientation(img, orient)), nil
+}
+
+// imageDecode is image.Decode, hardened against headers that make the
+// decoders allocate far more than the file describes:
+//
+//   - TIFF files are decoded from memory. Given a plain reader,
+//     x/image/tiff buffers the file up to the offsets in its header, so a
+//     few bytes with a bogus offset allocate gigabytes; through an
+//     io.ReaderAt the offsets are checked against the size.
+//   - WebP files whose VP8X canvas differs from the size of the frame are
+//     rejected. x/image/webp reports the canvas size but decodes the frame
+//     at its own size, which bypasses any check of the reported size.
+func imageDecode(r io.Reader) (image.Image, string, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(12)
+	switch {
+	case isTIFFHeader(magic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, "", err
+		}
+		img, err := tiff.Decode(bytes.NewReader(data))
+		return img, "tiff", err
+	case isWebPHeader(magic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := checkWebPFrameSize(data); err != nil {
+			return nil, "", err
+		}
+		img, err := webp.Decode(bytes.NewReader(data))
+		return img, "webp", err
+	}
+	return image.Decode(br)
+}
+
+// imageDecodeConfig is image.DecodeConfig with the checks of imageDecode.
+func imageDecodeConfig(r io.Reader) (image.Config, string, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(12)
+	switch {
+	case isTIFFHeader(magic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return image.Config{}, "", err
+		}
+		cfg, err := tiff.DecodeConfig(bytes.NewReader(data))
+		return cfg, "tiff", err
+	case isWebPHeader(magic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return image.Config{}, "", err
+		}
+		if err := checkWebPFrameSize(data); err != nil {
+			return image.Config{}, "", err
+		}
+		cfg, err := webp.DecodeConfig(bytes.NewReader(data))
+		return cfg, "webp", err
+	}
+	return image.DecodeConfig(br)
+}
+
+// checkMemory fails with a *MemoryLimitError when decoding data would need
+// more than the memory limit
+func (c *decodeConfig) checkMemory(data []byte) error {
+	ic, _, err := imageDecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if need := c.memoryNeeded(ic, len(data)); need > c.memoryLimit {
+		return &MemoryLimitError{Op: "decode", Need: need, Limit: c.memoryLimit}
+	}
+	return nil
+}
+
+// convert applies the background and color model options to a decoded image
+func (c *decodeConfig) convert(img image.Image) image.Image {
+	if c.background != nil {
+		flat := image.NewNRGBA64(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(c.background), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		img = flat
+	}
+
+	switch c.colorModel {
+	case color.Gray16Model:
+		if _, ok := img.(*image.Gray16); !ok {
+			dst := image.NewGray16(img.Bounds())
+			draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+			img = dst
+		}
+	case color.NRGBA64Model:
+		if _, ok := img.(*image.NRGBA64); !ok {
+			dst := image.NewNRGBA64(img.Bounds())
+			draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+			img = dst
+		}
+	}
+	return img
 }
 
 // open loads an image from file (internal use only - use Load() instead).
@@ -104,6 +265,7 @@ const (
 	TIFF
 	BMP
 	WEBP
+	PSD // read-only: the flattened composite is decoded
 )
 
 var formatExts = map[string]Format{
@@ -115,6 +277,8 @@ var formatExts = map[string]Format{
 	"tiff": TIFF,
 	"bmp":  BMP,
 	"webp": WEBP,
+	"psd":  PSD,
+	"psb":  PSD,
 }
 
 var formatNames = map[Format]string{
@@ -124,6 +288,7 @@ var formatNames = map[Format]string{
 	TIFF: "TIFF",
 	BMP:  "BMP",
 	WEBP: "WEBP",
+	PSD:  "PSD",
 }
 
 func (f Format) String() string {
@@ -140,7 +305,8 @@ const DefaultJPEGQuality = 95
 var ErrUnsupportedFormat = errors.New("imgx: unsupported image format")
 
 // FormatFromExtension parses image format from filename extension:
-// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp" and "webp" are supported.
+// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp", "webp" and
+// "psd" (or "psb", read-only) are supported.
 func FormatFromExtension(ext string) (Format, error) {
 	if f, ok := formatExts[strings.ToLower(strings.TrimPrefix(ext, "."))]; ok {
 		return f, nil
@@ -149,7 +315,8 @@ func FormatFromExtension(ext string) (Format, error) {
 }
 
 // FormatFromFilename parses image format from filename:
-// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp" and "webp" are supported.
+// "jpg" (or "jpeg"), "png", "gif", "tif" (or "tiff"), "bmp", "webp" and
+// "psd" (or "psb", read-only) are supported.
 func FormatFromFilename(filename string) (Format, error) {
 	ext := filepath.Ext(filename)
 	return FormatFromExtension(ext)
@@ -163,6 +330,8 @@ type encodeConfig struct {
 	pngCompressionLevel png.CompressionLevel
 	webpQuality         int
 	webpLossless        bool
+	webpEffort          int
+	err                 error // First invalid option, returned by Encode
 }
 
 var defaultEncodeConfig = encodeConfig{
@@ -173,6 +342,7 @@ var defaultEncodeConfig = encodeConfig{
 	pngCompressionLevel: png.DefaultCompression,
 	webpQuality:         80,
 	webpLossless:        false,
+	webpEffort:          DefaultWebPEffort,
 }
 
 // EncodeOption sets an optional parameter for the Encode and Save functions.
@@ -180,28 +350,27 @@ type EncodeOption func(*encodeConfig)
 
 // JPEGQuality returns an EncodeOption that sets the output JPEG quality.
 // Quality ranges from 1 to 100 inclusive, higher is better. Default is 95.
+// Encode fails with a *ValidationError for a quality outside the range.
 func JPEGQuality(quality int) EncodeOption {
 	return func(c *encodeConfig) {
-		if quality < 1 {
-			quality = 1
-		}
-		if quality > 100 {
-			quality = 100
-		}
+		c.setErr(ValidateJPEGQuality(quality))
 		c.jpegQuality = quality
 	}
 }
 
+// setErr records the first invalid option
+func (c *encodeConfig) setErr(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
 // GIFNumColors returns an EncodeOption that sets the maximum number of colors
 // used in the GIF-encoded image. It ranges from 1 to 256.  Default is 256.
+// Encode fails with a *ValidationError for a number outside the range.
 func GIFNumColors(numColors int) EncodeOption {
 	return func(c *encodeConfig) {
-		if numColors < 1 {
-			numColors = 1
-		}
-		if numColors > 256 {
-			numColors = 256
-		}
+		c.setErr(validateRange("encode", "GIF colors", numColors, 1, 256))
 		c.gifNumColors = numColors
 	}
 }
@@ -232,14 +401,10 @@ func PNGCompressionLevel(level png.CompressionLevel) EncodeOption {
 
 // WebPQuality returns an EncodeOption that sets the output WebP quality.
 // Quality ranges from 0 to 100 inclusive, higher is better. Default is 80.
+// Encode fails with a *ValidationError for a quality outside the range.
 func WebPQuality(quality int) EncodeOption {
 	return func(c *encodeConfig) {
-		if quality < 0 {
-			quality = 0
-		}
-		if quality > 100 {
-			quality = 100
-		}
+		c.setErr(validateRange("encode", "WebP quality", quality, 0, 100))
 		c.webpQuality = quality
 	}
 }
@@ -252,12 +417,36 @@ func WebPLossless(lossless bool) EncodeOption {
 	}
 }
 
+// DefaultWebPEffort is the WebP encoding effort used unless WebPEffort is set.
+const DefaultWebPEffort = 4
+
+// WebPEffort returns an EncodeOption that sets the WebP encoding effort, the
+// trade-off between encoding speed and compression. It ranges from 0 (fastest,
+// for on-the-fly serving) to 6 (slowest, smallest files, for archival).
+// Default is 4. At equal quality, higher efforts give smaller files of about
+// the same visual quality. Encode fails with a *ValidationError for an effort
+// outside the range.
+func WebPEffort(effort int) EncodeOption {
+	return func(c *encodeConfig) {
+		c.setErr(validateRange("encode", "WebP effort", effort, 0, 6))
+		c.webpEffort = effort
+	}
+}
+
 // Encode writes the image img to w in the specified format (JPEG, PNG, GIF, TIFF, BMP or WEBP).
+// Invalid encode options and empty images fail with a *ValidationError.
 func Encode(w io.Writer, img image.Image, format Format, opts ...EncodeOption) error {
 	cfg := defaultEncodeConfig
 	for _, option := range opts {
 		option(&cfg)
 	}
+	if cfg.err != nil {
+		return cfg.err
+	}
+	if b := img.Bounds(); b.Empty() {
+		return &ValidationError{Op: "encode", Param: "image size", Value: fmt.Sprintf("%dx%d", b.Dx(), b.Dy()),
+			Reason: "image is empty (check the dimensions of the operations that produced it)"}
+	}
 
 	switch format {
 	case JPEG:
@@ -292,6 +481,7 @@ func Encode(w io.Writer, img image.Image, format Format, opts ...EncodeOption) e
 		return gowebp.Encode(w, img, gowebp.Options{
 			Quality:  cfg.webpQuality,
 			Lossless: cfg.webpLossless,
+			Method:   cfg.webpEffort,
 		})
 	}
 
@@ -321,6 +511,24 @@ func save(img image.Image, filename string, opts ...EncodeOption) (err error) {
 	return nil
 }
 
+// writeFile writes encoded data to filename, removing the file on failure
+func writeFile(filename string, data []byte) error {
+	file, err := fs.Create(filename)
+	if err != nil {
+		return err
+	}
+	_, writeErr := file.Write(data)
+	closeErr := file.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(filename)
+		return writeErr
+	}
+	return nil
+}
+
 // orientation is an EXIF flag that specifies the transformation
 // that should be applied to image to display it correctly.
 type orientation int
@@ -356,27 +564,28 @@ func checkJPEGSOI(r io.Reader) bool {
 	return soi == markerSOI
 }
 
-// findAPP1Marker searches for the JPEG APP1 marker that contains EXIF data.
-func findAPP1Marker(r io.Reader) bool {
+// findAPP1Marker searches for the JPEG APP1 marker that contains EXIF data
+// and returns the size of its payload.
+func findAPP1Marker(r io.Reader) (int64, bool) {
 	for {
 		var marker, size uint16
 		if err := binary.Read(r, binary.BigEndian, &marker); err != nil {
-			return false
+			return 0, false
 		}
 		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
-			return false
+			return 0, false
 		}
 		if marker>>8 != 0xff {
-			return false // Invalid JPEG marker.
-		}
-		if marker == markerAPP1 {
-			return true
+			return 0, false // Invalid JPEG marker.
 		}
 		if size < 2 {
-			return false // Invalid block size.
+			return 0, false // Invalid block size.
+		}
+		if marker == markerAPP1 {
+			return int64(size - 2), true
 		}
 		if _, err := io.CopyN(io.Discard, r, int64(size-2)); err != nil {
-			return false
+			return 0, false
 		}
 	}
 }
@@ -390,11 +599,13 @@ func validateEXIFHeader(r io.Reader) bool {
 	if header != exifHeader {
 		return false
 	}
-	// Skip the null terminator (2 bytes).
-	if _, err := io.CopyN(io.Discard, r, 2); err != nil {
+	// The null terminator (2 bytes) is required, as by ReadEXIF and
+	// NormalizeJPEGOrientation.
+	var terminator uint16
+	if err := binary.Read(r, binary.BigEndian, &terminator); err != nil {
 		return false
 	}
-	return true
+	return terminator == 0
 }
 
 // readByteOrder reads and determines the byte order from the TIFF header.
@@ -490,9 +701,12 @@ func readOrientation(r io.Reader) orientation {
 		return orientationUnspecified
 	}
 
-	if !findAPP1Marker(r) {
+	size, ok := findAPP1Marker(r)
+	if !ok {
 		return orientationUnspecified
 	}
+	// Tags past the end of the segment are not EXIF data.
+	r = io.LimitReader(r, size)
 
 	if !validateEXIFHeader(r) {
 		return orientationUnspecified
@@ -510,6 +724,12 @@ func readOrientation(r io.Reader) orientation {
 	return findOrientationInTags(r, byteOrder)
 }
 
+// ReadOrientation returns the EXIF orientation (1-8) of the JPEG data in r,
+// or 0 if it has none.
+func ReadOrientation(r io.Reader) int {
+	return int(readOrientation(r))
+}
+
 // fixOrientation applies a transform to img corresponding to the given orientation flag.
 func fixOrientation(img image.Image, o orientation) image.Image {
 	switch o {
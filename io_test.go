@@ -3,6 +3,7 @@ package imgx
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/color/palette"
@@ -11,6 +12,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -450,6 +452,67 @@ func TestAutoOrientation(t *testing.T) {
 	}
 }
 
+func TestDecodeOptions(t *testing.T) {
+	// A 2x1 PNG: a transparent pixel and an opaque red one.
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Decode(bytes.NewReader(buf.Bytes()), WithBackground(color.White))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := color.NRGBAModel.Convert(img.At(0, 0)); got != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("flattened transparent pixel = %v, want white", got)
+	}
+	if got := color.NRGBAModel.Convert(img.At(1, 0)); got != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("flattened opaque pixel = %v, want red", got)
+	}
+
+	img, err = Decode(bytes.NewReader(buf.Bytes()), WithGray16())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if _, ok := img.(*image.Gray16); !ok {
+		t.Errorf("WithGray16: got %T, want *image.Gray16", img)
+	}
+
+	img, err = Decode(bytes.NewReader(buf.Bytes()), WithNRGBA64())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if _, ok := img.(*image.NRGBA64); !ok {
+		t.Errorf("WithNRGBA64: got %T, want *image.NRGBA64", img)
+	}
+
+	// Orientation 6 would rotate the 16x8 JPEG; WithIgnoreOrientation wins
+	// over AutoOrientation.
+	payload := append([]byte("Exif\x00\x00"), buildEXIF()...)
+	seg := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	jpg := spliceJPEGSegments(encodeTestJPEG(t, 16, 8, false), [][]byte{seg})
+	img, err = Decode(bytes.NewReader(jpg), AutoOrientation(true), WithIgnoreOrientation())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+		t.Errorf("WithIgnoreOrientation: size = %dx%d, want 16x8", b.Dx(), b.Dy())
+	}
+
+	loaded, err := DecodeWithMetadata(bytes.NewReader(jpg), Options{
+		AutoOrient: true,
+		Decode:     []DecodeOption{WithIgnoreOrientation()},
+	})
+	if err != nil {
+		t.Fatalf("DecodeWithMetadata() error = %v", err)
+	}
+	if b := loaded.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+		t.Errorf("Options.Decode: size = %dx%d, want 16x8", b.Dx(), b.Dy())
+	}
+}
+
 func TestDefaultJPEGQuality(t *testing.T) {
 	if DefaultJPEGQuality != 95 {
 		t.Errorf("DefaultJPEGQuality = %d, want 95", DefaultJPEGQuality)
@@ -459,74 +522,52 @@ func TestDefaultJPEGQuality(t *testing.T) {
 	}
 }
 
-func TestEncodeOptionClamping(t *testing.T) {
+func TestEncodeOptionValidation(t *testing.T) {
 	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
 	img.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
 
 	testCases := []struct {
-		name   string
-		option EncodeOption
-		check  func(cfg encodeConfig) (got, want int)
+		name    string
+		format  Format
+		option  EncodeOption
+		wantErr bool
 	}{
-		{
-			name:   "JPEGQuality(0) clamps to 1",
-			option: JPEGQuality(0),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.jpegQuality, 1 },
-		},
-		{
-			name:   "JPEGQuality(200) clamps to 100",
-			option: JPEGQuality(200),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.jpegQuality, 100 },
-		},
-		{
-			name:   "JPEGQuality(50) passes through",
-			option: JPEGQuality(50),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.jpegQuality, 50 },
-		},
-		{
-			name:   "WebPQuality(-5) clamps to 0",
-			option: WebPQuality(-5),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.webpQuality, 0 },
-		},
-		{
-			name:   "WebPQuality(150) clamps to 100",
-			option: WebPQuality(150),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.webpQuality, 100 },
-		},
-		{
-			name:   "WebPQuality(50) passes through",
-			option: WebPQuality(50),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.webpQuality, 50 },
-		},
-		{
-			name:   "GIFNumColors(0) clamps to 1",
-			option: GIFNumColors(0),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.gifNumColors, 1 },
-		},
-		{
-			name:   "GIFNumColors(500) clamps to 256",
-			option: GIFNumColors(500),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.gifNumColors, 256 },
-		},
-		{
-			name:   "GIFNumColors(128) passes through",
-			option: GIFNumColors(128),
-			check:  func(cfg encodeConfig) (int, int) { return cfg.gifNumColors, 128 },
-		},
+		{"JPEGQuality(0)", JPEG, JPEGQuality(0), true},
+		{"JPEGQuality(200)", JPEG, JPEGQuality(200), true},
+		{"JPEGQuality(50)", JPEG, JPEGQuality(50), false},
+		{"WebPQuality(-5)", WEBP, WebPQuality(-5), true},
+		{"WebPQuality(150)", WEBP, WebPQuality(150), true},
+		{"WebPQuality(0)", WEBP, WebPQuality(0), false},
+		{"WebPEffort(-1)", WEBP, WebPEffort(-1), true},
+		{"WebPEffort(7)", WEBP, WebPEffort(7), true},
+		{"WebPEffort(0)", WEBP, WebPEffort(0), false},
+		{"GIFNumColors(0)", GIF, GIFNumColors(0), true},
+		{"GIFNumColors(500)", GIF, GIFNumColors(500), true},
+		{"GIFNumColors(128)", GIF, GIFNumColors(128), false},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			cfg := defaultEncodeConfig
-			tc.option(&cfg)
-			got, want := tc.check(cfg)
-			if got != want {
-				t.Errorf("got %d, want %d", got, want)
+			err := Encode(io.Discard, img, tc.format, tc.option)
+			if !tc.wantErr {
+				if err != nil {
+					t.Errorf("Encode: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("Encode error = %v, want a *ValidationError", err)
 			}
 		})
 	}
 
-	_ = img
+	t.Run("empty image", func(t *testing.T) {
+		err := Encode(io.Discard, &image.NRGBA{}, PNG)
+		if !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Encode of an empty image = %v, want ErrInvalidParameter", err)
+		}
+	})
 }
 
 func TestSaveCleanupOnError(t *testing.T) {
@@ -557,3 +598,102 @@ func TestSaveCleanupOnError(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeTIFFBogusOffset(t *testing.T) {
+	// An IFD offset near 4GB made x/image/tiff buffer the stream up to it
+	data := []byte("II*\x00\xf0\xff\xff\xff\x00\x00")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := Decode(bytes.NewReader(data)); err == nil {
+		t.Error("Decode of a truncated TIFF should fail")
+	}
+	if _, _, err := imageDecodeConfig(bytes.NewReader(data)); err == nil {
+		t.Error("imageDecodeConfig of a truncated TIFF should fail")
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("decoding a %d-byte TIFF allocated %d bytes", len(data), allocated)
+	}
+}
+
+// fuzzMaxPixels bounds the images decoded by the fuzz targets, so that a
+// header claiming a huge size is not mistaken for a crash
+const fuzzMaxPixels = 1 << 22
+
+// fuzzSeedImages returns a small image encoded in every supported format,
+// as the seed corpus of the decoder fuzz targets
+func fuzzSeedImages(tb testing.TB) [][]byte {
+	tb.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 9, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 9; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 28), uint8(y * 36), 128, uint8(255 - x*y)})
+		}
+	}
+	var seeds [][]byte
+	for _, format := range []Format{JPEG, PNG, GIF, TIFF, BMP, WEBP} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, format); err != nil {
+			tb.Fatalf("Encode(%v): %v", format, err)
+		}
+		seeds = append(seeds, buf.Bytes())
+	}
+	seeds = append(seeds, withEXIF(encodeTestJPEG(tb, 16, 8, false), 6))
+	seeds = append(seeds, buildPSD(psdSpec{width: 5, height: 3, mode: 3, channels: 4, rle: true}))
+	return seeds
+}
+
+func FuzzReadOrientation(f *testing.F) {
+	jpg := encodeTestJPEG(f, 16, 8, false)
+	for o := 1; o <= 8; o++ {
+		f.Add(withEXIF(jpg, o))
+	}
+	f.Add(jpg)
+	f.Add([]byte("\xff\xd8\xff\xe1\x00\x08Exif\x00\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		o := readOrientation(bytes.NewReader(data))
+		if o > 8 {
+			t.Errorf("readOrientation = %d, want 0-8", o)
+		}
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, seed := range fuzzSeedImages(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, _, err := imageDecodeConfig(bytes.NewReader(data))
+		if err != nil || cfg.Width*cfg.Height > fuzzMaxPixels {
+			return
+		}
+		img, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// GIF frames may be smaller than the logical screen
+		if b := img.Bounds(); b.Dx()*b.Dy() > cfg.Width*cfg.Height {
+			t.Errorf("Decode size %v, larger than DecodeConfig %dx%d", b.Size(), cfg.Width, cfg.Height)
+		}
+	})
+}
+
+// BenchmarkEncodeWebP measures the speed/size trade-off of the WebP efforts.
+// The "bytes" metric is the size of the encoded image.
+func BenchmarkEncodeWebP(b *testing.B) {
+	img := testdataBranchJPG.ToNRGBA()
+	for _, effort := range []int{0, 2, 4, 6} {
+		b.Run(fmt.Sprintf("effort %d", effort), func(b *testing.B) {
+			var buf bytes.Buffer
+			for b.Loop() {
+				buf.Reset()
+				if err := Encode(&buf, img, WEBP, WebPQuality(80), WebPEffort(effort)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "bytes")
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+)
+
+// QuantizationTable is a JPEG quantization table: the divisors of the 64
+// DCT coefficients of an 8x8 block, in natural (row-major) order. Larger
+// divisors discard more detail.
+type QuantizationTable struct {
+	ID     int        `json:"id"` // Table slot 0-3; 0 is usually luminance, 1 chrominance
+	Values [64]uint16 `json:"values"`
+}
+
+// ijgQuantTables are the luminance and chrominance tables of the JPEG spec
+// (Annex K) in zig-zag order, which libjpeg, image/jpeg and most encoders
+// scale by the quality setting.
+var ijgQuantTables = [2][64]uint16{
+	{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	},
+	{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+// EstimateJPEGQuality returns the quality (1-100) the JPEG file at path was
+// most likely encoded with, inferred from its quantization tables. Files
+// written by libjpeg-based encoders, including image/jpeg and imgx, yield
+// their exact quality setting; for cameras and editors with their own
+// tables it is the closest libjpeg quality.
+//
+// Re-encoding above the quality of the source adds bytes but no detail, so
+// SaveWithResult warns about it (WarnQualityInflated).
+//
+// Example:
+//
+//	q, err := imgx.EstimateJPEGQuality("photo.jpg")
+//	if err == nil && q < 90 {
+//		err = img.Save("out.jpg", imgx.WithJPEGQuality(q))
+//	}
+func EstimateJPEGQuality(path string) (int, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	// The tables precede the scan, after at most 64KB segments of metadata
+	head, err := io.ReadAll(io.LimitReader(f, 256<<10))
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.HasPrefix(head, []byte{0xff, 0xd8}) {
+		return 0, fmt.Errorf("%w: %s is not a JPEG file", ErrUnsupportedFormat, filepath.Base(path))
+	}
+	tables := readJPEGHeader(head).quantTables
+	if len(tables) == 0 {
+		return 0, errors.New("imgx: JPEG has no quantization tables")
+	}
+	return estimateJPEGQuality(tables), nil
+}
+
+// estimateJPEGQuality returns the libjpeg quality whose scaled tables are
+// closest to tables 0 (luminance) and 1 (chrominance), or 0 if there are
+// none. Equally close qualities resolve to the highest, so that a save is
+// not flagged for exceeding a quality the source might have had.
+func estimateJPEGQuality(tables []QuantizationTable) int {
+	if !slices.ContainsFunc(tables, func(t QuantizationTable) bool { return t.ID <= 1 }) {
+		return 0
+	}
+	best, bestErr := 0, -1
+	for q := 100; q >= 1; q-- {
+		scale := 200 - 2*q
+		if q < 50 {
+			scale = 5000 / q
+		}
+		diff := 0
+		for _, t := range tables {
+			if t.ID > 1 {
+				continue
+			}
+			for k, v := range ijgQuantTables[t.ID] {
+				want := min(max((int(v)*scale+50)/100, 1), 255)
+				d := int(t.Values[jpegUnzig[k]]) - want
+				diff += max(d, -d)
+			}
+		}
+		if bestErr < 0 || diff < bestErr {
+			best, bestErr = q, diff
+		}
+	}
+	return best
+}
+
+// parseJPEGQuantTables parses the tables of a DQT segment, which stores them
+// in zig-zag order. Malformed tables end the parsing.
+func parseJPEGQuantTables(seg []byte) []QuantizationTable {
+	var tables []QuantizationTable
+	for len(seg) > 0 {
+		pq, tq := seg[0]>>4, seg[0]&0x0f
+		size := 64 * (1 + int(pq))
+		if pq > 1 || tq > 3 || len(seg) < 1+size {
+			break
+		}
+		t := QuantizationTable{ID: int(tq)}
+		for k := 0; k < 64; k++ {
+			if pq == 0 {
+				t.Values[jpegUnzig[k]] = uint16(seg[1+k])
+			} else {
+				t.Values[jpegUnzig[k]] = binary.BigEndian.Uint16(seg[1+2*k:])
+			}
+		}
+		tables = append(tables, t)
+		seg = seg[1+size:]
+	}
+	return tables
+}
@@ -0,0 +1,105 @@
+package imgx
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestJPEG writes a gradient encoded at the given quality and returns
+// its path
+func writeTestJPEG(t *testing.T, dir string, quality int) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 8), uint8(y * 8), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "q.jpg")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEstimateJPEGQuality(t *testing.T) {
+	dir := t.TempDir()
+	for _, quality := range []int{10, 50, 75, 90, 95, 100} {
+		got, err := EstimateJPEGQuality(writeTestJPEG(t, dir, quality))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != quality {
+			t.Errorf("EstimateJPEGQuality(quality %d) = %d", quality, got)
+		}
+	}
+
+	png := filepath.Join(dir, "image.png")
+	if err := NewImage(4, 4, color.White).Save(png); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EstimateJPEGQuality(png); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("EstimateJPEGQuality(png) error = %v, want ErrUnsupportedFormat", err)
+	}
+
+	// The tables are exposed in natural order: the DC divisor of the
+	// quality 50 luminance table is 16, the last AC one 99
+	meta, err := Metadata(writeTestJPEG(t, dir, 50), WithBasicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.JPEGQuality != 50 || len(meta.QuantizationTables) != 2 {
+		t.Fatalf("metadata quality %d, %d tables", meta.JPEGQuality, len(meta.QuantizationTables))
+	}
+	if lum := meta.QuantizationTables[0]; lum.ID != 0 || lum.Values[0] != 16 || lum.Values[1] != 11 || lum.Values[8] != 12 || lum.Values[63] != 99 {
+		t.Errorf("luminance table = %+v", lum)
+	}
+}
+
+func TestSaveQualityInflated(t *testing.T) {
+	dir := t.TempDir()
+	img, err := Load(writeTestJPEG(t, dir, 75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.GetMetadata().File.JPEGQuality != 75 {
+		t.Errorf("file metadata quality = %d, want 75", img.GetMetadata().File.JPEGQuality)
+	}
+
+	out := filepath.Join(dir, "out.jpg")
+	result, err := img.SaveWithResult(out, WithoutMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.HasWarning(WarnQualityInflated) {
+		t.Errorf("saving a quality 75 JPEG at %d: warnings = %v, want %s", DefaultJPEGQuality, result.Warnings, WarnQualityInflated)
+	}
+
+	for _, quality := range []int{75, 60} {
+		result, err = img.SaveWithResult(out, WithoutMetadata(), WithJPEGQuality(quality), Strict())
+		if err != nil {
+			t.Fatalf("saving at quality %d: %v", quality, err)
+		}
+		if result.HasWarning(WarnQualityInflated) {
+			t.Errorf("saving at quality %d: warnings = %v", quality, result.Warnings)
+		}
+	}
+	// PNG output is not affected
+	if result, err = img.SaveWithResult(filepath.Join(dir, "out.png"), WithoutMetadata()); err != nil || result.HasWarning(WarnQualityInflated) {
+		t.Errorf("saving as PNG: %v, %v", err, result)
+	}
+
+	if _, err := img.SaveWithResult(out, WithoutMetadata(), WithJPEGQuality(90), Strict()); !errors.Is(err, ErrFidelityLoss) {
+		t.Errorf("strict save at quality 90 error = %v, want ErrFidelityLoss", err)
+	}
+}
@@ -1,8 +1,10 @@
 package imgx
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"io"
 )
 
 // Version, Author, and ProjectURL are defined in version.go
@@ -18,6 +20,14 @@ type Options struct {
 	// Author sets a custom artist/creator name for the image metadata
 	// Empty string uses the default author
 	Author string
+
+	// Decode holds extra decode options, e.g. WithIgnoreOrientation,
+	// WithBackground or WithMemoryLimit. The image is still stored as 8-bit NRGBA, so WithGray16
+	// yields a grayscale image.
+	Decode []DecodeOption
+
+	// Profile enables profiling from the decoding on (see Image.Profile)
+	Profile bool
 }
 
 // Load loads an image from a file path and returns an Image instance
@@ -27,23 +37,59 @@ type Options struct {
 //   img, err := imgx.Load("photo.jpg")  // use defaults
 //   img, err := imgx.Load("photo.jpg", imgx.Options{AutoOrient: true})
 //   img, err := imgx.Load("photo.jpg", imgx.Options{Author: "John Doe"})
+//   img, err := imgx.Load("logo.png", imgx.Options{Decode: []imgx.DecodeOption{imgx.WithBackground(color.White)}})
 func Load(path string, opts ...Options) (*Image, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return decodeImage(data, path, opts...)
+}
+
+// DecodeWithMetadata decodes an image from r like Load, keeping the file
+// metadata (format, EXIF, orientation) available through FileMetadata.
+// Use it when images arrive as bytes or streams rather than files.
+//
+// Examples:
+//   img, err := imgx.DecodeWithMetadata(req.Body)
+//   img, err := imgx.DecodeWithMetadata(bytes.NewReader(data), imgx.Options{AutoOrient: true})
+func DecodeWithMetadata(r io.Reader, opts ...Options) (*Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeImage(data, "", opts...)
+}
+
+// decodeImage decodes an encoded image read from path ("" for readers)
+func decodeImage(data []byte, path string, opts ...Options) (*Image, error) {
 	// Use defaults if no opts provided
 	var opt Options
 	if len(opts) > 0 {
 		opt = opts[0]
 	}
 
-	// Use internal open() function
 	var decodeOpts []DecodeOption
 	if opt.AutoOrient {
 		decodeOpts = append(decodeOpts, AutoOrientation(true))
 	}
+	decodeOpts = append(decodeOpts, opt.Decode...)
+	decodeOpts = append(decodeOpts, func(c *decodeConfig) { c.nrgba = true })
 
-	data, err := open(path, decodeOpts...)
+	var prof *profile
+	if opt.Profile {
+		prof = newProfile(image.Point{})
+	}
+	decoded, err := Decode(bytes.NewReader(data), decodeOpts...)
 	if err != nil {
 		return nil, err
 	}
+	nrgba := toNRGBA(decoded)
 
 	// Determine author - priority: per-image option > global config > default
 	author := Author
@@ -53,17 +99,20 @@ func Load(path string, opts ...Options) (*Image, error) {
 		author = globalAuthor
 	}
 
-	return &Image{
-		data: toNRGBA(data),
-		metadata: &ProcessingMetadata{
-			SourcePath:  path,
-			Software:    "imgx",
-			Version:     Version,
-			Author:      author,
-			ProjectURL:  ProjectURL,
-			AddMetadata: !opt.DisableMetadata && globalConfig.AddMetadata,
-		},
-	}, nil
+	meta := &ProcessingMetadata{
+		SourcePath:  path,
+		Software:    "imgx",
+		Version:     Version,
+		Author:      author,
+		ProjectURL:  ProjectURL,
+		AddMetadata: !opt.DisableMetadata && globalConfig.AddMetadata,
+		File:        readFileMetadata(data),
+		profile:     prof,
+	}
+	if prof != nil {
+		prof.record(meta, "decode", nrgba.Bounds().Size())
+	}
+	return &Image{data: nrgba, metadata: meta}, nil
 }
 
 // FromImage creates an Image instance from an existing image.Image
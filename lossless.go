@@ -0,0 +1,1001 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// JPEGTransform identifies a transform that can be applied to a baseline JPEG
+// directly on its DCT coefficients, without decoding to pixels and re-encoding.
+type JPEGTransform int
+
+// Lossless JPEG transforms. They match the semantics of the pixel-based
+// functions of the same name (FlipH, Rotate90, ...).
+const (
+	JPEGIdentity JPEGTransform = iota
+	JPEGFlipH
+	JPEGFlipV
+	JPEGTranspose
+	JPEGTransverse
+	JPEGRotate90
+	JPEGRotate180
+	JPEGRotate270
+)
+
+var jpegTransformNames = map[JPEGTransform]string{
+	JPEGIdentity:   "identity",
+	JPEGFlipH:      "fliph",
+	JPEGFlipV:      "flipv",
+	JPEGTranspose:  "transpose",
+	JPEGTransverse: "transverse",
+	JPEGRotate90:   "rotate90",
+	JPEGRotate180:  "rotate180",
+	JPEGRotate270:  "rotate270",
+}
+
+func (t JPEGTransform) String() string {
+	if name, ok := jpegTransformNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ErrNotLossless is returned by the lossless JPEG functions when the requested
+// operation cannot be performed without recompression, e.g. because the image
+// dimensions are not a multiple of the MCU size or the JPEG is progressive.
+// Callers are expected to fall back to the pixel-based path.
+var ErrNotLossless = errors.New("imgx: lossless JPEG transform not possible")
+
+// TransformJPEG reads a baseline JPEG from r, applies t to its DCT
+// coefficients and writes the result to w. Quantization tables and APPn/COM
+// segments (EXIF, ICC profiles, XMP) are carried over untouched, so no
+// generation loss occurs.
+//
+// Flips along an axis require the image size along that axis to be a multiple
+// of the MCU size (8 or 16 pixels depending on chroma subsampling); otherwise
+// ErrNotLossless is returned.
+//
+// Example:
+//
+//	err := imgx.TransformJPEG(in, out, imgx.JPEGRotate90)
+//	if errors.Is(err, imgx.ErrNotLossless) {
+//		// fall back to Load + Rotate90 + Save
+//	}
+func TransformJPEG(r io.Reader, w io.Writer, t JPEGTransform) error {
+	j, err := readJPEGCoefficients(r)
+	if err != nil {
+		return err
+	}
+	if err := j.transform(t); err != nil {
+		return err
+	}
+	return j.write(w)
+}
+
+// CropJPEG losslessly crops a baseline JPEG to rect. The top-left corner of
+// rect must lie on an MCU boundary; otherwise ErrNotLossless is returned.
+// The rectangle is clipped to the image bounds.
+func CropJPEG(r io.Reader, w io.Writer, rect image.Rectangle) error {
+	j, err := readJPEGCoefficients(r)
+	if err != nil {
+		return err
+	}
+	if err := j.crop(rect); err != nil {
+		return err
+	}
+	return j.write(w)
+}
+
+// JPEGMCUSize returns the size in pixels of the minimum coded unit of the
+// JPEG read from r. Lossless operations are exact only on multiples of it.
+func JPEGMCUSize(r io.Reader) (width, height int, err error) {
+	j, err := readJPEGCoefficients(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return 8 * j.hmax, 8 * j.vmax, nil
+}
+
+// jpegUnzig maps a zig-zag index to its natural (row-major) block index.
+var jpegUnzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// jpegZig is the inverse of jpegUnzig.
+var jpegZig [64]int
+
+func init() {
+	for k, n := range jpegUnzig {
+		jpegZig[n] = k
+	}
+}
+
+// jpegBlock holds the 64 quantized DCT coefficients of an 8x8 block in
+// zig-zag order. The DC coefficient is stored as an absolute value.
+type jpegBlock [64]int32
+
+type jpegComponent struct {
+	id     uint8
+	h, v   int // sampling factors
+	tq     uint8
+	bw, bh int // block grid size, padded to whole MCUs
+	blocks []jpegBlock
+}
+
+// jpegCoefficients is a JPEG decoded down to its quantized DCT coefficients.
+type jpegCoefficients struct {
+	width, height int
+	hmax, vmax    int
+	comps         []*jpegComponent
+	qt            [4][64]uint16
+	qtPrec        [4]uint8
+	qtSet         [4]bool
+	segments      [][]byte // raw APPn and COM segments, marker included
+
+	dcTables [4]*jpegHuffDecoder
+	acTables [4]*jpegHuffDecoder
+	restart  int
+}
+
+func (j *jpegCoefficients) mcus() (int, int) {
+	return (j.width + 8*j.hmax - 1) / (8 * j.hmax), (j.height + 8*j.vmax - 1) / (8 * j.vmax)
+}
+
+func notLossless(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrNotLossless, fmt.Sprintf(format, args...))
+}
+
+func readJPEGCoefficients(r io.Reader) (*jpegCoefficients, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, notLossless("not a JPEG file")
+	}
+
+	j := &jpegCoefficients{}
+	pos := 2
+	for {
+		// Find the next marker, skipping any fill bytes.
+		for pos < len(data) && data[pos] != 0xff {
+			pos++
+		}
+		for pos < len(data) && data[pos] == 0xff {
+			pos++
+		}
+		if pos >= len(data) {
+			return nil, errors.New("imgx: unexpected end of JPEG data")
+		}
+		marker := data[pos]
+		pos++
+
+		if marker == 0xd9 { // EOI
+			break
+		}
+		if marker >= 0xd0 && marker <= 0xd7 { // stray RSTn
+			continue
+		}
+		if pos+2 > len(data) {
+			return nil, errors.New("imgx: unexpected end of JPEG data")
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			return nil, errors.New("imgx: invalid JPEG segment length")
+		}
+		seg := data[pos+2 : pos+length]
+
+		switch {
+		case marker >= 0xe0 && marker <= 0xef, marker == 0xfe:
+			j.segments = append(j.segments, data[pos-2:pos+length])
+		case marker == 0xdb:
+			if err := j.parseDQT(seg); err != nil {
+				return nil, err
+			}
+		case marker == 0xc4:
+			if err := j.parseDHT(seg); err != nil {
+				return nil, err
+			}
+		case marker == 0xc0 || marker == 0xc1:
+			if err := j.parseSOF(seg, len(data)-pos-length); err != nil {
+				return nil, err
+			}
+		case marker >= 0xc2 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc:
+			return nil, notLossless("only baseline Huffman-coded JPEGs are supported")
+		case marker == 0xdd:
+			if len(seg) < 2 {
+				return nil, errors.New("imgx: invalid JPEG DRI segment")
+			}
+			j.restart = int(binary.BigEndian.Uint16(seg))
+		case marker == 0xda:
+			end, err := j.decodeScan(data, pos+length, seg)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			continue
+		}
+		pos += length
+	}
+
+	if j.comps == nil {
+		return nil, errors.New("imgx: JPEG has no frame header")
+	}
+	return j, nil
+}
+
+func (j *jpegCoefficients) parseDQT(seg []byte) error {
+	for len(seg) > 0 {
+		pq, tq := seg[0]>>4, seg[0]&0x0f
+		if tq > 3 || pq > 1 {
+			return errors.New("imgx: invalid JPEG quantization table")
+		}
+		size := 64 * (1 + int(pq))
+		if len(seg) < 1+size {
+			return errors.New("imgx: invalid JPEG quantization table")
+		}
+		for k := 0; k < 64; k++ {
+			if pq == 0 {
+				j.qt[tq][k] = uint16(seg[1+k])
+			} else {
+				j.qt[tq][k] = binary.BigEndian.Uint16(seg[1+2*k:])
+			}
+		}
+		j.qtPrec[tq] = pq
+		j.qtSet[tq] = true
+		seg = seg[1+size:]
+	}
+	return nil
+}
+
+func (j *jpegCoefficients) parseDHT(seg []byte) error {
+	for len(seg) > 0 {
+		if len(seg) < 17 {
+			return errors.New("imgx: invalid JPEG Huffman table")
+		}
+		tc, th := seg[0]>>4, seg[0]&0x0f
+		if tc > 1 || th > 3 {
+			return errors.New("imgx: invalid JPEG Huffman table")
+		}
+		var counts [16]int
+		total := 0
+		for i := range counts {
+			counts[i] = int(seg[1+i])
+			total += counts[i]
+		}
+		if len(seg) < 17+total {
+			return errors.New("imgx: invalid JPEG Huffman table")
+		}
+		dec := newJPEGHuffDecoder(counts, seg[17:17+total])
+		if tc == 0 {
+			j.dcTables[th] = dec
+		} else {
+			j.acTables[th] = dec
+		}
+		seg = seg[17+total:]
+	}
+	return nil
+}
+
+// parseSOF reads the frame header and allocates the coefficients of its
+// components. avail is the number of bytes after the header, which bounds
+// the number of blocks the scans can code.
+func (j *jpegCoefficients) parseSOF(seg []byte, avail int) error {
+	if j.comps != nil {
+		return errors.New("imgx: multiple JPEG frame headers")
+	}
+	if len(seg) < 6 {
+		return errors.New("imgx: invalid JPEG frame header")
+	}
+	if seg[0] != 8 {
+		return notLossless("unsupported sample precision %d", seg[0])
+	}
+	j.height = int(binary.BigEndian.Uint16(seg[1:]))
+	j.width = int(binary.BigEndian.Uint16(seg[3:]))
+	n := int(seg[5])
+	if j.width == 0 || j.height == 0 {
+		return notLossless("JPEG height is defined by a DNL marker")
+	}
+	if n < 1 || n > 4 || len(seg) < 6+3*n {
+		return errors.New("imgx: invalid JPEG frame header")
+	}
+	j.hmax, j.vmax = 1, 1
+	for i := 0; i < n; i++ {
+		c := &jpegComponent{
+			id: seg[6+3*i],
+			h:  int(seg[7+3*i] >> 4),
+			v:  int(seg[7+3*i] & 0x0f),
+			tq: seg[8+3*i],
+		}
+		if c.h < 1 || c.h > 4 || c.v < 1 || c.v > 4 || c.tq > 3 {
+			return errors.New("imgx: invalid JPEG frame header")
+		}
+		j.hmax = max(j.hmax, c.h)
+		j.vmax = max(j.vmax, c.v)
+		j.comps = append(j.comps, c)
+	}
+	// Every coded block takes at least 2 bits (a DC code and an EOB), and
+	// the padding to whole MCUs adds at most h*v <= 16 blocks per coded
+	// block, so more than 64 blocks per byte of data left cannot be a valid
+	// JPEG. Check before allocating: a forged header in a tiny file can
+	// declare 65535x65535 pixels.
+	mx, my := j.mcus()
+	blocks := 0
+	for _, c := range j.comps {
+		blocks += mx * c.h * my * c.v
+	}
+	if blocks > 64*avail {
+		return fmt.Errorf("imgx: JPEG frame of %dx%d pixels does not fit its %d bytes of data", j.width, j.height, avail)
+	}
+	for _, c := range j.comps {
+		c.bw, c.bh = mx*c.h, my*c.v
+		c.blocks = make([]jpegBlock, c.bw*c.bh)
+	}
+	return nil
+}
+
+// scanBlocks calls fn for every block of the scan covering comps, in the
+// order they appear in the entropy-coded data. newMCU is true for the first
+// block of each MCU.
+func (j *jpegCoefficients) scanBlocks(comps []int, fn func(ci int, b *jpegBlock, newMCU bool) error) error {
+	if len(comps) == 1 {
+		// Non-interleaved scans only cover the blocks inside the image.
+		c := j.comps[comps[0]]
+		cw := (j.width*c.h + j.hmax - 1) / j.hmax
+		ch := (j.height*c.v + j.vmax - 1) / j.vmax
+		bw, bh := (cw+7)/8, (ch+7)/8
+		for by := 0; by < bh; by++ {
+			for bx := 0; bx < bw; bx++ {
+				if err := fn(comps[0], &c.blocks[by*c.bw+bx], true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	mx, my := j.mcus()
+	for y := 0; y < my; y++ {
+		for x := 0; x < mx; x++ {
+			first := true
+			for _, ci := range comps {
+				c := j.comps[ci]
+				for v := 0; v < c.v; v++ {
+					for h := 0; h < c.h; h++ {
+						b := &c.blocks[(y*c.v+v)*c.bw+x*c.h+h]
+						if err := fn(ci, b, first); err != nil {
+							return err
+						}
+						first = false
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// decodeScan decodes the entropy-coded segment following an SOS header and
+// returns the position right after it.
+func (j *jpegCoefficients) decodeScan(data []byte, pos int, hdr []byte) (int, error) {
+	if j.comps == nil {
+		return 0, errors.New("imgx: JPEG scan before frame header")
+	}
+	if len(hdr) < 1 {
+		return 0, errors.New("imgx: invalid JPEG scan header")
+	}
+	n := int(hdr[0])
+	if n < 1 || n > len(j.comps) || len(hdr) < 1+2*n+3 {
+		return 0, errors.New("imgx: invalid JPEG scan header")
+	}
+
+	comps := make([]int, n)
+	dc := make([]*jpegHuffDecoder, len(j.comps))
+	ac := make([]*jpegHuffDecoder, len(j.comps))
+	for i := 0; i < n; i++ {
+		id := hdr[1+2*i]
+		ci := -1
+		for k, c := range j.comps {
+			if c.id == id {
+				ci = k
+			}
+		}
+		if ci < 0 {
+			return 0, errors.New("imgx: JPEG scan references unknown component")
+		}
+		td, ta := hdr[2+2*i]>>4, hdr[2+2*i]&0x0f
+		if td > 3 || ta > 3 || j.dcTables[td] == nil || j.acTables[ta] == nil {
+			return 0, errors.New("imgx: JPEG scan references missing Huffman table")
+		}
+		comps[i] = ci
+		dc[ci], ac[ci] = j.dcTables[td], j.acTables[ta]
+	}
+
+	br := &jpegBitReader{data: data, pos: pos}
+	pred := make([]int32, len(j.comps))
+	mcu := 0
+	err := j.scanBlocks(comps, func(ci int, b *jpegBlock, newMCU bool) error {
+		if newMCU {
+			if j.restart > 0 && mcu > 0 && mcu%j.restart == 0 {
+				if err := br.restart(); err != nil {
+					return err
+				}
+				clear(pred)
+			}
+			mcu++
+		}
+
+		s, err := dc[ci].decode(br)
+		if err != nil {
+			return err
+		}
+		diff, err := br.receiveExtend(s)
+		if err != nil {
+			return err
+		}
+		pred[ci] += diff
+		b[0] = pred[ci]
+
+		for k := 1; k < 64; k++ {
+			rs, err := ac[ci].decode(br)
+			if err != nil {
+				return err
+			}
+			r, s := int(rs>>4), rs&0x0f
+			if s == 0 {
+				if r != 15 {
+					break // EOB
+				}
+				k += 15 // ZRL
+				continue
+			}
+			k += r
+			if k > 63 {
+				return errors.New("imgx: corrupt JPEG data")
+			}
+			v, err := br.receiveExtend(s)
+			if err != nil {
+				return err
+			}
+			b[k] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return br.pos, nil
+}
+
+// transform applies t to the coefficient data in place.
+func (j *jpegCoefficients) transform(t JPEGTransform) error {
+	var transpose, flipX, flipY bool
+	switch t {
+	case JPEGIdentity:
+	case JPEGFlipH:
+		flipX = true
+	case JPEGFlipV:
+		flipY = true
+	case JPEGRotate180:
+		flipX, flipY = true, true
+	case JPEGTranspose:
+		transpose = true
+	case JPEGRotate90:
+		transpose, flipY = true, true
+	case JPEGRotate270:
+		transpose, flipX = true, true
+	case JPEGTransverse:
+		transpose, flipX, flipY = true, true, true
+	default:
+		return fmt.Errorf("imgx: unknown JPEG transform %d", t)
+	}
+
+	width, height, hmax, vmax := j.width, j.height, j.hmax, j.vmax
+	if transpose {
+		width, height, hmax, vmax = height, width, vmax, hmax
+	}
+	if flipX && width%(8*hmax) != 0 {
+		return notLossless("width %d is not a multiple of the %dpx MCU", width, 8*hmax)
+	}
+	if flipY && height%(8*vmax) != 0 {
+		return notLossless("height %d is not a multiple of the %dpx MCU", height, 8*vmax)
+	}
+
+	// Build the coefficient permutation and sign flips. A horizontal flip
+	// negates odd horizontal frequencies, a vertical flip odd vertical ones.
+	var dst [64]int
+	var neg [64]bool
+	for k := 0; k < 64; k++ {
+		n := jpegUnzig[k]
+		v, u := n/8, n%8
+		if transpose {
+			v, u = u, v
+		}
+		dst[k] = jpegZig[v*8+u]
+		neg[k] = (flipX && u%2 == 1) != (flipY && v%2 == 1)
+	}
+
+	for _, c := range j.comps {
+		bw, bh := c.bw, c.bh
+		if transpose {
+			bw, bh = bh, bw
+		}
+		blocks := make([]jpegBlock, bw*bh)
+		for y := 0; y < c.bh; y++ {
+			for x := 0; x < c.bw; x++ {
+				nx, ny := x, y
+				if transpose {
+					nx, ny = y, x
+				}
+				if flipX {
+					nx = bw - 1 - nx
+				}
+				if flipY {
+					ny = bh - 1 - ny
+				}
+				src := &c.blocks[y*c.bw+x]
+				out := &blocks[ny*bw+nx]
+				for k := 0; k < 64; k++ {
+					if neg[k] {
+						out[dst[k]] = -src[k]
+					} else {
+						out[dst[k]] = src[k]
+					}
+				}
+			}
+		}
+		c.blocks, c.bw, c.bh = blocks, bw, bh
+		if transpose {
+			c.h, c.v = c.v, c.h
+		}
+	}
+
+	if transpose {
+		for i := range j.qt {
+			var q [64]uint16
+			for k := 0; k < 64; k++ {
+				n := jpegUnzig[k]
+				q[jpegZig[(n%8)*8+n/8]] = j.qt[i][k]
+			}
+			j.qt[i] = q
+		}
+	}
+	j.width, j.height, j.hmax, j.vmax = width, height, hmax, vmax
+	return nil
+}
+
+// crop restricts the coefficient data to rect in place.
+func (j *jpegCoefficients) crop(rect image.Rectangle) error {
+	rect = rect.Intersect(image.Rect(0, 0, j.width, j.height))
+	if rect.Empty() {
+		return errors.New("imgx: crop rectangle is outside the image")
+	}
+	mw, mh := 8*j.hmax, 8*j.vmax
+	if rect.Min.X%mw != 0 || rect.Min.Y%mh != 0 {
+		return notLossless("crop origin (%d,%d) is not aligned to the %dx%d MCU grid", rect.Min.X, rect.Min.Y, mw, mh)
+	}
+
+	j.width, j.height = rect.Dx(), rect.Dy()
+	mx, my := j.mcus()
+	ox, oy := rect.Min.X/mw, rect.Min.Y/mh
+	for _, c := range j.comps {
+		bw, bh := mx*c.h, my*c.v
+		blocks := make([]jpegBlock, bw*bh)
+		for y := 0; y < bh; y++ {
+			copy(blocks[y*bw:(y+1)*bw], c.blocks[(oy*c.v+y)*c.bw+ox*c.h:])
+		}
+		c.blocks, c.bw, c.bh = blocks, bw, bh
+	}
+	return nil
+}
+
+// write encodes the coefficient data as a baseline JPEG with Huffman tables
+// optimized for the (possibly transformed) coefficients.
+func (j *jpegCoefficients) write(w io.Writer) error {
+	comps := make([]int, len(j.comps))
+	for i := range comps {
+		comps[i] = i
+	}
+	table := func(ci int) int {
+		if ci == 0 {
+			return 0
+		}
+		return 1
+	}
+	ntables := min(len(j.comps), 2)
+
+	// First pass: gather symbol statistics.
+	var dcFreq, acFreq [2][257]int
+	pred := make([]int32, len(j.comps))
+	j.forEachScanSymbol(comps, pred, func(ci int, dc bool, sym uint8, _ int32, _ uint8) {
+		if dc {
+			dcFreq[table(ci)][sym]++
+		} else {
+			acFreq[table(ci)][sym]++
+		}
+	})
+	var dcEnc, acEnc [2]*jpegHuffEncoder
+	for t := 0; t < ntables; t++ {
+		dcEnc[t] = newOptimalJPEGHuffEncoder(dcFreq[t])
+		acEnc[t] = newOptimalJPEGHuffEncoder(acFreq[t])
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	for _, seg := range j.segments {
+		buf.Write(seg)
+	}
+
+	// Quantization tables.
+	extended := false
+	for i := range j.qt {
+		if !j.qtSet[i] {
+			continue
+		}
+		prec := j.qtPrec[i]
+		extended = extended || prec != 0
+		seg := []byte{prec<<4 | uint8(i)}
+		for _, q := range j.qt[i] {
+			if prec == 0 {
+				seg = append(seg, uint8(q))
+			} else {
+				seg = append(seg, uint8(q>>8), uint8(q))
+			}
+		}
+		writeJPEGSegment(&buf, 0xdb, seg)
+	}
+
+	// Frame header.
+	sof := []byte{8, uint8(j.height >> 8), uint8(j.height), uint8(j.width >> 8), uint8(j.width), uint8(len(j.comps))}
+	for _, c := range j.comps {
+		sof = append(sof, c.id, uint8(c.h<<4|c.v), c.tq)
+	}
+	if extended {
+		writeJPEGSegment(&buf, 0xc1, sof)
+	} else {
+		writeJPEGSegment(&buf, 0xc0, sof)
+	}
+
+	// Huffman tables.
+	for t := 0; t < ntables; t++ {
+		writeJPEGSegment(&buf, 0xc4, dcEnc[t].segment(0, t))
+		writeJPEGSegment(&buf, 0xc4, acEnc[t].segment(1, t))
+	}
+
+	// Scan header.
+	sos := []byte{uint8(len(j.comps))}
+	for ci, c := range j.comps {
+		t := uint8(table(ci))
+		sos = append(sos, c.id, t<<4|t)
+	}
+	sos = append(sos, 0, 63, 0)
+	writeJPEGSegment(&buf, 0xda, sos)
+
+	// Second pass: entropy-coded data.
+	bw := &jpegBitWriter{w: &buf}
+	clear(pred)
+	j.forEachScanSymbol(comps, pred, func(ci int, dc bool, sym uint8, extra int32, nbits uint8) {
+		enc := acEnc[table(ci)]
+		if dc {
+			enc = dcEnc[table(ci)]
+		}
+		bw.emit(uint32(enc.code[sym]), enc.size[sym])
+		if nbits > 0 {
+			bw.emit(uint32(extra)&(1<<nbits-1), nbits)
+		}
+	})
+	bw.flush()
+	buf.Write([]byte{0xff, 0xd9})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// forEachScanSymbol walks the blocks of a single scan over comps and reports
+// every Huffman symbol together with the additional bits that follow it.
+func (j *jpegCoefficients) forEachScanSymbol(comps []int, pred []int32, fn func(ci int, dc bool, sym uint8, extra int32, nbits uint8)) {
+	_ = j.scanBlocks(comps, func(ci int, b *jpegBlock, _ bool) error {
+		diff := b[0] - pred[ci]
+		pred[ci] = b[0]
+		s, bits := jpegMagnitude(diff)
+		fn(ci, true, s, bits, s)
+
+		run := 0
+		for k := 1; k < 64; k++ {
+			if b[k] == 0 {
+				run++
+				continue
+			}
+			for run > 15 {
+				fn(ci, false, 0xf0, 0, 0)
+				run -= 16
+			}
+			s, bits := jpegMagnitude(b[k])
+			fn(ci, false, uint8(run<<4)|s, bits, s)
+			run = 0
+		}
+		if run > 0 {
+			fn(ci, false, 0x00, 0, 0)
+		}
+		return nil
+	})
+}
+
+// jpegMagnitude returns the magnitude category of v and the additional bits
+// used to encode it.
+func jpegMagnitude(v int32) (uint8, int32) {
+	a := v
+	if a < 0 {
+		a = -a
+		v--
+	}
+	var s uint8
+	for a > 0 {
+		s++
+		a >>= 1
+	}
+	return s, v
+}
+
+func writeJPEGSegment(buf *bytes.Buffer, marker byte, payload []byte) {
+	n := len(payload) + 2
+	buf.Write([]byte{0xff, marker, uint8(n >> 8), uint8(n)})
+	buf.Write(payload)
+}
+
+// jpegBitReader reads bits from entropy-coded JPEG data, removing byte
+// stuffing. Once a marker is reached it yields zero bits.
+type jpegBitReader struct {
+	data  []byte
+	pos   int
+	acc   uint32
+	n     uint8
+	ended bool
+}
+
+func (br *jpegBitReader) bit() (uint32, error) {
+	if br.n == 0 {
+		if br.pos >= len(br.data) {
+			return 0, errors.New("imgx: unexpected end of JPEG data")
+		}
+		b := br.data[br.pos]
+		switch {
+		case br.ended:
+			b = 0
+		case b == 0xff:
+			if br.pos+1 < len(br.data) && br.data[br.pos+1] == 0x00 {
+				br.pos += 2
+			} else {
+				br.ended = true
+				b = 0
+			}
+		default:
+			br.pos++
+		}
+		br.acc, br.n = uint32(b), 8
+	}
+	br.n--
+	return (br.acc >> br.n) & 1, nil
+}
+
+// receiveExtend reads s additional bits and sign-extends them.
+func (br *jpegBitReader) receiveExtend(s uint8) (int32, error) {
+	if s == 0 {
+		return 0, nil
+	}
+	if s > 16 {
+		return 0, errors.New("imgx: corrupt JPEG data")
+	}
+	var v int32
+	for i := uint8(0); i < s; i++ {
+		b, err := br.bit()
+		if err != nil {
+			return 0, err
+		}
+		v = v<<1 | int32(b)
+	}
+	if v < 1<<(s-1) {
+		v += -1<<s + 1
+	}
+	return v, nil
+}
+
+// restart consumes an RSTn marker.
+func (br *jpegBitReader) restart() error {
+	br.n, br.ended = 0, false
+	if br.pos+1 >= len(br.data) || br.data[br.pos] != 0xff || br.data[br.pos+1] < 0xd0 || br.data[br.pos+1] > 0xd7 {
+		return errors.New("imgx: missing JPEG restart marker")
+	}
+	br.pos += 2
+	return nil
+}
+
+type jpegHuffDecoder struct {
+	maxcode [18]int32
+	valptr  [17]int32
+	mincode [17]int32
+	vals    []uint8
+}
+
+func newJPEGHuffDecoder(counts [16]int, vals []uint8) *jpegHuffDecoder {
+	d := &jpegHuffDecoder{vals: vals}
+	code, k := int32(0), int32(0)
+	for l := 1; l <= 16; l++ {
+		n := int32(counts[l-1])
+		if n == 0 {
+			d.maxcode[l] = -1
+		} else {
+			d.valptr[l] = k
+			d.mincode[l] = code
+			code += n
+			k += n
+			d.maxcode[l] = code - 1
+		}
+		code <<= 1
+	}
+	d.maxcode[17] = 1 << 30
+	return d
+}
+
+func (d *jpegHuffDecoder) decode(br *jpegBitReader) (uint8, error) {
+	var code int32
+	for l := 1; l <= 16; l++ {
+		b, err := br.bit()
+		if err != nil {
+			return 0, err
+		}
+		code = code<<1 | int32(b)
+		if code <= d.maxcode[l] {
+			idx := d.valptr[l] + code - d.mincode[l]
+			if int(idx) >= len(d.vals) {
+				break
+			}
+			return d.vals[idx], nil
+		}
+	}
+	return 0, errors.New("imgx: corrupt JPEG Huffman code")
+}
+
+type jpegHuffEncoder struct {
+	bits [17]uint8 // number of codes of each length
+	vals []uint8
+	code [256]uint16
+	size [256]uint8
+}
+
+// newOptimalJPEGHuffEncoder builds a length-limited Huffman code for the
+// given symbol frequencies (ITU T.81, Annex K.2).
+func newOptimalJPEGHuffEncoder(freq [257]int) *jpegHuffEncoder {
+	var codesize [257]int
+	var others [257]int
+	for i := range others {
+		others[i] = -1
+	}
+	// Reserve one code point so that no real code consists of all ones.
+	freq[256] = 1
+
+	for {
+		c1, c2 := -1, -1
+		for i := 0; i <= 256; i++ {
+			if freq[i] > 0 && (c1 < 0 || freq[i] <= freq[c1]) {
+				c1 = i
+			}
+		}
+		for i := 0; i <= 256; i++ {
+			if freq[i] > 0 && i != c1 && (c2 < 0 || freq[i] <= freq[c2]) {
+				c2 = i
+			}
+		}
+		if c2 < 0 {
+			break
+		}
+		freq[c1] += freq[c2]
+		freq[c2] = 0
+		codesize[c1]++
+		for others[c1] >= 0 {
+			c1 = others[c1]
+			codesize[c1]++
+		}
+		others[c1] = c2
+		codesize[c2]++
+		for others[c2] >= 0 {
+			c2 = others[c2]
+			codesize[c2]++
+		}
+	}
+
+	var bits [33]int
+	for i := 0; i <= 256; i++ {
+		if codesize[i] > 0 {
+			bits[codesize[i]]++
+		}
+	}
+	for i := 32; i > 16; i-- {
+		for bits[i] > 0 {
+			k := i - 2
+			for bits[k] == 0 {
+				k--
+			}
+			bits[i] -= 2
+			bits[i-1]++
+			bits[k+1] += 2
+			bits[k]--
+		}
+	}
+	i := 16
+	for bits[i] == 0 {
+		i--
+	}
+	bits[i]-- // drop the reserved code point
+
+	e := &jpegHuffEncoder{}
+	for l := 1; l <= 16; l++ {
+		e.bits[l] = uint8(bits[l])
+	}
+	for l := 1; l <= 32; l++ {
+		for s := 0; s < 256; s++ {
+			if codesize[s] == l {
+				e.vals = append(e.vals, uint8(s))
+			}
+		}
+	}
+
+	code, k := uint16(0), 0
+	for l := 1; l <= 16; l++ {
+		for n := 0; n < int(e.bits[l]); n++ {
+			s := e.vals[k]
+			e.code[s], e.size[s] = code, uint8(l)
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return e
+}
+
+// segment returns the DHT payload for this table.
+func (e *jpegHuffEncoder) segment(class, id int) []byte {
+	seg := []byte{uint8(class<<4 | id)}
+	seg = append(seg, e.bits[1:]...)
+	return append(seg, e.vals...)
+}
+
+// jpegBitWriter writes entropy-coded data with byte stuffing.
+type jpegBitWriter struct {
+	w   *bytes.Buffer
+	acc uint32
+	n   uint8
+}
+
+func (bw *jpegBitWriter) emit(bits uint32, n uint8) {
+	for i := int(n) - 1; i >= 0; i-- {
+		bw.acc = bw.acc<<1 | (bits>>uint(i))&1
+		bw.n++
+		if bw.n == 8 {
+			b := uint8(bw.acc)
+			bw.w.WriteByte(b)
+			if b == 0xff {
+				bw.w.WriteByte(0)
+			}
+			bw.acc, bw.n = 0, 0
+		}
+	}
+}
+
+// flush pads the last byte with one bits.
+func (bw *jpegBitWriter) flush() {
+	if bw.n > 0 {
+		bw.emit(1<<(8-bw.n)-1, 8-bw.n)
+	}
+}
@@ -0,0 +1,201 @@
+package imgx
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+// encodeTestJPEG encodes a colorful w x h test pattern. The standard library
+// encoder uses 4:2:0 subsampling, i.e. a 16x16 MCU.
+func encodeTestJPEG(t testing.TB, w, h int, gray bool) []byte {
+	t.Helper()
+	var src image.Image
+	if gray {
+		g := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				g.SetGray(x, y, color.Gray{uint8((x*7 + y*3) % 256)})
+			}
+		}
+		src = g
+	} else {
+		m := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				m.SetNRGBA(x, y, color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x + y) % 64 * 4), 255})
+			}
+		}
+		src = m
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decodeTestJPEG(t *testing.T, data []byte) *image.NRGBA {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("jpeg.Decode: %v", err)
+	}
+	return Clone(img)
+}
+
+func TestTransformJPEG(t *testing.T) {
+	testCases := []struct {
+		transform JPEGTransform
+		pixel     func(image.Image) *image.NRGBA
+	}{
+		{JPEGIdentity, Clone},
+		{JPEGFlipH, FlipH},
+		{JPEGFlipV, FlipV},
+		{JPEGTranspose, Transpose},
+		{JPEGTransverse, Transverse},
+		{JPEGRotate90, Rotate90},
+		{JPEGRotate180, Rotate180},
+		{JPEGRotate270, Rotate270},
+	}
+	for _, gray := range []bool{false, true} {
+		src := encodeTestJPEG(t, 64, 48, gray)
+		orig := decodeTestJPEG(t, src)
+		for _, tc := range testCases {
+			t.Run(tc.transform.String(), func(t *testing.T) {
+				var out bytes.Buffer
+				if err := TransformJPEG(bytes.NewReader(src), &out, tc.transform); err != nil {
+					t.Fatalf("TransformJPEG(%v): %v", tc.transform, err)
+				}
+				got := decodeTestJPEG(t, out.Bytes())
+				want := tc.pixel(orig)
+				if !compareNRGBA(got, want, 4) {
+					t.Fatalf("TransformJPEG(%v) differs from the pixel transform", tc.transform)
+				}
+			})
+		}
+	}
+}
+
+func TestTransformJPEGIdentityIsExact(t *testing.T) {
+	src := encodeTestJPEG(t, 40, 30, false)
+	var out bytes.Buffer
+	if err := TransformJPEG(bytes.NewReader(src), &out, JPEGIdentity); err != nil {
+		t.Fatalf("TransformJPEG: %v", err)
+	}
+	if !compareNRGBA(decodeTestJPEG(t, out.Bytes()), decodeTestJPEG(t, src), 0) {
+		t.Fatal("identity transform changed pixel data")
+	}
+}
+
+func TestTransformJPEGNotAligned(t *testing.T) {
+	src := encodeTestJPEG(t, 40, 30, false)
+	testCases := []struct {
+		transform JPEGTransform
+		wantErr   bool
+	}{
+		{JPEGTranspose, false},
+		{JPEGFlipH, true},
+		{JPEGFlipV, true},
+		{JPEGRotate90, true},
+		{JPEGRotate180, true},
+	}
+	for _, tc := range testCases {
+		err := TransformJPEG(bytes.NewReader(src), &bytes.Buffer{}, tc.transform)
+		if got := errors.Is(err, ErrNotLossless); got != tc.wantErr {
+			t.Errorf("TransformJPEG(%v) error = %v, want ErrNotLossless: %v", tc.transform, err, tc.wantErr)
+		}
+	}
+}
+
+func TestTransformJPEGPreservesSegments(t *testing.T) {
+	src := encodeTestJPEG(t, 32, 32, false)
+	comment := []byte{0xff, 0xfe, 0x00, 0x07, 'h', 'e', 'l', 'l', 'o'}
+	withCOM := append(append([]byte{0xff, 0xd8}, comment...), src[2:]...)
+
+	var out bytes.Buffer
+	if err := TransformJPEG(bytes.NewReader(withCOM), &out, JPEGRotate90); err != nil {
+		t.Fatalf("TransformJPEG: %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), comment) {
+		t.Fatal("COM segment was not preserved")
+	}
+}
+
+func TestTransformJPEGUnsupported(t *testing.T) {
+	err := TransformJPEG(bytes.NewReader([]byte("not a jpeg")), &bytes.Buffer{}, JPEGRotate90)
+	if !errors.Is(err, ErrNotLossless) {
+		t.Fatalf("got error %v, want ErrNotLossless", err)
+	}
+}
+
+func TestCropJPEG(t *testing.T) {
+	src := encodeTestJPEG(t, 64, 48, false)
+	orig := decodeTestJPEG(t, src)
+
+	rect := image.Rect(16, 16, 50, 40)
+	var out bytes.Buffer
+	if err := CropJPEG(bytes.NewReader(src), &out, rect); err != nil {
+		t.Fatalf("CropJPEG: %v", err)
+	}
+	got := decodeTestJPEG(t, out.Bytes())
+	if !compareNRGBA(got, Crop(orig, rect), 0) {
+		t.Fatal("CropJPEG differs from the pixel crop")
+	}
+
+	err := CropJPEG(bytes.NewReader(src), &bytes.Buffer{}, image.Rect(3, 0, 20, 20))
+	if !errors.Is(err, ErrNotLossless) {
+		t.Fatalf("unaligned crop: got error %v, want ErrNotLossless", err)
+	}
+}
+
+func TestJPEGMCUSize(t *testing.T) {
+	w, h, err := JPEGMCUSize(bytes.NewReader(encodeTestJPEG(t, 8, 8, false)))
+	if err != nil {
+		t.Fatalf("JPEGMCUSize: %v", err)
+	}
+	if w != 16 || h != 16 {
+		t.Fatalf("JPEGMCUSize = %dx%d, want 16x16", w, h)
+	}
+}
+
+// forgeJPEGSize rewrites the frame size of a JPEG
+func forgeJPEGSize(data []byte, w, h int) []byte {
+	out := bytes.Clone(data)
+	i := bytes.Index(out, []byte{0xff, 0xc0})
+	out[i+5], out[i+6] = byte(h>>8), byte(h)
+	out[i+7], out[i+8] = byte(w>>8), byte(w)
+	return out
+}
+
+func TestTransformJPEGForgedSize(t *testing.T) {
+	// A small file declaring 65520x65520 pixels would need 25 GB of blocks
+	data := forgeJPEGSize(encodeTestJPEG(t, 16, 16, false), 65520, 65520)
+	if len(data) > 1000 {
+		t.Fatalf("test file has %d bytes", len(data))
+	}
+	if err := TransformJPEG(bytes.NewReader(data), io.Discard, JPEGRotate90); err == nil {
+		t.Error("TransformJPEG() of a forged frame size succeeded")
+	}
+	if err := CropJPEG(bytes.NewReader(data), io.Discard, image.Rect(0, 0, 16, 16)); err == nil {
+		t.Error("CropJPEG() of a forged frame size succeeded")
+	}
+	if _, err := NormalizeJPEGOrientation(bytes.NewReader(withEXIF(data, 6)), io.Discard, 90); err == nil {
+		t.Error("NormalizeJPEGOrientation() of a forged frame size succeeded")
+	}
+}
+
+func FuzzTransformJPEG(f *testing.F) {
+	f.Add(encodeTestJPEG(f, 16, 16, false), uint8(JPEGRotate90))
+	f.Add(encodeTestJPEG(f, 24, 16, true), uint8(JPEGFlipH))
+	f.Add(withEXIF(encodeTestJPEG(f, 16, 8, false), 6), uint8(JPEGTransverse))
+
+	f.Fuzz(func(t *testing.T, data []byte, transform uint8) {
+		TransformJPEG(bytes.NewReader(data), io.Discard, JPEGTransform(transform%8))
+		CropJPEG(bytes.NewReader(data), io.Discard, image.Rect(0, 0, 8, 8))
+	})
+}
@@ -0,0 +1,142 @@
+package imgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// MapOptions configures MapImages.
+type MapOptions struct {
+	// Workers is the number of images processed concurrently.
+	// Default is runtime.GOMAXPROCS(0).
+	Workers int
+
+	// FailFast stops starting new images after the first error.
+	FailFast bool
+
+	// Memory, if set, bounds the memory of the images in flight. Each image
+	// holds an estimate of its working set (twice its NRGBA pixels: the
+	// input and the result of the current step) while it is processed;
+	// images wait for memory to be released, and an image larger than the
+	// whole budget fails with a *MemoryLimitError. Share one budget between
+	// concurrent calls to bound them together. Pipelines that enlarge images
+	// should leave headroom.
+	Memory *MemoryBudget
+
+	// OnResult, if set, is called as each image finishes, in completion
+	// order. Calls are serialized, so it may update shared state (progress
+	// bars, counters) without locking.
+	OnResult func(index int, result *Image, err error)
+}
+
+// MapError is the error of one input of MapImages.
+type MapError struct {
+	Index int
+	Err   error
+}
+
+func (e *MapError) Error() string {
+	return fmt.Sprintf("imgx: image %d: %v", e.Index, e.Err)
+}
+
+func (e *MapError) Unwrap() error {
+	return e.Err
+}
+
+// MapImages applies pipeline to every input concurrently and returns the
+// results in input order. Failed inputs (a nil image, a panicking
+// operation) leave a nil result; their errors are joined into the returned
+// error as *MapError values. When ctx is canceled, or after the first error
+// with FailFast, the remaining inputs are skipped with the context error
+// (context.Canceled for FailFast).
+//
+// Example:
+//
+//	frames, err := imgx.MapImages(ctx, frames, imgx.Chain(
+//		imgx.OpResize(640, 0, imgx.Lanczos),
+//		imgx.OpSharpen(0.5),
+//	), imgx.MapOptions{Workers: 4})
+func MapImages(ctx context.Context, inputs []*Image, pipeline Op, opts MapOptions) ([]*Image, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(inputs))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*Image, len(inputs))
+	errs := make([]error, len(inputs))
+	var mu sync.Mutex // serializes OnResult
+	finish := func(i int) {
+		if errs[i] != nil && opts.FailFast {
+			cancel()
+		}
+		if opts.OnResult != nil {
+			mu.Lock()
+			opts.OnResult(i, results[i], errs[i])
+			mu.Unlock()
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					errs[i] = ctx.Err()
+				} else {
+					results[i], errs[i] = applyWithBudget(ctx, inputs[i], pipeline, opts.Memory)
+				}
+				finish(i)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var all []error
+	for i, err := range errs {
+		if err != nil {
+			all = append(all, &MapError{Index: i, Err: err})
+		}
+	}
+	return results, errors.Join(all...)
+}
+
+// applyWithBudget runs applyPipeline holding the memory of img in budget,
+// if any
+func applyWithBudget(ctx context.Context, img *Image, pipeline Op, budget *MemoryBudget) (*Image, error) {
+	if budget == nil || img == nil {
+		return applyPipeline(img, pipeline)
+	}
+	n := pipelineMemory(img)
+	if err := budget.Acquire(ctx, "pipeline", n); err != nil {
+		return nil, err
+	}
+	defer budget.Release(n)
+	return applyPipeline(img, pipeline)
+}
+
+// applyPipeline runs pipeline on img, turning panics into errors
+func applyPipeline(img *Image, pipeline Op) (result *Image, err error) {
+	if img == nil {
+		return nil, errors.New("nil image")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return pipeline(img), nil
+}
@@ -0,0 +1,75 @@
+package imgx
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMapImages(t *testing.T) {
+	var inputs []*Image
+	for i := 1; i <= 20; i++ {
+		inputs = append(inputs, NewImage(i, 10, color.White))
+	}
+
+	var calls int
+	results, err := MapImages(context.Background(), inputs, OpResize(0, 5, Box), MapOptions{
+		Workers:  4,
+		OnResult: func(index int, result *Image, err error) { calls++ }, // serialized, no lock needed
+	})
+	if err != nil {
+		t.Fatalf("MapImages() error = %v", err)
+	}
+	if calls != len(inputs) {
+		t.Errorf("OnResult called %d times, want %d", calls, len(inputs))
+	}
+	for i, r := range results {
+		if want := inputs[i].Resize(0, 5, Box).Bounds(); r.Bounds() != want {
+			t.Errorf("result %d size = %v, want input order", i, r.Bounds().Size())
+		}
+	}
+}
+
+func TestMapImagesErrors(t *testing.T) {
+	inputs := []*Image{NewImage(4, 4, color.White), nil, NewImage(4, 4, color.White)}
+
+	results, err := MapImages(context.Background(), inputs, OpInvert(), MapOptions{})
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) || mapErr.Index != 1 {
+		t.Fatalf("MapImages() error = %v, want a MapError for image 1", err)
+	}
+	if results[0] == nil || results[1] != nil || results[2] == nil {
+		t.Errorf("results = %v, want only the nil input to fail", results)
+	}
+
+	panics := func(img *Image) *Image { panic("boom") }
+	if _, err := MapImages(context.Background(), inputs[:1], panics, MapOptions{}); err == nil {
+		t.Error("MapImages() with a panicking op should fail")
+	}
+
+	// FailFast skips what hasn't started after the first error.
+	var processed atomic.Int32
+	counting := func(img *Image) *Image {
+		processed.Add(1)
+		return img
+	}
+	many := make([]*Image, 50)
+	for i := 1; i < len(many); i++ {
+		many[i] = NewImage(2, 2, color.White)
+	}
+	_, err = MapImages(context.Background(), many, counting, MapOptions{Workers: 1, FailFast: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FailFast error = %v, want skipped images to report context.Canceled", err)
+	}
+	if n := processed.Load(); n != 0 {
+		t.Errorf("FailFast processed %d images after the first error, want 0", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MapImages(ctx, many[1:], counting, MapOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled MapImages() error = %v, want context.Canceled", err)
+	}
+}
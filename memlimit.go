@@ -0,0 +1,178 @@
+package imgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+)
+
+// ErrMemoryLimit matches every *MemoryLimitError with errors.Is.
+var ErrMemoryLimit = errors.New("imgx: memory limit exceeded")
+
+// MemoryLimitError reports an operation whose estimated working set is over
+// the memory limit. It is returned before anything large is allocated.
+//
+// Example:
+//
+//	img, err := imgx.Load(path, imgx.Options{Decode: []imgx.DecodeOption{imgx.WithMemoryLimit(256 << 20)}})
+//	if errors.Is(err, imgx.ErrMemoryLimit) {
+//		http.Error(w, "image too large", http.StatusRequestEntityTooLarge)
+//	}
+type MemoryLimitError struct {
+	Op    string // Operation, e.g. "decode"
+	Need  int64  // Estimated working set in bytes
+	Limit int64  // The limit in bytes
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("imgx: %s needs %s, over the memory limit of %s", e.Op, formatMemory(e.Need), formatMemory(e.Limit))
+}
+
+// Is makes errors.Is(err, ErrMemoryLimit) true.
+func (e *MemoryLimitError) Is(target error) bool {
+	return target == ErrMemoryLimit
+}
+
+// WithMemoryLimit returns a DecodeOption that fails with a *MemoryLimitError
+// when decoding would need more than limit bytes. The size is read from the
+// image header first, so a huge image is rejected without being decoded.
+// The estimate covers the encoded data, the decoded pixels and the copies
+// made by the other decode options. A limit <= 0 disables the check.
+func WithMemoryLimit(limit int64) DecodeOption {
+	return func(c *decodeConfig) {
+		c.memoryLimit = limit
+	}
+}
+
+// memoryNeeded estimates the bytes decoding allocates for an image of cfg
+// read from encoded bytes of data
+func (c *decodeConfig) memoryNeeded(cfg image.Config, encoded int) int64 {
+	pixels := int64(cfg.Width) * int64(cfg.Height)
+	need := int64(encoded) + pixels*bytesPerPixel(cfg.ColorModel)
+	if c.autoOrientation && !c.ignoreOrientation {
+		need += pixels * 4
+	}
+	if c.background != nil {
+		need += pixels * 8
+	}
+	if c.colorModel != nil {
+		need += pixels * bytesPerPixel(c.colorModel)
+	}
+	if c.nrgba {
+		need += pixels * 4
+	}
+	return need
+}
+
+// bytesPerPixel returns the size of a pixel stored in model
+func bytesPerPixel(model color.Model) int64 {
+	switch model {
+	case color.GrayModel, color.AlphaModel:
+		return 1
+	case color.Gray16Model, color.Alpha16Model:
+		return 2
+	case color.YCbCrModel:
+		return 3
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 8
+	}
+	if _, ok := model.(color.Palette); ok {
+		return 1
+	}
+	return 4
+}
+
+// formatMemory formats a byte count as a human-readable string
+func formatMemory(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// MemoryBudget accounts the memory of images processed concurrently. Work
+// that does not fit waits until enough memory is released instead of
+// exhausting the process, and work larger than the whole budget fails with
+// a *MemoryLimitError. A server can share one budget between all its
+// requests (see MapOptions.Memory).
+//
+// Example:
+//
+//	budget := imgx.NewMemoryBudget(2 << 30) // 2 GB for all requests
+//	results, err := imgx.MapImages(ctx, images, pipeline, imgx.MapOptions{Memory: budget})
+type MemoryBudget struct {
+	limit int64
+	mu    sync.Mutex
+	cond  *sync.Cond
+	used  int64
+}
+
+// NewMemoryBudget returns a budget of limit bytes.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	b := &MemoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Limit returns the size of the budget in bytes.
+func (b *MemoryBudget) Limit() int64 {
+	return b.limit
+}
+
+// InUse returns the bytes currently acquired.
+func (b *MemoryBudget) InUse() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Acquire reserves n bytes for op, waiting until they are available. It
+// fails with a *MemoryLimitError when n is larger than the whole budget, and
+// with the context error when ctx is done first. Release the bytes when the
+// work is finished.
+func (b *MemoryBudget) Acquire(ctx context.Context, op string, n int64) error {
+	if n > b.limit {
+		return &MemoryLimitError{Op: op, Need: n, Limit: b.limit}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.cond.Broadcast()
+	})
+	defer stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used+n > b.limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.cond.Wait()
+	}
+	b.used += n
+	return nil
+}
+
+// Release returns n bytes acquired with Acquire.
+func (b *MemoryBudget) Release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	b.cond.Broadcast()
+}
+
+// pipelineMemory estimates the working set of a pipeline run on img: the
+// input and the result of the current step, both NRGBA
+func pipelineMemory(img *Image) int64 {
+	b := img.Bounds()
+	return 2 * 4 * int64(b.Dx()) * int64(b.Dy())
+}
@@ -0,0 +1,150 @@
+package imgx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMemoryLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, New(100, 80, color.White), PNG); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if _, err := Decode(bytes.NewReader(data), WithMemoryLimit(1<<20)); err != nil {
+		t.Errorf("Decode within the limit: %v", err)
+	}
+
+	_, err := Decode(bytes.NewReader(data), WithMemoryLimit(10_000))
+	var merr *MemoryLimitError
+	if !errors.As(err, &merr) || !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Decode over the limit = %v, want a *MemoryLimitError", err)
+	}
+	if merr.Op != "decode" || merr.Limit != 10_000 || merr.Need <= merr.Limit {
+		t.Errorf("MemoryLimitError = %+v", merr)
+	}
+
+	// Load also counts the NRGBA copy of the decoded image.
+	_, plain := Decode(bytes.NewReader(data), WithMemoryLimit(int64(len(data))+100*80*4))
+	_, loaded := DecodeWithMetadata(bytes.NewReader(data), Options{
+		Decode: []DecodeOption{WithMemoryLimit(int64(len(data)) + 100*80*4)},
+	})
+	if plain != nil || !errors.Is(loaded, ErrMemoryLimit) {
+		t.Errorf("Decode = %v, DecodeWithMetadata = %v, want only the latter over the limit", plain, loaded)
+	}
+}
+
+func TestWithMemoryLimitHugeHeader(t *testing.T) {
+	// A GIF whose logical screen claims 65535x65535 pixels
+	var buf bytes.Buffer
+	if err := Encode(&buf, New(2, 2, color.Black), GIF); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	copy(data[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+	_, err := Decode(bytes.NewReader(data), WithMemoryLimit(512<<20))
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Errorf("Decode = %v, want ErrMemoryLimit", err)
+	}
+}
+
+func TestMemoryNeeded(t *testing.T) {
+	cfg := image.Config{Width: 10, Height: 10, ColorModel: color.GrayModel}
+	plain := decodeConfig{}
+	if got := plain.memoryNeeded(cfg, 50); got != 150 {
+		t.Errorf("memoryNeeded = %d, want 150", got)
+	}
+	converted := decodeConfig{autoOrientation: true, background: color.White, colorModel: color.NRGBA64Model, nrgba: true}
+	if got := converted.memoryNeeded(cfg, 50); got != 150+400+800+800+400 {
+		t.Errorf("memoryNeeded with conversions = %d, want %d", got, 150+400+800+800+400)
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	b := NewMemoryBudget(100)
+	ctx := context.Background()
+
+	if err := b.Acquire(ctx, "test", 101); !errors.Is(err, ErrMemoryLimit) {
+		t.Errorf("Acquire over the limit = %v, want ErrMemoryLimit", err)
+	}
+	if err := b.Acquire(ctx, "test", 60); err != nil {
+		t.Fatal(err)
+	}
+	if b.InUse() != 60 {
+		t.Errorf("InUse = %d, want 60", b.InUse())
+	}
+
+	// A second acquire waits for the first to be released.
+	acquired := make(chan error)
+	go func() { acquired <- b.Acquire(ctx, "test", 60) }()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire did not wait for memory")
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.Release(60)
+	if err := <-acquired; err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if err := b.Acquire(ctx, "test", 60); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire canceled = %v, want context.Canceled", err)
+	}
+	b.Release(60)
+	if b.InUse() != 0 {
+		t.Errorf("InUse = %d, want 0", b.InUse())
+	}
+}
+
+func TestMapImagesMemory(t *testing.T) {
+	inputs := make([]*Image, 12)
+	for i := range inputs {
+		inputs[i] = NewImage(10, 10, color.White)
+	}
+	// Room for two 10x10 images at a time (800 bytes each)
+	budget := NewMemoryBudget(1600)
+	var active, peak atomic.Int32
+	track := func(img *Image) *Image {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		return img.Invert()
+	}
+
+	results, err := MapImages(context.Background(), inputs, track, MapOptions{Workers: 6, Memory: budget})
+	if err != nil {
+		t.Fatalf("MapImages() error = %v", err)
+	}
+	for i, r := range results {
+		if r == nil {
+			t.Errorf("result %d is nil", i)
+		}
+	}
+	if peak.Load() > 2 {
+		t.Errorf("%d images processed at once, want at most 2", peak.Load())
+	}
+	if budget.InUse() != 0 {
+		t.Errorf("InUse after MapImages = %d, want 0", budget.InUse())
+	}
+
+	big := []*Image{NewImage(100, 100, color.White)}
+	_, err = MapImages(context.Background(), big, OpInvert(), MapOptions{Memory: budget})
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Errorf("MapImages() of an image over the budget = %v, want ErrMemoryLimit", err)
+	}
+}
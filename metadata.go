@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"image"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,14 +29,27 @@ type ImageMetadata struct {
 	Orientation int     `json:"orientation,omitempty"`
 
 	// Image Technical Details
-	BitDepth         int     `json:"bit_depth,omitempty"`
-	ColorSpace       string  `json:"color_space,omitempty"`
-	Compression      string  `json:"compression,omitempty"`
-	XResolution      float64 `json:"x_resolution,omitempty"`
-	YResolution      float64 `json:"y_resolution,omitempty"`
-	ResolutionUnit   string  `json:"resolution_unit,omitempty"`
-	ImageDescription string  `json:"image_description,omitempty"`
-	UserComment      string  `json:"user_comment,omitempty"`
+	BitDepth         int      `json:"bit_depth,omitempty"`
+	ColorSpace       string   `json:"color_space,omitempty"`
+	Compression      string   `json:"compression,omitempty"`
+	Interlaced       bool     `json:"interlaced,omitempty"` // Adam7 PNG, progressive JPEG or interlaced GIF
+	HasICCProfile    bool     `json:"has_icc_profile"`
+	HasEXIF          bool     `json:"has_exif"`
+	Layers           []string `json:"layers,omitempty"` // PSD layer names, topmost first
+	Frames           int      `json:"frames,omitempty"` // Frames of animated GIF and WebP files
+	XResolution      float64  `json:"x_resolution,omitempty"`
+	YResolution      float64  `json:"y_resolution,omitempty"`
+	ResolutionUnit   string   `json:"resolution_unit,omitempty"`
+	ImageDescription string   `json:"image_description,omitempty"`
+	UserComment      string   `json:"user_comment,omitempty"`
+
+	// JPEGQuality is the quality a JPEG was encoded with, estimated from its
+	// QuantizationTables (see EstimateJPEGQuality)
+	JPEGQuality        int                 `json:"jpeg_quality,omitempty"`
+	QuantizationTables []QuantizationTable `json:"quantization_tables,omitempty"`
+
+	// Content analysis, with WithAnalysis
+	Analysis *ImageAnalysis `json:"analysis,omitempty"`
 
 	// Extended Metadata
 	Extended    map[string]any `json:"extended,omitempty"`
@@ -107,6 +119,7 @@ type MetadataOption func(*metadataConfig)
 
 type metadataConfig struct {
 	basicOnly bool // Force basic metadata only, skip exiftool
+	analyze   bool // Measure sharpness, exposure and borders
 }
 
 // WithBasicOnly forces basic metadata extraction only (skip exiftool check)
@@ -116,6 +129,14 @@ func WithBasicOnly() MetadataOption {
 	}
 }
 
+// WithAnalysis adds an analysis of the pixels (sharpness, exposure and
+// borders, see Analyze) to the metadata. It decodes the full image.
+func WithAnalysis() MetadataOption {
+	return func(c *metadataConfig) {
+		c.analyze = true
+	}
+}
+
 var (
 	exiftoolCache      *bool
 	exiftoolCacheMutex sync.RWMutex
@@ -144,7 +165,7 @@ func Metadata(src string, options ...MetadataOption) (*ImageMetadata, error) {
 	}
 
 	// Extract basic metadata first
-	metadata, err := extractBasicMetadata(src)
+	metadata, err := extractBasicMetadata(src, config)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +236,7 @@ func FormatAspectRatio(width, height int) string {
 }
 
 // extractBasicMetadata extracts basic metadata using Go's image package
-func extractBasicMetadata(src string) (*ImageMetadata, error) {
+func extractBasicMetadata(src string, config *metadataConfig) (*ImageMetadata, error) {
 	// Get file information
 	fileInfo, err := os.Stat(src)
 	if err != nil {
@@ -252,6 +273,25 @@ func extractBasicMetadata(src string) (*ImageMetadata, error) {
 		HasExtended: false,
 	}
 
+	// Technical details from the format headers, available without exiftool
+	header := inspectFormatHeader(src)
+	metadata.BitDepth = header.bitDepth
+	metadata.Compression = header.compression
+	metadata.Interlaced = header.interlaced
+	metadata.HasICCProfile = header.icc
+	metadata.HasEXIF = header.exif
+	metadata.Layers = header.layers
+	metadata.QuantizationTables = header.quantTables
+	metadata.JPEGQuality = estimateJPEGQuality(header.quantTables)
+	if header.frames > 1 {
+		metadata.Frames = header.frames
+	}
+
+	if config.analyze {
+		analysis := Analyze(img)
+		metadata.Analysis = &analysis
+	}
+
 	return metadata, nil
 }
 
@@ -266,7 +306,7 @@ func detectFormatDetails(src string) (string, string, error) {
 	}
 	defer file.Close()
 
-	_, decodedFormat, err := image.DecodeConfig(file)
+	_, decodedFormat, err := imageDecodeConfig(file)
 	if err != nil {
 		return "", "", err
 	}
@@ -288,6 +328,8 @@ func normalizeDecodedFormat(format string) string {
 		return "BMP"
 	case "webp":
 		return "WEBP"
+	case "psd":
+		return "PSD"
 	default:
 		if strings.TrimSpace(format) == "" {
 			return "Unknown"
@@ -310,6 +352,8 @@ func mimeFromDecodedFormat(format string) string {
 		return "image/bmp"
 	case "webp":
 		return "image/webp"
+	case "psd":
+		return "image/vnd.adobe.photoshop"
 	default:
 		return "application/octet-stream"
 	}
@@ -368,17 +412,21 @@ func parseCommonFields(metadata *ImageMetadata, data map[string]any) {
 		return 0
 	}
 
-	// Image Technical Details
-	metadata.BitDepth = getInt("EXIF:BitsPerSample")
+	// Image Technical Details (header values from extractBasicMetadata win)
+	if metadata.BitDepth == 0 {
+		metadata.BitDepth = getInt("EXIF:BitsPerSample")
+	}
 	metadata.ColorSpace = getString("EXIF:ColorSpace")
 	if metadata.ColorSpace == "" {
 		metadata.ColorSpace = getString("ICC_Profile:ColorSpaceData")
 	}
-	metadata.Compression = getString("EXIF:Compression")
+	if metadata.Compression == "" {
+		metadata.Compression = getString("EXIF:Compression")
+	}
 	metadata.ImageDescription = getString("EXIF:ImageDescription")
 	metadata.UserComment = getString("EXIF:UserComment")
 
-	if orientation := getInt("EXIF:Orientation"); orientation > 0 {
+	if orientation := getInt("EXIF:Orientation"); orientation > 0 && orientation <= 8 {
 		metadata.Orientation = orientation
 	}
 	if xRes := getFloat("EXIF:XResolution"); xRes > 0 {
@@ -545,6 +593,8 @@ func mimeFromFormat(format Format) string {
 		return "image/jpeg"
 	case WEBP:
 		return "image/webp"
+	case PSD:
+		return "image/vnd.adobe.photoshop"
 	default:
 		return "application/octet-stream"
 	}
@@ -1,6 +1,9 @@
 package imgx
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestGCD(t *testing.T) {
 	tests := []struct {
@@ -54,3 +57,21 @@ func TestFormatAspectRatio(t *testing.T) {
 		})
 	}
 }
+
+func FuzzParseCommonFields(f *testing.F) {
+	f.Add(`{"EXIF:Make": "Canon", "EXIF:Orientation": 6, "EXIF:FNumber": 2.8, "EXIF:ISO": 400, "XMP:Rating": 4}`)
+	f.Add(`{"EXIF:FNumber": "f/1.8", "EXIF:ISO": "100", "Composite:GPSLatitude": "52 deg 31' N"}`)
+	f.Add(`{"EXIF:Orientation": 1e300, "EXIF:XResolution": -72, "XMP:Rating": "five"}`)
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return
+		}
+		metadata := &ImageMetadata{}
+		parseCommonFields(metadata, data)
+		if metadata.Orientation < 0 || metadata.Orientation > 8 {
+			t.Errorf("Orientation = %d, want 0-8", metadata.Orientation)
+		}
+	})
+}
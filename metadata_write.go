@@ -1,9 +1,15 @@
 package imgx
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
 	"image/png"
+	"io"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -31,6 +37,12 @@ type SaveConfig struct {
 	JPEGQuality     int
 	PNGCompression  png.CompressionLevel
 	GIFNumColors    int
+	WebPQuality     int
+	WebPLossless    bool
+	WebPEffort      int // 0 (fastest) to 6 (smallest), see WebPEffort
+	Strict          bool
+	HookPolicy      HookPolicy // What a failing save hook does (see OnSave)
+	DisableHooks    bool
 	// Add other encode options as needed
 }
 
@@ -41,7 +53,28 @@ func WithoutMetadata() SaveOption {
 	}
 }
 
-// WithJPEGQuality sets the JPEG quality (1-100)
+// Strict makes the save fail with ErrFidelityLoss instead of silently
+// degrading the image: transparency flattened for JPEG, palette reduction
+// for GIF, 16-bit sources, ICC/EXIF data of the source being dropped, an
+// animated source reduced to its first frame, encode options not applied
+// to an animation copied unchanged, or a JPEG source re-encoded above its
+// own quality.
+// Nothing is written when the save fails.
+//
+// Example:
+//
+//	err := img.Save("archive.jpg", imgx.Strict())
+//	if errors.Is(err, imgx.ErrFidelityLoss) {
+//		err = img.Save("archive.tiff", imgx.Strict())
+//	}
+func Strict() SaveOption {
+	return func(c *SaveConfig) {
+		c.Strict = true
+	}
+}
+
+// WithJPEGQuality sets the JPEG quality (1-100, 0 keeps the default). Save
+// fails with a *ValidationError for a quality outside the range.
 func WithJPEGQuality(quality int) SaveOption {
 	return func(c *SaveConfig) {
 		c.JPEGQuality = quality
@@ -62,13 +95,75 @@ func WithGIFNumColors(numColors int) SaveOption {
 	}
 }
 
-// Save saves the image to the specified path with optional metadata injection
+// WithWebPQuality sets the lossy WebP quality (0-100, default 80)
+func WithWebPQuality(quality int) SaveOption {
+	return func(c *SaveConfig) {
+		c.WebPQuality = quality
+	}
+}
+
+// WithWebPLossless enables lossless WebP encoding
+func WithWebPLossless() SaveOption {
+	return func(c *SaveConfig) {
+		c.WebPLossless = true
+	}
+}
+
+// WithWebPEffort sets the WebP encoding effort (0-6, default 4), trading
+// encoding speed for smaller files. Save fails with a *ValidationError for
+// an effort outside the range.
+func WithWebPEffort(effort int) SaveOption {
+	return func(c *SaveConfig) {
+		c.WebPEffort = effort
+	}
+}
+
+// Save saves the image to the specified path with optional metadata injection.
+// Non-fatal issues are dropped, except a failed metadata write which is
+// returned as a *MetadataWriteWarning. Use SaveWithResult to inspect them.
 func (img *Image) Save(path string, opts ...SaveOption) error {
+	result, err := img.SaveWithResult(path, opts...)
+	if err != nil {
+		return err
+	}
+	for _, w := range result.Warnings {
+		if w.Code == WarnMetadataFailed {
+			return &MetadataWriteWarning{Err: errors.New(w.Message)}
+		}
+	}
+	return nil
+}
+
+// SaveWithResult saves the image like Save and reports non-fatal issues
+// (alpha flattened, palette reduced, metadata not written, ICC profile or
+// EXIF of the source dropped, animation reduced to its first frame, JPEG
+// quality above that of the source) in the returned Result. Hooks registered with OnSave run once the file is
+// written.
+//
+// An animated GIF or WebP saved in its own format with its pixels
+// unchanged (no operation, or only operations that left the first frame as
+// it was) is copied from the source file, so the animation is kept. The
+// encode options are then not applied, which is reported as
+// WarnOptionsIgnored. Any other operation works on the first frame only
+// and drops the animation.
+//
+// Example:
+//
+//	result, err := img.SaveWithResult("out.jpg")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, w := range result.Warnings {
+//		log.Println("warning:", w)
+//	}
+func (img *Image) SaveWithResult(path string, opts ...SaveOption) (*Result, error) {
 	config := &SaveConfig{
 		DisableMetadata: false,
 		JPEGQuality:     DefaultJPEGQuality,
 		PNGCompression:  png.DefaultCompression,
 		GIFNumColors:    256,
+		WebPQuality:     80,
+		WebPEffort:      DefaultWebPEffort,
 	}
 	for _, opt := range opts {
 		opt(config)
@@ -76,7 +171,7 @@ func (img *Image) Save(path string, opts ...SaveOption) error {
 
 	// Convert SaveConfig to EncodeOptions
 	var encodeOpts []EncodeOption
-	if config.JPEGQuality > 0 {
+	if config.JPEGQuality != 0 {
 		encodeOpts = append(encodeOpts, JPEGQuality(config.JPEGQuality))
 	}
 	if config.PNGCompression != png.DefaultCompression {
@@ -85,21 +180,176 @@ func (img *Image) Save(path string, opts ...SaveOption) error {
 	if config.GIFNumColors != 256 {
 		encodeOpts = append(encodeOpts, GIFNumColors(config.GIFNumColors))
 	}
+	encodeOpts = append(encodeOpts, WebPQuality(config.WebPQuality), WebPLossless(config.WebPLossless), WebPEffort(config.WebPEffort))
 
-	// Save image using internal save() function
-	if err := save(img.data, path, encodeOpts...); err != nil {
-		return err
+	format, err := FormatFromFilename(path)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Path: path, Format: format.String()}
+	if animation := img.animatedSource(format); animation != nil {
+		// Unchanged animation saved in its own format: copy the frames
+		checkIgnoredOptions(result, format, config)
+		if config.Strict {
+			if err := result.strictErr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeFile(path, animation); err != nil {
+			return nil, err
+		}
+	} else {
+		img.checkFidelity(result, format, config)
+		if config.Strict {
+			if err := result.strictErr(); err != nil {
+				return nil, err
+			}
+		}
+
+		// Save image using internal save() function
+		if prof := img.metadata.profile; prof != nil {
+			prof.begin()
+		}
+		if err := save(img.data, path, encodeOpts...); err != nil {
+			return nil, err
+		}
+		if prof := img.metadata.profile; prof != nil {
+			prof.record(img.metadata, "encode", img.data.Bounds().Size())
+		}
 	}
 
 	// Write metadata if enabled
 	shouldWriteMetadata := img.metadata.AddMetadata && !config.DisableMetadata
 	if shouldWriteMetadata {
-		if err := img.writeXMPMetadata(path); err != nil {
-			return &MetadataWriteWarning{Err: err}
+		if !isExiftoolAvailable() {
+			result.Warn(WarnMetadataSkipped, "exiftool not found, processing metadata was not written")
+		} else if err := img.writeXMPMetadata(path); err != nil {
+			result.Warn(WarnMetadataFailed, "%v", err)
 		}
 	}
 
-	return nil
+	if err := img.runSaveHooks(path, result, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkFidelity records the information that will be lost when the image is
+// encoded as format.
+func (img *Image) checkFidelity(result *Result, format Format, config *SaveConfig) {
+	switch format {
+	case JPEG, BMP:
+		if !img.data.Opaque() {
+			result.Warn(WarnAlphaFlattened, "%s has no alpha channel, transparency was discarded", format)
+		}
+	case GIF:
+		if countColorsAbove(img.data, config.GIFNumColors) {
+			result.Warn(WarnColorsReduced, "image was reduced to a %d color palette", config.GIFNumColors)
+		}
+	}
+
+	if file := img.metadata.File; file != nil && file.Frames > 1 {
+		result.Warn(WarnAnimationDropped, "animated %s has %d frames, only the first was saved", file.Format, file.Frames)
+	}
+
+	src := img.metadata.SourcePath
+	if src == "" {
+		return
+	}
+	name := filepath.Base(src)
+	info := inspectSource(src)
+	if info.bitDepth > 8 {
+		result.Warn(WarnDepthReduced, "%s has %d bits per channel, saved with 8", name, info.bitDepth)
+	}
+	if info.icc {
+		result.Warn(WarnICCIgnored, "ICC profile of %s was not applied or preserved", name)
+	}
+	if info.exif {
+		result.Warn(WarnEXIFDiscarded, "EXIF data of %s was not preserved", name)
+	}
+	quality := config.JPEGQuality
+	if quality == 0 {
+		quality = DefaultJPEGQuality
+	}
+	if format == JPEG && info.jpegQuality > 0 && quality > info.jpegQuality {
+		result.Warn(WarnQualityInflated, "JPEG quality %d is above the estimated quality %d of %s, which adds bytes but no detail",
+			quality, info.jpegQuality, name)
+	}
+}
+
+// checkIgnoredOptions records the encode options of config that differ from
+// the defaults of format and are not applied to an animation copied from its
+// source file.
+func checkIgnoredOptions(result *Result, format Format, config *SaveConfig) {
+	var ignored []string
+	switch format {
+	case GIF:
+		if config.GIFNumColors != 256 {
+			ignored = append(ignored, fmt.Sprintf("%d colors", config.GIFNumColors))
+		}
+	case WEBP:
+		if config.WebPLossless {
+			ignored = append(ignored, "lossless")
+		} else if config.WebPQuality != 80 {
+			ignored = append(ignored, fmt.Sprintf("quality %d", config.WebPQuality))
+		}
+		if config.WebPEffort != DefaultWebPEffort {
+			ignored = append(ignored, fmt.Sprintf("effort %d", config.WebPEffort))
+		}
+	}
+	if len(ignored) > 0 {
+		result.Warn(WarnOptionsIgnored, "animated %s was copied unchanged, %s not applied", format, strings.Join(ignored, " and "))
+	}
+}
+
+// animatedSource returns the content of the source file when the image is
+// an animated GIF or WebP saved in its own format with the pixels of its
+// first frame unchanged, so that the animation can be kept by copying the
+// file. Operations are allowed as long as they left the pixels as they
+// were, e.g. a rotation by 0°. It returns nil when the pixels changed or
+// the file changed since it was loaded.
+func (img *Image) animatedSource(format Format) []byte {
+	file, src := img.metadata.File, img.metadata.SourcePath
+	if file == nil || file.Frames < 2 || src == "" {
+		return nil
+	}
+	if file.Format != format.String() || img.data.Rect.Dx() != file.Width || img.data.Rect.Dy() != file.Height {
+		return nil
+	}
+	f, err := fs.Open(src)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, file.Size+1))
+	if err != nil || int64(len(data)) != file.Size || readFormatHeader(data).frames != file.Frames {
+		return nil
+	}
+	if len(img.metadata.Operations) > 0 {
+		// Compare the first frame with the image instead of trusting the
+		// recorded operations
+		frame, err := Decode(bytes.NewReader(data), func(c *decodeConfig) { c.nrgba = true })
+		if err != nil || !samePixels(toNRGBA(frame), img.data) {
+			return nil
+		}
+	}
+	return data
+}
+
+// samePixels reports whether a and b have the same size and pixels
+func samePixels(a, b *image.NRGBA) bool {
+	w, h := a.Rect.Dx(), a.Rect.Dy()
+	if w != b.Rect.Dx() || h != b.Rect.Dy() {
+		return false
+	}
+	for y := 0; y < h; y++ {
+		rowA := a.Pix[a.PixOffset(a.Rect.Min.X, a.Rect.Min.Y+y):][:w*4]
+		rowB := b.Pix[b.PixOffset(b.Rect.Min.X, b.Rect.Min.Y+y):][:w*4]
+		if !bytes.Equal(rowA, rowB) {
+			return false
+		}
+	}
+	return true
 }
 
 // writeXMPMetadata writes XMP metadata to the image file using exiftool
@@ -133,3 +383,26 @@ func (img *Image) writeXMPMetadata(path string) error {
 	cmd := exec.Command("exiftool", args...)
 	return cmd.Run()
 }
+
+// WriteDescription stores description in the XMP dc:description (and
+// IPTC Core alt text) of the image file at path, in place, using exiftool.
+// Other metadata is preserved.
+//
+// Example:
+//
+//	err := imgx.WriteDescription("photo.jpg", "A red bicycle leaning against a brick wall.")
+func WriteDescription(path, description string) error {
+	if !isExiftoolAvailable() {
+		return errors.New("imgx: exiftool is required to write descriptions")
+	}
+	cmd := exec.Command("exiftool",
+		"-overwrite_original",
+		"-XMP-dc:Description="+description,
+		"-XMP-iptcCore:AltTextAccessibility="+description,
+		path,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("imgx: failed to write description: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math/rand/v2"
+)
+
+// RandomOption sets an optional parameter of the operations that use random
+// numbers (AddNoise, AddGrain, Dither).
+type RandomOption func(*randomConfig)
+
+type randomConfig struct {
+	seed       uint64
+	monochrome bool
+}
+
+// WithSeed returns a RandomOption that sets the seed of the random numbers.
+// The same image, parameters and seed give the same pixels on every run and
+// platform, so results can be golden-tested and cached by content hash.
+// Without WithSeed the seed is 0: results are reproducible by default, and
+// a different seed gives a different pattern.
+//
+// Example:
+//
+//	dst := imgx.AddNoise(src, 8, imgx.WithSeed(42))
+func WithSeed(seed uint64) RandomOption {
+	return func(c *randomConfig) {
+		c.seed = seed
+	}
+}
+
+// WithMonochrome returns a RandomOption that applies the same noise to the
+// red, green and blue channels, so it changes brightness but not color.
+func WithMonochrome() RandomOption {
+	return func(c *randomConfig) {
+		c.monochrome = true
+	}
+}
+
+func newRandomConfig(opts []RandomOption) randomConfig {
+	var cfg randomConfig
+	for _, option := range opts {
+		option(&cfg)
+	}
+	return cfg
+}
+
+// rowRand returns the random number generator of row y. Each row has its
+// own PCG stream, so the result doesn't depend on how rows are split between
+// goroutines.
+func rowRand(seed uint64, y int) *rand.PCG {
+	return rand.NewPCG(seed, uint64(y))
+}
+
+// The noise is the sum of four 16-bit uniform numbers (an Irwin-Hall
+// approximation of a Gaussian), and pixels are computed with integers
+// only, as floating point results may differ between platforms.
+const (
+	noiseMean   = 4 * 65535 / 2
+	noiseStdDev = 37837 // 65536 * sqrt(4/12)
+)
+
+// gaussianNoise returns a noise value with mean 0 and standard deviation
+// noiseStdDev
+func gaussianNoise(r *rand.PCG) int64 {
+	v := r.Uint64()
+	return int64(v&0xffff+v>>16&0xffff+v>>32&0xffff+v>>48) - noiseMean
+}
+
+// noiseScale returns the fixed-point factor (32 fractional bits) turning
+// gaussianNoise into noise with the standard deviation sigma
+func noiseScale(sigma float64) int64 {
+	return int64(sigma / noiseStdDev * (1 << 32))
+}
+
+func clampInt(v int64) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return uint8(v)
+}
+
+// AddNoise adds Gaussian noise with the standard deviation sigma (in levels
+// of 0-255) to the color channels of the image. Alpha is kept. Use
+// WithMonochrome for luminance-only noise and WithSeed to choose the pattern.
+//
+// Example:
+//
+//	dstImage := imgx.AddNoise(srcImage, 10, imgx.WithSeed(7))
+func AddNoise(img image.Image, sigma float64, opts ...RandomOption) *image.NRGBA {
+	if sigma <= 0 {
+		return Clone(img)
+	}
+	cfg := newRandomConfig(opts)
+	k := noiseScale(sigma)
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	parallel(0, src.h, func(ys <-chan int) {
+		for y := range ys {
+			r := rowRand(cfg.seed, y)
+			i := y * dst.Stride
+			src.scan(0, y, src.w, y+1, dst.Pix[i:i+src.w*4])
+			for x := 0; x < src.w; x++ {
+				d := dst.Pix[i : i+3 : i+3]
+				n := gaussianNoise(r) * k / (1 << 32)
+				for c := range d {
+					if c > 0 && !cfg.monochrome {
+						n = gaussianNoise(r) * k / (1 << 32)
+					}
+					d[c] = clampInt(int64(d[c]) + n)
+				}
+				i += 4
+			}
+		}
+	})
+	return dst
+}
+
+// AddGrain adds film grain: monochrome noise that is strongest in the
+// midtones and fades out in the shadows and highlights. amount is the
+// standard deviation of the noise in the midtones (in levels of 0-255).
+// WithSeed chooses the pattern.
+//
+// Example:
+//
+//	dstImage := imgx.AddGrain(srcImage, 12)
+func AddGrain(img image.Image, amount float64, opts ...RandomOption) *image.NRGBA {
+	if amount <= 0 {
+		return Clone(img)
+	}
+	cfg := newRandomConfig(opts)
+	k := noiseScale(amount)
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	parallel(0, src.h, func(ys <-chan int) {
+		for y := range ys {
+			r := rowRand(cfg.seed, y)
+			i := y * dst.Stride
+			src.scan(0, y, src.w, y+1, dst.Pix[i:i+src.w*4])
+			for x := 0; x < src.w; x++ {
+				d := dst.Pix[i : i+3 : i+3]
+				// BT.601 luminance in integers; the weight peaks at 1 for
+				// mid-gray (127*128 = 16256)
+				l := (299*int64(d[0]) + 587*int64(d[1]) + 114*int64(d[2]) + 500) / 1000
+				n := gaussianNoise(r) * k * (l * (255 - l)) / 16256 / (1 << 32)
+				d[0] = clampInt(int64(d[0]) + n)
+				d[1] = clampInt(int64(d[1]) + n)
+				d[2] = clampInt(int64(d[2]) + n)
+				i += 4
+			}
+		}
+	})
+	return dst
+}
+
+// Dither reduces each color channel to the given number of evenly spaced
+// levels (2-256) with Floyd-Steinberg error diffusion. The threshold is
+// jittered with random noise, which breaks up the regular patterns of plain
+// error diffusion in flat areas; WithSeed chooses the noise. Alpha is kept.
+//
+// Example:
+//
+//	dstImage := imgx.Dither(srcImage, 2) // 8 colors
+func Dither(img image.Image, levels int, opts ...RandomOption) *image.NRGBA {
+	levels = max(2, min(256, levels))
+	cfg := newRandomConfig(opts)
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	for y := 0; y < src.h; y++ {
+		i := y * dst.Stride
+		src.scan(0, y, src.w, y+1, dst.Pix[i:i+src.w*4])
+	}
+	if levels == 256 {
+		return dst
+	}
+
+	// Errors in 1/16 of a level of 0-255, for the current and next row
+	w := src.w
+	cur := make([]int64, (w+2)*3)
+	next := make([]int64, (w+2)*3)
+	step := int64(255 * 16 / (levels - 1))
+	for y := 0; y < src.h; y++ {
+		r := rowRand(cfg.seed, y)
+		for x := 0; x < w; x++ {
+			p := y*dst.Stride + x*4
+			d := dst.Pix[p : p+3 : p+3]
+			// Jitter of up to a quarter of a level step either way
+			jitter := (int64(r.Uint64()>>48) - 32768) * step / (4 * 32768)
+			for c := range d {
+				e := (x + 1) * 3
+				v := int64(d[c])*16 + cur[e+c]
+				q := max(0, min(int64(levels-1), (v+step/2+jitter)/step))
+				out := q * 255 / int64(levels-1)
+				d[c] = uint8(out)
+				diff := v - out*16
+				cur[e+3+c] += diff * 7 / 16
+				next[e-3+c] += diff * 3 / 16
+				next[e+c] += diff * 5 / 16
+				next[e+3+c] += diff / 16
+			}
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return dst
+}
+
+// denoiseRadius is the radius of the neighborhood averaged by Denoise, and
+// denoiseMaxDiff the largest channel difference of a neighbor averaged at
+// strength 1
+const (
+	denoiseRadius  = 2
+	denoiseMaxDiff = 64
+)
+
+// Denoise smooths noise while keeping edges (a surface blur): every pixel
+// becomes the average of the pixels of its 5x5 neighborhood whose channels
+// differ from its own by at most strength*64. Strength ranges from 0 (no
+// change) to 1; around 0.3-0.5 suits high-ISO photos.
+//
+// Example:
+//
+//	dstImage := imgx.Denoise(srcImage, 0.4)
+func Denoise(img image.Image, strength float64) *image.NRGBA {
+	src := Clone(img)
+	threshold := int(min(max(strength, 0), 1) * denoiseMaxDiff)
+	if threshold == 0 {
+		return src
+	}
+	dst := image.NewNRGBA(src.Rect)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	parallel(0, h, func(ys <-chan int) {
+		for y := range ys {
+			for x := 0; x < w; x++ {
+				p := src.Pix[y*src.Stride+x*4 : y*src.Stride+x*4+4]
+				var sum [4]int
+				n := 0
+				for ny := max(y-denoiseRadius, 0); ny <= min(y+denoiseRadius, h-1); ny++ {
+					for nx := max(x-denoiseRadius, 0); nx <= min(x+denoiseRadius, w-1); nx++ {
+						q := src.Pix[ny*src.Stride+nx*4 : ny*src.Stride+nx*4+4]
+						if absint(int(q[0])-int(p[0])) > threshold || absint(int(q[1])-int(p[1])) > threshold ||
+							absint(int(q[2])-int(p[2])) > threshold || absint(int(q[3])-int(p[3])) > threshold {
+							continue
+						}
+						for c := range sum {
+							sum[c] += int(q[c])
+						}
+						n++
+					}
+				}
+				d := dst.Pix[y*dst.Stride+x*4:]
+				for c := range sum {
+					d[c] = uint8((sum[c] + n/2) / n)
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// Denoise smooths noise while keeping edges (see Denoise)
+func (img *Image) Denoise(strength float64) *Image {
+	newData := Denoise(img.data, strength)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("denoise", fmt.Sprintf("strength=%.2f", strength))
+	return newImage(newData, newMeta)
+}
+
+// AddNoise adds Gaussian noise to the image (see AddNoise)
+func (img *Image) AddNoise(sigma float64, opts ...RandomOption) *Image {
+	cfg := newRandomConfig(opts)
+	newData := AddNoise(img.data, sigma, opts...)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("addNoise", fmt.Sprintf("sigma=%.2f, monochrome=%t, seed=%d", sigma, cfg.monochrome, cfg.seed))
+	return newImage(newData, newMeta)
+}
+
+// AddGrain adds film grain to the image (see AddGrain)
+func (img *Image) AddGrain(amount float64, opts ...RandomOption) *Image {
+	cfg := newRandomConfig(opts)
+	newData := AddGrain(img.data, amount, opts...)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("addGrain", fmt.Sprintf("amount=%.2f, seed=%d", amount, cfg.seed))
+	return newImage(newData, newMeta)
+}
+
+// Dither reduces the color channels to levels values with error diffusion
+// (see Dither)
+func (img *Image) Dither(levels int, opts ...RandomOption) *Image {
+	cfg := newRandomConfig(opts)
+	newData := Dither(img.data, levels, opts...)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("dither", fmt.Sprintf("levels=%d, seed=%d", levels, cfg.seed))
+	return newImage(newData, newMeta)
+}
@@ -0,0 +1,193 @@
+package imgx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func noiseTestImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 4), uint8(y * 5), 128, 255})
+		}
+	}
+	return img
+}
+
+func pixHash(img *image.NRGBA) string {
+	sum := sha256.Sum256(img.Pix)
+	return hex.EncodeToString(sum[:8])
+}
+
+func TestRandomOpsReproducible(t *testing.T) {
+	src := noiseTestImage()
+	ops := map[string]func(...RandomOption) *image.NRGBA{
+		"AddNoise":   func(o ...RandomOption) *image.NRGBA { return AddNoise(src, 10, o...) },
+		"Monochrome": func(o ...RandomOption) *image.NRGBA { return AddNoise(src, 10, append(o, WithMonochrome())...) },
+		"AddGrain":   func(o ...RandomOption) *image.NRGBA { return AddGrain(src, 12, o...) },
+		"Dither":     func(o ...RandomOption) *image.NRGBA { return Dither(src, 3, o...) },
+	}
+	for name, op := range ops {
+		a, b := op(WithSeed(42)), op(WithSeed(42))
+		if !compareNRGBA(a, b, 0) {
+			t.Errorf("%s: same seed gave different images", name)
+		}
+		if compareNRGBA(a, op(WithSeed(43)), 0) {
+			t.Errorf("%s: different seeds gave the same image", name)
+		}
+		if !compareNRGBA(op(), op(WithSeed(0)), 0) {
+			t.Errorf("%s: default seed is not 0", name)
+		}
+	}
+}
+
+// TestRandomOpsGolden pins the output of the seeded operations, which must
+// not change between releases or platforms.
+func TestRandomOpsGolden(t *testing.T) {
+	src := noiseTestImage()
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+		want string
+	}{
+		{"AddNoise", AddNoise(src, 10, WithSeed(42)), "e509de7f36d3cc93"},
+		{"AddGrain", AddGrain(src, 12, WithSeed(42)), "247a6c970f7d993f"},
+		{"Dither", Dither(src, 3, WithSeed(42)), "e8efca0193a0e6fe"},
+	}
+	for _, tt := range tests {
+		if got := pixHash(tt.img); got != tt.want {
+			t.Errorf("%s hash = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddNoise(t *testing.T) {
+	src := NewImage(128, 128, color.NRGBA{128, 128, 128, 200}).ToNRGBA()
+	dst := AddNoise(src, 10)
+
+	var sum, sumSq float64
+	for i := 0; i < len(dst.Pix); i += 4 {
+		if dst.Pix[i+3] != 200 {
+			t.Fatalf("alpha changed to %d", dst.Pix[i+3])
+		}
+		d := float64(dst.Pix[i]) - 128
+		sum += d
+		sumSq += d * d
+	}
+	n := float64(len(dst.Pix) / 4)
+	mean, std := sum/n, math.Sqrt(sumSq/n-(sum/n)*(sum/n))
+	if math.Abs(mean) > 0.5 || math.Abs(std-10) > 0.5 {
+		t.Errorf("noise mean = %.2f, std = %.2f, want 0 and 10", mean, std)
+	}
+
+	mono := AddNoise(src, 10, WithMonochrome())
+	for i := 0; i < len(mono.Pix); i += 4 {
+		if mono.Pix[i] != mono.Pix[i+1] || mono.Pix[i] != mono.Pix[i+2] {
+			t.Fatalf("monochrome noise changed the color: %v", mono.Pix[i:i+3])
+		}
+	}
+
+	if !compareNRGBA(AddNoise(src, 0), src, 0) {
+		t.Error("AddNoise(0) should return a copy")
+	}
+}
+
+func TestAddGrain(t *testing.T) {
+	for _, tt := range []struct {
+		level   uint8
+		changed bool
+	}{{0, false}, {255, false}, {128, true}} {
+		src := NewImage(32, 32, color.NRGBA{tt.level, tt.level, tt.level, 255}).ToNRGBA()
+		if changed := !compareNRGBA(AddGrain(src, 20), src, 0); changed != tt.changed {
+			t.Errorf("AddGrain on level %d changed = %v, want %v", tt.level, changed, tt.changed)
+		}
+	}
+}
+
+func TestDither(t *testing.T) {
+	// A flat 25% gray dithers to black and white with about 25% white.
+	src := NewImage(64, 64, color.NRGBA{64, 64, 64, 255}).ToNRGBA()
+	dst := Dither(src, 2)
+	white := 0
+	for i := 0; i < len(dst.Pix); i += 4 {
+		switch dst.Pix[i] {
+		case 255:
+			white++
+		case 0:
+		default:
+			t.Fatalf("level %d is not black or white", dst.Pix[i])
+		}
+	}
+	if ratio := float64(white) / (64 * 64); math.Abs(ratio-0.25) > 0.02 {
+		t.Errorf("white ratio = %.3f, want 0.25", ratio)
+	}
+
+	if !compareNRGBA(Dither(src, 256), src, 0) {
+		t.Error("Dither(256) should return a copy")
+	}
+}
+
+func TestDenoise(t *testing.T) {
+	// Noise on a black/white edge: the noise is smoothed, the edge kept
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(40)
+			if x >= 32 {
+				v = 215
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	noisy := AddNoise(src, 8, WithMonochrome())
+	dst := Denoise(noisy, 0.5)
+
+	// stdDev is the deviation of the red channel from flat in columns x0-x1
+	stdDev := func(img *image.NRGBA, x0, x1 int, flat float64) float64 {
+		var sumSq float64
+		for y := 0; y < 64; y++ {
+			for x := x0; x < x1; x++ {
+				d := float64(img.Pix[img.PixOffset(x, y)]) - flat
+				sumSq += d * d
+			}
+		}
+		return math.Sqrt(sumSq / float64(64*(x1-x0)))
+	}
+	if before, after := stdDev(noisy, 0, 30, 40), stdDev(dst, 0, 30, 40); after > before/2 {
+		t.Errorf("noise std = %.2f after denoising, %.2f before", after, before)
+	}
+	if left, right := dst.Pix[dst.PixOffset(31, 32)], dst.Pix[dst.PixOffset(32, 32)]; left > 80 || right < 175 {
+		t.Errorf("edge blurred to %d|%d", left, right)
+	}
+
+	if !compareNRGBA(Denoise(noisy, 0), noisy, 0) {
+		t.Error("Denoise(0) should return a copy")
+	}
+}
+
+func TestRandomOpsMetadata(t *testing.T) {
+	img := NewImage(16, 16, color.NRGBA{100, 100, 100, 255})
+	got := img.ApplyAll(OpAddNoise(5, WithSeed(7)), OpAddGrain(4), OpDither(4, WithSeed(9)))
+	ops := got.GetMetadata().Operations
+	want := []string{
+		"addNoise(sigma=5.00, monochrome=false, seed=7)",
+		"addGrain(amount=4.00, seed=0)",
+		"dither(levels=4, seed=9)",
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("recorded %d operations, want %d", len(ops), len(want))
+	}
+	for i, op := range ops {
+		if s := op.Action + "(" + op.Parameters + ")"; s != want[i] {
+			t.Errorf("operation %d = %s, want %s", i, s, want[i])
+		}
+	}
+	if !compareNRGBA(got.ToNRGBA(), Dither(AddGrain(AddNoise(img.ToNRGBA(), 5, WithSeed(7)), 4), 4, WithSeed(9)), 0) {
+		t.Error("Image methods differ from the functions")
+	}
+}
@@ -0,0 +1,180 @@
+package imgx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Layer is one layer of a layered document written by EncodeOpenRaster.
+type Layer struct {
+	// Name is shown in the layers panel of the editor.
+	Name string
+
+	// Image holds the pixels of the layer.
+	Image image.Image
+
+	// Pos is the position of the top-left corner of the layer on the
+	// canvas.
+	Pos image.Point
+
+	// Opacity of the layer, from 0.0 to 1.0. NewLayer sets it to 1.
+	Opacity float64
+
+	// Hidden layers are kept in the document but left out of the merged
+	// image.
+	Hidden bool
+}
+
+// NewLayer returns an opaque, visible layer at the top-left corner of the
+// canvas.
+func NewLayer(name string, img image.Image) Layer {
+	return Layer{Name: name, Image: img, Opacity: 1}
+}
+
+// oraStack is the stack.xml of an OpenRaster document
+type oraStack struct {
+	XMLName xml.Name   `xml:"image"`
+	Version string     `xml:"version,attr"`
+	Width   int        `xml:"w,attr"`
+	Height  int        `xml:"h,attr"`
+	Layers  []oraLayer `xml:"stack>layer"`
+}
+
+// oraLayer is a layer element of stack.xml
+type oraLayer struct {
+	Name       string `xml:"name,attr"`
+	Src        string `xml:"src,attr"`
+	X          int    `xml:"x,attr"`
+	Y          int    `xml:"y,attr"`
+	Opacity    string `xml:"opacity,attr"`
+	Visibility string `xml:"visibility,attr"`
+}
+
+// EncodeOpenRaster writes layers as an OpenRaster (.ora) document with a
+// canvas of the given size, so that a composition can be refined in GIMP,
+// Krita or MyPaint with its layers, names and opacities intact. Layers are
+// listed bottom first, in the order they would be drawn. The document also
+// holds the merged image of the visible layers and its thumbnail, shown by
+// viewers that do not read layers.
+//
+// Example:
+//
+//	background := imgx.NewLayer("background", photo.ToNRGBA())
+//	logo := imgx.NewLayer("logo", logoImg.ToNRGBA())
+//	logo.Pos = image.Pt(40, 40)
+//	logo.Opacity = 0.8
+//
+//	f, err := os.Create("poster.ora")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	err = imgx.EncodeOpenRaster(f, 1200, 800, []imgx.Layer{background, logo})
+func EncodeOpenRaster(w io.Writer, width, height int, layers []Layer) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("imgx: invalid OpenRaster canvas size %dx%d", width, height)
+	}
+	if len(layers) == 0 {
+		return fmt.Errorf("imgx: cannot encode an OpenRaster document without layers")
+	}
+	for i, l := range layers {
+		if l.Image == nil {
+			return fmt.Errorf("imgx: OpenRaster layer %d (%q) has no image", i, l.Name)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+
+	// The mimetype comes first and uncompressed, so the type can be read
+	// at a fixed offset
+	mimetype := []byte("image/openraster")
+	mw, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(mimetype); err != nil {
+		return err
+	}
+
+	stack := oraStack{Version: "0.0.5", Width: width, Height: height}
+	merged := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, l := range layers {
+		opacity := math.Min(math.Max(l.Opacity, 0), 1)
+		src := fmt.Sprintf("data/layer%d.png", i)
+		if err := writeZipPNG(zw, src, l.Image); err != nil {
+			return err
+		}
+
+		visibility := "visible"
+		if l.Hidden {
+			visibility = "hidden"
+		} else {
+			b := l.Image.Bounds()
+			mask := image.NewUniform(color.Alpha{A: uint8(opacity*255 + 0.5)})
+			draw.DrawMask(merged, b.Sub(b.Min).Add(l.Pos), l.Image, b.Min, mask, image.Point{}, draw.Over)
+		}
+
+		// stack.xml lists the topmost layer first
+		stack.Layers = append([]oraLayer{{
+			Name:       l.Name,
+			Src:        src,
+			X:          l.Pos.X,
+			Y:          l.Pos.Y,
+			Opacity:    fmt.Sprintf("%.3f", opacity),
+			Visibility: visibility,
+		}}, stack.Layers...)
+	}
+
+	sw, err := zw.Create("stack.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sw, xml.Header); err != nil {
+		return err
+	}
+	if err := xml.NewEncoder(sw).Encode(stack); err != nil {
+		return err
+	}
+
+	if err := writeZipPNG(zw, "mergedimage.png", merged); err != nil {
+		return err
+	}
+	thumb := image.Image(merged)
+	if width > 256 || height > 256 {
+		thumb = Fit(merged, 256, 256, Lanczos)
+	}
+	if err := writeZipPNG(zw, "Thumbnails/thumbnail.png", thumb); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeZipPNG adds img to zw as a PNG file. PNG data is already compressed,
+// so it is stored as is.
+func writeZipPNG(zw *zip.Writer, name string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(buf.Bytes())
+	return err
+}
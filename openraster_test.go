@@ -0,0 +1,106 @@
+package imgx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func TestEncodeOpenRaster(t *testing.T) {
+	background := NewLayer("background", New(4, 4, color.NRGBA{255, 0, 0, 255}))
+	logo := NewLayer("logo & text", New(2, 2, color.NRGBA{0, 0, 255, 255}))
+	logo.Pos = image.Pt(1, 1)
+	logo.Opacity = 0.5
+	sketch := NewLayer("sketch", New(4, 4, color.NRGBA{0, 255, 0, 255}))
+	sketch.Hidden = true
+
+	var buf bytes.Buffer
+	if err := EncodeOpenRaster(&buf, 4, 4, []Layer{background, logo, sketch}); err != nil {
+		t.Fatalf("EncodeOpenRaster() error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := zr.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store || first.Flags&0x8 != 0 {
+		t.Fatalf("first entry = %q (method %d, flags %#x), want stored mimetype", first.Name, first.Method, first.Flags)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	if got := string(files["mimetype"]); got != "image/openraster" {
+		t.Errorf("mimetype = %q", got)
+	}
+
+	var stack oraStack
+	if err := xml.Unmarshal(files["stack.xml"], &stack); err != nil {
+		t.Fatalf("stack.xml: %v", err)
+	}
+	if stack.Width != 4 || stack.Height != 4 || len(stack.Layers) != 3 {
+		t.Fatalf("stack = %+v, want 4x4 with 3 layers", stack)
+	}
+	want := []oraLayer{
+		{Name: "sketch", Src: "data/layer2.png", Opacity: "1.000", Visibility: "hidden"},
+		{Name: "logo & text", Src: "data/layer1.png", X: 1, Y: 1, Opacity: "0.500", Visibility: "visible"},
+		{Name: "background", Src: "data/layer0.png", Opacity: "1.000", Visibility: "visible"},
+	}
+	for i, l := range stack.Layers {
+		if l != want[i] {
+			t.Errorf("layer %d = %+v, want %+v", i, l, want[i])
+		}
+		if _, ok := files[l.Src]; !ok {
+			t.Errorf("layer %q: %s missing", l.Name, l.Src)
+		}
+	}
+
+	merged, err := png.Decode(bytes.NewReader(files["mergedimage.png"]))
+	if err != nil {
+		t.Fatalf("mergedimage.png: %v", err)
+	}
+	for _, tc := range []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{0, 0, color.NRGBA{255, 0, 0, 255}},
+		{1, 1, color.NRGBA{127, 0, 128, 255}},
+		{3, 3, color.NRGBA{255, 0, 0, 255}},
+	} {
+		if got := color.NRGBAModel.Convert(merged.At(tc.x, tc.y)).(color.NRGBA); got != tc.want {
+			t.Errorf("merged (%d,%d) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+	if _, err := png.Decode(bytes.NewReader(files["Thumbnails/thumbnail.png"])); err != nil {
+		t.Errorf("thumbnail: %v", err)
+	}
+}
+
+func TestEncodeOpenRasterErrors(t *testing.T) {
+	layer := NewLayer("layer", New(2, 2, color.White))
+	testCases := []struct {
+		name          string
+		width, height int
+		layers        []Layer
+	}{
+		{"empty canvas", 0, 4, []Layer{layer}},
+		{"no layers", 4, 4, nil},
+		{"no image", 4, 4, []Layer{{Name: "empty"}}},
+	}
+	for _, tc := range testCases {
+		if err := EncodeOpenRaster(io.Discard, tc.width, tc.height, tc.layers); err == nil {
+			t.Errorf("%s: EncodeOpenRaster() should fail", tc.name)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+)
+
+// Op is an image operation as a value, so pipelines can be built, stored and
+// reused as data. Every Image method that returns a new image has an Op
+// constructor named after it (OpResize for Resize, OpSharpen for Sharpen, ...).
+// Operations that can fail (ResizeTo, CropToAspect) have no Op; call them
+// directly and check the error.
+//
+// Example:
+//
+//	web := []imgx.Op{
+//		imgx.OpFit(1600, 1600, imgx.Lanczos),
+//		imgx.OpSharpen(0.6),
+//		imgx.OpWatermark(imgx.WatermarkOptions{Text: "© Jane"}),
+//	}
+//	for _, img := range images {
+//		img.ApplyAll(web...).Save(name)
+//	}
+type Op func(*Image) *Image
+
+// ApplyAll applies ops in order and returns the result. The operations are
+// recorded in the metadata as if the methods were chained.
+func (img *Image) ApplyAll(ops ...Op) *Image {
+	for _, op := range ops {
+		img = op(img)
+	}
+	return img
+}
+
+// Chain combines ops into a single Op applying them in order.
+func Chain(ops ...Op) Op {
+	return func(img *Image) *Image {
+		return img.ApplyAll(ops...)
+	}
+}
+
+// OpRegion returns an Op applying op to the rect area of the image (see Region.Apply).
+func OpRegion(rect image.Rectangle, op Op) Op {
+	return func(img *Image) *Image { return img.Region(rect).Apply(op) }
+}
+
+// OpApplyMasked returns an Op applying op where mask is set (see ApplyMasked).
+func OpApplyMasked(mask *Image, op Op) Op {
+	return func(img *Image) *Image { return img.ApplyMasked(mask, op) }
+}
+
+// Resizing
+
+// OpResize returns an Op calling Resize.
+func OpResize(width, height int, filter ResampleFilter, opts ...ResizeOption) Op {
+	return func(img *Image) *Image { return img.Resize(width, height, filter, opts...) }
+}
+
+// OpFit returns an Op calling Fit.
+func OpFit(width, height int, filter ResampleFilter, opts ...ResizeOption) Op {
+	return func(img *Image) *Image { return img.Fit(width, height, filter, opts...) }
+}
+
+// OpFill returns an Op calling Fill.
+func OpFill(width, height int, anchor Anchor, filter ResampleFilter, opts ...ResizeOption) Op {
+	return func(img *Image) *Image { return img.Fill(width, height, anchor, filter, opts...) }
+}
+
+// OpThumbnail returns an Op calling Thumbnail.
+func OpThumbnail(width, height int, filter ResampleFilter, opts ...ResizeOption) Op {
+	return func(img *Image) *Image { return img.Thumbnail(width, height, filter, opts...) }
+}
+
+// Cropping and compositing
+
+// OpCrop returns an Op calling Crop.
+func OpCrop(rect image.Rectangle) Op {
+	return func(img *Image) *Image { return img.Crop(rect) }
+}
+
+// OpCropAnchor returns an Op calling CropAnchor.
+func OpCropAnchor(width, height int, anchor Anchor) Op {
+	return func(img *Image) *Image { return img.CropAnchor(width, height, anchor) }
+}
+
+// OpCropCenter returns an Op calling CropCenter.
+func OpCropCenter(width, height int) Op {
+	return func(img *Image) *Image { return img.CropCenter(width, height) }
+}
+
+// OpPaste returns an Op calling Paste.
+func OpPaste(src *Image, pos image.Point) Op {
+	return func(img *Image) *Image { return img.Paste(src, pos) }
+}
+
+// OpPasteCenter returns an Op calling PasteCenter.
+func OpPasteCenter(src *Image) Op {
+	return func(img *Image) *Image { return img.PasteCenter(src) }
+}
+
+// OpOverlay returns an Op calling Overlay.
+func OpOverlay(src *Image, pos image.Point, opacity float64) Op {
+	return func(img *Image) *Image { return img.Overlay(src, pos, opacity) }
+}
+
+// OpOverlayCenter returns an Op calling OverlayCenter.
+func OpOverlayCenter(src *Image, opacity float64) Op {
+	return func(img *Image) *Image { return img.OverlayCenter(src, opacity) }
+}
+
+// Transforms
+
+// OpRotate returns an Op calling Rotate.
+func OpRotate(angle float64, bgColor color.Color, opts ...TransformOption) Op {
+	return func(img *Image) *Image { return img.Rotate(angle, bgColor, opts...) }
+}
+
+// OpRotate90 returns an Op calling Rotate90.
+func OpRotate90() Op { return (*Image).Rotate90 }
+
+// OpRotate180 returns an Op calling Rotate180.
+func OpRotate180() Op { return (*Image).Rotate180 }
+
+// OpRotate270 returns an Op calling Rotate270.
+func OpRotate270() Op { return (*Image).Rotate270 }
+
+// OpFlipH returns an Op calling FlipH.
+func OpFlipH() Op { return (*Image).FlipH }
+
+// OpFlipV returns an Op calling FlipV.
+func OpFlipV() Op { return (*Image).FlipV }
+
+// OpTranspose returns an Op calling Transpose.
+func OpTranspose() Op { return (*Image).Transpose }
+
+// OpTransverse returns an Op calling Transverse.
+func OpTransverse() Op { return (*Image).Transverse }
+
+// OpShearH returns an Op calling ShearH.
+func OpShearH(angle float64, bgColor color.Color, opts ...TransformOption) Op {
+	return func(img *Image) *Image { return img.ShearH(angle, bgColor, opts...) }
+}
+
+// OpShearV returns an Op calling ShearV.
+func OpShearV(angle float64, bgColor color.Color, opts ...TransformOption) Op {
+	return func(img *Image) *Image { return img.ShearV(angle, bgColor, opts...) }
+}
+
+// OpAutoRotateByContent returns an Op calling AutoRotateByContent.
+func OpAutoRotateByContent() Op { return (*Image).AutoRotateByContent }
+
+// Color adjustments
+
+// OpAdjustBrightness returns an Op calling AdjustBrightness.
+func OpAdjustBrightness(percentage float64) Op {
+	return func(img *Image) *Image { return img.AdjustBrightness(percentage) }
+}
+
+// OpAdjustContrast returns an Op calling AdjustContrast.
+func OpAdjustContrast(percentage float64) Op {
+	return func(img *Image) *Image { return img.AdjustContrast(percentage) }
+}
+
+// OpAdjustGamma returns an Op calling AdjustGamma.
+func OpAdjustGamma(gamma float64) Op {
+	return func(img *Image) *Image { return img.AdjustGamma(gamma) }
+}
+
+// OpAdjustSaturation returns an Op calling AdjustSaturation.
+func OpAdjustSaturation(percentage float64) Op {
+	return func(img *Image) *Image { return img.AdjustSaturation(percentage) }
+}
+
+// OpAdjustHue returns an Op calling AdjustHue.
+func OpAdjustHue(shift float64) Op {
+	return func(img *Image) *Image { return img.AdjustHue(shift) }
+}
+
+// OpAdjustSigmoid returns an Op calling AdjustSigmoid.
+func OpAdjustSigmoid(midpoint, factor float64) Op {
+	return func(img *Image) *Image { return img.AdjustSigmoid(midpoint, factor) }
+}
+
+// OpGrayscale returns an Op calling Grayscale.
+func OpGrayscale() Op { return (*Image).Grayscale }
+
+// OpInvert returns an Op calling Invert.
+func OpInvert() Op { return (*Image).Invert }
+
+// OpSimulateColorBlindness returns an Op calling SimulateColorBlindness.
+func OpSimulateColorBlindness(kind ColorBlindness) Op {
+	return func(img *Image) *Image { return img.SimulateColorBlindness(kind) }
+}
+
+// Effects
+
+// OpBlur returns an Op calling Blur.
+func OpBlur(sigma float64) Op {
+	return func(img *Image) *Image { return img.Blur(sigma) }
+}
+
+// OpSharpen returns an Op calling Sharpen.
+func OpSharpen(sigma float64) Op {
+	return func(img *Image) *Image { return img.Sharpen(sigma) }
+}
+
+// OpAddNoise returns an Op calling AddNoise.
+func OpAddNoise(sigma float64, opts ...RandomOption) Op {
+	return func(img *Image) *Image { return img.AddNoise(sigma, opts...) }
+}
+
+// OpAddGrain returns an Op calling AddGrain.
+func OpAddGrain(amount float64, opts ...RandomOption) Op {
+	return func(img *Image) *Image { return img.AddGrain(amount, opts...) }
+}
+
+// OpDither returns an Op calling Dither.
+func OpDither(levels int, opts ...RandomOption) Op {
+	return func(img *Image) *Image { return img.Dither(levels, opts...) }
+}
+
+// OpDenoise returns an Op calling Denoise.
+func OpDenoise(strength float64) Op {
+	return func(img *Image) *Image { return img.Denoise(strength) }
+}
+
+// OpPixelate returns an Op calling Pixelate.
+func OpPixelate(size int) Op {
+	return func(img *Image) *Image { return img.Pixelate(size) }
+}
+
+// OpRedact returns an Op calling Redact.
+func OpRedact(rects []image.Rectangle, opts RedactOptions) Op {
+	return func(img *Image) *Image { return img.Redact(rects, opts) }
+}
+
+// OpConvolve3x3 returns an Op calling Convolve3x3.
+func OpConvolve3x3(kernel [9]float64, options *ConvolveOptions) Op {
+	return func(img *Image) *Image { return img.Convolve3x3(kernel, options) }
+}
+
+// OpConvolve5x5 returns an Op calling Convolve5x5.
+func OpConvolve5x5(kernel [25]float64, options *ConvolveOptions) Op {
+	return func(img *Image) *Image { return img.Convolve5x5(kernel, options) }
+}
+
+// OpWatermark returns an Op calling Watermark.
+func OpWatermark(opts WatermarkOptions) Op {
+	return func(img *Image) *Image { return img.Watermark(opts) }
+}
+
+// OpDrawGuides returns an Op calling DrawGuides.
+func OpDrawGuides(opts GuideOptions) Op {
+	return func(img *Image) *Image { return img.DrawGuides(opts) }
+}
+
+// Selection and retouching
+
+// OpSelectByColor returns an Op calling SelectByColor (the result is a mask).
+func OpSelectByColor(seed image.Point, tolerance int) Op {
+	return func(img *Image) *Image { return img.SelectByColor(seed, tolerance) }
+}
+
+// OpKnockout returns an Op calling Knockout.
+func OpKnockout(mask *Image) Op {
+	return func(img *Image) *Image { return img.Knockout(mask) }
+}
+
+// OpInpaint returns an Op calling Inpaint.
+func OpInpaint(mask *Image) Op {
+	return func(img *Image) *Image { return img.Inpaint(mask) }
+}
+
+// OpDetectDust returns an Op calling DetectDust (the result is a mask).
+func OpDetectDust(sensitivity float64) Op {
+	return func(img *Image) *Image { return img.DetectDust(sensitivity) }
+}
+
+// OpRemoveDust returns an Op calling RemoveDust.
+func OpRemoveDust(sensitivity float64) Op {
+	return func(img *Image) *Image { return img.RemoveDust(sensitivity) }
+}
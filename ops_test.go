@@ -0,0 +1,44 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyAll(t *testing.T) {
+	img := NewImage(100, 80, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	ops := []Op{
+		OpResize(50, 0, Lanczos),
+		OpRotate90(),
+		OpRegion(image.Rect(0, 0, 10, 10), OpInvert()),
+		Chain(OpAdjustBrightness(10), OpGrayscale()),
+	}
+	got := img.ApplyAll(ops...)
+	want := img.Resize(50, 0, Lanczos).Rotate90().Region(image.Rect(0, 0, 10, 10)).Apply(func(sub *Image) *Image {
+		return sub.Invert()
+	}).AdjustBrightness(10).Grayscale()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+	}
+	if !compareNRGBA(got.ToNRGBA(), want.ToNRGBA(), 0) {
+		t.Error("ApplyAll result differs from the chained methods")
+	}
+
+	gotOps, wantOps := got.GetMetadata().Operations, want.GetMetadata().Operations
+	if len(gotOps) != len(wantOps) {
+		t.Fatalf("recorded %d operations, want %d", len(gotOps), len(wantOps))
+	}
+	for i := range gotOps {
+		if gotOps[i].Action != wantOps[i].Action || gotOps[i].Parameters != wantOps[i].Parameters {
+			t.Errorf("operation %d = %s(%s), want %s(%s)", i,
+				gotOps[i].Action, gotOps[i].Parameters, wantOps[i].Action, wantOps[i].Parameters)
+		}
+	}
+
+	if same := img.ApplyAll(); same != img {
+		t.Error("ApplyAll() without ops should return the image itself")
+	}
+}
@@ -0,0 +1,170 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/jpeg"
+	"io"
+)
+
+// OrientationTransform returns the lossless JPEG transform that displays an
+// image with the given EXIF orientation (1-8) upright.
+func OrientationTransform(o int) JPEGTransform {
+	switch o {
+	case orientationFlipH:
+		return JPEGFlipH
+	case orientationRotate180:
+		return JPEGRotate180
+	case orientationFlipV:
+		return JPEGFlipV
+	case orientationTranspose:
+		return JPEGTranspose
+	case orientationRotate270:
+		return JPEGRotate270
+	case orientationTransverse:
+		return JPEGTransverse
+	case orientationRotate90:
+		return JPEGRotate90
+	}
+	return JPEGIdentity
+}
+
+// NormalizeJPEGOrientation applies the EXIF orientation of the JPEG read from
+// r to its pixel data and writes the result to w with the orientation tag
+// reset to 1. All other metadata segments are preserved.
+//
+// The transform is done losslessly on the DCT coefficients when possible.
+// Otherwise the image is decoded, oriented and re-encoded with the given
+// JPEG quality. The returned value reports which path was taken. Images
+// without an orientation tag (or with orientation 1) are copied unchanged.
+//
+// Example:
+//
+//	lossless, err := imgx.NormalizeJPEGOrientation(in, out, 95)
+func NormalizeJPEGOrientation(r io.Reader, w io.Writer, quality int) (lossless bool, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+
+	o := readOrientation(bytes.NewReader(data))
+	if o == orientationUnspecified || o == orientationNormal {
+		_, err := w.Write(data)
+		return true, err
+	}
+
+	j, err := readJPEGCoefficients(bytes.NewReader(data))
+	if err == nil {
+		err = j.transform(OrientationTransform(int(o)))
+	}
+	if err == nil {
+		for _, seg := range j.segments {
+			setEXIFOrientation(seg, orientationNormal)
+		}
+		return true, j.write(w)
+	}
+	if !errors.Is(err, ErrNotLossless) {
+		// Corrupt data, e.g. a forged frame size, fails the pixel path too
+		return false, err
+	}
+
+	// Fall back to the pixel path.
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	img = fixOrientation(img, o)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, JPEG, JPEGQuality(quality)); err != nil {
+		return false, err
+	}
+	segments, err := jpegMetadataSegments(data)
+	if err != nil {
+		return false, err
+	}
+	for _, seg := range segments {
+		setEXIFOrientation(seg, orientationNormal)
+	}
+	_, err = w.Write(spliceJPEGSegments(buf.Bytes(), segments))
+	return false, err
+}
+
+// jpegMetadataSegments returns copies of the APPn and COM segments of a JPEG,
+// skipping Adobe APP14 which describes the original color transform.
+func jpegMetadataSegments(data []byte) ([][]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, ErrUnsupportedFormat
+	}
+	var segments [][]byte
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		if (marker >= 0xe0 && marker <= 0xef && marker != 0xee) || marker == 0xfe {
+			segments = append(segments, bytes.Clone(data[pos:pos+2+length]))
+		}
+		pos += 2 + length
+	}
+	return segments, nil
+}
+
+// spliceJPEGSegments inserts segments right after the SOI marker of data.
+func spliceJPEGSegments(data []byte, segments [][]byte) []byte {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	for _, seg := range segments {
+		out = append(out, seg...)
+	}
+	return append(out, data[2:]...)
+}
+
+// setEXIFOrientation rewrites the orientation tag in IFD0 of a raw APP1 EXIF
+// segment (marker included) in place. It reports whether the tag was found.
+func setEXIFOrientation(seg []byte, o orientation) bool {
+	const tiffStart = 10 // marker (2) + length (2) + "Exif\0\0" (6)
+	if len(seg) < tiffStart+8 || seg[1] != 0xe1 || string(seg[4:10]) != "Exif\x00\x00" {
+		return false
+	}
+	tiff := seg[tiffStart:]
+
+	var order binary.ByteOrder
+	switch binary.BigEndian.Uint16(tiff) {
+	case byteOrderBE:
+		order = binary.BigEndian
+	case byteOrderLE:
+		order = binary.LittleEndian
+	default:
+		return false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return false
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+2 > len(tiff) {
+			return false
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		// The value is the first 2 bytes of the value field, as read by
+		// readOrientation.
+		if entry+10 > len(tiff) {
+			return false
+		}
+		order.PutUint16(tiff[entry+8:], uint16(o))
+		return true
+	}
+	return false
+}
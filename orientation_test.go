@@ -0,0 +1,126 @@
+package imgx
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment builds a minimal big-endian APP1 EXIF segment holding an
+// orientation tag and a second, unrelated tag.
+func exifSegment(o int) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2a, 0x00, 0x00, 0x00, 0x08, // header, IFD0 at offset 8
+		0x00, 0x02, // two entries
+		0x01, 0x0f, 0x00, 0x02, 0x00, 0x00, 0x00, 0x04, 'i', 'm', 'g', 0x00, // Make
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(o), 0x00, 0x00, // Orientation
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	n := len(payload) + 2
+	return append([]byte{0xff, 0xe1, byte(n >> 8), byte(n)}, payload...)
+}
+
+func withEXIF(data []byte, o int) []byte {
+	return spliceJPEGSegments(data, [][]byte{exifSegment(o)})
+}
+
+func TestNormalizeJPEGOrientation(t *testing.T) {
+	testCases := []struct {
+		name         string
+		w, h         int
+		orientation  int
+		wantLossless bool
+	}{
+		{"rotate270 aligned", 64, 48, 6, true},
+		{"rotate90 aligned", 64, 48, 8, true},
+		{"fliph aligned", 64, 48, 2, true},
+		{"transverse unaligned", 40, 30, 7, false},
+		{"normal", 40, 30, 1, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plain := encodeTestJPEG(t, tc.w, tc.h, false)
+			src := withEXIF(plain, tc.orientation)
+
+			var out bytes.Buffer
+			lossless, err := NormalizeJPEGOrientation(bytes.NewReader(src), &out, 95)
+			if err != nil {
+				t.Fatalf("NormalizeJPEGOrientation: %v", err)
+			}
+			if lossless != tc.wantLossless {
+				t.Errorf("lossless = %v, want %v", lossless, tc.wantLossless)
+			}
+
+			if o := ReadOrientation(bytes.NewReader(out.Bytes())); o != 1 {
+				t.Errorf("orientation after normalization = %d, want 1", o)
+			}
+			if !bytes.Contains(out.Bytes(), []byte{'i', 'm', 'g', 0x00}) {
+				t.Error("other EXIF tags were not preserved")
+			}
+
+			want := fixOrientation(decodeTestJPEG(t, plain), orientation(tc.orientation))
+			got := decodeTestJPEG(t, out.Bytes())
+			delta := 4
+			if !lossless {
+				delta = 48 // re-encoding adds chroma error on the synthetic pattern
+			}
+			if !compareNRGBA(got, Clone(want), delta) {
+				t.Errorf("normalized pixels differ from the oriented image")
+			}
+		})
+	}
+}
+
+func TestSetEXIFOrientation(t *testing.T) {
+	seg := exifSegment(6)
+	if !setEXIFOrientation(seg, orientationNormal) {
+		t.Fatal("setEXIFOrientation did not find the orientation tag")
+	}
+	data := spliceJPEGSegments(encodeTestJPEG(t, 8, 8, false), [][]byte{seg})
+	if o := ReadOrientation(bytes.NewReader(data)); o != 1 {
+		t.Fatalf("ReadOrientation = %d, want 1", o)
+	}
+
+	if setEXIFOrientation([]byte{0xff, 0xe0, 0x00, 0x02}, orientationNormal) {
+		t.Fatal("setEXIFOrientation accepted a non-EXIF segment")
+	}
+}
+
+func TestOrientationTransform(t *testing.T) {
+	src := Clone(image.NewNRGBA(image.Rect(0, 0, 3, 2)))
+	src.Pix[0] = 0xff
+	pixel := map[JPEGTransform]func(image.Image) *image.NRGBA{
+		JPEGIdentity: Clone, JPEGFlipH: FlipH, JPEGFlipV: FlipV, JPEGRotate90: Rotate90,
+		JPEGRotate180: Rotate180, JPEGRotate270: Rotate270, JPEGTranspose: Transpose, JPEGTransverse: Transverse,
+	}
+	for o := 1; o <= 8; o++ {
+		want := Clone(fixOrientation(src, orientation(o)))
+		got := pixel[OrientationTransform(o)](src)
+		if !compareNRGBA(got, want, 0) {
+			t.Errorf("OrientationTransform(%d) = %v does not match fixOrientation", o, OrientationTransform(o))
+		}
+	}
+}
+
+func FuzzNormalizeJPEGOrientation(f *testing.F) {
+	for _, o := range []int{2, 6, 7} {
+		f.Add(withEXIF(encodeTestJPEG(f, 16, 8, false), o))
+	}
+	f.Add(withEXIF(encodeTestJPEG(f, 20, 10, true), 8))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil || cfg.Width*cfg.Height > fuzzMaxPixels {
+			return
+		}
+		var out bytes.Buffer
+		if _, err := NormalizeJPEGOrientation(bytes.NewReader(data), &out, 90); err != nil {
+			return
+		}
+		if o := ReadOrientation(bytes.NewReader(out.Bytes())); o > 1 {
+			t.Errorf("orientation after normalization = %d, want 0 or 1", o)
+		}
+	})
+}
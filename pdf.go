@@ -0,0 +1,142 @@
+package imgx
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+// PDFOptions configures EncodePDF.
+type PDFOptions struct {
+	// DPI sets the page size: an image DPI pixels wide makes a page one
+	// inch wide. Default is 300, the usual resolution of document scans.
+	DPI float64
+
+	// Quality is the JPEG quality of grayscale and color pages. Default is
+	// 85.
+	Quality int
+}
+
+// EncodePDF writes img as a single-page PDF document, with the page sized
+// to the image at the given DPI. The storage is chosen for compactness:
+// black-and-white images (only pure black and white pixels, such as the
+// result of AdaptiveThreshold) become 1-bit Flate-compressed bitmaps, other
+// gray images 8-bit grayscale JPEGs and the rest RGB JPEGs. Transparency is
+// flattened onto white.
+//
+// Example:
+//
+//	f, err := os.Create("receipt.pdf")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	err = imgx.EncodePDF(f, imgx.AdaptiveThreshold(page, 0, 0), imgx.PDFOptions{})
+func EncodePDF(w io.Writer, img image.Image, opts PDFOptions) error {
+	if opts.DPI <= 0 {
+		opts.DPI = 300
+	}
+	if opts.Quality <= 0 {
+		opts.Quality = 85
+	}
+	src := Clone(img)
+	b := src.Bounds()
+	if b.Empty() {
+		return fmt.Errorf("imgx: cannot encode an empty image as PDF")
+	}
+
+	// Flatten onto white and classify the pixels
+	gray, bilevel := true, true
+	for i := 0; i < len(src.Pix); i += 4 {
+		px := src.Pix[i : i+4 : i+4]
+		if a := px[3]; a != 0xff {
+			for c := 0; c < 3; c++ {
+				px[c] = uint8((int(px[c])*int(a) + 0xff*(0xff-int(a)) + 0x7f) / 0xff)
+			}
+			px[3] = 0xff
+		}
+		if px[0] != px[1] || px[1] != px[2] {
+			gray, bilevel = false, false
+		} else if px[0] != 0 && px[0] != 0xff {
+			bilevel = false
+		}
+	}
+
+	var data bytes.Buffer
+	var colorSpace, filter string
+	bits := 8
+	switch {
+	case bilevel:
+		colorSpace, filter, bits = "/DeviceGray", "/FlateDecode", 1
+		zw := zlib.NewWriter(&data)
+		row := make([]byte, (b.Dx()+7)/8)
+		for y := 0; y < b.Dy(); y++ {
+			clear(row)
+			for x := 0; x < b.Dx(); x++ {
+				if src.Pix[src.PixOffset(x, y)] != 0 {
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+			if _, err := zw.Write(row); err != nil {
+				return err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	case gray:
+		colorSpace, filter = "/DeviceGray", "/DCTDecode"
+		g := image.NewGray(b)
+		for i := range g.Pix {
+			g.Pix[i] = src.Pix[i*4]
+		}
+		if err := jpeg.Encode(&data, g, &jpeg.Options{Quality: opts.Quality}); err != nil {
+			return err
+		}
+	default:
+		colorSpace, filter = "/DeviceRGB", "/DCTDecode"
+		rgba := &image.RGBA{Pix: src.Pix, Stride: src.Stride, Rect: src.Rect}
+		if err := jpeg.Encode(&data, rgba, &jpeg.Options{Quality: opts.Quality}); err != nil {
+			return err
+		}
+	}
+
+	// Page size in points (1/72 inch)
+	pw := float64(b.Dx()) * 72 / opts.DPI
+	ph := float64(b.Dy()) * 72 / opts.DPI
+	content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", pw, ph)
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>", pw, ph), nil)
+	object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent %d /Filter %s /Length %d >>",
+		b.Dx(), b.Dy(), colorSpace, bits, filter, data.Len()), data.Bytes())
+	object(fmt.Sprintf("<< /Length %d >>", len(content)), []byte(content))
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
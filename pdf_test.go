@@ -0,0 +1,129 @@
+package imgx
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEncodePDF(t *testing.T) {
+	bw := New(20, 10, color.White)
+	bw.SetNRGBA(0, 0, color.NRGBA{0, 0, 0, 255})
+	bw.SetNRGBA(9, 1, color.NRGBA{0, 0, 0, 255})
+	translucent := New(20, 10, color.NRGBA{255, 0, 0, 0})
+
+	testCases := []struct {
+		name  string
+		img   image.Image
+		opts  PDFOptions
+		dict  []string
+		media string
+	}{
+		{
+			name:  "black and white",
+			img:   bw,
+			dict:  []string{"/ColorSpace /DeviceGray", "/BitsPerComponent 1", "/Filter /FlateDecode"},
+			media: "[0 0 4.80 2.40]",
+		},
+		{
+			name:  "transparent flattens to white",
+			img:   translucent,
+			opts:  PDFOptions{DPI: 72},
+			dict:  []string{"/ColorSpace /DeviceGray", "/BitsPerComponent 1"},
+			media: "[0 0 20.00 10.00]",
+		},
+		{
+			name:  "gray",
+			img:   New(20, 10, color.Gray{128}),
+			dict:  []string{"/ColorSpace /DeviceGray", "/BitsPerComponent 8", "/Filter /DCTDecode"},
+			media: "[0 0 4.80 2.40]",
+		},
+		{
+			name:  "color",
+			img:   testdataFlowerJPG.ToNRGBA(),
+			opts:  PDFOptions{DPI: 150},
+			dict:  []string{"/ColorSpace /DeviceRGB", "/BitsPerComponent 8", "/Filter /DCTDecode"},
+			media: fmt.Sprintf("[0 0 %.2f %.2f]", float64(testdataFlowerJPG.Bounds().Dx())*72/150, float64(testdataFlowerJPG.Bounds().Dy())*72/150),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodePDF(&buf, tc.img, tc.opts); err != nil {
+				t.Fatalf("EncodePDF() error = %v", err)
+			}
+			pdf := buf.String()
+			if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+				t.Fatal("missing PDF header or trailer")
+			}
+			for _, want := range append(tc.dict, "/MediaBox "+tc.media) {
+				if !strings.Contains(pdf, want) {
+					t.Errorf("PDF does not contain %q", want)
+				}
+			}
+			checkPDFXref(t, pdf)
+		})
+	}
+
+	if err := EncodePDF(io.Discard, &image.NRGBA{}, PDFOptions{}); err == nil {
+		t.Error("EncodePDF(empty) should fail")
+	}
+}
+
+func TestEncodePDFBilevelData(t *testing.T) {
+	img := New(10, 2, color.White)
+	img.SetNRGBA(0, 0, color.NRGBA{0, 0, 0, 255})
+	img.SetNRGBA(9, 1, color.NRGBA{0, 0, 0, 255})
+	var buf bytes.Buffer
+	if err := EncodePDF(&buf, img, PDFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	pdf := buf.Bytes()
+	start := bytes.Index(pdf, []byte("/FlateDecode"))
+	start += bytes.Index(pdf[start:], []byte("stream\n")) + len("stream\n")
+	zr, err := zlib.NewReader(bytes.NewReader(pdf[start:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 is white; rows are padded with zero bits to whole bytes
+	want := []byte{0x7f, 0xc0, 0xff, 0x80}
+	if !bytes.Equal(data, want) {
+		t.Errorf("bitmap = %x, want %x", data, want)
+	}
+}
+
+// checkPDFXref verifies that the cross-reference table points at the
+// objects.
+func checkPDFXref(t *testing.T, pdf string) {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	if len(entries) != 5 {
+		t.Fatalf("xref has %d objects, want 5", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(pdf[offset:], want) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, pdf[offset:offset+10], want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// Hash is a 64-bit perceptual image hash. Visually similar images have
+// hashes with a small Hamming distance, regardless of scaling, re-encoding
+// or minor color adjustments.
+type Hash uint64
+
+// Distance returns the Hamming distance between two hashes (0-64).
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h) ^ uint64(other))
+}
+
+// String returns the hash as 16 hex digits.
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// ParseHash parses a hash formatted by Hash.String.
+func ParseHash(s string) (Hash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("imgx: invalid hash %q", s)
+	}
+	return Hash(v), nil
+}
+
+// pHashSize is the side of the grayscale thumbnail transformed by PerceptualHash.
+const pHashSize = 32
+
+var pHashCos [8][pHashSize]float64
+
+func init() {
+	for u := 0; u < 8; u++ {
+		for x := 0; x < pHashSize; x++ {
+			pHashCos[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * pHashSize))
+		}
+	}
+}
+
+// PerceptualHash computes the DCT-based perceptual hash (pHash) of img.
+// The image is reduced to a 32x32 grayscale thumbnail, transformed with a
+// DCT, and each of the 8x8 lowest frequencies is compared with their median.
+//
+// Example:
+//
+//	d := imgx.PerceptualHash(a).Distance(imgx.PerceptualHash(b))
+//	if d <= 10 {
+//		// near-duplicates
+//	}
+func PerceptualHash(img image.Image) Hash {
+	lum := hashThumbnail(img, pHashSize, pHashSize)
+
+	// Separable DCT-II, keeping only the 8 lowest frequencies per axis.
+	var rows [pHashSize][8]float64
+	for y := 0; y < pHashSize; y++ {
+		for u := 0; u < 8; u++ {
+			var s float64
+			for x := 0; x < pHashSize; x++ {
+				s += lum[y*pHashSize+x] * pHashCos[u][x]
+			}
+			rows[y][u] = s
+		}
+	}
+	var coeffs [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var s float64
+			for y := 0; y < pHashSize; y++ {
+				s += rows[y][u] * pHashCos[v][y]
+			}
+			coeffs[v*8+u] = s
+		}
+	}
+
+	// The DC term only reflects overall brightness; leave it out of the median.
+	sorted := make([]float64, 63)
+	copy(sorted, coeffs[1:])
+	sort.Float64s(sorted)
+	median := (sorted[31] + sorted[32]) / 2
+
+	var h Hash
+	for i, c := range coeffs {
+		if c > median {
+			h |= 1 << uint(63-i)
+		}
+	}
+	return h
+}
+
+// DifferenceHash computes the gradient-based difference hash (dHash) of img.
+// It is cheaper than PerceptualHash and robust to brightness changes, but
+// slightly less tolerant of crops.
+func DifferenceHash(img image.Image) Hash {
+	lum := hashThumbnail(img, 9, 8)
+	var h Hash
+	bit := 63
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if lum[y*9+x] > lum[y*9+x+1] {
+				h |= 1 << uint(bit)
+			}
+			bit--
+		}
+	}
+	return h
+}
+
+// hashThumbnail resizes img to w x h (ignoring aspect ratio) and returns its
+// luminance values.
+func hashThumbnail(img image.Image, w, h int) []float64 {
+	small := Resize(img, w, h, Box)
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*small.Stride + x*4
+			px := small.Pix[i : i+4 : i+4]
+			lum[y*w+x] = luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+		}
+	}
+	return lum
+}
+
+// PerceptualHash returns the perceptual hash of the image
+func (img *Image) PerceptualHash() Hash {
+	return PerceptualHash(img.data)
+}
+
+// ClusterHashes groups indexes of hashes that lie within maxDistance of each
+// other (transitively). Only groups with at least two members are returned.
+func ClusterHashes(hashes []Hash, maxDistance int) [][]int {
+	return Cluster(len(hashes), func(i, j int) bool {
+		return hashes[i].Distance(hashes[j]) <= maxDistance
+	})
+}
+
+// Cluster groups the indexes 0..n-1 into connected components of the
+// similar relation. Groups are returned sorted by their first index, and
+// only groups with at least two members are returned.
+func Cluster(n int, similar func(i, j int) bool) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if find(i) != find(j) && similar(i, j) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := 0; i < n; i++ {
+		r := find(i)
+		if _, ok := groups[r]; !ok {
+			roots = append(roots, r)
+		}
+		groups[r] = append(groups[r], i)
+	}
+
+	var clusters [][]int
+	for _, r := range roots {
+		if len(groups[r]) > 1 {
+			clusters = append(clusters, groups[r])
+		}
+	}
+	return clusters
+}
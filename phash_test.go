@@ -0,0 +1,95 @@
+package imgx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPerceptualHash(t *testing.T) {
+	flower := testdataFlowerJPG.ToNRGBA()
+	base := PerceptualHash(flower)
+
+	similar := []struct {
+		name string
+		hash Hash
+	}{
+		{"resized", PerceptualHash(Resize(flower, 120, 0, Lanczos))},
+		{"brighter", PerceptualHash(AdjustBrightness(flower, 10))},
+		{"blurred", PerceptualHash(Blur(flower, 1))},
+	}
+	for _, tc := range similar {
+		if d := base.Distance(tc.hash); d > 10 {
+			t.Errorf("PerceptualHash distance to %s copy = %d, want <= 10", tc.name, d)
+		}
+	}
+
+	if d := base.Distance(PerceptualHash(testdataBranchJPG.ToNRGBA())); d < 16 {
+		t.Errorf("PerceptualHash distance between different images = %d, want >= 16", d)
+	}
+	if got := testdataFlowerJPG.PerceptualHash(); got != base {
+		t.Errorf("Image.PerceptualHash() = %v, want %v", got, base)
+	}
+}
+
+func TestDifferenceHash(t *testing.T) {
+	flower := testdataFlowerJPG.ToNRGBA()
+	base := DifferenceHash(flower)
+	if d := base.Distance(DifferenceHash(Resize(flower, 100, 0, Linear))); d > 10 {
+		t.Errorf("DifferenceHash distance to resized copy = %d, want <= 10", d)
+	}
+	if d := base.Distance(DifferenceHash(testdataBranchJPG.ToNRGBA())); d < 16 {
+		t.Errorf("DifferenceHash distance between different images = %d, want >= 16", d)
+	}
+}
+
+func TestHashStringRoundTrip(t *testing.T) {
+	h := Hash(0x00ff00ff12345678)
+	if h.String() != "00ff00ff12345678" {
+		t.Fatalf("Hash.String() = %q", h.String())
+	}
+	got, err := ParseHash(h.String())
+	if err != nil || got != h {
+		t.Fatalf("ParseHash(%q) = %v, %v, want %v", h.String(), got, err, h)
+	}
+	if _, err := ParseHash("xyz"); err == nil {
+		t.Fatal("ParseHash(\"xyz\") should fail")
+	}
+}
+
+func TestClusterHashes(t *testing.T) {
+	hashes := []Hash{
+		0x0000000000000000,
+		0xffffffffffffffff,
+		0x0000000000000003, // 2 bits from #0
+		0x000000000000000f, // 2 bits from #2, 4 from #0
+		0xfffffffffffffff0, // 4 bits from #1
+		0x00000000ffff0000,
+	}
+	got := ClusterHashes(hashes, 2)
+	want := [][]int{{0, 2, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ClusterHashes(_, 2) = %v, want %v", got, want)
+	}
+
+	got = ClusterHashes(hashes, 4)
+	want = [][]int{{0, 2, 3}, {1, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ClusterHashes(_, 4) = %v, want %v", got, want)
+	}
+}
+
+func FuzzParseHash(f *testing.F) {
+	for _, s := range []string{"0000000000000000", "ffffffffffffffff", "8f3a", "", "g", "10000000000000000"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		h, err := ParseHash(s)
+		if err != nil {
+			return
+		}
+		if again, err := ParseHash(h.String()); err != nil || again != h {
+			t.Errorf("ParseHash(%q) = %v, but its String parses as %v, %v", s, h, again, err)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package imgx
+
+import (
+	"encoding/binary"
+	"hash"
+	"image"
+	"image/color"
+)
+
+// PixelHash writes the pixels of img to h and returns the digest. Unlike a
+// hash of the file, it only depends on the pixels: a PNG re-encoded as TIFF
+// or WebP lossless, or with other compression settings or metadata, has the
+// same pixel hash. JPEG and lossy WebP re-encodes change the pixels.
+//
+// The digest covers the dimensions and every pixel as 16-bit
+// non-premultiplied RGBA, so 16-bit images are compared at full depth. The
+// color of fully transparent pixels is ignored. The EXIF orientation is not
+// applied: decode img without AutoOrientation.
+//
+// Example:
+//
+//	sum := imgx.PixelHash(img, sha256.New())
+//	fmt.Printf("%x\n", sum)
+func PixelHash(img image.Image, h hash.Hash) []byte {
+	b := img.Bounds()
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(header[4:8], uint32(b.Dy()))
+	h.Write(header[:])
+
+	row := make([]byte, b.Dx()*8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := pixelNRGBA64(img, x, y)
+			if c.A == 0 {
+				c = color.NRGBA64{}
+			}
+			p := row[(x-b.Min.X)*8:]
+			binary.BigEndian.PutUint16(p[0:2], c.R)
+			binary.BigEndian.PutUint16(p[2:4], c.G)
+			binary.BigEndian.PutUint16(p[4:6], c.B)
+			binary.BigEndian.PutUint16(p[6:8], c.A)
+		}
+		h.Write(row)
+	}
+	return h.Sum(nil)
+}
+
+// pixelNRGBA64 returns the pixel at (x, y) as 16-bit non-premultiplied
+// color. Non-premultiplied and gray colors are widened exactly, so an image
+// decoded as *image.NRGBA and its palette or 16-bit equivalent agree.
+func pixelNRGBA64(img image.Image, x, y int) color.NRGBA64 {
+	switch c := img.At(x, y).(type) {
+	case color.NRGBA64:
+		return c
+	case color.NRGBA:
+		return color.NRGBA64{uint16(c.R) * 0x101, uint16(c.G) * 0x101, uint16(c.B) * 0x101, uint16(c.A) * 0x101}
+	case color.Gray:
+		v := uint16(c.Y) * 0x101
+		return color.NRGBA64{v, v, v, 0xffff}
+	case color.Gray16:
+		return color.NRGBA64{c.Y, c.Y, c.Y, 0xffff}
+	default:
+		return color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	}
+}
@@ -0,0 +1,94 @@
+package imgx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestPixelHash(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x * 30), uint8(y * 40), 200, uint8(255 - x*20)})
+		}
+	}
+	want := PixelHash(src, sha256.New())
+
+	// Lossless re-encodes decode to the same pixels
+	for _, format := range []Format{PNG, TIFF} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, format); err != nil {
+			t.Fatal(err)
+		}
+		img, err := Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := PixelHash(img, sha256.New()); !bytes.Equal(got, want) {
+			t.Errorf("%v re-encode changed the pixel hash", format)
+		}
+	}
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := encoder.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if img, _ := Decode(&buf); !bytes.Equal(PixelHash(img, sha256.New()), want) {
+		t.Error("PNG compression level changed the pixel hash")
+	}
+
+	// A palette image and its NRGBA and NRGBA64 equivalents agree
+	palette := color.Palette{color.NRGBA{10, 20, 30, 128}, color.NRGBA{200, 100, 50, 255}}
+	paletted := image.NewPaletted(image.Rect(0, 0, 2, 1), palette)
+	paletted.SetColorIndex(1, 0, 1)
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	nrgba.SetNRGBA(0, 0, color.NRGBA{10, 20, 30, 128})
+	nrgba.SetNRGBA(1, 0, color.NRGBA{200, 100, 50, 255})
+	nrgba64 := image.NewNRGBA64(nrgba.Rect)
+	nrgba64.SetNRGBA64(0, 0, color.NRGBA64{10 * 0x101, 20 * 0x101, 30 * 0x101, 128 * 0x101})
+	nrgba64.SetNRGBA64(1, 0, color.NRGBA64{200 * 0x101, 100 * 0x101, 50 * 0x101, 0xffff})
+	h := PixelHash(nrgba, sha256.New())
+	if !bytes.Equal(PixelHash(paletted, sha256.New()), h) || !bytes.Equal(PixelHash(nrgba64, sha256.New()), h) {
+		t.Error("paletted, NRGBA and NRGBA64 versions of an image have different pixel hashes")
+	}
+
+	// Gray and opaque RGBA agree
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	gray.Pix = []uint8{0, 80, 160, 255}
+	rgba := image.NewRGBA(gray.Rect)
+	for i, v := range gray.Pix {
+		rgba.Pix[i*4], rgba.Pix[i*4+1], rgba.Pix[i*4+2], rgba.Pix[i*4+3] = v, v, v, 255
+	}
+	if !bytes.Equal(PixelHash(gray, sha256.New()), PixelHash(rgba, sha256.New())) {
+		t.Error("gray and RGBA versions of an image have different pixel hashes")
+	}
+
+	// The color of transparent pixels is ignored
+	transparent := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	transparent2 := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	transparent2.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 0})
+	if !bytes.Equal(PixelHash(transparent, sha256.New()), PixelHash(transparent2, sha256.New())) {
+		t.Error("the color of a transparent pixel changed the pixel hash")
+	}
+
+	// Any pixel change, and the dimensions, change the hash
+	changed := Clone(src)
+	changed.Pix[5*changed.Stride+7*4]++
+	if bytes.Equal(PixelHash(changed, sha256.New()), want) {
+		t.Error("a changed pixel kept the pixel hash")
+	}
+	wide, tall := image.NewNRGBA(image.Rect(0, 0, 4, 1)), image.NewNRGBA(image.Rect(0, 0, 1, 4))
+	if bytes.Equal(PixelHash(wide, sha256.New()), PixelHash(tall, sha256.New())) {
+		t.Error("4x1 and 1x4 images have the same pixel hash")
+	}
+
+	// The origin of the bounds doesn't matter
+	sub := src.SubImage(image.Rect(2, 2, 5, 5))
+	if !bytes.Equal(PixelHash(sub, sha256.New()), PixelHash(Clone(sub), sha256.New())) {
+		t.Error("a sub-image and its copy have different pixel hashes")
+	}
+}
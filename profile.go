@@ -0,0 +1,153 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OperationTiming is the cost of one operation of a profiled image
+type OperationTiming struct {
+	Action     string        // Operation name as in ProcessingMetadata.Operations, or "decode" and "encode"
+	Duration   time.Duration // Wall time
+	Allocs     uint64        // Heap objects allocated
+	AllocBytes uint64        // Heap bytes allocated
+	InputSize  image.Point   // Size of the image the operation started from
+	OutputSize image.Point   // Size of the image it produced
+}
+
+// Timings are the operation timings of a profiled image, oldest first
+type Timings []OperationTiming
+
+// Total returns the wall time of all operations.
+func (t Timings) Total() time.Duration {
+	var total time.Duration
+	for _, op := range t {
+		total += op.Duration
+	}
+	return total
+}
+
+// String returns a one-line summary such as
+// "decode 40ms, resize 120ms, blur 340ms, encode 95ms (total 595ms)", or ""
+// without timings.
+func (t Timings) String() string {
+	if len(t) == 0 {
+		return ""
+	}
+	parts := make([]string, len(t))
+	for i, op := range t {
+		parts[i] = op.Action + " " + formatDuration(op.Duration)
+	}
+	return fmt.Sprintf("%s (total %s)", strings.Join(parts, ", "), formatDuration(t.Total()))
+}
+
+// formatDuration rounds d for reports: whole milliseconds above 10ms,
+// three significant digits below
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 10*time.Millisecond:
+		return d.Round(time.Millisecond).String()
+	case d >= 10*time.Microsecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
+
+// profile is shared by the images derived from one profiled image. It
+// holds the end of the last operation, where the next one starts.
+type profile struct {
+	mu      sync.Mutex
+	mark    time.Time
+	mallocs uint64
+	bytes   uint64
+	start   image.Point // Size of the image profiling started from
+}
+
+// newProfile starts a profile of an image of size
+func newProfile(size image.Point) *profile {
+	p := &profile{start: size}
+	p.begin()
+	return p
+}
+
+// begin moves the mark to now, so that the next record measures from here
+func (p *profile) begin() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mark, p.mallocs, p.bytes = time.Now(), ms.Mallocs, ms.TotalAlloc
+}
+
+// record appends to m the timing of action, from the mark to now, and
+// moves the mark. An empty action is the last operation of m.
+func (p *profile) record(m *ProcessingMetadata, action string, size image.Point) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if action == "" && len(m.Operations) > 0 {
+		action = m.Operations[len(m.Operations)-1].Action
+	}
+	t := OperationTiming{
+		Action:     action,
+		Duration:   now.Sub(p.mark),
+		Allocs:     ms.Mallocs - p.mallocs,
+		AllocBytes: ms.TotalAlloc - p.bytes,
+		InputSize:  p.start,
+		OutputSize: size,
+	}
+	if n := len(m.timings); n > 0 {
+		t.InputSize = m.timings[n-1].OutputSize
+	}
+	m.timings = append(m.timings, t)
+	p.mark, p.mallocs, p.bytes = now, ms.Mallocs, ms.TotalAlloc
+}
+
+// newImage returns the image of data with meta, the metadata of the
+// operation that produced it, recording its timing if meta is profiled
+func newImage(data *image.NRGBA, meta *ProcessingMetadata) *Image {
+	if meta != nil && meta.profile != nil {
+		meta.profile.record(meta, "", data.Bounds().Size())
+	}
+	return &Image{data: data, metadata: meta}
+}
+
+// Profile returns the image with profiling enabled: it and the images
+// derived from it record the wall time, allocations and sizes of their
+// operations, and Save adds the encoding. Use Options.Profile to include
+// the decoding. Profiling starts afresh on every call.
+//
+// An operation is measured from the end of the previous operation of the
+// profile, so work done between chained calls counts toward the next
+// operation; allocations are those of the whole process. Profile one
+// pipeline at a time for meaningful numbers.
+//
+// Example:
+//
+//	out := img.Profile().Resize(1600, 0, imgx.Lanczos).Blur(2)
+//	out.Save("out.jpg")
+//	log.Println(out.Timings()) // resize 120ms, blur 340ms, encode 95ms (total 555ms)
+func (img *Image) Profile() *Image {
+	meta := img.metadata.Clone()
+	meta.timings = nil
+	meta.profile = newProfile(img.data.Bounds().Size())
+	return &Image{data: img.data, metadata: meta}
+}
+
+// Timings returns the operation timings of a profiled image, or nil if it
+// is not profiled.
+func (img *Image) Timings() Timings {
+	if img.metadata == nil || img.metadata.profile == nil {
+		return nil
+	}
+	img.metadata.profile.mu.Lock()
+	defer img.metadata.profile.mu.Unlock()
+	return append(Timings(nil), img.metadata.timings...)
+}
@@ -0,0 +1,88 @@
+package imgx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProfile(t *testing.T) {
+	img := NewImage(200, 100, color.White)
+	if img.Timings() != nil {
+		t.Errorf("Timings() of an unprofiled image = %v, want nil", img.Timings())
+	}
+
+	out := img.Profile().Resize(100, 0, Lanczos).Blur(2).CropCenter(40, 40)
+	timings := out.Timings()
+	if len(timings) != 3 {
+		t.Fatalf("got %d timings, want 3: %v", len(timings), timings)
+	}
+	want := []struct {
+		action  string
+		in, out image.Point
+	}{
+		{"resize", image.Pt(200, 100), image.Pt(100, 50)},
+		{"blur", image.Pt(100, 50), image.Pt(100, 50)},
+		{"cropCenter", image.Pt(100, 50), image.Pt(40, 40)},
+	}
+	for i, w := range want {
+		got := timings[i]
+		if got.Action != w.action || got.InputSize != w.in || got.OutputSize != w.out {
+			t.Errorf("timing %d = %s %v -> %v, want %s %v -> %v", i, got.Action, got.InputSize, got.OutputSize, w.action, w.in, w.out)
+		}
+		if got.Duration <= 0 {
+			t.Errorf("timing %d has no duration", i)
+		}
+	}
+	if timings[0].AllocBytes < 100*50*4 {
+		t.Errorf("resize allocated %d bytes, want at least the output", timings[0].AllocBytes)
+	}
+
+	// Branches keep their own timings
+	branch := out.Invert()
+	if len(out.Timings()) != 3 || len(branch.Timings()) != 4 {
+		t.Errorf("got %d and %d timings after branching, want 3 and 4", len(out.Timings()), len(branch.Timings()))
+	}
+
+	// Saving adds the encoding
+	if err := out.Save(filepath.Join(t.TempDir(), "out.png"), WithoutMetadata()); err != nil {
+		t.Fatal(err)
+	}
+	if timings := out.Timings(); len(timings) != 4 || timings[3].Action != "encode" {
+		t.Errorf("timings after Save = %v, want encode last", timings)
+	}
+}
+
+func TestProfileDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 30, 20))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeWithMetadata(&buf, Options{Profile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timings := img.Grayscale().Timings()
+	if len(timings) != 2 || timings[0].Action != "decode" || timings[0].OutputSize != image.Pt(30, 20) || timings[1].InputSize != image.Pt(30, 20) {
+		t.Errorf("timings = %+v, want decode then grayscale", timings)
+	}
+}
+
+func TestTimingsString(t *testing.T) {
+	timings := Timings{
+		{Action: "resize", Duration: 120 * time.Millisecond},
+		{Action: "blur", Duration: 340*time.Millisecond + 400*time.Microsecond},
+		{Action: "encode", Duration: 1500 * time.Microsecond},
+	}
+	got := timings.String()
+	if want := "resize 120ms, blur 340ms, encode 1.5ms (total 462ms)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := Timings(nil).String(); got != "" {
+		t.Errorf("String() of no timings = %q, want \"\"", got)
+	}
+}
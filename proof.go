@@ -0,0 +1,224 @@
+package imgx
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/razzkumar/imgx/colorspace"
+)
+
+// DefaultProofTolerance is the default CIEDE2000 tolerance of CompareProof,
+// a difference noticeable only on close inspection.
+const DefaultProofTolerance = 3.0
+
+// ErrProofMismatch means a proof and its reference have different aspect
+// ratios, so they cannot be compared region by region.
+var ErrProofMismatch = errors.New("imgx: proof and reference aspect ratios differ")
+
+// ProofOptions configures CompareProof.
+type ProofOptions struct {
+	// Columns and Rows divide the images into regions that are compared by
+	// their mean color. Default is 8x8.
+	Columns, Rows int
+
+	// Tolerance is the largest CIEDE2000 difference a region may have.
+	// Default is DefaultProofTolerance.
+	Tolerance float64
+}
+
+// ProofRegion is the comparison of one region of a proof.
+type ProofRegion struct {
+	Rect      image.Rectangle `json:"rect"`      // In reference coordinates
+	Proof     color.NRGBA     `json:"proof"`     // Mean color of the proof
+	Reference color.NRGBA     `json:"reference"` // Mean color of the reference
+	DeltaE    float64         `json:"delta_e"`   // CIEDE2000 between the means
+	Pass      bool            `json:"pass"`
+}
+
+// ProofReport is the result of CompareProof.
+type ProofReport struct {
+	MeanDeltaE float64       `json:"mean_delta_e"` // Mean over the regions
+	MaxDeltaE  float64       `json:"max_delta_e"`
+	Tolerance  float64       `json:"tolerance"`
+	Failed     int           `json:"failed"` // Regions over the tolerance
+	Pass       bool          `json:"pass"`
+	Regions    []ProofRegion `json:"regions"`
+}
+
+// Worst returns the region with the largest difference.
+func (r *ProofReport) Worst() ProofRegion {
+	var worst ProofRegion
+	for _, region := range r.Regions {
+		if region.DeltaE >= worst.DeltaE {
+			worst = region
+		}
+	}
+	return worst
+}
+
+// CompareProof compares the colors of a proof (e.g. a scan of a print)
+// with its reference. Both images are divided into a grid of regions, and
+// the mean colors of each pair of regions are compared with CIEDE2000; the
+// proof passes when no region exceeds the tolerance. Comparing means makes
+// the check robust to halftone patterns and scanner noise.
+//
+// A proof of a different size is resized to the reference first. It must
+// have the same aspect ratio (within 2%), otherwise ErrProofMismatch is
+// returned.
+//
+// Example:
+//
+//	report, err := imgx.CompareProof(scan, original, imgx.ProofOptions{Tolerance: 2})
+//	if err == nil && !report.Pass {
+//		worst := report.Worst()
+//		fmt.Printf("ΔE00 %.1f at %v\n", worst.DeltaE, worst.Rect)
+//	}
+func CompareProof(proof, reference image.Image, opts ProofOptions) (*ProofReport, error) {
+	if opts.Columns <= 0 {
+		opts.Columns = 8
+	}
+	if opts.Rows <= 0 {
+		opts.Rows = 8
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultProofTolerance
+	}
+
+	p, ref, err := alignProof(proof, reference)
+	if err != nil {
+		return nil, err
+	}
+	b := ref.Bounds()
+	columns, rows := min(opts.Columns, b.Dx()), min(opts.Rows, b.Dy())
+	pLab, refLab := colorspace.ToLabImage(p), colorspace.ToLabImage(ref)
+
+	report := &ProofReport{Tolerance: opts.Tolerance, Pass: true}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			r := image.Rect(
+				b.Min.X+col*b.Dx()/columns, b.Min.Y+row*b.Dy()/rows,
+				b.Min.X+(col+1)*b.Dx()/columns, b.Min.Y+(row+1)*b.Dy()/rows,
+			)
+			pMean, refMean := meanLab(pLab, r), meanLab(refLab, r)
+			region := ProofRegion{
+				Rect:      r,
+				Proof:     color.NRGBAModel.Convert(pMean).(color.NRGBA),
+				Reference: color.NRGBAModel.Convert(refMean).(color.NRGBA),
+				DeltaE:    colorspace.DeltaE2000(pMean, refMean),
+			}
+			region.Pass = region.DeltaE <= opts.Tolerance
+			if !region.Pass {
+				report.Failed++
+				report.Pass = false
+			}
+			report.MeanDeltaE += region.DeltaE
+			report.MaxDeltaE = math.Max(report.MaxDeltaE, region.DeltaE)
+			report.Regions = append(report.Regions, region)
+		}
+	}
+	report.MeanDeltaE /= float64(len(report.Regions))
+	return report, nil
+}
+
+// CompareProof compares the colors of the image with a reference (see CompareProof)
+func (img *Image) CompareProof(reference image.Image, opts ProofOptions) (*ProofReport, error) {
+	return CompareProof(img.data, reference, opts)
+}
+
+// ProofHeatmap renders the per-pixel CIEDE2000 difference between a proof
+// and its reference over a dimmed grayscale copy of the reference: matching
+// areas stay gray, differences go from green through yellow (at the
+// tolerance) to red (twice the tolerance and above). Both images are
+// slightly blurred first, so halftone and noise don't dominate the map. A
+// tolerance <= 0 uses DefaultProofTolerance.
+//
+// Example:
+//
+//	heatmap, err := imgx.ProofHeatmap(scan, original, 3)
+func ProofHeatmap(proof, reference image.Image, tolerance float64) (*image.NRGBA, error) {
+	if tolerance <= 0 {
+		tolerance = DefaultProofTolerance
+	}
+	p, ref, err := alignProof(proof, reference)
+	if err != nil {
+		return nil, err
+	}
+	b := ref.Bounds()
+	sigma := math.Max(1, float64(min(b.Dx(), b.Dy()))/300)
+	pLab := colorspace.ToLabImage(Blur(p, sigma))
+	refLab := colorspace.ToLabImage(Blur(ref, sigma))
+
+	dst := Grayscale(ref)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			d := colorspace.DeltaE2000(pLab.LabAt(x, y), refLab.LabAt(x, y))
+			heat := heatColor(math.Min(1, d/(2*tolerance)))
+			i := dst.PixOffset(x, y)
+			px := dst.Pix[i : i+4 : i+4]
+			// Dim the background and blend in the heat color, fully
+			// from the tolerance on
+			alpha := 0.8 * math.Min(1, d/tolerance)
+			for c := 0; c < 3; c++ {
+				px[c] = clamp(float64(px[c])*0.5*(1-alpha) + float64(heat[c])*alpha)
+			}
+			px[3] = 255
+		}
+	}
+	return dst, nil
+}
+
+// ProofHeatmap renders the color difference to a reference (see ProofHeatmap)
+func (img *Image) ProofHeatmap(reference image.Image, tolerance float64) (*Image, error) {
+	newData, err := ProofHeatmap(img.data, reference, tolerance)
+	if err != nil {
+		return nil, err
+	}
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("proofHeatmap", fmt.Sprintf("tolerance=%.1f", tolerance))
+	return newImage(newData, newMeta), nil
+}
+
+// alignProof returns the proof resized to the size of the reference, and
+// the reference, both with bounds starting at (0, 0).
+func alignProof(proof, reference image.Image) (p, ref *image.NRGBA, err error) {
+	pb, rb := proof.Bounds(), reference.Bounds()
+	if pb.Empty() || rb.Empty() {
+		return nil, nil, errors.New("imgx: empty proof or reference image")
+	}
+	pAspect := float64(pb.Dx()) / float64(pb.Dy())
+	rAspect := float64(rb.Dx()) / float64(rb.Dy())
+	if math.Abs(pAspect/rAspect-1) > 0.02 {
+		return nil, nil, fmt.Errorf("%w: %dx%d vs %dx%d", ErrProofMismatch, pb.Dx(), pb.Dy(), rb.Dx(), rb.Dy())
+	}
+	ref = toNRGBA(reference)
+	if pb.Size() == rb.Size() {
+		return toNRGBA(proof), ref, nil
+	}
+	return Resize(proof, rb.Dx(), rb.Dy(), Lanczos), ref, nil
+}
+
+// meanLab returns the mean color of region r of img.
+func meanLab(img *colorspace.LabImage, r image.Rectangle) colorspace.Lab {
+	var sum colorspace.Lab
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.LabAt(x, y)
+			sum.L += c.L
+			sum.A += c.A
+			sum.B += c.B
+		}
+	}
+	n := float64(r.Dx() * r.Dy())
+	return colorspace.Lab{L: sum.L / n, A: sum.A / n, B: sum.B / n}
+}
+
+// heatColor maps t in [0, 1] to green, yellow and red.
+func heatColor(t float64) [3]float64 {
+	if t < 0.5 {
+		return [3]float64{510 * t, 200, 0}
+	}
+	return [3]float64{255, 200 * (2 - 2*t), 0}
+}
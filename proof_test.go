@@ -0,0 +1,56 @@
+package imgx
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestCompareProof(t *testing.T) {
+	reference := image.NewNRGBA(image.Rect(0, 0, 80, 40))
+	draw.Draw(reference, reference.Bounds(), image.NewUniform(color.NRGBA{0, 102, 204, 255}), image.Point{}, draw.Src)
+
+	// A scan at twice the resolution with a little noise
+	proof := image.NewNRGBA(image.Rect(0, 0, 160, 80))
+	for i := 0; i < len(proof.Pix); i += 4 {
+		n := uint8(i / 4 % 3) // -1..+1 noise
+		proof.Pix[i], proof.Pix[i+1], proof.Pix[i+2], proof.Pix[i+3] = n, 101+n, 203+n, 255
+	}
+	report, err := CompareProof(proof, reference, ProofOptions{Columns: 4, Rows: 2})
+	if err != nil {
+		t.Fatalf("CompareProof() error = %v", err)
+	}
+	if !report.Pass || len(report.Regions) != 8 || report.MaxDeltaE > 1 {
+		t.Errorf("CompareProof() of a matching proof = pass %v, %d regions, max ΔE %.2f", report.Pass, len(report.Regions), report.MaxDeltaE)
+	}
+
+	// The bottom-right region is printed too red
+	draw.Draw(proof, image.Rect(120, 40, 160, 80), image.NewUniform(color.NRGBA{60, 102, 204, 255}), image.Point{}, draw.Src)
+	report, err = CompareProof(proof, reference, ProofOptions{Columns: 4, Rows: 2})
+	if err != nil {
+		t.Fatalf("CompareProof() error = %v", err)
+	}
+	if report.Pass || report.Failed != 1 {
+		t.Errorf("CompareProof() = pass %v with %d failed regions, want 1 failed", report.Pass, report.Failed)
+	}
+	if worst := report.Worst(); worst.Rect != image.Rect(60, 20, 80, 40) || worst.DeltaE < DefaultProofTolerance {
+		t.Errorf("Worst() = %v with ΔE %.2f, want the bottom-right region", worst.Rect, worst.DeltaE)
+	}
+
+	heatmap, err := ProofHeatmap(proof, reference, 0)
+	if err != nil {
+		t.Fatalf("ProofHeatmap() error = %v", err)
+	}
+	if heatmap.Bounds() != reference.Bounds() {
+		t.Fatalf("ProofHeatmap() bounds = %v, want %v", heatmap.Bounds(), reference.Bounds())
+	}
+	if ok, bad := heatmap.NRGBAAt(5, 5), heatmap.NRGBAAt(75, 35); bad.R <= ok.R || bad.R < 200 {
+		t.Errorf("heatmap pixels = %v (match) and %v (mismatch), want the mismatch red", ok, bad)
+	}
+
+	if _, err := CompareProof(image.NewNRGBA(image.Rect(0, 0, 40, 40)), reference, ProofOptions{}); !errors.Is(err, ErrProofMismatch) {
+		t.Errorf("CompareProof() with another aspect ratio error = %v, want ErrProofMismatch", err)
+	}
+}
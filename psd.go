@@ -0,0 +1,543 @@
+package imgx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"unicode/utf16"
+)
+
+// PSD color modes
+const (
+	psdBitmap    = 0
+	psdGrayscale = 1
+	psdIndexed   = 2
+	psdRGB       = 3
+	psdCMYK      = 4
+	psdDuotone   = 8
+)
+
+// PSD image resource IDs
+const (
+	psdResourceICC   = 0x040f
+	psdResourceEXIF1 = 0x0422
+	psdResourceEXIF3 = 0x0423
+)
+
+// psdCompressions names the compression methods of PSD image data.
+var psdCompressions = map[int]string{
+	0: "None",
+	1: "RLE (PackBits)",
+	2: "ZIP",
+	3: "ZIP with prediction",
+}
+
+// psdLongKeys are the additional layer information keys whose length field
+// is 8 bytes long in PSB files.
+var psdLongKeys = map[string]bool{
+	"LMsk": true, "Lr16": true, "Lr32": true, "Layr": true, "Mt16": true,
+	"Mt32": true, "Mtrn": true, "Alph": true, "FMsk": true, "lnk2": true,
+	"FEid": true, "FXid": true, "PxSD": true,
+}
+
+var errPSDFormat = errors.New("imgx: psd: invalid format")
+
+func init() {
+	image.RegisterFormat("psd", "8BPS", decodePSD, decodePSDConfig)
+}
+
+// psdFile is a parsed PSD or PSB (large document) file. Only the flattened
+// composite is decoded; layers are read for their names.
+type psdFile struct {
+	large       bool // PSB
+	channels    int
+	width       int
+	height      int
+	depth       int
+	colorMode   int
+	colorData   []byte // palette of indexed images
+	resources   []byte
+	layers      []byte // layer and mask information section
+	compression int
+	imageData   []byte // composite image data, possibly truncated
+}
+
+// parsePSD splits data into the sections of a PSD file. Sections cut off
+// by the end of data are truncated rather than rejected, so the header of
+// a partially read file can still be inspected.
+func parsePSD(data []byte) (*psdFile, error) {
+	if len(data) < 26 || string(data[:4]) != "8BPS" {
+		return nil, errPSDFormat
+	}
+	f := &psdFile{
+		channels:  int(binary.BigEndian.Uint16(data[12:])),
+		height:    int(binary.BigEndian.Uint32(data[14:])),
+		width:     int(binary.BigEndian.Uint32(data[18:])),
+		depth:     int(binary.BigEndian.Uint16(data[22:])),
+		colorMode: int(binary.BigEndian.Uint16(data[24:])),
+	}
+	switch binary.BigEndian.Uint16(data[4:]) {
+	case 1:
+	case 2:
+		f.large = true
+	default:
+		return nil, errPSDFormat
+	}
+	if f.channels < 1 || f.channels > 56 || f.width < 1 || f.height < 1 || f.width > 300000 || f.height > 300000 {
+		return nil, errPSDFormat
+	}
+
+	rest := data[26:]
+	section := func(lenSize int) []byte {
+		if len(rest) < lenSize {
+			rest = nil
+			return nil
+		}
+		n := psdUint(rest, lenSize)
+		rest = rest[lenSize:]
+		if n > uint64(len(rest)) {
+			s := rest
+			rest = nil
+			return s
+		}
+		s := rest[:n]
+		rest = rest[n:]
+		return s
+	}
+	f.colorData = section(4)
+	f.resources = section(4)
+	f.layers = section(f.lenSize())
+	if len(rest) >= 2 {
+		f.compression = int(binary.BigEndian.Uint16(rest))
+		f.imageData = rest[2:]
+	}
+	return f, nil
+}
+
+// lenSize is the size of the section length fields: 8 in PSB files.
+func (f *psdFile) lenSize() int {
+	if f.large {
+		return 8
+	}
+	return 4
+}
+
+// psdUint reads a big-endian unsigned integer of size 2, 4 or 8.
+func psdUint(b []byte, size int) uint64 {
+	switch size {
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b))
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// colorChannels is the number of color channels of the composite.
+func (f *psdFile) colorChannels() int {
+	switch f.colorMode {
+	case psdRGB:
+		return 3
+	case psdCMYK:
+		return 4
+	}
+	return 1
+}
+
+func decodePSDConfig(r io.Reader) (image.Config, error) {
+	var head [26]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return image.Config{}, err
+	}
+	f, err := parsePSD(head[:])
+	if err != nil {
+		return image.Config{}, err
+	}
+	model := color.NRGBAModel
+	if f.depth == 16 {
+		model = color.NRGBA64Model
+	}
+	return image.Config{ColorModel: model, Width: f.width, Height: f.height}, nil
+}
+
+func decodePSD(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parsePSD(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.composite()
+}
+
+// composite decodes the flattened composite image. Bitmap, grayscale,
+// duotone (as grayscale), indexed, RGB and CMYK documents with 1, 8 or 16
+// bits per channel are supported.
+func (f *psdFile) composite() (image.Image, error) {
+	switch f.colorMode {
+	case psdBitmap:
+		if f.depth != 1 {
+			return nil, errPSDFormat
+		}
+	case psdGrayscale, psdDuotone, psdRGB, psdCMYK:
+		if f.depth != 8 && f.depth != 16 {
+			return nil, fmt.Errorf("imgx: psd: unsupported bit depth %d", f.depth)
+		}
+	case psdIndexed:
+		if f.depth != 8 || len(f.colorData) < 768 {
+			return nil, errPSDFormat
+		}
+	default:
+		return nil, fmt.Errorf("imgx: psd: unsupported color mode %d", f.colorMode)
+	}
+
+	nColor := f.colorChannels()
+	if f.channels < nColor {
+		return nil, errPSDFormat
+	}
+	// The first extra channel holds the transparency of the composite when
+	// the layer count is negative; otherwise extra channels are saved
+	// selections.
+	_, mergedAlpha := f.layerInfo()
+	mergedAlpha = mergedAlpha && f.channels > nColor
+	n := nColor
+	if mergedAlpha {
+		n++
+	}
+	planes, err := f.readPlanes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := f.width, f.height
+	sample := func(c, i int) uint32 {
+		switch f.depth {
+		case 1:
+			if planes[c][i/w*((w+7)/8)+i%w/8]&(0x80>>(i%w%8)) != 0 {
+				return 0 // 1 is black
+			}
+			return 0xffff
+		case 16:
+			return uint32(binary.BigEndian.Uint16(planes[c][2*i:]))
+		}
+		return uint32(planes[c][i]) * 0x101
+	}
+
+	dst := image.NewNRGBA64(image.Rect(0, 0, w, h))
+	for i := 0; i < w*h; i++ {
+		var r, g, b uint32
+		switch f.colorMode {
+		case psdRGB:
+			r, g, b = sample(0, i), sample(1, i), sample(2, i)
+		case psdCMYK:
+			// Samples are stored inverted (0 is full ink)
+			k := sample(3, i)
+			r, g, b = sample(0, i)*k/0xffff, sample(1, i)*k/0xffff, sample(2, i)*k/0xffff
+		case psdIndexed:
+			idx := planes[0][i]
+			r = uint32(f.colorData[idx]) * 0x101
+			g = uint32(f.colorData[256+int(idx)]) * 0x101
+			b = uint32(f.colorData[512+int(idx)]) * 0x101
+		default:
+			r = sample(0, i)
+			g, b = r, r
+		}
+		a := uint32(0xffff)
+		if mergedAlpha {
+			a = sample(nColor, i)
+			r, g, b = unmatteWhite(r, a), unmatteWhite(g, a), unmatteWhite(b, a)
+		}
+		p := dst.Pix[i*8 : i*8+8 : i*8+8]
+		binary.BigEndian.PutUint16(p[0:], uint16(r))
+		binary.BigEndian.PutUint16(p[2:], uint16(g))
+		binary.BigEndian.PutUint16(p[4:], uint16(b))
+		binary.BigEndian.PutUint16(p[6:], uint16(a))
+	}
+	if f.depth == 16 {
+		return dst, nil
+	}
+	return toNRGBA(dst), nil
+}
+
+// unmatteWhite removes the white background Photoshop blends transparent
+// composites with.
+func unmatteWhite(c, a uint32) uint32 {
+	if a == 0 {
+		return 0
+	}
+	if a == 0xffff {
+		return c
+	}
+	v := (int64(c) - int64(0xffff-a)) * 0xffff / int64(a)
+	return uint32(max(0, min(0xffff, v)))
+}
+
+// readPlanes returns the first n channels of the composite, one plane per
+// channel with rows of (width*depth+7)/8 bytes.
+func (f *psdFile) readPlanes(n int) ([][]byte, error) {
+	rowBytes := (f.width*f.depth + 7) / 8
+	planeSize := rowBytes * f.height
+	planes := make([][]byte, n)
+
+	switch f.compression {
+	case 0:
+		if len(f.imageData)/planeSize < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for c := range planes {
+			planes[c] = f.imageData[c*planeSize : (c+1)*planeSize]
+		}
+
+	case 1:
+		countSize := 2
+		if f.large {
+			countSize = 4
+		}
+		rows := f.channels * f.height
+		if len(f.imageData)/countSize < rows {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data := f.imageData[rows*countSize:]
+		for c := range planes {
+			plane := make([]byte, planeSize)
+			for y := 0; y < f.height; y++ {
+				count := int(psdUint(f.imageData[(c*f.height+y)*countSize:], countSize))
+				if count > len(data) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				if err := unpackBits(plane[y*rowBytes:(y+1)*rowBytes], data[:count]); err != nil {
+					return nil, err
+				}
+				data = data[count:]
+			}
+			planes[c] = plane
+		}
+
+	default:
+		return nil, fmt.Errorf("imgx: psd: unsupported compression %d", f.compression)
+	}
+	return planes, nil
+}
+
+// unpackBits decodes PackBits data into dst, which must be filled exactly.
+func unpackBits(dst, src []byte) error {
+	for len(src) > 0 {
+		n := int(int8(src[0]))
+		src = src[1:]
+		switch {
+		case n >= 0:
+			if n+1 > len(src) || n+1 > len(dst) {
+				return errPSDFormat
+			}
+			copy(dst, src[:n+1])
+			dst, src = dst[n+1:], src[n+1:]
+		case n > -128:
+			if len(src) == 0 || 1-n > len(dst) {
+				return errPSDFormat
+			}
+			for i := 0; i < 1-n; i++ {
+				dst[i] = src[0]
+			}
+			dst, src = dst[1-n:], src[1:]
+		}
+	}
+	if len(dst) != 0 {
+		return errPSDFormat
+	}
+	return nil
+}
+
+// layerInfo returns the layer names, topmost first, and whether the
+// composite has a transparency channel. 16- and 32-bit documents keep the
+// layer information in an Lr16/Lr32 block after the global layer mask.
+func (f *psdFile) layerInfo() (names []string, mergedAlpha bool) {
+	ls := f.lenSize()
+	section := f.layers
+	if len(section) < ls {
+		return nil, false
+	}
+	n := psdUint(section, ls)
+	section = section[ls:]
+	if n > 0 {
+		return f.parseLayerRecords(section[:min(n, uint64(len(section)))])
+	}
+
+	// Skip the global layer mask info and look for Lr16/Lr32
+	if len(section) < 4 {
+		return nil, false
+	}
+	maskLen := int(binary.BigEndian.Uint32(section))
+	if maskLen > len(section)-4 {
+		return nil, false
+	}
+	blocks := f.additionalInfo(section[4+maskLen:])
+	for _, key := range []string{"Lr16", "Lr32", "Layr"} {
+		if data, ok := blocks[key]; ok {
+			return f.parseLayerRecords(data)
+		}
+	}
+	return nil, false
+}
+
+// parseLayerRecords reads the layer names from the layer records of a layer
+// info block. Records cut off by the end of data are ignored.
+func (f *psdFile) parseLayerRecords(data []byte) (names []string, mergedAlpha bool) {
+	if len(data) < 2 {
+		return nil, false
+	}
+	count := int(int16(binary.BigEndian.Uint16(data)))
+	if count < 0 {
+		count, mergedAlpha = -count, true
+	}
+	pos := 2
+	for i := 0; i < count; i++ {
+		if pos+18 > len(data) {
+			break
+		}
+		channels := int(binary.BigEndian.Uint16(data[pos+16:]))
+		pos += 18 + channels*(2+f.lenSize())
+		// Blend mode signature and key, opacity, clipping, flags, filler
+		pos += 12
+		if pos+4 > len(data) {
+			break
+		}
+		extraLen := int(binary.BigEndian.Uint32(data[pos:]))
+		pos += 4
+		if extraLen > len(data)-pos {
+			break
+		}
+		extra := data[pos : pos+extraLen]
+		pos += extraLen
+
+		name, divider, ok := f.parseLayerExtra(extra)
+		if ok && !divider {
+			names = append(names, name)
+		}
+	}
+	// Records are stored bottom to top
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names, mergedAlpha
+}
+
+// parseLayerExtra reads the name of a layer from the extra data of its
+// record, preferring the Unicode name. divider reports hidden group end
+// markers.
+func (f *psdFile) parseLayerExtra(extra []byte) (name string, divider, ok bool) {
+	pos := 0
+	for k := 0; k < 2; k++ { // layer mask and blending ranges
+		if pos+4 > len(extra) {
+			return "", false, false
+		}
+		pos += 4 + int(binary.BigEndian.Uint32(extra[pos:]))
+	}
+	if pos >= len(extra) {
+		return "", false, false
+	}
+	n := int(extra[pos])
+	if pos+1+n > len(extra) {
+		return "", false, false
+	}
+	name = string(extra[pos+1 : pos+1+n])
+	pos += (1 + n + 3) &^ 3 // padded to a multiple of 4
+
+	if pos < len(extra) {
+		blocks := f.additionalInfo(extra[pos:])
+		if luni := blocks["luni"]; len(luni) >= 4 {
+			count := int(binary.BigEndian.Uint32(luni))
+			if 4+2*count <= len(luni) {
+				u := make([]uint16, count)
+				for i := range u {
+					u[i] = binary.BigEndian.Uint16(luni[4+2*i:])
+				}
+				name = string(utf16.Decode(trimUTF16Null(u)))
+			}
+		}
+		if lsct := blocks["lsct"]; len(lsct) >= 4 {
+			divider = binary.BigEndian.Uint32(lsct) == 3
+		}
+	}
+	return name, divider, true
+}
+
+// trimUTF16Null removes a trailing NUL from a UTF-16 string.
+func trimUTF16Null(u []uint16) []uint16 {
+	if len(u) > 0 && u[len(u)-1] == 0 {
+		return u[:len(u)-1]
+	}
+	return u
+}
+
+// additionalInfo splits tagged additional layer information blocks by key.
+func (f *psdFile) additionalInfo(data []byte) map[string][]byte {
+	blocks := make(map[string][]byte)
+	for len(data) >= 12 {
+		sig, key := string(data[:4]), string(data[4:8])
+		if sig != "8BIM" && sig != "8B64" {
+			break
+		}
+		lenSize := 4
+		if f.large && psdLongKeys[key] {
+			lenSize = 8
+		}
+		if len(data) < 8+lenSize {
+			break
+		}
+		n := psdUint(data[8:], lenSize)
+		data = data[8+lenSize:]
+		if n > uint64(len(data)) {
+			break
+		}
+		blocks[key] = data[:n]
+		data = data[min(uint64(len(data)), n+n%2):]
+	}
+	return blocks
+}
+
+// resourceFlags reports whether the image resources hold an ICC profile and
+// EXIF data.
+func (f *psdFile) resourceFlags() (icc, exif bool) {
+	data := f.resources
+	for len(data) >= 8 && string(data[:4]) == "8BIM" {
+		id := binary.BigEndian.Uint16(data[4:])
+		nameLen := (1 + int(data[6]) + 1) &^ 1 // Pascal string padded to even
+		if 6+nameLen+4 > len(data) {
+			break
+		}
+		size := int(binary.BigEndian.Uint32(data[6+nameLen:]))
+		switch id {
+		case psdResourceICC:
+			icc = true
+		case psdResourceEXIF1, psdResourceEXIF3:
+			exif = true
+		}
+		next := 6 + nameLen + 4 + (size+1)&^1
+		if next > len(data) || next <= 0 {
+			break
+		}
+		data = data[next:]
+	}
+	return icc, exif
+}
+
+// readPSDHeader reads the depth, compression, resources and layer names of
+// a PSD or PSB file.
+func readPSDHeader(data []byte) formatHeader {
+	f, err := parsePSD(data)
+	if err != nil {
+		return formatHeader{}
+	}
+	h := formatHeader{bitDepth: f.depth}
+	if f.imageData != nil {
+		h.compression = psdCompressions[f.compression]
+	}
+	h.icc, h.exif = f.resourceFlags()
+	h.layers, _ = f.layerInfo()
+	return h
+}
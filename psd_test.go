@@ -0,0 +1,362 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// psdSpec describes a synthetic PSD file for buildPSD
+type psdSpec struct {
+	width, height int
+	mode          int
+	depth         int      // default 8
+	channels      int      // number of composite channels
+	planes        [][]byte // channel data; default is a gradient per channel
+	rle           bool
+	large         bool     // PSB
+	layers        []string // layer names, bottom first
+	mergedAlpha   bool     // negative layer count
+	palette       []byte   // color mode data
+}
+
+// packBitsTest encodes row with PackBits, using runs for repeated bytes.
+func packBitsTest(row []byte) []byte {
+	var out []byte
+	for i := 0; i < len(row); {
+		j := i + 1
+		for j < len(row) && j-i < 128 && row[j] == row[i] {
+			j++
+		}
+		if j-i >= 3 {
+			out = append(out, byte(int8(1-(j-i))), row[i])
+			i = j
+			continue
+		}
+		j = i + 1
+		for j < len(row) && j-i < 128 && !(j+2 < len(row) && row[j] == row[j+1] && row[j] == row[j+2]) {
+			j++
+		}
+		out = append(out, byte(j-i-1))
+		out = append(out, row[i:j]...)
+		i = j
+	}
+	return out
+}
+
+// buildPSD writes a PSD (or PSB) file for spec.
+func buildPSD(spec psdSpec) []byte {
+	if spec.depth == 0 {
+		spec.depth = 8
+	}
+	lenSize := 4
+	version := uint16(1)
+	if spec.large {
+		lenSize, version = 8, 2
+	}
+	putLen := func(b *bytes.Buffer, n int) {
+		if lenSize == 8 {
+			binary.Write(b, binary.BigEndian, uint64(n))
+		} else {
+			binary.Write(b, binary.BigEndian, uint32(n))
+		}
+	}
+	rowBytes := (spec.width*spec.depth + 7) / 8
+	if spec.planes == nil {
+		for c := 0; c < spec.channels; c++ {
+			plane := make([]byte, rowBytes*spec.height)
+			for i := range plane {
+				plane[i] = byte(i*16 + c*80)
+			}
+			spec.planes = append(spec.planes, plane)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("8BPS")
+	binary.Write(&buf, binary.BigEndian, version)
+	buf.Write(make([]byte, 6))
+	binary.Write(&buf, binary.BigEndian, uint16(spec.channels))
+	binary.Write(&buf, binary.BigEndian, uint32(spec.height))
+	binary.Write(&buf, binary.BigEndian, uint32(spec.width))
+	binary.Write(&buf, binary.BigEndian, uint16(spec.depth))
+	binary.Write(&buf, binary.BigEndian, uint16(spec.mode))
+
+	binary.Write(&buf, binary.BigEndian, uint32(len(spec.palette)))
+	buf.Write(spec.palette)
+	binary.Write(&buf, binary.BigEndian, uint32(0)) // image resources
+
+	var layerInfo bytes.Buffer
+	if len(spec.layers) > 0 {
+		count := int16(len(spec.layers))
+		if spec.mergedAlpha {
+			count = -count
+		}
+		binary.Write(&layerInfo, binary.BigEndian, count)
+		for _, name := range spec.layers {
+			var extra bytes.Buffer
+			extra.Write(make([]byte, 8)) // no mask, no blending ranges
+			pascal := append([]byte{byte(len(name))}, name...)
+			for len(pascal)%4 != 0 {
+				pascal = append(pascal, 0)
+			}
+			extra.Write(pascal)
+			u := utf16.Encode([]rune(name))
+			extra.WriteString("8BIMluni")
+			binary.Write(&extra, binary.BigEndian, uint32(4+2*len(u)))
+			binary.Write(&extra, binary.BigEndian, uint32(len(u)))
+			binary.Write(&extra, binary.BigEndian, u)
+			if name == "</Layer group>" {
+				extra.WriteString("8BIMlsct")
+				binary.Write(&extra, binary.BigEndian, uint32(4))
+				binary.Write(&extra, binary.BigEndian, uint32(3))
+			}
+
+			layerInfo.Write(make([]byte, 16))                     // bounds
+			binary.Write(&layerInfo, binary.BigEndian, uint16(0)) // no channels
+			layerInfo.WriteString("8BIMnorm")                     // blend mode
+			layerInfo.Write([]byte{255, 0, 0, 0})                 // opacity, clipping, flags, filler
+			binary.Write(&layerInfo, binary.BigEndian, uint32(extra.Len()))
+			layerInfo.Write(extra.Bytes())
+		}
+	}
+	var layers bytes.Buffer
+	if layerInfo.Len() > 0 {
+		putLen(&layers, layerInfo.Len())
+		layers.Write(layerInfo.Bytes())
+		binary.Write(&layers, binary.BigEndian, uint32(0)) // global layer mask
+	}
+	putLen(&buf, layers.Len())
+	buf.Write(layers.Bytes())
+
+	if !spec.rle {
+		binary.Write(&buf, binary.BigEndian, uint16(0))
+		for _, plane := range spec.planes {
+			buf.Write(plane)
+		}
+		return buf.Bytes()
+	}
+	binary.Write(&buf, binary.BigEndian, uint16(1))
+	var rows [][]byte
+	for _, plane := range spec.planes {
+		for y := 0; y < spec.height; y++ {
+			rows = append(rows, packBitsTest(plane[y*rowBytes:(y+1)*rowBytes]))
+		}
+	}
+	for _, row := range rows {
+		if spec.large {
+			binary.Write(&buf, binary.BigEndian, uint32(len(row)))
+		} else {
+			binary.Write(&buf, binary.BigEndian, uint16(len(row)))
+		}
+	}
+	for _, row := range rows {
+		buf.Write(row)
+	}
+	return buf.Bytes()
+}
+
+func decodeTestPSD(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("image.Decode() error = %v", err)
+	}
+	if format != "psd" {
+		t.Fatalf("image.Decode() format = %q, want psd", format)
+	}
+	return img
+}
+
+func TestDecodePSD(t *testing.T) {
+	red := []byte{255, 255, 0, 0, 10, 10}
+	green := []byte{0, 0, 255, 255, 20, 20}
+	blue := []byte{0, 0, 0, 0, 30, 200}
+	want := &image.NRGBA{
+		Rect:   image.Rect(0, 0, 3, 2),
+		Stride: 12,
+		Pix: []uint8{
+			255, 0, 0, 255, 255, 0, 0, 255, 0, 255, 0, 255,
+			0, 255, 0, 255, 10, 20, 30, 255, 10, 20, 200, 255,
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		rle   bool
+		large bool
+	}{
+		{"raw", false, false},
+		{"rle", true, false},
+		{"psb", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := buildPSD(psdSpec{
+				width: 3, height: 2, mode: psdRGB, channels: 3,
+				planes: [][]byte{red, green, blue}, rle: tc.rle, large: tc.large,
+			})
+			got := toNRGBA(decodeTestPSD(t, data))
+			if !compareNRGBA(got, want, 0) {
+				t.Errorf("decoded pixels = %v, want %v", got.Pix, want.Pix)
+			}
+		})
+	}
+}
+
+func TestDecodePSDColorModes(t *testing.T) {
+	t.Run("merged alpha", func(t *testing.T) {
+		// Red at 50% opacity, blended with white as Photoshop stores it
+		data := buildPSD(psdSpec{
+			width: 1, height: 1, mode: psdRGB, channels: 4, mergedAlpha: true,
+			planes: [][]byte{{255}, {127}, {127}, {128}}, layers: []string{"Layer 1"},
+		})
+		got := toNRGBA(decodeTestPSD(t, data)).Pix
+		if got[0] != 255 || got[1] > 1 || got[2] > 1 || got[3] != 128 {
+			t.Errorf("pixel = %v, want red at alpha 128", got)
+		}
+	})
+
+	t.Run("extra channel without merged alpha", func(t *testing.T) {
+		data := buildPSD(psdSpec{
+			width: 1, height: 1, mode: psdRGB, channels: 4,
+			planes: [][]byte{{10}, {20}, {30}, {0}},
+		})
+		if got := toNRGBA(decodeTestPSD(t, data)).Pix; !bytes.Equal(got, []byte{10, 20, 30, 255}) {
+			t.Errorf("pixel = %v, want the selection channel ignored", got)
+		}
+	})
+
+	t.Run("cmyk", func(t *testing.T) {
+		// Samples are inverted: 255 is no ink
+		data := buildPSD(psdSpec{
+			width: 2, height: 1, mode: psdCMYK, channels: 4,
+			planes: [][]byte{{255, 0}, {255, 255}, {255, 255}, {255, 255}},
+		})
+		if got := toNRGBA(decodeTestPSD(t, data)).Pix; !bytes.Equal(got, []byte{255, 255, 255, 255, 0, 255, 255, 255}) {
+			t.Errorf("pixels = %v, want white and cyan", got)
+		}
+	})
+
+	t.Run("grayscale 16-bit", func(t *testing.T) {
+		data := buildPSD(psdSpec{
+			width: 2, height: 1, mode: psdGrayscale, depth: 16, channels: 1,
+			planes: [][]byte{{0x12, 0x34, 0xff, 0xff}}, rle: true,
+		})
+		img, ok := decodeTestPSD(t, data).(*image.NRGBA64)
+		if !ok {
+			t.Fatalf("16-bit PSD decoded as %T, want *image.NRGBA64", img)
+		}
+		if c := img.NRGBA64At(0, 0); c != (color.NRGBA64{0x1234, 0x1234, 0x1234, 0xffff}) {
+			t.Errorf("pixel = %v, want gray 0x1234", c)
+		}
+	})
+
+	t.Run("indexed", func(t *testing.T) {
+		palette := make([]byte, 768)
+		palette[1], palette[256+1], palette[512+1] = 200, 100, 50
+		data := buildPSD(psdSpec{
+			width: 2, height: 1, mode: psdIndexed, channels: 1,
+			planes: [][]byte{{1, 0}}, palette: palette,
+		})
+		if got := toNRGBA(decodeTestPSD(t, data)).Pix; !bytes.Equal(got, []byte{200, 100, 50, 255, 0, 0, 0, 255}) {
+			t.Errorf("pixels = %v, want the palette colors", got)
+		}
+	})
+
+	t.Run("bitmap", func(t *testing.T) {
+		data := buildPSD(psdSpec{
+			width: 3, height: 1, mode: psdBitmap, depth: 1, channels: 1,
+			planes: [][]byte{{0b01000000}},
+		})
+		got := toNRGBA(decodeTestPSD(t, data)).Pix
+		if got[0] != 255 || got[4] != 0 || got[8] != 255 {
+			t.Errorf("pixels = %v, want white, black, white", got)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		data := buildPSD(psdSpec{width: 1, height: 1, mode: 9, channels: 3}) // Lab
+		if _, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+			t.Error("decoding a Lab PSD should fail")
+		}
+	})
+}
+
+func TestDecodePSDTruncated(t *testing.T) {
+	data := buildPSD(psdSpec{width: 4, height: 3, mode: psdRGB, channels: 4, rle: true, mergedAlpha: true, layers: []string{"a", "b"}})
+	for n := 0; n < len(data); n++ {
+		if _, _, err := image.Decode(bytes.NewReader(data[:n])); err == nil {
+			t.Fatalf("decoding %d of %d bytes should fail", n, len(data))
+		}
+		readFormatHeader(data[:n]) // must not panic
+	}
+}
+
+func TestPSDLayers(t *testing.T) {
+	data := buildPSD(psdSpec{
+		width: 2, height: 2, mode: psdRGB, channels: 3,
+		layers: []string{"Background", "</Layer group>", "Logo", "Überschrift"},
+	})
+	path := filepath.Join(t.TempDir(), "mockup.psd")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	fm := img.FileMetadata()
+	want := []string{"Überschrift", "Logo", "Background"}
+	if fm.Format != "PSD" || !reflect.DeepEqual(fm.Layers, want) {
+		t.Errorf("FileMetadata() format = %q, layers = %q, want PSD with %q", fm.Format, fm.Layers, want)
+	}
+
+	meta, err := Metadata(path)
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if !reflect.DeepEqual(meta.Layers, want) {
+		t.Errorf("Metadata().Layers = %q, want %q", meta.Layers, want)
+	}
+
+	if err := img.Save(filepath.Join(t.TempDir(), "out.psd")); err == nil {
+		t.Error("saving as PSD should fail")
+	}
+}
+
+func FuzzDecodePSD(f *testing.F) {
+	palette := make([]byte, 768)
+	for _, spec := range []psdSpec{
+		{width: 3, height: 2, mode: psdRGB, channels: 3, rle: true},
+		{width: 3, height: 2, mode: psdRGB, channels: 3, rle: true, large: true},
+		{width: 4, height: 3, mode: psdRGB, channels: 4, rle: true, mergedAlpha: true, layers: []string{"a", "b"}},
+		{width: 2, height: 1, mode: psdCMYK, channels: 4},
+		{width: 2, height: 1, mode: psdGrayscale, depth: 16, channels: 1, rle: true},
+		{width: 2, height: 1, mode: psdIndexed, channels: 1, palette: palette},
+		{width: 3, height: 1, mode: psdBitmap, depth: 1, channels: 1},
+	} {
+		f.Add(buildPSD(spec))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		readPSDHeader(data)
+		cfg, err := decodePSDConfig(bytes.NewReader(data))
+		if err != nil || cfg.Width*cfg.Height > fuzzMaxPixels {
+			return
+		}
+		img, err := decodePSD(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if b := img.Bounds(); b.Dx() != cfg.Width || b.Dy() != cfg.Height {
+			t.Errorf("decodePSD size %v, decodePSDConfig %dx%d", b.Size(), cfg.Width, cfg.Height)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package imgx
+
+import (
+	"image"
+)
+
+// sharpnessAnalysisSize bounds the size of the image analyzed by Sharpness,
+// so that scores are comparable across resolutions.
+const sharpnessAnalysisSize = 1024
+
+// Sharpness returns a focus measure for img: the variance of the Laplacian of
+// its luminance. Higher values mean more fine detail; blurry or out-of-focus
+// images score low. Images larger than 1024px are downscaled first so scores
+// of the same scene at different resolutions are comparable.
+//
+// Example:
+//
+//	if imgx.Sharpness(img) < 100 {
+//		fmt.Println("image looks blurry")
+//	}
+func Sharpness(img image.Image) float64 {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	if b.Dx() > sharpnessAnalysisSize || b.Dy() > sharpnessAnalysisSize {
+		src = Fit(src, sharpnessAnalysisSize, sharpnessAnalysisSize, Box)
+		b = src.Bounds()
+	}
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return 0
+	}
+
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*src.Stride + x*4
+			px := src.Pix[i : i+4 : i+4]
+			lum[y*w+x] = luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+		}
+	}
+
+	var sum, sumSq float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			l := lum[i-w] + lum[i+w] + lum[i-1] + lum[i+1] - 4*lum[i]
+			sum += l
+			sumSq += l * l
+		}
+	}
+	n := float64((w - 2) * (h - 2))
+	mean := sum / n
+	return sumSq/n - mean*mean
+}
+
+// Sharpness returns the focus measure of the image (see Sharpness)
+func (img *Image) Sharpness() float64 {
+	return Sharpness(img.data)
+}
+
+// ExposureClipping returns the fractions of pixels whose luminance is crushed
+// to black (<= 2) or blown out to white (>= 253).
+func ExposureClipping(img image.Image) (shadows, highlights float64) {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	total := b.Dx() * b.Dy()
+	if total == 0 {
+		return 0, 0
+	}
+
+	var dark, bright int
+	for y := 0; y < b.Dy(); y++ {
+		i := y * src.Stride
+		for x := 0; x < b.Dx(); x++ {
+			px := src.Pix[i : i+4 : i+4]
+			l := luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+			switch {
+			case l <= 2:
+				dark++
+			case l >= 253:
+				bright++
+			}
+			i += 4
+		}
+	}
+	return float64(dark) / float64(total), float64(bright) / float64(total)
+}
+
+// ImageAnalysis holds measurements of the content of an image: focus,
+// exposure and borders.
+type ImageAnalysis struct {
+	Sharpness     float64 `json:"sharpness"`      // Focus measure, see Sharpness
+	MeanLuminance float64 `json:"mean_luminance"` // 0-255; low is underexposed, high overexposed
+
+	// Percentages of pixels crushed to black or blown out to white, as
+	// reported by ExposureClipping
+	ClippedShadowsPercent    float64 `json:"clipped_shadows_percent"`
+	ClippedHighlightsPercent float64 `json:"clipped_highlights_percent"`
+
+	Borders Borders `json:"borders"` // See DetectBorders
+}
+
+// Analyze measures the sharpness, exposure and borders of img. Metadata
+// includes it with WithAnalysis.
+//
+// Example:
+//
+//	a := imgx.Analyze(img)
+//	fmt.Printf("sharpness %.0f, mean luminance %.0f\n", a.Sharpness, a.MeanLuminance)
+func Analyze(img image.Image) ImageAnalysis {
+	stats := Stats(img)
+	return ImageAnalysis{
+		Sharpness:                Sharpness(img),
+		MeanLuminance:            stats.Luminance.Mean,
+		ClippedShadowsPercent:    stats.ClippedShadowsPercent,
+		ClippedHighlightsPercent: stats.ClippedHighlightsPercent,
+		Borders:                  DetectBorders(img),
+	}
+}
+
+// Analyze measures the sharpness, exposure and borders of the image (see Analyze)
+func (img *Image) Analyze() ImageAnalysis {
+	return Analyze(img.data)
+}
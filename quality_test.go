@@ -0,0 +1,43 @@
+package imgx
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSharpness(t *testing.T) {
+	flower := testdataFlowerJPG.ToNRGBA()
+	sharp := Sharpness(flower)
+	blurred := Sharpness(Blur(flower, 3))
+	if sharp <= blurred {
+		t.Errorf("Sharpness(original) = %v, want > Sharpness(blurred) = %v", sharp, blurred)
+	}
+
+	if got := Sharpness(New(50, 50, color.White)); got != 0 {
+		t.Errorf("Sharpness(flat) = %v, want 0", got)
+	}
+	if got := Sharpness(New(2, 2, color.White)); got != 0 {
+		t.Errorf("Sharpness(2x2) = %v, want 0", got)
+	}
+	if got := testdataFlowerJPG.Sharpness(); got != sharp {
+		t.Errorf("Image.Sharpness() = %v, want %v", got, sharp)
+	}
+}
+
+func TestExposureClipping(t *testing.T) {
+	img := New(10, 10, color.Gray{128})
+	for x := 0; x < 10; x++ {
+		img.Set(x, 0, color.Black)
+		img.Set(x, 9, color.White)
+		img.Set(x, 8, color.White)
+	}
+	shadows, highlights := ExposureClipping(img)
+	if shadows != 0.1 || highlights != 0.2 {
+		t.Errorf("ExposureClipping() = %v, %v, want 0.1, 0.2", shadows, highlights)
+	}
+
+	shadows, highlights = ExposureClipping(New(0, 0, color.White))
+	if shadows != 0 || highlights != 0 {
+		t.Errorf("ExposureClipping(empty) = %v, %v, want 0, 0", shadows, highlights)
+	}
+}
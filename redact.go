@@ -0,0 +1,175 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// RedactMode selects how Redact hides a region.
+type RedactMode int
+
+// Redaction modes.
+const (
+	// RedactBlur applies a strong Gaussian blur. It keeps the look of the
+	// photo but small text may stay legible.
+	RedactBlur RedactMode = iota
+	// RedactPixelate replaces the region with large blocks of its average
+	// colors.
+	RedactPixelate
+	// RedactFill paints the region with a solid color, the only mode that
+	// leaves nothing to recover; use it for text.
+	RedactFill
+)
+
+// redactModeNames are the names of the redaction modes, in RedactMode order
+var redactModeNames = []string{"blur", "pixelate", "fill"}
+
+// String returns the name of the redaction mode, e.g. "pixelate".
+func (m RedactMode) String() string {
+	if m >= 0 && int(m) < len(redactModeNames) {
+		return redactModeNames[m]
+	}
+	return fmt.Sprintf("RedactMode(%d)", int(m))
+}
+
+// ParseRedactMode returns the redaction mode of a name: "blur", "pixelate"
+// or "fill" (case-insensitive).
+func ParseRedactMode(name string) (RedactMode, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for i, n := range redactModeNames {
+		if n == key {
+			return RedactMode(i), nil
+		}
+	}
+	return RedactBlur, &ValidationError{Op: "redact", Param: "mode", Value: fmt.Sprintf("%q", name),
+		Reason: "must be one of " + strings.Join(redactModeNames, ", ")}
+}
+
+// RedactOptions configures Redact.
+type RedactOptions struct {
+	// Mode is how the regions are hidden. Default is RedactBlur.
+	Mode RedactMode
+
+	// Padding grows each region by this many pixels on every side, so that
+	// the edges of a loosely detected face or word are covered too.
+	Padding int
+
+	// Sigma is the blur strength of RedactBlur.
+	// Default is a fifth of the shorter side of each region, at least 3.
+	Sigma float64
+
+	// BlockSize is the block size in pixels of RedactPixelate.
+	// Default is an eighth of the shorter side of each region, at least 4.
+	BlockSize int
+
+	// Color is the color of RedactFill. Default is black.
+	Color color.Color
+}
+
+// Redact hides the rectangles of img (in image coordinates, e.g. detected
+// faces or text) by blurring, pixelating or filling them, and returns the
+// redacted image. Rectangles are clipped to the image.
+//
+// Example:
+//
+//	// Pixelate two faces, with some margin.
+//	dstImage := imgx.Redact(photo, faces, imgx.RedactOptions{Mode: imgx.RedactPixelate, Padding: 8})
+func Redact(img image.Image, rects []image.Rectangle, opts RedactOptions) *image.NRGBA {
+	dst := Clone(img)
+	origin := img.Bounds().Min
+	for _, rect := range rects {
+		r := rect.Canon().Inset(-opts.Padding).Sub(origin).Intersect(dst.Rect)
+		if r.Empty() {
+			continue
+		}
+		side := min(r.Dx(), r.Dy())
+		var patch image.Image
+		switch opts.Mode {
+		case RedactPixelate:
+			size := opts.BlockSize
+			if size <= 0 {
+				size = max(4, side/8)
+			}
+			patch = Pixelate(Crop(dst, r), size)
+		case RedactFill:
+			c := opts.Color
+			if c == nil {
+				c = color.Black
+			}
+			patch = image.NewUniform(c)
+		default:
+			sigma := opts.Sigma
+			if sigma <= 0 {
+				sigma = max(3, float64(side)/5)
+			}
+			patch = Blur(Crop(dst, r), sigma)
+		}
+		// Replace the pixels, so that no trace of the region shows through
+		draw.Draw(dst, r, patch, patch.Bounds().Min, draw.Src)
+	}
+	return dst
+}
+
+// Pixelate replaces each size x size block of img with its average color,
+// starting from the top-left corner. Transparent pixels do not contribute
+// to the color of a block.
+//
+// Example:
+//
+//	dstImage := imgx.Pixelate(srcImage, 12)
+func Pixelate(img image.Image, size int) *image.NRGBA {
+	dst := Clone(img)
+	if size <= 1 {
+		return dst
+	}
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	parallel(0, (h+size-1)/size, func(rows <-chan int) {
+		for row := range rows {
+			y0, y1 := row*size, min(row*size+size, h)
+			for x0 := 0; x0 < w; x0 += size {
+				x1 := min(x0+size, w)
+				var r, g, b, a float64
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						p := dst.Pix[dst.PixOffset(x, y):]
+						pa := float64(p[3])
+						r += float64(p[0]) * pa
+						g += float64(p[1]) * pa
+						b += float64(p[2]) * pa
+						a += pa
+					}
+				}
+				var c [4]uint8
+				if a > 0 {
+					c = [4]uint8{clamp(r / a), clamp(g / a), clamp(b / a), clamp(a / float64((y1-y0)*(x1-x0)))}
+				}
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						copy(dst.Pix[dst.PixOffset(x, y):], c[:])
+					}
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// Redact hides the rectangles of the image (see Redact)
+func (img *Image) Redact(rects []image.Rectangle, opts RedactOptions) *Image {
+	newData := Redact(img.data, rects, opts)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("redact", fmt.Sprintf("regions=%d, mode=%s, padding=%d", len(rects), opts.Mode, opts.Padding))
+	return newImage(newData, newMeta)
+}
+
+// Pixelate replaces each size x size block of the image with its average
+// color (see Pixelate)
+func (img *Image) Pixelate(size int) *Image {
+	newData := Pixelate(img.data, size)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("pixelate", fmt.Sprintf("size=%d", size))
+	return newImage(newData, newMeta)
+}
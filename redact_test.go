@@ -0,0 +1,123 @@
+package imgx
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkerboard returns a w x h black and white checkerboard of 1px squares
+// with its top-left corner at (x0, y0)
+func checkerboard(x0, y0, w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(x0, y0, x0+w, y0+h))
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestPixelate(t *testing.T) {
+	got := Pixelate(checkerboard(0, 0, 5, 4), 2)
+	// Full blocks are the average gray, the last column its own block
+	for _, p := range []image.Point{{0, 0}, {1, 1}, {3, 2}} {
+		if c := got.NRGBAAt(p.X, p.Y); c != (color.NRGBA{128, 128, 128, 255}) {
+			t.Errorf("pixel %v = %v, want gray", p, c)
+		}
+	}
+	if c := got.NRGBAAt(4, 0); c != (color.NRGBA{128, 128, 128, 255}) {
+		t.Errorf("pixel (4,0) = %v, want gray", c)
+	}
+
+	// Transparent pixels don't darken the block
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	got = Pixelate(img, 2)
+	if c := got.NRGBAAt(1, 0); c != (color.NRGBA{255, 0, 0, 128}) {
+		t.Errorf("half transparent block = %v, want red at half alpha", c)
+	}
+
+	if got := Pixelate(img, 1); got.NRGBAAt(1, 0).A != 0 {
+		t.Error("Pixelate(1) changed the image")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	src := checkerboard(10, 10, 20, 20)
+	face := image.Rect(14, 14, 20, 20)
+
+	tests := []struct {
+		name string
+		opts RedactOptions
+	}{
+		{"blur", RedactOptions{Sigma: 4}},
+		{"pixelate", RedactOptions{Mode: RedactPixelate, BlockSize: 6}},
+		{"fill", RedactOptions{Mode: RedactFill, Color: color.NRGBA{255, 0, 0, 255}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Redact(src, []image.Rectangle{face}, tt.opts)
+			if got.Bounds() != image.Rect(0, 0, 20, 20) {
+				t.Fatalf("bounds = %v", got.Bounds())
+			}
+			// The checkerboard is gone inside the region, intact outside
+			if a, b := got.NRGBAAt(6, 6), got.NRGBAAt(7, 6); absDiff(a.R, b.R) > 2 {
+				t.Errorf("redacted pixels differ: %v, %v", a, b)
+			}
+			if a, b := got.NRGBAAt(2, 2), got.NRGBAAt(3, 2); a == b {
+				t.Errorf("pixels outside the region were changed: %v, %v", a, b)
+			}
+		})
+	}
+
+	got := Redact(src, []image.Rectangle{face}, RedactOptions{Mode: RedactFill, Padding: 2})
+	if c := got.NRGBAAt(2, 2); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("padded corner = %v, want black", c)
+	}
+	if c := got.NRGBAAt(12, 12); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel past the padding = %v, want unchanged white", c)
+	}
+
+	// Regions outside the image are ignored
+	got = Redact(src, []image.Rectangle{image.Rect(100, 100, 120, 120)}, RedactOptions{Mode: RedactFill})
+	if c := got.NRGBAAt(0, 0); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel (0,0) = %v, want unchanged white", c)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestParseRedactMode(t *testing.T) {
+	for _, mode := range []RedactMode{RedactBlur, RedactPixelate, RedactFill} {
+		got, err := ParseRedactMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseRedactMode(%q) = %v, %v", mode, got, err)
+		}
+	}
+	var verr *ValidationError
+	if _, err := ParseRedactMode("smudge"); !errors.As(err, &verr) {
+		t.Errorf("ParseRedactMode(smudge) error = %v, want a ValidationError", err)
+	}
+}
+
+func TestImageRedact(t *testing.T) {
+	img := NewImage(8, 8, color.White).Redact([]image.Rectangle{image.Rect(0, 0, 4, 4)}, RedactOptions{Mode: RedactFill})
+	if c := img.ToNRGBA().NRGBAAt(1, 1); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("redacted pixel = %v", c)
+	}
+	ops := img.GetMetadata().Operations
+	if len(ops) == 0 || ops[len(ops)-1].Action != "redact" {
+		t.Errorf("operations = %+v, want redact last", ops)
+	}
+}
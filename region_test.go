@@ -0,0 +1,108 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestMaskedBlend(t *testing.T) {
+	base := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	over := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	mask := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	for x := 0; x < 4; x++ {
+		base.SetNRGBA(x, 0, color.NRGBA{0, 0, 0, 255})
+		over.SetNRGBA(x, 0, color.NRGBA{200, 100, 50, 255})
+	}
+	mask.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255}) // take over
+	mask.SetNRGBA(1, 0, color.NRGBA{0, 0, 0, 255})       // keep base
+	mask.SetNRGBA(2, 0, color.NRGBA{128, 128, 128, 255}) // half
+	mask.SetNRGBA(3, 0, color.NRGBA{255, 255, 255, 0})   // transparent: keep base
+
+	got := MaskedBlend(base, over, mask)
+	want := []color.NRGBA{
+		{200, 100, 50, 255},
+		{0, 0, 0, 255},
+		{100, 50, 25, 255},
+		{0, 0, 0, 255},
+	}
+	for x, w := range want {
+		c := got.NRGBAAt(x, 0)
+		if absint(int(c.R)-int(w.R)) > 1 || absint(int(c.G)-int(w.G)) > 1 || absint(int(c.B)-int(w.B)) > 1 || c.A != w.A {
+			t.Errorf("pixel %d = %v, want %v", x, c, w)
+		}
+	}
+
+	// A smaller mask is scaled to the image.
+	small := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	small.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255})
+	if !compareNRGBA(MaskedBlend(base, over, small), over, 0) {
+		t.Error("MaskedBlend() with a scaled white mask should return over")
+	}
+}
+
+func TestApplyMasked(t *testing.T) {
+	img := NewImage(10, 10, color.NRGBA{100, 100, 100, 255}, Options{DisableMetadata: true})
+	mask := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 5; x < 10; x++ {
+			mask.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+		}
+	}
+
+	got := img.ApplyMasked(FromImage(mask), func(i *Image) *Image { return i.Invert() })
+	if c := got.ToNRGBA().NRGBAAt(2, 2); c.R != 100 {
+		t.Errorf("unmasked pixel = %v, want unchanged", c)
+	}
+	if c := got.ToNRGBA().NRGBAAt(7, 2); c.R != 155 {
+		t.Errorf("masked pixel = %v, want inverted", c)
+	}
+	ops := got.GetMetadata().Operations
+	if last := ops[len(ops)-1]; last.Action != "applyMasked" || !strings.Contains(last.Parameters, "invert") {
+		t.Errorf("recorded operation = %+v", last)
+	}
+}
+
+func TestRegionApply(t *testing.T) {
+	img := NewImage(10, 10, color.NRGBA{100, 100, 100, 255}, Options{DisableMetadata: true})
+	rect := image.Rect(2, 3, 6, 8)
+
+	got := img.Region(rect).Apply(func(sub *Image) *Image {
+		if b := sub.Bounds(); b.Dx() != 4 || b.Dy() != 5 {
+			t.Errorf("op got a %dx%d image, want 4x5", b.Dx(), b.Dy())
+		}
+		return sub.Invert()
+	})
+	data := got.ToNRGBA()
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			want := uint8(100)
+			if image.Pt(x, y).In(rect) {
+				want = 155
+			}
+			if c := data.NRGBAAt(x, y); c.R != want {
+				t.Fatalf("pixel (%d,%d) = %v, want R=%d", x, y, c, want)
+			}
+		}
+	}
+	ops := got.GetMetadata().Operations
+	if last := ops[len(ops)-1]; last.Action != "region" || last.Parameters != "x=2, y=3, w=4, h=5: invert(invert colors)" {
+		t.Errorf("recorded operation = %+v", last)
+	}
+
+	// Regions are clipped and larger results are cut to the region.
+	clipped := img.Region(image.Rect(8, 8, 20, 20))
+	if b := clipped.Bounds(); b != image.Rect(8, 8, 10, 10) {
+		t.Errorf("Bounds() = %v, want clipped to the image", b)
+	}
+	grown := clipped.Apply(func(sub *Image) *Image {
+		return NewImage(50, 50, color.NRGBA{255, 0, 0, 255})
+	})
+	if b := grown.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+		t.Errorf("Apply() changed the image size to %v", b)
+	}
+	if c := grown.ToNRGBA().NRGBAAt(9, 9); c.R != 255 || c.G != 0 {
+		t.Errorf("pixel in region = %v, want red", c)
+	}
+}
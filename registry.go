@@ -0,0 +1,136 @@
+package imgx
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownImage is the error, or panic value in Ops, when a Registry has
+// no image of a name. Use errors.Is to detect it.
+var ErrUnknownImage = errors.New("imgx: unknown image name")
+
+// Registry holds named intermediate images of a pipeline, so that a branch
+// can store a result (a mask, a blurred copy) that later steps use: the
+// fan-out and fan-in of pipelines such as "select the background, blur it,
+// composite the subject back". Names may be written with a leading "$"
+// ("$mask" and "mask" are the same image). It is safe for concurrent use.
+//
+// A Registry holds the branches of one image; to run a pipeline over a
+// batch with MapImages, create a Registry per image.
+//
+// Example:
+//
+//	reg := imgx.NewRegistry()
+//	result := img.ApplyAll(
+//		reg.OpSave("$photo"),
+//		imgx.OpSelectByColor(image.Pt(5, 5), 30),
+//		reg.OpSave("$mask"),
+//		reg.OpLoad("$photo"),
+//		reg.OpApplyMasked("$mask", imgx.OpBlur(12)),
+//	)
+type Registry struct {
+	mu     sync.RWMutex
+	images map[string]*Image
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{images: make(map[string]*Image)}
+}
+
+// registryKey returns name without its leading "$"
+func registryKey(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), "$")
+}
+
+// Set stores img under name, replacing any image of that name.
+func (r *Registry) Set(name string, img *Image) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[registryKey(name)] = img
+}
+
+// Get returns the image of name, or an error wrapping ErrUnknownImage.
+func (r *Registry) Get(name string) (*Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	img, ok := r.images[registryKey(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: $%s", ErrUnknownImage, registryKey(name))
+	}
+	return img, nil
+}
+
+// Delete removes the image of name, freeing its memory once no pipeline
+// uses it.
+func (r *Registry) Delete(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.images, registryKey(name))
+}
+
+// Names returns the names of the stored images, sorted, without "$".
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.images))
+	for name := range r.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mustGet is Get for Ops, which cannot return errors: it panics with the
+// error, which MapImages turns back into an error
+func (r *Registry) mustGet(name string) *Image {
+	img, err := r.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
+
+// OpSave returns an Op storing the image under name and passing it on
+// unchanged ("save as $name").
+func (r *Registry) OpSave(name string) Op {
+	return func(img *Image) *Image {
+		r.Set(name, img)
+		return img
+	}
+}
+
+// OpLoad returns an Op replacing the image with the one stored under name
+// ("load $name"). The Op panics with ErrUnknownImage if there is none.
+func (r *Registry) OpLoad(name string) Op {
+	return func(*Image) *Image { return r.mustGet(name) }
+}
+
+// OpWith returns an Op calling fn with the image and the one stored under
+// name, looked up when the Op runs, e.g. to use a mask stored by an earlier
+// step. The Op panics with ErrUnknownImage if there is none.
+func (r *Registry) OpWith(name string, fn func(img, named *Image) *Image) Op {
+	return func(img *Image) *Image { return fn(img, r.mustGet(name)) }
+}
+
+// OpApplyMasked returns an Op applying op where the mask stored under name
+// is set (see ApplyMasked).
+func (r *Registry) OpApplyMasked(mask string, op Op) Op {
+	return r.OpWith(mask, func(img, m *Image) *Image { return img.ApplyMasked(m, op) })
+}
+
+// OpKnockout returns an Op making the area of the mask stored under name
+// transparent (see Knockout).
+func (r *Registry) OpKnockout(mask string) Op {
+	return r.OpWith(mask, func(img, m *Image) *Image { return img.Knockout(m) })
+}
+
+// OpComposite returns an Op compositing the image stored under name onto
+// the image at pos (see Composite).
+func (r *Registry) OpComposite(name string, pos image.Point, opacity float64, opts CompositeOptions) Op {
+	return r.OpWith(name, func(img, src *Image) *Image { return img.Composite(src, pos, opacity, opts) })
+}
@@ -0,0 +1,79 @@
+package imgx
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	img := NewImage(4, 4, color.White)
+	reg.Set("$a", img)
+	reg.Set("b", img)
+	if got, err := reg.Get("a"); err != nil || got != img {
+		t.Errorf("Get(a) = %v, %v", got, err)
+	}
+	if names := reg.Names(); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("Names() = %v", names)
+	}
+	reg.Delete("$b")
+	if _, err := reg.Get("$b"); !errors.Is(err, ErrUnknownImage) {
+		t.Errorf("Get(deleted) error = %v, want ErrUnknownImage", err)
+	}
+}
+
+// TestRegistryOps tests a fan-out/fan-in pipeline: blur the background
+// selected by a mask, keep the subject sharp
+func TestRegistryOps(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			c := color.NRGBA{0, 0, 255, 255} // Blue background
+			if x >= 5 && x < 15 && y >= 5 && y < 15 {
+				c = color.NRGBA{255, 0, 0, 255} // Red subject
+			}
+			if (x+y)%2 == 0 {
+				c.G = 40 // Texture for the blur to smooth
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	reg := NewRegistry()
+	got := FromImage(src).ApplyAll(
+		reg.OpSave("$photo"),
+		OpSelectByColor(image.Pt(0, 0), 50), // The background
+		reg.OpSave("$mask"),
+		reg.OpLoad("$photo"),
+		reg.OpApplyMasked("$mask", OpBlur(3)),
+	)
+	if got.Bounds() != src.Bounds() {
+		t.Fatalf("bounds = %v", got.Bounds())
+	}
+	if c := got.ToNRGBA().NRGBAAt(10, 10); c != src.NRGBAAt(10, 10) {
+		t.Errorf("subject pixel = %v, want unchanged %v", c, src.NRGBAAt(10, 10))
+	}
+	if c := got.ToNRGBA().NRGBAAt(0, 0); c.G < 10 || c.G > 30 {
+		t.Errorf("background pixel = %v, want blurred texture", c)
+	}
+
+	// Composite the stored photo onto a larger canvas
+	over := NewImage(30, 30, color.White).ApplyAll(reg.OpComposite("photo", image.Pt(10, 10), 1, CompositeOptions{}))
+	if c := over.ToNRGBA().NRGBAAt(10, 10); c != src.NRGBAAt(0, 0) {
+		t.Errorf("composited pixel = %v, want %v", c, src.NRGBAAt(0, 0))
+	}
+
+	// Unknown names panic with ErrUnknownImage, which MapImages reports as
+	// an error
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrUnknownImage) {
+			t.Errorf("recovered %v, want ErrUnknownImage", err)
+		}
+	}()
+	got.ApplyAll(reg.OpKnockout("$missing"))
+	t.Error("OpKnockout($missing) did not panic")
+}
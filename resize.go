@@ -74,12 +74,23 @@ func precomputeWeights(dstSize, srcSize int, filter ResampleFilter) [][]indexWei
 
 // Resize resizes the image to the specified width and height using the specified resampling
 // filter and returns the transformed image. If one of width or height is 0, the image aspect
-// ratio is preserved.
+// ratio is preserved. Invalid dimensions give an empty image; check them first with
+// ValidateResize to get an error instead.
 //
 // Example:
 //
 //	dstImage := imaging.Resize(srcImage, 800, 600, imaging.Lanczos)
-func Resize(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA {
+func Resize(img image.Image, width, height int, filter ResampleFilter, opts ...ResizeOption) *image.NRGBA {
+	dst := resize(img, width, height, filter)
+	b := img.Bounds()
+	if dst.Rect.Empty() || b.Empty() {
+		return dst
+	}
+	scale := math.Sqrt(float64(b.Dx()*b.Dy()) / float64(dst.Rect.Dx()*dst.Rect.Dy()))
+	return sharpenAfterResize(dst, scale, opts)
+}
+
+func resize(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA {
 	dstW, dstH := width, height
 	if dstW < 0 || dstH < 0 {
 		return &image.NRGBA{}
@@ -237,7 +248,7 @@ func resizeNearest(img image.Image, width, height int) *image.NRGBA {
 // Example:
 //
 //	dstImage := imaging.Fit(srcImage, 800, 600, imaging.Lanczos)
-func Fit(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA {
+func Fit(img image.Image, width, height int, filter ResampleFilter, opts ...ResizeOption) *image.NRGBA {
 	maxW, maxH := width, height
 
 	if maxW <= 0 || maxH <= 0 {
@@ -268,7 +279,7 @@ func Fit(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA
 		newW = int(float64(newH) * srcAspectRatio)
 	}
 
-	return Resize(img, newW, newH, filter)
+	return Resize(img, newW, newH, filter, opts...)
 }
 
 // Fill creates an image with the specified dimensions and fills it with the scaled source image.
@@ -277,7 +288,7 @@ func Fit(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA
 // Example:
 //
 //	dstImage := imaging.Fill(srcImage, 800, 600, imaging.Center, imaging.Lanczos)
-func Fill(img image.Image, width, height int, anchor Anchor, filter ResampleFilter) *image.NRGBA {
+func Fill(img image.Image, width, height int, anchor Anchor, filter ResampleFilter, opts ...ResizeOption) *image.NRGBA {
 	dstW, dstH := width, height
 
 	if dstW <= 0 || dstH <= 0 {
@@ -296,10 +307,14 @@ func Fill(img image.Image, width, height int, anchor Anchor, filter ResampleFilt
 		return Clone(img)
 	}
 
+	var dst *image.NRGBA
 	if srcW >= 100 && srcH >= 100 {
-		return cropAndResize(img, dstW, dstH, anchor, filter)
+		dst = cropAndResize(img, dstW, dstH, anchor, filter)
+	} else {
+		dst = resizeAndCrop(img, dstW, dstH, anchor, filter)
 	}
-	return resizeAndCrop(img, dstW, dstH, anchor, filter)
+	scale := math.Min(float64(srcW)/float64(dstW), float64(srcH)/float64(dstH))
+	return sharpenAfterResize(dst, scale, opts)
 }
 
 // cropAndResize crops the image to the smallest possible size that has the required aspect ratio using
@@ -355,8 +370,74 @@ func resizeAndCrop(img image.Image, width, height int, anchor Anchor, filter Res
 // Example:
 //
 //	dstImage := imaging.Thumbnail(srcImage, 100, 100, imaging.Lanczos)
-func Thumbnail(img image.Image, width, height int, filter ResampleFilter) *image.NRGBA {
-	return Fill(img, width, height, Center, filter)
+func Thumbnail(img image.Image, width, height int, filter ResampleFilter, opts ...ResizeOption) *image.NRGBA {
+	return Fill(img, width, height, Center, filter, opts...)
+}
+
+// AutoSharpen makes WithPostResizeSharpen pick the amount from the scale factor.
+const AutoSharpen = -1
+
+// ResizeOption configures Resize, Fit, Fill and Thumbnail.
+type ResizeOption func(*resizeConfig)
+
+type resizeConfig struct {
+	sharpen float64 // 0: off, < 0: auto, > 0: unsharp mask amount
+}
+
+// WithPostResizeSharpen applies an unsharp mask after resampling to restore
+// the crispness lost when downscaling. The radius follows the scale factor;
+// amount is the mask strength (0.3-1 is typical), or AutoSharpen to derive it
+// from the scale factor as well. Auto sharpening is skipped when the image
+// is not made smaller.
+//
+// Example:
+//
+//	thumb := imgx.Thumbnail(src, 200, 200, imgx.Lanczos, imgx.WithPostResizeSharpen(imgx.AutoSharpen))
+func WithPostResizeSharpen(amount float64) ResizeOption {
+	return func(c *resizeConfig) {
+		c.sharpen = amount
+	}
+}
+
+func newResizeConfig(opts []ResizeOption) resizeConfig {
+	var c resizeConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// sharpenAfterResize applies the post-resize unsharp mask configured by opts
+// to dst, an image downscaled by scale (source size / destination size).
+func sharpenAfterResize(dst *image.NRGBA, scale float64, opts []ResizeOption) *image.NRGBA {
+	c := newResizeConfig(opts)
+	if c.sharpen == 0 {
+		return dst
+	}
+	steps := math.Log2(math.Max(scale, 1))
+	amount := c.sharpen
+	if amount < 0 {
+		if scale <= 1 {
+			return dst
+		}
+		amount = math.Min(0.8, 0.25*steps)
+	}
+	sigma := math.Min(1, 0.5+0.125*steps)
+
+	blurred := Blur(dst, sigma)
+	parallel(0, dst.Rect.Dy(), func(ys <-chan int) {
+		for y := range ys {
+			i := y * dst.Stride
+			for x := 0; x < dst.Rect.Dx(); x++ {
+				for c := 0; c < 3; c++ {
+					v := float64(dst.Pix[i+c])
+					dst.Pix[i+c] = clamp(v + amount*(v-float64(blurred.Pix[i+c])))
+				}
+				i += 4
+			}
+		}
+	})
+	return dst
 }
 
 // ResampleFilter specifies a resampling filter to be used for image resizing.
@@ -627,41 +708,52 @@ func init() {
 
 // Resize resizes the image to the specified width and height using the specified resampling filter.
 // If width or height is 0, it will be calculated to preserve the aspect ratio.
-func (img *Image) Resize(width, height int, filter ResampleFilter) *Image {
-	newData := Resize(img.data, width, height, filter)
+func (img *Image) Resize(width, height int, filter ResampleFilter, opts ...ResizeOption) *Image {
+	newData := Resize(img.data, width, height, filter, opts...)
 	newMeta := img.metadata.Clone()
-	newMeta.AddOperation("resize", formatResizeParams(width, height, filter))
-	return &Image{data: newData, metadata: newMeta}
+	newMeta.AddOperation("resize", formatResizeParams(width, height, filter)+formatSharpenParams(opts))
+	return newImage(newData, newMeta)
 }
 
 // Fit scales the image down to fit within the specified maximum width and height while preserving aspect ratio.
-func (img *Image) Fit(width, height int, filter ResampleFilter) *Image {
-	newData := Fit(img.data, width, height, filter)
+func (img *Image) Fit(width, height int, filter ResampleFilter, opts ...ResizeOption) *Image {
+	newData := Fit(img.data, width, height, filter, opts...)
 	newMeta := img.metadata.Clone()
-	newMeta.AddOperation("fit", formatResizeParams(width, height, filter))
-	return &Image{data: newData, metadata: newMeta}
+	newMeta.AddOperation("fit", formatResizeParams(width, height, filter)+formatSharpenParams(opts))
+	return newImage(newData, newMeta)
 }
 
 // Fill resizes and crops the image to fill the specified dimensions using the specified anchor point.
-func (img *Image) Fill(width, height int, anchor Anchor, filter ResampleFilter) *Image {
-	newData := Fill(img.data, width, height, anchor, filter)
+func (img *Image) Fill(width, height int, anchor Anchor, filter ResampleFilter, opts ...ResizeOption) *Image {
+	newData := Fill(img.data, width, height, anchor, filter, opts...)
 	newMeta := img.metadata.Clone()
-	newMeta.AddOperation("fill", formatFillParams(width, height, anchor, filter))
-	return &Image{data: newData, metadata: newMeta}
+	newMeta.AddOperation("fill", formatFillParams(width, height, anchor, filter)+formatSharpenParams(opts))
+	return newImage(newData, newMeta)
 }
 
 // Thumbnail creates a square thumbnail by cropping and resizing the image.
-func (img *Image) Thumbnail(width, height int, filter ResampleFilter) *Image {
-	newData := Thumbnail(img.data, width, height, filter)
+func (img *Image) Thumbnail(width, height int, filter ResampleFilter, opts ...ResizeOption) *Image {
+	newData := Thumbnail(img.data, width, height, filter, opts...)
 	newMeta := img.metadata.Clone()
-	newMeta.AddOperation("thumbnail", formatResizeParams(width, height, filter))
-	return &Image{data: newData, metadata: newMeta}
+	newMeta.AddOperation("thumbnail", formatResizeParams(width, height, filter)+formatSharpenParams(opts))
+	return newImage(newData, newMeta)
 }
 
 func formatResizeParams(width, height int, filter ResampleFilter) string {
 	return fmt.Sprintf("%dx%d, filter=%s", width, height, formatFilterName(filter))
 }
 
+func formatSharpenParams(opts []ResizeOption) string {
+	c := newResizeConfig(opts)
+	switch {
+	case c.sharpen < 0:
+		return ", sharpen=auto"
+	case c.sharpen > 0:
+		return fmt.Sprintf(", sharpen=%.2f", c.sharpen)
+	}
+	return ""
+}
+
 func formatFillParams(width, height int, anchor Anchor, filter ResampleFilter) string {
 	return fmt.Sprintf("%dx%d, anchor=%s, filter=%s", width, height, formatAnchorName(anchor), formatFilterName(filter))
 }
@@ -693,6 +785,8 @@ func formatAnchorName(anchor Anchor) string {
 		return "Bottom"
 	case BottomRight:
 		return "BottomRight"
+	case Smart:
+		return "Smart"
 	default:
 		return "Unknown"
 	}
@@ -3,6 +3,7 @@ package imgx
 import (
 	"fmt"
 	"image"
+	"image/color"
 	"path/filepath"
 	"testing"
 )
@@ -965,6 +966,54 @@ func BenchmarkFill(b *testing.B) {
 	}
 }
 
+func TestPostResizeSharpen(t *testing.T) {
+	// Fine diagonal detail that gets soft when downscaled.
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			v := uint8(0)
+			if (x+y)/6%2 == 0 {
+				v = 255
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+
+	plain := Resize(src, 100, 0, Lanczos)
+	testCases := []struct {
+		name string
+		dst  *image.NRGBA
+	}{
+		{"resize auto", Resize(src, 100, 0, Lanczos, WithPostResizeSharpen(AutoSharpen))},
+		{"resize amount", Resize(src, 100, 0, Lanczos, WithPostResizeSharpen(0.5))},
+		{"fit", Fit(src, 100, 100, Lanczos, WithPostResizeSharpen(AutoSharpen))},
+		{"thumbnail", Thumbnail(src, 100, 100, Lanczos, WithPostResizeSharpen(AutoSharpen))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if b := tc.dst.Bounds(); b.Dx() != 100 || b.Dy() != 100 {
+				t.Fatalf("size = %dx%d, want 100x100", b.Dx(), b.Dy())
+			}
+			if got, base := Sharpness(tc.dst), Sharpness(plain); got <= base {
+				t.Errorf("Sharpness() = %.1f, want more than %.1f without sharpening", got, base)
+			}
+		})
+	}
+
+	// Auto sharpening leaves upscaled images alone.
+	small := Resize(src, 50, 50, Lanczos)
+	up := Resize(small, 100, 100, Lanczos)
+	if !compareNRGBA(Resize(small, 100, 100, Lanczos, WithPostResizeSharpen(AutoSharpen)), up, 0) {
+		t.Error("auto sharpening changed an upscaled image")
+	}
+
+	img := FromImage(src).Thumbnail(100, 100, Lanczos, WithPostResizeSharpen(AutoSharpen))
+	ops := img.GetMetadata().Operations
+	if got := ops[len(ops)-1].Parameters; got != "100x100, filter=Lanczos, sharpen=auto" {
+		t.Errorf("recorded parameters = %q", got)
+	}
+}
+
 func TestFormatFilterName(t *testing.T) {
 	filters := map[string]ResampleFilter{
 		"NearestNeighbor":   NearestNeighbor,
@@ -0,0 +1,220 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unit is the unit of a Length.
+type Unit int
+
+// Supported length units.
+const (
+	Pixels Unit = iota
+	Percent
+	Millimeters
+	Centimeters
+	Inches
+)
+
+var unitSuffixes = []struct {
+	suffix string
+	unit   Unit
+}{
+	// A bare number is in pixels.
+	{"px", Pixels},
+	{"mm", Millimeters},
+	{"cm", Centimeters},
+	{"in", Inches},
+	{"%", Percent},
+}
+
+// Length is a size along one axis, in pixels, a percentage of the source
+// size, or a physical unit converted with a DPI.
+type Length struct {
+	Value float64
+	Unit  Unit
+}
+
+// IsZero reports whether the length is unset.
+func (l Length) IsZero() bool {
+	return l.Value == 0
+}
+
+func (l Length) String() string {
+	v := strconv.FormatFloat(l.Value, 'f', -1, 64)
+	switch l.Unit {
+	case Percent:
+		return v + "%"
+	case Millimeters:
+		return v + "mm"
+	case Centimeters:
+		return v + "cm"
+	case Inches:
+		return v + "in"
+	}
+	return v + "px"
+}
+
+// ParseLength parses a length such as "800", "800px", "50%", "10cm",
+// "85mm" or "4in". An empty string yields the zero (unset) Length.
+func ParseLength(s string) (Length, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Length{}, nil
+	}
+	l := Length{Unit: Pixels}
+	num := s
+	for _, u := range unitSuffixes {
+		if strings.HasSuffix(s, u.suffix) {
+			l.Unit = u.unit
+			num = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return Length{}, fmt.Errorf("imgx: invalid length %q", s)
+	}
+	l.Value = v
+	return l, nil
+}
+
+// Pixels converts the length to pixels. Percentages are relative to
+// reference; physical units require a positive dpi.
+func (l Length) Pixels(reference int, dpi float64) (int, error) {
+	var px float64
+	switch l.Unit {
+	case Pixels:
+		px = l.Value
+	case Percent:
+		px = l.Value * float64(reference) / 100
+	case Millimeters, Centimeters, Inches:
+		if dpi <= 0 {
+			return 0, fmt.Errorf("imgx: %s needs a DPI to convert to pixels", l)
+		}
+		inches := l.Value
+		switch l.Unit {
+		case Millimeters:
+			inches /= 25.4
+		case Centimeters:
+			inches /= 2.54
+		}
+		px = inches * dpi
+	default:
+		return 0, fmt.Errorf("imgx: unknown unit in %s", l)
+	}
+	n := int(math.Round(px))
+	if n < 1 && l.Value > 0 {
+		n = 1
+	}
+	return n, nil
+}
+
+// ResizeSpec describes a target size independently of the source size.
+// Set either Width and/or Height, or one of LongEdge and ShortEdge. An unset
+// dimension preserves the aspect ratio.
+type ResizeSpec struct {
+	Width     Length
+	Height    Length
+	LongEdge  Length
+	ShortEdge Length
+	// DPI converts physical units (mm, cm, in) to pixels.
+	DPI float64
+}
+
+func (s ResizeSpec) String() string {
+	var parts []string
+	add := func(name string, l Length) {
+		if !l.IsZero() {
+			parts = append(parts, name+"="+l.String())
+		}
+	}
+	add("width", s.Width)
+	add("height", s.Height)
+	add("long-edge", s.LongEdge)
+	add("short-edge", s.ShortEdge)
+	if s.DPI > 0 {
+		parts = append(parts, "dpi="+strconv.FormatFloat(s.DPI, 'f', -1, 64))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Dimensions resolves the spec against a source of width x height pixels.
+// A returned dimension of 0 means "preserve the aspect ratio", as accepted
+// by Resize.
+func (s ResizeSpec) Dimensions(width, height int) (int, int, error) {
+	edges := !s.LongEdge.IsZero() || !s.ShortEdge.IsZero()
+	sides := !s.Width.IsZero() || !s.Height.IsZero()
+	switch {
+	case edges && sides:
+		return 0, 0, fmt.Errorf("imgx: long/short edge can't be combined with width/height")
+	case !s.LongEdge.IsZero() && !s.ShortEdge.IsZero():
+		return 0, 0, fmt.Errorf("imgx: set only one of long edge and short edge")
+	case !edges && !sides:
+		return 0, 0, fmt.Errorf("imgx: empty resize spec")
+	}
+
+	if edges {
+		landscape := width >= height
+		edge, reference := s.LongEdge, max(width, height)
+		if edge.IsZero() {
+			edge, reference = s.ShortEdge, min(width, height)
+			landscape = !landscape
+		}
+		px, err := edge.Pixels(reference, s.DPI)
+		if err != nil {
+			return 0, 0, err
+		}
+		if landscape {
+			return px, 0, nil
+		}
+		return 0, px, nil
+	}
+
+	var w, h int
+	var err error
+	if !s.Width.IsZero() {
+		if w, err = s.Width.Pixels(width, s.DPI); err != nil {
+			return 0, 0, err
+		}
+	}
+	if !s.Height.IsZero() {
+		if h, err = s.Height.Pixels(height, s.DPI); err != nil {
+			return 0, 0, err
+		}
+	}
+	return w, h, nil
+}
+
+// ResizeTo resizes img according to spec.
+//
+// Example:
+//
+//	// Print at 10cm wide, 300 DPI.
+//	dst, err := imgx.ResizeTo(src, imgx.ResizeSpec{
+//		Width: imgx.Length{Value: 10, Unit: imgx.Centimeters},
+//		DPI:   300,
+//	}, imgx.Lanczos)
+func ResizeTo(img image.Image, spec ResizeSpec, filter ResampleFilter, opts ...ResizeOption) (*image.NRGBA, error) {
+	b := img.Bounds()
+	w, h, err := spec.Dimensions(b.Dx(), b.Dy())
+	if err != nil {
+		return nil, err
+	}
+	return Resize(img, w, h, filter, opts...), nil
+}
+
+// ResizeTo resizes the image according to a unit-aware ResizeSpec
+func (img *Image) ResizeTo(spec ResizeSpec, filter ResampleFilter, opts ...ResizeOption) (*Image, error) {
+	newData, err := ResizeTo(img.data, spec, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("resize", fmt.Sprintf("%s%s (%s)", formatResizeParams(newData.Bounds().Dx(), newData.Bounds().Dy(), filter), formatSharpenParams(opts), spec))
+	return newImage(newData, newMeta), nil
+}
@@ -0,0 +1,114 @@
+package imgx
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestParseLength(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    Length
+		wantErr bool
+	}{
+		{"800", Length{800, Pixels}, false},
+		{"800px", Length{800, Pixels}, false},
+		{"50%", Length{50, Percent}, false},
+		{"12.5 %", Length{12.5, Percent}, false},
+		{"10cm", Length{10, Centimeters}, false},
+		{"85MM", Length{85, Millimeters}, false},
+		{"4in", Length{4, Inches}, false},
+		{"", Length{}, false},
+		{"-5", Length{}, true},
+		{"ten", Length{}, true},
+		{"10ft", Length{}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseLength(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseLength(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseLength(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResizeSpecDimensions(t *testing.T) {
+	px := func(v float64) Length { return Length{v, Pixels} }
+	testCases := []struct {
+		name         string
+		spec         ResizeSpec
+		srcW, srcH   int
+		wantW, wantH int
+		wantErr      bool
+	}{
+		{"pixels", ResizeSpec{Width: px(800)}, 4000, 3000, 800, 0, false},
+		{"both pixels", ResizeSpec{Width: px(800), Height: px(600)}, 4000, 3000, 800, 600, false},
+		{"percent", ResizeSpec{Width: Length{50, Percent}}, 4000, 3000, 2000, 0, false},
+		{"percent height", ResizeSpec{Height: Length{10, Percent}}, 4000, 3000, 0, 300, false},
+		{"long edge landscape", ResizeSpec{LongEdge: px(2048)}, 4000, 3000, 2048, 0, false},
+		{"long edge portrait", ResizeSpec{LongEdge: px(2048)}, 3000, 4000, 0, 2048, false},
+		{"short edge landscape", ResizeSpec{ShortEdge: px(1080)}, 4000, 3000, 0, 1080, false},
+		{"short edge portrait", ResizeSpec{ShortEdge: px(1080)}, 3000, 4000, 1080, 0, false},
+		{"long edge percent", ResizeSpec{LongEdge: Length{25, Percent}}, 3000, 4000, 0, 1000, false},
+		{"centimeters", ResizeSpec{Width: Length{10, Centimeters}, DPI: 300}, 4000, 3000, 1181, 0, false},
+		{"inches", ResizeSpec{Width: Length{4, Inches}, Height: Length{6, Inches}, DPI: 300}, 4000, 3000, 1200, 1800, false},
+		{"physical without dpi", ResizeSpec{Width: Length{10, Centimeters}}, 4000, 3000, 0, 0, true},
+		{"edge and width", ResizeSpec{Width: px(100), LongEdge: px(100)}, 4000, 3000, 0, 0, true},
+		{"both edges", ResizeSpec{LongEdge: px(100), ShortEdge: px(100)}, 4000, 3000, 0, 0, true},
+		{"empty", ResizeSpec{}, 4000, 3000, 0, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, h, err := tc.spec.Dimensions(tc.srcW, tc.srcH)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Dimensions() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if w != tc.wantW || h != tc.wantH {
+				t.Errorf("Dimensions() = %dx%d, want %dx%d", w, h, tc.wantW, tc.wantH)
+			}
+		})
+	}
+}
+
+func TestImageResizeTo(t *testing.T) {
+	img := FromImage(image.NewNRGBA(image.Rect(0, 0, 400, 200)))
+	got, err := img.ResizeTo(ResizeSpec{LongEdge: Length{50, Percent}}, Lanczos)
+	if err != nil {
+		t.Fatalf("ResizeTo() error = %v", err)
+	}
+	if b := got.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("ResizeTo() size = %dx%d, want 200x100", b.Dx(), b.Dy())
+	}
+	ops := got.GetMetadata().Operations
+	if len(ops) == 0 || ops[len(ops)-1].Action != "resize" {
+		t.Errorf("ResizeTo() did not record the operation: %v", ops)
+	}
+
+	if _, err := img.ResizeTo(ResizeSpec{}, Lanczos); err == nil {
+		t.Error("ResizeTo() with an empty spec should fail")
+	}
+}
+
+func FuzzParseLength(f *testing.F) {
+	for _, s := range []string{"800", "800px", "50%", "10cm", "85mm", "4in", " 2.5 IN ", "", "-1", "1e400"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		l, err := ParseLength(s)
+		if err != nil || l.IsZero() {
+			return
+		}
+		if l.Value < 0 || math.IsInf(l.Value, 0) || math.IsNaN(l.Value) {
+			t.Fatalf("ParseLength(%q) = %v, want a finite non-negative value", s, l)
+		}
+		if again, err := ParseLength(l.String()); err != nil || again != l {
+			t.Errorf("ParseLength(%q) = %v, but its String %q parses as %v, %v", s, l, l.String(), again, err)
+		}
+	})
+}
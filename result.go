@@ -0,0 +1,262 @@
+package imgx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Warning codes reported in Result.Warnings.
+const (
+	// WarnMetadataSkipped: XMP metadata was not written because exiftool is
+	// not installed.
+	WarnMetadataSkipped = "metadata_skipped"
+	// WarnMetadataFailed: the image was saved but writing metadata failed.
+	WarnMetadataFailed = "metadata_failed"
+	// WarnAlphaFlattened: the output format has no alpha channel and
+	// transparent pixels were written as opaque.
+	WarnAlphaFlattened = "alpha_flattened"
+	// WarnColorsReduced: the image has more colors than the GIF palette.
+	WarnColorsReduced = "colors_reduced"
+	// WarnICCIgnored: the source file carries an ICC profile which is not
+	// applied on load nor written to the output.
+	WarnICCIgnored = "icc_ignored"
+	// WarnQualityReduced: the encoder quality was lowered to meet a size target.
+	WarnQualityReduced = "quality_reduced"
+	// WarnDepthReduced: the source has more than 8 bits per channel, which
+	// were reduced to 8 on load.
+	WarnDepthReduced = "depth_reduced"
+	// WarnEXIFDiscarded: the EXIF block of the source file is not copied to
+	// the output.
+	WarnEXIFDiscarded = "exif_discarded"
+	// WarnAnimationDropped: the source is an animated GIF or WebP and only
+	// its first frame was saved.
+	WarnAnimationDropped = "animation_dropped"
+	// WarnQualityInflated: a JPEG source is re-encoded at a higher quality
+	// than it was encoded with (see EstimateJPEGQuality), which adds bytes
+	// but no detail.
+	WarnQualityInflated = "quality_inflated"
+	// WarnOptionsIgnored: an animated GIF or WebP was copied unchanged from
+	// its source file and the encode options of the save were not applied.
+	WarnOptionsIgnored = "options_ignored"
+	// WarnHookFailed: a save hook registered with OnSave failed and the save
+	// uses HookWarn.
+	WarnHookFailed = "hook_failed"
+)
+
+// fidelityWarnings are the warnings that mean image data or embedded
+// metadata was lost, encode options were not applied, or bytes wasted on
+// detail that is not there. They make a save fail in strict mode.
+var fidelityWarnings = map[string]bool{
+	WarnAlphaFlattened: true,
+	WarnColorsReduced:  true,
+	WarnICCIgnored:     true,
+	WarnQualityReduced: true,
+	WarnDepthReduced:   true,
+	WarnEXIFDiscarded:  true,
+
+	WarnAnimationDropped: true,
+	WarnOptionsIgnored:   true,
+	WarnQualityInflated:  true,
+}
+
+// ErrFidelityLoss is returned in strict mode when saving would silently
+// lose data. Use errors.Is to detect it.
+var ErrFidelityLoss = errors.New("imgx: strict mode: fidelity loss")
+
+// Warning is a non-fatal issue encountered while processing an image.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return w.Code + ": " + w.Message
+}
+
+// Result describes a successful operation together with the non-fatal
+// issues that occurred along the way.
+type Result struct {
+	Path     string    `json:"path,omitempty"`
+	Format   string    `json:"format,omitempty"`
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// Warn records a warning.
+func (r *Result) Warn(code, format string, args ...any) {
+	r.Warnings = append(r.Warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// HasWarning reports whether a warning with the given code was recorded.
+func (r *Result) HasWarning(code string) bool {
+	for _, w := range r.Warnings {
+		if w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the warnings as a *WarningsError, or nil if there are none.
+// It lets callers treat warnings as errors.
+func (r *Result) Err() error {
+	if r == nil || len(r.Warnings) == 0 {
+		return nil
+	}
+	return &WarningsError{Warnings: r.Warnings}
+}
+
+// WarningsError is returned by Result.Err.
+type WarningsError struct {
+	Warnings []Warning
+}
+
+func (e *WarningsError) Error() string {
+	msgs := make([]string, len(e.Warnings))
+	for i, w := range e.Warnings {
+		msgs[i] = w.Message
+	}
+	return "imgx: " + strings.Join(msgs, "; ")
+}
+
+// strictErr returns an ErrFidelityLoss error listing the fidelity warnings of
+// r, or nil if there are none.
+func (r *Result) strictErr() error {
+	var msgs []string
+	for _, w := range r.Warnings {
+		if fidelityWarnings[w.Code] {
+			msgs = append(msgs, w.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrFidelityLoss, strings.Join(msgs, "; "))
+}
+
+// sourceInfo describes properties of the source file that don't survive
+// decoding into an 8-bit NRGBA image.
+type sourceInfo struct {
+	icc         bool
+	exif        bool
+	bitDepth    int
+	jpegQuality int // Estimated quality of JPEG sources
+}
+
+// inspectSource reads the header of the file at path. Unreadable files
+// yield a zero sourceInfo.
+func inspectSource(path string) sourceInfo {
+	var info sourceInfo
+	f, err := fs.Open(path)
+	if err != nil {
+		return info
+	}
+	// JPEG and PNG metadata precedes the image data; EXIF is at most 64KB.
+	head, _ := io.ReadAll(io.LimitReader(f, 256<<10))
+	f.Close()
+	info.icc = hasICCProfile(bytes.NewReader(head))
+	// Only JPEG EXIF is tracked; for TIFF sources the "EXIF block" is the
+	// file structure itself.
+	info.exif = bytes.HasPrefix(head, []byte{0xff, 0xd8}) && findEXIFBlock(head) != nil
+	if bytes.HasPrefix(head, []byte{0xff, 0xd8}) {
+		info.jpegQuality = estimateJPEGQuality(readJPEGHeader(head).quantTables)
+	}
+
+	// TIFF directories may sit at the end of the file, so decode the
+	// config from the whole file.
+	if f, err := fs.Open(path); err == nil {
+		if cfg, _, err := imageDecodeConfig(f); err == nil {
+			switch cfg.ColorModel {
+			case color.RGBA64Model, color.NRGBA64Model, color.Gray16Model:
+				info.bitDepth = 16
+			default:
+				info.bitDepth = 8
+			}
+		}
+		f.Close()
+	}
+	return info
+}
+
+// hasICCProfile reports whether a JPEG or PNG stream embeds an ICC profile.
+func hasICCProfile(r io.Reader) bool {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return false
+	}
+
+	if head[0] == 0xff && head[1] == 0xd8 {
+		// JPEG: look for an APP2 "ICC_PROFILE" segment before the scan.
+		seg := make([]byte, 12)
+		marker := head[2:4]
+		for marker[0] == 0xff && marker[1] != 0xda && marker[1] != 0xd9 {
+			var length uint16
+			if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+				return false
+			}
+			n := int(length) - 2
+			if marker[1] == 0xe2 && n >= len(seg) {
+				if _, err := io.ReadFull(r, seg); err != nil {
+					return false
+				}
+				if bytes.Equal(seg, []byte("ICC_PROFILE\x00")) {
+					return true
+				}
+				n -= len(seg)
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+				return false
+			}
+			if _, err := io.ReadFull(r, head[:2]); err != nil {
+				return false
+			}
+			marker = head[:2]
+		}
+		return false
+	}
+
+	if bytes.Equal(head, []byte("\x89PNG")) {
+		// PNG: look for an iCCP chunk before the image data.
+		if _, err := io.CopyN(io.Discard, r, 4); err != nil {
+			return false
+		}
+		chunk := make([]byte, 8)
+		for {
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return false
+			}
+			switch string(chunk[4:]) {
+			case "iCCP":
+				return true
+			case "IDAT", "IEND":
+				return false
+			}
+			length := int64(binary.BigEndian.Uint32(chunk))
+			if _, err := io.CopyN(io.Discard, r, length+4); err != nil {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// countColorsAbove reports whether img uses more than limit distinct colors.
+func countColorsAbove(img *image.NRGBA, limit int) bool {
+	seen := make(map[uint32]struct{}, limit+1)
+	b := img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+b.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			seen[binary.LittleEndian.Uint32(row[i:i+4])] = struct{}{}
+			if len(seen) > limit {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package imgx
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveWithResultWarnings(t *testing.T) {
+	opaque := NewImage(16, 16, color.White, Options{DisableMetadata: true})
+	transparent := NewImage(16, 16, color.NRGBA{255, 0, 0, 128}, Options{DisableMetadata: true})
+
+	gradient := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{uint8(x * 8), uint8(y * 8), 0, 255})
+		}
+	}
+	colorful := FromImage(gradient, Options{DisableMetadata: true})
+
+	testCases := []struct {
+		name string
+		img  *Image
+		file string
+		opts []SaveOption
+		want []string
+	}{
+		{"opaque jpeg", opaque, "out.jpg", nil, nil},
+		{"transparent jpeg", transparent, "out.jpg", nil, []string{WarnAlphaFlattened}},
+		{"transparent png", transparent, "out.png", nil, nil},
+		{"gif within palette", opaque, "out.gif", nil, nil},
+		{"gif palette reduced", colorful, "out.gif", []SaveOption{WithGIFNumColors(16)}, []string{WarnColorsReduced}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.img.SaveWithResult(filepath.Join(t.TempDir(), tc.file), tc.opts...)
+			if err != nil {
+				t.Fatalf("SaveWithResult() error = %v", err)
+			}
+			var got []string
+			for _, w := range result.Warnings {
+				got = append(got, w.Code)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("SaveWithResult() warnings = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("SaveWithResult() warnings = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestResultErr(t *testing.T) {
+	var result Result
+	if err := result.Err(); err != nil {
+		t.Errorf("Err() with no warnings = %v, want nil", err)
+	}
+
+	result.Warn(WarnAlphaFlattened, "transparency discarded")
+	result.Warn(WarnICCIgnored, "profile %q ignored", "Display P3")
+	if !result.HasWarning(WarnICCIgnored) || result.HasWarning(WarnColorsReduced) {
+		t.Errorf("HasWarning() does not match recorded warnings %v", result.Warnings)
+	}
+
+	var werr *WarningsError
+	if err := result.Err(); !errors.As(err, &werr) || len(werr.Warnings) != 2 {
+		t.Fatalf("Err() = %v, want *WarningsError with 2 warnings", err)
+	}
+	if got, want := result.Err().Error(), `imgx: transparency discarded; profile "Display P3" ignored`; got != want {
+		t.Errorf("Err().Error() = %q, want %q", got, want)
+	}
+}
+
+func TestHasICCProfile(t *testing.T) {
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert an iCCP chunk right after IHDR (8 byte signature + 25 byte IHDR).
+	data := plain.Bytes()
+	iccp := []byte{0, 0, 0, 3, 'i', 'C', 'C', 'P', 'p', 0, 0, 0, 0, 0, 0}
+	withICC := append(append(append([]byte{}, data[:33]...), iccp...), data[33:]...)
+
+	jpegICC := []byte{0xff, 0xd8, 0xff, 0xe0, 0, 4, 0, 0, 0xff, 0xe2, 0, 16}
+	jpegICC = append(jpegICC, "ICC_PROFILE\x00\x01\x01"...)
+	jpegICC = append(jpegICC, 0xff, 0xda)
+
+	testCases := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"png", data, false},
+		{"png with iCCP", withICC, true},
+		{"jpeg with ICC", jpegICC, true},
+		{"jpeg", encodeTestJPEG(t, 16, 16, false), false},
+		{"garbage", []byte("not an image"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasICCProfile(bytes.NewReader(tc.data)); got != tc.want {
+				t.Errorf("hasICCProfile() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSaveStrict(t *testing.T) {
+	dir := t.TempDir()
+
+	deep := image.NewNRGBA64(image.Rect(0, 0, 8, 8))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, deep); err != nil {
+		t.Fatal(err)
+	}
+	deepPath := filepath.Join(dir, "deep.png")
+	if err := os.WriteFile(deepPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exifPath := filepath.Join(dir, "camera.jpg")
+	if err := os.WriteFile(exifPath, withEXIF(encodeTestJPEG(t, 16, 16, false), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(path string) *Image {
+		img, err := Load(path, Options{DisableMetadata: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+	transparent := NewImage(16, 16, color.NRGBA{255, 0, 0, 128}, Options{DisableMetadata: true})
+
+	testCases := []struct {
+		name    string
+		img     *Image
+		file    string
+		wantErr bool
+	}{
+		{"transparent to png", transparent, "a.png", false},
+		{"transparent to jpeg", transparent, "a.jpg", true},
+		{"16-bit source", load(deepPath), "b.png", true},
+		{"exif source", load(exifPath), "c.jpg", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.file)
+			err := tc.img.Save(path, Strict())
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("Save(Strict()) error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrFidelityLoss) {
+				t.Fatalf("Save(Strict()) error = %v, want ErrFidelityLoss", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("Save(Strict()) wrote the file despite failing")
+			}
+			// Without strict mode the same save succeeds.
+			if err := tc.img.Save(path); err != nil {
+				t.Errorf("Save() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveAnimated(t *testing.T) {
+	dir := t.TempDir()
+	palette := color.Palette{color.Black, color.White}
+	var frames []*image.Paletted
+	for i := 0; i < 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+		frame.SetColorIndex(i, i, 1)
+		frames = append(frames, frame)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: frames, Delay: []int{5, 5, 5}}); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "spinner.gif")
+	if err := os.WriteFile(src, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Load(src, Options{DisableMetadata: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.FileMetadata().Frames != 3 {
+		t.Fatalf("Frames = %d, want 3", img.FileMetadata().Frames)
+	}
+
+	// Saved unchanged as GIF, the file is copied with its animation
+	copied := filepath.Join(dir, "copy.gif")
+	result, err := img.SaveWithResult(copied, Strict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(copied); !bytes.Equal(data, buf.Bytes()) || result.HasWarning(WarnAnimationDropped) {
+		t.Errorf("unchanged animation was re-encoded (warnings %v)", result.Warnings)
+	}
+
+	// Operations that leave the pixels as they were keep the animation too
+	turned := filepath.Join(dir, "turned.gif")
+	if _, err := img.Rotate180().Rotate180().SaveWithResult(turned, Strict()); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(turned); !bytes.Equal(data, buf.Bytes()) {
+		t.Error("animation turned back to its orientation was re-encoded")
+	}
+
+	// Encode options of a copied animation are reported as not applied
+	result, err = img.SaveWithResult(filepath.Join(dir, "colors.gif"), WithGIFNumColors(16))
+	if err != nil || !result.HasWarning(WarnOptionsIgnored) {
+		t.Errorf("WithGIFNumColors: err = %v, warnings = %v, want options_ignored", err, result)
+	}
+	if _, err := img.SaveWithResult(filepath.Join(dir, "strict.gif"), WithGIFNumColors(16), Strict()); !errors.Is(err, ErrFidelityLoss) {
+		t.Errorf("WithGIFNumColors, Strict(): error = %v, want ErrFidelityLoss", err)
+	}
+
+	// Any operation, or another format, keeps only the first frame
+	for name, save := range map[string]func(opts ...SaveOption) (*Result, error){
+		"resized": func(opts ...SaveOption) (*Result, error) {
+			return img.Resize(4, 4, NearestNeighbor).SaveWithResult(filepath.Join(dir, "small.gif"), opts...)
+		},
+		"png": func(opts ...SaveOption) (*Result, error) {
+			return img.SaveWithResult(filepath.Join(dir, "frame.png"), opts...)
+		},
+	} {
+		result, err := save()
+		if err != nil || !result.HasWarning(WarnAnimationDropped) {
+			t.Errorf("%s: err = %v, warnings = %v, want animation_dropped", name, err, result)
+		}
+		if _, err := save(Strict()); !errors.Is(err, ErrFidelityLoss) {
+			t.Errorf("%s: Strict() error = %v, want ErrFidelityLoss", name, err)
+		}
+	}
+}
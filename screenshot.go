@@ -0,0 +1,140 @@
+package imgx
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+)
+
+// Screenshot detection thresholds
+const (
+	// screenshotRunLength is the shortest horizontal run of identical pixels
+	// that counts as flat. Runs this long are common in UI backgrounds and
+	// between lines of text, but rare in photos, whose noise and
+	// compression break up even smooth areas.
+	screenshotRunLength = 8
+	// screenshotFlatFraction is the fraction of flat pixels above which an
+	// image is considered screenshot-like
+	screenshotFlatFraction = 0.35
+	// screenshotColorFraction is the most distinct colors per pixel of a
+	// screenshot-like image; at least 256 colors are always allowed
+	screenshotColorFraction = 0.01
+	// screenshotColorLimit is the least number of distinct colors counted
+	// by ProfileScreenshot
+	screenshotColorLimit = 1 << 16
+)
+
+// ScreenshotProfile describes the features that tell screenshots, UI
+// captures and diagrams apart from photos, as measured by ProfileScreenshot.
+type ScreenshotProfile struct {
+	// FlatPercent is the percentage of pixels in horizontal runs of at
+	// least 8 identical pixels
+	FlatPercent float64 `json:"flat_percent"`
+
+	// Colors is the number of distinct colors. Counting stops above 65536
+	// colors, or above 1% of the pixels for larger images.
+	Colors int `json:"colors"`
+
+	// Opaque reports whether all pixels are fully opaque, so an alpha
+	// channel would be unused
+	Opaque bool `json:"opaque"`
+
+	// Screenshot reports whether the content looks like a screenshot: at
+	// least 35% of the pixels are flat and there is at most one color per
+	// hundred pixels (or 256 colors). Such images compress better and stay
+	// sharper with lossless formats.
+	Screenshot bool `json:"screenshot"`
+}
+
+// ProfileScreenshot measures how screenshot-like img is.
+//
+// Example:
+//
+//	p := imgx.ProfileScreenshot(img)
+//	if p.Screenshot && p.Colors <= 256 {
+//		pal, _ := imgx.Palettize(img)
+//		err = imgx.Encode(w, pal, imgx.PNG)
+//	}
+func ProfileScreenshot(img image.Image) ScreenshotProfile {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	profile := ScreenshotProfile{Opaque: src.Opaque()}
+	if b.Empty() {
+		return profile
+	}
+
+	n := b.Dx() * b.Dy()
+	limit := max(screenshotColorLimit, n/100) + 1
+	seen := make(map[uint32]struct{})
+	flat := 0
+	for y := 0; y < b.Dy(); y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+b.Dx()*4]
+		run := 0
+		var prev uint32
+		for i := 0; i < len(row); i += 4 {
+			c := binary.LittleEndian.Uint32(row[i : i+4])
+			if i > 0 && c == prev {
+				run++
+				continue
+			}
+			if run >= screenshotRunLength {
+				flat += run
+			}
+			run, prev = 1, c
+			if len(seen) < limit {
+				seen[c] = struct{}{}
+			}
+		}
+		if run >= screenshotRunLength {
+			flat += run
+		}
+	}
+	fraction := float64(flat) / float64(n)
+	profile.FlatPercent = fraction * 100
+	profile.Colors = len(seen)
+	profile.Screenshot = fraction >= screenshotFlatFraction &&
+		profile.Colors <= max(256, int(float64(n)*screenshotColorFraction))
+	return profile
+}
+
+// ProfileScreenshot measures how screenshot-like the image is (see ProfileScreenshot)
+func (img *Image) ProfileScreenshot() ScreenshotProfile {
+	return ProfileScreenshot(img.data)
+}
+
+// Palettize converts img to a paletted image holding exactly its colors.
+// It reports false if img has more than 256 colors. Unlike the GIF
+// encoder's quantization, no color changes, so encoding the result as PNG
+// gives a lossless 8-bit (PNG-8) file.
+//
+// Example:
+//
+//	if pal, ok := imgx.Palettize(img); ok {
+//		err = imgx.Encode(w, pal, imgx.PNG)
+//	}
+func Palettize(img image.Image) (*image.Paletted, bool) {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	index := make(map[uint32]uint8)
+	var palette color.Palette
+	dst := image.NewPaletted(b, nil)
+	for y := 0; y < b.Dy(); y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+b.Dx()*4]
+		out := dst.Pix[y*dst.Stride:]
+		for i := 0; i < len(row); i += 4 {
+			c := binary.LittleEndian.Uint32(row[i : i+4])
+			n, ok := index[c]
+			if !ok {
+				if len(palette) == 256 {
+					return nil, false
+				}
+				n = uint8(len(palette))
+				index[c] = n
+				palette = append(palette, color.NRGBA{row[i], row[i+1], row[i+2], row[i+3]})
+			}
+			out[i/4] = n
+		}
+	}
+	dst.Palette = palette
+	return dst, true
+}
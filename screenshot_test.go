@@ -0,0 +1,91 @@
+package imgx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+// testScreenshot returns a UI-like image: a flat window with a title bar
+// and a few lines of "text"
+func testScreenshot() *image.NRGBA {
+	img := New(200, 120, color.NRGBA{245, 245, 245, 255})
+	draw.Draw(img, image.Rect(0, 0, 200, 20), &image.Uniform{color.NRGBA{40, 60, 120, 255}}, image.Point{}, draw.Src)
+	for line := 0; line < 5; line++ {
+		y := 30 + line*16
+		for x := 10; x < 180; x += 3 {
+			img.SetNRGBA(x, y, color.NRGBA{20, 20, 20, 255})
+			img.SetNRGBA(x, y+1, color.NRGBA{120, 120, 120, 255})
+		}
+	}
+	return img
+}
+
+func TestProfileScreenshot(t *testing.T) {
+	shot := ProfileScreenshot(testScreenshot())
+	if !shot.Screenshot || !shot.Opaque || shot.Colors != 4 {
+		t.Errorf("ProfileScreenshot(screenshot) = %+v, want screenshot, opaque, 4 colors", shot)
+	}
+	if shot.FlatPercent < 90 {
+		t.Errorf("FlatPercent = %.1f, want >= 90", shot.FlatPercent)
+	}
+
+	photo := ProfileScreenshot(testdataFlowerJPG.ToNRGBA())
+	if photo.Screenshot {
+		t.Errorf("ProfileScreenshot(photo) = %+v, want no screenshot", photo)
+	}
+
+	translucent := New(10, 10, color.NRGBA{255, 0, 0, 128})
+	if p := ProfileScreenshot(translucent); p.Opaque || p.FlatPercent != 100 || p.Colors != 1 {
+		t.Errorf("ProfileScreenshot(translucent) = %+v, want not opaque, 100%% flat, 1 color", p)
+	}
+
+	// Flat padding alone doesn't make a photo a screenshot
+	padded := New(600, 400, color.Black)
+	draw.Draw(padded, image.Rect(100, 0, 500, 400), testdataFlowerJPG.ToNRGBA(), image.Point{}, draw.Src)
+	if p := ProfileScreenshot(padded); p.Screenshot {
+		t.Errorf("ProfileScreenshot(padded photo) = %+v, want no screenshot", p)
+	}
+
+	if p := ProfileScreenshot(&image.NRGBA{}); p.Screenshot || p.Colors != 0 {
+		t.Errorf("ProfileScreenshot(empty) = %+v, want zero", p)
+	}
+}
+
+func TestPalettize(t *testing.T) {
+	src := testScreenshot()
+	src.SetNRGBA(0, 119, color.NRGBA{0, 0, 0, 0})
+	pal, ok := Palettize(src)
+	if !ok {
+		t.Fatal("Palettize() = false, want true")
+	}
+	if len(pal.Palette) != 5 {
+		t.Errorf("len(Palette) = %d, want 5", len(pal.Palette))
+	}
+	if !compareNRGBA(Clone(pal), src, 0) {
+		t.Error("Palettize() changed colors")
+	}
+
+	// The paletted image encodes as a PNG-8 that decodes to the same pixels
+	var buf bytes.Buffer
+	if err := Encode(&buf, pal, PNG); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.(*image.Paletted); !ok {
+		t.Errorf("decoded PNG is %T, want *image.Paletted", decoded)
+	}
+	if !compareNRGBA(Clone(decoded), src, 0) {
+		t.Error("PNG-8 round trip changed colors")
+	}
+
+	if _, ok := Palettize(testdataFlowerJPG.ToNRGBA()); ok {
+		t.Error("Palettize(photo) = true, want false")
+	}
+}
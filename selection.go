@@ -0,0 +1,100 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+)
+
+// SelectByColor selects the area connected to seed whose colors are close to
+// the color at seed (magic wand) and returns it as a mask: white where
+// selected, black elsewhere. tolerance is the largest difference allowed in
+// any channel, from 0 (exact color) to 255 (everything). Pixels are connected
+// horizontally and vertically. A seed outside the image selects nothing.
+//
+// The mask can be used with Knockout, MaskedBlend and Image.ApplyMasked.
+//
+// Example:
+//
+//	// Darken everything but the backdrop.
+//	backdrop := imgx.SelectByColor(src, image.Pt(5, 5), 12)
+//	dst := imgx.MaskedBlend(src, imgx.AdjustBrightness(src, -30), imgx.Invert(backdrop))
+func SelectByColor(img image.Image, seed image.Point, tolerance int) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	mask := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 3; i < len(mask.Pix); i += 4 {
+		mask.Pix[i] = 0xff
+	}
+	seed = seed.Sub(img.Bounds().Min)
+	if !seed.In(src.Rect) {
+		return mask
+	}
+
+	si := src.PixOffset(seed.X, seed.Y)
+	ref := src.Pix[si : si+4 : si+4]
+	match := func(x, y int) bool {
+		i := src.PixOffset(x, y)
+		for c := 0; c < 4; c++ {
+			if absint(int(src.Pix[i+c])-int(ref[c])) > tolerance {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Scanline flood fill.
+	visited := make([]bool, w*h)
+	stack := []image.Point{seed}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[p.Y*w+p.X] || !match(p.X, p.Y) {
+			continue
+		}
+		x0, x1 := p.X, p.X
+		for x0 > 0 && !visited[p.Y*w+x0-1] && match(x0-1, p.Y) {
+			x0--
+		}
+		for x1 < w-1 && !visited[p.Y*w+x1+1] && match(x1+1, p.Y) {
+			x1++
+		}
+		for x := x0; x <= x1; x++ {
+			visited[p.Y*w+x] = true
+			i := mask.PixOffset(x, p.Y)
+			mask.Pix[i], mask.Pix[i+1], mask.Pix[i+2] = 0xff, 0xff, 0xff
+			for _, y := range [2]int{p.Y - 1, p.Y + 1} {
+				if y >= 0 && y < h && !visited[y*w+x] {
+					stack = append(stack, image.Pt(x, y))
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// SelectByColor returns a magic-wand mask of the area connected to seed with similar colors
+func (img *Image) SelectByColor(seed image.Point, tolerance int) *Image {
+	newData := SelectByColor(img.data, seed, tolerance)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("selectByColor", fmt.Sprintf("seed=%d,%d, tolerance=%d", seed.X, seed.Y, tolerance))
+	return newImage(newData, newMeta)
+}
+
+// Knockout makes the selected (white) area of mask transparent, blending
+// through gray mask levels, and returns the result.
+//
+// Example:
+//
+//	mask := imgx.SelectByColor(product, image.Pt(5, 5), 12)
+//	cutout := imgx.Knockout(product, mask)
+func Knockout(img, mask image.Image) *image.NRGBA {
+	return MaskedBlend(img, image.NewNRGBA(img.Bounds()), mask)
+}
+
+// Knockout makes the selected area of mask transparent
+func (img *Image) Knockout(mask *Image) *Image {
+	newData := Knockout(img.data, mask.data)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("knockout", fmt.Sprintf("mask=%dx%d", mask.data.Bounds().Dx(), mask.data.Bounds().Dy()))
+	return newImage(newData, newMeta)
+}
@@ -0,0 +1,70 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// productPhoto is a light backdrop with a dark square and an isolated
+// backdrop-colored hole in the middle of the square.
+func productPhoto() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			c := color.NRGBA{240, 240, uint8(235 + x%3), 255}
+			if x >= 5 && x < 15 && y >= 5 && y < 15 && !(x == 10 && y == 10) {
+				c = color.NRGBA{40, 60, 200, 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestSelectByColor(t *testing.T) {
+	src := productPhoto()
+	testCases := []struct {
+		name      string
+		seed      image.Point
+		tolerance int
+		selected  []image.Point
+		excluded  []image.Point
+	}{
+		{"backdrop", image.Pt(0, 0), 5, []image.Point{{19, 19}, {4, 10}}, []image.Point{{7, 7}, {10, 10}}},
+		{"tolerance too low", image.Pt(0, 0), 0, []image.Point{{0, 19}}, []image.Point{{1, 0}}},
+		{"object", image.Pt(7, 7), 10, []image.Point{{14, 14}}, []image.Point{{0, 0}, {10, 10}}},
+		{"everything", image.Pt(0, 0), 255, []image.Point{{10, 10}, {7, 7}}, nil},
+		{"outside", image.Pt(-1, 3), 255, nil, []image.Point{{0, 0}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mask := SelectByColor(src, tc.seed, tc.tolerance)
+			for _, p := range tc.selected {
+				if c := mask.NRGBAAt(p.X, p.Y); c.R != 255 || c.A != 255 {
+					t.Errorf("pixel %v = %v, want selected", p, c)
+				}
+			}
+			for _, p := range tc.excluded {
+				if c := mask.NRGBAAt(p.X, p.Y); c.R != 0 || c.A != 255 {
+					t.Errorf("pixel %v = %v, want not selected", p, c)
+				}
+			}
+		})
+	}
+}
+
+func TestKnockout(t *testing.T) {
+	img := FromImage(productPhoto())
+	got := img.Knockout(img.SelectByColor(image.Pt(0, 0), 5)).ToNRGBA()
+	if a := got.NRGBAAt(0, 0).A; a != 0 {
+		t.Errorf("backdrop alpha = %d, want 0", a)
+	}
+	if c := got.NRGBAAt(7, 7); c != (color.NRGBA{40, 60, 200, 255}) {
+		t.Errorf("object pixel = %v, want unchanged", c)
+	}
+	// The enclosed hole is not connected to the seed.
+	if a := got.NRGBAAt(10, 10).A; a != 255 {
+		t.Errorf("enclosed pixel alpha = %d, want 255", a)
+	}
+}
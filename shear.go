@@ -0,0 +1,106 @@
+package imgx
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ShearH shears the image horizontally by the given angle in degrees and
+// returns the transformed image. Positive angles slant vertical lines to the
+// right (the top moves right), like italic text. The image is widened to fit;
+// bgColor fills the uncovered corners. Pixels are interpolated bilinearly
+// unless WithInterpolation is given; WithAutoCrop removes the corners.
+// Angles must be within (-90, 90); other angles return a copy of the image.
+//
+// Example:
+//
+//	dst := imgx.ShearH(src, 15, color.White, imgx.WithInterpolation(imgx.Lanczos))
+func ShearH(img image.Image, angle float64, bgColor color.Color, opts ...TransformOption) *image.NRGBA {
+	return shear(img, angle, bgColor, true, opts)
+}
+
+// ShearV shears the image vertically by the given angle in degrees and
+// returns the transformed image. Positive angles raise the right side. The
+// image is made taller to fit; see ShearH for the options.
+//
+// Example:
+//
+//	dst := imgx.ShearV(src, -10, color.Transparent)
+func ShearV(img image.Image, angle float64, bgColor color.Color, opts ...TransformOption) *image.NRGBA {
+	return shear(img, angle, bgColor, false, opts)
+}
+
+func shear(img image.Image, angle float64, bgColor color.Color, horizontal bool, opts []TransformOption) *image.NRGBA {
+	if angle == 0 || angle <= -90 || angle >= 90 {
+		return Clone(img)
+	}
+	cfg := newTransformConfig(opts)
+	src := toNRGBA(img)
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	if srcW <= 0 || srcH <= 0 {
+		return &image.NRGBA{}
+	}
+
+	t := math.Tan(math.Pi * angle / 180)
+	// along is the sheared axis, across the axis the shift depends on.
+	along, across := srcW, srcH
+	if !horizontal {
+		along, across = srcH, srcW
+	}
+	grown := along + int(math.Ceil(math.Abs(t)*float64(across-1)-1e-9))
+
+	dstW, dstH := grown, srcH
+	if !horizontal {
+		dstW, dstH = srcW, grown
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	srcXOff, srcYOff := float64(srcW)/2-0.5, float64(srcH)/2-0.5
+	dstXOff, dstYOff := float64(dstW)/2-0.5, float64(dstH)/2-0.5
+	bg := color.NRGBAModel.Convert(bgColor).(color.NRGBA)
+
+	parallel(0, dstH, func(ys <-chan int) {
+		for dstY := range ys {
+			for dstX := range dstW {
+				x, y := float64(dstX)-dstXOff, float64(dstY)-dstYOff
+				if horizontal {
+					x += t * y
+				} else {
+					y += t * x
+				}
+				cfg.samplePoint(dst, dstX, dstY, src, x+srcXOff, y+srcYOff, bg)
+			}
+		}
+	})
+
+	if cfg.autoCrop {
+		// The largest rectangle in the parallelogram trades length across
+		// the shear for length along it.
+		a := math.Abs(t)
+		span := math.Min(float64(across), float64(along)/(2*a))
+		length := float64(along) - a*span
+		if horizontal {
+			return cropInscribed(dst, length, span, cfg.margin())
+		}
+		return cropInscribed(dst, span, length, cfg.margin())
+	}
+	return dst
+}
+
+// ShearH shears the image horizontally by the given angle in degrees
+func (img *Image) ShearH(angle float64, bgColor color.Color, opts ...TransformOption) *Image {
+	newData := ShearH(img.data, angle, bgColor, opts...)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("shearH", fmt.Sprintf("angle=%.2f°, %s", angle, newTransformConfig(opts)))
+	return newImage(newData, newMeta)
+}
+
+// ShearV shears the image vertically by the given angle in degrees
+func (img *Image) ShearV(angle float64, bgColor color.Color, opts ...TransformOption) *Image {
+	newData := ShearV(img.data, angle, bgColor, opts...)
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("shearV", fmt.Sprintf("angle=%.2f°, %s", angle, newTransformConfig(opts)))
+	return newImage(newData, newMeta)
+}
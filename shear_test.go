@@ -0,0 +1,80 @@
+package imgx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestShear(t *testing.T) {
+	// A white vertical line down the middle of a black image.
+	src := image.NewNRGBA(image.Rect(0, 0, 21, 21))
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 21; x++ {
+			c := color.NRGBA{0, 0, 0, 255}
+			if x == 10 {
+				c = color.NRGBA{255, 255, 255, 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+	bg := color.NRGBA{0, 0, 0, 0}
+	nearest := WithInterpolation(NearestNeighbor)
+
+	lineX := func(img *image.NRGBA, y int) int {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.NRGBAAt(x, y).R == 255 {
+				return x
+			}
+		}
+		return -1
+	}
+
+	h := ShearH(src, 45, bg, nearest)
+	if b := h.Bounds(); b.Dx() != 41 || b.Dy() != 21 {
+		t.Fatalf("ShearH() size = %dx%d, want 41x21", b.Dx(), b.Dy())
+	}
+	if top, bottom := lineX(h, 0), lineX(h, 20); top-bottom != 20 {
+		t.Errorf("ShearH() line at x=%d (top) and x=%d (bottom), want the top 20px to the right", top, bottom)
+	}
+
+	v := ShearV(src, 30, bg)
+	if b := v.Bounds(); b.Dx() != 21 || b.Dy() != 33 {
+		t.Errorf("ShearV() size = %dx%d, want 21x33", b.Dx(), b.Dy())
+	}
+
+	for _, tc := range []struct {
+		name string
+		dst  *image.NRGBA
+	}{
+		{"horizontal", ShearH(src, 20, bg, WithAutoCrop())},
+		{"vertical", ShearV(src, -35, bg, WithInterpolation(Lanczos), WithAutoCrop())},
+	} {
+		t.Run(tc.name+" auto-crop", func(t *testing.T) {
+			b := tc.dst.Bounds()
+			if b.Dx() > 21 || b.Dy() > 21 || b.Empty() {
+				t.Fatalf("auto-cropped size = %dx%d", b.Dx(), b.Dy())
+			}
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					if a := tc.dst.NRGBAAt(x, y).A; a != 255 {
+						t.Fatalf("pixel (%d,%d) alpha = %d, want 255", x, y, a)
+					}
+				}
+			}
+		})
+	}
+
+	if !compareNRGBA(ShearH(src, 0, bg), src, 0) {
+		t.Error("ShearH() by 0° changed the image")
+	}
+	if !compareNRGBA(ShearV(src, 90, bg), src, 0) {
+		t.Error("ShearV() by 90° should return the image unchanged")
+	}
+
+	img := FromImage(src).ShearH(10, bg, nearest)
+	ops := img.GetMetadata().Operations
+	if got, want := ops[len(ops)-1].Parameters, "angle=10.00°, filter=NearestNeighbor"; got != want {
+		t.Errorf("recorded parameters = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package imgx
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignURL(t *testing.T) {
+	secret := []byte("s3cret")
+	signed, err := SignURL("/fit/400x400/cat.jpg?format=webp", secret, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(signed, "sig=") || !strings.Contains(signed, "expires=") {
+		t.Fatalf("SignURL() = %q, want sig and expires parameters", signed)
+	}
+
+	spec, err := VerifyURL(signed, secret)
+	if err != nil || spec != "/fit/400x400/cat.jpg?format=webp" {
+		t.Errorf("VerifyURL() = %q, %v", spec, err)
+	}
+
+	// Any change to the path, the parameters or the expiry is rejected
+	for _, tampered := range []string{
+		strings.Replace(signed, "400x400", "4000x4000", 1),
+		strings.Replace(signed, "format=webp", "format=png", 1),
+		signed + "&quality=100",
+		"/fit/400x400/cat.jpg?format=webp",
+	} {
+		if _, err := VerifyURL(tampered, secret); !errors.Is(err, ErrURLSignature) {
+			t.Errorf("VerifyURL(%q) error = %v, want ErrURLSignature", tampered, err)
+		}
+	}
+	u, _ := url.Parse(signed)
+	q := u.Query()
+	q.Set("expires", "99999999999")
+	u.RawQuery = q.Encode()
+	if _, err := VerifyURL(u.String(), secret); !errors.Is(err, ErrURLSignature) {
+		t.Errorf("extended expiry error = %v, want ErrURLSignature", err)
+	}
+
+	// Key rotation: URLs signed with the previous secret remain valid
+	// while it is listed
+	newSecret := []byte("n3w")
+	if _, err := VerifyURL(signed, newSecret, secret); err != nil {
+		t.Errorf("VerifyURL() with the previous secret = %v", err)
+	}
+	if _, err := VerifyURL(signed, newSecret); !errors.Is(err, ErrURLSignature) {
+		t.Errorf("VerifyURL() with a retired secret = %v, want ErrURLSignature", err)
+	}
+
+	expired, _ := SignURL("/resize/10x10/a.png", secret, time.Now().Add(-time.Minute))
+	if _, err := VerifyURL(expired, secret); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("expired URL error = %v, want ErrURLExpired", err)
+	}
+
+	// Absolute URLs keep their host, without signing it
+	forever, err := SignURL("https://img.example.com/thumb/cat.jpg", secret, time.Time{})
+	if err != nil || strings.Contains(forever, "expires=") {
+		t.Fatalf("SignURL() without expiry = %q, %v", forever, err)
+	}
+	moved := strings.Replace(forever, "img.example.com", "cdn.example.com", 1)
+	if spec, err := VerifyURL(moved, secret); err != nil || spec != "https://cdn.example.com/thumb/cat.jpg" {
+		t.Errorf("VerifyURL() behind another host = %q, %v", spec, err)
+	}
+
+	if _, err := SignURL("/a.jpg", nil, time.Time{}); err == nil {
+		t.Error("SignURL() accepted an empty secret")
+	}
+}
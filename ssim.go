@@ -0,0 +1,152 @@
+package imgx
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// ErrSizeMismatch means two images compared pixel by pixel differ in size.
+var ErrSizeMismatch = errors.New("imgx: images differ in size")
+
+// ssimWindow and ssimStep are the size and the stride of the SSIM windows
+const (
+	ssimWindow = 8
+	ssimStep   = 4
+)
+
+// SSIM returns the structural similarity index (Wang et al., 2004) of two
+// images of the same size: 1 for identical images, lower as brightness,
+// contrast or structure differ, and about 0 for unrelated images. It is
+// computed on the luminance over 8x8 windows moving by 4 pixels and
+// averaged; transparent pixels count as black. 1-SSIM is a convenient
+// difference score for visual regression tests, since it ignores changes
+// too small to see, such as encoder noise.
+//
+// Images of different sizes return ErrSizeMismatch.
+//
+// Example:
+//
+//	score, err := imgx.SSIM(baseline, screenshot)
+//	if err == nil && 1-score > 0.01 {
+//		fmt.Println("screenshot changed")
+//	}
+func SSIM(a, b image.Image) (float64, error) {
+	la, lb, err := alignSame(a, b)
+	if err != nil {
+		return 0, err
+	}
+	ya, yb := luminance(la), luminance(lb)
+	w, h := la.Rect.Dx(), la.Rect.Dy()
+	ww, wh := min(ssimWindow, w), min(ssimWindow, h)
+
+	const c1 = (0.01 * 255) * (0.01 * 255)
+	const c2 = (0.03 * 255) * (0.03 * 255)
+	var sum float64
+	var windows int
+	for y0 := 0; y0+wh <= h; y0 += ssimStep {
+		for x0 := 0; x0+ww <= w; x0 += ssimStep {
+			var sa, sb, saa, sbb, sab float64
+			for y := y0; y < y0+wh; y++ {
+				for x := x0; x < x0+ww; x++ {
+					va, vb := ya[y*w+x], yb[y*w+x]
+					sa += va
+					sb += vb
+					saa += va * va
+					sbb += vb * vb
+					sab += va * vb
+				}
+			}
+			n := float64(ww * wh)
+			ma, mb := sa/n, sb/n
+			vara, varb := saa/n-ma*ma, sbb/n-mb*mb
+			cov := sab/n - ma*mb
+			sum += (2*ma*mb + c1) * (2*cov + c2) / ((ma*ma + mb*mb + c1) * (vara + varb + c2))
+			windows++
+		}
+	}
+	return sum / float64(windows), nil
+}
+
+// SSIM returns the structural similarity of the image and other (see SSIM)
+func (img *Image) SSIM(other image.Image) (float64, error) {
+	return SSIM(img.data, other)
+}
+
+// DiffHeatmap renders the per-pixel difference between two images of the
+// same size over a dimmed grayscale copy of b: identical pixels stay gray,
+// differences go from green (slight) through yellow to red (a quarter of
+// the range and above). A difference of 16 levels in any channel, alpha
+// included, is shown at full strength, so small shifts remain visible.
+//
+// Images of different sizes return ErrSizeMismatch.
+//
+// Example:
+//
+//	heatmap, err := imgx.DiffHeatmap(baseline, screenshot)
+func DiffHeatmap(a, b image.Image) (*image.NRGBA, error) {
+	na, nb, err := alignSame(a, b)
+	if err != nil {
+		return nil, err
+	}
+	dst := Grayscale(nb)
+	size := dst.Rect.Size()
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			pa, pb := na.Pix[na.PixOffset(x, y):], nb.Pix[nb.PixOffset(x, y):]
+			var d float64
+			for c := 0; c < 4; c++ {
+				d = math.Max(d, math.Abs(float64(pa[c])-float64(pb[c])))
+			}
+			heat := heatColor(math.Min(1, d/64))
+			alpha := 0.8 * math.Min(1, d/16)
+			i := dst.PixOffset(x, y)
+			px := dst.Pix[i : i+4 : i+4]
+			for c := 0; c < 3; c++ {
+				px[c] = clamp(float64(px[c])*0.5*(1-alpha) + heat[c]*alpha)
+			}
+			px[3] = 255
+		}
+	}
+	return dst, nil
+}
+
+// DiffHeatmap renders the difference to other (see DiffHeatmap)
+func (img *Image) DiffHeatmap(other image.Image) (*Image, error) {
+	newData, err := DiffHeatmap(img.data, other)
+	if err != nil {
+		return nil, err
+	}
+	newMeta := img.metadata.Clone()
+	newMeta.AddOperation("diffHeatmap", "")
+	return newImage(newData, newMeta), nil
+}
+
+// alignSame returns a and b as NRGBA images with bounds starting at (0, 0),
+// or ErrSizeMismatch.
+func alignSame(a, b image.Image) (na, nb *image.NRGBA, err error) {
+	sa, sb := a.Bounds().Size(), b.Bounds().Size()
+	if sa != sb {
+		return nil, nil, fmt.Errorf("%w: %dx%d vs %dx%d", ErrSizeMismatch, sa.X, sa.Y, sb.X, sb.Y)
+	}
+	if sa.X <= 0 || sa.Y <= 0 {
+		return nil, nil, errors.New("imgx: empty image")
+	}
+	return toNRGBA(a), toNRGBA(b), nil
+}
+
+// luminance returns the Rec. 601 luma of img (with bounds starting at
+// (0, 0)) premultiplied by alpha, row by row
+func luminance(img *image.NRGBA) []float64 {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	y := make([]float64, w*h)
+	for row := 0; row < h; row++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, row)
+			px := img.Pix[i : i+4 : i+4]
+			y[row*w+x] = (0.299*float64(px[0]) + 0.587*float64(px[1]) + 0.114*float64(px[2])) * float64(px[3]) / 255
+		}
+	}
+	return y
+}
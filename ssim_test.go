@@ -0,0 +1,81 @@
+package imgx
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+// ssimTestImage returns a 64x48 image of diagonal stripes
+func ssimTestImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(((x + y) / 4 % 2) * 200)
+			img.SetNRGBA(x, y, color.NRGBA{v, v, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func TestSSIM(t *testing.T) {
+	a := ssimTestImage()
+
+	score, err := SSIM(a, Clone(a))
+	if err != nil || math.Abs(score-1) > 1e-9 {
+		t.Errorf("SSIM of identical images = %v, %v, want 1", score, err)
+	}
+
+	// A little noise is still very similar; a changed block is not.
+	noisy := Clone(a)
+	for i := 0; i < len(noisy.Pix); i += 4 {
+		noisy.Pix[i] ^= uint8(i / 4 % 2)
+	}
+	changed := Clone(a)
+	draw.Draw(changed, image.Rect(16, 8, 48, 40), image.NewUniform(color.NRGBA{128, 0, 0, 255}), image.Point{}, draw.Src)
+	noiseScore, _ := SSIM(a, noisy)
+	changedScore, _ := SSIM(a, changed)
+	if noiseScore < 0.99 {
+		t.Errorf("SSIM with noise = %.4f, want > 0.99", noiseScore)
+	}
+	if changedScore > 0.8 || changedScore >= noiseScore {
+		t.Errorf("SSIM with a changed block = %.4f, want well below %.4f", changedScore, noiseScore)
+	}
+
+	// Windows are clipped to images smaller than 8 pixels.
+	small := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	if score, err := SSIM(small, small); err != nil || math.Abs(score-1) > 1e-9 {
+		t.Errorf("SSIM of a 3x2 image = %v, %v, want 1", score, err)
+	}
+
+	if _, err := SSIM(a, image.NewNRGBA(image.Rect(0, 0, 10, 10))); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("SSIM of different sizes = %v, want ErrSizeMismatch", err)
+	}
+}
+
+func TestDiffHeatmap(t *testing.T) {
+	a := ssimTestImage()
+	b := Clone(a)
+	draw.Draw(b, image.Rect(0, 0, 8, 8), image.NewUniform(color.NRGBA{0, 255, 0, 255}), image.Point{}, draw.Src)
+
+	heatmap, err := DiffHeatmap(a, b)
+	if err != nil {
+		t.Fatalf("DiffHeatmap() error = %v", err)
+	}
+	if heatmap.Bounds() != a.Bounds() {
+		t.Fatalf("heatmap bounds = %v, want %v", heatmap.Bounds(), a.Bounds())
+	}
+	if c := heatmap.NRGBAAt(2, 2); c.R < 200 || c.G > 50 || c.B > 50 {
+		t.Errorf("changed pixel = %v, want red", c)
+	}
+	if c := heatmap.NRGBAAt(40, 40); c.R != c.G || c.G != c.B {
+		t.Errorf("unchanged pixel = %v, want gray", c)
+	}
+
+	if _, err := DiffHeatmap(a, image.NewNRGBA(image.Rect(0, 0, 64, 47))); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("DiffHeatmap of different sizes = %v, want ErrSizeMismatch", err)
+	}
+}
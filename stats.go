@@ -0,0 +1,128 @@
+package imgx
+
+import (
+	"image"
+	"math"
+)
+
+// ChannelStats summarizes the 8-bit values of one channel
+type ChannelStats struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    uint8   `json:"min"`
+	Max    uint8   `json:"max"`
+}
+
+// ImageStats holds pixel statistics of an image
+type ImageStats struct {
+	Width     int          `json:"width"`
+	Height    int          `json:"height"`
+	Red       ChannelStats `json:"red"`
+	Green     ChannelStats `json:"green"`
+	Blue      ChannelStats `json:"blue"`
+	Alpha     ChannelStats `json:"alpha"`
+	Luminance ChannelStats `json:"luminance"`
+
+	// Entropy is the Shannon entropy of the luminance histogram in bits
+	// (0 for a flat image, up to 8 for evenly spread tones)
+	Entropy float64 `json:"entropy"`
+
+	// Percentages of pixels whose luminance is crushed to black (<= 2) or
+	// blown out to white (>= 253), as reported by ExposureClipping
+	ClippedShadowsPercent    float64 `json:"clipped_shadows_percent"`
+	ClippedHighlightsPercent float64 `json:"clipped_highlights_percent"`
+}
+
+// channelAccumulator accumulates the values of one channel
+type channelAccumulator struct {
+	sum, sumSq float64
+	min, max   uint8
+}
+
+func (a *channelAccumulator) add(v uint8) {
+	f := float64(v)
+	a.sum += f
+	a.sumSq += f * f
+	a.min = min(a.min, v)
+	a.max = max(a.max, v)
+}
+
+func (a *channelAccumulator) stats(n float64) ChannelStats {
+	mean := a.sum / n
+	return ChannelStats{
+		Mean:   mean,
+		StdDev: math.Sqrt(math.Max(0, a.sumSq/n-mean*mean)),
+		Min:    a.min,
+		Max:    a.max,
+	}
+}
+
+// Stats returns per-channel mean, standard deviation, min and max, the
+// luminance entropy and the clipped-pixel percentages of img. Empty images
+// return zero statistics.
+//
+// Example:
+//
+//	stats := imgx.Stats(img)
+//	if stats.ClippedHighlightsPercent > 5 {
+//		fmt.Printf("%.1f%% blown highlights\n", stats.ClippedHighlightsPercent)
+//	}
+func Stats(img image.Image) ImageStats {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	stats := ImageStats{Width: b.Dx(), Height: b.Dy()}
+	total := b.Dx() * b.Dy()
+	if total == 0 {
+		return stats
+	}
+
+	acc := [5]channelAccumulator{}
+	for i := range acc {
+		acc[i].min = 255
+	}
+	var histogram [256]int
+	var dark, bright int
+	for y := 0; y < b.Dy(); y++ {
+		i := y * src.Stride
+		for x := 0; x < b.Dx(); x++ {
+			px := src.Pix[i : i+4 : i+4]
+			acc[0].add(px[0])
+			acc[1].add(px[1])
+			acc[2].add(px[2])
+			acc[3].add(px[3])
+
+			l := luminanceRedWeight*float64(px[0]) + luminanceGreenWeight*float64(px[1]) + luminanceBlueWeight*float64(px[2])
+			lum := uint8(l + 0.5)
+			acc[4].add(lum)
+			histogram[lum]++
+			switch {
+			case l <= 2:
+				dark++
+			case l >= 253:
+				bright++
+			}
+			i += 4
+		}
+	}
+
+	n := float64(total)
+	stats.Red = acc[0].stats(n)
+	stats.Green = acc[1].stats(n)
+	stats.Blue = acc[2].stats(n)
+	stats.Alpha = acc[3].stats(n)
+	stats.Luminance = acc[4].stats(n)
+	for _, count := range histogram {
+		if count > 0 {
+			p := float64(count) / n
+			stats.Entropy -= p * math.Log2(p)
+		}
+	}
+	stats.ClippedShadowsPercent = float64(dark) / n * 100
+	stats.ClippedHighlightsPercent = float64(bright) / n * 100
+	return stats
+}
+
+// Stats returns the pixel statistics of the image (see Stats)
+func (img *Image) Stats() ImageStats {
+	return Stats(img.data)
+}
@@ -0,0 +1,45 @@
+package imgx
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	// Top half black, bottom half white.
+	img := New(10, 10, color.Black)
+	for y := 5; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	stats := Stats(img)
+	if stats.Width != 10 || stats.Height != 10 {
+		t.Errorf("size = %dx%d, want 10x10", stats.Width, stats.Height)
+	}
+	for name, ch := range map[string]ChannelStats{"red": stats.Red, "luminance": stats.Luminance} {
+		if ch.Mean != 127.5 || ch.StdDev != 127.5 || ch.Min != 0 || ch.Max != 255 {
+			t.Errorf("%s = %+v, want mean 127.5, stddev 127.5, range 0-255", name, ch)
+		}
+	}
+	if stats.Alpha.Mean != 255 || stats.Alpha.StdDev != 0 {
+		t.Errorf("alpha = %+v, want opaque", stats.Alpha)
+	}
+	if math.Abs(stats.Entropy-1) > 1e-9 {
+		t.Errorf("Entropy = %v, want 1", stats.Entropy)
+	}
+	if stats.ClippedShadowsPercent != 50 || stats.ClippedHighlightsPercent != 50 {
+		t.Errorf("clipping = %v%%, %v%%, want 50%%, 50%%", stats.ClippedShadowsPercent, stats.ClippedHighlightsPercent)
+	}
+
+	flat := NewImage(4, 4, color.Gray{128}).Stats()
+	if flat.Entropy != 0 || flat.Luminance.StdDev != 0 || flat.Green.Mean != 128 {
+		t.Errorf("flat image stats = %+v", flat)
+	}
+
+	if empty := Stats(New(0, 0, color.White)); empty != (ImageStats{}) {
+		t.Errorf("Stats(empty) = %+v, want zero", empty)
+	}
+}
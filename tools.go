@@ -54,6 +54,9 @@ const (
 	BottomLeft
 	Bottom
 	BottomRight
+	// Smart keeps the region with the most detail when cropping (CropAnchor,
+	// CropToAspect, Fill). Elsewhere it behaves like Center.
+	Smart
 )
 
 func anchorPt(b image.Rectangle, w, h int, anchor Anchor) image.Point {
@@ -118,6 +121,9 @@ func Crop(img image.Image, rect image.Rectangle) *image.NRGBA {
 func CropAnchor(img image.Image, width, height int, anchor Anchor) *image.NRGBA {
 	srcBounds := img.Bounds()
 	pt := anchorPt(srcBounds, width, height, anchor)
+	if anchor == Smart {
+		pt = smartAnchorPt(img, width, height)
+	}
 	r := image.Rect(0, 0, width, height).Add(pt)
 	b := srcBounds.Intersect(r)
 	return Crop(img, b)
@@ -261,7 +267,7 @@ func (img *Image) Crop(rect image.Rectangle) *Image {
 	newData := Crop(img.data, rect)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("crop", fmt.Sprintf("x=%d, y=%d, w=%d, h=%d", rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy()))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // CropAnchor cuts out a rectangular region with the specified size using the anchor point
@@ -269,7 +275,7 @@ func (img *Image) CropAnchor(width, height int, anchor Anchor) *Image {
 	newData := CropAnchor(img.data, width, height, anchor)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("cropAnchor", fmt.Sprintf("w=%d, h=%d, anchor=%s", width, height, formatAnchorName(anchor)))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // CropCenter cuts out a rectangular region from the center of the image
@@ -277,7 +283,7 @@ func (img *Image) CropCenter(width, height int) *Image {
 	newData := CropCenter(img.data, width, height)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("cropCenter", fmt.Sprintf("w=%d, h=%d", width, height))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Paste pastes another image onto this image at the specified position
@@ -285,7 +291,7 @@ func (img *Image) Paste(src *Image, pos image.Point) *Image {
 	newData := Paste(img.data, src.data, pos)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("paste", fmt.Sprintf("x=%d, y=%d", pos.X, pos.Y))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // PasteCenter pastes another image at the center of this image
@@ -293,7 +299,7 @@ func (img *Image) PasteCenter(src *Image) *Image {
 	newData := PasteCenter(img.data, src.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("pasteCenter", "paste at center")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Overlay overlays another image on top of this image with the specified opacity
@@ -301,7 +307,7 @@ func (img *Image) Overlay(src *Image, pos image.Point, opacity float64) *Image {
 	newData := Overlay(img.data, src.data, pos, opacity)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("overlay", fmt.Sprintf("x=%d, y=%d, opacity=%.2f", pos.X, pos.Y, opacity))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // OverlayCenter overlays another image at the center with the specified opacity
@@ -309,5 +315,5 @@ func (img *Image) OverlayCenter(src *Image, opacity float64) *Image {
 	newData := OverlayCenter(img.data, src.data, opacity)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("overlayCenter", fmt.Sprintf("opacity=%.2f", opacity))
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
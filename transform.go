@@ -133,7 +133,15 @@ func Rotate270(img image.Image) *image.NRGBA {
 // Rotate rotates an image by the given angle counter-clockwise .
 // The angle parameter is the rotation angle in degrees.
 // The bgColor parameter specifies the color of the uncovered zone after the rotation.
-func Rotate(img image.Image, angle float64, bgColor color.Color) *image.NRGBA {
+// Pixels are interpolated bilinearly unless WithInterpolation is given;
+// WithAutoCrop removes the uncovered zone.
+//
+// Example:
+//
+//	// Straighten a horizon without background wedges.
+//	dst := imgx.Rotate(src, -2.5, color.Black, imgx.WithInterpolation(imgx.Lanczos), imgx.WithAutoCrop())
+func Rotate(img image.Image, angle float64, bgColor color.Color, opts ...TransformOption) *image.NRGBA {
+	cfg := newTransformConfig(opts)
 	angle = angle - math.Floor(angle/360)*360
 
 	switch angle {
@@ -170,11 +178,15 @@ func Rotate(img image.Image, angle float64, bgColor color.Color) *image.NRGBA {
 			for dstX := range dstW {
 				xf, yf := rotatePoint(float64(dstX)-dstXOff, float64(dstY)-dstYOff, sin, cos)
 				xf, yf = xf+srcXOff, yf+srcYOff
-				interpolatePoint(dst, dstX, dstY, src, xf, yf, bgColorNRGBA)
+				cfg.samplePoint(dst, dstX, dstY, src, xf, yf, bgColorNRGBA)
 			}
 		}
 	})
 
+	if cfg.autoCrop {
+		w, h := inscribedSize(srcW, srcH, angle)
+		return cropInscribed(dst, w, h, cfg.margin())
+	}
 	return dst
 }
 
@@ -273,7 +285,7 @@ func (img *Image) FlipH() *Image {
 	newData := FlipH(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("flipH", "horizontal flip")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // FlipV flips the image vertically
@@ -281,7 +293,7 @@ func (img *Image) FlipV() *Image {
 	newData := FlipV(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("flipV", "vertical flip")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Transpose flips the image horizontally and rotates 90° counter-clockwise
@@ -289,7 +301,7 @@ func (img *Image) Transpose() *Image {
 	newData := Transpose(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("transpose", "flip horizontal + rotate 90° CCW")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Transverse flips the image vertically and rotates 90° counter-clockwise
@@ -297,7 +309,7 @@ func (img *Image) Transverse() *Image {
 	newData := Transverse(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("transverse", "flip vertical + rotate 90° CCW")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Rotate90 rotates the image 90° counter-clockwise
@@ -305,7 +317,7 @@ func (img *Image) Rotate90() *Image {
 	newData := Rotate90(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("rotate90", "90° counter-clockwise")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Rotate180 rotates the image 180°
@@ -313,7 +325,7 @@ func (img *Image) Rotate180() *Image {
 	newData := Rotate180(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("rotate180", "180°")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Rotate270 rotates the image 270° counter-clockwise (90° clockwise)
@@ -321,15 +333,19 @@ func (img *Image) Rotate270() *Image {
 	newData := Rotate270(img.data)
 	newMeta := img.metadata.Clone()
 	newMeta.AddOperation("rotate270", "270° counter-clockwise (90° clockwise)")
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
 
 // Rotate rotates the image by the given angle counter-clockwise.
 // The angle parameter is the rotation angle in degrees.
 // The bgColor parameter specifies the color of the uncovered areas after rotation.
-func (img *Image) Rotate(angle float64, bgColor color.Color) *Image {
-	newData := Rotate(img.data, angle, bgColor)
+func (img *Image) Rotate(angle float64, bgColor color.Color, opts ...TransformOption) *Image {
+	newData := Rotate(img.data, angle, bgColor, opts...)
 	newMeta := img.metadata.Clone()
-	newMeta.AddOperation("rotate", fmt.Sprintf("%.2f° counter-clockwise", angle))
-	return &Image{data: newData, metadata: newMeta}
+	params := fmt.Sprintf("%.2f° counter-clockwise", angle)
+	if len(opts) > 0 {
+		params += ", " + newTransformConfig(opts).String()
+	}
+	newMeta.AddOperation("rotate", params)
+	return newImage(newData, newMeta)
 }
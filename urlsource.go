@@ -0,0 +1,207 @@
+package imgx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// URLStatus tells how URLCache.Fetch got a source.
+type URLStatus string
+
+const (
+	URLDownloaded  URLStatus = "downloaded"   // New or changed source, downloaded
+	URLNotModified URLStatus = "not_modified" // Unchanged (HTTP 304), read from the cache
+)
+
+// URLSource is a remote image fetched by URLCache.
+type URLSource struct {
+	URL          string
+	Data         []byte `json:"-"`
+	Hash         string // SHA-256 of Data, e.g. for CacheKey
+	ETag         string `json:",omitempty"`
+	LastModified string `json:",omitempty"`
+	Fetched      time.Time
+	Status       URLStatus `json:"-"`
+}
+
+// URLStats counts the fetches of a URLCache.
+type URLStats struct {
+	Downloads   int64
+	NotModified int64
+	BytesSaved  int64 // Bytes not downloaded thanks to 304 responses
+}
+
+// URLCache downloads remote images and remembers their ETag and
+// Last-Modified headers, so that later fetches are conditional GETs: an
+// unchanged source is answered with 304 Not Modified and read from the
+// cache instead of being downloaded again. Since the Hash of an unchanged
+// source stays the same, a render cache keyed by CacheKey(src.Hash, spec)
+// is hit as well and the source is not processed again.
+//
+// It is safe for concurrent use. Fetches fail with ErrOffline in offline
+// mode.
+type URLCache struct {
+	dir    string
+	client *http.Client
+
+	mu    sync.Mutex
+	stats URLStats
+}
+
+// NewURLCache returns a URLCache storing the sources and their validators
+// in dir. A nil client uses http.DefaultClient.
+//
+// Example:
+//
+//	sources, _ := imgx.NewURLCache("/var/cache/imgx/sources", nil)
+//	img, src, err := sources.Load(ctx, "https://example.com/cat.jpg")
+//	if err == nil && src.Status == imgx.URLNotModified {
+//		log.Println("source unchanged")
+//	}
+func NewURLCache(dir string, client *http.Client) (*URLCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("imgx: url cache: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &URLCache{dir: dir, client: client}, nil
+}
+
+// Fetch returns the content of url, sending the validators of the cached
+// copy if there is one. Responses other than 200 and 304 are errors.
+func (c *URLCache) Fetch(ctx context.Context, url string) (*URLSource, error) {
+	if IsOffline() {
+		return nil, fmt.Errorf("imgx: fetch %s: %w", url, ErrOffline)
+	}
+	name := c.name(url)
+	cached := c.read(name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("imgx: fetch %s: %w", url, err)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("imgx: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		c.mu.Lock()
+		c.stats.NotModified++
+		c.stats.BytesSaved += int64(len(cached.Data))
+		c.mu.Unlock()
+		cached.Status = URLNotModified
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("imgx: fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("imgx: fetch %s: %w", url, err)
+	}
+	sum := sha256.Sum256(data)
+	src := &URLSource{
+		URL:          url,
+		Data:         data,
+		Hash:         hex.EncodeToString(sum[:]),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+		Status:       URLDownloaded,
+	}
+	c.mu.Lock()
+	c.stats.Downloads++
+	c.mu.Unlock()
+	if src.ETag != "" || src.LastModified != "" {
+		if err := c.write(name, src); err != nil {
+			return src, err
+		}
+	}
+	return src, nil
+}
+
+// Load fetches url like Fetch and decodes it like Load. The image's
+// SourcePath is the URL.
+func (c *URLCache) Load(ctx context.Context, url string, opts ...Options) (*Image, *URLSource, error) {
+	src, err := c.Fetch(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := decodeImage(src.Data, url, opts...)
+	if err != nil {
+		return nil, src, err
+	}
+	return img, src, nil
+}
+
+// Stats returns the fetch counters.
+func (c *URLCache) Stats() URLStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// name returns the base name of the cache files of url
+func (c *URLCache) name(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// read returns the cached copy stored under name, or nil. A copy whose
+// content does not match its hash is ignored.
+func (c *URLCache) read(name string) *URLSource {
+	meta, err := os.ReadFile(name + ".json")
+	if err != nil {
+		return nil
+	}
+	var src URLSource
+	if json.Unmarshal(meta, &src) != nil {
+		return nil
+	}
+	if src.Data, err = os.ReadFile(name); err != nil {
+		return nil
+	}
+	if sum := sha256.Sum256(src.Data); hex.EncodeToString(sum[:]) != src.Hash {
+		return nil
+	}
+	return &src
+}
+
+// write stores src under name: the content first, then the validators, so
+// that an interrupted write leaves no validators for a partial content
+func (c *URLCache) write(name string, src *URLSource) error {
+	os.Remove(name + ".json")
+	err := os.WriteFile(name, src.Data, 0644)
+	if err == nil {
+		var meta []byte
+		if meta, err = json.Marshal(src); err == nil {
+			err = os.WriteFile(name+".json", meta, 0644)
+		}
+	}
+	if err != nil {
+		os.Remove(name)
+		return fmt.Errorf("imgx: url cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package imgx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestURLCache(t *testing.T) {
+	var body bytes.Buffer
+	if err := Encode(&body, New(8, 6, color.White), PNG); err != nil {
+		t.Fatal(err)
+	}
+	etag := `"v1"`
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing.png":
+			http.NotFound(w, r)
+			return
+		case "/plain.png": // no validators
+		default:
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		downloads.Add(1)
+		w.Write(body.Bytes())
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache, err := NewURLCache(dir, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, src, err := cache.Load(ctx, srv.URL+"/cat.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Status != URLDownloaded || src.ETag != etag || img.Bounds().Dx() != 8 || img.GetMetadata().SourcePath != srv.URL+"/cat.png" {
+		t.Errorf("first Load() = %+v, %v", src, img.Bounds())
+	}
+
+	// A new process sends the stored ETag and gets a 304
+	cache, _ = NewURLCache(dir, srv.Client())
+	again, err := cache.Fetch(ctx, srv.URL+"/cat.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Status != URLNotModified || again.Hash != src.Hash || !bytes.Equal(again.Data, body.Bytes()) {
+		t.Errorf("second Fetch() = %s, hash %s, want not modified with %s", again.Status, again.Hash, src.Hash)
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("downloaded %d times, want 1", downloads.Load())
+	}
+
+	// A changed source is downloaded again
+	etag = `"v2"`
+	if changed, _ := cache.Fetch(ctx, srv.URL+"/cat.png"); changed.Status != URLDownloaded || changed.ETag != `"v2"` {
+		t.Errorf("changed Fetch() = %s %s", changed.Status, changed.ETag)
+	}
+	// Without validators every fetch downloads
+	cache.Fetch(ctx, srv.URL+"/plain.png")
+	if plain, _ := cache.Fetch(ctx, srv.URL+"/plain.png"); plain.Status != URLDownloaded {
+		t.Errorf("Fetch() without validators = %s", plain.Status)
+	}
+	if s := cache.Stats(); s.Downloads != 3 || s.NotModified != 1 || s.BytesSaved != int64(body.Len()) {
+		t.Errorf("Stats() = %+v", s)
+	}
+
+	if _, err := cache.Fetch(ctx, srv.URL+"/missing.png"); err == nil {
+		t.Error("Fetch() of a 404 succeeded")
+	}
+	OfflineMode(true)
+	defer OfflineMode(false)
+	if _, err := cache.Fetch(ctx, srv.URL+"/cat.png"); !errors.Is(err, ErrOffline) {
+		t.Errorf("offline Fetch() error = %v, want ErrOffline", err)
+	}
+}
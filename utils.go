@@ -3,10 +3,11 @@ package imgx
 import (
 	"context"
 	"image"
-	"math"
 	"runtime"
 	"sync"
 	"sync/atomic"
+
+	"github.com/razzkumar/imgx/colorspace"
 )
 
 var maxProcs int64
@@ -134,83 +135,14 @@ func toNRGBA(img image.Image) *image.NRGBA {
 	return Clone(img)
 }
 
-// rgbToHSL converts a color from RGB to HSL.
+// rgbToHSL converts a color from RGB to HSL, with all components in [0, 1].
 func rgbToHSL(r, g, b uint8) (float64, float64, float64) {
-	rr := float64(r) / 255
-	gg := float64(g) / 255
-	bb := float64(b) / 255
-
-	max := math.Max(rr, math.Max(gg, bb))
-	min := math.Min(rr, math.Min(gg, bb))
-
-	l := (max + min) / 2
-
-	if max == min {
-		return 0, 0, l
-	}
-
-	var h, s float64
-	d := max - min
-	if l > 0.5 {
-		s = d / (2 - max - min)
-	} else {
-		s = d / (max + min)
-	}
-
-	switch max {
-	case rr:
-		h = (gg - bb) / d
-		if g < b {
-			h += 6
-		}
-	case gg:
-		h = (bb-rr)/d + 2
-	case bb:
-		h = (rr-gg)/d + 4
-	}
-	h /= 6
-
-	return h, s, l
+	c := colorspace.RGB{R: float64(r) / 255, G: float64(g) / 255, B: float64(b) / 255}.HSL()
+	return c.H / 360, c.S, c.L
 }
 
-// hslToRGB converts a color from HSL to RGB.
+// hslToRGB converts a color from HSL, with all components in [0, 1], to RGB.
 func hslToRGB(h, s, l float64) (uint8, uint8, uint8) {
-	var r, g, b float64
-	if s == 0 {
-		v := clamp(l * 255)
-		return v, v, v
-	}
-
-	var q float64
-	if l < 0.5 {
-		q = l * (1 + s)
-	} else {
-		q = l + s - l*s
-	}
-	p := 2*l - q
-
-	r = hueToRGB(p, q, h+1/3.0)
-	g = hueToRGB(p, q, h)
-	b = hueToRGB(p, q, h-1/3.0)
-
-	return clamp(r * 255), clamp(g * 255), clamp(b * 255)
-}
-
-func hueToRGB(p, q, t float64) float64 {
-	if t < 0 {
-		t++
-	}
-	if t > 1 {
-		t--
-	}
-	if t < 1/6.0 {
-		return p + (q-p)*6*t
-	}
-	if t < 1/2.0 {
-		return q
-	}
-	if t < 2/3.0 {
-		return p + (q-p)*(2/3.0-t)*6
-	}
-	return p
+	c := colorspace.HSL{H: h * 360, S: s, L: l}.RGB()
+	return clamp(c.R * 255), clamp(c.G * 255), clamp(c.B * 255)
 }
@@ -0,0 +1,126 @@
+package imgx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrInvalidParameter matches every *ValidationError with errors.Is.
+var ErrInvalidParameter = errors.New("imgx: invalid parameter")
+
+// ValidationError reports an operation parameter outside its valid range.
+// It is returned by the Validate functions, by Encode and Save for invalid
+// encode options, and by ParseAnchor.
+//
+// Example:
+//
+//	var verr *imgx.ValidationError
+//	if errors.As(err, &verr) {
+//		fmt.Println(verr.Param, verr.Reason)
+//	}
+type ValidationError struct {
+	Op     string // Operation, e.g. "resize" or "encode"
+	Param  string // Parameter name, e.g. "width"
+	Value  any    // The invalid value
+	Reason string // What a valid value looks like
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("imgx: %s: invalid %s %v: %s", e.Op, e.Param, e.Value, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidParameter) true.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidParameter
+}
+
+// ValidateResize checks the dimensions of Resize: neither may be negative
+// and at least one must be positive (0 preserves the aspect ratio). Resize
+// itself returns an empty image for such dimensions.
+func ValidateResize(width, height int) error {
+	if width < 0 {
+		return &ValidationError{Op: "resize", Param: "width", Value: width, Reason: "must not be negative"}
+	}
+	if height < 0 {
+		return &ValidationError{Op: "resize", Param: "height", Value: height, Reason: "must not be negative"}
+	}
+	if width == 0 && height == 0 {
+		return &ValidationError{Op: "resize", Param: "size", Value: "0x0", Reason: "width or height must be positive"}
+	}
+	return nil
+}
+
+// ValidateSize checks the dimensions of the operations that need both
+// (Fit, Fill, Thumbnail, CropAnchor): op names the operation in the error.
+// These operations return an empty image for dimensions that are not
+// positive.
+func ValidateSize(op string, width, height int) error {
+	if width <= 0 {
+		return &ValidationError{Op: op, Param: "width", Value: width, Reason: "must be positive"}
+	}
+	if height <= 0 {
+		return &ValidationError{Op: op, Param: "height", Value: height, Reason: "must be positive"}
+	}
+	return nil
+}
+
+// ValidateSigma checks the sigma of Blur, Sharpen and the other Gaussian
+// operations: it must be a positive number. These operations return an
+// unchanged copy for a sigma of 0 or less.
+func ValidateSigma(op string, sigma float64) error {
+	if !(sigma > 0) || math.IsInf(sigma, 1) {
+		return &ValidationError{Op: op, Param: "sigma", Value: sigma, Reason: "must be a positive number"}
+	}
+	return nil
+}
+
+// ValidateJPEGQuality checks a JPEG quality: 1 to 100 inclusive.
+func ValidateJPEGQuality(quality int) error {
+	return validateRange("encode", "JPEG quality", quality, 1, 100)
+}
+
+func validateRange(op, param string, value, lo, hi int) error {
+	if value < lo || value > hi {
+		return &ValidationError{Op: op, Param: param, Value: value, Reason: fmt.Sprintf("must be between %d and %d", lo, hi)}
+	}
+	return nil
+}
+
+// anchorNames are the names accepted by ParseAnchor, with the canonical
+// name of each anchor first
+var anchorNames = []struct {
+	anchor Anchor
+	names  []string
+}{
+	{Center, []string{"center", "centre"}},
+	{TopLeft, []string{"top-left", "topleft"}},
+	{Top, []string{"top"}},
+	{TopRight, []string{"top-right", "topright"}},
+	{Left, []string{"left"}},
+	{Right, []string{"right"}},
+	{BottomLeft, []string{"bottom-left", "bottomleft"}},
+	{Bottom, []string{"bottom"}},
+	{BottomRight, []string{"bottom-right", "bottomright"}},
+	{Smart, []string{"smart"}},
+}
+
+// ParseAnchor returns the anchor named name, case-insensitively: center,
+// top-left, top, top-right, left, right, bottom-left, bottom, bottom-right
+// or smart (the hyphen is optional). Unknown names give a
+// *ValidationError.
+func ParseAnchor(name string) (Anchor, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	var valid []string
+	for _, a := range anchorNames {
+		for _, n := range a.names {
+			if n == key {
+				return a.anchor, nil
+			}
+		}
+		valid = append(valid, a.names[0])
+	}
+	return Center, &ValidationError{Op: "anchor", Param: "name", Value: fmt.Sprintf("%q", name),
+		Reason: "must be one of " + strings.Join(valid, ", ")}
+}
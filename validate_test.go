@@ -0,0 +1,128 @@
+package imgx
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidateResize(t *testing.T) {
+	testCases := []struct {
+		width, height int
+		wantParam     string
+	}{
+		{800, 600, ""},
+		{800, 0, ""},
+		{0, 600, ""},
+		{-1, 600, "width"},
+		{800, -5, "height"},
+		{0, 0, "size"},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateResize(tc.width, tc.height)
+		if tc.wantParam == "" {
+			if err != nil {
+				t.Errorf("ValidateResize(%d, %d) = %v, want nil", tc.width, tc.height, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Param != tc.wantParam || verr.Op != "resize" {
+			t.Errorf("ValidateResize(%d, %d) = %v, want a resize %s error", tc.width, tc.height, err, tc.wantParam)
+		}
+	}
+}
+
+func TestValidateSize(t *testing.T) {
+	if err := ValidateSize("fill", 100, 100); err != nil {
+		t.Errorf("ValidateSize(100, 100) = %v, want nil", err)
+	}
+	for _, dims := range [][2]int{{0, 100}, {100, 0}, {-3, 100}} {
+		err := ValidateSize("fill", dims[0], dims[1])
+		if !errors.Is(err, ErrInvalidParameter) || !strings.Contains(err.Error(), "fill") {
+			t.Errorf("ValidateSize(%d, %d) = %v, want a fill validation error", dims[0], dims[1], err)
+		}
+	}
+}
+
+func TestValidateSigma(t *testing.T) {
+	if err := ValidateSigma("blur", 2.5); err != nil {
+		t.Errorf("ValidateSigma(2.5) = %v, want nil", err)
+	}
+	for _, sigma := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if err := ValidateSigma("blur", sigma); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("ValidateSigma(%v) = %v, want ErrInvalidParameter", sigma, err)
+		}
+	}
+}
+
+func TestValidateJPEGQuality(t *testing.T) {
+	for _, q := range []int{1, 50, 100} {
+		if err := ValidateJPEGQuality(q); err != nil {
+			t.Errorf("ValidateJPEGQuality(%d) = %v, want nil", q, err)
+		}
+	}
+	for _, q := range []int{0, -10, 101} {
+		if err := ValidateJPEGQuality(q); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("ValidateJPEGQuality(%d) = %v, want ErrInvalidParameter", q, err)
+		}
+	}
+}
+
+func TestParseAnchor(t *testing.T) {
+	testCases := map[string]Anchor{
+		"center":       Center,
+		"Centre":       Center,
+		"top-left":     TopLeft,
+		"topleft":      TopLeft,
+		"BOTTOM-RIGHT": BottomRight,
+		" bottom ":     Bottom,
+		"smart":        Smart,
+	}
+	for name, want := range testCases {
+		got, err := ParseAnchor(name)
+		if err != nil || got != want {
+			t.Errorf("ParseAnchor(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	_, err := ParseAnchor("middle")
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ParseAnchor(middle) = %v, want a *ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "top-left") {
+		t.Errorf("error %q does not list the valid anchors", err)
+	}
+}
+
+func FuzzParseAnchor(f *testing.F) {
+	for _, a := range anchorNames {
+		for _, name := range a.names {
+			f.Add(name)
+		}
+	}
+	f.Add("Top-Left ")
+	f.Add("middle")
+
+	f.Fuzz(func(t *testing.T, name string) {
+		anchor, err := ParseAnchor(name)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("ParseAnchor(%q) error = %v, want ErrInvalidParameter", name, err)
+			}
+			return
+		}
+		for _, a := range anchorNames {
+			if a.anchor == anchor {
+				if again, err := ParseAnchor(a.names[0]); err != nil || again != anchor {
+					t.Errorf("ParseAnchor(%q) = %v, but its name %q parses as %v", name, anchor, a.names[0], again)
+				}
+				return
+			}
+		}
+		t.Errorf("ParseAnchor(%q) = %v, not a named anchor", name, anchor)
+	})
+}
@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
 )
 
-// WatermarkOptions contains options for adding a text watermark to an image.
+// WatermarkOptions contains options for adding a text or image watermark to an image.
 type WatermarkOptions struct {
 	// Text is the watermark text to be rendered on the image.
 	Text string
@@ -20,7 +19,7 @@ type WatermarkOptions struct {
 	// Default is BottomRight.
 	Position Anchor
 
-	// Opacity controls the transparency of the watermark text (0.0 to 1.0).
+	// Opacity controls the transparency of the watermark (0.0 to 1.0).
 	// 0.0 is fully transparent, 1.0 is fully opaque.
 	// Default is 0.5 (50% opacity).
 	Opacity float64
@@ -36,9 +35,22 @@ type WatermarkOptions struct {
 	// Padding is the number of pixels to offset from the edge based on Position.
 	// Default is 10 pixels.
 	Padding int
+
+	// Image is a logo or other image drawn instead of Text. Its per-pixel
+	// alpha is kept, so antialiased edges and soft shadows blend in.
+	Image image.Image
+
+	// Blend is the blend mode of the watermark (see BlendMode).
+	// Default is BlendNormal.
+	Blend BlendMode
+
+	// GammaSpace composites in sRGB instead of linear light (see
+	// CompositeOptions).
+	GammaSpace bool
 }
 
-// Watermark adds a text watermark to an image and returns the result.
+// Watermark adds a text or image watermark to an image and returns the
+// result. The watermark is composited in linear light (see Composite).
 //
 // Example:
 //
@@ -54,7 +66,7 @@ func Watermark(img image.Image, opts WatermarkOptions) *image.NRGBA {
 	dst := Clone(img)
 
 	// Set defaults
-	if opts.Text == "" {
+	if opts.Text == "" && opts.Image == nil {
 		return dst // Nothing to watermark
 	}
 
@@ -78,36 +90,47 @@ func Watermark(img image.Image, opts WatermarkOptions) *image.NRGBA {
 		opts.Padding = 10
 	}
 
+	// Render the text, or take the image, with alpha
+	var mark *image.NRGBA
+	if opts.Image != nil {
+		mark = Clone(opts.Image)
+	} else {
+		mark = renderWatermarkText(opts.Text, opts.Font, opts.TextColor)
+	}
+
+	// Calculate position based on anchor
+	pos := calculateWatermarkPosition(dst.Bounds(), mark.Bounds().Dx(), mark.Bounds().Dy(), opts.Position, opts.Padding)
+
+	// Apply opacity to the watermark
+	if opts.Opacity < 1.0 {
+		applyOpacity(mark, opts.Opacity)
+	}
+
+	// Composite the watermark onto the destination image
+	return Composite(dst, mark, pos, 1, CompositeOptions{Blend: opts.Blend, GammaSpace: opts.GammaSpace})
+}
+
+// renderWatermarkText draws text in textColor on a transparent image of
+// its size
+func renderWatermarkText(text string, face font.Face, textColor color.Color) *image.NRGBA {
 	// Measure the text dimensions
-	textBounds, textAdvance := measureText(opts.Text, opts.Font)
+	textBounds, textAdvance := measureText(text, face)
 	textWidth := textAdvance.Ceil()
 	textHeight := textBounds.Max.Y.Ceil() - textBounds.Min.Y.Ceil()
 
-	// Calculate position based on anchor
-	bounds := dst.Bounds()
-	pos := calculateWatermarkPosition(bounds, textWidth, textHeight, opts.Position, opts.Padding)
-
 	// Create a temporary image for the text with alpha
 	textImg := image.NewNRGBA(image.Rect(0, 0, textWidth, textHeight))
 
 	// Draw text on the temporary image
 	drawer := &font.Drawer{
 		Dst:  textImg,
-		Src:  image.NewUniform(opts.TextColor),
-		Face: opts.Font,
-		Dot:  fixed.Point26_6{X: 0, Y: opts.Font.Metrics().Ascent},
-	}
-	drawer.DrawString(opts.Text)
-
-	// Apply opacity to the text image
-	if opts.Opacity < 1.0 {
-		applyOpacity(textImg, opts.Opacity)
+		Src:  image.NewUniform(textColor),
+		Face: face,
+		Dot:  fixed.Point26_6{X: 0, Y: face.Metrics().Ascent},
 	}
+	drawer.DrawString(text)
 
-	// Overlay the text onto the destination image
-	draw.Draw(dst, image.Rect(pos.X, pos.Y, pos.X+textWidth, pos.Y+textHeight), textImg, image.Point{}, draw.Over)
-
-	return dst
+	return textImg
 }
 
 // measureText measures the dimensions of the given text using the specified font.
@@ -223,6 +246,13 @@ func (img *Image) Watermark(opts WatermarkOptions) *Image {
 	newData := Watermark(img.data, opts)
 	newMeta := img.metadata.Clone()
 	params := fmt.Sprintf("text=%q, position=%s, opacity=%.2f", opts.Text, formatAnchorName(opts.Position), opts.Opacity)
+	if opts.Image != nil {
+		params = fmt.Sprintf("image=%dx%d, position=%s, opacity=%.2f",
+			opts.Image.Bounds().Dx(), opts.Image.Bounds().Dy(), formatAnchorName(opts.Position), opts.Opacity)
+	}
+	if opts.Blend != BlendNormal {
+		params += fmt.Sprintf(", blend=%s", opts.Blend)
+	}
 	newMeta.AddOperation("watermark", params)
-	return &Image{data: newData, metadata: newMeta}
+	return newImage(newData, newMeta)
 }
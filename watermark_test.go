@@ -364,6 +364,36 @@ func BenchmarkWatermarkSmall(b *testing.B) {
 	}
 }
 
+func TestWatermarkImage(t *testing.T) {
+	// A white logo with a half transparent black shadow
+	logo := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		logo.SetNRGBA(x, 0, color.NRGBA{255, 255, 255, 255})
+		logo.SetNRGBA(x, 1, color.NRGBA{0, 0, 0, 128})
+	}
+	for _, bg := range []uint8{20, 235} {
+		src := New(20, 20, color.NRGBA{bg, bg, bg, 255})
+		got := Watermark(src, WatermarkOptions{Image: logo, Opacity: 1, Position: TopLeft, Padding: 2})
+		if c := got.NRGBAAt(2, 2); c.R != 255 {
+			t.Errorf("logo on %d = %v, want white", bg, c)
+		}
+		// The shadow halves the linear light of the background
+		want := linearToSRGB(srgbToLinear[bg] / 2)
+		if c := got.NRGBAAt(2, 3); c.R < want-1 || c.R > want+1 {
+			t.Errorf("shadow on %d = %v, want R=%d", bg, c, want)
+		}
+		if c := got.NRGBAAt(2, 4); c.R != bg {
+			t.Errorf("pixel below the logo on %d = %v, want unchanged", bg, c)
+		}
+	}
+
+	img := NewImage(20, 20, color.Black).Watermark(WatermarkOptions{Image: logo, Opacity: 0.5, Blend: BlendScreen})
+	ops := img.GetMetadata().Operations
+	if len(ops) != 1 || ops[0].Parameters != "image=4x2, position=Center, opacity=0.50, blend=screen" {
+		t.Errorf("operations = %+v", ops)
+	}
+}
+
 // Helper method for Anchor.String() used in tests
 func (a Anchor) String() string {
 	switch a {